@@ -0,0 +1,289 @@
+// admin is a command-line tool for managing the users, access tokens and
+// repository grants that the MCP Memory Server checks when access control
+// is enabled, and for rotating the encryption-at-rest passphrase.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/security"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: admin [-file path] <command> [flags]
+
+Commands:
+  user-create   -username name -email address   Create a user
+  users                                          List users and their grants
+  token-issue   -user name [-ttl 2160h]          Issue a token (printed once)
+  tokens        [-user name]                     List tokens
+  token-revoke  -token id-or-token               Revoke a token
+  grant         -user name -repo repo -level l   Grant read, write or admin on a repository ("*" for all)
+  revoke-grant  -user name -repo repo            Remove a repository grant
+  encryption-rotate [-keyring path]              Re-wrap the keyring with a new passphrase read
+                                                 from stdin and switch to a new data key
+
+The file defaults to MCP_MEMORY_ACCESS_CONTROL_FILE. The server picks up
+changes without a restart.
+
+encryption-rotate reads the current passphrase from
+MCP_MEMORY_ENCRYPTION_PASSPHRASE and must run while the server is stopped.
+Update the variable afterwards; stored memories are re-encrypted with the new
+data key when the server next starts.
+`
+
+func main() {
+	// Pick up MCP_MEMORY_ACCESS_CONTROL_FILE from .env like the server does
+	_ = godotenv.Load()
+
+	defaultFile := config.DefaultConfig().Security.AccessControlFile
+	if path := os.Getenv("MCP_MEMORY_ACCESS_CONTROL_FILE"); path != "" {
+		defaultFile = path
+	}
+
+	file := flag.String("file", defaultFile, "Access control file")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Key rotation works on the keyring, not the access control file
+	if flag.Arg(0) == "encryption-rotate" {
+		if err := rotateEncryption(flag.Args()[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	acm, err := security.OpenAccessControlManager(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := run(acm, flag.Arg(0), flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(acm *security.AccessControlManager, command string, args []string) error {
+	switch command {
+	case "user-create":
+		return createUser(acm, args)
+	case "users":
+		return listUsers(acm)
+	case "token-issue":
+		return issueToken(acm, args)
+	case "tokens":
+		return listTokens(acm, args)
+	case "token-revoke":
+		return revokeToken(acm, args)
+	case "grant":
+		return grant(acm, args)
+	case "revoke-grant":
+		return revokeGrant(acm, args)
+	case "help":
+		fmt.Print(usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q, run 'admin help'", command)
+	}
+}
+
+func createUser(acm *security.AccessControlManager, args []string) error {
+	fs := flag.NewFlagSet("user-create", flag.ExitOnError)
+	username := fs.String("username", "", "Username")
+	email := fs.String("email", "", "Email address")
+	_ = fs.Parse(args)
+
+	if _, exists := acm.FindUser(*username); exists {
+		return fmt.Errorf("user %s already exists", *username)
+	}
+
+	user, err := acm.CreateUser(*username, *email)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created user %s (%s)\n", user.Username, user.ID)
+	return nil
+}
+
+func listUsers(acm *security.AccessControlManager) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tUSERNAME\tEMAIL\tACTIVE\tGRANTS")
+	for _, user := range acm.ListUsers() {
+		grants, err := acm.ListGrants(user.ID)
+		if err != nil {
+			return err
+		}
+		described := make([]string, 0, len(grants))
+		for _, g := range grants {
+			described = append(described, fmt.Sprintf("%s=%s", g.Repository, g.Level))
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n", user.ID, user.Username, user.Email, user.IsActive, strings.Join(described, ","))
+	}
+	return w.Flush()
+}
+
+func issueToken(acm *security.AccessControlManager, args []string) error {
+	fs := flag.NewFlagSet("token-issue", flag.ExitOnError)
+	username := fs.String("user", "", "Username or user ID")
+	ttl := fs.Duration("ttl", security.DefaultTokenLifetime, "Token lifetime")
+	_ = fs.Parse(args)
+
+	user, err := findUser(acm, *username)
+	if err != nil {
+		return err
+	}
+
+	token, err := acm.GenerateToken(user.ID, nil, *ttl)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Token %s for %s, expires %s\n", token.ID, user.Username, token.ExpiresAt.Format(time.RFC3339))
+	fmt.Println("Store it now, it cannot be shown again:")
+	fmt.Println(token.Token)
+	return nil
+}
+
+func listTokens(acm *security.AccessControlManager, args []string) error {
+	fs := flag.NewFlagSet("tokens", flag.ExitOnError)
+	username := fs.String("user", "", "Username or user ID (default: all users)")
+	_ = fs.Parse(args)
+
+	userID := ""
+	if *username != "" {
+		user, err := findUser(acm, *username)
+		if err != nil {
+			return err
+		}
+		userID = user.ID
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tUSER\tCREATED\tEXPIRES")
+	for _, token := range acm.ListTokens(userID) {
+		owner := token.UserID
+		if user, ok := acm.FindUser(token.UserID); ok {
+			owner = user.Username
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", token.ID, owner, token.CreatedAt.Format(time.RFC3339), token.ExpiresAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+func revokeToken(acm *security.AccessControlManager, args []string) error {
+	fs := flag.NewFlagSet("token-revoke", flag.ExitOnError)
+	token := fs.String("token", "", "Token ID or token")
+	_ = fs.Parse(args)
+
+	if *token == "" {
+		return errors.New("-token is required")
+	}
+	if err := acm.RevokeToken(*token); err != nil {
+		return err
+	}
+	fmt.Println("Token revoked")
+	return nil
+}
+
+func grant(acm *security.AccessControlManager, args []string) error {
+	fs := flag.NewFlagSet("grant", flag.ExitOnError)
+	username := fs.String("user", "", "Username or user ID")
+	repository := fs.String("repo", "", "Repository, e.g. github.com/acme/api, or * for all")
+	level := fs.String("level", string(security.AccessLevelRead), "Access level: read, write or admin")
+	_ = fs.Parse(args)
+
+	user, err := findUser(acm, *username)
+	if err != nil {
+		return err
+	}
+	if err := acm.GrantRepositoryAccessByName(*repository, user.ID, security.AccessLevel(*level)); err != nil {
+		return err
+	}
+	fmt.Printf("Granted %s on %s to %s\n", *level, *repository, user.Username)
+	return nil
+}
+
+func revokeGrant(acm *security.AccessControlManager, args []string) error {
+	fs := flag.NewFlagSet("revoke-grant", flag.ExitOnError)
+	username := fs.String("user", "", "Username or user ID")
+	repository := fs.String("repo", "", "Repository, or * for the global grant")
+	_ = fs.Parse(args)
+
+	user, err := findUser(acm, *username)
+	if err != nil {
+		return err
+	}
+	if err := acm.RevokeRepositoryAccess(*repository, user.ID); err != nil {
+		return err
+	}
+	fmt.Printf("Revoked %s access for %s\n", *repository, user.Username)
+	return nil
+}
+
+func rotateEncryption(args []string) error {
+	defaultKeyring := config.DefaultConfig().Security.EncryptionKeyringFile
+	if path := os.Getenv("MCP_MEMORY_ENCRYPTION_KEYRING_FILE"); path != "" {
+		defaultKeyring = path
+	}
+
+	fs := flag.NewFlagSet("encryption-rotate", flag.ExitOnError)
+	keyring := fs.String("keyring", defaultKeyring, "Encryption keyring file")
+	_ = fs.Parse(args)
+
+	passphrase := os.Getenv("MCP_MEMORY_ENCRYPTION_PASSPHRASE")
+	if passphrase == "" {
+		return errors.New("MCP_MEMORY_ENCRYPTION_PASSPHRASE must hold the current passphrase")
+	}
+	if _, err := os.Stat(*keyring); err != nil {
+		return fmt.Errorf("keyring %s not found: %w", *keyring, err)
+	}
+
+	fmt.Fprint(os.Stderr, "New passphrase: ")
+	newPassphrase, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read new passphrase: %w", err)
+	}
+	newPassphrase = strings.TrimRight(newPassphrase, "\r\n")
+	if newPassphrase == "" {
+		return errors.New("new passphrase cannot be empty")
+	}
+
+	em, err := security.OpenEncryptionManager(passphrase, *keyring)
+	if err != nil {
+		return err
+	}
+	if err := em.RotateKey(newPassphrase); err != nil {
+		return err
+	}
+	fmt.Printf("Keyring re-wrapped, active data key is now %s\n", em.ActiveKeyID())
+	fmt.Println("Set MCP_MEMORY_ENCRYPTION_PASSPHRASE to the new passphrase before starting the server.")
+	return nil
+}
+
+func findUser(acm *security.AccessControlManager, idOrUsername string) (*security.User, error) {
+	if idOrUsername == "" {
+		return nil, errors.New("-user is required")
+	}
+	user, ok := acm.FindUser(idOrUsername)
+	if !ok {
+		return nil, fmt.Errorf("user %s not found", idOrUsername)
+	}
+	return user, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"lerian-mcp-memory/internal/security"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// tokenQueryParam carries the token for clients that cannot set headers,
+// such as browser EventSource and WebSocket
+const tokenQueryParam = "access_token"
+
+// requireToken authenticates requests with a bearer token before passing them
+// on. The caller is attached to the request context so tool calls can be
+// checked against its repository grants. A nil manager disables the check.
+func requireToken(acm *security.AccessControlManager, next http.HandlerFunc) http.HandlerFunc {
+	if acm == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// CORS preflight requests never carry credentials
+		if r.Method == methodOptions {
+			next(w, r)
+			return
+		}
+
+		tokenString := bearerToken(r)
+		if tokenString == "" {
+			writeUnauthorized(w, "missing bearer token")
+			return
+		}
+
+		token, err := acm.ValidateToken(tokenString)
+		if err != nil {
+			log.Printf("Rejected request to %s from %s: %v", r.URL.Path, r.RemoteAddr, err)
+			writeUnauthorized(w, err.Error())
+			return
+		}
+
+		ctx := security.WithPrincipal(r.Context(), security.Principal{UserID: token.UserID, TokenID: token.ID})
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// bearerToken returns the token from the Authorization header, falling back
+// to the access_token query parameter
+func bearerToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		if found && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	return r.URL.Query().Get(tokenQueryParam)
+}
+
+func writeUnauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", `Bearer realm="lerian-mcp-memory"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": "unauthorized", "message": message})
+}
+
+// canSubscribe reports whether the caller may receive updates for the given
+// repositories. Subscribing to every repository needs a global read grant.
+func canSubscribe(ctx context.Context, acm *security.AccessControlManager, repositories []string) bool {
+	principal, ok := security.PrincipalFromContext(ctx)
+	if acm == nil || !ok {
+		return true
+	}
+
+	if len(repositories) == 0 {
+		repositories = []string{security.GlobalResource}
+	}
+	for _, repository := range repositories {
+		allowed, err := acm.CheckRepositoryAccess(ctx, principal.UserID, repository, security.AccessLevelRead)
+		if err != nil || !allowed {
+			return false
+		}
+	}
+	return true
+}
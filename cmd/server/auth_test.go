@@ -0,0 +1,66 @@
+package main
+
+import (
+	"lerian-mcp-memory/internal/security"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireToken(t *testing.T) {
+	acm := security.NewAccessControlManager()
+	user, err := acm.CreateUser("alice", "alice@example.com")
+	require.NoError(t, err)
+	token, err := acm.GenerateToken(user.ID, nil, time.Hour)
+	require.NoError(t, err)
+
+	var seen security.Principal
+	handler := requireToken(acm, func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = security.PrincipalFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		header string
+		status int
+	}{
+		{name: "missing token", method: http.MethodPost, target: "/mcp", status: http.StatusUnauthorized},
+		{name: "invalid token", method: http.MethodPost, target: "/mcp", header: "Bearer nope", status: http.StatusUnauthorized},
+		{name: "wrong scheme", method: http.MethodPost, target: "/mcp", header: "Basic " + token.Token, status: http.StatusUnauthorized},
+		{name: "preflight", method: methodOptions, target: "/mcp", status: http.StatusOK},
+		{name: "bearer header", method: http.MethodPost, target: "/mcp", header: "Bearer " + token.Token, status: http.StatusOK},
+		{name: "query parameter", method: http.MethodGet, target: "/sse?access_token=" + token.Token, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, http.NoBody)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+
+	assert.Equal(t, user.ID, seen.UserID)
+	assert.Equal(t, token.ID, seen.TokenID)
+}
+
+func TestRequireTokenDisabled(t *testing.T) {
+	handler := requireToken(nil, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/mcp", http.NoBody))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"lerian-mcp-memory/internal/config"
+	"os"
+)
+
+const commandUsage = `Usage: lerian-mcp-memory-server [-config file] [-mode stdio|http] [-addr :9080]
+       lerian-mcp-memory-server [-config file] config print
+
+Commands:
+  config print   Print the resolved configuration (defaults, then the config
+                 file, then environment variables) with secrets masked
+`
+
+// runCommand runs a subcommand and returns the exit code
+func runCommand(args []string, configFile string) int {
+	if len(args) < 2 || args[0] != "config" || args[1] != "print" {
+		fmt.Fprint(os.Stderr, commandUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	fs.StringVar(&configFile, "config", configFile, "YAML config file")
+	if err := fs.Parse(args[2:]); err != nil {
+		return 2
+	}
+
+	if err := printConfig(configFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printConfig writes the resolved configuration to stdout. An invalid
+// configuration is still printed, so the offending value can be found.
+func printConfig(configFile string) error {
+	cfg, err := config.ResolveConfig(configFile)
+	if err != nil {
+		return err
+	}
+
+	if configFile == "" {
+		configFile = config.ConfigFilePath()
+	}
+	if configFile == "" {
+		fmt.Println("# config file: none")
+	} else {
+		fmt.Printf("# config file: %s\n", configFile)
+	}
+	if err := cfg.Masked().WriteYAML(os.Stdout); err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	return nil
+}
@@ -7,19 +7,25 @@ import (
 	"encoding/json"
 	"errors"
 	"flag"
-	"fmt"
 	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/deployment"
 	"lerian-mcp-memory/internal/mcp"
+	"lerian-mcp-memory/internal/metrics"
+	"lerian-mcp-memory/internal/security"
+	"lerian-mcp-memory/internal/sse"
+	"lerian-mcp-memory/internal/tracing"
 	mcpwebsocket "lerian-mcp-memory/internal/websocket"
 	"log"
+	"net"
 	"net/http"
+	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/fredcamaral/gomcp-sdk/protocol"
-	"github.com/fredcamaral/gomcp-sdk/server"
 	"github.com/fredcamaral/gomcp-sdk/transport"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
@@ -37,17 +43,28 @@ const (
 func main() {
 	// Parse command line flags
 	var (
-		mode = flag.String("mode", "stdio", "Server mode: stdio or http")
-		addr = flag.String("addr", ":9080", "HTTP server address (when mode=http)")
+		mode       = flag.String("mode", "stdio", "Server mode: stdio or http")
+		addr       = flag.String("addr", ":9080", "HTTP server address (when mode=http)")
+		configFile = flag.String("config", "", "YAML config file (default: MCP_MEMORY_CONFIG_FILE or CONFIG_PATH)")
 	)
 	flag.Parse()
 
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args(), *configFile))
+	}
+
 	// Load configuration
-	cfg, err := config.LoadConfig()
+	cfg, err := config.LoadConfigFile(*configFile)
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Export traces before anything creates spans
+	shutdownTracing, err := tracing.Setup(context.Background(), &cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+
 	// Create memory server
 	memoryServer, err := mcp.NewMemoryServer(cfg)
 	if err != nil {
@@ -60,22 +77,37 @@ func main() {
 		log.Fatalf("Failed to start memory server: %v", err)
 	}
 
-	// Get the underlying MCP server
-	mcpServer := memoryServer.GetMCPServer()
-
 	// Set up graceful shutdown
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	// Apply config changes on SIGHUP or when the config file is saved; the
+	// transports and their sessions keep running
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go config.NewWatcher(*configFile, func(cfg *config.Config) {
+		memoryServer.ApplyConfig(cfg)
+	}).Run(ctx, reload)
+
+	// Keep health results fresh in the background so probes read the cache
+	health := memoryServer.GetContainer().NewHealthManager(os.Getenv("SERVICE_VERSION"))
+	healthInterval := memoryServer.GetContainer().HealthCheckInterval()
+	health.SetMaxAge(2 * healthInterval)
+	go health.StartPeriodicChecks(ctx, healthInterval)
+
+	// A dedicated metrics port serves scrapers and probes in every mode
+	if cfg.Metrics.Enabled && cfg.Metrics.Port > 0 {
+		go startMetricsServer(ctx, &cfg.Metrics, cfg.Server.Host, health)
+	}
+
 	switch *mode {
 	case "stdio":
 		log.Printf("🚀 Starting MCP Memory Server in stdio mode")
 		// Set up stdio transport for MCP protocol
 		stdioTransport := transport.NewStdioTransport()
-		mcpServer.SetTransport(stdioTransport)
 
 		// Start the MCP server
-		if err := mcpServer.Start(ctx); err != nil {
+		if err := stdioTransport.Start(ctx, memoryServer); err != nil {
 			if !errors.Is(err, context.Canceled) {
 				cancel()
 				log.Printf("MCP server failed: %v", err)
@@ -87,7 +119,7 @@ func main() {
 		log.Printf("🚀 Starting MCP Memory Server in HTTP mode on %s", *addr)
 		log.Printf("📡 Ready to receive requests from mcp-proxy.js")
 		// Set up HTTP server for MCP-over-HTTP
-		if err := startHTTPServer(ctx, mcpServer, *addr); err != nil {
+		if err := startHTTPServer(ctx, memoryServer, health, *addr); err != nil {
 			if !errors.Is(err, context.Canceled) {
 				cancel()
 				log.Printf("HTTP server failed: %v", err)
@@ -105,72 +137,61 @@ func main() {
 	if err := memoryServer.Close(); err != nil {
 		log.Printf("Error during shutdown: %v", err)
 	}
-}
 
-func startHTTPServer(ctx context.Context, mcpServer *server.Server, addr string) error {
-	// Initialize core components
-	wsHub, _, err := initializeServerComponents(ctx)
-	if err != nil {
-		return err
+	// Flush spans still buffered for export
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("Error flushing traces: %v", err)
 	}
-
-	// Setup HTTP routes
-	mux := setupHTTPRoutes(ctx, mcpServer, wsHub)
-
-	// Create and start HTTP server
-	return startAndRunHTTPServer(ctx, mux, addr)
 }
 
-// initializeServerComponents initializes WebSocket hub and memory server
-func initializeServerComponents(ctx context.Context) (*mcpwebsocket.Hub, *mcp.MemoryServer, error) {
-	// Create WebSocket hub for real-time updates
+func startHTTPServer(ctx context.Context, memoryServer *mcp.MemoryServer, health *deployment.HealthManager, addr string) error {
+	acm := memoryServer.GetContainer().GetAccessControl()
+
+	// The server that handles requests broadcasts its memory changes
 	wsHub := mcpwebsocket.NewHub()
 	go wsHub.Run(ctx)
+	memoryServer.SetWebSocketHub(wsHub)
 
-	// Create memory server instance to access DI container
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load config for GraphQL: %w", err)
-	}
-
-	memoryServer, err := mcp.NewMemoryServer(cfg) //nolint:contextcheck // Constructor doesn't need context, Start() method does
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create memory server: %w", err)
-	}
+	// SSE sessions outlive single requests; idle ones are closed
+	sessions := sse.NewManager(sse.DefaultReplayBufferSize, sse.DefaultSessionIdleTimeout)
+	go sessions.Run(ctx)
 
-	// Initialize memory server
-	if err := memoryServer.Start(ctx); err != nil {
-		return nil, nil, fmt.Errorf("failed to start memory server: %w", err)
+	// Setup HTTP routes
+	mux := setupHTTPRoutes(ctx, memoryServer, wsHub, sessions, acm, health)
+	if metricsConfig := memoryServer.GetContainer().Config.Metrics; metricsConfig.Enabled && metricsConfig.Port == 0 {
+		setupMetricsHandler(mux, metricsConfig.Path)
 	}
 
-	// Set the WebSocket hub in the memory server for broadcasting
-	memoryServer.SetWebSocketHub(wsHub)
-
-	return wsHub, memoryServer, nil
+	// Create and start HTTP server
+	return startAndRunHTTPServer(ctx, mux, addr)
 }
 
-// setupHTTPRoutes configures all HTTP routes and handlers
-func setupHTTPRoutes(ctx context.Context, mcpServer *server.Server, wsHub *mcpwebsocket.Hub) *http.ServeMux {
+// setupHTTPRoutes configures all HTTP routes and handlers. When access
+// control is enabled, every transport requires a bearer token; the health
+// checks stay open.
+func setupHTTPRoutes(ctx context.Context, mcpServer transport.RequestHandler, wsHub *mcpwebsocket.Hub, sessions *sse.Manager, acm *security.AccessControlManager, health *deployment.HealthManager) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// Setup MCP endpoint
-	setupMCPHandler(mux, mcpServer)
+	setupMCPHandler(mux, mcpServer, acm)
 
 	// Setup SSE endpoint
-	setupSSEHandler(mux, mcpServer)
+	setupSSEHandler(mux, mcpServer, sessions, acm)
 
 	// Setup WebSocket endpoint
-	setupWebSocketHandler(mux, ctx, wsHub)
+	setupWebSocketHandler(mux, ctx, wsHub, acm)
 
-	// Setup health check endpoint
-	setupHealthHandler(mux)
+	// Setup health, readiness and liveness endpoints
+	setupHealthHandlers(mux, health)
 
 	return mux
 }
 
 // setupMCPHandler configures the MCP-over-HTTP endpoint
-func setupMCPHandler(mux *http.ServeMux, mcpServer *server.Server) {
-	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+func setupMCPHandler(mux *http.ServeMux, mcpServer transport.RequestHandler, acm *security.AccessControlManager) {
+	mux.HandleFunc("/mcp", requireToken(acm, func(w http.ResponseWriter, r *http.Request) {
 		// Set CORS headers with specific origin to allow credentials
 		origin := r.Header.Get("Origin")
 		if origin == "" {
@@ -178,7 +199,7 @@ func setupMCPHandler(mux *http.ServeMux, mcpServer *server.Server) {
 		}
 		w.Header().Set("Access-Control-Allow-Origin", origin)
 		w.Header().Set("Access-Control-Allow-Methods", "POST, "+methodOptions)
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-CSRF-Token")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-CSRF-Token, traceparent, tracestate")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 		w.Header().Set("Content-Type", "application/json")
 
@@ -199,120 +220,46 @@ func setupMCPHandler(mux *http.ServeMux, mcpServer *server.Server) {
 			return
 		}
 
-		// Process the request through MCP server
-		resp := mcpServer.HandleRequest(r.Context(), &req)
+		// Process the request through MCP server, continuing the caller's
+		// trace when it sends one
+		resp := mcpServer.HandleRequest(tracing.Extract(r.Context(), r.Header), &req)
 
 		// Send the response
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
 			log.Printf("Error encoding response: %v", err)
 		}
-	})
+	}))
 }
 
-// setupSSEHandler configures the Server-Sent Events endpoint
-func setupSSEHandler(mux *http.ServeMux, mcpServer *server.Server) {
-	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
-		// Handle CORS preflight
-		if r.Method == methodOptions {
-			origin := r.Header.Get("Origin")
-			if origin == "" {
-				origin = defaultLocalOrigin
-			}
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, "+methodOptions)
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Cache-Control, X-CSRF-Token")
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+// setupSSEHandler configures the Server-Sent Events endpoint. Responses and
+// server notifications are delivered on the session stream.
+func setupSSEHandler(mux *http.ServeMux, mcpServer transport.RequestHandler, sessions *sse.Manager, acm *security.AccessControlManager) {
+	streams := sse.NewHandler(sessions, mcpServer, "/sse")
 
-		// Handle POST requests for MCP JSON-RPC
-		if r.Method == "POST" {
-			handleSSEPost(w, r, mcpServer)
-			return
+	mux.HandleFunc("/sse", requireToken(acm, func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			origin = defaultLocalOrigin
 		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Set("Access-Control-Expose-Headers", sse.SessionHeader)
 
-		// Handle GET requests for SSE stream
-		if r.Method != "GET" {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		// Handle CORS preflight
+		if r.Method == methodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, "+methodOptions)
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Cache-Control, Authorization, X-CSRF-Token, Last-Event-ID, "+sse.SessionHeader)
+			w.WriteHeader(http.StatusOK)
 			return
 		}
 
-		handleSSEStream(w, r)
-	})
-}
-
-// handleSSEPost handles POST requests to the SSE endpoint
-func handleSSEPost(w http.ResponseWriter, r *http.Request, mcpServer *server.Server) {
-	origin := r.Header.Get("Origin")
-	if origin == "" {
-		origin = defaultLocalOrigin
-	}
-	w.Header().Set("Access-Control-Allow-Origin", origin)
-	w.Header().Set("Access-Control-Allow-Credentials", "true")
-	w.Header().Set("Content-Type", "application/json")
-
-	// Parse JSON-RPC request
-	var req protocol.JSONRPCRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON-RPC request", http.StatusBadRequest)
-		return
-	}
-
-	// Process MCP request
-	resp := mcpServer.HandleRequest(r.Context(), &req)
-
-	// Send JSON-RPC response
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Printf("Error encoding SSE response: %v", err)
-	}
-}
-
-// handleSSEStream handles GET requests for SSE streaming
-func handleSSEStream(w http.ResponseWriter, r *http.Request) {
-	// Set SSE headers
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	origin := r.Header.Get("Origin")
-	if origin == "" {
-		origin = defaultLocalOrigin
-	}
-	w.Header().Set("Access-Control-Allow-Origin", origin)
-	w.Header().Set("Access-Control-Allow-Headers", "Cache-Control, X-CSRF-Token")
-	w.Header().Set("Access-Control-Allow-Credentials", "true")
-
-	// Keep connection alive
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
-		return
-	}
-
-	// Send initial connection message
-	_, _ = fmt.Fprintf(w, "data: {\"type\":\"connected\",\"server\":\"lerian-mcp-memory\",\"protocols\":[\"json-rpc\",\"sse\"]}\n\n")
-	flusher.Flush()
-
-	// Keep connection open and send periodic heartbeats
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			// Send heartbeat
-			_, _ = fmt.Fprintf(w, "data: {\"type\":\"heartbeat\",\"timestamp\":\"%s\"}\n\n", time.Now().UTC().Format(time.RFC3339))
-			flusher.Flush()
-		case <-r.Context().Done():
-			return
-		}
-	}
+		streams.ServeHTTP(w, r)
+	}))
 }
 
 // setupWebSocketHandler configures the WebSocket endpoint
-func setupWebSocketHandler(mux *http.ServeMux, ctx context.Context, wsHub *mcpwebsocket.Hub) {
+func setupWebSocketHandler(mux *http.ServeMux, ctx context.Context, wsHub *mcpwebsocket.Hub, acm *security.AccessControlManager) {
 	// WebSocket upgrader with specific origin check
 	var upgrader = websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool {
@@ -323,7 +270,7 @@ func setupWebSocketHandler(mux *http.ServeMux, ctx context.Context, wsHub *mcpwe
 	}
 
 	// WebSocket endpoint for real-time memory updates
-	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/ws", requireToken(acm, func(w http.ResponseWriter, r *http.Request) {
 		// Check if it's a WebSocket upgrade request
 		if !strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade") ||
 			strings.ToLower(r.Header.Get("Upgrade")) != "websocket" {
@@ -331,6 +278,13 @@ func setupWebSocketHandler(mux *http.ServeMux, ctx context.Context, wsHub *mcpwe
 			return
 		}
 
+		// Updates for a repository are only sent to callers who may read it
+		subscription := subscriptionFromQuery(r)
+		if !canSubscribe(r.Context(), acm, subscription.Repositories) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
 		// Upgrade the HTTP connection to WebSocket
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
@@ -338,13 +292,13 @@ func setupWebSocketHandler(mux *http.ServeMux, ctx context.Context, wsHub *mcpwe
 			return
 		}
 
-		// Get client preferences from query parameters
-		repository := r.URL.Query().Get("repository")
-		sessionID := r.URL.Query().Get("session_id")
-
-		// Create a new client
+		// Create a new client; later subscription changes are checked too
 		clientID := uuid.New().String()
-		client := mcpwebsocket.NewClient(clientID, conn, wsHub, repository, sessionID)
+		client := mcpwebsocket.NewClient(clientID, conn, wsHub, subscription)
+		principalCtx := context.WithoutCancel(r.Context())
+		client.SetAuthorizer(func(repositories []string) bool {
+			return canSubscribe(principalCtx, acm, repositories)
+		})
 
 		// Register client with hub
 		wsHub.RegisterClient(client)
@@ -354,20 +308,81 @@ func setupWebSocketHandler(mux *http.ServeMux, ctx context.Context, wsHub *mcpwe
 		go client.ReadPump(ctx)
 
 		log.Printf("WebSocket client %s connected from %s", clientID, r.RemoteAddr)
-	})
+	}))
+}
+
+// subscriptionFromQuery reads the initial WebSocket subscription. List
+// parameters may repeat or hold comma-separated values.
+func subscriptionFromQuery(r *http.Request) mcpwebsocket.Subscription {
+	query := r.URL.Query()
+	return mcpwebsocket.Subscription{
+		Repositories: queryList(query["repository"]),
+		SessionID:    query.Get("session_id"),
+		ChunkTypes:   queryList(query["chunk_types"]),
+		Tags:         queryList(query["tags"]),
+		EventTypes:   queryList(query["event_types"]),
+	}
+}
+
+func queryList(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
 }
 
-// setupHealthHandler configures the health check endpoint
-func setupHealthHandler(mux *http.ServeMux) {
+// setupHealthHandlers configures the health, readiness and liveness
+// endpoints. /health and /ready answer 503 when a critical dependency such
+// as the vector store is down; /live only fails if the process cannot
+// respond at all.
+func setupHealthHandlers(mux *http.ServeMux, health *deployment.HealthManager) {
+	healthHandler := health.HTTPHandler()
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
 		if origin != "" {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 		}
-		w.Header().Set("Content-Type", "application/json")
-		_, _ = fmt.Fprintf(w, `{"status": "healthy", "server": "lerian-mcp-memory", "mode": "development with hot-reload"}`)
+		healthHandler(w, r)
 	})
+	mux.HandleFunc("/ready", health.ReadinessHandler())
+	mux.HandleFunc("/live", health.LivenessHandler())
+}
+
+// setupMetricsHandler configures the Prometheus scrape endpoint. Like the
+// health check it needs no token.
+func setupMetricsHandler(mux *http.ServeMux, path string) {
+	mux.Handle(path, metrics.Default.Handler())
+}
+
+// startMetricsServer serves metrics and the health checks on their own port
+// until ctx is done
+func startMetricsServer(ctx context.Context, metricsConfig *config.MetricsConfig, host string, health *deployment.HealthManager) {
+	mux := http.NewServeMux()
+	setupMetricsHandler(mux, metricsConfig.Path)
+	setupHealthHandlers(mux, health)
+	metricsServer := &http.Server{
+		Addr:              net.JoinHostPort(host, strconv.Itoa(metricsConfig.Port)),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = metricsServer.Shutdown(shutdownCtx) //nolint:contextcheck // Fresh context needed for shutdown when parent is cancelled
+	}()
+
+	log.Printf("📈 Metrics: http://%s%s", metricsServer.Addr, metricsConfig.Path)
+	if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("Metrics server error: %v", err)
+	}
 }
 
 // startAndRunHTTPServer creates and runs the HTTP server
@@ -387,7 +402,7 @@ func startAndRunHTTPServer(ctx context.Context, mux *http.ServeMux, addr string)
 		log.Printf("🔗 MCP endpoint: http://localhost%s/mcp", addr)
 		log.Printf("📡 SSE endpoint: http://localhost%s/sse", addr)
 		log.Printf("🔌 WebSocket endpoint: ws://localhost%s/ws", addr)
-		log.Printf("💚 Health check: http://localhost%s/health", addr)
+		log.Printf("💚 Health check: http://localhost%s/health (readiness /ready, liveness /live)", addr)
 		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Printf("HTTP server error: %v", err)
 		}
@@ -49,6 +49,9 @@ const (
 	EventTypeExport EventType = "export"
 	// EventTypeImport represents data import operations
 	EventTypeImport EventType = "import"
+	// EventTypeRedaction represents secrets or personal data scrubbed from,
+	// or blocking, incoming content
+	EventTypeRedaction EventType = "redaction"
 	// EventTypeSystemStart represents system startup events
 	EventTypeSystemStart EventType = "system_start"
 	// EventTypeSystemShutdown represents system shutdown events
@@ -74,6 +77,7 @@ type Event struct {
 	Duration   time.Duration          `json:"duration,omitempty"`
 	IPAddress  string                 `json:"ip_address,omitempty"`
 	UserAgent  string                 `json:"user_agent,omitempty"`
+	TraceID    string                 `json:"trace_id,omitempty"`
 }
 
 // Logger handles persistent audit logging
@@ -90,6 +94,9 @@ type Logger struct {
 	eventCount map[EventType]int64
 	errorCount int64
 	lastFlush  time.Time
+
+	// writeErr is the error from the last flush, nil once a write succeeds
+	writeErr error
 }
 
 // NewLogger creates a new audit logger
@@ -148,6 +155,7 @@ func (al *Logger) LogEvent(ctx context.Context, eventType EventType, action, res
 		event.Repository = repo
 	}
 
+	event.TraceID = logging.GetTraceID(ctx)
 	al.addEvent(&event)
 }
 
@@ -172,6 +180,7 @@ func (al *Logger) LogError(ctx context.Context, eventType EventType, action, res
 		event.UserID = userID
 	}
 
+	event.TraceID = logging.GetTraceID(ctx)
 	al.addEvent(&event)
 	al.errorCount++
 }
@@ -201,6 +210,7 @@ func (al *Logger) LogEventWithDuration(ctx context.Context, eventType EventType,
 		event.Repository = repo
 	}
 
+	event.TraceID = logging.GetTraceID(ctx)
 	al.addEvent(&event)
 }
 
@@ -228,16 +238,20 @@ func (al *Logger) flush() {
 	if al.currentFile != nil {
 		if info, err := al.currentFile.Stat(); err == nil {
 			if info.Size() > al.maxFileSize {
-				_ = al.rotateFile()
+				if err := al.rotateFile(); err != nil {
+					logging.Error("Failed to rotate audit file", "error", err)
+				}
 			}
 		}
 	}
 
 	// Write events to file
+	al.writeErr = nil
 	encoder := json.NewEncoder(al.currentFile)
 	for i := range al.buffer {
 		if err := encoder.Encode(al.buffer[i]); err != nil {
 			logging.Error("Failed to write audit event", "error", err, "event_id", al.buffer[i].ID)
+			al.writeErr = err
 		}
 	}
 
@@ -322,6 +336,24 @@ func (al *Logger) cleanup() {
 	}
 }
 
+// HealthCheck reports whether audit events still reach disk: the last
+// flush must have succeeded and the current file must still exist
+func (al *Logger) HealthCheck(_ context.Context) error {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+
+	if al.writeErr != nil {
+		return fmt.Errorf("failed to write audit events: %w", al.writeErr)
+	}
+	if al.currentFile == nil {
+		return errors.New("audit file is not open")
+	}
+	if _, err := os.Stat(al.currentFile.Name()); err != nil {
+		return fmt.Errorf("audit file is unavailable: %w", err)
+	}
+	return nil
+}
+
 // GetStatistics returns audit statistics
 func (al *Logger) GetStatistics() map[string]interface{} {
 	al.mu.Lock()
@@ -444,6 +476,7 @@ func (al *Logger) Stop() {
 	// Close file
 	if al.currentFile != nil {
 		_ = al.currentFile.Close()
+		al.currentFile = nil
 	}
 }
 
@@ -226,6 +226,32 @@ func TestAuditLogger_FileRotation(t *testing.T) {
 	}
 }
 
+func TestAuditLogger_HealthCheck(t *testing.T) {
+	tempDir := t.TempDir()
+
+	logger, err := NewLogger(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create audit logger: %v", err)
+	}
+
+	if err := logger.HealthCheck(context.Background()); err != nil {
+		t.Errorf("Expected healthy audit logger, got: %v", err)
+	}
+
+	// Removing the audit directory under a running logger loses events
+	if err := os.RemoveAll(tempDir); err != nil {
+		t.Fatalf("Failed to remove audit directory: %v", err)
+	}
+	if err := logger.HealthCheck(context.Background()); err == nil {
+		t.Error("Expected health check to fail once the audit file is gone")
+	}
+
+	logger.Stop()
+	if err := logger.HealthCheck(context.Background()); err == nil {
+		t.Error("Expected health check to fail after stop")
+	}
+}
+
 func TestSearchCriteria_Matches(t *testing.T) {
 	event := Event{
 		ID:         "test-1",
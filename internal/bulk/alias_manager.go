@@ -2,6 +2,7 @@ package bulk
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"lerian-mcp-memory/internal/storage"
@@ -121,6 +122,7 @@ type AliasResult struct {
 // AliasManager handles memory aliases for flexible referencing
 type AliasManager struct {
 	storage    storage.VectorStore
+	exporter   *Exporter // shares its filter logic with filter targets
 	aliases    map[string]*Alias
 	aliasesMux sync.RWMutex
 	logger     *log.Logger
@@ -133,12 +135,42 @@ func NewAliasManager(vectorStore storage.VectorStore, logger *log.Logger) *Alias
 	}
 
 	return &AliasManager{
-		storage: vectorStore,
-		aliases: make(map[string]*Alias),
-		logger:  logger,
+		storage:  vectorStore,
+		exporter: NewExporter(vectorStore, logger),
+		aliases:  make(map[string]*Alias),
+		logger:   logger,
 	}
 }
 
+// LoadAliases loads persisted aliases into memory, replacing any with the
+// same ID. Call it once the storage backend is initialized.
+func (am *AliasManager) LoadAliases(ctx context.Context) (int, error) {
+	aliasStore, ok := storage.FindAliasStore(am.storage)
+	if !ok {
+		return 0, nil
+	}
+
+	records, err := aliasStore.ListAliases(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load aliases: %w", err)
+	}
+
+	am.aliasesMux.Lock()
+	defer am.aliasesMux.Unlock()
+
+	loaded := 0
+	for i := range records {
+		var alias Alias
+		if err := json.Unmarshal(records[i].Data, &alias); err != nil {
+			am.logger.Printf("Warning: skipping unreadable alias %s: %v", records[i].ID, err)
+			continue
+		}
+		am.aliases[alias.ID] = &alias
+		loaded++
+	}
+	return loaded, nil
+}
+
 // CreateAlias creates a new alias
 func (am *AliasManager) CreateAlias(ctx context.Context, alias *Alias) (*Alias, error) {
 	// Validate alias
@@ -166,7 +198,7 @@ func (am *AliasManager) CreateAlias(ctx context.Context, alias *Alias) (*Alias,
 	am.aliases[alias.ID] = alias
 	am.aliasesMux.Unlock()
 
-	// Persist to storage (would be implemented based on storage backend)
+	// Persist to storage
 	if err := am.persistAlias(ctx, alias); err != nil {
 		am.logger.Printf("Warning: failed to persist alias %s: %v", alias.ID, err)
 	}
@@ -262,6 +294,9 @@ func (am *AliasManager) ResolveAlias(ctx context.Context, aliasName string) (*Al
 
 	// Update access tracking
 	am.trackAccess(alias.ID)
+	if err := am.persistAlias(ctx, alias); err != nil {
+		am.logger.Printf("Warning: failed to persist access to alias %s: %v", alias.ID, err)
+	}
 
 	// Resolve based on target type
 	chunks, err := am.resolveTarget(ctx, alias.Target)
@@ -479,10 +514,25 @@ func (am *AliasManager) resolveQueryTarget(_ context.Context, query *QueryTarget
 	return []types.ConversationChunk{}, nil
 }
 
-func (am *AliasManager) resolveFilterTarget(_ context.Context, _ *FilterTarget) ([]types.ConversationChunk, error) {
-	// This would apply the filter criteria to find matching chunks
-	// For now, return empty - real implementation would query storage
-	return []types.ConversationChunk{}, nil
+func (am *AliasManager) resolveFilterTarget(ctx context.Context, filter *FilterTarget) ([]types.ConversationChunk, error) {
+	exportFilter := &ExportFilter{
+		Repository:    filter.Repository,
+		SessionIDs:    filter.SessionIDs,
+		ChunkTypes:    filter.ChunkTypes,
+		Tags:          filter.Tags,
+		Outcomes:      filter.Outcomes,
+		Difficulties:  filter.Difficulties,
+		ContentFilter: filter.ContentMatch,
+	}
+	if filter.DateRange != nil {
+		exportFilter.DateRange = &ExportDateRange{Start: filter.DateRange.Start, End: filter.DateRange.End}
+	}
+
+	chunks, err := am.exporter.queryChunks(ctx, exportFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query filter target: %w", err)
+	}
+	return chunks, nil
 }
 
 func (am *AliasManager) resolveCollectionTarget(ctx context.Context, collection *CollectionTarget) ([]types.ConversationChunk, error) {
@@ -568,15 +618,37 @@ func (am *AliasManager) generateResultMessage(alias *Alias, resultCount int) str
 		resultCount, alias.Name, alias.Type)
 }
 
-// Persistence methods (would be implemented based on storage backend)
+// Persistence methods. Aliases are kept in memory and written through to
+// the storage backend when it can persist them.
 
-func (am *AliasManager) persistAlias(_ context.Context, _ *Alias) error {
-	// This would persist the alias to storage
-	// Implementation depends on the storage backend
-	return nil
+func (am *AliasManager) persistAlias(ctx context.Context, alias *Alias) error {
+	aliasStore, ok := storage.FindAliasStore(am.storage)
+	if !ok {
+		return nil
+	}
+
+	// Marshal under the lock; access tracking mutates aliases in place
+	am.aliasesMux.RLock()
+	data, err := json.Marshal(alias)
+	record := &storage.AliasRecord{
+		ID:         alias.ID,
+		Name:       alias.Name,
+		Repository: alias.Metadata.Repository,
+		UpdatedAt:  alias.UpdatedAt,
+	}
+	am.aliasesMux.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal alias: %w", err)
+	}
+
+	record.Data = data
+	return aliasStore.StoreAlias(ctx, record)
 }
 
-func (am *AliasManager) removePersistedAlias(_ context.Context, _ string) error {
-	// This would remove the alias from persistent storage
-	return nil
+func (am *AliasManager) removePersistedAlias(ctx context.Context, aliasID string) error {
+	aliasStore, ok := storage.FindAliasStore(am.storage)
+	if !ok {
+		return nil
+	}
+	return aliasStore.DeleteAlias(ctx, aliasID)
 }
@@ -0,0 +1,92 @@
+package bulk
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"lerian-mcp-memory/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliasManagerPersistsAliases(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	logger := log.New(io.Discard, "", 0)
+
+	store := newReembedTestStore(t, dir)
+	manager := NewAliasManager(store, logger)
+
+	created, err := manager.CreateAlias(ctx, &Alias{
+		Name:   "@auth",
+		Type:   AliasTypeTag,
+		Target: AliasTarget{Type: TargetTypeChunks, ChunkIDs: []string{"chunk-1"}},
+	})
+	require.NoError(t, err)
+	doomed, err := manager.CreateAlias(ctx, &Alias{
+		ID:     "alias-doomed",
+		Name:   "@doomed",
+		Type:   AliasTypeTag,
+		Target: AliasTarget{Type: TargetTypeChunks, ChunkIDs: []string{"chunk-2"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, manager.DeleteAlias(ctx, doomed.ID))
+	require.NoError(t, store.Close())
+
+	// A new manager over the same directory sees the surviving alias
+	restarted := NewAliasManager(newReembedTestStore(t, dir), logger)
+	loaded, err := restarted.LoadAliases(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, loaded)
+
+	alias, err := restarted.GetAlias(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "@auth", alias.Name)
+	assert.Equal(t, []string{"chunk-1"}, alias.Target.ChunkIDs)
+
+	_, err = restarted.GetAlias(doomed.ID)
+	assert.Error(t, err)
+}
+
+func TestAliasManagerResolvesFilterTargets(t *testing.T) {
+	ctx := context.Background()
+	store := newReembedTestStore(t, t.TempDir())
+	seedReembedChunks(t, store, 3)
+
+	// Tag one problem chunk so the filter has something to single out
+	chunk, err := store.GetByID(ctx, "chunk-1")
+	require.NoError(t, err)
+	chunk.Type = types.ChunkTypeProblem
+	chunk.Metadata.Tags = []string{"auth"}
+	require.NoError(t, store.Update(ctx, chunk))
+
+	manager := NewAliasManager(store, log.New(io.Discard, "", 0))
+	repository := "github.com/acme/api"
+	since := time.Now().Add(-time.Hour)
+	_, err = manager.CreateAlias(ctx, &Alias{
+		Name: "@auth-problems",
+		Type: AliasTypeTag,
+		Target: AliasTarget{
+			Type: TargetTypeFilter,
+			Filter: &FilterTarget{
+				Repository: &repository,
+				ChunkTypes: []types.ChunkType{types.ChunkTypeProblem},
+				Tags:       []string{"auth"},
+				DateRange:  &DateRange{Start: &since},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	results, err := manager.ResolveAliasReference(ctx, "login failures in @auth-problems")
+	require.NoError(t, err)
+	require.Contains(t, results, "@auth-problems")
+	result := results["@auth-problems"]
+	require.Len(t, result.Chunks, 1)
+	assert.Equal(t, "chunk-1", result.Chunks[0].ID)
+	assert.Equal(t, 1, result.Alias.AccessCount)
+}
@@ -35,6 +35,14 @@ const (
 	FormatCSV ImportFormat = "csv"
 	// FormatArchive imports data from archive format
 	FormatArchive ImportFormat = "archive"
+	// FormatClaudeCode imports Claude Code session logs (JSON Lines)
+	FormatClaudeCode ImportFormat = "claude_code"
+	// FormatChatGPT imports a ChatGPT data export's conversations.json
+	FormatChatGPT ImportFormat = "chatgpt"
+	// FormatCursor imports Cursor and VS Code chat exports
+	FormatCursor ImportFormat = "cursor"
+	// FormatOpenAIChat imports OpenAI chat-completion request/response logs
+	FormatOpenAIChat ImportFormat = "openai_chat"
 	// FormatAuto automatically detects the format
 	FormatAuto ImportFormat = "auto" // Auto-detect format
 )
@@ -140,6 +148,8 @@ func (imp *Importer) Import(ctx context.Context, data string, options *ImportOpt
 		return imp.importCSV(ctx, data, options, result)
 	case FormatArchive:
 		return imp.importArchive(ctx, data, options, result)
+	case FormatClaudeCode, FormatChatGPT, FormatCursor, FormatOpenAIChat:
+		return imp.importTranscript(ctx, data, options, result)
 	case FormatAuto:
 		// Auto-detect format and retry
 		options.Format = imp.detectFormat(data)
@@ -153,6 +163,11 @@ func (imp *Importer) Import(ctx context.Context, data string, options *ImportOpt
 func (imp *Importer) detectFormat(data string) ImportFormat {
 	data = strings.TrimSpace(data)
 
+	// Check for assistant transcripts, which are JSON or JSON Lines too
+	if format, ok := detectTranscriptFormat(data); ok {
+		return format
+	}
+
 	// Check for JSON
 	if strings.HasPrefix(data, "{") || strings.HasPrefix(data, "[") {
 		return FormatJSON
@@ -781,7 +796,10 @@ func (imp *Importer) processArchiveFile(filename, content string, options *Impor
 	ext := strings.ToLower(filepath.Ext(filename))
 
 	switch ext {
-	case ".json":
+	case ".json", ".jsonl":
+		if format, ok := detectTranscriptFormat(content); ok {
+			return imp.parseTranscriptContent(content, format, options, filename)
+		}
 		return imp.parseJSONContent(content, options, filename)
 	case ".md", ".markdown":
 		return imp.parseMarkdownContent(content, options, filename)
@@ -5,6 +5,7 @@ package bulk
 import (
 	"context"
 	"errors"
+	"lerian-mcp-memory/internal/metrics"
 	"lerian-mcp-memory/internal/storage"
 	"lerian-mcp-memory/pkg/types"
 	"log"
@@ -94,6 +95,7 @@ type Progress struct {
 	EstimatedTime    time.Duration     `json:"estimated_time"`
 	Errors           []Error           `json:"errors,omitempty"`
 	ValidationErrors []ValidationError `json:"validation_errors,omitempty"`
+	Phase            string            `json:"phase,omitempty"` // Stage of multi-step jobs such as re-embedding
 }
 
 // Error represents an error that occurred during bulk processing
@@ -122,6 +124,7 @@ type Result struct {
 type Manager struct {
 	storage       storage.VectorStore
 	operations    map[string]*Request
+	tracked       map[string]func() *Progress
 	operationsMux sync.RWMutex
 	logger        *log.Logger
 }
@@ -135,6 +138,7 @@ func NewManager(vectorStore storage.VectorStore, logger *log.Logger) *Manager {
 	return &Manager{
 		storage:    vectorStore,
 		operations: make(map[string]*Request),
+		tracked:    make(map[string]func() *Progress),
 		logger:     logger,
 	}
 }
@@ -172,7 +176,9 @@ func (m *Manager) SubmitOperation(ctx context.Context, req *Request) (*Progress,
 
 	// Start processing asynchronously
 	go func() {
-		if err := m.processOperation(ctx, req.ID); err != nil {
+		err := m.processOperation(ctx, req.ID)
+		metrics.BulkJobs.Inc(string(req.Operation), metrics.Status(err))
+		if err != nil {
 			m.logger.Printf("Error processing bulk operation %s: %v", req.ID, err)
 		}
 	}()
@@ -180,9 +186,21 @@ func (m *Manager) SubmitOperation(ctx context.Context, req *Request) (*Progress,
 	return initialProgress, nil
 }
 
+// TrackOperation registers a job that runs outside the manager, such as a
+// re-embedding, so its progress is reported alongside bulk operations
+func (m *Manager) TrackOperation(operationID string, progress func() *Progress) {
+	m.operationsMux.Lock()
+	defer m.operationsMux.Unlock()
+	m.tracked[operationID] = progress
+}
+
 // GetProgress returns the current progress of an operation
 func (m *Manager) GetProgress(operationID string) (*Progress, error) {
 	m.operationsMux.RLock()
+	if progress, tracked := m.tracked[operationID]; tracked {
+		m.operationsMux.RUnlock()
+		return progress(), nil
+	}
 	op, exists := m.operations[operationID]
 	if !exists {
 		m.operationsMux.RUnlock()
@@ -216,7 +234,7 @@ func (m *Manager) ListOperations(status *OperationStatus, limit int) ([]*Progres
 	m.operationsMux.RLock()
 	defer m.operationsMux.RUnlock()
 
-	capacity := len(m.operations)
+	capacity := len(m.operations) + len(m.tracked)
 	if limit > 0 && limit < capacity {
 		capacity = limit
 	}
@@ -230,6 +248,19 @@ func (m *Manager) ListOperations(status *OperationStatus, limit int) ([]*Progres
 
 		results = append(results, progress)
 
+		if limit > 0 && len(results) >= limit {
+			return results, nil
+		}
+	}
+
+	for _, track := range m.tracked {
+		progress := track()
+		if status != nil && progress.Status != *status {
+			continue
+		}
+
+		results = append(results, progress)
+
 		if limit > 0 && len(results) >= limit {
 			break
 		}
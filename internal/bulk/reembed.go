@@ -0,0 +1,774 @@
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/embeddings"
+	"lerian-mcp-memory/internal/metrics"
+	"lerian-mcp-memory/internal/storage"
+	"lerian-mcp-memory/pkg/types"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ReembedPhase is the stage a re-embedding job has reached
+type ReembedPhase string
+
+const (
+	// ReembedPhasePending indicates the job has not started copying
+	ReembedPhasePending ReembedPhase = "pending"
+	// ReembedPhaseCopying indicates chunks are being re-embedded into the shadow collection
+	ReembedPhaseCopying ReembedPhase = "copying"
+	// ReembedPhaseCatchingUp indicates writes made during the copy are being applied
+	ReembedPhaseCatchingUp ReembedPhase = "catching_up"
+	// ReembedPhasePromoting indicates the shadow collection is being made active
+	ReembedPhasePromoting ReembedPhase = "promoting"
+	// ReembedPhaseCompleted indicates the shadow collection is active
+	ReembedPhaseCompleted ReembedPhase = "completed"
+	// ReembedPhaseFailed indicates the job stopped; it resumes from its cursor
+	ReembedPhaseFailed ReembedPhase = "failed"
+)
+
+const (
+	defaultReembedBatchSize = 64
+	maxReembedBatchSize     = 2048
+	maxReembedErrors        = 20
+	maxCatchUpPasses        = 3
+	reembedCharsPerToken    = 4
+	reembedStateExtension   = ".json"
+	reembedOperation        = "reembed" // operation label on /metrics
+)
+
+// reembedSuffixPattern matches the suffix added to shadow collection names,
+// so repeated migrations do not keep growing the name
+var reembedSuffixPattern = regexp.MustCompile(`_reembed_[0-9]+$`)
+
+// reembedPricePerMillionTokens lists list prices in USD for hosted models.
+// Self-hosted providers cost nothing per token.
+var reembedPricePerMillionTokens = map[string]float64{
+	"text-embedding-ada-002": 0.10,
+	"text-embedding-3-small": 0.02,
+	"text-embedding-3-large": 0.13,
+}
+
+// ReembedOptions describes a requested migration to another embedding model
+type ReembedOptions struct {
+	Target       config.EmbeddingConfig `json:"target"`
+	BatchSize    int                    `json:"batch_size"`
+	DropPrevious bool                   `json:"drop_previous"` // Delete the old collection after the swap
+}
+
+// ReembedJob is the persisted state of a re-embedding. The target's API key
+// is never written; it is taken from the running configuration on resume.
+type ReembedJob struct {
+	ID                 string                 `json:"id"`
+	Phase              ReembedPhase           `json:"phase"`
+	Target             config.EmbeddingConfig `json:"target"`
+	BatchSize          int                    `json:"batch_size"`
+	DropPrevious       bool                   `json:"drop_previous"`
+	SourceCollection   string                 `json:"source_collection"`
+	ShadowCollection   string                 `json:"shadow_collection"`
+	PreviousCollection string                 `json:"previous_collection,omitempty"`
+	Cursor             string                 `json:"cursor,omitempty"` // Last chunk ID copied, in ID order
+	TotalItems         int                    `json:"total_items"`
+	ProcessedItems     int                    `json:"processed_items"`
+	SuccessfulItems    int                    `json:"successful_items"`
+	FailedItems        int                    `json:"failed_items"`
+	CatchUpItems       int                    `json:"catch_up_items"`
+	Errors             []Error                `json:"errors,omitempty"`
+	LastError          string                 `json:"last_error,omitempty"`
+	CreatedAt          time.Time              `json:"created_at"`
+	StartedAt          time.Time              `json:"started_at"`
+	UpdatedAt          time.Time              `json:"updated_at"`
+	CompletedAt        *time.Time             `json:"completed_at,omitempty"`
+}
+
+// ReembedEstimate is the dry-run cost of a re-embedding
+type ReembedEstimate struct {
+	Provider              string  `json:"provider"`
+	Model                 string  `json:"model"`
+	CurrentDimension      int     `json:"current_dimension"`
+	TargetDimension       int     `json:"target_dimension"`
+	SourceCollection      string  `json:"source_collection"`
+	ShadowCollection      string  `json:"shadow_collection"`
+	Chunks                int     `json:"chunks"`
+	Characters            int64   `json:"characters"`
+	EstimatedTokens       int64   `json:"estimated_tokens"`
+	BatchSize             int     `json:"batch_size"`
+	Requests              int     `json:"requests"`
+	PricePerMillionTokens float64 `json:"price_per_million_tokens"`
+	PriceKnown            bool    `json:"price_known"`
+	EstimatedCostUSD      float64 `json:"estimated_cost_usd"`
+}
+
+// ReembedderConfig wires a Reembedder into the running server
+type ReembedderConfig struct {
+	// Store is the live store; its wrapper chain must contain a
+	// storage.ShadowCollectionStore
+	Store storage.VectorStore
+
+	// CurrentEmbedding is the service used for new chunks today
+	CurrentEmbedding embeddings.EmbeddingService
+
+	// StateDir holds one JSON file per job
+	StateDir string
+
+	// NewEmbeddingService builds the target provider
+	NewEmbeddingService func(target *config.EmbeddingConfig) (embeddings.EmbeddingService, error)
+
+	// OnPromoted switches the running server to the target provider once the
+	// shadow collection is active
+	OnPromoted func(target *config.EmbeddingConfig, service embeddings.EmbeddingService)
+
+	// EmbeddingText returns the text embedded for a chunk; defaults to its content
+	EmbeddingText func(chunk *types.ConversationChunk) string
+
+	// Manager reports job progress through get_bulk_progress when set
+	Manager *Manager
+
+	Logger *log.Logger
+}
+
+// Reembedder rebuilds the vector collection with a different embedding model.
+// Chunks are streamed in ID order into a shadow collection with the new
+// model's dimension, writes made in the meantime are caught up, and the
+// shadow collection is then promoted in a single swap. Job state is saved
+// after every batch so a crashed job resumes where it stopped.
+type Reembedder struct {
+	config ReembedderConfig
+	logger *log.Logger
+
+	mu   sync.Mutex
+	jobs map[string]*ReembedJob
+}
+
+// NewReembedder creates a re-embedding coordinator
+func NewReembedder(cfg ReembedderConfig) *Reembedder {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = log.New(log.Writer(), "[Reembedder] ", log.LstdFlags)
+	}
+	if cfg.EmbeddingText == nil {
+		cfg.EmbeddingText = func(chunk *types.ConversationChunk) string { return chunk.Content }
+	}
+
+	return &Reembedder{
+		config: cfg,
+		logger: logger,
+		jobs:   make(map[string]*ReembedJob),
+	}
+}
+
+// Estimate reports what a re-embedding would process and cost without
+// writing anything
+func (r *Reembedder) Estimate(ctx context.Context, opts *ReembedOptions) (*ReembedEstimate, error) {
+	swapper, err := r.shadowStore()
+	if err != nil {
+		return nil, err
+	}
+	service, err := r.config.NewEmbeddingService(&opts.Target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target embedding provider: %w", err)
+	}
+
+	chunks, err := r.config.Store.GetAllChunks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chunks: %w", err)
+	}
+
+	batchSize := normalizeReembedBatchSize(opts.BatchSize)
+	estimate := &ReembedEstimate{
+		Provider:         opts.Target.Provider,
+		Model:            service.GetModel(),
+		TargetDimension:  service.GetDimension(),
+		SourceCollection: swapper.ActiveCollection(),
+		ShadowCollection: shadowCollectionName(swapper.ActiveCollection(), time.Now()),
+		Chunks:           len(chunks),
+		BatchSize:        batchSize,
+		Requests:         (len(chunks) + batchSize - 1) / batchSize,
+	}
+	if r.config.CurrentEmbedding != nil {
+		estimate.CurrentDimension = r.config.CurrentEmbedding.GetDimension()
+	}
+
+	for i := range chunks {
+		estimate.Characters += int64(len(r.config.EmbeddingText(&chunks[i])))
+	}
+	estimate.EstimatedTokens = (estimate.Characters + reembedCharsPerToken - 1) / reembedCharsPerToken
+
+	switch opts.Target.Provider {
+	case config.EmbeddingProviderOllama, config.EmbeddingProviderHash:
+		estimate.PriceKnown = true
+	default:
+		estimate.PricePerMillionTokens, estimate.PriceKnown = reembedPricePerMillionTokens[service.GetModel()]
+	}
+	estimate.EstimatedCostUSD = float64(estimate.EstimatedTokens) / 1e6 * estimate.PricePerMillionTokens
+
+	return estimate, nil
+}
+
+// Start validates the target and begins a re-embedding in the background.
+// Only one job runs at a time.
+func (r *Reembedder) Start(ctx context.Context, opts *ReembedOptions) (*ReembedJob, error) {
+	swapper, err := r.shadowStore()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.config.NewEmbeddingService(&opts.Target); err != nil {
+		return nil, fmt.Errorf("invalid target embedding provider: %w", err)
+	}
+
+	r.mu.Lock()
+	for _, existing := range r.jobs {
+		if !existing.terminal() {
+			r.mu.Unlock()
+			return nil, fmt.Errorf("re-embedding %s is already in progress", existing.ID)
+		}
+	}
+
+	now := time.Now().UTC()
+	target := opts.Target
+	target.APIKey = ""
+	job := &ReembedJob{
+		ID:               "reembed_" + strconv.FormatInt(now.UnixNano(), 10),
+		Phase:            ReembedPhasePending,
+		Target:           target,
+		BatchSize:        normalizeReembedBatchSize(opts.BatchSize),
+		DropPrevious:     opts.DropPrevious,
+		SourceCollection: swapper.ActiveCollection(),
+		ShadowCollection: shadowCollectionName(swapper.ActiveCollection(), now),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	r.jobs[job.ID] = job
+	snapshot := *job
+	r.mu.Unlock()
+
+	if err := r.save(job); err != nil {
+		r.mu.Lock()
+		delete(r.jobs, job.ID)
+		r.mu.Unlock()
+		return nil, err
+	}
+	r.track(job.ID)
+
+	go r.run(ctx, job.ID, opts.Target.APIKey)
+	return &snapshot, nil
+}
+
+// Resume loads saved jobs and restarts any that did not complete. It returns
+// the IDs of the restarted jobs.
+func (r *Reembedder) Resume(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(r.config.StateDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read re-embedding state: %w", err)
+	}
+
+	var resumed []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != reembedStateExtension {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(r.config.StateDir, filepath.Clean(entry.Name())))
+		if err != nil {
+			return resumed, fmt.Errorf("failed to read re-embedding state %s: %w", entry.Name(), err)
+		}
+		var job ReembedJob
+		if err := json.Unmarshal(data, &job); err != nil {
+			return resumed, fmt.Errorf("failed to decode re-embedding state %s: %w", entry.Name(), err)
+		}
+
+		r.mu.Lock()
+		if _, loaded := r.jobs[job.ID]; loaded {
+			r.mu.Unlock()
+			continue
+		}
+		r.jobs[job.ID] = &job
+		r.mu.Unlock()
+		r.track(job.ID)
+
+		// Failed jobs wait for an explicit retry; interrupted ones continue
+		if job.terminal() {
+			continue
+		}
+		r.logger.Printf("Resuming re-embedding %s in phase %s at cursor %q", job.ID, job.Phase, job.Cursor)
+		resumed = append(resumed, job.ID)
+		go r.run(ctx, job.ID, "")
+	}
+	return resumed, nil
+}
+
+// Retry restarts a failed job from its saved cursor
+func (r *Reembedder) Retry(ctx context.Context, jobID string) (*ReembedJob, error) {
+	r.mu.Lock()
+	job, exists := r.jobs[jobID]
+	if !exists {
+		r.mu.Unlock()
+		return nil, errors.New("re-embedding " + jobID + " not found")
+	}
+	if job.Phase != ReembedPhaseFailed {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("re-embedding %s is %s, only failed jobs can be retried", jobID, job.Phase)
+	}
+	job.Phase = ReembedPhasePending
+	job.LastError = ""
+	snapshot := *job
+	r.mu.Unlock()
+
+	go r.run(ctx, jobID, "")
+	return &snapshot, nil
+}
+
+// Job returns a copy of a job's state
+func (r *Reembedder) Job(jobID string) (*ReembedJob, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, exists := r.jobs[jobID]
+	if !exists {
+		return nil, false
+	}
+	snapshot := *job
+	snapshot.Errors = append([]Error(nil), job.Errors...)
+	return &snapshot, true
+}
+
+// Progress converts a job's state into bulk progress
+func (r *Reembedder) Progress(jobID string) *Progress {
+	job, exists := r.Job(jobID)
+	if !exists {
+		return &Progress{OperationID: jobID, Status: StatusFailed}
+	}
+
+	progress := &Progress{
+		OperationID:     job.ID,
+		Phase:           string(job.Phase),
+		TotalItems:      job.TotalItems,
+		ProcessedItems:  job.ProcessedItems,
+		SuccessfulItems: job.SuccessfulItems,
+		FailedItems:     job.FailedItems,
+		StartTime:       job.CreatedAt,
+		Errors:          job.Errors,
+	}
+	if job.BatchSize > 0 {
+		progress.TotalBatches = (job.TotalItems + job.BatchSize - 1) / job.BatchSize
+		progress.CurrentBatch = (job.ProcessedItems + job.BatchSize - 1) / job.BatchSize
+	}
+
+	switch job.Phase {
+	case ReembedPhasePending:
+		progress.Status = StatusPending
+	case ReembedPhaseCompleted:
+		progress.Status = StatusCompleted
+	case ReembedPhaseFailed:
+		progress.Status = StatusFailed
+	default:
+		progress.Status = StatusRunning
+	}
+
+	if !job.StartedAt.IsZero() {
+		end := time.Now()
+		if job.CompletedAt != nil {
+			end = *job.CompletedAt
+		}
+		progress.ElapsedTime = end.Sub(job.StartedAt)
+		if progress.Status == StatusRunning && job.ProcessedItems > 0 {
+			perItem := progress.ElapsedTime / time.Duration(job.ProcessedItems)
+			progress.EstimatedTime = perItem * time.Duration(job.TotalItems-job.ProcessedItems)
+		}
+	}
+	return progress
+}
+
+// run drives a job through its remaining phases
+func (r *Reembedder) run(ctx context.Context, jobID, apiKey string) {
+	job, _ := r.Job(jobID)
+	err := r.execute(ctx, job, apiKey)
+	metrics.BulkJobs.Inc(reembedOperation, metrics.Status(err))
+	if err != nil {
+		r.logger.Printf("Re-embedding %s failed: %v", jobID, err)
+		r.update(jobID, func(job *ReembedJob) {
+			job.Phase = ReembedPhaseFailed
+			job.LastError = err.Error()
+			job.Errors = appendReembedError(job.Errors, Error{ItemIndex: -1, Error: err.Error(), Timestamp: time.Now().UTC()})
+		})
+	}
+}
+
+func (r *Reembedder) execute(ctx context.Context, job *ReembedJob, apiKey string) error {
+	swapper, err := r.shadowStore()
+	if err != nil {
+		return err
+	}
+
+	target := job.Target
+	target.APIKey = apiKey
+	service, err := r.config.NewEmbeddingService(&target)
+	if err != nil {
+		return fmt.Errorf("failed to create target embedding provider: %w", err)
+	}
+
+	// A crash between the swap and saving its result leaves the shadow
+	// active. Stores that rename on promotion cannot tell; for them the job
+	// copies the now-active collection again, which is slow but harmless.
+	if swapper.ActiveCollection() == job.ShadowCollection {
+		release := r.fenceWrites()
+		defer release(true)
+		return r.finish(ctx, swapper, job.ID, job.SourceCollection, &target, service)
+	}
+
+	if err := r.update(job.ID, func(job *ReembedJob) {
+		if job.StartedAt.IsZero() {
+			job.StartedAt = time.Now().UTC()
+		}
+		if job.Phase == ReembedPhasePending {
+			job.Phase = ReembedPhaseCopying
+		}
+	}); err != nil {
+		return err
+	}
+
+	shadow, err := swapper.OpenShadowCollection(ctx, job.ShadowCollection, service.GetDimension())
+	if err != nil {
+		return fmt.Errorf("failed to open shadow collection: %w", err)
+	}
+	defer func() { _ = shadow.Close() }()
+
+	if err := r.copyChunks(ctx, job.ID, shadow, service); err != nil {
+		return err
+	}
+
+	if err := r.update(job.ID, func(job *ReembedJob) { job.Phase = ReembedPhaseCatchingUp }); err != nil {
+		return err
+	}
+	// Catch up while writes continue, so the fenced passes below are short
+	if _, err := r.catchUpPasses(ctx, job.ID, shadow, service); err != nil {
+		return err
+	}
+
+	// Writes stay blocked until the server embeds with the target provider:
+	// a chunk stored in between would be lost with the old collection, or
+	// land in the new one with a vector from the old model
+	release := r.fenceWrites()
+	promoted := false
+	defer func() { release(promoted) }()
+
+	changed, err := r.catchUpPasses(ctx, job.ID, shadow, service)
+	if err != nil {
+		return err
+	}
+	if changed > 0 {
+		return fmt.Errorf("live collection still changed %d chunks in the last catch-up pass; not promoting", changed)
+	}
+
+	if err := r.update(job.ID, func(job *ReembedJob) { job.Phase = ReembedPhasePromoting }); err != nil {
+		return err
+	}
+	// Flush the shadow store before the live store takes it over
+	if err := shadow.Close(); err != nil {
+		return fmt.Errorf("failed to close shadow collection: %w", err)
+	}
+
+	previous, err := swapper.PromoteCollection(ctx, job.ShadowCollection)
+	if err != nil {
+		return fmt.Errorf("failed to promote shadow collection: %w", err)
+	}
+	promoted = true
+	return r.finish(ctx, swapper, job.ID, previous, &target, service)
+}
+
+// catchUpPasses runs catch-up passes until one finds nothing to copy, at most
+// maxCatchUpPasses, and returns the number of chunks the last pass changed
+func (r *Reembedder) catchUpPasses(ctx context.Context, id string, shadow storage.VectorStore, service embeddings.EmbeddingService) (int, error) {
+	changed := 0
+	for pass := 0; pass < maxCatchUpPasses; pass++ {
+		var err error
+		if changed, err = r.catchUp(ctx, id, shadow, service); err != nil {
+			return 0, err
+		}
+		if changed == 0 {
+			break
+		}
+	}
+	return changed, nil
+}
+
+// fenceWrites blocks chunk writes through the live store, when its wrapper
+// chain supports it, and returns the function reopening them
+func (r *Reembedder) fenceWrites() func(promoted bool) {
+	fence, ok := storage.FindWriteFence(r.config.Store)
+	if !ok {
+		return func(bool) {}
+	}
+	return fence.FenceWrites()
+}
+
+// finish switches the server to the new provider and records completion
+func (r *Reembedder) finish(ctx context.Context, swapper storage.ShadowCollectionStore, id, previous string, target *config.EmbeddingConfig, service embeddings.EmbeddingService) error {
+	if r.config.OnPromoted != nil {
+		r.config.OnPromoted(target, service)
+	}
+
+	job, _ := r.Job(id)
+	if job.DropPrevious && previous != "" && previous != swapper.ActiveCollection() {
+		if err := swapper.DropCollection(ctx, previous); err != nil {
+			// The swap succeeded; a leftover collection only costs disk space
+			r.logger.Printf("Re-embedding %s could not drop previous collection %s: %v", id, previous, err)
+		} else {
+			previous = ""
+		}
+	}
+
+	r.logger.Printf("Re-embedding %s completed: %s is active (model %s, %d dimensions)",
+		id, job.ShadowCollection, service.GetModel(), service.GetDimension())
+	return r.update(id, func(job *ReembedJob) {
+		job.Phase = ReembedPhaseCompleted
+		job.PreviousCollection = previous
+		job.CompletedAt = timePtr(time.Now().UTC())
+	})
+}
+
+// copyChunks streams chunks after the job's cursor into the shadow collection
+func (r *Reembedder) copyChunks(ctx context.Context, id string, shadow storage.VectorStore, service embeddings.EmbeddingService) error {
+	chunks, err := r.config.Store.GetAllChunks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list chunks: %w", err)
+	}
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].ID < chunks[j].ID })
+
+	job, _ := r.Job(id)
+	start := sort.Search(len(chunks), func(i int) bool { return chunks[i].ID > job.Cursor })
+	if err := r.update(id, func(job *ReembedJob) {
+		job.TotalItems = len(chunks)
+		job.ProcessedItems = start
+		job.SuccessfulItems = start - job.FailedItems
+	}); err != nil {
+		return err
+	}
+
+	for begin := start; begin < len(chunks); begin += job.BatchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		end := begin + job.BatchSize
+		if end > len(chunks) {
+			end = len(chunks)
+		}
+		if err := r.embedInto(ctx, shadow, service, chunks[begin:end]); err != nil {
+			return fmt.Errorf("batch starting at chunk %s: %w", chunks[begin].ID, err)
+		}
+
+		last := chunks[end-1].ID
+		processed := end - begin
+		if err := r.update(id, func(job *ReembedJob) {
+			job.Cursor = last
+			job.ProcessedItems += processed
+			job.SuccessfulItems += processed
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// catchUp applies writes made to the live collection since they were copied
+// and returns the number of chunks it changed
+func (r *Reembedder) catchUp(ctx context.Context, id string, shadow storage.VectorStore, service embeddings.EmbeddingService) (int, error) {
+	live, err := r.config.Store.GetAllChunks(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list chunks: %w", err)
+	}
+	copied, err := shadow.GetAllChunks(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list shadow chunks: %w", err)
+	}
+
+	copiedByID := make(map[string]*types.ConversationChunk, len(copied))
+	for i := range copied {
+		copiedByID[copied[i].ID] = &copied[i]
+	}
+
+	var stale []types.ConversationChunk
+	for i := range live {
+		existing, ok := copiedByID[live[i].ID]
+		delete(copiedByID, live[i].ID)
+		if !ok || chunkFingerprint(existing) != chunkFingerprint(&live[i]) {
+			stale = append(stale, live[i])
+		}
+	}
+	removed := make([]string, 0, len(copiedByID))
+	for chunkID := range copiedByID {
+		removed = append(removed, chunkID)
+	}
+
+	job, _ := r.Job(id)
+	for begin := 0; begin < len(stale); begin += job.BatchSize {
+		end := begin + job.BatchSize
+		if end > len(stale) {
+			end = len(stale)
+		}
+		if err := r.embedInto(ctx, shadow, service, stale[begin:end]); err != nil {
+			return 0, fmt.Errorf("catch-up: %w", err)
+		}
+	}
+	if len(removed) > 0 {
+		if _, err := shadow.BatchDelete(ctx, removed); err != nil {
+			return 0, fmt.Errorf("catch-up: failed to delete removed chunks: %w", err)
+		}
+	}
+
+	changed := len(stale) + len(removed)
+	if err := r.update(id, func(job *ReembedJob) {
+		job.TotalItems = len(live)
+		job.CatchUpItems += changed
+	}); err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
+
+// embedInto regenerates vectors for chunks and writes them to the shadow.
+// Any failure fails the batch: promoting a collection with missing chunks
+// would lose memories.
+func (r *Reembedder) embedInto(ctx context.Context, shadow storage.VectorStore, service embeddings.EmbeddingService, chunks []types.ConversationChunk) error {
+	texts := make([]string, len(chunks))
+	for i := range chunks {
+		texts[i] = r.config.EmbeddingText(&chunks[i])
+	}
+
+	vectors, err := service.GenerateBatchEmbeddings(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+	if len(vectors) != len(chunks) {
+		return fmt.Errorf("embedding provider returned %d vectors for %d chunks", len(vectors), len(chunks))
+	}
+
+	batch := make([]*types.ConversationChunk, len(chunks))
+	for i := range chunks {
+		if len(vectors[i]) != service.GetDimension() {
+			return fmt.Errorf("chunk %s: expected %d dimensions, got %d", chunks[i].ID, service.GetDimension(), len(vectors[i]))
+		}
+		copied := chunks[i]
+		copied.Embeddings = vectors[i]
+		batch[i] = &copied
+	}
+
+	result, err := shadow.BatchStore(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("failed to store re-embedded chunks: %w", err)
+	}
+	if result.Failed > 0 {
+		return fmt.Errorf("failed to store %d re-embedded chunks: %v", result.Failed, result.Errors)
+	}
+	return nil
+}
+
+// update applies change to a job under the lock and saves it
+func (r *Reembedder) update(id string, change func(job *ReembedJob)) error {
+	r.mu.Lock()
+	job, exists := r.jobs[id]
+	if !exists {
+		r.mu.Unlock()
+		return errors.New("re-embedding " + id + " not found")
+	}
+	change(job)
+	job.UpdatedAt = time.Now().UTC()
+	snapshot := *job
+	r.mu.Unlock()
+
+	return r.save(&snapshot)
+}
+
+// save atomically writes a job's state file
+func (r *Reembedder) save(job *ReembedJob) error {
+	if err := os.MkdirAll(r.config.StateDir, 0o750); err != nil {
+		return fmt.Errorf("failed to create re-embedding state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode re-embedding state: %w", err)
+	}
+	path := filepath.Join(r.config.StateDir, job.ID+reembedStateExtension)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save re-embedding state: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to save re-embedding state: %w", err)
+	}
+	return nil
+}
+
+// track reports the job through the bulk manager
+func (r *Reembedder) track(id string) {
+	if r.config.Manager != nil {
+		r.config.Manager.TrackOperation(id, func() *Progress { return r.Progress(id) })
+	}
+}
+
+func (r *Reembedder) shadowStore() (storage.ShadowCollectionStore, error) {
+	swapper, ok := storage.FindShadowCollectionStore(r.config.Store)
+	if !ok {
+		return nil, errors.New("the configured vector store does not support shadow collections")
+	}
+	return swapper, nil
+}
+
+func (job *ReembedJob) terminal() bool {
+	return job.Phase == ReembedPhaseCompleted || job.Phase == ReembedPhaseFailed
+}
+
+// shadowCollectionName derives a new collection name from the active one
+func shadowCollectionName(active string, now time.Time) string {
+	base := reembedSuffixPattern.ReplaceAllString(active, "")
+	suffix := "_reembed_" + strconv.FormatInt(now.Unix(), 10)
+	if maxBase := 63 - len(suffix); len(base) > maxBase {
+		base = base[:maxBase]
+	}
+	return base + suffix
+}
+
+func normalizeReembedBatchSize(size int) int {
+	switch {
+	case size <= 0:
+		return defaultReembedBatchSize
+	case size > maxReembedBatchSize:
+		return maxReembedBatchSize
+	default:
+		return size
+	}
+}
+
+// chunkFingerprint identifies a chunk's stored content, ignoring its vector
+func chunkFingerprint(chunk *types.ConversationChunk) string {
+	copied := *chunk
+	copied.Embeddings = nil
+	data, err := json.Marshal(&copied)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// appendReembedError keeps the most recent errors only
+func appendReembedError(errs []Error, err Error) []Error {
+	errs = append(errs, err)
+	if len(errs) > maxReembedErrors {
+		errs = errs[len(errs)-maxReembedErrors:]
+	}
+	return errs
+}
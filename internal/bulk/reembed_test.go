@@ -0,0 +1,397 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/embeddings"
+	"lerian-mcp-memory/internal/storage"
+	"lerian-mcp-memory/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingEmbedder counts embedded texts and can fail after a number of batches
+type countingEmbedder struct {
+	embeddings.EmbeddingService
+	model     string
+	texts     atomic.Int64
+	batches   atomic.Int64
+	failAfter int64
+}
+
+func (c *countingEmbedder) GenerateBatchEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	if c.failAfter > 0 && c.batches.Load() >= c.failAfter {
+		return nil, errors.New("provider unavailable")
+	}
+	c.batches.Add(1)
+	c.texts.Add(int64(len(texts)))
+	return c.EmbeddingService.GenerateBatchEmbeddings(ctx, texts)
+}
+
+func (c *countingEmbedder) GetModel() string {
+	if c.model != "" {
+		return c.model
+	}
+	return c.EmbeddingService.GetModel()
+}
+
+func newReembedTestStore(t *testing.T, dataDir string) *storage.LocalStore {
+	t.Helper()
+	store := storage.NewLocalStore(&config.LocalStorageConfig{
+		DataDir:     dataDir,
+		Collection:  "memories",
+		IndexTables: 2,
+		IndexBits:   4,
+	})
+	require.NoError(t, store.Initialize(context.Background()))
+	return store
+}
+
+func seedReembedChunks(t *testing.T, store storage.VectorStore, count int) []string {
+	t.Helper()
+	source := embeddings.NewHashEmbeddingService(8)
+	ids := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		content := "memory number " + strconv.Itoa(i)
+		vector, err := source.GenerateEmbedding(context.Background(), content)
+		require.NoError(t, err)
+		chunk := &types.ConversationChunk{
+			ID:         "chunk-" + strconv.Itoa(i),
+			SessionID:  "session",
+			Timestamp:  time.Now(),
+			Type:       types.ChunkTypeDiscussion,
+			Content:    content,
+			Embeddings: vector,
+			Metadata: types.ChunkMetadata{
+				Repository: "github.com/acme/api",
+				Outcome:    types.OutcomeSuccess,
+				Difficulty: types.DifficultySimple,
+			},
+		}
+		require.NoError(t, store.Store(context.Background(), chunk))
+		ids = append(ids, chunk.ID)
+	}
+	return ids
+}
+
+func waitForReembedPhase(t *testing.T, reembedder *Reembedder, id string, phase ReembedPhase) *ReembedJob {
+	t.Helper()
+	var job *ReembedJob
+	require.Eventually(t, func() bool {
+		job, _ = reembedder.Job(id)
+		return job.Phase == phase
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestReembedderMigratesToNewDimension(t *testing.T) {
+	ctx := context.Background()
+	store := newReembedTestStore(t, t.TempDir())
+	defer func() { _ = store.Close() }()
+	seedReembedChunks(t, store, 5)
+
+	target := &countingEmbedder{EmbeddingService: embeddings.NewHashEmbeddingService(16)}
+	var promoted embeddings.EmbeddingService
+	manager := NewManager(store, log.New(io.Discard, "", 0))
+	reembedder := NewReembedder(ReembedderConfig{
+		Store:            storage.NewRetryableVectorStore(store, nil),
+		CurrentEmbedding: embeddings.NewHashEmbeddingService(8),
+		StateDir:         t.TempDir(),
+		NewEmbeddingService: func(_ *config.EmbeddingConfig) (embeddings.EmbeddingService, error) {
+			return target, nil
+		},
+		OnPromoted: func(_ *config.EmbeddingConfig, service embeddings.EmbeddingService) {
+			promoted = service
+		},
+		Manager: manager,
+		Logger:  log.New(io.Discard, "", 0),
+	})
+
+	job, err := reembedder.Start(ctx, &ReembedOptions{
+		Target:       config.EmbeddingConfig{Provider: config.EmbeddingProviderHash, Dimension: 16, APIKey: "secret"},
+		BatchSize:    2,
+		DropPrevious: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "memories", job.SourceCollection)
+	assert.Empty(t, job.Target.APIKey, "API keys are not kept in job state")
+
+	done := waitForReembedPhase(t, reembedder, job.ID, ReembedPhaseCompleted)
+	assert.Equal(t, 5, done.ProcessedItems)
+	assert.Equal(t, int64(5), target.texts.Load())
+	assert.Same(t, target, promoted)
+	assert.Empty(t, done.PreviousCollection, "previous collection was dropped")
+
+	assert.Equal(t, job.ShadowCollection, store.ActiveCollection())
+	dimension, err := store.VectorDimension(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 16, dimension)
+	chunks, err := store.GetAllChunks(ctx)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 5)
+
+	progress, err := manager.GetProgress(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, progress.Status)
+	assert.Equal(t, string(ReembedPhaseCompleted), progress.Phase)
+	assert.Equal(t, 3, progress.TotalBatches)
+
+	_, err = reembedder.Start(ctx, &ReembedOptions{Target: config.EmbeddingConfig{Provider: config.EmbeddingProviderHash}})
+	assert.NoError(t, err, "a new job may start once the previous one completed")
+}
+
+func TestReembedderResumesFromCursor(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	stateDir := t.TempDir()
+	store := newReembedTestStore(t, dataDir)
+	ids := seedReembedChunks(t, store, 5)
+
+	failing := &countingEmbedder{EmbeddingService: embeddings.NewHashEmbeddingService(16), failAfter: 1}
+	first := NewReembedder(ReembedderConfig{
+		Store:    store,
+		StateDir: stateDir,
+		NewEmbeddingService: func(_ *config.EmbeddingConfig) (embeddings.EmbeddingService, error) {
+			return failing, nil
+		},
+		Logger: log.New(io.Discard, "", 0),
+	})
+	job, err := first.Start(ctx, &ReembedOptions{Target: config.EmbeddingConfig{Provider: config.EmbeddingProviderHash, Dimension: 16}, BatchSize: 2})
+	require.NoError(t, err)
+
+	failed := waitForReembedPhase(t, first, job.ID, ReembedPhaseFailed)
+	assert.Equal(t, ids[1], failed.Cursor, "the first batch was saved")
+	assert.Contains(t, failed.LastError, "provider unavailable")
+	assert.Equal(t, "memories", store.ActiveCollection(), "nothing is promoted on failure")
+
+	// Simulate a crash mid-copy: the state file still says copying
+	statePath := filepath.Join(stateDir, job.ID+reembedStateExtension)
+	failed.Phase = ReembedPhaseCopying
+	require.NoError(t, first.save(failed))
+	require.FileExists(t, statePath)
+	require.NoError(t, store.Close())
+
+	restarted := newReembedTestStore(t, dataDir)
+	defer func() { _ = restarted.Close() }()
+	resumedEmbedder := &countingEmbedder{EmbeddingService: embeddings.NewHashEmbeddingService(16)}
+	second := NewReembedder(ReembedderConfig{
+		Store:    restarted,
+		StateDir: stateDir,
+		NewEmbeddingService: func(_ *config.EmbeddingConfig) (embeddings.EmbeddingService, error) {
+			return resumedEmbedder, nil
+		},
+		Logger: log.New(io.Discard, "", 0),
+	})
+	resumed, err := second.Resume(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{job.ID}, resumed)
+
+	done := waitForReembedPhase(t, second, job.ID, ReembedPhaseCompleted)
+	assert.Equal(t, int64(3), resumedEmbedder.texts.Load(), "only chunks after the cursor are embedded again")
+	assert.Equal(t, 5, done.ProcessedItems)
+	assert.Equal(t, "memories", done.PreviousCollection)
+	assert.Equal(t, job.ShadowCollection, restarted.ActiveCollection())
+
+	chunks, err := restarted.GetAllChunks(ctx)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 5)
+	for i := range chunks {
+		assert.Len(t, chunks[i].Embeddings, 16)
+	}
+
+	_, err = os.Stat(filepath.Join(dataDir, "memories"))
+	assert.NoError(t, err, "the previous collection is kept unless asked otherwise")
+}
+
+func TestReembedderCatchesUpConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	store := newReembedTestStore(t, t.TempDir())
+	defer func() { _ = store.Close() }()
+	ids := seedReembedChunks(t, store, 3)
+
+	reembedder := NewReembedder(ReembedderConfig{
+		Store:    store,
+		StateDir: t.TempDir(),
+		Logger:   log.New(io.Discard, "", 0),
+	})
+	swapper, ok := storage.FindShadowCollectionStore(store)
+	require.True(t, ok)
+	shadow, err := swapper.OpenShadowCollection(ctx, "memories_reembed_1", 16)
+	require.NoError(t, err)
+	defer func() { _ = shadow.Close() }()
+	service := embeddings.NewHashEmbeddingService(16)
+
+	reembedder.jobs["job"] = &ReembedJob{ID: "job", BatchSize: 10}
+	require.NoError(t, reembedder.copyChunks(ctx, "job", shadow, service))
+
+	// Writes that land in the live collection while copying
+	updated, err := store.GetByID(ctx, ids[0])
+	require.NoError(t, err)
+	updated.Content = "rewritten while copying"
+	require.NoError(t, store.Update(ctx, updated))
+	require.NoError(t, store.Delete(ctx, ids[1]))
+	inserted := *updated
+	inserted.ID = "chunk-new"
+	require.NoError(t, store.Store(ctx, &inserted))
+
+	changed, err := reembedder.catchUp(ctx, "job", shadow, service)
+	require.NoError(t, err)
+	assert.Equal(t, 3, changed, "one update, one delete, one insert")
+
+	changed, err = reembedder.catchUp(ctx, "job", shadow, service)
+	require.NoError(t, err)
+	assert.Zero(t, changed)
+
+	got, err := shadow.GetByID(ctx, ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, "rewritten while copying", got.Content)
+	_, err = shadow.GetByID(ctx, ids[1])
+	assert.Error(t, err)
+}
+
+func TestReembedderEstimate(t *testing.T) {
+	ctx := context.Background()
+	store := newReembedTestStore(t, t.TempDir())
+	defer func() { _ = store.Close() }()
+	seedReembedChunks(t, store, 3)
+
+	reembedder := NewReembedder(ReembedderConfig{
+		Store:            store,
+		CurrentEmbedding: embeddings.NewHashEmbeddingService(8),
+		StateDir:         t.TempDir(),
+		EmbeddingText:    func(*types.ConversationChunk) string { return "12345678" },
+		NewEmbeddingService: func(target *config.EmbeddingConfig) (embeddings.EmbeddingService, error) {
+			return &countingEmbedder{EmbeddingService: embeddings.NewHashEmbeddingService(1536), model: target.Model}, nil
+		},
+	})
+
+	estimate, err := reembedder.Estimate(ctx, &ReembedOptions{
+		Target:    config.EmbeddingConfig{Provider: config.EmbeddingProviderOpenAI, Model: "text-embedding-3-small"},
+		BatchSize: 2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, estimate.Chunks)
+	assert.Equal(t, int64(24), estimate.Characters)
+	assert.Equal(t, int64(6), estimate.EstimatedTokens)
+	assert.Equal(t, 2, estimate.Requests)
+	assert.Equal(t, 8, estimate.CurrentDimension)
+	assert.Equal(t, 1536, estimate.TargetDimension)
+	assert.True(t, estimate.PriceKnown)
+	assert.InDelta(t, 6*0.02/1e6, estimate.EstimatedCostUSD, 1e-12)
+
+	estimate, err = reembedder.Estimate(ctx, &ReembedOptions{Target: config.EmbeddingConfig{Provider: config.EmbeddingProviderOpenAICompatible, Model: "bge"}})
+	require.NoError(t, err)
+	assert.False(t, estimate.PriceKnown)
+
+	collections, err := store.ListCollections(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"memories"}, collections, "a dry run writes nothing")
+}
+
+func TestShadowCollectionName(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	assert.Equal(t, "claude_memory_reembed_1700000000", shadowCollectionName("claude_memory", now))
+	assert.Equal(t, "claude_memory_reembed_1700000000", shadowCollectionName("claude_memory_reembed_1600000000", now))
+	assert.LessOrEqual(t, len(shadowCollectionName(string(make([]byte, 80)), now)), 63)
+}
+
+// churningStore reports a new live chunk on every listing, as if writes never
+// stopped
+type churningStore struct {
+	*storage.LocalStore
+	listings atomic.Int64
+}
+
+func (s *churningStore) GetAllChunks(ctx context.Context) ([]types.ConversationChunk, error) {
+	chunks, err := s.LocalStore.GetAllChunks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	n := s.listings.Add(1)
+	for i := int64(0); i < n; i++ {
+		chunk := chunks[0]
+		chunk.ID = "churn-" + strconv.FormatInt(i, 10)
+		chunk.Content = "written during catch-up " + strconv.FormatInt(n, 10)
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
+
+func (s *churningStore) Unwrap() storage.VectorStore {
+	return s.LocalStore
+}
+
+func TestReembedderFencesWritesUntilProviderSwitch(t *testing.T) {
+	ctx := context.Background()
+	local := newReembedTestStore(t, t.TempDir())
+	defer func() { _ = local.Close() }()
+	seedReembedChunks(t, local, 3)
+	store := storage.NewObservedStore(local)
+
+	late := make(chan error, 1)
+	reembedder := NewReembedder(ReembedderConfig{
+		Store:    store,
+		StateDir: t.TempDir(),
+		NewEmbeddingService: func(_ *config.EmbeddingConfig) (embeddings.EmbeddingService, error) {
+			return embeddings.NewHashEmbeddingService(16), nil
+		},
+		OnPromoted: func(_ *config.EmbeddingConfig, _ embeddings.EmbeddingService) {
+			// A write embedded with the old model, racing the switch
+			chunk, err := local.GetByID(ctx, "chunk-0")
+			require.NoError(t, err)
+			chunk.ID = "chunk-late"
+			go func() { late <- store.Store(ctx, chunk) }()
+			select {
+			case err := <-late:
+				t.Errorf("write finished before the provider switched: %v", err)
+			case <-time.After(50 * time.Millisecond):
+			}
+		},
+		Logger: log.New(io.Discard, "", 0),
+	})
+
+	job, err := reembedder.Start(ctx, &ReembedOptions{Target: config.EmbeddingConfig{Provider: config.EmbeddingProviderHash, Dimension: 16}})
+	require.NoError(t, err)
+	waitForReembedPhase(t, reembedder, job.ID, ReembedPhaseCompleted)
+
+	assert.ErrorIs(t, <-late, storage.ErrEmbeddingModelChanged)
+	_, err = local.GetByID(ctx, "chunk-late")
+	assert.Error(t, err, "no old-model vector reaches the new collection")
+}
+
+func TestReembedderRefusesToPromoteWhileChanging(t *testing.T) {
+	ctx := context.Background()
+	local := newReembedTestStore(t, t.TempDir())
+	defer func() { _ = local.Close() }()
+	seedReembedChunks(t, local, 2)
+
+	promoted := false
+	reembedder := NewReembedder(ReembedderConfig{
+		Store:    &churningStore{LocalStore: local},
+		StateDir: t.TempDir(),
+		NewEmbeddingService: func(_ *config.EmbeddingConfig) (embeddings.EmbeddingService, error) {
+			return embeddings.NewHashEmbeddingService(16), nil
+		},
+		OnPromoted: func(*config.EmbeddingConfig, embeddings.EmbeddingService) { promoted = true },
+		Logger:     log.New(io.Discard, "", 0),
+	})
+
+	job, err := reembedder.Start(ctx, &ReembedOptions{Target: config.EmbeddingConfig{Provider: config.EmbeddingProviderHash, Dimension: 16}})
+	require.NoError(t, err)
+	failed := waitForReembedPhase(t, reembedder, job.ID, ReembedPhaseFailed)
+
+	assert.Contains(t, failed.LastError, "not promoting")
+	assert.False(t, promoted)
+	assert.Equal(t, "memories", local.ActiveCollection())
+}
@@ -0,0 +1,986 @@
+package bulk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lerian-mcp-memory/pkg/types"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Transcript roles, normalised across formats
+const (
+	transcriptRoleUser      = "user"
+	transcriptRoleAssistant = "assistant"
+)
+
+// maxTranscriptLineSize bounds a single JSONL line; Claude Code lines carry
+// whole tool results and can be large
+const maxTranscriptLineSize = 16 * 1024 * 1024
+
+// editToolKeywords mark tool names that change files; their path arguments
+// become FilesModified
+var editToolKeywords = []string{"edit", "write", "create", "replace", "patch", "insert", "delete", "rename", "move", "apply"}
+
+// pathArgumentKeys are the argument names tools use for the file they touch
+var pathArgumentKeys = []string{"file_path", "filePath", "target_file", "notebook_path", "relative_workspace_path", "path", "filename", "file", "uri"}
+
+// transcript is one assistant session read from an export
+type transcript struct {
+	sessionID  string
+	title      string
+	workingDir string
+	gitBranch  string
+	turns      []transcriptTurn
+}
+
+// transcriptTurn is a message, or the tool calls made in its place
+type transcriptTurn struct {
+	role      string
+	text      string
+	tools     []string
+	files     []string
+	timestamp time.Time
+}
+
+// detectTranscriptFormat recognises assistant transcripts by their shape
+func detectTranscriptFormat(data string) (ImportFormat, bool) {
+	data = strings.TrimSpace(data)
+
+	var document interface{}
+	if err := json.Unmarshal([]byte(data), &document); err != nil {
+		// JSON Lines: judge by the first record
+		firstLine, _, _ := strings.Cut(data, "\n")
+		var record map[string]interface{}
+		if json.Unmarshal([]byte(firstLine), &record) != nil {
+			return "", false
+		}
+		return detectTranscriptRecord(record)
+	}
+
+	switch value := document.(type) {
+	case []interface{}:
+		if len(value) == 0 {
+			return "", false
+		}
+		record, ok := value[0].(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		return detectTranscriptRecord(record)
+	case map[string]interface{}:
+		return detectTranscriptRecord(value)
+	}
+	return "", false
+}
+
+// detectTranscriptRecord identifies the format a single record belongs to
+func detectTranscriptRecord(record map[string]interface{}) (ImportFormat, bool) {
+	if _, ok := record["mapping"]; ok {
+		return FormatChatGPT, true
+	}
+	if _, ok := record["sessionId"]; ok {
+		if _, ok := record["message"]; ok {
+			return FormatClaudeCode, true
+		}
+		if recordType, _ := record["type"].(string); recordType == "summary" {
+			return FormatClaudeCode, true
+		}
+	}
+	if recordType, _ := record["type"].(string); recordType == "summary" && record["leafUuid"] != nil {
+		return FormatClaudeCode, true
+	}
+	for _, key := range []string{"requests", "tabs", "composers", "allComposers", "composerId", "bubbles"} {
+		if _, ok := record[key]; ok {
+			return FormatCursor, true
+		}
+	}
+	if _, ok := record["choices"]; ok {
+		return FormatOpenAIChat, true
+	}
+	if request, ok := record["request"].(map[string]interface{}); ok {
+		if _, ok := request["messages"]; ok {
+			return FormatOpenAIChat, true
+		}
+	}
+	if messages, ok := record["messages"].([]interface{}); ok && hasToolCalls(messages) {
+		return FormatOpenAIChat, true
+	}
+	return "", false
+}
+
+// hasToolCalls reports whether chat messages use the chat-completion tool
+// fields, which the generic messages import does not understand
+func hasToolCalls(messages []interface{}) bool {
+	for _, message := range messages {
+		fields, ok := message.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := fields["tool_calls"]; ok {
+			return true
+		}
+		if _, ok := fields["function_call"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTranscripts reads the sessions in data according to format
+func parseTranscripts(data string, format ImportFormat) ([]transcript, error) {
+	switch format {
+	case FormatClaudeCode:
+		return parseClaudeCodeTranscripts(data)
+	case FormatChatGPT:
+		return parseChatGPTTranscripts(data)
+	case FormatCursor:
+		return parseCursorTranscripts(data)
+	case FormatOpenAIChat:
+		return parseOpenAIChatTranscripts(data)
+	default:
+		return nil, fmt.Errorf("unsupported transcript format: %s", format)
+	}
+}
+
+// transcriptChunks turns each exchange, a user message and everything the
+// assistant did in reply, into one chunk
+func (imp *Importer) transcriptChunks(transcripts []transcript, format ImportFormat, options *ImportOptions) []types.ConversationChunk {
+	var chunks []types.ConversationChunk
+	for i := range transcripts {
+		session := &transcripts[i]
+		var exchange []transcriptTurn
+		for _, turn := range session.turns {
+			if turn.role == transcriptRoleUser && turn.text != "" && len(exchange) > 0 {
+				if chunk := imp.exchangeChunk(session, exchange, format, options); chunk != nil {
+					chunks = append(chunks, *chunk)
+				}
+				exchange = nil
+			}
+			exchange = append(exchange, turn)
+		}
+		if chunk := imp.exchangeChunk(session, exchange, format, options); chunk != nil {
+			chunks = append(chunks, *chunk)
+		}
+	}
+	return chunks
+}
+
+// exchangeChunk builds the chunk for one exchange, or nil when it holds no
+// text or tool use
+func (imp *Importer) exchangeChunk(session *transcript, exchange []transcriptTurn, format ImportFormat, options *ImportOptions) *types.ConversationChunk {
+	var lines, tools, files []string
+	var timestamp time.Time
+	var question string
+	answered := false
+
+	for _, turn := range exchange {
+		if timestamp.IsZero() && !turn.timestamp.IsZero() {
+			timestamp = turn.timestamp
+		}
+		if turn.text != "" {
+			label := "User"
+			if turn.role == transcriptRoleAssistant {
+				label = "Assistant"
+				answered = true
+			} else if question == "" {
+				question = turn.text
+			}
+			lines = append(lines, label+": "+turn.text)
+		}
+		for _, tool := range turn.tools {
+			lines = append(lines, "Tool: "+tool)
+		}
+		tools = appendUnique(tools, turn.tools...)
+		files = appendUnique(files, turn.files...)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	sessionID := session.sessionID
+	if sessionID == "" {
+		sessionID = options.DefaultSessionID
+	}
+	if sessionID == "" {
+		sessionID = "imported_session"
+	}
+	if timestamp.IsZero() {
+		timestamp = time.Now().UTC()
+	}
+
+	// Edits make it a code change; an unanswered question stays a problem
+	chunkType := types.ChunkTypeSolution
+	switch {
+	case len(files) > 0:
+		chunkType = types.ChunkTypeCodeChange
+	case !answered:
+		chunkType = types.ChunkTypeProblem
+	}
+
+	tags := append([]string{}, options.DefaultTags...)
+	tags = append(tags, options.Metadata.Tags...)
+	tags = append(tags, "transcript-import", "source:"+string(format))
+	if options.Metadata.SourceSystem != "" {
+		tags = append(tags, "source:"+options.Metadata.SourceSystem)
+	}
+
+	extended := map[string]interface{}{"transcript_format": string(format)}
+	if session.title != "" {
+		extended["transcript_title"] = session.title
+	}
+	if session.workingDir != "" {
+		extended[types.EMKeyWorkingDir] = session.workingDir
+	}
+	if session.gitBranch != "" {
+		extended[types.EMKeyGitBranch] = session.gitBranch
+	}
+
+	summary := question
+	if summary == "" {
+		summary = strings.TrimPrefix(lines[0], "Assistant: ")
+	}
+
+	return &types.ConversationChunk{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		Timestamp: timestamp.UTC(),
+		Type:      chunkType,
+		Content:   strings.Join(lines, "\n\n"),
+		Summary:   imp.generateSummary(summary),
+		Metadata: types.ChunkMetadata{
+			Repository:       options.Repository,
+			Branch:           session.gitBranch,
+			FilesModified:    files,
+			ToolsUsed:        tools,
+			Outcome:          types.OutcomeSuccess,
+			Tags:             tags,
+			Difficulty:       types.DifficultyModerate,
+			ExtendedMetadata: extended,
+		},
+	}
+}
+
+// Claude Code
+
+// claudeCodeRecord is one line of a Claude Code session log
+type claudeCodeRecord struct {
+	Type      string `json:"type"`
+	SessionID string `json:"sessionId"`
+	Timestamp string `json:"timestamp"`
+	Cwd       string `json:"cwd"`
+	GitBranch string `json:"gitBranch"`
+	IsMeta    bool   `json:"isMeta"`
+	Summary   string `json:"summary"`
+	Message   *struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// claudeCodeBlock is an entry of a message's content array
+type claudeCodeBlock struct {
+	Type  string                 `json:"type"`
+	Text  string                 `json:"text"`
+	Name  string                 `json:"name"`
+	Input map[string]interface{} `json:"input"`
+}
+
+// parseClaudeCodeTranscripts reads Claude Code session logs, one JSON record
+// per line. Tool results come back as user records and do not start a new
+// exchange.
+func parseClaudeCodeTranscripts(data string) ([]transcript, error) {
+	sessions := make(map[string]*transcript)
+	var order []string
+	var summaries []string
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTranscriptLineSize)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var record claudeCodeRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		if record.Type == "summary" {
+			summaries = append(summaries, record.Summary)
+			continue
+		}
+		if record.Message == nil || record.IsMeta || (record.Type != transcriptRoleUser && record.Type != transcriptRoleAssistant) {
+			continue
+		}
+
+		session, ok := sessions[record.SessionID]
+		if !ok {
+			session = &transcript{sessionID: record.SessionID}
+			sessions[record.SessionID] = session
+			order = append(order, record.SessionID)
+		}
+		if session.workingDir == "" {
+			session.workingDir = record.Cwd
+		}
+		if session.gitBranch == "" {
+			session.gitBranch = record.GitBranch
+		}
+
+		turn := transcriptTurn{role: record.Type, timestamp: parseTranscriptTime(record.Timestamp)}
+		var text string
+		if err := json.Unmarshal(record.Message.Content, &text); err == nil {
+			turn.text = strings.TrimSpace(text)
+		} else {
+			var blocks []claudeCodeBlock
+			if err := json.Unmarshal(record.Message.Content, &blocks); err != nil {
+				return nil, fmt.Errorf("line %d: unrecognized message content: %w", lineNum, err)
+			}
+			var texts []string
+			for _, block := range blocks {
+				switch block.Type {
+				case "text":
+					if strings.TrimSpace(block.Text) != "" {
+						texts = append(texts, strings.TrimSpace(block.Text))
+					}
+				case "tool_use":
+					turn.tools = append(turn.tools, block.Name)
+					turn.files = append(turn.files, editedFiles(block.Name, block.Input)...)
+				}
+			}
+			turn.text = strings.Join(texts, "\n")
+		}
+		if turn.text != "" || len(turn.tools) > 0 {
+			session.turns = append(session.turns, turn)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read session log: %w", err)
+	}
+
+	transcripts := make([]transcript, 0, len(order))
+	for _, id := range order {
+		session := sessions[id]
+		// Claude Code writes the session title as a summary record
+		if len(summaries) > 0 {
+			session.title = summaries[len(summaries)-1]
+		}
+		transcripts = append(transcripts, *session)
+	}
+	return transcripts, nil
+}
+
+// ChatGPT
+
+// chatGPTConversation is one conversation of a ChatGPT conversations.json
+// export. Messages form a tree; the current node ends the branch that was
+// shown last.
+type chatGPTConversation struct {
+	ID             string                 `json:"id"`
+	ConversationID string                 `json:"conversation_id"`
+	Title          string                 `json:"title"`
+	CreateTime     float64                `json:"create_time"`
+	CurrentNode    string                 `json:"current_node"`
+	Mapping        map[string]chatGPTNode `json:"mapping"`
+}
+
+// chatGPTNode is a node of the message tree
+type chatGPTNode struct {
+	Parent  string `json:"parent"`
+	Message *struct {
+		Author struct {
+			Role string `json:"role"`
+			Name string `json:"name"`
+		} `json:"author"`
+		CreateTime float64 `json:"create_time"`
+		Recipient  string  `json:"recipient"`
+		Content    struct {
+			ContentType string        `json:"content_type"`
+			Parts       []interface{} `json:"parts"`
+			Text        string        `json:"text"`
+		} `json:"content"`
+		Metadata map[string]interface{} `json:"metadata"`
+	} `json:"message"`
+}
+
+// parseChatGPTTranscripts reads a ChatGPT data export's conversations.json
+func parseChatGPTTranscripts(data string) ([]transcript, error) {
+	var conversations []chatGPTConversation
+	if err := json.Unmarshal([]byte(data), &conversations); err != nil {
+		var single chatGPTConversation
+		if err := json.Unmarshal([]byte(data), &single); err != nil {
+			return nil, fmt.Errorf("failed to parse ChatGPT export: %w", err)
+		}
+		conversations = []chatGPTConversation{single}
+	}
+
+	transcripts := make([]transcript, 0, len(conversations))
+	for i := range conversations {
+		conversation := &conversations[i]
+		session := transcript{sessionID: conversation.ConversationID, title: conversation.Title}
+		if session.sessionID == "" {
+			session.sessionID = conversation.ID
+		}
+
+		// Walk from the current node to the root, then replay in order
+		var branch []chatGPTNode
+		for id, seen := conversation.CurrentNode, 0; id != "" && seen <= len(conversation.Mapping); seen++ {
+			node, ok := conversation.Mapping[id]
+			if !ok {
+				break
+			}
+			branch = append(branch, node)
+			id = node.Parent
+		}
+
+		for j := len(branch) - 1; j >= 0; j-- {
+			if turn, ok := chatGPTTurn(&branch[j], conversation.CreateTime); ok {
+				session.turns = append(session.turns, turn)
+			}
+		}
+		transcripts = append(transcripts, session)
+	}
+	return transcripts, nil
+}
+
+// chatGPTTurn converts a message node. Tool output is dropped; the tool that
+// produced it, or the tool an assistant message was addressed to, is kept.
+func chatGPTTurn(node *chatGPTNode, fallbackTime float64) (transcriptTurn, bool) {
+	message := node.Message
+	if message == nil {
+		return transcriptTurn{}, false
+	}
+	if hidden, _ := message.Metadata["is_visually_hidden_from_conversation"].(bool); hidden {
+		return transcriptTurn{}, false
+	}
+
+	created := message.CreateTime
+	if created == 0 {
+		created = fallbackTime
+	}
+	turn := transcriptTurn{role: message.Author.Role, timestamp: unixSecondsTime(created)}
+
+	switch message.Author.Role {
+	case transcriptRoleUser:
+	case transcriptRoleAssistant:
+		if message.Recipient != "" && message.Recipient != "all" {
+			turn.tools = []string{message.Recipient}
+			return turn, true
+		}
+	case "tool":
+		if message.Author.Name == "" {
+			return transcriptTurn{}, false
+		}
+		turn.role = transcriptRoleAssistant
+		turn.tools = []string{message.Author.Name}
+		return turn, true
+	default:
+		return transcriptTurn{}, false
+	}
+
+	var texts []string
+	for _, part := range message.Content.Parts {
+		if text, ok := part.(string); ok && strings.TrimSpace(text) != "" {
+			texts = append(texts, strings.TrimSpace(text))
+		}
+	}
+	if message.Content.Text != "" {
+		texts = append(texts, strings.TrimSpace(message.Content.Text))
+	}
+	turn.text = strings.Join(texts, "\n")
+	return turn, turn.text != ""
+}
+
+// Cursor and VS Code
+
+// parseCursorTranscripts reads chat exports from VS Code ("Chat: Export
+// Chat", a requests array) and Cursor (chat tabs of bubbles, or composer
+// conversations), alone or in an array
+func parseCursorTranscripts(data string) ([]transcript, error) {
+	var document interface{}
+	if err := json.Unmarshal([]byte(data), &document); err != nil {
+		return nil, fmt.Errorf("failed to parse chat export: %w", err)
+	}
+
+	var transcripts []transcript
+	var collect func(value interface{})
+	collect = func(value interface{}) {
+		switch v := value.(type) {
+		case []interface{}:
+			for _, item := range v {
+				collect(item)
+			}
+		case map[string]interface{}:
+			switch {
+			case v["requests"] != nil:
+				transcripts = append(transcripts, vsCodeTranscript(v))
+			case v["tabs"] != nil:
+				collect(v["tabs"])
+			case v["composers"] != nil:
+				collect(v["composers"])
+			case v["allComposers"] != nil:
+				collect(v["allComposers"])
+			case v["bubbles"] != nil:
+				transcripts = append(transcripts, cursorTabTranscript(v))
+			case v["conversation"] != nil:
+				transcripts = append(transcripts, cursorComposerTranscript(v))
+			}
+		}
+	}
+	collect(document)
+
+	if len(transcripts) == 0 {
+		return nil, errors.New("no chat sessions found in export")
+	}
+	return transcripts, nil
+}
+
+// vsCodeTranscript converts a VS Code chat export
+func vsCodeTranscript(export map[string]interface{}) transcript {
+	session := transcript{sessionID: stringField(export, "sessionId"), title: stringField(export, "customTitle")}
+
+	requests, _ := export["requests"].([]interface{})
+	for _, item := range requests {
+		request, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		timestamp := unixMillisTime(numberField(request, "timestamp"))
+		message, _ := request["message"].(map[string]interface{})
+		if text := strings.TrimSpace(stringField(message, "text")); text != "" {
+			session.turns = append(session.turns, transcriptTurn{role: transcriptRoleUser, text: text, timestamp: timestamp})
+		}
+
+		reply := transcriptTurn{role: transcriptRoleAssistant, timestamp: timestamp}
+		var texts []string
+		parts, _ := request["response"].([]interface{})
+		for _, item := range parts {
+			part, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch stringField(part, "kind") {
+			case "", "markdownContent":
+				if value := stringField(part, "value"); strings.TrimSpace(value) != "" {
+					texts = append(texts, value)
+				}
+			case "toolInvocationSerialized", "toolInvocation":
+				if tool := stringField(part, "toolId"); tool != "" {
+					reply.tools = append(reply.tools, tool)
+				}
+			case "textEditGroup", "notebookEditGroup":
+				if path := uriPath(part["uri"]); path != "" {
+					reply.files = append(reply.files, path)
+				}
+			}
+		}
+		reply.text = strings.TrimSpace(strings.Join(texts, ""))
+		if reply.text != "" || len(reply.tools) > 0 || len(reply.files) > 0 {
+			session.turns = append(session.turns, reply)
+		}
+	}
+	return session
+}
+
+// cursorTabTranscript converts a Cursor chat tab
+func cursorTabTranscript(tab map[string]interface{}) transcript {
+	session := transcript{sessionID: stringField(tab, "tabId"), title: stringField(tab, "chatTitle")}
+	fallback := unixMillisTime(numberField(tab, "lastSendTime"))
+
+	bubbles, _ := tab["bubbles"].([]interface{})
+	for _, item := range bubbles {
+		bubble, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		role := transcriptRoleAssistant
+		if stringField(bubble, "type") == transcriptRoleUser {
+			role = transcriptRoleUser
+		}
+		text := stringField(bubble, "text")
+		if text == "" {
+			text = stringField(bubble, "rawText")
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			session.turns = append(session.turns, transcriptTurn{role: role, text: text, timestamp: fallback})
+		}
+	}
+	return session
+}
+
+// cursorComposerTranscript converts a Cursor composer (agent) conversation,
+// where bubble type 1 is the user and 2 the assistant
+func cursorComposerTranscript(composer map[string]interface{}) transcript {
+	session := transcript{sessionID: stringField(composer, "composerId"), title: stringField(composer, "name")}
+	fallback := unixMillisTime(numberField(composer, "createdAt"))
+
+	conversation, _ := composer["conversation"].([]interface{})
+	for _, item := range conversation {
+		bubble, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		turn := transcriptTurn{role: transcriptRoleAssistant, text: strings.TrimSpace(stringField(bubble, "text")), timestamp: fallback}
+		if numberField(bubble, "type") == 1 {
+			turn.role = transcriptRoleUser
+		}
+		if timing, ok := bubble["timingInfo"].(map[string]interface{}); ok {
+			if started := numberField(timing, "clientStartTime"); started > 0 {
+				turn.timestamp = unixMillisTime(started)
+			}
+		}
+
+		if tool, ok := bubble["toolFormerData"].(map[string]interface{}); ok {
+			name := stringField(tool, "name")
+			if name != "" {
+				turn.tools = append(turn.tools, name)
+				turn.files = append(turn.files, editedFiles(name, toolArguments(tool["params"], tool["rawArgs"]))...)
+			}
+		}
+		if turn.text != "" || len(turn.tools) > 0 {
+			session.turns = append(session.turns, turn)
+		}
+	}
+	return session
+}
+
+// OpenAI chat completions
+
+// parseOpenAIChatTranscripts reads chat-completion logs: request/response
+// pairs, merged records or bare message lists, as a JSON array or JSON Lines.
+// Each call resends the history, so a record that extends the previous one
+// only adds its new messages; one that does not starts a new session.
+func parseOpenAIChatTranscripts(data string) ([]transcript, error) {
+	records, err := decodeRecords(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse chat-completion log: %w", err)
+	}
+
+	var transcripts []transcript
+	var previous []string
+	for _, record := range records {
+		messages, timestamp := openAIRecordMessages(record)
+		if len(messages) == 0 {
+			continue
+		}
+
+		keys := make([]string, len(messages))
+		for i, message := range messages {
+			encoded, _ := json.Marshal(message)
+			keys[i] = string(encoded)
+		}
+		start := commonPrefix(previous, keys)
+		if start == 0 || len(transcripts) == 0 {
+			sessionID := stringField(record, "session_id")
+			if sessionID == "" {
+				sessionID = stringField(record, "id")
+			}
+			if response, ok := record["response"].(map[string]interface{}); ok && sessionID == "" {
+				sessionID = stringField(response, "id")
+			}
+			transcripts = append(transcripts, transcript{sessionID: sessionID})
+			start = 0
+		}
+		previous = keys
+
+		session := &transcripts[len(transcripts)-1]
+		for _, message := range messages[start:] {
+			if turn, ok := openAITurn(message, timestamp); ok {
+				session.turns = append(session.turns, turn)
+			}
+		}
+	}
+
+	if len(transcripts) == 0 {
+		return nil, errors.New("no chat messages found in log")
+	}
+	return transcripts, nil
+}
+
+// openAIRecordMessages returns the messages of a logged call, the reply
+// included, and when it was made
+func openAIRecordMessages(record map[string]interface{}) ([]map[string]interface{}, time.Time) {
+	request := record
+	if nested, ok := record["request"].(map[string]interface{}); ok {
+		request = nested
+	}
+	response := record
+	if nested, ok := record["response"].(map[string]interface{}); ok {
+		response = nested
+	}
+
+	var messages []map[string]interface{}
+	items, _ := request["messages"].([]interface{})
+	for _, item := range items {
+		if message, ok := item.(map[string]interface{}); ok {
+			messages = append(messages, message)
+		}
+	}
+	if choices, ok := response["choices"].([]interface{}); ok && len(choices) > 0 {
+		if choice, ok := choices[0].(map[string]interface{}); ok {
+			if message, ok := choice["message"].(map[string]interface{}); ok {
+				messages = append(messages, message)
+			}
+		}
+	}
+
+	timestamp := unixSecondsTime(numberField(response, "created"))
+	if timestamp.IsZero() {
+		timestamp = parseTranscriptTime(stringField(record, "timestamp"))
+	}
+	if timestamp.IsZero() {
+		timestamp = unixSecondsTime(numberField(record, "timestamp"))
+	}
+	return messages, timestamp
+}
+
+// openAITurn converts a chat-completion message. System prompts and tool
+// results are left out; the calls that produced results are kept.
+func openAITurn(message map[string]interface{}, timestamp time.Time) (transcriptTurn, bool) {
+	role := stringField(message, "role")
+	if role != transcriptRoleUser && role != transcriptRoleAssistant {
+		return transcriptTurn{}, false
+	}
+
+	turn := transcriptTurn{role: role, text: strings.TrimSpace(messageText(message["content"])), timestamp: timestamp}
+	calls, _ := message["tool_calls"].([]interface{})
+	if legacy, ok := message["function_call"]; ok {
+		calls = append(calls, map[string]interface{}{"function": legacy})
+	}
+	for _, item := range calls {
+		call, _ := item.(map[string]interface{})
+		function, _ := call["function"].(map[string]interface{})
+		name := stringField(function, "name")
+		if name == "" {
+			continue
+		}
+		turn.tools = append(turn.tools, name)
+		turn.files = append(turn.files, editedFiles(name, toolArguments(nil, function["arguments"]))...)
+	}
+	return turn, turn.text != "" || len(turn.tools) > 0
+}
+
+// messageText returns the text of a content string or content-part array
+func messageText(content interface{}) string {
+	switch value := content.(type) {
+	case string:
+		return value
+	case []interface{}:
+		var texts []string
+		for _, item := range value {
+			if part, ok := item.(map[string]interface{}); ok && stringField(part, "type") == "text" {
+				texts = append(texts, stringField(part, "text"))
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+	return ""
+}
+
+// Shared helpers
+
+// decodeRecords reads a JSON array, a single object or JSON Lines
+func decodeRecords(data string) ([]map[string]interface{}, error) {
+	data = strings.TrimSpace(data)
+
+	var records []map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &records); err == nil {
+		return records, nil
+	}
+	var single map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &single); err == nil {
+		return []map[string]interface{}{single}, nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTranscriptLineSize)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// editedFiles returns the files a tool call changed, judged by the tool name
+func editedFiles(toolName string, arguments map[string]interface{}) []string {
+	name := strings.ToLower(toolName)
+	edits := false
+	for _, keyword := range editToolKeywords {
+		if strings.Contains(name, keyword) {
+			edits = true
+			break
+		}
+	}
+	if !edits {
+		return nil
+	}
+
+	for _, key := range pathArgumentKeys {
+		if path := uriPath(arguments[key]); path != "" {
+			return []string{path}
+		}
+	}
+	return nil
+}
+
+// toolArguments returns parsed tool arguments, from an object or the JSON
+// string tools often carry them in
+func toolArguments(params, raw interface{}) map[string]interface{} {
+	if arguments, ok := params.(map[string]interface{}); ok {
+		return arguments
+	}
+	for _, value := range []interface{}{params, raw} {
+		if text, ok := value.(string); ok {
+			var arguments map[string]interface{}
+			if json.Unmarshal([]byte(text), &arguments) == nil {
+				return arguments
+			}
+		}
+	}
+	if arguments, ok := raw.(map[string]interface{}); ok {
+		return arguments
+	}
+	return nil
+}
+
+// uriPath returns a file path from a string or a VS Code URI object
+func uriPath(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimPrefix(v, "file://")
+	case map[string]interface{}:
+		if path := stringField(v, "fsPath"); path != "" {
+			return path
+		}
+		return stringField(v, "path")
+	}
+	return ""
+}
+
+// stringField returns fields[key] when it is a string
+func stringField(fields map[string]interface{}, key string) string {
+	value, _ := fields[key].(string)
+	return value
+}
+
+// numberField returns fields[key] as a float, accepting numeric strings
+func numberField(fields map[string]interface{}, key string) float64 {
+	switch value := fields[key].(type) {
+	case float64:
+		return value
+	case string:
+		number, _ := strconv.ParseFloat(value, 64)
+		return number
+	}
+	return 0
+}
+
+// parseTranscriptTime parses an RFC 3339 timestamp, returning zero when it
+// is missing or malformed
+func parseTranscriptTime(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed
+}
+
+// unixSecondsTime converts fractional Unix seconds; zero stays zero
+func unixSecondsTime(seconds float64) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))).UTC()
+}
+
+// unixMillisTime converts Unix milliseconds; zero stays zero
+func unixMillisTime(millis float64) time.Time {
+	if millis <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(millis)).UTC()
+}
+
+// commonPrefix returns how many leading entries a and b share
+func commonPrefix(a, b []string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// appendUnique appends values not already in list
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// sortTranscriptsByStart orders sessions by their first message, so chunks
+// are stored oldest first
+func sortTranscriptsByStart(transcripts []transcript) {
+	start := func(session transcript) time.Time {
+		for _, turn := range session.turns {
+			if !turn.timestamp.IsZero() {
+				return turn.timestamp
+			}
+		}
+		return time.Time{}
+	}
+	sort.SliceStable(transcripts, func(i, j int) bool {
+		return start(transcripts[i]).Before(start(transcripts[j]))
+	})
+}
+
+// importTranscript imports assistant session transcripts, one chunk per
+// exchange
+func (imp *Importer) importTranscript(_ context.Context, data string, options *ImportOptions, result *ImportResult) (*ImportResult, error) {
+	transcripts, err := parseTranscripts(data, options.Format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s transcript: %w", options.Format, err)
+	}
+	sortTranscriptsByStart(transcripts)
+
+	return imp.processChunks(imp.transcriptChunks(transcripts, options.Format, options), options, result)
+}
+
+// parseTranscriptContent parses a transcript file from an archive
+func (imp *Importer) parseTranscriptContent(content string, format ImportFormat, options *ImportOptions, filename string) ([]types.ConversationChunk, error) {
+	transcripts, err := parseTranscripts(content, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s transcript from file %s: %w", format, filename, err)
+	}
+	sortTranscriptsByStart(transcripts)
+
+	return imp.transcriptChunks(transcripts, format, options), nil
+}
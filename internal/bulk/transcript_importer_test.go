@@ -0,0 +1,175 @@
+package bulk
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"lerian-mcp-memory/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const claudeCodeSession = `{"type":"summary","summary":"Fix flaky cache test","leafUuid":"l1"}
+{"type":"user","sessionId":"s-1","cwd":"/work/api","gitBranch":"fix/cache","timestamp":"2025-03-01T10:00:00.000Z","message":{"role":"user","content":"The cache test is flaky"}}
+{"type":"assistant","sessionId":"s-1","timestamp":"2025-03-01T10:00:05.000Z","message":{"role":"assistant","content":[{"type":"text","text":"Let me look at it."},{"type":"tool_use","name":"Read","input":{"file_path":"/work/api/cache_test.go"}}]}}
+{"type":"user","sessionId":"s-1","timestamp":"2025-03-01T10:00:06.000Z","message":{"role":"user","content":[{"type":"tool_result","content":"package cache"}]}}
+{"type":"assistant","sessionId":"s-1","timestamp":"2025-03-01T10:00:09.000Z","message":{"role":"assistant","content":[{"type":"tool_use","name":"Edit","input":{"file_path":"/work/api/cache_test.go","old_string":"a","new_string":"b"}},{"type":"text","text":"Fixed the sleep."}]}}
+{"type":"user","sessionId":"s-1","isMeta":true,"timestamp":"2025-03-01T10:01:00.000Z","message":{"role":"user","content":"<command-name>/clear</command-name>"}}
+{"type":"user","sessionId":"s-1","timestamp":"2025-03-01T10:02:00.000Z","message":{"role":"user","content":"Thanks, what about the docs?"}}
+{"type":"assistant","sessionId":"s-1","timestamp":"2025-03-01T10:02:03.000Z","message":{"role":"assistant","content":[{"type":"text","text":"The docs are current."}]}}`
+
+const chatGPTExport = `[{
+  "title": "Retry design",
+  "conversation_id": "c-1",
+  "create_time": 1740823200.5,
+  "current_node": "n4",
+  "mapping": {
+    "root": {"parent": null, "message": null},
+    "n0": {"parent": "root", "message": {"author": {"role": "system"}, "content": {"content_type": "text", "parts": [""]}, "metadata": {"is_visually_hidden_from_conversation": true}}},
+    "n1": {"parent": "n0", "message": {"author": {"role": "user"}, "create_time": 1740823200.5, "content": {"content_type": "text", "parts": ["How should retries back off?"]}}},
+    "n2": {"parent": "n1", "message": {"author": {"role": "assistant"}, "recipient": "python", "create_time": 1740823210, "content": {"content_type": "code", "text": "print(2**3)"}}},
+    "n3": {"parent": "n2", "message": {"author": {"role": "tool", "name": "python"}, "create_time": 1740823211, "content": {"content_type": "execution_output", "text": "8"}}},
+    "n4": {"parent": "n3", "message": {"author": {"role": "assistant"}, "recipient": "all", "create_time": 1740823215, "content": {"content_type": "text", "parts": ["Use exponential backoff with jitter."]}}},
+    "dead": {"parent": "n1", "message": {"author": {"role": "assistant"}, "content": {"content_type": "text", "parts": ["An abandoned draft"]}}}
+  }
+}]`
+
+const vsCodeExport = `{
+  "sessionId": "vs-1",
+  "requests": [{
+    "timestamp": 1740823200000,
+    "message": {"text": "Rename the handler"},
+    "response": [
+      {"value": "Renaming it now."},
+      {"kind": "toolInvocationSerialized", "toolId": "copilot_replaceString"},
+      {"kind": "textEditGroup", "uri": {"fsPath": "/work/web/handler.ts", "path": "/work/web/handler.ts"}}
+    ]
+  }]
+}`
+
+const cursorComposerExport = `{
+  "composerId": "cmp-1",
+  "name": "Add pagination",
+  "createdAt": 1740823200000,
+  "conversation": [
+    {"type": 1, "text": "Add pagination to the list endpoint"},
+    {"type": 2, "text": "", "toolFormerData": {"name": "edit_file", "rawArgs": "{\"target_file\": \"api/list.go\", \"code_edit\": \"...\"}"}},
+    {"type": 2, "text": "Pagination is in place."}
+  ]
+}`
+
+const openAIChatLog = `{"request":{"model":"gpt-4o","messages":[{"role":"system","content":"You are helpful"},{"role":"user","content":"Write the config file"}]},"response":{"id":"chatcmpl-1","created":1740823200,"choices":[{"message":{"role":"assistant","content":null,"tool_calls":[{"id":"t1","type":"function","function":{"name":"write_file","arguments":"{\"path\":\"config.yaml\",\"content\":\"a: 1\"}"}}]}}]}}
+{"request":{"model":"gpt-4o","messages":[{"role":"system","content":"You are helpful"},{"role":"user","content":"Write the config file"},{"role":"assistant","content":null,"tool_calls":[{"id":"t1","type":"function","function":{"name":"write_file","arguments":"{\"path\":\"config.yaml\",\"content\":\"a: 1\"}"}}]},{"role":"tool","tool_call_id":"t1","content":"ok"}]},"response":{"created":1740823205,"choices":[{"message":{"role":"assistant","content":"Wrote config.yaml."}}]}}
+{"request":{"model":"gpt-4o","messages":[{"role":"user","content":"Unrelated question"}]},"response":{"id":"chatcmpl-3","created":1740830000,"choices":[{"message":{"role":"assistant","content":"Unrelated answer"}}]}}`
+
+func importTranscript(t *testing.T, data string, format ImportFormat) *ImportResult {
+	t.Helper()
+
+	options := &ImportOptions{
+		Format:         format,
+		Repository:     "test-repo",
+		DefaultTags:    []string{"backfill"},
+		ConflictPolicy: ConflictPolicySkip,
+		ValidateChunks: true,
+	}
+	result, err := NewImporter(nil).Import(context.Background(), data, options)
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	return result
+}
+
+func TestImportClaudeCodeSession(t *testing.T) {
+	result := importTranscript(t, claudeCodeSession, FormatClaudeCode)
+	require.Len(t, result.Chunks, 2, "tool results and meta records do not start exchanges")
+
+	first := result.Chunks[0]
+	assert.Equal(t, "s-1", first.SessionID)
+	assert.Equal(t, types.ChunkTypeCodeChange, first.Type)
+	assert.Equal(t, time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), first.Timestamp)
+	assert.Equal(t, []string{"Read", "Edit"}, first.Metadata.ToolsUsed)
+	assert.Equal(t, []string{"/work/api/cache_test.go"}, first.Metadata.FilesModified)
+	assert.Equal(t, "fix/cache", first.Metadata.Branch)
+	assert.Equal(t, "/work/api", first.Metadata.ExtendedMetadata[types.EMKeyWorkingDir])
+	assert.Equal(t, "Fix flaky cache test", first.Metadata.ExtendedMetadata["transcript_title"])
+	assert.Contains(t, first.Content, "User: The cache test is flaky")
+	assert.Contains(t, first.Content, "Assistant: Fixed the sleep.")
+	assert.NotContains(t, first.Content, "package cache", "tool output is not stored")
+	assert.Contains(t, first.Metadata.Tags, "source:claude_code")
+	assert.Contains(t, first.Metadata.Tags, "backfill")
+
+	second := result.Chunks[1]
+	assert.Equal(t, types.ChunkTypeSolution, second.Type)
+	assert.Empty(t, second.Metadata.FilesModified)
+	assert.NotContains(t, second.Content, "/clear")
+}
+
+func TestImportChatGPTExport(t *testing.T) {
+	result := importTranscript(t, chatGPTExport, FormatChatGPT)
+	require.Len(t, result.Chunks, 1)
+
+	chunk := result.Chunks[0]
+	assert.Equal(t, "c-1", chunk.SessionID)
+	assert.Equal(t, time.Unix(1740823200, 500000000).UTC(), chunk.Timestamp)
+	assert.Equal(t, []string{"python"}, chunk.Metadata.ToolsUsed)
+	assert.Contains(t, chunk.Content, "Use exponential backoff with jitter.")
+	assert.NotContains(t, chunk.Content, "abandoned draft", "only the current branch is imported")
+	assert.Equal(t, "Retry design", chunk.Metadata.ExtendedMetadata["transcript_title"])
+}
+
+func TestImportCursorAndVSCodeExports(t *testing.T) {
+	result := importTranscript(t, vsCodeExport, FormatCursor)
+	require.Len(t, result.Chunks, 1)
+	assert.Equal(t, "vs-1", result.Chunks[0].SessionID)
+	assert.Equal(t, []string{"copilot_replaceString"}, result.Chunks[0].Metadata.ToolsUsed)
+	assert.Equal(t, []string{"/work/web/handler.ts"}, result.Chunks[0].Metadata.FilesModified)
+	assert.Equal(t, time.UnixMilli(1740823200000).UTC(), result.Chunks[0].Timestamp)
+
+	result = importTranscript(t, cursorComposerExport, FormatCursor)
+	require.Len(t, result.Chunks, 1)
+	assert.Equal(t, "cmp-1", result.Chunks[0].SessionID)
+	assert.Equal(t, []string{"edit_file"}, result.Chunks[0].Metadata.ToolsUsed)
+	assert.Equal(t, []string{"api/list.go"}, result.Chunks[0].Metadata.FilesModified)
+	assert.Contains(t, result.Chunks[0].Content, "Pagination is in place.")
+}
+
+func TestImportOpenAIChatLog(t *testing.T) {
+	result := importTranscript(t, openAIChatLog, FormatOpenAIChat)
+	require.Len(t, result.Chunks, 2, "resent history is imported once and unrelated calls start a new session")
+
+	first := result.Chunks[0]
+	assert.Equal(t, []string{"write_file"}, first.Metadata.ToolsUsed)
+	assert.Equal(t, []string{"config.yaml"}, first.Metadata.FilesModified)
+	assert.Equal(t, time.Unix(1740823200, 0).UTC(), first.Timestamp)
+	assert.Equal(t, 1, strings.Count(first.Content, "Write the config file"))
+	assert.NotContains(t, first.Content, "You are helpful")
+
+	assert.Equal(t, "chatcmpl-1", first.SessionID)
+	assert.Equal(t, "chatcmpl-3", result.Chunks[1].SessionID)
+	assert.Contains(t, result.Chunks[1].Content, "Unrelated answer")
+}
+
+func TestDetectTranscriptFormats(t *testing.T) {
+	imp := NewImporter(nil)
+	tests := []struct {
+		name string
+		data string
+		want ImportFormat
+	}{
+		{"claude code", claudeCodeSession, FormatClaudeCode},
+		{"chatgpt", chatGPTExport, FormatChatGPT},
+		{"vs code", vsCodeExport, FormatCursor},
+		{"cursor", cursorComposerExport, FormatCursor},
+		{"openai chat", openAIChatLog, FormatOpenAIChat},
+		{"plain messages stay json", `{"messages":[{"role":"user","content":"hi"}]}`, FormatJSON},
+		{"markdown", "# Notes\n\nSome text", FormatMarkdown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, imp.detectFormat(tt.data))
+		})
+	}
+}
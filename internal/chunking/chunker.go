@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"lerian-mcp-memory/internal/config"
 	"lerian-mcp-memory/internal/embeddings"
+	"lerian-mcp-memory/internal/logging"
+	"lerian-mcp-memory/internal/summarizer"
 	"lerian-mcp-memory/pkg/types"
 	"os"
 	"regexp"
@@ -23,10 +25,15 @@ const (
 	SignificanceLow    = "low"
 )
 
+// abstractiveSummaryMinLength is the content length below which the first
+// line already makes a good summary and no model is asked
+const abstractiveSummaryMinLength = 200
+
 // Service handles the intelligent chunking of conversations
 type Service struct {
 	config           *config.ChunkingConfig
 	embeddingService embeddings.EmbeddingService
+	summarizer       *summarizer.Service
 
 	// State tracking for smart chunking
 	currentContext *types.ChunkingContext
@@ -60,6 +67,12 @@ func NewService(cfg *config.ChunkingConfig, embeddingService embeddings.Embeddin
 	return cs
 }
 
+// SetSummarizer makes chunk and session summaries abstractive. Without one,
+// or when it is unavailable, the extractive summaries are used.
+func (cs *Service) SetSummarizer(s *summarizer.Service) {
+	cs.summarizer = s
+}
+
 // initializePatterns sets up regex patterns for content analysis
 func (cs *Service) initializePatterns() {
 	// Problem identification patterns
@@ -468,10 +481,16 @@ func (cs *Service) assessOutcome(content string) types.Outcome {
 	return types.OutcomeInProgress // Default assumption
 }
 
-// generateSummary creates an AI-powered summary of the content
-func (cs *Service) generateSummary(_ context.Context, content string, _ types.ChunkType) string {
-	// For now, implement a simple extractive summary
-	// In a full implementation, this would use an LLM for abstractive summarization
+// generateSummary asks the summarizer for an abstractive summary of longer
+// content, falling back to the extractive summary
+func (cs *Service) generateSummary(ctx context.Context, content string, _ types.ChunkType) string {
+	if cs.summarizer.Available() && len(content) >= abstractiveSummaryMinLength {
+		summary, err := cs.summarizer.Summarize(ctx, summarizer.KindChunk, content)
+		if err == nil {
+			return summary
+		}
+		logging.Debug("Using extractive chunk summary", "error", err)
+	}
 	return cs.generateSimpleSummary(content)
 }
 
@@ -495,6 +514,12 @@ func (cs *Service) generateSimpleSummary(content string) string {
 	return content
 }
 
+// EmbeddingText returns the text embedded for chunk at ingest, so vectors
+// regenerated later match those of freshly stored chunks
+func (cs *Service) EmbeddingText(chunk *types.ConversationChunk) string {
+	return cs.prepareContentForEmbedding(chunk)
+}
+
 // prepareContentForEmbedding formats content optimally for embedding generation
 func (cs *Service) prepareContentForEmbedding(chunk *types.ConversationChunk) string {
 	parts := []string{}
@@ -517,6 +542,12 @@ func (cs *Service) prepareContentForEmbedding(chunk *types.ConversationChunk) st
 		parts = append(parts, "Repository: "+chunk.Metadata.Repository)
 	}
 
+	// Code blocks are reduced to a marker below; the symbols they define
+	// keep them searchable
+	if symbols := chunk.Metadata.CodeSymbols(); len(symbols) > 0 {
+		parts = append(parts, "Symbols: "+strings.Join(symbols, ", "))
+	}
+
 	if len(chunk.Metadata.Tags) > 0 {
 		// Clean tags too
 		cleanTags := make([]string, len(chunk.Metadata.Tags))
@@ -713,7 +744,9 @@ func (cs *Service) ProcessConversation(ctx context.Context, sessionID, conversat
 	return chunks, nil
 }
 
-// splitConversation splits a conversation into logical segments
+// splitConversation splits a conversation into logical segments. Fenced
+// code blocks are never split on the boundaries below; one too long for a
+// chunk is cut between declarations instead of through a function body.
 func (cs *Service) splitConversation(conversation string) []string {
 	segments := []string{}
 	currentSegment := ""
@@ -727,7 +760,17 @@ func (cs *Service) splitConversation(conversation string) []string {
 		regexp.MustCompile(`^(Step|Task|Problem|Solution)[\s:]`),
 	}
 
-	for i, line := range lines {
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		// Take a fenced code block as a unit
+		if fence := fencePattern.FindStringSubmatch(line); fence != nil {
+			end := closingFence(lines, i, fence[1])
+			segments, currentSegment = cs.addCodeBlock(segments, currentSegment, strings.Join(lines[i:end+1], "\n"))
+			i = end
+			continue
+		}
+
 		// Check if this line marks a boundary
 		isBoundary := false
 		for _, pattern := range boundaryPatterns {
@@ -766,6 +809,57 @@ func (cs *Service) splitConversation(conversation string) []string {
 	return segments
 }
 
+// closingFence returns the line closing the fence opened at start, or the
+// last line when it is never closed
+func closingFence(lines []string, start int, fence string) int {
+	for i := start + 1; i < len(lines); i++ {
+		if match := fencePattern.FindStringSubmatch(lines[i]); match != nil && strings.HasPrefix(match[1], fence) && match[2] == "" {
+			return i
+		}
+	}
+	return len(lines) - 1
+}
+
+// addCodeBlock adds a fenced block to the segment being built. A block that
+// does not fit starts a new segment, and one longer than a chunk is split
+// into declaration-sized pieces, the first kept with the text introducing it.
+func (cs *Service) addCodeBlock(segments []string, currentSegment, block string) (updatedSegments []string, updatedCurrent string) {
+	maxLength := cs.config.MaxContentLength
+	if len(currentSegment)+len(block) <= maxLength {
+		return segments, currentSegment + block + "\n"
+	}
+
+	if len(block) <= maxLength {
+		if strings.TrimSpace(currentSegment) != "" {
+			segments = append(segments, strings.TrimSpace(currentSegment))
+		}
+		return segments, block + "\n"
+	}
+
+	var pieces []string
+	if blocks := extractCodeBlocks(block); len(blocks) > 0 {
+		pieces = splitCodeBlock(blocks[0], maxLength)
+	}
+	if len(pieces) == 0 {
+		// Nothing to split on; the block becomes a segment of its own
+		if strings.TrimSpace(currentSegment) != "" {
+			segments = append(segments, strings.TrimSpace(currentSegment))
+		}
+		return append(segments, strings.TrimSpace(block)), ""
+	}
+	if strings.TrimSpace(currentSegment) != "" {
+		if len(currentSegment)+len(pieces[0]) <= maxLength {
+			pieces[0] = currentSegment + pieces[0]
+		} else {
+			segments = append(segments, strings.TrimSpace(currentSegment))
+		}
+	}
+	for _, piece := range pieces {
+		segments = append(segments, strings.TrimSpace(piece))
+	}
+	return segments, ""
+}
+
 // createSummaryChunk creates a summary chunk for a group of chunks
 func (cs *Service) createSummaryChunk(ctx context.Context, sessionID string, chunks []types.ConversationChunk, baseMetadata *types.ChunkMetadata) *types.ConversationChunk {
 	if len(chunks) == 0 {
@@ -802,8 +896,13 @@ func (cs *Service) createSessionSummary(ctx context.Context, sessionID string, c
 		typeCounts[chunks[i].Type]++
 	}
 
-	// Build summary content
+	// Build summary content, led by a narrative when a summarizer is set
 	contentParts := []string{"Session Summary:"}
+	if narrative, err := cs.summarizer.SummarizeChunks(ctx, summarizer.KindSession, chunks); err == nil {
+		contentParts = append(contentParts, narrative, "")
+	} else if cs.summarizer.Available() {
+		logging.Debug("Using extractive session summary", "session_id", sessionID, "error", err)
+	}
 	contentParts = append(contentParts, fmt.Sprintf("Total chunks: %d", len(chunks)))
 
 	// Add type breakdown
@@ -1069,6 +1168,16 @@ func (cs *Service) buildExtendedMetadata(content string, metadata *types.ChunkMe
 	learningValue := cs.assessLearningValue(content, impactScore)
 	extended["learning_value"] = learningValue
 
+	// Functions and types defined in code blocks, so a symbol search finds
+	// the chunk that defines it
+	languages, symbols := codeSymbols(content)
+	if len(languages) > 0 {
+		extended[types.EMKeyCodeLanguages] = languages
+	}
+	if len(symbols) > 0 {
+		extended[types.EMKeyCodeSymbols] = symbols
+	}
+
 	return extended
 }
 
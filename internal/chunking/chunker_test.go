@@ -2,9 +2,12 @@ package chunking
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/summarizer"
 	"lerian-mcp-memory/pkg/types"
 )
 
@@ -122,3 +125,86 @@ func TestCreateChunk(t *testing.T) {
 		t.Errorf("Expected chunk type %v, got %v", types.ChunkTypeSolution, chunk.Type)
 	}
 }
+
+// stubSummaryProvider answers every prompt with reply, or fails with err
+type stubSummaryProvider struct {
+	reply string
+	err   error
+	calls int
+}
+
+func (p *stubSummaryProvider) Complete(_ context.Context, _, _ string, _ int) (string, error) {
+	p.calls++
+	return p.reply, p.err
+}
+
+func (p *stubSummaryProvider) Model() string {
+	return "stub-model"
+}
+
+func TestAbstractiveSummaries(t *testing.T) {
+	cfg := &config.ChunkingConfig{MaxContentLength: 1000, TimeThresholdMinutes: 30, FileChangeThreshold: 5}
+	settings := config.DefaultConfig().Summarizer
+	ctx := context.Background()
+	metadata := types.ChunkMetadata{Repository: "test-repo"}
+	long := "Looked into why the nightly export kept timing out. " + strings.Repeat("The query scanned the whole events table. ", 6)
+
+	provider := &stubSummaryProvider{reply: "Nightly export timed out from a full scan of the events table."}
+	cs := NewService(cfg, &MockEmbeddingService{})
+	cs.SetSummarizer(summarizer.NewService(provider, &settings))
+
+	chunk, err := cs.CreateChunk(ctx, "s1", long, &metadata)
+	if err != nil {
+		t.Fatalf("CreateChunk failed: %v", err)
+	}
+	if chunk.Summary != provider.reply {
+		t.Errorf("Expected the abstractive summary, got %q", chunk.Summary)
+	}
+
+	chunk, err = cs.CreateChunk(ctx, "s1", "Short notes keep their first line as summary", &metadata)
+	if err != nil {
+		t.Fatalf("CreateChunk failed: %v", err)
+	}
+	if chunk.Summary != "Short notes keep their first line as summary" || provider.calls != 1 {
+		t.Errorf("Expected short content to skip the summarizer, got %q after %d calls", chunk.Summary, provider.calls)
+	}
+
+	session := cs.createSessionSummary(ctx, "s1", []types.ConversationChunk{*chunk, *chunk}, &metadata)
+	if session == nil || !strings.Contains(session.Content, provider.reply) || !strings.Contains(session.Content, "Total chunks: 2") {
+		t.Errorf("Expected the session summary to lead with the narrative and keep the counts, got %+v", session)
+	}
+
+	// A failing provider falls back to the extractive summary
+	failing := NewService(cfg, &MockEmbeddingService{})
+	failing.SetSummarizer(summarizer.NewService(&stubSummaryProvider{err: errors.New("connection refused")}, &settings))
+	chunk, err = failing.CreateChunk(ctx, "s1", long, &metadata)
+	if err != nil {
+		t.Fatalf("CreateChunk failed: %v", err)
+	}
+	if chunk.Summary != failing.generateSimpleSummary(long) {
+		t.Errorf("Expected the extractive summary, got %q", chunk.Summary)
+	}
+}
+
+func TestSplitConversationBlankCodeBlock(t *testing.T) {
+	cfg := &config.ChunkingConfig{MaxContentLength: 50, TimeThresholdMinutes: 30, FileChangeThreshold: 5}
+	cs := NewService(cfg, &MockEmbeddingService{})
+
+	// Text before a fence holding only blank lines, longer than a chunk
+	conversation := "Here is the output:\n```\n" + strings.Repeat("\n", 80) + "```\nDone."
+	segments := cs.splitConversation(conversation)
+	if len(segments) == 0 {
+		t.Fatal("Expected segments")
+	}
+	if !strings.Contains(strings.Join(segments, "\n"), "Here is the output:") {
+		t.Errorf("Expected the introducing text to be kept, got %q", segments)
+	}
+
+	if pieces := splitLines(strings.Repeat("\n", 80), 50); len(pieces) != 1 {
+		t.Errorf("Expected blank code as one piece, got %d", len(pieces))
+	}
+	var empty []string
+	if _, current := cs.addCodeBlock(empty, "Intro text\n", "```\n"+strings.Repeat("  \n", 40)+"```"); current != "" {
+		t.Errorf("Expected the block to close the segment, got %q", current)
+	}
+}
@@ -0,0 +1,456 @@
+package chunking
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// languageGo is the fence language parsed with go/parser; other languages
+// fall back to declaration patterns
+const languageGo = "go"
+
+// goSnippetPackage is prepended to Go snippets without a package clause so
+// they parse as a file
+const goSnippetPackage = "package snippet\n"
+
+// fencePattern matches an opening or closing code fence and its info string
+var fencePattern = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([\\w+#.-]*)")
+
+// languageAliases maps fence info strings to the language names used below
+var languageAliases = map[string]string{
+	"golang":  languageGo,
+	"py":      "python",
+	"python3": "python",
+	"js":      "javascript",
+	"jsx":     "javascript",
+	"mjs":     "javascript",
+	"node":    "javascript",
+	"ts":      "typescript",
+	"tsx":     "typescript",
+	"rs":      "rust",
+	"rb":      "ruby",
+	"kt":      "kotlin",
+	"kts":     "kotlin",
+	"cs":      "csharp",
+	"c#":      "csharp",
+	"c++":     "cpp",
+	"cc":      "cpp",
+	"cxx":     "cpp",
+	"hpp":     "cpp",
+	"h":       "c",
+}
+
+// declarationPatterns find declarations in languages without a parser. The
+// first group is the declared name.
+var declarationPatterns = map[string][]*regexp.Regexp{
+	languageGo: {
+		regexp.MustCompile(`^func\s+(?:\([^)]*\)\s*)?(\w+)`),
+		regexp.MustCompile(`^type\s+(\w+)`),
+	},
+	"python": {
+		regexp.MustCompile(`^\s*(?:async\s+)?def\s+(\w+)`),
+		regexp.MustCompile(`^\s*class\s+(\w+)`),
+	},
+	"javascript": {
+		regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(\w+)`),
+		regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?class\s+(\w+)`),
+		regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+(\w+)\s*=\s*(?:async\s+)?(?:function|\([^)]*\)\s*=>|\w+\s*=>)`),
+	},
+	"typescript": {
+		regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(\w+)`),
+		regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(\w+)`),
+		regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+(\w+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function|\([^)]*\)\s*(?::[^=]+)?=>|\w+\s*=>)`),
+		regexp.MustCompile(`^\s*(?:export\s+)?(?:interface|enum)\s+(\w+)`),
+		regexp.MustCompile(`^\s*(?:export\s+)?type\s+(\w+)\s*(?:<[^>]*>)?\s*=`),
+	},
+	"rust": {
+		regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?fn\s+(\w+)`),
+		regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:struct|enum|trait|union)\s+(\w+)`),
+	},
+	"ruby": {
+		regexp.MustCompile(`^\s*def\s+(?:self\.)?(\w+[?!]?)`),
+		regexp.MustCompile(`^\s*(?:class|module)\s+(\w+)`),
+	},
+	"java": {
+		regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|final|abstract|sealed)\s+)*(?:class|interface|enum|record)\s+(\w+)`),
+		regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|final|abstract|synchronized|native)\s+)+[\w<>\[\]?,.]+\s+(\w+)\s*\(`),
+	},
+	"kotlin": {
+		regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|open|abstract|sealed|data|enum)\s+)*(?:class|interface|object)\s+(\w+)`),
+		regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|override|open|suspend|inline)\s+)*fun\s+(?:<[^>]*>\s*)?(?:\w+\.)?(\w+)`),
+	},
+	"csharp": {
+		regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|static|sealed|abstract|partial)\s+)*(?:class|interface|struct|enum|record)\s+(\w+)`),
+		regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|static|override|virtual|abstract|async)\s+)+[\w<>\[\]?,.]+\s+(\w+)\s*\(`),
+	},
+	"c": {
+		regexp.MustCompile(`^(?:static\s+|inline\s+|extern\s+)*[A-Za-z_][\w\s\*]*?[\s\*](\w+)\s*\([^;]*$`),
+		regexp.MustCompile(`^\s*(?:typedef\s+)?(?:struct|enum|union)\s+(\w+)`),
+	},
+	"cpp": {
+		regexp.MustCompile(`^(?:static\s+|inline\s+|virtual\s+|extern\s+)*[A-Za-z_][\w\s\*&:<>,]*?[\s\*&]((?:\w+::)*~?\w+)\s*\([^;]*$`),
+		regexp.MustCompile(`^\s*(?:template\s*<[^>]*>\s*)?(?:class|struct|enum(?:\s+class)?|union|namespace)\s+(\w+)`),
+	},
+}
+
+// genericDeclarationPatterns cover unlabelled fences in languages not listed
+// above
+var genericDeclarationPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^\s*(?:export\s+|pub\s+|public\s+|private\s+|static\s+|async\s+)*(?:func|function|def|fn|fun|sub|proc)\s+(\w+)`),
+	regexp.MustCompile(`^\s*(?:export\s+|pub\s+|public\s+|private\s+)*(?:class|struct|interface|trait|enum|module|type)\s+(\w+)`),
+}
+
+// codeBlock is a fenced code block found in a message
+type codeBlock struct {
+	language string
+	code     string
+}
+
+// codeDeclaration is a top-level declaration and the source that defines it,
+// including its doc comment
+type codeDeclaration struct {
+	names []string
+	text  string
+}
+
+// extractCodeBlocks returns the fenced code blocks in content. An unclosed
+// fence runs to the end of the content.
+func extractCodeBlocks(content string) []codeBlock {
+	var blocks []codeBlock
+	var current *codeBlock
+	var fence string
+	var lines []string
+
+	for _, line := range strings.Split(content, "\n") {
+		match := fencePattern.FindStringSubmatch(line)
+		switch {
+		case current == nil && match != nil:
+			current = &codeBlock{language: normalizeLanguage(match[2])}
+			fence = match[1]
+			lines = lines[:0]
+		case current != nil && match != nil && strings.HasPrefix(match[1], fence) && match[2] == "":
+			current.code = strings.Join(lines, "\n")
+			blocks = append(blocks, *current)
+			current = nil
+		case current != nil:
+			lines = append(lines, line)
+		}
+	}
+	if current != nil {
+		current.code = strings.Join(lines, "\n")
+		blocks = append(blocks, *current)
+	}
+
+	for i := range blocks {
+		if blocks[i].language == "" {
+			blocks[i].language = guessLanguage(blocks[i].code)
+		}
+	}
+	return blocks
+}
+
+// normalizeLanguage lowercases a fence info string and resolves aliases
+func normalizeLanguage(info string) string {
+	language := strings.ToLower(strings.TrimSpace(info))
+	if alias, ok := languageAliases[language]; ok {
+		return alias
+	}
+	return language
+}
+
+// guessLanguage recognises Go in unlabelled fences, which is the language
+// this server sees most; other code is matched with the generic patterns
+func guessLanguage(code string) string {
+	for _, line := range strings.Split(code, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "package ") || strings.HasPrefix(line, "func ") {
+			return languageGo
+		}
+	}
+	return ""
+}
+
+// codeSymbols returns the languages of the fenced blocks in content and the
+// names they declare, each sorted and without duplicates
+func codeSymbols(content string) (languages, symbols []string) {
+	seenLanguages := make(map[string]bool)
+	seenSymbols := make(map[string]bool)
+
+	for _, block := range extractCodeBlocks(content) {
+		if block.language != "" && !seenLanguages[block.language] {
+			seenLanguages[block.language] = true
+			languages = append(languages, block.language)
+		}
+		for _, declaration := range splitDeclarations(block) {
+			for _, name := range declaration.names {
+				if !seenSymbols[name] {
+					seenSymbols[name] = true
+					symbols = append(symbols, name)
+				}
+			}
+		}
+	}
+
+	sort.Strings(languages)
+	sort.Strings(symbols)
+	return languages, symbols
+}
+
+// splitDeclarations cuts a code block at its top-level declarations. Go is
+// parsed; other languages are split where a declaration pattern matches an
+// unindented line. Code before the first declaration stays with it.
+func splitDeclarations(block codeBlock) []codeDeclaration {
+	if block.language == languageGo {
+		if declarations, ok := splitGoDeclarations(block.code); ok {
+			return declarations
+		}
+	}
+	return splitHeuristicDeclarations(block.code, patternsFor(block.language))
+}
+
+// patternsFor returns the declaration patterns of language
+func patternsFor(language string) []*regexp.Regexp {
+	if patterns, ok := declarationPatterns[language]; ok {
+		return patterns
+	}
+	return genericDeclarationPatterns
+}
+
+// splitGoDeclarations parses code as a Go file, adding a package clause to
+// snippets without one. It reports false when the code does not parse, for
+// example a few statements pasted without their function.
+func splitGoDeclarations(code string) ([]codeDeclaration, bool) {
+	source, offset := code, 0
+	if !hasPackageClause(code) {
+		source, offset = goSnippetPackage+code, len(goSnippetPackage)
+	}
+
+	fileSet := token.NewFileSet()
+	file, err := parser.ParseFile(fileSet, "", source, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil || len(file.Decls) == 0 {
+		return nil, false
+	}
+
+	var starts []int
+	var names [][]string
+	for _, decl := range file.Decls {
+		declNames := goDeclarationNames(decl)
+		if len(declNames) == 0 && len(starts) > 0 {
+			// Imports and blank declarations stay with what precedes them
+			continue
+		}
+		start := fileSet.Position(declStart(decl)).Offset - offset
+		if len(starts) == 0 {
+			start = 0
+		}
+		starts = append(starts, start)
+		names = append(names, declNames)
+	}
+
+	declarations := make([]codeDeclaration, len(starts))
+	for i, start := range starts {
+		end := len(code)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		declarations[i] = codeDeclaration{names: names[i], text: strings.TrimRight(code[start:end], "\n")}
+	}
+	return declarations, true
+}
+
+// hasPackageClause reports whether code starts with a package clause after
+// leading comments
+func hasPackageClause(code string) bool {
+	for _, line := range strings.Split(code, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		return strings.HasPrefix(line, "package ")
+	}
+	return false
+}
+
+// declStart returns where decl begins, including its doc comment
+func declStart(decl ast.Decl) token.Pos {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Doc != nil {
+			return d.Doc.Pos()
+		}
+	case *ast.GenDecl:
+		if d.Doc != nil {
+			return d.Doc.Pos()
+		}
+	}
+	return decl.Pos()
+}
+
+// goDeclarationNames returns the functions and types decl declares. Methods
+// are recorded both bare and qualified by their receiver type.
+func goDeclarationNames(decl ast.Decl) []string {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv != nil && len(d.Recv.List) > 0 {
+			if receiver := receiverTypeName(d.Recv.List[0].Type); receiver != "" {
+				return []string{d.Name.Name, receiver + "." + d.Name.Name}
+			}
+		}
+		return []string{d.Name.Name}
+	case *ast.GenDecl:
+		if d.Tok != token.TYPE {
+			return nil
+		}
+		var names []string
+		for _, spec := range d.Specs {
+			if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+				names = append(names, typeSpec.Name.Name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// receiverTypeName returns the type name of a method receiver
+func receiverTypeName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(e.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(e.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+// splitHeuristicDeclarations starts a declaration at every unindented line a
+// pattern matches, pulling comment and decorator lines directly above it
+// along. Indented matches, such as methods, are named but not split off.
+func splitHeuristicDeclarations(code string, patterns []*regexp.Regexp) []codeDeclaration {
+	lines := strings.Split(code, "\n")
+	var declarations []codeDeclaration
+	current := codeDeclaration{}
+	start := 0
+
+	for i, line := range lines {
+		name := matchDeclaration(line, patterns)
+		if name == "" {
+			continue
+		}
+		if indented(line) || i == 0 {
+			current.names = append(current.names, name)
+			continue
+		}
+
+		boundary := i
+		for boundary > start && isLeadingComment(lines[boundary-1]) {
+			boundary--
+		}
+		if boundary > start {
+			current.text = strings.Join(lines[start:boundary], "\n")
+			declarations = append(declarations, current)
+			current = codeDeclaration{}
+			start = boundary
+		}
+		current.names = append(current.names, name)
+	}
+
+	current.text = strings.Join(lines[start:], "\n")
+	return append(declarations, current)
+}
+
+// matchDeclaration returns the name declared on line, if any
+func matchDeclaration(line string, patterns []*regexp.Regexp) string {
+	for _, pattern := range patterns {
+		if match := pattern.FindStringSubmatch(line); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// indented reports whether line starts with whitespace
+func indented(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t')
+}
+
+// isLeadingComment reports whether line documents or decorates the
+// declaration below it
+func isLeadingComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range []string{"//", "#", "/*", "*", "@", "--", ";;"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitCodeBlock cuts a block longer than maxLength into fenced pieces that
+// each hold whole declarations. A declaration longer than maxLength is kept
+// whole rather than cut through its body; code with no declarations to cut
+// at is split on lines.
+func splitCodeBlock(block codeBlock, maxLength int) []string {
+	declarations := splitDeclarations(block)
+	if len(declarations) < 2 {
+		return fenceAll(block.language, splitLines(block.code, maxLength))
+	}
+
+	var pieces []string
+	current := ""
+	for _, declaration := range declarations {
+		if current != "" && len(current)+len(declaration.text)+1 > maxLength {
+			pieces = append(pieces, current)
+			current = ""
+		}
+		if current != "" {
+			current += "\n"
+		}
+		current += declaration.text
+	}
+	if strings.TrimSpace(current) != "" {
+		pieces = append(pieces, current)
+	}
+	return fenceAll(block.language, pieces)
+}
+
+// splitLines groups lines into pieces of at most maxLength
+func splitLines(code string, maxLength int) []string {
+	var pieces []string
+	current := ""
+	for _, line := range strings.Split(code, "\n") {
+		if current != "" && len(current)+len(line)+1 > maxLength {
+			pieces = append(pieces, current)
+			current = ""
+		}
+		if current != "" {
+			current += "\n"
+		}
+		current += line
+	}
+	if strings.TrimSpace(current) != "" {
+		pieces = append(pieces, current)
+	}
+	if len(pieces) == 0 {
+		// Blank code has no lines to split on; keep it as one piece
+		return []string{code}
+	}
+	return pieces
+}
+
+// fenceAll wraps each piece in a fence labelled with language
+func fenceAll(language string, pieces []string) []string {
+	fenced := make([]string, len(pieces))
+	for i, piece := range pieces {
+		fenced[i] = "```" + language + "\n" + strings.Trim(piece, "\n") + "\n```"
+	}
+	return fenced
+}
@@ -0,0 +1,144 @@
+package chunking
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/pkg/types"
+)
+
+const goSnippet = "```go\n" + `package store
+
+import "context"
+
+// Store keeps chunks
+type Store struct {
+	items map[string]string
+}
+
+// Get returns an item
+func (s *Store) Get(ctx context.Context, id string) string {
+	return s.items[id]
+}
+
+// NewStore creates a store
+func NewStore() *Store {
+	return &Store{items: map[string]string{}}
+}
+` + "```"
+
+func TestCodeSymbolsGo(t *testing.T) {
+	languages, symbols := codeSymbols("Here is the store:\n\n" + goSnippet)
+
+	if strings.Join(languages, ",") != "go" {
+		t.Errorf("Expected languages [go], got %v", languages)
+	}
+	want := "Get,NewStore,Store,Store.Get"
+	if got := strings.Join(symbols, ","); got != want {
+		t.Errorf("Expected symbols %s, got %s", want, got)
+	}
+}
+
+func TestCodeSymbolsHeuristic(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "python",
+			content: "```py\nclass Cache:\n    def get(self, key):\n        pass\n\nasync def refresh():\n    pass\n```",
+			want:    "Cache,get,refresh",
+		},
+		{
+			name:    "typescript",
+			content: "```ts\nexport interface Chunk { id: string }\nexport const loadChunk = async (id: string) => fetch(id)\nexport function saveChunk(c: Chunk) {}\n```",
+			want:    "Chunk,loadChunk,saveChunk",
+		},
+		{
+			name:    "rust",
+			content: "```rust\npub struct Index;\npub fn search(q: &str) -> Vec<u32> { vec![] }\n```",
+			want:    "Index,search",
+		},
+		{
+			name:    "go snippet that does not parse",
+			content: "```go\nfunc broken( {\n}\ntype Half struct\n```",
+			want:    "Half,broken",
+		},
+		{
+			name:    "unlabelled fence",
+			content: "```\nfunction render() {}\n```",
+			want:    "render",
+		},
+		{
+			name:    "no code",
+			content: "Just talking about func names",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, symbols := codeSymbols(tt.content)
+			if got := strings.Join(symbols, ","); got != tt.want {
+				t.Errorf("Expected symbols %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSplitConversationKeepsDeclarationsWhole(t *testing.T) {
+	cs := NewService(&config.ChunkingConfig{MaxContentLength: 200}, &MockEmbeddingService{})
+
+	conversation := "Human: How should the store look?\n\nAssistant: Like this:\n" + goSnippet + "\nThat covers it."
+	segments := cs.splitConversation(conversation)
+
+	var code []string
+	for _, segment := range segments {
+		if strings.Contains(segment, "```") {
+			code = append(code, segment)
+			if strings.Count(segment, "```") != 2 {
+				t.Errorf("Expected every code segment to be a closed fence, got:\n%s", segment)
+			}
+		}
+	}
+	if len(code) < 2 {
+		t.Fatalf("Expected the oversized block to be split, got %d code segments", len(code))
+	}
+
+	for _, declaration := range []string{"type Store struct {\n\titems map[string]string\n}", "func (s *Store) Get(ctx context.Context, id string) string {\n\treturn s.items[id]\n}", "func NewStore() *Store {\n\treturn &Store{items: map[string]string{}}\n}"} {
+		found := false
+		for _, segment := range code {
+			if strings.Contains(segment, declaration) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected a segment holding the whole declaration:\n%s", declaration)
+		}
+	}
+
+	// Boundary markers inside a fence do not split it
+	segments = cs.splitConversation("```markdown\n### Heading\n1. item\n```")
+	if len(segments) != 1 {
+		t.Errorf("Expected a fenced block to stay whole, got %d segments", len(segments))
+	}
+}
+
+func TestCreateChunkRecordsCodeSymbols(t *testing.T) {
+	cs := NewService(&config.ChunkingConfig{MaxContentLength: 1000}, &MockEmbeddingService{})
+
+	chunk, err := cs.CreateChunk(context.Background(), "session", "Implemented the store:\n"+goSnippet, &types.ChunkMetadata{Repository: "test-repo"})
+	if err != nil {
+		t.Fatalf("CreateChunk failed: %v", err)
+	}
+
+	if got := strings.Join(chunk.Metadata.CodeSymbols(), ","); got != "Get,NewStore,Store,Store.Get" {
+		t.Errorf("Expected code symbols in extended metadata, got %q", got)
+	}
+	if !strings.Contains(cs.EmbeddingText(chunk), "Symbols: Get, NewStore, Store, Store.Get") {
+		t.Error("Expected the embedded text to name the symbols")
+	}
+}
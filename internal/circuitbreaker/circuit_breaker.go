@@ -5,9 +5,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"lerian-mcp-memory/internal/tracing"
 	"math"
 	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // State represents the circuit breaker state
@@ -93,10 +96,15 @@ func (cb *CircuitBreaker) Execute(ctx context.Context, fn func(context.Context)
 	return cb.ExecuteWithFallback(ctx, fn, nil)
 }
 
-// ExecuteWithFallback runs the function with circuit breaker protection and fallback
-func (cb *CircuitBreaker) ExecuteWithFallback(ctx context.Context, fn func(context.Context) error, fallback func(context.Context, error) error) error {
+// ExecuteWithFallback runs the function with circuit breaker protection and
+// fallback. The call is a span carrying the breaker's state.
+func (cb *CircuitBreaker) ExecuteWithFallback(ctx context.Context, fn func(context.Context) error, fallback func(context.Context, error) error) (err error) {
+	ctx, span := tracing.Start(ctx, "circuit_breaker", attribute.String("circuit_breaker.state", cb.getState().String()))
+	defer func() { tracing.End(span, err) }()
+
 	cbErr := cb.canExecute()
 	if cbErr != nil {
+		span.SetAttributes(attribute.Bool("circuit_breaker.rejected", true))
 		atomic.AddInt64(&cb.totalRejections, 1)
 		if fallback != nil {
 			return fallback(ctx, cbErr)
@@ -107,7 +115,7 @@ func (cb *CircuitBreaker) ExecuteWithFallback(ctx context.Context, fn func(conte
 	atomic.AddInt64(&cb.totalRequests, 1)
 
 	// Execute the function
-	err := fn(ctx)
+	err = fn(ctx)
 
 	// Record the result
 	cb.recordResult(err)
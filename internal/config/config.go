@@ -5,119 +5,411 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
+// Storage provider names accepted by Storage.Provider
+const (
+	StorageProviderQdrant   = "qdrant"
+	StorageProviderLocal    = "local"
+	StorageProviderPostgres = "postgres"
+)
+
+// Embedding provider names accepted by Embedding.Provider
+const (
+	EmbeddingProviderOpenAI           = "openai"
+	EmbeddingProviderOpenAICompatible = "openai_compatible"
+	EmbeddingProviderOllama           = "ollama"
+	EmbeddingProviderHash             = "hash"
+)
+
+// Summarizer provider names accepted by Summarizer.Provider
+const (
+	// SummarizerProviderNone keeps the built-in extractive summaries
+	SummarizerProviderNone             = "none"
+	SummarizerProviderOpenAI           = "openai"
+	SummarizerProviderOpenAICompatible = "openai_compatible"
+	SummarizerProviderOllama           = "ollama"
+)
+
+// Redaction actions accepted by Security.Redaction and redaction rules
+const (
+	// RedactionActionBlock refuses to store content that matches
+	RedactionActionBlock = "block"
+	// RedactionActionMask replaces the match with a placeholder
+	RedactionActionMask = "mask"
+	// RedactionActionHash replaces the match with a one-way hash, so equal
+	// values can still be correlated
+	RedactionActionHash = "hash"
+)
+
+// Duplicate policies accepted by Dedupe.Policy and the duplicate_policy
+// option of store_chunk
+const (
+	// DedupePolicyMerge folds the new chunk into the one it duplicates
+	DedupePolicyMerge = "merge"
+	// DedupePolicySupersede stores the new chunk and marks it as
+	// superseding the one it duplicates
+	DedupePolicySupersede = "supersede"
+	// DedupePolicyReject refuses the new chunk
+	DedupePolicyReject = "reject"
+	// DedupePolicyAllow stores the new chunk without checking
+	DedupePolicyAllow = "allow"
+)
+
+// Search modes accepted by Search.DefaultMode and the search_mode option
+const (
+	SearchModeVector  = "vector"
+	SearchModeLexical = "lexical"
+	SearchModeHybrid  = "hybrid"
+)
+
 // Config represents the application configuration
 type Config struct {
-	Server   ServerConfig   `json:"server"`
-	Qdrant   QdrantConfig   `json:"qdrant"`
-	OpenAI   OpenAIConfig   `json:"openai"`
-	Storage  StorageConfig  `json:"storage"`
-	Chunking ChunkingConfig `json:"chunking"`
-	Search   SearchConfig   `json:"search"`
-	Logging  LoggingConfig  `json:"logging"`
+	Server     ServerConfig     `json:"server" yaml:"server"`
+	Qdrant     QdrantConfig     `json:"qdrant" yaml:"qdrant"`
+	OpenAI     OpenAIConfig     `json:"openai" yaml:"openai"`
+	Embedding  EmbeddingConfig  `json:"embedding" yaml:"embedding"`
+	Summarizer SummarizerConfig `json:"summarizer" yaml:"summarizer"`
+	Storage    StorageConfig    `json:"storage" yaml:"storage"`
+	Chunking   ChunkingConfig   `json:"chunking" yaml:"chunking"`
+	Search     SearchConfig     `json:"search" yaml:"search"`
+	Decay      DecayConfig      `json:"decay" yaml:"decay"`
+	Dedupe     DedupeConfig     `json:"dedupe" yaml:"dedupe"`
+	Templates  TemplatesConfig  `json:"templates" yaml:"templates"`
+	Security   SecurityConfig   `json:"security" yaml:"security"`
+	Logging    LoggingConfig    `json:"logging" yaml:"logging"`
+	Metrics    MetricsConfig    `json:"metrics" yaml:"metrics"`
+	Tracing    TracingConfig    `json:"tracing" yaml:"tracing"`
 }
 
 // ServerConfig represents server configuration
 type ServerConfig struct {
-	Port         int    `json:"port"`
-	Host         string `json:"host"`
-	ReadTimeout  int    `json:"read_timeout_seconds"`
-	WriteTimeout int    `json:"write_timeout_seconds"`
+	Port         int    `json:"port" yaml:"port"`
+	Host         string `json:"host" yaml:"host"`
+	ReadTimeout  int    `json:"read_timeout_seconds" yaml:"read_timeout_seconds"`
+	WriteTimeout int    `json:"write_timeout_seconds" yaml:"write_timeout_seconds"`
+	// HealthCheckInterval is how often health checks refresh the results
+	// probes read
+	HealthCheckInterval int `json:"health_check_interval_seconds" yaml:"health_check_interval_seconds"`
+	// HealthMaxMemoryMB is the heap size the health check reports as
+	// unhealthy; zero only reports it
+	HealthMaxMemoryMB int `json:"health_max_memory_mb" yaml:"health_max_memory_mb"`
 }
 
 // QdrantConfig represents Qdrant vector database configuration
 type QdrantConfig struct {
-	Host           string       `json:"host"`
-	Port           int          `json:"port"`
-	APIKey         string       `json:"-"` // Never serialize API key
-	UseTLS         bool         `json:"use_tls"`
-	Collection     string       `json:"collection"`
-	Docker         DockerConfig `json:"docker"`
-	HealthCheck    bool         `json:"health_check"`
-	RetryAttempts  int          `json:"retry_attempts"`
-	TimeoutSeconds int          `json:"timeout_seconds"`
+	Host           string       `json:"host" yaml:"host"`
+	Port           int          `json:"port" yaml:"port"`
+	APIKey         string       `json:"-" yaml:"api_key"` // Never serialize API key to JSON
+	UseTLS         bool         `json:"use_tls" yaml:"use_tls"`
+	Collection     string       `json:"collection" yaml:"collection"`
+	Docker         DockerConfig `json:"docker" yaml:"docker"`
+	HealthCheck    bool         `json:"health_check" yaml:"health_check"`
+	RetryAttempts  int          `json:"retry_attempts" yaml:"retry_attempts"`
+	TimeoutSeconds int          `json:"timeout_seconds" yaml:"timeout_seconds"`
+	VectorSize     int          `json:"vector_size" yaml:"vector_size"`
 }
 
 // DockerConfig represents Docker-specific configuration
 type DockerConfig struct {
-	Enabled       bool   `json:"enabled"`
-	ContainerName string `json:"container_name"`
-	VolumePath    string `json:"volume_path"`
-	Image         string `json:"image"`
+	Enabled       bool   `json:"enabled" yaml:"enabled"`
+	ContainerName string `json:"container_name" yaml:"container_name"`
+	VolumePath    string `json:"volume_path" yaml:"volume_path"`
+	Image         string `json:"image" yaml:"image"`
 }
 
 // OpenAIConfig represents OpenAI API configuration
 type OpenAIConfig struct {
-	APIKey         string  `json:"-"` // Never serialize API key
-	EmbeddingModel string  `json:"embedding_model"`
-	MaxTokens      int     `json:"max_tokens"`
-	Temperature    float64 `json:"temperature"`
-	RequestTimeout int     `json:"request_timeout_seconds"`
-	RateLimitRPM   int     `json:"rate_limit_rpm"`
+	APIKey         string  `json:"-" yaml:"api_key"` // Never serialize API key to JSON
+	EmbeddingModel string  `json:"embedding_model" yaml:"embedding_model"`
+	MaxTokens      int     `json:"max_tokens" yaml:"max_tokens"`
+	Temperature    float64 `json:"temperature" yaml:"temperature"`
+	RequestTimeout int     `json:"request_timeout_seconds" yaml:"request_timeout_seconds"`
+	RateLimitRPM   int     `json:"rate_limit_rpm" yaml:"rate_limit_rpm"`
+}
+
+// EmbeddingConfig selects the embedding provider. The openai provider reads
+// its key and model from OpenAIConfig; the self-hosted providers use the
+// fields below. Dimension may be left at 0 for models with a known size.
+type EmbeddingConfig struct {
+	Provider       string `json:"provider" yaml:"provider"`
+	Model          string `json:"model" yaml:"model"`
+	BaseURL        string `json:"base_url" yaml:"base_url"`
+	APIKey         string `json:"-" yaml:"api_key"` // Never serialize API key to JSON
+	Dimension      int    `json:"dimension" yaml:"dimension"`
+	RequestTimeout int    `json:"request_timeout_seconds" yaml:"request_timeout_seconds"`
+	RateLimitRPM   int    `json:"rate_limit_rpm" yaml:"rate_limit_rpm"`
+}
+
+// SummarizerConfig selects the language model that writes chunk, session
+// and decay summaries. The openai provider falls back to OpenAIConfig's key;
+// ollama talks to its OpenAI-compatible endpoint and defaults BaseURL and
+// Model. Summaries fall back to the extractive heuristics when the provider
+// is none or fails.
+type SummarizerConfig struct {
+	Provider string `json:"provider" yaml:"provider"`
+	Model    string `json:"model" yaml:"model"`
+	BaseURL  string `json:"base_url" yaml:"base_url"`
+	APIKey   string `json:"-" yaml:"api_key"` // Never serialize API key to JSON
+	// MaxInputTokens caps the text sent per request; longer input is cut,
+	// sharing the budget evenly between chunks
+	MaxInputTokens int `json:"max_input_tokens" yaml:"max_input_tokens"`
+	// MaxOutputTokens caps session and decay summaries; chunk summaries are
+	// kept to a sentence or two
+	MaxOutputTokens int     `json:"max_output_tokens" yaml:"max_output_tokens"`
+	Temperature     float64 `json:"temperature" yaml:"temperature"`
+	RequestTimeout  int     `json:"request_timeout_seconds" yaml:"request_timeout_seconds"`
+	// CacheSize is how many summaries are kept in memory; 0 disables the cache
+	CacheSize int `json:"cache_size" yaml:"cache_size"`
 }
 
 // StorageConfig represents storage configuration
 type StorageConfig struct {
-	Provider       string                `json:"provider"`
-	RetentionDays  int                   `json:"retention_days"`
-	BackupEnabled  bool                  `json:"backup_enabled"`
-	BackupInterval int                   `json:"backup_interval_hours"`
-	Repositories   map[string]RepoConfig `json:"repositories"`
+	Provider       string                `json:"provider" yaml:"provider"`
+	RetentionDays  int                   `json:"retention_days" yaml:"retention_days"`
+	BackupEnabled  bool                  `json:"backup_enabled" yaml:"backup_enabled"`
+	BackupInterval int                   `json:"backup_interval_hours" yaml:"backup_interval_hours"`
+	Repositories   map[string]RepoConfig `json:"repositories" yaml:"repositories"`
+	// RepositoriesFile keeps the per-repository settings changed at runtime
+	RepositoriesFile string `json:"repositories_file" yaml:"repositories_file"`
+	// ReembedStateDir keeps the progress of re-embedding jobs so they can
+	// resume after a restart
+	ReembedStateDir string `json:"reembed_state_dir" yaml:"reembed_state_dir"`
+	// WebhookStateDir keeps webhook subscriptions and undelivered events
+	WebhookStateDir string                `json:"webhook_state_dir" yaml:"webhook_state_dir"`
+	Local           LocalStorageConfig    `json:"local" yaml:"local"`
+	Postgres        PostgresStorageConfig `json:"postgres" yaml:"postgres"`
+}
+
+// LocalStorageConfig represents the embedded on-disk vector store configuration
+type LocalStorageConfig struct {
+	DataDir     string `json:"data_dir" yaml:"data_dir"`
+	Collection  string `json:"collection" yaml:"collection"`
+	IndexTables int    `json:"index_tables" yaml:"index_tables"`
+	IndexBits   int    `json:"index_bits" yaml:"index_bits"`
+}
+
+// PostgresStorageConfig represents the PostgreSQL + pgvector store configuration.
+// DSN takes precedence over the individual connection fields when set.
+type PostgresStorageConfig struct {
+	DSN             string `json:"-" yaml:"dsn"` // Never serialize the DSN to JSON; it may hold the password
+	Host            string `json:"host" yaml:"host"`
+	Port            int    `json:"port" yaml:"port"`
+	Database        string `json:"database" yaml:"database"`
+	User            string `json:"user" yaml:"user"`
+	Password        string `json:"-" yaml:"password"` // Never serialize password to JSON
+	SSLMode         string `json:"ssl_mode" yaml:"ssl_mode"`
+	MaxConnections  int    `json:"max_connections" yaml:"max_connections"`
+	VectorDimension int    `json:"vector_dimension" yaml:"vector_dimension"`
 }
 
-// RepoConfig represents repository-specific configuration
+// RepoConfig represents repository-specific configuration. Zero values of
+// the override fields mean the server-wide setting applies.
 type RepoConfig struct {
-	Enabled         bool     `json:"enabled"`
-	Sensitivity     string   `json:"sensitivity"`
-	ExcludePatterns []string `json:"exclude_patterns"`
-	Tags            []string `json:"tags"`
+	Enabled         bool     `json:"enabled" yaml:"enabled"`
+	Sensitivity     string   `json:"sensitivity" yaml:"sensitivity"`
+	ExcludePatterns []string `json:"exclude_patterns" yaml:"exclude_patterns"`
+	Tags            []string `json:"tags" yaml:"tags"`
+	// SimilarityThreshold is the minimum relevance for searches that do not
+	// set one; 0 uses search.default_min_relevance
+	SimilarityThreshold float64 `json:"similarity_threshold" yaml:"similarity_threshold"`
+	// RetentionDays overrides storage.retention_days; 0 uses it
+	RetentionDays int         `json:"retention_days" yaml:"retention_days"`
+	Decay         DecayPolicy `json:"decay" yaml:"decay"`
+	// RedactionRules are applied to this repository's content on top of
+	// security.redaction
+	RedactionRules []RedactionRule `json:"redaction_rules,omitempty" yaml:"redaction_rules"`
+}
+
+// DecayPolicy is a repository's decay override
+type DecayPolicy struct {
+	// Exempt keeps the repository out of decay and retention cleanup
+	Exempt                 bool    `json:"exempt" yaml:"exempt"`
+	SummarizationThreshold float64 `json:"summarization_threshold" yaml:"summarization_threshold"`
+	DeletionThreshold      float64 `json:"deletion_threshold" yaml:"deletion_threshold"`
 }
 
 // ChunkingConfig represents chunking algorithm configuration
 type ChunkingConfig struct {
-	Strategy              string  `json:"strategy"`
-	MinContentLength      int     `json:"min_content_length"`
-	MaxContentLength      int     `json:"max_content_length"`
-	TodoCompletionTrigger bool    `json:"todo_completion_trigger"`
-	FileChangeThreshold   int     `json:"file_change_threshold"`
-	TimeThresholdMinutes  int     `json:"time_threshold_minutes"`
-	SimilarityThreshold   float64 `json:"similarity_threshold"`
+	Strategy              string  `json:"strategy" yaml:"strategy"`
+	MinContentLength      int     `json:"min_content_length" yaml:"min_content_length"`
+	MaxContentLength      int     `json:"max_content_length" yaml:"max_content_length"`
+	TodoCompletionTrigger bool    `json:"todo_completion_trigger" yaml:"todo_completion_trigger"`
+	FileChangeThreshold   int     `json:"file_change_threshold" yaml:"file_change_threshold"`
+	TimeThresholdMinutes  int     `json:"time_threshold_minutes" yaml:"time_threshold_minutes"`
+	SimilarityThreshold   float64 `json:"similarity_threshold" yaml:"similarity_threshold"`
 }
 
 // SearchConfig represents search behavior configuration
 type SearchConfig struct {
-	DefaultMinRelevance      float64 `json:"default_min_relevance"`
-	RelaxedMinRelevance      float64 `json:"relaxed_min_relevance"`
-	BroadestMinRelevance     float64 `json:"broadest_min_relevance"`
-	EnableProgressiveSearch  bool    `json:"enable_progressive_search"`
-	EnableRepositoryFallback bool    `json:"enable_repository_fallback"`
-	MaxRelatedRepos          int     `json:"max_related_repos"`
+	DefaultMinRelevance      float64 `json:"default_min_relevance" yaml:"default_min_relevance"`
+	RelaxedMinRelevance      float64 `json:"relaxed_min_relevance" yaml:"relaxed_min_relevance"`
+	BroadestMinRelevance     float64 `json:"broadest_min_relevance" yaml:"broadest_min_relevance"`
+	EnableProgressiveSearch  bool    `json:"enable_progressive_search" yaml:"enable_progressive_search"`
+	EnableRepositoryFallback bool    `json:"enable_repository_fallback" yaml:"enable_repository_fallback"`
+	MaxRelatedRepos          int     `json:"max_related_repos" yaml:"max_related_repos"`
+	DefaultMode              string  `json:"default_mode" yaml:"default_mode"`
+	RRFK                     int     `json:"rrf_k" yaml:"rrf_k"`
+}
+
+// DecayConfig represents memory decay and cleanup settings
+type DecayConfig struct {
+	// IntervalHours is how often retention cleanup runs
+	IntervalHours int `json:"interval_hours" yaml:"interval_hours"`
+	// Chunks whose decay score falls below these are candidates for
+	// summarization and deletion
+	SummarizationThreshold float64 `json:"summarization_threshold" yaml:"summarization_threshold"`
+	DeletionThreshold      float64 `json:"deletion_threshold" yaml:"deletion_threshold"`
+}
+
+// DedupeConfig controls near-duplicate detection when chunks are stored
+type DedupeConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Policy applies when store_chunk does not set duplicate_policy
+	Policy string `json:"policy" yaml:"policy"`
+	// A chunk duplicates an earlier one when the cosine similarity of their
+	// embeddings or the Jaccard similarity of their word shingles reaches
+	// these thresholds
+	EmbeddingThreshold float64 `json:"embedding_threshold" yaml:"embedding_threshold"`
+	ShingleThreshold   float64 `json:"shingle_threshold" yaml:"shingle_threshold"`
+	// WindowHours limits the comparison to chunks stored this recently
+	WindowHours int `json:"window_hours" yaml:"window_hours"`
+	// Candidates is how many of the most similar chunks are compared
+	Candidates int `json:"candidates" yaml:"candidates"`
+}
+
+// ValidateDedupePolicy checks that policy is merge, supersede, reject or allow
+func ValidateDedupePolicy(policy string) error {
+	switch policy {
+	case DedupePolicyMerge, DedupePolicySupersede, DedupePolicyReject, DedupePolicyAllow:
+		return nil
+	default:
+		return fmt.Errorf("invalid duplicate policy %q: must be merge, supersede, reject or allow", policy)
+	}
+}
+
+// TemplatesConfig represents memory template settings
+type TemplatesConfig struct {
+	// Directory holds team templates as YAML files, loaded next to the
+	// built-in ones. Empty disables custom templates.
+	Directory string `json:"directory,omitempty" yaml:"directory"`
+}
+
+// SecurityConfig represents authentication and access control settings
+type SecurityConfig struct {
+	// AccessControlEnabled requires a bearer token on the HTTP transports and
+	// checks repository grants on every tool call. stdio is not affected.
+	AccessControlEnabled bool `json:"access_control_enabled" yaml:"access_control_enabled"`
+	// AccessControlFile keeps users, hashed tokens and grants
+	AccessControlFile string `json:"access_control_file" yaml:"access_control_file"`
+	// EncryptionEnabled encrypts chunk content, summaries and sensitive
+	// metadata before they are stored. Embeddings are computed on plaintext.
+	EncryptionEnabled bool `json:"encryption_enabled" yaml:"encryption_enabled"`
+	// EncryptionPassphrase wraps the data keys; it is never serialized
+	EncryptionPassphrase string `json:"-" yaml:"encryption_passphrase"`
+	// EncryptionKeyringFile keeps the wrapped data keys
+	EncryptionKeyringFile string `json:"encryption_keyring_file" yaml:"encryption_keyring_file"`
+	// Redaction scrubs secrets and personal data before content is embedded
+	// and stored
+	Redaction RedactionConfig `json:"redaction" yaml:"redaction"`
+}
+
+// RedactionConfig selects what the ingest path scrubs and how
+type RedactionConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// SecretAction applies to the API key, token, private key, password and
+	// high-entropy detectors
+	SecretAction string `json:"secret_action" yaml:"secret_action"`
+	// PIIAction applies to the email, IP address and phone number detectors
+	PIIAction string `json:"pii_action" yaml:"pii_action"`
+	// EntropyThreshold is the Shannon entropy, in bits per character, above
+	// which a long unbroken token counts as a secret; 0 turns it off
+	EntropyThreshold float64 `json:"entropy_threshold" yaml:"entropy_threshold"`
+	// Rules are custom rules applied to every repository
+	Rules []RedactionRule `json:"rules,omitempty" yaml:"rules"`
+}
+
+// RedactionRule is a custom regular expression and what to do with matches.
+// A named group "secret" limits the action to that part of the match.
+type RedactionRule struct {
+	Name    string `json:"name" yaml:"name"`
+	Pattern string `json:"pattern" yaml:"pattern"`
+	Action  string `json:"action" yaml:"action"`
+}
+
+// Validate checks that the rule compiles and names a known action
+func (r *RedactionRule) Validate() error {
+	if r.Name == "" {
+		return errors.New("redaction rule name is required")
+	}
+	if r.Pattern == "" {
+		return fmt.Errorf("redaction rule %s: pattern is required", r.Name)
+	}
+	if _, err := regexp.Compile(r.Pattern); err != nil {
+		return fmt.Errorf("redaction rule %s: invalid pattern: %w", r.Name, err)
+	}
+	return ValidateRedactionAction(r.Action)
+}
+
+// ValidateRedactionAction checks that action is block, mask or hash
+func ValidateRedactionAction(action string) error {
+	switch action {
+	case RedactionActionBlock, RedactionActionMask, RedactionActionHash:
+		return nil
+	default:
+		return fmt.Errorf("invalid redaction action %q: must be block, mask or hash", action)
+	}
 }
 
 // LoggingConfig represents logging configuration
 type LoggingConfig struct {
-	Level      string `json:"level"`
-	Format     string `json:"format"`
-	File       string `json:"file,omitempty"`
-	MaxSize    int    `json:"max_size_mb"`
-	MaxBackups int    `json:"max_backups"`
-	MaxAge     int    `json:"max_age_days"`
+	Level      string `json:"level" yaml:"level"`
+	Format     string `json:"format" yaml:"format"`
+	File       string `json:"file,omitempty" yaml:"file"`
+	MaxSize    int    `json:"max_size_mb" yaml:"max_size_mb"`
+	MaxBackups int    `json:"max_backups" yaml:"max_backups"`
+	MaxAge     int    `json:"max_age_days" yaml:"max_age_days"`
+}
+
+// MetricsConfig represents the Prometheus metrics endpoint
+type MetricsConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Port starts a dedicated metrics listener on the server host, also in
+	// stdio mode. Zero serves metrics only on the HTTP transport's port.
+	Port int    `json:"port" yaml:"port"`
+	Path string `json:"path" yaml:"path"`
+}
+
+// TracingConfig represents OpenTelemetry trace export
+type TracingConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Endpoint is the OTLP/HTTP collector URL, such as
+	// http://localhost:4318. Empty uses OTEL_EXPORTER_OTLP_ENDPOINT or the
+	// exporter's default.
+	Endpoint    string `json:"endpoint" yaml:"endpoint"`
+	ServiceName string `json:"service_name" yaml:"service_name"`
+	// SampleRatio is the share of new traces recorded; traces started by a
+	// caller follow the caller's decision
+	SampleRatio float64 `json:"sample_ratio" yaml:"sample_ratio"`
 }
 
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port:         8080,
-			Host:         "localhost",
-			ReadTimeout:  30,
-			WriteTimeout: 30,
+			Port:                8080,
+			Host:                "localhost",
+			ReadTimeout:         30,
+			WriteTimeout:        30,
+			HealthCheckInterval: 15,
 		},
 		Qdrant: QdrantConfig{
 			Host:           "localhost",
@@ -127,6 +419,7 @@ func DefaultConfig() *Config {
 			HealthCheck:    true,
 			RetryAttempts:  3,
 			TimeoutSeconds: 30,
+			VectorSize:     1536,
 			Docker: DockerConfig{
 				Enabled:       true,
 				ContainerName: "claude-memory-qdrant",
@@ -141,12 +434,43 @@ func DefaultConfig() *Config {
 			RequestTimeout: 60,
 			RateLimitRPM:   60,
 		},
+		Embedding: EmbeddingConfig{
+			Provider:       EmbeddingProviderOpenAI,
+			RequestTimeout: 60,
+			RateLimitRPM:   600,
+		},
+		Summarizer: SummarizerConfig{
+			Provider:        SummarizerProviderNone,
+			MaxInputTokens:  4000,
+			MaxOutputTokens: 300,
+			Temperature:     0.2,
+			RequestTimeout:  30,
+			CacheSize:       1000,
+		},
 		Storage: StorageConfig{
-			Provider:       "qdrant",
-			RetentionDays:  90,
-			BackupEnabled:  false,
-			BackupInterval: 24,
-			Repositories:   make(map[string]RepoConfig),
+			Provider:         StorageProviderQdrant,
+			RetentionDays:    90,
+			BackupEnabled:    false,
+			BackupInterval:   24,
+			Repositories:     make(map[string]RepoConfig),
+			RepositoriesFile: "./data/repositories.json",
+			ReembedStateDir:  "./data/reembed",
+			WebhookStateDir:  "./data/webhooks",
+			Local: LocalStorageConfig{
+				DataDir:     "./data/local",
+				Collection:  "claude_memory",
+				IndexTables: 8,
+				IndexBits:   12,
+			},
+			Postgres: PostgresStorageConfig{
+				Host:            "localhost",
+				Port:            5432,
+				Database:        "mcp_memory",
+				User:            "mcpuser",
+				SSLMode:         "disable",
+				MaxConnections:  10,
+				VectorDimension: 1536,
+			},
 		},
 		Chunking: ChunkingConfig{
 			Strategy:              "smart",
@@ -164,6 +488,33 @@ func DefaultConfig() *Config {
 			EnableProgressiveSearch:  true,
 			EnableRepositoryFallback: true,
 			MaxRelatedRepos:          3,
+			DefaultMode:              SearchModeVector,
+			RRFK:                     60,
+		},
+		Decay: DecayConfig{
+			IntervalHours:          24,
+			SummarizationThreshold: 0.4,
+			DeletionThreshold:      0.1,
+		},
+		Dedupe: DedupeConfig{
+			Enabled:            true,
+			Policy:             DedupePolicyMerge,
+			EmbeddingThreshold: 0.97,
+			ShingleThreshold:   0.7,
+			WindowHours:        24,
+			Candidates:         10,
+		},
+		Security: SecurityConfig{
+			AccessControlEnabled:  false,
+			AccessControlFile:     "./data/access_control.json",
+			EncryptionEnabled:     false,
+			EncryptionKeyringFile: "./data/keyring.json",
+			Redaction: RedactionConfig{
+				Enabled:          true,
+				SecretAction:     RedactionActionMask,
+				PIIAction:        RedactionActionMask,
+				EntropyThreshold: 4.3,
+			},
 		},
 		Logging: LoggingConfig{
 			Level:      "info",
@@ -172,11 +523,43 @@ func DefaultConfig() *Config {
 			MaxBackups: 3,
 			MaxAge:     30,
 		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+		Tracing: TracingConfig{
+			Enabled:     false,
+			ServiceName: "lerian-mcp-memory",
+			SampleRatio: 1.0,
+		},
 	}
 }
 
-// LoadConfig loads configuration from environment variables and defaults
+// LoadConfig loads configuration from defaults, the YAML file named by
+// MCP_MEMORY_CONFIG_FILE (or CONFIG_PATH) if any, and environment variables
 func LoadConfig() (*Config, error) {
+	return LoadConfigFile("")
+}
+
+// LoadConfigFile loads configuration like LoadConfig, reading path instead
+// of the file named in the environment when path is set
+func LoadConfigFile(path string) (*Config, error) {
+	config, err := ResolveConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate configuration
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return config, nil
+}
+
+// ResolveConfig layers defaults, the config file and environment variables
+// without validating the result
+func ResolveConfig(path string) (*Config, error) {
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
 		// Don't fail if .env doesn't exist
@@ -187,14 +570,18 @@ func LoadConfig() (*Config, error) {
 
 	config := DefaultConfig()
 
+	if path == "" {
+		path = ConfigFilePath()
+	}
+	if path != "" {
+		if err := loadFromFile(config, path); err != nil {
+			return nil, err
+		}
+	}
+
 	// Override with environment variables
 	loadFromEnv(config)
 
-	// Validate configuration
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
-	}
-
 	return config, nil
 }
 
@@ -204,7 +591,12 @@ func loadFromEnv(config *Config) {
 	loadQdrantConfig(config)
 	loadStorageAndOtherConfig(config)
 	loadOpenAIConfig(config)
+	loadEmbeddingConfig(config)
+	loadSummarizerConfig(config)
 	loadDecayConfig(config)
+	loadDedupeConfig(config)
+	loadMetricsConfig(config)
+	loadTracingConfig(config)
 	loadIntelligenceConfig(config)
 	loadPerformanceConfig(config)
 }
@@ -232,6 +624,8 @@ func loadServerConfig(config *Config) {
 			config.Server.WriteTimeout = wt
 		}
 	}
+	config.Server.HealthCheckInterval = getIntEnvWithDefault("MCP_MEMORY_HEALTH_CHECK_INTERVAL_SECONDS", config.Server.HealthCheckInterval)
+	config.Server.HealthMaxMemoryMB = getIntEnvWithDefault("MCP_MEMORY_HEALTH_MAX_MEMORY_MB", config.Server.HealthMaxMemoryMB)
 }
 
 // loadQdrantConfig loads Qdrant configuration from environment
@@ -323,6 +717,15 @@ func getIntEnvWithDefault(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getFloatEnvWithDefault(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
 // loadQdrantDockerConfig loads Docker-related Qdrant settings
 func loadQdrantDockerConfig(config *Config) {
 	if dockerEnabled := os.Getenv("MCP_MEMORY_QDRANT_DOCKER_ENABLED"); dockerEnabled != "" {
@@ -344,9 +747,53 @@ func loadQdrantDockerConfig(config *Config) {
 func loadStorageAndOtherConfig(config *Config) {
 	loadStorageConfig(config)
 	loadChunkingConfig(config)
+	loadSearchConfig(config)
+	loadTemplatesConfig(config)
+	loadSecurityConfig(config)
 	loadLoggingConfig(config)
 }
 
+// loadSecurityConfig loads access control and encryption settings from environment
+func loadSecurityConfig(config *Config) {
+	config.Security.AccessControlEnabled = getBoolEnvWithDefault("MCP_MEMORY_ACCESS_CONTROL_ENABLED", config.Security.AccessControlEnabled)
+	if path := os.Getenv("MCP_MEMORY_ACCESS_CONTROL_FILE"); path != "" {
+		config.Security.AccessControlFile = path
+	}
+
+	config.Security.EncryptionEnabled = getBoolEnvWithDefault("MCP_MEMORY_ENCRYPTION_ENABLED", config.Security.EncryptionEnabled)
+	if passphrase := os.Getenv("MCP_MEMORY_ENCRYPTION_PASSPHRASE"); passphrase != "" {
+		config.Security.EncryptionPassphrase = passphrase
+	}
+	if path := os.Getenv("MCP_MEMORY_ENCRYPTION_KEYRING_FILE"); path != "" {
+		config.Security.EncryptionKeyringFile = path
+	}
+
+	redaction := &config.Security.Redaction
+	redaction.Enabled = getBoolEnvWithDefault("MCP_MEMORY_REDACTION_ENABLED", redaction.Enabled)
+	if action := os.Getenv("MCP_MEMORY_REDACTION_SECRET_ACTION"); action != "" {
+		redaction.SecretAction = action
+	}
+	if action := os.Getenv("MCP_MEMORY_REDACTION_PII_ACTION"); action != "" {
+		redaction.PIIAction = action
+	}
+	redaction.EntropyThreshold = getFloatEnvWithDefault("MCP_MEMORY_REDACTION_ENTROPY_THRESHOLD", redaction.EntropyThreshold)
+}
+
+// loadTemplatesConfig loads memory template settings from environment
+func loadTemplatesConfig(config *Config) {
+	if dir := os.Getenv("MCP_MEMORY_TEMPLATES_DIR"); dir != "" {
+		config.Templates.Directory = dir
+	}
+}
+
+// loadSearchConfig loads search configuration from environment
+func loadSearchConfig(config *Config) {
+	if mode := os.Getenv("MCP_MEMORY_SEARCH_DEFAULT_MODE"); mode != "" {
+		config.Search.DefaultMode = mode
+	}
+	config.Search.RRFK = getIntEnvWithDefault("MCP_MEMORY_SEARCH_RRF_K", config.Search.RRFK)
+}
+
 // loadStorageConfig loads storage configuration from environment
 func loadStorageConfig(config *Config) {
 	if provider := os.Getenv("MCP_MEMORY_STORAGE_PROVIDER"); provider != "" {
@@ -367,6 +814,53 @@ func loadStorageConfig(config *Config) {
 			config.Storage.BackupInterval = bi
 		}
 	}
+	if path := os.Getenv("MCP_MEMORY_REPOSITORIES_FILE"); path != "" {
+		config.Storage.RepositoriesFile = path
+	}
+	if dir := os.Getenv("MCP_MEMORY_REEMBED_STATE_DIR"); dir != "" {
+		config.Storage.ReembedStateDir = dir
+	}
+	if dir := os.Getenv("MCP_MEMORY_WEBHOOK_STATE_DIR"); dir != "" {
+		config.Storage.WebhookStateDir = dir
+	}
+	loadLocalStorageConfig(config)
+	loadPostgresStorageConfig(config)
+}
+
+// loadLocalStorageConfig loads embedded local store settings from environment
+func loadLocalStorageConfig(config *Config) {
+	if dataDir := os.Getenv("MCP_MEMORY_LOCAL_DATA_DIR"); dataDir != "" {
+		config.Storage.Local.DataDir = dataDir
+	}
+	if collection := os.Getenv("MCP_MEMORY_LOCAL_COLLECTION"); collection != "" {
+		config.Storage.Local.Collection = collection
+	}
+	config.Storage.Local.IndexTables = getIntEnvWithDefault("MCP_MEMORY_LOCAL_INDEX_TABLES", config.Storage.Local.IndexTables)
+	config.Storage.Local.IndexBits = getIntEnvWithDefault("MCP_MEMORY_LOCAL_INDEX_BITS", config.Storage.Local.IndexBits)
+}
+
+// loadPostgresStorageConfig loads PostgreSQL store settings from environment
+func loadPostgresStorageConfig(config *Config) {
+	pg := &config.Storage.Postgres
+	pg.DSN = getStringEnvWithFallback("MCP_MEMORY_POSTGRES_DSN", "DATABASE_URL", pg.DSN)
+	if host := os.Getenv("MCP_MEMORY_POSTGRES_HOST"); host != "" {
+		pg.Host = host
+	}
+	pg.Port = getIntEnvWithDefault("MCP_MEMORY_POSTGRES_PORT", pg.Port)
+	if database := os.Getenv("MCP_MEMORY_POSTGRES_DATABASE"); database != "" {
+		pg.Database = database
+	}
+	if user := os.Getenv("MCP_MEMORY_POSTGRES_USER"); user != "" {
+		pg.User = user
+	}
+	if password := os.Getenv("MCP_MEMORY_POSTGRES_PASSWORD"); password != "" {
+		pg.Password = password
+	}
+	if sslMode := os.Getenv("MCP_MEMORY_POSTGRES_SSL_MODE"); sslMode != "" {
+		pg.SSLMode = sslMode
+	}
+	pg.MaxConnections = getIntEnvWithDefault("MCP_MEMORY_POSTGRES_MAX_CONNECTIONS", pg.MaxConnections)
+	pg.VectorDimension = getIntEnvWithDefault("MCP_MEMORY_EMBEDDING_DIMENSION", pg.VectorDimension)
 }
 
 // loadChunkingConfig loads chunking configuration from environment
@@ -449,9 +943,92 @@ func loadOpenAIConfig(config *Config) {
 	}
 }
 
+// loadEmbeddingConfig loads embedding provider settings from environment
+func loadEmbeddingConfig(config *Config) {
+	embedding := &config.Embedding
+	if provider := os.Getenv("MCP_MEMORY_EMBEDDING_PROVIDER"); provider != "" {
+		embedding.Provider = provider
+	}
+	if model := os.Getenv("MCP_MEMORY_EMBEDDING_MODEL"); model != "" {
+		embedding.Model = model
+	}
+	if baseURL := os.Getenv("MCP_MEMORY_EMBEDDING_BASE_URL"); baseURL != "" {
+		embedding.BaseURL = baseURL
+	}
+	if apiKey := os.Getenv("MCP_MEMORY_EMBEDDING_API_KEY"); apiKey != "" {
+		embedding.APIKey = apiKey
+	}
+	embedding.Dimension = getIntEnvWithDefault("MCP_MEMORY_EMBEDDING_DIMENSION", embedding.Dimension)
+	embedding.RequestTimeout = getIntEnvWithDefault("MCP_MEMORY_EMBEDDING_REQUEST_TIMEOUT_SECONDS", embedding.RequestTimeout)
+	embedding.RateLimitRPM = getIntEnvWithDefault("MCP_MEMORY_EMBEDDING_RATE_LIMIT_RPM", embedding.RateLimitRPM)
+}
+
+// loadSummarizerConfig loads summarization provider settings from environment
+func loadSummarizerConfig(config *Config) {
+	summarizer := &config.Summarizer
+	if provider := os.Getenv("MCP_MEMORY_SUMMARIZER_PROVIDER"); provider != "" {
+		summarizer.Provider = provider
+	}
+	if model := os.Getenv("MCP_MEMORY_SUMMARIZER_MODEL"); model != "" {
+		summarizer.Model = model
+	}
+	if baseURL := os.Getenv("MCP_MEMORY_SUMMARIZER_BASE_URL"); baseURL != "" {
+		summarizer.BaseURL = baseURL
+	}
+	if apiKey := os.Getenv("MCP_MEMORY_SUMMARIZER_API_KEY"); apiKey != "" {
+		summarizer.APIKey = apiKey
+	}
+	summarizer.MaxInputTokens = getIntEnvWithDefault("MCP_MEMORY_SUMMARIZER_MAX_INPUT_TOKENS", summarizer.MaxInputTokens)
+	summarizer.MaxOutputTokens = getIntEnvWithDefault("MCP_MEMORY_SUMMARIZER_MAX_OUTPUT_TOKENS", summarizer.MaxOutputTokens)
+	summarizer.RequestTimeout = getIntEnvWithDefault("MCP_MEMORY_SUMMARIZER_REQUEST_TIMEOUT_SECONDS", summarizer.RequestTimeout)
+	summarizer.CacheSize = getIntEnvWithDefault("MCP_MEMORY_SUMMARIZER_CACHE_SIZE", summarizer.CacheSize)
+}
+
 // loadDecayConfig loads decay configuration from environment
-func loadDecayConfig(_ *Config) {
-	// Add decay config loading if needed
+func loadDecayConfig(config *Config) {
+	config.Decay.IntervalHours = getIntEnvWithDefault("MCP_MEMORY_DECAY_INTERVAL_HOURS", config.Decay.IntervalHours)
+	config.Decay.SummarizationThreshold = getFloatEnvWithDefault("MCP_MEMORY_DECAY_SUMMARIZATION_THRESHOLD", config.Decay.SummarizationThreshold)
+	config.Decay.DeletionThreshold = getFloatEnvWithDefault("MCP_MEMORY_DECAY_DELETION_THRESHOLD", config.Decay.DeletionThreshold)
+}
+
+// loadDedupeConfig loads near-duplicate detection settings from environment
+func loadDedupeConfig(config *Config) {
+	config.Dedupe.Enabled = getBoolEnvWithDefault("MCP_MEMORY_DEDUPE_ENABLED", config.Dedupe.Enabled)
+	if policy := os.Getenv("MCP_MEMORY_DEDUPE_POLICY"); policy != "" {
+		config.Dedupe.Policy = policy
+	}
+	config.Dedupe.EmbeddingThreshold = getFloatEnvWithDefault("MCP_MEMORY_DEDUPE_EMBEDDING_THRESHOLD", config.Dedupe.EmbeddingThreshold)
+	config.Dedupe.ShingleThreshold = getFloatEnvWithDefault("MCP_MEMORY_DEDUPE_SHINGLE_THRESHOLD", config.Dedupe.ShingleThreshold)
+	config.Dedupe.WindowHours = getIntEnvWithDefault("MCP_MEMORY_DEDUPE_WINDOW_HOURS", config.Dedupe.WindowHours)
+}
+
+// loadMetricsConfig loads metrics endpoint configuration from environment
+func loadMetricsConfig(config *Config) {
+	if metricsEnabled := os.Getenv("MCP_MEMORY_METRICS_ENABLED"); metricsEnabled != "" {
+		if me, err := strconv.ParseBool(metricsEnabled); err == nil {
+			config.Metrics.Enabled = me
+		}
+	}
+	config.Metrics.Port = getIntEnvWithDefault("MCP_MEMORY_METRICS_PORT", config.Metrics.Port)
+	if path := os.Getenv("MCP_MEMORY_METRICS_PATH"); path != "" {
+		config.Metrics.Path = path
+	}
+}
+
+// loadTracingConfig loads trace export configuration from environment
+func loadTracingConfig(config *Config) {
+	if tracingEnabled := os.Getenv("MCP_MEMORY_TRACING_ENABLED"); tracingEnabled != "" {
+		if te, err := strconv.ParseBool(tracingEnabled); err == nil {
+			config.Tracing.Enabled = te
+		}
+	}
+	if endpoint := os.Getenv("MCP_MEMORY_TRACING_ENDPOINT"); endpoint != "" {
+		config.Tracing.Endpoint = endpoint
+	}
+	if serviceName := os.Getenv("MCP_MEMORY_TRACING_SERVICE_NAME"); serviceName != "" {
+		config.Tracing.ServiceName = serviceName
+	}
+	config.Tracing.SampleRatio = getFloatEnvWithDefault("MCP_MEMORY_TRACING_SAMPLE_RATIO", config.Tracing.SampleRatio)
 }
 
 // loadIntelligenceConfig loads intelligence configuration from environment
@@ -474,7 +1051,11 @@ func (c *Config) Validate() error {
 		return err
 	}
 
-	if err := c.validateOpenAIConfig(); err != nil {
+	if err := c.validateEmbeddingConfig(); err != nil {
+		return err
+	}
+
+	if err := c.validateSummarizerConfig(); err != nil {
 		return err
 	}
 
@@ -486,6 +1067,137 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if err := c.validateSearchConfig(); err != nil {
+		return err
+	}
+
+	if err := c.validateDecayConfig(); err != nil {
+		return err
+	}
+
+	if err := c.validateDedupeConfig(); err != nil {
+		return err
+	}
+
+	if err := c.validateSecurityConfig(); err != nil {
+		return err
+	}
+
+	if err := c.validateMetricsConfig(); err != nil {
+		return err
+	}
+
+	if err := c.validateTracingConfig(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateTracingConfig validates trace export settings
+func (c *Config) validateTracingConfig() error {
+	if !c.Tracing.Enabled {
+		return nil
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return errors.New("tracing sample ratio must be between 0 and 1")
+	}
+	if c.Tracing.Endpoint != "" {
+		endpoint, err := url.Parse(c.Tracing.Endpoint)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return fmt.Errorf("tracing endpoint %q must be an http or https URL", c.Tracing.Endpoint)
+		}
+	}
+	return nil
+}
+
+// validateMetricsConfig validates the metrics endpoint settings
+func (c *Config) validateMetricsConfig() error {
+	if !c.Metrics.Enabled {
+		return nil
+	}
+	if c.Metrics.Port < 0 || c.Metrics.Port > 65535 {
+		return fmt.Errorf("invalid metrics port: %d", c.Metrics.Port)
+	}
+	if c.Metrics.Port != 0 && c.Metrics.Port == c.Server.Port {
+		return errors.New("metrics port must differ from the server port")
+	}
+	if !strings.HasPrefix(c.Metrics.Path, "/") {
+		return fmt.Errorf("metrics path %q must start with /", c.Metrics.Path)
+	}
+	return nil
+}
+
+// validateDedupeConfig validates near-duplicate detection settings
+func (c *Config) validateDedupeConfig() error {
+	if err := ValidateDedupePolicy(c.Dedupe.Policy); err != nil {
+		return err
+	}
+	for _, threshold := range []float64{c.Dedupe.EmbeddingThreshold, c.Dedupe.ShingleThreshold} {
+		if threshold <= 0 || threshold > 1 {
+			return errors.New("dedupe thresholds must be greater than 0 and at most 1")
+		}
+	}
+	if c.Dedupe.WindowHours <= 0 || c.Dedupe.Candidates <= 0 {
+		return errors.New("dedupe window hours and candidates must be positive")
+	}
+	return nil
+}
+
+// validateDecayConfig validates decay settings
+func (c *Config) validateDecayConfig() error {
+	if c.Decay.IntervalHours <= 0 {
+		return errors.New("decay interval hours must be positive")
+	}
+	return ValidateDecayThresholds(c.Decay.SummarizationThreshold, c.Decay.DeletionThreshold)
+}
+
+// ValidateDecayThresholds checks that both thresholds are between 0 and 1
+// and that deletion does not start above summarization
+func ValidateDecayThresholds(summarization, deletion float64) error {
+	if summarization < 0 || summarization > 1 || deletion < 0 || deletion > 1 {
+		return errors.New("decay thresholds must be between 0 and 1")
+	}
+	if deletion > summarization {
+		return errors.New("decay deletion threshold must not exceed the summarization threshold")
+	}
+	return nil
+}
+
+// validateSecurityConfig validates encryption settings
+func (c *Config) validateSecurityConfig() error {
+	if c.Security.EncryptionEnabled && c.Security.EncryptionPassphrase == "" {
+		return errors.New("encryption is enabled but MCP_MEMORY_ENCRYPTION_PASSPHRASE is not set")
+	}
+
+	redaction := &c.Security.Redaction
+	if err := ValidateRedactionAction(redaction.SecretAction); err != nil {
+		return fmt.Errorf("redaction secret_action: %w", err)
+	}
+	if err := ValidateRedactionAction(redaction.PIIAction); err != nil {
+		return fmt.Errorf("redaction pii_action: %w", err)
+	}
+	if redaction.EntropyThreshold < 0 {
+		return errors.New("redaction entropy threshold must not be negative")
+	}
+	for i := range redaction.Rules {
+		if err := redaction.Rules[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSearchConfig validates search configuration settings
+func (c *Config) validateSearchConfig() error {
+	switch c.Search.DefaultMode {
+	case SearchModeVector, SearchModeLexical, SearchModeHybrid:
+	default:
+		return fmt.Errorf("invalid search mode %q: must be vector, lexical or hybrid", c.Search.DefaultMode)
+	}
+	if c.Search.RRFK <= 0 {
+		return errors.New("search rrf_k must be positive")
+	}
 	return nil
 }
 
@@ -497,6 +1209,12 @@ func (c *Config) validateServerConfig() error {
 	if c.Server.Host == "" {
 		return errors.New("server host cannot be empty")
 	}
+	if c.Server.HealthCheckInterval <= 0 {
+		return errors.New("health check interval must be positive")
+	}
+	if c.Server.HealthMaxMemoryMB < 0 {
+		return errors.New("health max memory cannot be negative")
+	}
 	return nil
 }
 
@@ -528,11 +1246,93 @@ func (c *Config) validateOpenAIConfig() error {
 	return nil
 }
 
+// validateEmbeddingConfig validates the selected embedding provider
+func (c *Config) validateEmbeddingConfig() error {
+	if c.Embedding.Dimension < 0 {
+		return errors.New("embedding dimension cannot be negative")
+	}
+
+	switch c.Embedding.Provider {
+	case EmbeddingProviderOpenAI, "":
+		return c.validateOpenAIConfig()
+	case EmbeddingProviderOpenAICompatible:
+		if c.Embedding.BaseURL == "" {
+			return errors.New("embedding base URL is required for the openai_compatible provider")
+		}
+		if c.Embedding.Model == "" {
+			return errors.New("embedding model is required for the openai_compatible provider")
+		}
+	case EmbeddingProviderOllama, EmbeddingProviderHash:
+		// Both have usable defaults for every field
+	default:
+		return fmt.Errorf("unknown embedding provider: %s", c.Embedding.Provider)
+	}
+	return nil
+}
+
+// validateSummarizerConfig validates the summarization provider
+func (c *Config) validateSummarizerConfig() error {
+	switch c.Summarizer.Provider {
+	case SummarizerProviderNone, "":
+		return nil
+	case SummarizerProviderOpenAI:
+		if c.Summarizer.APIKey == "" && c.OpenAI.APIKey == "" {
+			return errors.New("summarizer API key or OpenAI API key is required for the openai summarizer")
+		}
+	case SummarizerProviderOpenAICompatible:
+		if c.Summarizer.BaseURL == "" {
+			return errors.New("summarizer base URL is required for the openai_compatible provider")
+		}
+		if c.Summarizer.Model == "" {
+			return errors.New("summarizer model is required for the openai_compatible provider")
+		}
+	case SummarizerProviderOllama:
+		// Defaults to a local server
+	default:
+		return fmt.Errorf("unknown summarizer provider: %s", c.Summarizer.Provider)
+	}
+	if c.Summarizer.MaxInputTokens <= 0 || c.Summarizer.MaxOutputTokens <= 0 {
+		return errors.New("summarizer token budgets must be positive")
+	}
+	if c.Summarizer.CacheSize < 0 {
+		return errors.New("summarizer cache size cannot be negative")
+	}
+	return nil
+}
+
 // validateStorageConfig validates storage configuration settings
 func (c *Config) validateStorageConfig() error {
 	if c.Storage.RetentionDays <= 0 {
 		return errors.New("retention days must be positive")
 	}
+	if c.Storage.ReembedStateDir == "" {
+		return errors.New("re-embedding state directory cannot be empty")
+	}
+	if c.Storage.WebhookStateDir == "" {
+		return errors.New("webhook state directory cannot be empty")
+	}
+	if c.Storage.Provider == StorageProviderLocal {
+		if c.Storage.Local.DataDir == "" {
+			return errors.New("local storage data directory cannot be empty")
+		}
+		if c.Storage.Local.IndexTables <= 0 {
+			return errors.New("local storage index tables must be positive")
+		}
+		if c.Storage.Local.IndexBits <= 0 || c.Storage.Local.IndexBits > 64 {
+			return errors.New("local storage index bits must be between 1 and 64")
+		}
+	}
+	if c.Storage.Provider == StorageProviderPostgres {
+		if c.Storage.Postgres.DSN == "" && (c.Storage.Postgres.Host == "" || c.Storage.Postgres.Database == "") {
+			return errors.New("postgres storage requires a DSN or host and database")
+		}
+		if c.Storage.Postgres.MaxConnections <= 0 {
+			return errors.New("postgres max connections must be positive")
+		}
+		if c.Storage.Postgres.VectorDimension <= 0 {
+			return errors.New("postgres vector dimension must be positive")
+		}
+	}
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
@@ -22,6 +23,7 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Equal(t, "localhost", cfg.Server.Host)
 	assert.Equal(t, 30, cfg.Server.ReadTimeout)
 	assert.Equal(t, 30, cfg.Server.WriteTimeout)
+	assert.Equal(t, 15, cfg.Server.HealthCheckInterval)
 
 	// Qdrant defaults
 	assert.Equal(t, "localhost", cfg.Qdrant.Host)
@@ -172,6 +174,36 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "OpenAI embedding model cannot be empty",
 		},
+		{
+			name: "ollama provider without OpenAI key",
+			config: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Embedding.Provider = EmbeddingProviderOllama
+				return cfg
+			},
+			wantErr: false,
+		},
+		{
+			name: "openai compatible provider without base URL",
+			config: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Embedding.Provider = EmbeddingProviderOpenAICompatible
+				cfg.Embedding.Model = "bge-m3"
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "embedding base URL is required",
+		},
+		{
+			name: "unknown embedding provider",
+			config: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Embedding.Provider = "word2vec"
+				return cfg
+			},
+			wantErr: true,
+			errMsg:  "unknown embedding provider",
+		},
 		{
 			name: "invalid retention days",
 			config: func() *Config {
@@ -262,6 +294,11 @@ func TestLoadConfig_WithEnvVars(t *testing.T) {
 		"MCP_MEMORY_LOG_LEVEL":   "debug",
 		"MCP_MEMORY_LOG_FORMAT":  "text",
 		"MCP_MEMORY_LOG_FILE":    "/var/log/memory.log",
+
+		"MCP_MEMORY_HEALTH_MAX_MEMORY_MB":          "512",
+		"MCP_MEMORY_HEALTH_CHECK_INTERVAL_SECONDS": "45",
+		"MCP_MEMORY_REEMBED_STATE_DIR":             "/custom/reembed",
+		"MCP_MEMORY_WEBHOOK_STATE_DIR":             "/custom/webhooks",
 	}
 
 	// Set environment variables
@@ -293,6 +330,10 @@ func TestLoadConfig_WithEnvVars(t *testing.T) {
 	assert.Equal(t, "debug", cfg.Logging.Level)
 	assert.Equal(t, "text", cfg.Logging.Format)
 	assert.Equal(t, "/var/log/memory.log", cfg.Logging.File)
+	assert.Equal(t, 512, cfg.Server.HealthMaxMemoryMB)
+	assert.Equal(t, 45, cfg.Server.HealthCheckInterval)
+	assert.Equal(t, "/custom/reembed", cfg.Storage.ReembedStateDir)
+	assert.Equal(t, "/custom/webhooks", cfg.Storage.WebhookStateDir)
 }
 
 func TestLoadConfig_WithInvalidEnvVars(t *testing.T) {
@@ -443,3 +484,16 @@ func TestLoadConfig_InvalidConfig(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid configuration")
 }
+
+func TestConfigJSONOmitsSecrets(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.OpenAI.APIKey = testAPIKey
+	cfg.Storage.Postgres.Password = "pg-secret"
+	cfg.Storage.Postgres.DSN = "postgres://mcp:dsn-secret@db/mcp_memory"
+
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), testAPIKey)
+	assert.NotContains(t, string(data), "pg-secret")
+	assert.NotContains(t, string(data), "dsn-secret")
+}
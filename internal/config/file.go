@@ -0,0 +1,103 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maskedValue replaces secrets in printed configuration
+const maskedValue = "********"
+
+var (
+	// envReference matches ${NAME} references in config files
+	envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+	// dsnPassword matches the password in key=value connection strings
+	dsnPassword = regexp.MustCompile(`(password=)(\S+)`)
+)
+
+// ConfigFilePath returns the config file named in the environment, if any
+func ConfigFilePath() string {
+	return getStringEnvWithFallback("MCP_MEMORY_CONFIG_FILE", "CONFIG_PATH", "")
+}
+
+// loadFromFile overlays a YAML config file on config. Keys the file sets
+// replace the current values; keys it leaves out keep them. ${NAME}
+// references are replaced with environment variables, and keys that do not
+// exist in Config are errors so typos do not pass silently.
+func loadFromFile(config *Config, path string) error {
+	data, err := os.ReadFile(path) // #nosec G304 -- path comes from the operator
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	expanded := envReference.ReplaceAllStringFunc(string(data), func(ref string) string {
+		return os.Getenv(envReference.FindStringSubmatch(ref)[1])
+	})
+
+	decoder := yaml.NewDecoder(strings.NewReader(expanded))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Masked returns a copy of the configuration with API keys, passwords and
+// passphrases replaced, for display
+func (c *Config) Masked() *Config {
+	masked := *c
+	masked.Qdrant.APIKey = maskSecret(c.Qdrant.APIKey)
+	masked.OpenAI.APIKey = maskSecret(c.OpenAI.APIKey)
+	masked.Embedding.APIKey = maskSecret(c.Embedding.APIKey)
+	masked.Summarizer.APIKey = maskSecret(c.Summarizer.APIKey)
+	masked.Storage.Postgres.Password = maskSecret(c.Storage.Postgres.Password)
+	masked.Storage.Postgres.DSN = maskDSN(c.Storage.Postgres.DSN)
+	masked.Security.EncryptionPassphrase = maskSecret(c.Security.EncryptionPassphrase)
+	return &masked
+}
+
+// WriteYAML writes the configuration in the config file format
+func (c *Config) WriteYAML(w io.Writer) error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return maskedValue
+}
+
+// maskDSN hides the password in a URL or key=value connection string
+func maskDSN(dsn string) string {
+	if dsn == "" {
+		return ""
+	}
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return maskedValue
+		}
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+maskedValue)
+}
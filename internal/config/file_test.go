@@ -0,0 +1,116 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfigFile_Layers(t *testing.T) {
+	path := writeConfigFile(t, `
+server:
+  port: 7000
+  host: "0.0.0.0"
+openai:
+  api_key: "${TEST_CONFIG_OPENAI_KEY}"
+storage:
+  provider: "local"
+  local:
+    data_dir: "/srv/memory"
+logging:
+  level: "debug"
+`)
+	t.Setenv("TEST_CONFIG_OPENAI_KEY", "from-reference")
+	t.Setenv("MCP_MEMORY_LOG_LEVEL", "warn")
+
+	cfg, err := LoadConfigFile(path)
+	require.NoError(t, err)
+
+	// The file overrides defaults
+	assert.Equal(t, 7000, cfg.Server.Port)
+	assert.Equal(t, "0.0.0.0", cfg.Server.Host)
+	assert.Equal(t, StorageProviderLocal, cfg.Storage.Provider)
+	assert.Equal(t, "/srv/memory", cfg.Storage.Local.DataDir)
+	assert.Equal(t, "from-reference", cfg.OpenAI.APIKey)
+
+	// Keys the file leaves out keep their defaults
+	assert.Equal(t, 30, cfg.Server.ReadTimeout)
+	assert.Equal(t, 8, cfg.Storage.Local.IndexTables)
+
+	// Environment variables override the file
+	assert.Equal(t, "warn", cfg.Logging.Level)
+}
+
+func TestLoadConfigFile_FromEnvironment(t *testing.T) {
+	t.Setenv("MCP_MEMORY_CONFIG_FILE", writeConfigFile(t, "server:\n  port: 7100\n"))
+	t.Setenv("OPENAI_API_KEY", testAPIKey)
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 7100, cfg.Server.Port)
+}
+
+func TestLoadConfigFile_Errors(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", testAPIKey)
+
+	_, err := LoadConfigFile(writeConfigFile(t, "server:\n  prot: 9000\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field prot not found")
+
+	_, err = LoadConfigFile(writeConfigFile(t, "dashboards:\n  enabled: true\n"))
+	require.Error(t, err)
+
+	_, err = LoadConfigFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+
+	_, err = LoadConfigFile(writeConfigFile(t, "server:\n  port: 70000\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid configuration")
+
+	cfg, err := LoadConfigFile(writeConfigFile(t, "# nothing set\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.Server.Port)
+}
+
+func TestShippedConfigFilesAreValid(t *testing.T) {
+	paths, err := filepath.Glob("../../configs/*/config.yaml")
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	for _, path := range paths {
+		cfg := DefaultConfig()
+		assert.NoError(t, loadFromFile(cfg, path), path)
+	}
+}
+
+func TestConfigMasked(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.OpenAI.APIKey = "sk-secret"
+	cfg.Storage.Postgres.Password = "hunter2"
+	cfg.Storage.Postgres.DSN = "postgres://app:hunter2@db:5432/memory?sslmode=require"
+	cfg.Security.EncryptionPassphrase = "passphrase"
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.Masked().WriteYAML(&out))
+	printed := out.String()
+
+	assert.NotContains(t, printed, "sk-secret")
+	assert.NotContains(t, printed, "hunter2")
+	assert.NotContains(t, printed, ": passphrase")
+	assert.Contains(t, printed, "api_key: '"+maskedValue+"'")
+	assert.Contains(t, printed, "postgres://app:xxxxx@db:5432/memory")
+	assert.Equal(t, "sk-secret", cfg.OpenAI.APIKey, "the original is not changed")
+
+	assert.Equal(t, "host=db password="+maskedValue+" dbname=memory", maskDSN("host=db password=hunter2 dbname=memory"))
+}
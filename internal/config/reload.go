@@ -0,0 +1,146 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DefaultWatchInterval is how often a Watcher checks the config file
+const DefaultWatchInterval = 2 * time.Second
+
+// Watcher reloads the configuration when the config file changes or a reload
+// is requested, for example on SIGHUP. Each configuration that loads and
+// validates is handed to apply; one that does not is logged and the running
+// configuration stays.
+type Watcher struct {
+	path     string
+	interval time.Duration
+	apply    func(*Config)
+	logger   *log.Logger
+	modTime  time.Time
+}
+
+// NewWatcher creates a watcher for the config file at path, or for the
+// environment only when path is empty
+func NewWatcher(path string, apply func(*Config)) *Watcher {
+	if path == "" {
+		path = ConfigFilePath()
+	}
+	w := &Watcher{
+		path:     path,
+		interval: DefaultWatchInterval,
+		apply:    apply,
+		logger:   log.New(log.Writer(), "[Config] ", log.LstdFlags),
+	}
+	w.modTime = w.fileModTime()
+	return w
+}
+
+// Reload loads the configuration again and applies it
+func (w *Watcher) Reload() error {
+	cfg, err := LoadConfigFile(w.path)
+	if err != nil {
+		return err
+	}
+	w.apply(cfg)
+	return nil
+}
+
+// Run reloads on every value received from reload and whenever the config
+// file's modification time changes, until ctx is done
+func (w *Watcher) Run(ctx context.Context, reload <-chan os.Signal) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload:
+			w.modTime = w.fileModTime()
+			w.reload("reload requested")
+		case <-ticker.C:
+			if w.path == "" {
+				continue
+			}
+			modTime := w.fileModTime()
+			if modTime.Equal(w.modTime) {
+				continue
+			}
+			w.modTime = modTime
+			w.reload("config file changed")
+		}
+	}
+}
+
+func (w *Watcher) reload(reason string) {
+	if err := w.Reload(); err != nil {
+		w.logger.Printf("Warning: %s, keeping the running configuration: %v", reason, err)
+		return
+	}
+	w.logger.Printf("Configuration reloaded (%s)", reason)
+}
+
+func (w *Watcher) fileModTime() time.Time {
+	if w.path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// RestartRequired lists the settings that differ between two configurations
+// but only take effect after a restart. Log level, search thresholds, decay
+// settings, retention and per-repository settings apply while running.
+func RestartRequired(running, reloaded *Config) []string {
+	a, b := reloadableCleared(running), reloadableCleared(reloaded)
+
+	var changed []string
+	collectChanges(reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem(), "", &changed)
+	return changed
+}
+
+// reloadableCleared returns a copy of cfg with the settings that apply
+// while running set to their zero values
+func reloadableCleared(cfg *Config) *Config {
+	cleared := *cfg
+	cleared.Logging.Level = ""
+	cleared.Search.DefaultMinRelevance = 0
+	cleared.Search.RelaxedMinRelevance = 0
+	cleared.Search.BroadestMinRelevance = 0
+	cleared.Search.EnableProgressiveSearch = false
+	cleared.Search.EnableRepositoryFallback = false
+	cleared.Search.MaxRelatedRepos = 0
+	cleared.Search.DefaultMode = ""
+	cleared.Decay = DecayConfig{}
+	cleared.Dedupe = DedupeConfig{}
+	cleared.Storage.RetentionDays = 0
+	cleared.Storage.Repositories = nil
+	cleared.Security.Redaction = RedactionConfig{}
+	return &cleared
+}
+
+// collectChanges appends the yaml paths of the fields that differ
+func collectChanges(a, b reflect.Value, prefix string, changed *[]string) {
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if field.Type.Kind() == reflect.Struct {
+			collectChanges(a.Field(i), b.Field(i), name, changed)
+			continue
+		}
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			*changed = append(*changed, name)
+		}
+	}
+}
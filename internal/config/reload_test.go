@@ -0,0 +1,101 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcherReloadsOnSignalAndFileChange(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", testAPIKey)
+	path := writeConfigFile(t, "logging:\n  level: info\n")
+
+	applied := make(chan *Config, 4)
+	watcher := NewWatcher(path, func(cfg *Config) { applied <- cfg })
+	watcher.interval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	reload := make(chan os.Signal, 1)
+	go watcher.Run(ctx, reload)
+
+	reload <- syscall.SIGHUP
+	select {
+	case cfg := <-applied:
+		assert.Equal(t, "info", cfg.Logging.Level)
+	case <-time.After(2 * time.Second):
+		t.Fatal("SIGHUP did not reload the configuration")
+	}
+
+	// A file with a later modification time is picked up
+	require.NoError(t, os.WriteFile(path, []byte("logging:\n  level: debug\nsearch:\n  default_min_relevance: 0.6\n"), 0o600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+	select {
+	case cfg := <-applied:
+		assert.Equal(t, "debug", cfg.Logging.Level)
+		assert.InDelta(t, 0.6, cfg.Search.DefaultMinRelevance, 1e-9)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the file change was not picked up")
+	}
+}
+
+func TestWatcherKeepsConfigOnInvalidFile(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", testAPIKey)
+	path := writeConfigFile(t, "decay:\n  interval_hours: 0\n")
+
+	applied := false
+	watcher := NewWatcher(path, func(*Config) { applied = true })
+	require.Error(t, watcher.Reload())
+	assert.False(t, applied)
+}
+
+func TestRestartRequired(t *testing.T) {
+	running := DefaultConfig()
+	reloaded := DefaultConfig()
+	reloaded.Logging.Level = "debug"
+	reloaded.Search.DefaultMinRelevance = 0.7
+	reloaded.Decay.IntervalHours = 1
+	reloaded.Storage.RetentionDays = 10
+	reloaded.Storage.Repositories["github.com/acme/api"] = RepoConfig{Enabled: false}
+	assert.Empty(t, RestartRequired(running, reloaded))
+
+	reloaded.Server.Port = 9000
+	reloaded.Storage.Local.DataDir = "/elsewhere"
+	reloaded.Search.RRFK = 10
+	assert.Equal(t, []string{"server.port", "storage.local.data_dir", "search.rrf_k"}, RestartRequired(running, reloaded))
+}
+
+func TestRepoStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "repositories.json")
+	store, err := OpenRepoStore(path)
+	require.NoError(t, err)
+	assert.Empty(t, store.Repositories())
+
+	repoConfig := RepoConfig{Enabled: true, SimilarityThreshold: 0.6, RetentionDays: 30, Decay: DecayPolicy{DeletionThreshold: 0.2}}
+	require.NoError(t, store.Set("github.com/acme/api", repoConfig))
+	require.Error(t, store.Set("github.com/acme/api", RepoConfig{RetentionDays: -1}))
+	require.Error(t, store.Set("", repoConfig))
+
+	reopened, err := OpenRepoStore(path)
+	require.NoError(t, err)
+	saved, ok := reopened.Get("github.com/acme/api")
+	require.True(t, ok)
+	assert.Equal(t, repoConfig, saved)
+
+	cfg := DefaultConfig()
+	summarization, deletion := cfg.DecayThresholds(&saved)
+	assert.InDelta(t, 0.4, summarization, 1e-9)
+	assert.InDelta(t, 0.2, deletion, 1e-9)
+	assert.Equal(t, 30, cfg.RetentionDays(&saved))
+	assert.InDelta(t, 0.6, cfg.MinRelevance(&saved), 1e-9)
+
+	require.NoError(t, reopened.Delete("github.com/acme/api"))
+	assert.ErrorIs(t, reopened.Delete("github.com/acme/api"), ErrRepoConfigNotFound)
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ErrRepoConfigNotFound is returned when a repository has no stored settings
+var ErrRepoConfigNotFound = errors.New("repository has no stored settings")
+
+// RepoStore keeps per-repository settings changed at runtime in a JSON file,
+// so they survive restarts. Repositories it does not hold fall back to
+// storage.repositories in the config file.
+type RepoStore struct {
+	path string
+
+	mu    sync.RWMutex
+	repos map[string]RepoConfig
+}
+
+// OpenRepoStore loads the settings saved at path. A missing file is an
+// empty store; it is created on the first change.
+func OpenRepoStore(path string) (*RepoStore, error) {
+	store := &RepoStore{path: path, repos: make(map[string]RepoConfig)}
+
+	data, err := os.ReadFile(path) // #nosec G304 -- path comes from the operator
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to read repository settings: %w", err)
+	}
+	if err := json.Unmarshal(data, &store.repos); err != nil {
+		return nil, fmt.Errorf("failed to decode repository settings %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// Get returns the stored settings for a repository
+func (s *RepoStore) Get(repository string) (RepoConfig, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	repoConfig, ok := s.repos[repository]
+	return repoConfig, ok
+}
+
+// Set validates and saves the settings for a repository
+func (s *RepoStore) Set(repository string, repoConfig RepoConfig) error {
+	if repository == "" {
+		return errors.New("repository is required")
+	}
+	if err := repoConfig.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, existed := s.repos[repository]
+	s.repos[repository] = repoConfig
+	if err := s.saveLocked(); err != nil {
+		if existed {
+			s.repos[repository] = previous
+		} else {
+			delete(s.repos, repository)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete removes a repository's stored settings, so the config file and
+// defaults apply again
+func (s *RepoStore) Delete(repository string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, ok := s.repos[repository]
+	if !ok {
+		return ErrRepoConfigNotFound
+	}
+	delete(s.repos, repository)
+	if err := s.saveLocked(); err != nil {
+		s.repos[repository] = previous
+		return err
+	}
+	return nil
+}
+
+// Repositories lists the repositories with stored settings, sorted
+func (s *RepoStore) Repositories() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.repos))
+	for name := range s.repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// saveLocked atomically writes the settings file
+func (s *RepoStore) saveLocked() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o750); err != nil {
+		return fmt.Errorf("failed to create repository settings directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s.repos, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode repository settings: %w", err)
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save repository settings: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to save repository settings: %w", err)
+	}
+	return nil
+}
+
+// Validate checks the override fields of a repository's settings
+func (r *RepoConfig) Validate() error {
+	if r.SimilarityThreshold < 0 || r.SimilarityThreshold > 1 {
+		return errors.New("similarity threshold must be between 0 and 1")
+	}
+	if r.RetentionDays < 0 {
+		return errors.New("retention days must not be negative")
+	}
+	for i := range r.RedactionRules {
+		if err := r.RedactionRules[i].Validate(); err != nil {
+			return err
+		}
+	}
+	summarization, deletion := r.Decay.SummarizationThreshold, r.Decay.DeletionThreshold
+	if summarization == 0 {
+		// Only the deletion threshold is overridden; range-check it alone
+		summarization = 1
+	}
+	return ValidateDecayThresholds(summarization, deletion)
+}
+
+// DecayThresholds returns the summarization and deletion thresholds for a
+// repository, using the server-wide values where it sets none
+func (c *Config) DecayThresholds(repoConfig *RepoConfig) (summarization, deletion float64) {
+	summarization, deletion = c.Decay.SummarizationThreshold, c.Decay.DeletionThreshold
+	if repoConfig.Decay.SummarizationThreshold > 0 {
+		summarization = repoConfig.Decay.SummarizationThreshold
+	}
+	if repoConfig.Decay.DeletionThreshold > 0 {
+		deletion = repoConfig.Decay.DeletionThreshold
+	}
+	return summarization, deletion
+}
+
+// RetentionDays returns how long a repository's memories are kept
+func (c *Config) RetentionDays(repoConfig *RepoConfig) int {
+	if repoConfig.RetentionDays > 0 {
+		return repoConfig.RetentionDays
+	}
+	return c.Storage.RetentionDays
+}
+
+// MinRelevance returns the minimum relevance for a repository's searches
+func (c *Config) MinRelevance(repoConfig *RepoConfig) float64 {
+	if repoConfig.SimilarityThreshold > 0 {
+		return repoConfig.SimilarityThreshold
+	}
+	return c.Search.DefaultMinRelevance
+}
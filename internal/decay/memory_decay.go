@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"lerian-mcp-memory/internal/summarizer"
 	"lerian-mcp-memory/pkg/types"
 	"log"
 	"math"
@@ -77,6 +78,7 @@ type MemoryDecayManager struct {
 	config       *DecayConfig
 	store        MemoryStore
 	summarizer   Summarizer
+	abstractive  AbstractiveSummarizer
 	mu           sync.RWMutex
 	running      bool
 	stopCh       chan struct{}
@@ -97,6 +99,12 @@ type Summarizer interface {
 	SummarizeChain(ctx context.Context, chunks []types.ConversationChunk) (types.ConversationChunk, error)
 }
 
+// AbstractiveSummarizer writes summaries of chunks with a language model.
+// *summarizer.Service implements it.
+type AbstractiveSummarizer interface {
+	SummarizeChunks(ctx context.Context, kind summarizer.Kind, chunks []types.ConversationChunk) (string, error)
+}
+
 // EmbeddingGenerator interface for generating embeddings (duplicate from summarizer.go for independence)
 type EmbeddingGenerator interface {
 	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
@@ -133,6 +141,14 @@ func NewMemoryDecayManagerWithEmbeddings(config *DecayConfig, store MemoryStore,
 	}
 }
 
+// SetAbstractiveSummarizer has summary chunks written by a language model.
+// The Summarizer's content is kept when it is unavailable.
+func (m *MemoryDecayManager) SetAbstractiveSummarizer(abstractive AbstractiveSummarizer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.abstractive = abstractive
+}
+
 // Start begins the decay process
 func (m *MemoryDecayManager) Start(ctx context.Context) error {
 	m.mu.Lock()
@@ -182,8 +198,22 @@ func (m *MemoryDecayManager) runDecayLoop(ctx context.Context) {
 	}
 }
 
+// DecayResult counts the chunks a decay run changed
+type DecayResult struct {
+	Summarized int `json:"summarized"`
+	Updated    int `json:"updated"`
+	Deleted    int `json:"deleted"`
+}
+
 // RunDecay runs the decay process for a repository
 func (m *MemoryDecayManager) RunDecay(ctx context.Context, repository string) error {
+	_, err := m.RunDecayWithThresholds(ctx, repository, m.config.SummarizationThreshold, m.config.DeletionThreshold)
+	return err
+}
+
+// RunDecayWithThresholds runs the decay process for a repository with its
+// own summarization and deletion thresholds
+func (m *MemoryDecayManager) RunDecayWithThresholds(ctx context.Context, repository string, summarizationThreshold, deletionThreshold float64) (*DecayResult, error) {
 	m.mu.Lock()
 	m.lastDecayRun = time.Now()
 	m.mu.Unlock()
@@ -191,7 +221,7 @@ func (m *MemoryDecayManager) RunDecay(ctx context.Context, repository string) er
 	// Get all chunks
 	chunks, err := m.store.GetAllChunks(ctx, repository)
 	if err != nil {
-		return fmt.Errorf("failed to get chunks: %w", err)
+		return nil, fmt.Errorf("failed to get chunks: %w", err)
 	}
 
 	// Calculate relevance scores
@@ -209,9 +239,9 @@ func (m *MemoryDecayManager) RunDecay(ctx context.Context, repository string) er
 		}
 
 		switch {
-		case scoredChunks[i].Score < m.config.DeletionThreshold:
+		case scoredChunks[i].Score < deletionThreshold:
 			toDelete = append(toDelete, scoredChunks[i].Chunk.ID)
-		case scoredChunks[i].Score < m.config.SummarizationThreshold:
+		case scoredChunks[i].Score < summarizationThreshold:
 			toSummarize = append(toSummarize, scoredChunks[i])
 		case scoredChunks[i].Score < m.config.MinRelevanceScore:
 			// Update with decayed score
@@ -244,7 +274,7 @@ func (m *MemoryDecayManager) RunDecay(ctx context.Context, repository string) er
 	log.Printf("Decay process completed: %d summarized, %d updated, %d deleted",
 		len(toSummarize), len(toUpdate), len(toDelete))
 
-	return nil
+	return &DecayResult{Summarized: len(toSummarize), Updated: len(toUpdate), Deleted: len(toDelete)}, nil
 }
 
 // ScoredChunk holds a chunk with its relevance score
@@ -361,6 +391,7 @@ func (m *MemoryDecayManager) summarizeChunks(ctx context.Context, chunks []Score
 			log.Printf("Failed to summarize group: %v", err)
 			continue
 		}
+		m.applyAbstractiveSummary(ctx, &summary, groupChunks)
 
 		// Store summary
 		if err := m.store.StoreChunk(ctx, &summary); err != nil {
@@ -377,6 +408,24 @@ func (m *MemoryDecayManager) summarizeChunks(ctx context.Context, chunks []Score
 	}
 }
 
+// applyAbstractiveSummary replaces the content of a summary chunk with the
+// abstractive summarizer's, when one is set and available
+func (m *MemoryDecayManager) applyAbstractiveSummary(ctx context.Context, summary *types.ConversationChunk, chunks []types.ConversationChunk) {
+	m.mu.RLock()
+	abstractive := m.abstractive
+	m.mu.RUnlock()
+	if abstractive == nil {
+		return
+	}
+
+	content, err := abstractive.SummarizeChunks(ctx, summarizer.KindDecay, chunks)
+	if err != nil {
+		log.Printf("Keeping extractive summary: %v", err)
+		return
+	}
+	summary.Content = content
+}
+
 // groupRelatedChunks groups chunks that should be summarized together
 func (m *MemoryDecayManager) groupRelatedChunks(chunks []ScoredChunk) [][]ScoredChunk {
 	// Simple grouping by session and time proximity
@@ -2,7 +2,9 @@ package decay
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"lerian-mcp-memory/internal/summarizer"
 	"lerian-mcp-memory/pkg/types"
 	"testing"
 	"time"
@@ -258,6 +260,63 @@ func TestMemoryDecayManager_Summarization(t *testing.T) {
 	}
 }
 
+// MockAbstractiveSummarizer returns a fixed summary or error
+type MockAbstractiveSummarizer struct {
+	summary string
+	err     error
+	kinds   []summarizer.Kind
+}
+
+func (m *MockAbstractiveSummarizer) SummarizeChunks(ctx context.Context, kind summarizer.Kind, chunks []types.ConversationChunk) (string, error) {
+	m.kinds = append(m.kinds, kind)
+	return m.summary, m.err
+}
+
+func TestMemoryDecayManager_AbstractiveSummarization(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		mock     *MockAbstractiveSummarizer
+		expected string
+	}{
+		{"model summary replaces content", &MockAbstractiveSummarizer{summary: "Moved sessions to Redis after login latency spikes"}, "Moved sessions to Redis after login latency spikes"},
+		{"unavailable model keeps the extractive summary", &MockAbstractiveSummarizer{err: summarizer.ErrUnavailable}, "Summary of chunks"},
+		{"failed model keeps the extractive summary", &MockAbstractiveSummarizer{err: errors.New("timeout")}, "Summary of chunks"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMockMemoryStore()
+			manager := NewMemoryDecayManager(DefaultDecayConfig(), store, &MockSummarizer{})
+			manager.SetAbstractiveSummarizer(tt.mock)
+			ctx := context.Background()
+
+			baseTime := time.Now().Add(-50 * 24 * time.Hour)
+			chunks := make([]ScoredChunk, 3)
+			for i := range chunks {
+				chunks[i] = ScoredChunk{Chunk: types.ConversationChunk{
+					ID:        fmt.Sprintf("chunk-%d", i),
+					SessionID: "abstractive-session",
+					Timestamp: baseTime.Add(time.Duration(i) * time.Hour),
+					Type:      types.ChunkTypeDiscussion,
+					Content:   fmt.Sprintf("Discussion part %d", i),
+				}}
+				_ = store.StoreChunk(ctx, &chunks[i].Chunk)
+			}
+
+			manager.summarizeChunks(ctx, chunks)
+
+			if len(tt.mock.kinds) != 1 || tt.mock.kinds[0] != summarizer.KindDecay {
+				t.Errorf("Expected one decay summary request, got %v", tt.mock.kinds)
+			}
+			summary, ok := store.chunks["summary-chunk-0"]
+			if !ok {
+				t.Fatal("Expected a summary chunk")
+			}
+			if summary.Content != tt.expected {
+				t.Errorf("Expected content %q, got %q", tt.expected, summary.Content)
+			}
+		})
+	}
+}
+
 func TestDecayStrategies(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,158 @@
+// Package dedupe finds near-duplicate chunks at ingest time by comparing
+// embeddings and word shingles against recent chunks in the same repository.
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/storage"
+	"lerian-mcp-memory/pkg/types"
+)
+
+// shingleSize is how many consecutive words make up a shingle
+const shingleSize = 3
+
+// Match is an earlier chunk that a new chunk duplicates
+type Match struct {
+	Chunk               types.ConversationChunk `json:"-"`
+	EmbeddingSimilarity float64                 `json:"embedding_similarity"`
+	ShingleSimilarity   float64                 `json:"shingle_similarity"`
+}
+
+// Score is the higher of the two similarities
+func (m *Match) Score() float64 {
+	return math.Max(m.EmbeddingSimilarity, m.ShingleSimilarity)
+}
+
+// Detector looks up near-duplicates of new chunks in a vector store
+type Detector struct {
+	store storage.VectorStore
+}
+
+// NewDetector creates a detector over store
+func NewDetector(store storage.VectorStore) *Detector {
+	return &Detector{store: store}
+}
+
+// FindDuplicate returns the closest chunk in the same repository, stored
+// within cfg.WindowHours, whose embedding or shingle similarity reaches the
+// configured threshold. It returns nil when there is none or detection is
+// disabled.
+func (d *Detector) FindDuplicate(ctx context.Context, chunk *types.ConversationChunk, cfg *config.DedupeConfig) (*Match, error) {
+	if !cfg.Enabled || cfg.Policy == config.DedupePolicyAllow || len(chunk.Embeddings) == 0 {
+		return nil, nil
+	}
+
+	query := &types.MemoryQuery{
+		Query:   chunk.Content,
+		Recency: types.RecencyAllTime,
+		Limit:   cfg.Candidates,
+	}
+	if chunk.Metadata.Repository != "" {
+		repository := chunk.Metadata.Repository
+		query.Repository = &repository
+	}
+	results, err := d.store.Search(ctx, query, chunk.Embeddings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for duplicates: %w", err)
+	}
+
+	since := time.Now().Add(-time.Duration(cfg.WindowHours) * time.Hour)
+	fingerprint := Fingerprint(chunk.Content)
+
+	var best *Match
+	for i := range results.Results {
+		candidate := results.Results[i].Chunk
+		if candidate.ID == chunk.ID || candidate.Metadata.Repository != chunk.Metadata.Repository || candidate.Timestamp.Before(since) {
+			continue
+		}
+
+		match := &Match{
+			Chunk:             candidate,
+			ShingleSimilarity: ShingleSimilarity(fingerprint, Fingerprint(candidate.Content)),
+		}
+		// Stores that do not return vectors report the cosine score instead
+		if len(candidate.Embeddings) == len(chunk.Embeddings) {
+			match.EmbeddingSimilarity = cosine(chunk.Embeddings, candidate.Embeddings)
+		} else {
+			match.EmbeddingSimilarity = results.Results[i].Score
+		}
+
+		if match.EmbeddingSimilarity < cfg.EmbeddingThreshold && match.ShingleSimilarity < cfg.ShingleThreshold {
+			continue
+		}
+		if best == nil || match.Score() > best.Score() {
+			best = match
+		}
+	}
+	return best, nil
+}
+
+// Fingerprint returns the sorted, distinct hashes of the lowercased word
+// shingles of content. Content shorter than a shingle hashes each word.
+func Fingerprint(content string) []uint64 {
+	words := strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	size := shingleSize
+	if len(words) < size {
+		size = 1
+	}
+
+	seen := make(map[uint64]bool)
+	hashes := make([]uint64, 0, len(words))
+	for i := 0; i+size <= len(words); i++ {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(strings.Join(words[i:i+size], " ")))
+		sum := h.Sum64()
+		if !seen[sum] {
+			seen[sum] = true
+			hashes = append(hashes, sum)
+		}
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	return hashes
+}
+
+// ShingleSimilarity returns the Jaccard similarity of two fingerprints
+func ShingleSimilarity(a, b []uint64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			shared++
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// cosine returns the cosine similarity of two equal-length vectors
+func cosine(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,99 @@
+package dedupe
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/storage"
+	"lerian-mcp-memory/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *storage.LocalStore {
+	t.Helper()
+	store := storage.NewLocalStore(&config.LocalStorageConfig{
+		DataDir:     t.TempDir(),
+		Collection:  "test_memory",
+		IndexTables: 4,
+		IndexBits:   6,
+	})
+	require.NoError(t, store.Initialize(context.Background()))
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func newChunk(id, repository, content string, embeddings []float64, at time.Time) *types.ConversationChunk {
+	return &types.ConversationChunk{
+		ID:         id,
+		SessionID:  "s1",
+		Timestamp:  at,
+		Type:       types.ChunkTypeSolution,
+		Content:    content,
+		Embeddings: embeddings,
+		Metadata: types.ChunkMetadata{
+			Repository: repository,
+			Outcome:    types.OutcomeSuccess,
+			Difficulty: types.DifficultySimple,
+		},
+	}
+}
+
+func defaultDedupe() *config.DedupeConfig {
+	cfg := config.DefaultConfig().Dedupe
+	return &cfg
+}
+
+func TestShingleSimilarity(t *testing.T) {
+	a := Fingerprint("Fixed the race in the cache by holding the lock while refreshing entries")
+	b := Fingerprint("fixed the race in the cache by holding the lock while refreshing entries!")
+	c := Fingerprint("Fixed the race in the cache by holding the lock while refreshing all entries")
+	d := Fingerprint("Upgraded the postgres driver to get connection pool metrics")
+
+	assert.InDelta(t, 1.0, ShingleSimilarity(a, b), 1e-9, "case and punctuation are ignored")
+	assert.Greater(t, ShingleSimilarity(a, c), 0.6)
+	assert.Less(t, ShingleSimilarity(a, c), 1.0)
+	assert.Zero(t, ShingleSimilarity(a, d))
+	assert.Zero(t, ShingleSimilarity(a, nil))
+	assert.Len(t, Fingerprint("short note"), 2, "short content hashes each word")
+}
+
+func TestFindDuplicate(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	repo := "github.com/acme/api"
+	now := time.Now()
+
+	content := "Fixed the race in the cache by holding the lock while refreshing entries"
+	require.NoError(t, store.Store(ctx, newChunk("same-text", repo, content, []float64{1, 0, 0}, now.Add(-time.Hour))))
+	require.NoError(t, store.Store(ctx, newChunk("same-vector", repo, "Unrelated wording entirely", []float64{0, 1, 0}, now.Add(-time.Hour))))
+	require.NoError(t, store.Store(ctx, newChunk("other-repo", "github.com/acme/web", content, []float64{1, 0, 0}, now)))
+	require.NoError(t, store.Store(ctx, newChunk("too-old", repo, "Old note about the disk quota", []float64{0, 0, 1}, now.Add(-72*time.Hour))))
+
+	detector := NewDetector(store)
+
+	match, err := detector.FindDuplicate(ctx, newChunk("new-1", repo, content, []float64{0.6, 0.8, 0}, now), defaultDedupe())
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, "same-text", match.Chunk.ID, "shingles alone are enough")
+	assert.InDelta(t, 1.0, match.ShingleSimilarity, 1e-9)
+	assert.InDelta(t, 0.6, match.EmbeddingSimilarity, 1e-9)
+
+	match, err = detector.FindDuplicate(ctx, newChunk("new-2", repo, "Completely different text", []float64{0, 1, 0}, now), defaultDedupe())
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, "same-vector", match.Chunk.ID, "embeddings alone are enough")
+
+	match, err = detector.FindDuplicate(ctx, newChunk("new-3", repo, "Old note about the disk quota", []float64{0, 0, 1}, now), defaultDedupe())
+	require.NoError(t, err)
+	assert.Nil(t, match, "chunks outside the window are ignored")
+
+	cfg := defaultDedupe()
+	cfg.Enabled = false
+	match, err = detector.FindDuplicate(ctx, newChunk("new-4", repo, content, []float64{1, 0, 0}, now), cfg)
+	require.NoError(t, err)
+	assert.Nil(t, match)
+}
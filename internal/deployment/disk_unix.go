@@ -0,0 +1,15 @@
+//go:build !windows
+
+package deployment
+
+import "syscall"
+
+// diskUsage returns the free and total bytes of the filesystem holding path
+func diskUsage(path string) (freeBytes, totalBytes uint64, err error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	blockSize := uint64(stat.Bsize) // #nosec G115 -- block sizes are small and positive
+	return stat.Bavail * blockSize, stat.Blocks * blockSize, nil
+}
@@ -8,10 +8,13 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"sync"
 	"time"
+
+	"lerian-mcp-memory/internal/circuitbreaker"
 )
 
 // HealthStatus represents the health status of a component
@@ -80,6 +83,10 @@ type HealthManager struct {
 	version     string
 	lastChecks  map[string]HealthCheck
 	checksMutex sync.RWMutex
+
+	// maxAge lets the HTTP handlers answer from cached results this recent
+	// instead of running every check per probe
+	maxAge time.Duration
 }
 
 // DatabaseHealthChecker checks database connectivity
@@ -100,6 +107,29 @@ type MemoryHealthChecker struct {
 	maxMemoryMB uint64
 }
 
+// ServiceHealthChecker checks a dependency the server can run without for a
+// while, such as the embedding provider or the audit log. A failed ping
+// reports failureStatus instead of always making the server unhealthy.
+type ServiceHealthChecker struct {
+	name          string
+	ping          func(ctx context.Context) error
+	failureStatus HealthStatus
+}
+
+// DiskSpaceHealthChecker checks free space on the filesystem holding path
+type DiskSpaceHealthChecker struct {
+	name string
+	path string
+}
+
+// CircuitBreakerHealthChecker reports the state of a circuit breaker. An
+// open breaker reports openStatus; a half-open one is degraded.
+type CircuitBreakerHealthChecker struct {
+	name       string
+	stats      func() (circuitbreaker.Stats, bool)
+	openStatus HealthStatus
+}
+
 // NewHealthManager creates a new health manager
 func NewHealthManager(version string) *HealthManager {
 	return &HealthManager{
@@ -115,6 +145,13 @@ func (hm *HealthManager) AddChecker(checker HealthChecker) {
 	hm.checkers = append(hm.checkers, checker)
 }
 
+// SetMaxAge makes the HTTP handlers reuse cached results younger than
+// maxAge, normally refreshed by StartPeriodicChecks. Zero checks on every
+// request.
+func (hm *HealthManager) SetMaxAge(maxAge time.Duration) {
+	hm.maxAge = maxAge
+}
+
 // CheckHealth performs all health checks and returns system health
 func (hm *HealthManager) CheckHealth(ctx context.Context) *SystemHealth {
 	start := time.Now()
@@ -169,7 +206,12 @@ func (hm *HealthManager) GetCachedHealth() *SystemHealth {
 	checks := make([]HealthCheck, 0, len(hm.lastChecks))
 	overallStatus := HealthStatusHealthy
 
-	for _, check := range hm.lastChecks {
+	// Report checks in the order they were added
+	for _, checker := range hm.checkers {
+		check, ok := hm.lastChecks[checker.Name()]
+		if !ok {
+			continue
+		}
 		checks = append(checks, check)
 		switch check.Status {
 		case HealthStatusUnhealthy:
@@ -198,11 +240,35 @@ func (hm *HealthManager) GetCachedHealth() *SystemHealth {
 	}
 }
 
-// StartPeriodicChecks starts periodic health checks
+// currentHealth returns cached results when every checker has one younger
+// than maxAge, and runs the checks otherwise
+func (hm *HealthManager) currentHealth(ctx context.Context) *SystemHealth {
+	if hm.maxAge > 0 && hm.cacheIsFresh() {
+		return hm.GetCachedHealth()
+	}
+	return hm.CheckHealth(ctx)
+}
+
+// cacheIsFresh reports whether every checker has a result younger than maxAge
+func (hm *HealthManager) cacheIsFresh() bool {
+	hm.checksMutex.RLock()
+	defer hm.checksMutex.RUnlock()
+
+	for _, checker := range hm.checkers {
+		check, ok := hm.lastChecks[checker.Name()]
+		if !ok || time.Since(check.LastCheck) > hm.maxAge {
+			return false
+		}
+	}
+	return true
+}
+
+// StartPeriodicChecks runs the checks right away and then every interval
 func (hm *HealthManager) StartPeriodicChecks(ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	hm.CheckHealth(ctx)
 	for {
 		select {
 		case <-ctx.Done():
@@ -220,7 +286,7 @@ func (hm *HealthManager) HTTPHandler() http.HandlerFunc {
 		ctx, cancel := context.WithTimeout(r.Context(), timeout)
 		defer cancel()
 
-		health := hm.CheckHealth(ctx)
+		health := hm.currentHealth(ctx)
 
 		w.Header().Set("Content-Type", "application/json")
 
@@ -254,21 +320,23 @@ func (hm *HealthManager) ReadinessHandler() http.HandlerFunc {
 		ctx, cancel := context.WithTimeout(r.Context(), timeout)
 		defer cancel()
 
-		health := hm.CheckHealth(ctx)
+		health := hm.currentHealth(ctx)
 
-		w.Header().Set("Content-Type", "application/json")
+		// Degraded dependencies still serve traffic; only an unhealthy one,
+		// such as a lost vector store, takes the instance out of rotation
+		status, code := "ready", http.StatusOK
+		if health.Status == HealthStatusUnhealthy {
+			status, code = "not_ready", http.StatusServiceUnavailable
+		}
 
-		// Readiness is stricter - only healthy is ready
-		if health.Status == HealthStatusHealthy {
-			w.WriteHeader(http.StatusOK)
-			if _, err := fmt.Fprintf(w, `{"status":"ready","timestamp":"%s"}`, time.Now().Format(time.RFC3339)); err != nil {
-				http.Error(w, "Failed to write response", http.StatusInternalServerError)
-			}
-		} else {
-			w.WriteHeader(http.StatusServiceUnavailable)
-			if _, err := fmt.Fprintf(w, `{"status":"not_ready","timestamp":"%s"}`, time.Now().Format(time.RFC3339)); err != nil {
-				http.Error(w, "Failed to write response", http.StatusInternalServerError)
-			}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    status,
+			"timestamp": time.Now().Format(time.RFC3339),
+			"checks":    health.Checks,
+		}); err != nil {
+			http.Error(w, "Failed to write response", http.StatusInternalServerError)
 		}
 	}
 }
@@ -471,6 +539,178 @@ func (mhc *MemoryHealthChecker) Check(ctx context.Context) HealthCheck {
 	}
 }
 
+// NewServiceHealthChecker creates a checker that reports failureStatus when
+// ping fails
+func NewServiceHealthChecker(name string, pingFunc func(ctx context.Context) error, failureStatus HealthStatus) *ServiceHealthChecker {
+	return &ServiceHealthChecker{
+		name:          name,
+		ping:          pingFunc,
+		failureStatus: failureStatus,
+	}
+}
+
+func (shc *ServiceHealthChecker) Name() string {
+	return shc.name
+}
+
+func (shc *ServiceHealthChecker) Check(ctx context.Context) HealthCheck {
+	start := time.Now()
+
+	timeout := getEnvDuration("MCP_MEMORY_SERVICE_CHECK_TIMEOUT_SECONDS", 10)
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := shc.ping(checkCtx)
+	duration := time.Since(start)
+
+	status := HealthStatusHealthy
+	message := fmt.Sprintf("%s is healthy", shc.name)
+	if err != nil {
+		status = shc.failureStatus
+		message = fmt.Sprintf("%s check failed: %v", shc.name, err)
+	}
+
+	return HealthCheck{
+		Name:      shc.name,
+		Status:    status,
+		Message:   message,
+		LastCheck: start,
+		Duration:  duration,
+		Metadata: map[string]interface{}{
+			"response_time_ms": duration.Milliseconds(),
+		},
+	}
+}
+
+// NewDiskSpaceHealthChecker creates a checker for the filesystem holding
+// path. The path need not exist yet; its nearest existing parent is checked.
+func NewDiskSpaceHealthChecker(name, path string) *DiskSpaceHealthChecker {
+	return &DiskSpaceHealthChecker{
+		name: name,
+		path: path,
+	}
+}
+
+func (dshc *DiskSpaceHealthChecker) Name() string {
+	return dshc.name
+}
+
+func (dshc *DiskSpaceHealthChecker) Check(ctx context.Context) HealthCheck {
+	start := time.Now()
+
+	freeBytes, totalBytes, err := diskUsage(existingParent(dshc.path))
+	if err != nil {
+		return HealthCheck{
+			Name:      dshc.name,
+			Status:    HealthStatusUnknown,
+			Message:   fmt.Sprintf("Failed to read disk usage for %s: %v", dshc.path, err),
+			LastCheck: start,
+			Duration:  time.Since(start),
+		}
+	}
+
+	freePercent := 100.0
+	if totalBytes > 0 {
+		freePercent = float64(freeBytes) / float64(totalBytes) * 100
+	}
+	freeMB := freeBytes / 1024 / 1024
+
+	status := HealthStatusHealthy
+	message := fmt.Sprintf("Disk space for %s: %d MB free (%.1f%%)", dshc.path, freeMB, freePercent)
+	if freePercent < getEnvFloat("MCP_MEMORY_DISK_UNHEALTHY_THRESHOLD_PERCENT", 2) {
+		status = HealthStatusUnhealthy
+		message = fmt.Sprintf("Disk space for %s is exhausted: %d MB free (%.1f%%)", dshc.path, freeMB, freePercent)
+	} else if freePercent < getEnvFloat("MCP_MEMORY_DISK_DEGRADED_THRESHOLD_PERCENT", 10) {
+		status = HealthStatusDegraded
+		message = fmt.Sprintf("Disk space for %s is low: %d MB free (%.1f%%)", dshc.path, freeMB, freePercent)
+	}
+
+	return HealthCheck{
+		Name:      dshc.name,
+		Status:    status,
+		Message:   message,
+		LastCheck: start,
+		Duration:  time.Since(start),
+		Metadata: map[string]interface{}{
+			"path":         dshc.path,
+			"free_mb":      freeMB,
+			"total_mb":     totalBytes / 1024 / 1024,
+			"free_percent": freePercent,
+		},
+	}
+}
+
+// existingParent returns path or its nearest ancestor that exists
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// NewCircuitBreakerHealthChecker creates a checker for the breaker whose
+// stats are returned by stats. When stats reports false no breaker is in
+// use and the check is healthy.
+func NewCircuitBreakerHealthChecker(name string, stats func() (circuitbreaker.Stats, bool), openStatus HealthStatus) *CircuitBreakerHealthChecker {
+	return &CircuitBreakerHealthChecker{
+		name:       name,
+		stats:      stats,
+		openStatus: openStatus,
+	}
+}
+
+func (cbhc *CircuitBreakerHealthChecker) Name() string {
+	return cbhc.name
+}
+
+func (cbhc *CircuitBreakerHealthChecker) Check(ctx context.Context) HealthCheck {
+	start := time.Now()
+
+	stats, ok := cbhc.stats()
+	if !ok {
+		return HealthCheck{
+			Name:      cbhc.name,
+			Status:    HealthStatusHealthy,
+			Message:   "Circuit breaker is disabled",
+			LastCheck: start,
+			Duration:  time.Since(start),
+		}
+	}
+
+	status := HealthStatusHealthy
+	message := "Circuit breaker is closed"
+	switch stats.State {
+	case circuitbreaker.StateOpen:
+		status = cbhc.openStatus
+		message = "Circuit breaker is open, requests are rejected"
+	case circuitbreaker.StateHalfOpen:
+		status = HealthStatusDegraded
+		message = "Circuit breaker is half-open, testing recovery"
+	case circuitbreaker.StateClosed:
+		// Requests flow normally
+	}
+
+	return HealthCheck{
+		Name:      cbhc.name,
+		Status:    status,
+		Message:   message,
+		LastCheck: start,
+		Duration:  time.Since(start),
+		Metadata: map[string]interface{}{
+			"state":              stats.State.String(),
+			"failure_rate":       stats.FailureRate,
+			"consecutive_errors": stats.ConsecutiveErrors,
+			"total_rejections":   stats.TotalRejections,
+		},
+	}
+}
+
 // getEnvDuration gets a duration from environment variable with a default
 func getEnvDuration(key string, defaultSeconds int) time.Duration {
 	if val := os.Getenv(key); val != "" {
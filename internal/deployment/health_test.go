@@ -0,0 +1,146 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"lerian-mcp-memory/internal/circuitbreaker"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingChecker returns a fixed status and counts its runs
+type countingChecker struct {
+	name   string
+	status HealthStatus
+	runs   int
+}
+
+func (c *countingChecker) Name() string {
+	return c.name
+}
+
+func (c *countingChecker) Check(context.Context) HealthCheck {
+	c.runs++
+	return HealthCheck{Name: c.name, Status: c.status, LastCheck: time.Now()}
+}
+
+func serve(handler http.HandlerFunc, path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+	return recorder
+}
+
+func TestHealthEndpointsStatusCodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     HealthStatus
+		wantHealth int
+		wantReady  int
+	}{
+		{"healthy", HealthStatusHealthy, http.StatusOK, http.StatusOK},
+		{"degraded stays in rotation", HealthStatusDegraded, http.StatusOK, http.StatusOK},
+		{"unhealthy is taken out of rotation", HealthStatusUnhealthy, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewHealthManager("test")
+			manager.AddChecker(&countingChecker{name: "qdrant", status: HealthStatusHealthy})
+			manager.AddChecker(&countingChecker{name: "dependency", status: tt.status})
+
+			assert.Equal(t, tt.wantHealth, serve(manager.HTTPHandler(), "/health").Code)
+
+			ready := serve(manager.ReadinessHandler(), "/ready")
+			assert.Equal(t, tt.wantReady, ready.Code)
+			var body struct {
+				Status string        `json:"status"`
+				Checks []HealthCheck `json:"checks"`
+			}
+			require.NoError(t, json.Unmarshal(ready.Body.Bytes(), &body))
+			assert.Len(t, body.Checks, 2, "readiness explains itself")
+
+			assert.Equal(t, http.StatusOK, serve(manager.LivenessHandler(), "/live").Code)
+		})
+	}
+}
+
+func TestHealthHandlersUseFreshCache(t *testing.T) {
+	checker := &countingChecker{name: "qdrant", status: HealthStatusHealthy}
+	manager := NewHealthManager("test")
+	manager.AddChecker(checker)
+	manager.SetMaxAge(time.Minute)
+
+	serve(manager.ReadinessHandler(), "/ready")
+	serve(manager.HTTPHandler(), "/health")
+	assert.Equal(t, 1, checker.runs, "the second probe reads the cached result")
+
+	manager.SetMaxAge(0)
+	serve(manager.HTTPHandler(), "/health")
+	assert.Equal(t, 2, checker.runs)
+}
+
+func TestCachedHealthKeepsCheckerOrder(t *testing.T) {
+	manager := NewHealthManager("test")
+	for _, name := range []string{"qdrant", "embeddings", "audit_log", "backup_disk"} {
+		manager.AddChecker(&countingChecker{name: name, status: HealthStatusHealthy})
+	}
+	manager.CheckHealth(context.Background())
+
+	var names []string
+	for _, check := range manager.GetCachedHealth().Checks {
+		names = append(names, check.Name)
+	}
+	assert.Equal(t, []string{"qdrant", "embeddings", "audit_log", "backup_disk"}, names)
+}
+
+func TestServiceHealthChecker(t *testing.T) {
+	failing := NewServiceHealthChecker("embeddings", func(context.Context) error {
+		return errors.New("rate limited")
+	}, HealthStatusDegraded)
+	check := failing.Check(context.Background())
+	assert.Equal(t, HealthStatusDegraded, check.Status)
+	assert.Contains(t, check.Message, "rate limited")
+
+	passing := NewServiceHealthChecker("audit_log", func(context.Context) error { return nil }, HealthStatusDegraded)
+	assert.Equal(t, HealthStatusHealthy, passing.Check(context.Background()).Status)
+}
+
+func TestDiskSpaceHealthChecker(t *testing.T) {
+	// A backup directory that does not exist yet is checked on its parent
+	checker := NewDiskSpaceHealthChecker("backup_disk", filepath.Join(t.TempDir(), "backups", "daily"))
+	check := checker.Check(context.Background())
+
+	assert.NotEqual(t, HealthStatusUnknown, check.Status, check.Message)
+	assert.Contains(t, check.Metadata, "free_mb")
+}
+
+func TestCircuitBreakerHealthChecker(t *testing.T) {
+	tests := []struct {
+		name    string
+		present bool
+		state   circuitbreaker.State
+		want    HealthStatus
+	}{
+		{"disabled", false, circuitbreaker.StateClosed, HealthStatusHealthy},
+		{"closed", true, circuitbreaker.StateClosed, HealthStatusHealthy},
+		{"half-open", true, circuitbreaker.StateHalfOpen, HealthStatusDegraded},
+		{"open", true, circuitbreaker.StateOpen, HealthStatusUnhealthy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewCircuitBreakerHealthChecker("vector_store_circuit_breaker", func() (circuitbreaker.Stats, bool) {
+				return circuitbreaker.Stats{State: tt.state}, tt.present
+			}, HealthStatusUnhealthy)
+			assert.Equal(t, tt.want, checker.Check(context.Background()).Status)
+		})
+	}
+}
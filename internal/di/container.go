@@ -9,14 +9,22 @@ import (
 	"lerian-mcp-memory/internal/chains"
 	"lerian-mcp-memory/internal/chunking"
 	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/decay"
 	"lerian-mcp-memory/internal/embeddings"
 	"lerian-mcp-memory/internal/intelligence"
+	"lerian-mcp-memory/internal/logging"
 	"lerian-mcp-memory/internal/persistence"
 	"lerian-mcp-memory/internal/relationships"
+	"lerian-mcp-memory/internal/security"
 	"lerian-mcp-memory/internal/storage"
+	"lerian-mcp-memory/internal/summarizer"
+	"lerian-mcp-memory/internal/templates"
 	"lerian-mcp-memory/internal/threading"
 	"lerian-mcp-memory/internal/workflow"
+	"log"
 	"os"
+	"sync"
+	"sync/atomic"
 )
 
 const envValueTrue = "true"
@@ -25,8 +33,12 @@ const envValueTrue = "true"
 type Container struct {
 	Config              *config.Config
 	VectorStore         storage.VectorStore
+	LexicalIndex        *storage.LexicalIndex
+	HybridSearcher      *storage.HybridSearcher
 	EmbeddingService    embeddings.EmbeddingService
 	ChunkingService     *chunking.Service
+	Summarizer          *summarizer.Service
+	DecayManager        *decay.MemoryDecayManager
 	ContextSuggester    *workflow.ContextSuggester
 	BackupManager       *persistence.BackupManager
 	LearningEngine      *intelligence.LearningEngine
@@ -43,6 +55,19 @@ type Container struct {
 	ThreadStore         threading.ThreadStore
 	MemoryAnalytics     *analytics.MemoryAnalytics
 	AuditLogger         *audit.Logger
+	TemplateManager     *templates.TemplateManager
+	AccessControl       *security.AccessControlManager
+	Encryption          *security.EncryptionManager
+	Redactor            *security.Redactor
+	EncryptedStore      *storage.EncryptedStore
+	ObservedStore       *storage.ObservedStore
+	RepoConfigs         *config.RepoStore
+
+	// current is the latest configuration; it differs from Config after a
+	// reload
+	current         atomic.Pointer[config.Config]
+	embeddingSwitch *embeddings.SwitchableEmbeddingService
+	reencryptMu     sync.Mutex
 }
 
 // NewContainer creates a new dependency injection container
@@ -50,58 +75,274 @@ func NewContainer(cfg *config.Config) (*Container, error) {
 	container := &Container{
 		Config: cfg,
 	}
+	container.current.Store(cfg)
+	logging.SetLevel(logging.ParseLogLevel(cfg.Logging.Level))
 
-	// Initialize in dependency order
+	repoConfigs, err := config.OpenRepoStore(cfg.Storage.RepositoriesFile)
+	if err != nil {
+		return nil, err
+	}
+	container.RepoConfigs = repoConfigs
+
+	// Initialize in dependency order; embeddings come first so new
+	// collections are created with the provider's vector size
+	if err := container.initializeEmbeddings(); err != nil {
+		return nil, err
+	}
+	if err := container.initializeEncryption(); err != nil {
+		return nil, err
+	}
+	container.Redactor = security.NewRedactor(container.Encryption)
+	summaries, err := summarizer.NewFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	container.Summarizer = summaries
 	container.initializeStorage()
 
 	container.initializeServices()
 	container.initializeIntelligence()
 	container.initializeWorkflow()
 
+	if err := container.initializeAccessControl(); err != nil {
+		return nil, err
+	}
+
 	return container, nil
 }
 
+// CurrentConfig returns the configuration in effect, including changes
+// applied by a reload
+func (c *Container) CurrentConfig() *config.Config {
+	return c.current.Load()
+}
+
+// ApplyConfig makes a reloaded configuration current. The log level, search
+// thresholds, decay settings, retention and per-repository settings take
+// effect at once; the changed settings that need a restart are returned.
+func (c *Container) ApplyConfig(cfg *config.Config) []string {
+	restart := config.RestartRequired(c.CurrentConfig(), cfg)
+	c.current.Store(cfg)
+	logging.SetLevel(logging.ParseLogLevel(cfg.Logging.Level))
+	if len(restart) > 0 {
+		log.Printf("Warning: changed settings need a restart to take effect: %v", restart)
+	}
+	return restart
+}
+
+// Redact scrubs content bound for a repository with the current redaction
+// settings and the repository's own rules
+func (c *Container) Redact(repository, content string) (string, []security.Redaction, error) {
+	repoConfig := c.RepoConfig(repository)
+	return c.Redactor.Redact(content, &c.CurrentConfig().Security.Redaction, repoConfig.RedactionRules)
+}
+
+// RepoConfig returns the settings for a repository: those stored at
+// runtime, else those in the config file, else the defaults
+func (c *Container) RepoConfig(repository string) config.RepoConfig {
+	if repoConfig, ok := c.RepoConfigs.Get(repository); ok {
+		return repoConfig
+	}
+	return c.CurrentConfig().GetRepoConfig(repository)
+}
+
+// initializeAccessControl loads users, tokens and grants when access control
+// is enabled. Without it, AccessControl stays nil and every call is allowed.
+func (c *Container) initializeAccessControl() error {
+	if !c.Config.Security.AccessControlEnabled {
+		return nil
+	}
+
+	acm, err := security.OpenAccessControlManager(c.Config.Security.AccessControlFile)
+	if err != nil {
+		return fmt.Errorf("failed to load access control: %w", err)
+	}
+	c.AccessControl = acm
+	return nil
+}
+
+// initializeEncryption opens the keyring when encryption at rest is enabled.
+// Rotating the data key starts a background re-encryption of stored chunks.
+func (c *Container) initializeEncryption() error {
+	if !c.Config.Security.EncryptionEnabled {
+		return nil
+	}
+
+	em, err := security.OpenEncryptionManager(c.Config.Security.EncryptionPassphrase, c.Config.Security.EncryptionKeyringFile)
+	if err != nil {
+		return fmt.Errorf("failed to open encryption keyring: %w", err)
+	}
+	em.OnKeyRotated(func(string) {
+		go c.runReencryption(context.Background())
+	})
+	c.Encryption = em
+	return nil
+}
+
+// ResumeReencryption finishes a re-encryption that a key rotation started
+// but a restart interrupted. It returns immediately; the job runs in the
+// background.
+func (c *Container) ResumeReencryption(ctx context.Context) {
+	if c.EncryptedStore == nil || !c.Encryption.ReencryptPending() {
+		return
+	}
+	go c.runReencryption(context.WithoutCancel(ctx))
+}
+
+// runReencryption rewrites chunks with the active data key. Jobs started by
+// back-to-back rotations run one after the other.
+func (c *Container) runReencryption(ctx context.Context) {
+	if c.EncryptedStore == nil {
+		return
+	}
+	c.reencryptMu.Lock()
+	defer c.reencryptMu.Unlock()
+	if _, err := c.EncryptedStore.Reencrypt(ctx); err != nil {
+		log.Printf("Warning: re-encryption failed, it will be retried on the next start: %v", err)
+	}
+}
+
+// initializeEmbeddings creates the configured embedding provider
+func (c *Container) initializeEmbeddings() error {
+	baseEmbedding, err := embeddings.NewEmbeddingService(c.Config)
+	if err != nil {
+		return err
+	}
+
+	// Components keep this reference; a completed re-embedding swaps the
+	// provider behind it
+	c.embeddingSwitch = embeddings.NewSwitchableEmbeddingService(wrapEmbeddingService(baseEmbedding))
+	c.EmbeddingService = c.embeddingSwitch
+	return nil
+}
+
+// wrapEmbeddingService adds tracing, retry and, when enabled,
+// circuit-breaker handling
+func wrapEmbeddingService(service embeddings.EmbeddingService) embeddings.EmbeddingService {
+	// Trace each provider call, so retries show as separate spans
+	service = embeddings.NewTracedEmbeddingService(service)
+
+	// Wrap with retry logic
+	retryEmbedding := embeddings.NewRetryableEmbeddingService(service, nil)
+
+	// Wrap with circuit breaker if enabled
+	if useCircuitBreaker := os.Getenv("USE_CIRCUIT_BREAKER"); useCircuitBreaker == envValueTrue {
+		return embeddings.NewCircuitBreakerEmbeddingService(retryEmbedding, nil)
+	}
+	return retryEmbedding
+}
+
+// NewEmbeddingServiceFor builds a wrapped provider for target without
+// changing the active one. Empty credentials fall back to the current
+// configuration.
+func (c *Container) NewEmbeddingServiceFor(target *config.EmbeddingConfig) (embeddings.EmbeddingService, error) {
+	cfg := *c.Config
+	cfg.Embedding = *target
+	if cfg.Embedding.APIKey == "" {
+		cfg.Embedding.APIKey = c.Config.Embedding.APIKey
+	}
+	if target.Provider == config.EmbeddingProviderOpenAI && target.Model != "" {
+		cfg.OpenAI.EmbeddingModel = target.Model
+	}
+
+	service, err := embeddings.NewEmbeddingService(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	return wrapEmbeddingService(service), nil
+}
+
+// SwitchEmbeddingService makes service, built for target, the active
+// provider. Only the running process changes; the configuration must be
+// updated to match before the next restart.
+func (c *Container) SwitchEmbeddingService(target *config.EmbeddingConfig, service embeddings.EmbeddingService) {
+	c.embeddingSwitch.Swap(service)
+
+	apiKey := c.Config.Embedding.APIKey
+	c.Config.Embedding = *target
+	if c.Config.Embedding.APIKey == "" {
+		c.Config.Embedding.APIKey = apiKey
+	}
+	if target.Provider == config.EmbeddingProviderOpenAI && target.Model != "" {
+		c.Config.OpenAI.EmbeddingModel = target.Model
+	}
+}
+
 // initializeStorage sets up storage layer
 func (c *Container) initializeStorage() {
 	var baseStore storage.VectorStore
+	dimension := c.EmbeddingService.GetDimension()
 
 	// Initialize vector store based on provider
 	switch c.Config.Storage.Provider {
-	case "qdrant":
-		baseStore = storage.NewQdrantStore(&c.Config.Qdrant)
+	case config.StorageProviderQdrant:
+		baseStore = c.newQdrantStore(dimension)
+	case config.StorageProviderLocal:
+		// Embedded on-disk store for development and CI without Qdrant
+		baseStore = storage.NewLocalStore(&c.Config.Storage.Local)
+	case config.StorageProviderPostgres:
+		// PostgreSQL + pgvector; threads are persisted in the same database
+		postgresConfig := c.Config.Storage.Postgres
+		postgresConfig.VectorDimension = dimension
+		postgresStore := storage.NewPostgresStore(&postgresConfig)
+		c.ThreadStore = postgresStore
+		baseStore = postgresStore
 	default:
 		// Default to Qdrant for new installations
-		baseStore = storage.NewQdrantStore(&c.Config.Qdrant)
+		baseStore = c.newQdrantStore(dimension)
 	}
 
+	// Trace each backend call, so retries show as separate spans
+	baseStore = storage.NewTracedVectorStore(baseStore, c.Config.Storage.Provider)
+
 	// Wrap with retry logic
 	retryStore := storage.NewRetryableVectorStore(baseStore, nil)
 
 	// Wrap with circuit breaker if enabled
+	var resilientStore storage.VectorStore = retryStore
 	if useCircuitBreaker := os.Getenv("USE_CIRCUIT_BREAKER"); useCircuitBreaker == envValueTrue {
-		c.VectorStore = storage.NewCircuitBreakerVectorStore(retryStore, nil)
-	} else {
-		c.VectorStore = retryStore
+		resilientStore = storage.NewCircuitBreakerVectorStore(retryStore, nil)
 	}
-}
 
-// initializeServices sets up core services
-func (c *Container) initializeServices() {
-	// Initialize embedding service
-	baseEmbedding := embeddings.NewOpenAIEmbeddingService(&c.Config.OpenAI)
+	// Encrypt below the lexical index so it keeps indexing plaintext
+	if c.Encryption != nil {
+		c.EncryptedStore = storage.NewEncryptedStore(resilientStore, storage.NewChunkCipher(c.Encryption))
+		resilientStore = c.EncryptedStore
+	}
 
-	// Wrap with retry logic
-	retryEmbedding := embeddings.NewRetryableEmbeddingService(baseEmbedding, nil)
+	// Keep the BM25 index in step with every chunk write
+	c.LexicalIndex = storage.NewLexicalIndex()
+	indexedStore := storage.NewLexicalIndexedStore(resilientStore, c.LexicalIndex)
 
-	// Wrap with circuit breaker if enabled
-	if useCircuitBreaker := os.Getenv("USE_CIRCUIT_BREAKER"); useCircuitBreaker == envValueTrue {
-		c.EmbeddingService = embeddings.NewCircuitBreakerEmbeddingService(retryEmbedding, nil)
-	} else {
-		c.EmbeddingService = retryEmbedding
+	// Report writes outermost so listeners see the plaintext chunk
+	// and refuse vectors embedded before a re-embedding switched models
+	c.ObservedStore = storage.NewObservedStore(indexedStore)
+	if c.embeddingSwitch != nil {
+		c.ObservedStore.CheckVectors(c.embeddingSwitch.StaleVectors)
 	}
+	c.VectorStore = c.ObservedStore
+	c.HybridSearcher = storage.NewHybridSearcher(c.VectorStore, c.LexicalIndex, c.Config.Search.RRFK)
+}
+
+// newQdrantStore creates a Qdrant store whose new collections match the
+// embedding dimension
+func (c *Container) newQdrantStore(dimension int) storage.VectorStore {
+	qdrantConfig := c.Config.Qdrant
+	qdrantConfig.VectorSize = dimension
+	return storage.NewQdrantStore(&qdrantConfig)
+}
 
+// initializeServices sets up core services
+func (c *Container) initializeServices() {
 	// Initialize chunking service
 	c.ChunkingService = chunking.NewService(&c.Config.Chunking, c.EmbeddingService)
+	c.ChunkingService.SetSummarizer(c.Summarizer)
+
+	// Initialize memory decay; the language model writes the summaries that
+	// replace decayed chunks when one is configured
+	decayStore := storage.NewDecayStorageAdapter(c.VectorStore, c.EmbeddingService)
+	c.DecayManager = decay.NewMemoryDecayManager(nil, decayStore, decay.NewDefaultSummarizer())
+	c.DecayManager.SetAbstractiveSummarizer(c.Summarizer)
 
 	// Initialize backup manager
 	backupDir := os.Getenv("MCP_MEMORY_BACKUP_DIRECTORY")
@@ -113,18 +354,49 @@ func (c *Container) initializeServices() {
 	// Initialize relationship manager
 	c.RelationshipManager = relationships.NewManager()
 
+	// Keep chains and threads in the storage backend when it supports
+	// documents so they survive restarts; postgres keeps threads natively
+	if documents, ok := storage.FindDocumentStore(c.VectorStore); ok {
+		c.ChainStore = storage.NewDocumentChainStore(documents)
+		if c.ThreadStore == nil {
+			c.ThreadStore = storage.NewDocumentThreadStore(documents)
+		}
+	}
+
 	// Initialize chain components
-	c.ChainStore = chains.NewInMemoryChainStore()
+	if c.ChainStore == nil {
+		c.ChainStore = chains.NewInMemoryChainStore()
+	}
 	chainAnalyzer := chains.NewDefaultChainAnalyzer(c.EmbeddingService)
 	c.ChainBuilder = chains.NewChainBuilder(c.ChainStore, chainAnalyzer)
 
 	// Initialize threading components
-	c.ThreadStore = threading.NewInMemoryThreadStore()
+	if c.ThreadStore == nil {
+		c.ThreadStore = threading.NewInMemoryThreadStore()
+	}
+	c.BackupManager.SetThreadStore(c.ThreadStore)
+	c.BackupManager.SetChainStore(c.ChainStore)
+	if c.EncryptedStore != nil {
+		c.BackupManager.SetChunkEncryption(c.EncryptedStore.Cipher())
+	}
 	c.ThreadManager = threading.NewThreadManager(c.ChainBuilder, c.RelationshipManager, c.ThreadStore)
 
 	// Initialize memory analytics
 	c.MemoryAnalytics = analytics.NewMemoryAnalytics(c.VectorStore)
 
+	// Initialize memory templates, adding the team's own when configured
+	c.TemplateManager = templates.NewTemplateManager()
+	if dir := c.Config.Templates.Directory; dir != "" {
+		loaded, err := c.TemplateManager.LoadTemplatesFromDir(dir)
+		if err != nil {
+			// Log error but don't fail initialization
+			log.Printf("Warning: Failed to load templates from %s: %v", dir, err)
+		}
+		if loaded > 0 {
+			log.Printf("Loaded %d templates from %s", loaded, dir)
+		}
+	}
+
 	// Initialize audit logger
 	auditDir := os.Getenv("MCP_MEMORY_AUDIT_DIRECTORY")
 	if auditDir == "" {
@@ -213,11 +485,27 @@ func (c *Container) GetVectorStore() storage.VectorStore {
 	return c.VectorStore
 }
 
+// VerifyEmbeddingDimension refuses to run when the vector store's collection
+// was built with a different embedding dimension than the configured
+// provider produces. Call it after the store is initialized.
+func (c *Container) VerifyEmbeddingDimension(ctx context.Context) error {
+	if err := storage.VerifyVectorDimension(ctx, c.VectorStore, c.EmbeddingService.GetDimension()); err != nil {
+		return fmt.Errorf("embedding provider %s (%s) does not match the vector store: %w",
+			c.Config.Embedding.Provider, c.EmbeddingService.GetModel(), err)
+	}
+	return nil
+}
+
 // GetEmbeddingService returns the embedding service instance
 func (c *Container) GetEmbeddingService() embeddings.EmbeddingService {
 	return c.EmbeddingService
 }
 
+// GetDecayManager returns the memory decay manager
+func (c *Container) GetDecayManager() *decay.MemoryDecayManager {
+	return c.DecayManager
+}
+
 // GetChunkingService returns the chunking service instance
 func (c *Container) GetChunkingService() *chunking.Service {
 	return c.ChunkingService
@@ -248,6 +536,16 @@ func (c *Container) GetPatternAnalyzer() *workflow.PatternAnalyzer {
 	return c.PatternAnalyzer
 }
 
+// GetLexicalIndex returns the BM25 index maintained next to the vector store
+func (c *Container) GetLexicalIndex() *storage.LexicalIndex {
+	return c.LexicalIndex
+}
+
+// GetHybridSearcher returns the vector/lexical/hybrid searcher
+func (c *Container) GetHybridSearcher() *storage.HybridSearcher {
+	return c.HybridSearcher
+}
+
 // GetChainBuilder returns the chain builder instance
 func (c *Container) GetChainBuilder() *chains.ChainBuilder {
 	return c.ChainBuilder
@@ -273,6 +571,23 @@ func (c *Container) GetThreadManager() *threading.ThreadManager {
 	return c.ThreadManager
 }
 
+// GetTemplateManager returns the memory template manager instance
+func (c *Container) GetTemplateManager() *templates.TemplateManager {
+	return c.TemplateManager
+}
+
+// GetAccessControl returns the access control manager, or nil when access
+// control is disabled
+func (c *Container) GetAccessControl() *security.AccessControlManager {
+	return c.AccessControl
+}
+
+// GetEncryption returns the encryption manager, or nil when encryption at
+// rest is disabled
+func (c *Container) GetEncryption() *security.EncryptionManager {
+	return c.Encryption
+}
+
 // GetThreadStore returns the thread store instance
 func (c *Container) GetThreadStore() threading.ThreadStore {
 	return c.ThreadStore
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/deployment"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -36,6 +37,7 @@ func TestNewContainer(t *testing.T) {
 				assert.NotNil(t, c.GetVectorStore())
 				assert.NotNil(t, c.GetEmbeddingService())
 				assert.NotNil(t, c.GetChunkingService())
+				assert.NotNil(t, c.GetDecayManager())
 				assert.NotNil(t, c.GetBackupManager())
 				assert.NotNil(t, c.GetLearningEngine())
 				assert.NotNil(t, c.GetMultiRepoEngine())
@@ -491,3 +493,33 @@ func BenchmarkContainerHealthCheck(b *testing.B) {
 		}
 	}
 }
+
+func TestNewHealthManager(t *testing.T) {
+	t.Setenv("USE_CIRCUIT_BREAKER", "true")
+	t.Setenv("MCP_MEMORY_AUDIT_DIRECTORY", t.TempDir())
+	t.Setenv("MCP_MEMORY_BACKUP_DIRECTORY", filepath.Join(t.TempDir(), "backups"))
+
+	cfg := config.DefaultConfig()
+	cfg.Storage.Provider = config.StorageProviderLocal
+	cfg.Storage.Local.DataDir = t.TempDir()
+	cfg.Storage.RepositoriesFile = filepath.Join(t.TempDir(), "repositories.json")
+	cfg.Embedding.Provider = config.EmbeddingProviderHash
+
+	container, err := NewContainer(cfg)
+	require.NoError(t, err)
+	require.NoError(t, container.GetVectorStore().Initialize(context.Background()))
+	defer func() { _ = container.Shutdown() }()
+
+	health := container.NewHealthManager("test").CheckHealth(context.Background())
+	statuses := make(map[string]deployment.HealthStatus)
+	for _, check := range health.Checks {
+		statuses[check.Name] = check.Status
+	}
+	assert.Equal(t, deployment.HealthStatusHealthy, statuses[config.StorageProviderLocal])
+	assert.Equal(t, deployment.HealthStatusHealthy, statuses["vector_store_circuit_breaker"])
+	assert.Equal(t, deployment.HealthStatusHealthy, statuses["embeddings"])
+	assert.Equal(t, deployment.HealthStatusHealthy, statuses["embeddings_circuit_breaker"])
+	assert.Equal(t, deployment.HealthStatusHealthy, statuses["audit_log"])
+	assert.Contains(t, statuses, "backup_disk")
+	assert.Contains(t, statuses, "memory")
+}
@@ -0,0 +1,72 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"lerian-mcp-memory/internal/circuitbreaker"
+	"lerian-mcp-memory/internal/deployment"
+	"lerian-mcp-memory/internal/embeddings"
+	"lerian-mcp-memory/internal/storage"
+)
+
+// NewHealthManager assembles the checks behind /health and /ready. Losing
+// the vector store, or its circuit breaker opening, makes the server
+// unhealthy; the embedding provider, audit log and backup disk only degrade
+// it, since reads keep working without them.
+func (c *Container) NewHealthManager(version string) *deployment.HealthManager {
+	manager := deployment.NewHealthManager(version)
+
+	manager.AddChecker(deployment.NewVectorStorageHealthChecker(c.Config.Storage.Provider, c.VectorStore.HealthCheck))
+	manager.AddChecker(deployment.NewCircuitBreakerHealthChecker("vector_store_circuit_breaker", c.vectorStoreBreakerStats, deployment.HealthStatusUnhealthy))
+
+	manager.AddChecker(deployment.NewServiceHealthChecker("embeddings", c.EmbeddingService.HealthCheck, deployment.HealthStatusDegraded))
+	manager.AddChecker(deployment.NewCircuitBreakerHealthChecker("embeddings_circuit_breaker", c.embeddingBreakerStats, deployment.HealthStatusDegraded))
+
+	manager.AddChecker(deployment.NewServiceHealthChecker("audit_log", c.auditLogHealth, deployment.HealthStatusDegraded))
+	manager.AddChecker(deployment.NewDiskSpaceHealthChecker("backup_disk", c.BackupManager.GetBackupDir()))
+
+	manager.AddChecker(deployment.NewMemoryHealthChecker(uint64(c.Config.Server.HealthMaxMemoryMB)))
+
+	return manager
+}
+
+// HealthCheckInterval is how often the health manager should refresh its
+// results in the background
+func (c *Container) HealthCheckInterval() time.Duration {
+	return time.Duration(c.Config.Server.HealthCheckInterval) * time.Second
+}
+
+// vectorStoreBreakerStats returns the storage circuit breaker's stats, if
+// one is in use
+func (c *Container) vectorStoreBreakerStats() (circuitbreaker.Stats, bool) {
+	breaker, ok := storage.FindCircuitBreakerStore(c.VectorStore)
+	if !ok {
+		return circuitbreaker.Stats{}, false
+	}
+	return breaker.GetCircuitBreakerStats(), true
+}
+
+// embeddingBreakerStats returns the active provider's circuit breaker
+// stats, if one is in use. It looks at the current provider, so a switch
+// after re-embedding is picked up.
+func (c *Container) embeddingBreakerStats() (circuitbreaker.Stats, bool) {
+	if c.embeddingSwitch == nil {
+		return circuitbreaker.Stats{}, false
+	}
+	breaker, ok := c.embeddingSwitch.Current().(*embeddings.CircuitBreakerEmbeddingService)
+	if !ok {
+		return circuitbreaker.Stats{}, false
+	}
+	return breaker.GetCircuitBreakerStats(), true
+}
+
+// auditLogHealth checks the audit logger; one that failed to start is
+// reported as a failure
+func (c *Container) auditLogHealth(ctx context.Context) error {
+	if c.AuditLogger == nil {
+		return errors.New("audit logger failed to start")
+	}
+	return c.AuditLogger.HealthCheck(ctx)
+}
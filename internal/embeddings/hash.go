@@ -0,0 +1,121 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	defaultHashDimension = 384
+	hashEmbeddingModel   = "feature-hash-v1"
+	hashTrigramWeight    = 0.5
+)
+
+// HashEmbeddingService produces deterministic embeddings by feature hashing
+// words and character trigrams into a fixed number of buckets. It needs no
+// network or model, which suits air-gapped installs and tests. Similarity
+// reflects shared vocabulary and spelling rather than meaning.
+type HashEmbeddingService struct {
+	dimension int
+}
+
+// NewHashEmbeddingService creates a hashing embedder; dimension <= 0 uses 384
+func NewHashEmbeddingService(dimension int) *HashEmbeddingService {
+	if dimension <= 0 {
+		dimension = defaultHashDimension
+	}
+	return &HashEmbeddingService{dimension: dimension}
+}
+
+// GenerateEmbedding generates an L2-normalized embedding for a single text
+func (s *HashEmbeddingService) GenerateEmbedding(_ context.Context, text string) ([]float64, error) {
+	if text == "" {
+		return nil, errors.New("text cannot be empty")
+	}
+	return s.embed(text), nil
+}
+
+// GenerateBatchEmbeddings generates embeddings for multiple texts. Empty
+// texts leave a nil entry, matching the OpenAI service.
+func (s *HashEmbeddingService) GenerateBatchEmbeddings(_ context.Context, texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return nil, errors.New("texts cannot be empty")
+	}
+
+	results := make([][]float64, len(texts))
+	for i, text := range texts {
+		if text != "" {
+			results[i] = s.embed(text)
+		}
+	}
+	return results, nil
+}
+
+// embed hashes each feature to a bucket and a sign, so colliding features
+// tend to cancel out instead of accumulating bias
+func (s *HashEmbeddingService) embed(text string) []float64 {
+	vector := make([]float64, s.dimension)
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		// Punctuation-only text still gets a stable, non-zero vector
+		words = []string{strings.TrimSpace(text)}
+	}
+
+	for _, word := range words {
+		s.addFeature(vector, "w:"+word, 1)
+
+		padded := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			s.addFeature(vector, "t:"+string(padded[i:i+3]), hashTrigramWeight)
+		}
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm == 0 {
+		vector[0] = 1
+		return vector
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] /= norm
+	}
+	return vector
+}
+
+// addFeature adds a signed weight to the feature's bucket
+func (s *HashEmbeddingService) addFeature(vector []float64, feature string, weight float64) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(feature))
+	sum := h.Sum64()
+
+	bucket := sum % uint64(len(vector))
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	vector[bucket] += weight
+}
+
+// GetDimension returns the dimension of embeddings produced by this service
+func (s *HashEmbeddingService) GetDimension() int {
+	return s.dimension
+}
+
+// GetModel returns the model name
+func (s *HashEmbeddingService) GetModel() string {
+	return hashEmbeddingModel
+}
+
+// HealthCheck always succeeds; the embedder has no external dependencies
+func (s *HashEmbeddingService) HealthCheck(_ context.Context) error {
+	return nil
+}
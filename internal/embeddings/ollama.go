@@ -0,0 +1,177 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"lerian-mcp-memory/internal/config"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOllamaBaseURL = "http://localhost:11434"
+	defaultOllamaModel   = "nomic-embed-text"
+	ollamaEmbedPath      = "/api/embed"
+	maxOllamaErrorBody   = 512
+)
+
+// OllamaEmbeddingService implements EmbeddingService using a local Ollama
+// server's /api/embed endpoint
+type OllamaEmbeddingService struct {
+	httpClient  *http.Client
+	baseURL     string
+	model       string
+	dimension   int
+	rateLimiter *RateLimiter
+}
+
+// ollamaEmbedRequest is the /api/embed request body
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// ollamaEmbedResponse is the /api/embed response body
+type ollamaEmbedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// NewOllamaEmbeddingService creates an Ollama embedding service. BaseURL and
+// Model default to a local server running nomic-embed-text.
+func NewOllamaEmbeddingService(cfg *config.EmbeddingConfig) (*OllamaEmbeddingService, error) {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOllamaBaseURL
+	}
+	model := cfg.Model
+	if model == "" {
+		model = defaultOllamaModel
+	}
+	dimension, err := resolveDimension(model, cfg.Dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OllamaEmbeddingService{
+		httpClient: &http.Client{
+			Timeout: time.Duration(requestTimeoutSeconds(cfg.RequestTimeout)) * time.Second,
+		},
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		model:       model,
+		dimension:   dimension,
+		rateLimiter: newProviderRateLimiter(cfg.RateLimitRPM),
+	}, nil
+}
+
+// GenerateEmbedding generates an embedding for a single text
+func (s *OllamaEmbeddingService) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	if text == "" {
+		return nil, errors.New("text cannot be empty")
+	}
+
+	embeddings, err := s.embed(ctx, embedOperation, []string{text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding: %w", err)
+	}
+	return embeddings[0], nil
+}
+
+// GenerateBatchEmbeddings generates embeddings for multiple texts. Empty
+// texts are skipped and leave a nil entry, matching the OpenAI service.
+func (s *OllamaEmbeddingService) GenerateBatchEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return nil, errors.New("texts cannot be empty")
+	}
+
+	inputs := make([]string, 0, len(texts))
+	indices := make([]int, 0, len(texts))
+	for i, text := range texts {
+		if text == "" {
+			continue
+		}
+		inputs = append(inputs, text)
+		indices = append(indices, i)
+	}
+
+	results := make([][]float64, len(texts))
+	if len(inputs) == 0 {
+		return results, nil
+	}
+
+	embeddings, err := s.embed(ctx, embedBatchOperation, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch embeddings: %w", err)
+	}
+	for i, embedding := range embeddings {
+		results[indices[i]] = embedding
+	}
+	return results, nil
+}
+
+// embed calls /api/embed and validates the number and size of the vectors
+func (s *OllamaEmbeddingService) embed(ctx context.Context, operation string, inputs []string) (embeddings [][]float64, err error) {
+	if err := s.rateLimiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limiter error: %w", err)
+	}
+	start := time.Now()
+	defer func() { observeEmbedding(config.EmbeddingProviderOllama, operation, start, err) }()
+
+	body, err := json.Marshal(ollamaEmbedRequest{Model: s.model, Input: inputs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+ollamaEmbedPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, maxOllamaErrorBody))
+		// Status code is kept in the message so the retry wrapper can classify it
+		return nil, fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	var decoded ollamaEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(decoded.Embeddings) != len(inputs) {
+		return nil, fmt.Errorf("mismatch between input texts (%d) and embeddings (%d)", len(inputs), len(decoded.Embeddings))
+	}
+	for _, embedding := range decoded.Embeddings {
+		if len(embedding) != s.dimension {
+			return nil, fmt.Errorf("embedding dimension mismatch: expected %d, got %d", s.dimension, len(embedding))
+		}
+	}
+	return decoded.Embeddings, nil
+}
+
+// GetDimension returns the dimension of embeddings produced by this service
+func (s *OllamaEmbeddingService) GetDimension() int {
+	return s.dimension
+}
+
+// GetModel returns the model name
+func (s *OllamaEmbeddingService) GetModel() string {
+	return s.model
+}
+
+// HealthCheck verifies the server is reachable and the model is pulled
+func (s *OllamaEmbeddingService) HealthCheck(ctx context.Context) error {
+	_, err := s.GenerateEmbedding(ctx, "health check")
+	return err
+}
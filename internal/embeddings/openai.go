@@ -1,4 +1,5 @@
-// Package embeddings provides OpenAI integration for generating and managing
+// Package embeddings provides embedding providers (OpenAI, OpenAI-compatible
+// servers, Ollama and an offline hashing embedder) for generating and managing
 // text embeddings with circuit breaker and retry capabilities.
 package embeddings
 
@@ -9,9 +10,11 @@ import (
 	"errors"
 	"fmt"
 	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/metrics"
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -43,6 +46,8 @@ type OpenAIEmbeddingService struct {
 	cache       map[string][]float64
 	cacheMu     sync.RWMutex
 	rateLimiter *RateLimiter
+	dimension   int    // overrides the model table when set
+	provider    string // provider label on metrics
 }
 
 // RateLimiter implements a simple rate limiter for API calls
@@ -121,9 +126,43 @@ func NewOpenAIEmbeddingService(cfg *config.OpenAIConfig) *OpenAIEmbeddingService
 		config:      cfg,
 		cache:       make(map[string][]float64),
 		rateLimiter: rateLimiter,
+		provider:    config.EmbeddingProviderOpenAI,
 	}
 }
 
+// NewOpenAICompatibleEmbeddingService creates an embedding service for any
+// server exposing the OpenAI /v1/embeddings API (vLLM, LM Studio, LocalAI,
+// llama.cpp server and similar) at cfg.BaseURL
+func NewOpenAICompatibleEmbeddingService(cfg *config.EmbeddingConfig) (*OpenAIEmbeddingService, error) {
+	if cfg.BaseURL == "" {
+		return nil, errors.New("embedding base URL is required")
+	}
+	if cfg.Model == "" {
+		return nil, errors.New("embedding model is required")
+	}
+	dimension, err := resolveDimension(cfg.Model, cfg.Dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	clientConfig := openai.DefaultConfig(cfg.APIKey)
+	clientConfig.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	return &OpenAIEmbeddingService{
+		client: openai.NewClientWithConfig(clientConfig),
+		config: &config.OpenAIConfig{
+			APIKey:         cfg.APIKey,
+			EmbeddingModel: cfg.Model,
+			RequestTimeout: requestTimeoutSeconds(cfg.RequestTimeout),
+			RateLimitRPM:   cfg.RateLimitRPM,
+		},
+		cache:       make(map[string][]float64),
+		rateLimiter: newProviderRateLimiter(cfg.RateLimitRPM),
+		dimension:   dimension,
+		provider:    config.EmbeddingProviderOpenAICompatible,
+	}, nil
+}
+
 // GenerateEmbedding generates an embedding for a single text
 func (oes *OpenAIEmbeddingService) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
 	if text == "" {
@@ -152,7 +191,9 @@ func (oes *OpenAIEmbeddingService) GenerateEmbedding(ctx context.Context, text s
 	defer cancel()
 
 	// Make API call
+	start := time.Now()
 	resp, err := oes.client.CreateEmbeddings(timeoutCtx, req)
+	observeEmbedding(oes.provider, embedOperation, start, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create embedding: %w", err)
 	}
@@ -162,6 +203,9 @@ func (oes *OpenAIEmbeddingService) GenerateEmbedding(ctx context.Context, text s
 	}
 
 	embedding := resp.Data[0].Embedding
+	if oes.dimension > 0 && len(embedding) != oes.dimension {
+		return nil, fmt.Errorf("embedding dimension mismatch: expected %d, got %d", oes.dimension, len(embedding))
+	}
 
 	// Convert []float32 to []float64
 	embeddingFloat64 := make([]float64, len(embedding))
@@ -221,7 +265,9 @@ func (oes *OpenAIEmbeddingService) GenerateBatchEmbeddings(ctx context.Context,
 	defer cancel()
 
 	// Make API call
+	start := time.Now()
 	resp, err := oes.client.CreateEmbeddings(timeoutCtx, req)
+	observeEmbedding(oes.provider, embedBatchOperation, start, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create batch embeddings: %w", err)
 	}
@@ -233,6 +279,9 @@ func (oes *OpenAIEmbeddingService) GenerateBatchEmbeddings(ctx context.Context,
 	// Place embeddings in correct positions and cache them
 	for i, embeddingData := range resp.Data {
 		embedding := embeddingData.Embedding
+		if oes.dimension > 0 && len(embedding) != oes.dimension {
+			return nil, fmt.Errorf("embedding dimension mismatch: expected %d, got %d", oes.dimension, len(embedding))
+		}
 
 		// Convert []float32 to []float64
 		embeddingFloat64 := make([]float64, len(embedding))
@@ -253,6 +302,10 @@ func (oes *OpenAIEmbeddingService) GenerateBatchEmbeddings(ctx context.Context,
 
 // GetDimension returns the dimension of embeddings produced by this service
 func (oes *OpenAIEmbeddingService) GetDimension() int {
+	if oes.dimension > 0 {
+		return oes.dimension
+	}
+
 	// text-embedding-ada-002 produces 1536-dimensional embeddings
 	switch oes.config.EmbeddingModel {
 	case "text-embedding-ada-002":
@@ -291,12 +344,14 @@ func (oes *OpenAIEmbeddingService) getFromCache(key string) []float64 {
 	defer oes.cacheMu.RUnlock()
 
 	if embedding, exists := oes.cache[key]; exists {
+		metrics.CacheRequests.Inc(embeddingCacheName, metrics.CacheHit)
 		// Return a copy to prevent modification
 		result := make([]float64, len(embedding))
 		copy(result, embedding)
 		return result
 	}
 
+	metrics.CacheRequests.Inc(embeddingCacheName, metrics.CacheMiss)
 	return nil
 }
 
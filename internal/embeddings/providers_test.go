@@ -0,0 +1,186 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"lerian-mcp-memory/internal/config"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cosine(a, b []float64) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+func TestHashEmbeddingService(t *testing.T) {
+	ctx := context.Background()
+	service := NewHashEmbeddingService(0)
+	assert.Equal(t, defaultHashDimension, service.GetDimension())
+	assert.Equal(t, hashEmbeddingModel, service.GetModel())
+	require.NoError(t, service.HealthCheck(ctx))
+
+	first, err := service.GenerateEmbedding(ctx, "Fix connection pool timeout in Qdrant client")
+	require.NoError(t, err)
+	second, err := service.GenerateEmbedding(ctx, "Fix connection pool timeout in Qdrant client")
+	require.NoError(t, err)
+	assert.Equal(t, first, second, "embeddings are deterministic")
+	assert.Len(t, first, defaultHashDimension)
+	assert.InDelta(t, 1.0, cosine(first, first), 1e-9, "embeddings are normalized")
+
+	related, err := service.GenerateEmbedding(ctx, "Qdrant connection timeout when the pool is exhausted")
+	require.NoError(t, err)
+	unrelated, err := service.GenerateEmbedding(ctx, "Update README badges and license year")
+	require.NoError(t, err)
+	assert.Greater(t, cosine(first, related), cosine(first, unrelated))
+
+	punctuation, err := service.GenerateEmbedding(ctx, "!!!")
+	require.NoError(t, err)
+	assert.NotZero(t, cosine(punctuation, punctuation))
+
+	_, err = service.GenerateEmbedding(ctx, "")
+	assert.Error(t, err)
+
+	batch, err := service.GenerateBatchEmbeddings(ctx, []string{"a text", "", "another"})
+	require.NoError(t, err)
+	require.Len(t, batch, 3)
+	assert.Nil(t, batch[1])
+	assert.Len(t, NewHashEmbeddingService(64).embed("dimension"), 64)
+}
+
+func TestOllamaEmbeddingService(t *testing.T) {
+	ctx := context.Background()
+	var requests []ollamaEmbedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, ollamaEmbedPath, r.URL.Path)
+		var req ollamaEmbedRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+
+		if req.Model == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"model not found"}`))
+			return
+		}
+		resp := ollamaEmbedResponse{Model: req.Model}
+		for i := range req.Input {
+			resp.Embeddings = append(resp.Embeddings, []float64{float64(i), 1, 0, 0})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	service, err := NewOllamaEmbeddingService(&config.EmbeddingConfig{BaseURL: server.URL + "/", Model: "custom", Dimension: 4})
+	require.NoError(t, err)
+	assert.Equal(t, 4, service.GetDimension())
+	assert.Equal(t, "custom", service.GetModel())
+
+	embedding, err := service.GenerateEmbedding(ctx, "hello")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0, 1, 0, 0}, embedding)
+
+	batch, err := service.GenerateBatchEmbeddings(ctx, []string{"one", "", "two"})
+	require.NoError(t, err)
+	assert.Nil(t, batch[1])
+	assert.Equal(t, []float64{1, 1, 0, 0}, batch[2])
+	assert.Equal(t, []string{"one", "two"}, requests[len(requests)-1].Input, "empty texts are not sent")
+
+	t.Run("dimension mismatch", func(t *testing.T) {
+		wrong, err := NewOllamaEmbeddingService(&config.EmbeddingConfig{BaseURL: server.URL, Model: "custom", Dimension: 8})
+		require.NoError(t, err)
+		_, err = wrong.GenerateEmbedding(ctx, "hello")
+		assert.ErrorContains(t, err, "dimension mismatch")
+	})
+
+	t.Run("server error keeps status code", func(t *testing.T) {
+		missing, err := NewOllamaEmbeddingService(&config.EmbeddingConfig{BaseURL: server.URL, Model: "missing", Dimension: 4})
+		require.NoError(t, err)
+		err = missing.HealthCheck(ctx)
+		assert.ErrorContains(t, err, "404")
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		defaults, err := NewOllamaEmbeddingService(&config.EmbeddingConfig{})
+		require.NoError(t, err)
+		assert.Equal(t, defaultOllamaModel, defaults.GetModel())
+		assert.Equal(t, 768, defaults.GetDimension())
+		assert.Equal(t, defaultOllamaBaseURL, defaults.baseURL)
+	})
+}
+
+func TestOpenAICompatibleEmbeddingService(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer local-key", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"object":"list","model":"bge","data":[{"object":"embedding","index":0,"embedding":[0.5,0.5,0]}]}`))
+	}))
+	defer server.Close()
+
+	service, err := NewOpenAICompatibleEmbeddingService(&config.EmbeddingConfig{
+		BaseURL:   server.URL + "/v1",
+		Model:     "bge",
+		APIKey:    "local-key",
+		Dimension: 3,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, service.GetDimension())
+
+	embedding, err := service.GenerateEmbedding(ctx, "hello")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0.5, 0.5, 0}, embedding)
+
+	_, err = NewOpenAICompatibleEmbeddingService(&config.EmbeddingConfig{BaseURL: server.URL, Model: "unknown-model"})
+	assert.ErrorContains(t, err, "MCP_MEMORY_EMBEDDING_DIMENSION")
+}
+
+func TestNewEmbeddingService(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.OpenAI.APIKey = "test-key"
+
+	service, err := NewEmbeddingService(cfg)
+	require.NoError(t, err)
+	assert.IsType(t, &OpenAIEmbeddingService{}, service)
+
+	cfg.Embedding.Provider = config.EmbeddingProviderHash
+	cfg.Embedding.Dimension = 128
+	service, err = NewEmbeddingService(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 128, service.GetDimension())
+
+	cfg.Embedding.Provider = "unknown"
+	_, err = NewEmbeddingService(cfg)
+	assert.ErrorContains(t, err, "unknown embedding provider")
+
+	RegisterProvider("test-fixed", func(_ *config.Config) (EmbeddingService, error) {
+		return NewHashEmbeddingService(16), nil
+	})
+	assert.Contains(t, Providers(), "test-fixed")
+	cfg.Embedding.Provider = "test-fixed"
+	service, err = NewEmbeddingService(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 16, service.GetDimension())
+}
+
+func TestResolveDimension(t *testing.T) {
+	dimension, err := resolveDimension("nomic-embed-text:latest", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 768, dimension)
+
+	dimension, err = resolveDimension("anything", 512)
+	require.NoError(t, err)
+	assert.Equal(t, 512, dimension)
+
+	_, err = resolveDimension("anything", 0)
+	assert.Error(t, err)
+}
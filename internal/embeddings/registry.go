@@ -0,0 +1,143 @@
+package embeddings
+
+import (
+	"fmt"
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/metrics"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultProviderTimeoutSeconds = 60
+
+// Operation and cache labels on embedding metrics
+const (
+	embedOperation      = "embed"
+	embedBatchOperation = "embed_batch"
+	embeddingCacheName  = "embedding"
+)
+
+// ProviderFactory builds an unwrapped EmbeddingService from the application
+// configuration. Retry and circuit-breaker wrappers are applied by the caller.
+type ProviderFactory func(cfg *config.Config) (EmbeddingService, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]ProviderFactory{
+		config.EmbeddingProviderOpenAI: func(cfg *config.Config) (EmbeddingService, error) {
+			return NewOpenAIEmbeddingService(&cfg.OpenAI), nil
+		},
+		config.EmbeddingProviderOpenAICompatible: func(cfg *config.Config) (EmbeddingService, error) {
+			return NewOpenAICompatibleEmbeddingService(&cfg.Embedding)
+		},
+		config.EmbeddingProviderOllama: func(cfg *config.Config) (EmbeddingService, error) {
+			return NewOllamaEmbeddingService(&cfg.Embedding)
+		},
+		config.EmbeddingProviderHash: func(cfg *config.Config) (EmbeddingService, error) {
+			return NewHashEmbeddingService(cfg.Embedding.Dimension), nil
+		},
+	}
+)
+
+// knownModelDimensions lists the output size of common embedding models so
+// the dimension does not have to be configured for them
+var knownModelDimensions = map[string]int{
+	"text-embedding-ada-002":  1536,
+	"text-embedding-3-small":  1536,
+	"text-embedding-3-large":  3072,
+	"nomic-embed-text":        768,
+	"mxbai-embed-large":       1024,
+	"all-minilm":              384,
+	"snowflake-arctic-embed":  1024,
+	"snowflake-arctic-embed2": 1024,
+	"bge-m3":                  1024,
+	"bge-large":               1024,
+	"granite-embedding":       384,
+}
+
+// RegisterProvider adds or replaces an embedding provider under name
+func RegisterProvider(name string, factory ProviderFactory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = factory
+}
+
+// Providers returns the registered provider names in sorted order
+func Providers() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewEmbeddingService builds the provider selected by cfg.Embedding.Provider,
+// defaulting to OpenAI
+func NewEmbeddingService(cfg *config.Config) (EmbeddingService, error) {
+	name := cfg.Embedding.Provider
+	if name == "" {
+		name = config.EmbeddingProviderOpenAI
+	}
+
+	providersMu.RLock()
+	factory, ok := providers[name]
+	providersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown embedding provider %q (available: %s)", name, strings.Join(Providers(), ", "))
+	}
+
+	service, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s embedding provider: %w", name, err)
+	}
+	return service, nil
+}
+
+// resolveDimension returns the configured dimension, or the known size of
+// model when none is configured. Ollama-style ":tag" suffixes are ignored.
+func resolveDimension(model string, configured int) (int, error) {
+	if configured > 0 {
+		return configured, nil
+	}
+	if dimension, ok := knownModelDimensions[model]; ok {
+		return dimension, nil
+	}
+	if base, _, found := strings.Cut(model, ":"); found {
+		if dimension, ok := knownModelDimensions[base]; ok {
+			return dimension, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown dimension for embedding model %q: set MCP_MEMORY_EMBEDDING_DIMENSION", model)
+}
+
+// requestTimeoutSeconds applies the default timeout to non-positive values
+func requestTimeoutSeconds(seconds int) int {
+	if seconds <= 0 {
+		return defaultProviderTimeoutSeconds
+	}
+	return seconds
+}
+
+// newProviderRateLimiter creates a limiter allowing rpm requests per minute,
+// falling back to the OpenAI default for non-positive values
+func newProviderRateLimiter(rpm int) *RateLimiter {
+	if rpm <= 0 {
+		rpm = getEnvInt("MCP_MEMORY_OPENAI_DEFAULT_RPM", 60)
+	}
+	return NewRateLimiter(rpm, time.Minute/time.Duration(rpm))
+}
+
+// observeEmbedding records the latency of an embedding API request and
+// counts it as an error when it failed
+func observeEmbedding(provider, operation string, start time.Time, err error) {
+	metrics.ObserveSince(metrics.EmbeddingDuration, start, provider, operation)
+	if err != nil {
+		metrics.EmbeddingErrors.Inc(provider, operation)
+	}
+}
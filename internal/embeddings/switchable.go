@@ -0,0 +1,114 @@
+package embeddings
+
+import (
+	"context"
+	"sync"
+)
+
+type generationKey struct{}
+
+// generationTracker remembers the oldest provider generation that produced
+// vectors during one request
+type generationTracker struct {
+	mu         sync.Mutex
+	seen       bool
+	generation uint64
+}
+
+func (t *generationTracker) record(generation uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.seen || generation < t.generation {
+		t.seen, t.generation = true, generation
+	}
+}
+
+// WithGenerationTracking returns a context in which a SwitchableEmbeddingService
+// records which provider generation its vectors came from, so a write made
+// with that context can be checked by StaleVectors
+func WithGenerationTracking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, generationKey{}, &generationTracker{})
+}
+
+// SwitchableEmbeddingService delegates to a provider that can be replaced at
+// runtime. Components capture the service once at startup, so swapping the
+// delegate is how a completed re-embedding moves them all to the new model.
+type SwitchableEmbeddingService struct {
+	mu         sync.RWMutex
+	service    EmbeddingService
+	generation uint64
+}
+
+// NewSwitchableEmbeddingService creates a switchable service starting with service
+func NewSwitchableEmbeddingService(service EmbeddingService) *SwitchableEmbeddingService {
+	return &SwitchableEmbeddingService{service: service}
+}
+
+// Swap replaces the delegate and returns the previous one
+func (s *SwitchableEmbeddingService) Swap(service EmbeddingService) EmbeddingService {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous := s.service
+	s.service = service
+	s.generation++
+	return previous
+}
+
+// Current returns the active delegate
+func (s *SwitchableEmbeddingService) Current() EmbeddingService {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.service
+}
+
+// StaleVectors reports whether vectors generated with ctx came from a
+// delegate that has since been swapped out
+func (s *SwitchableEmbeddingService) StaleVectors(ctx context.Context) bool {
+	tracker, ok := ctx.Value(generationKey{}).(*generationTracker)
+	if !ok {
+		return false
+	}
+	tracker.mu.Lock()
+	seen, generation := tracker.seen, tracker.generation
+	tracker.mu.Unlock()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return seen && generation != s.generation
+}
+
+// track returns the active delegate and records its generation in ctx
+func (s *SwitchableEmbeddingService) track(ctx context.Context) EmbeddingService {
+	s.mu.RLock()
+	service, generation := s.service, s.generation
+	s.mu.RUnlock()
+	if tracker, ok := ctx.Value(generationKey{}).(*generationTracker); ok {
+		tracker.record(generation)
+	}
+	return service
+}
+
+// GenerateEmbedding generates an embedding with the active delegate
+func (s *SwitchableEmbeddingService) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	return s.track(ctx).GenerateEmbedding(ctx, text)
+}
+
+// GenerateBatchEmbeddings generates embeddings with the active delegate
+func (s *SwitchableEmbeddingService) GenerateBatchEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	return s.track(ctx).GenerateBatchEmbeddings(ctx, texts)
+}
+
+// GetDimension returns the active delegate's dimension
+func (s *SwitchableEmbeddingService) GetDimension() int {
+	return s.Current().GetDimension()
+}
+
+// GetModel returns the active delegate's model
+func (s *SwitchableEmbeddingService) GetModel() string {
+	return s.Current().GetModel()
+}
+
+// HealthCheck checks the active delegate
+func (s *SwitchableEmbeddingService) HealthCheck(ctx context.Context) error {
+	return s.Current().HealthCheck(ctx)
+}
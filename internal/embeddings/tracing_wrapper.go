@@ -0,0 +1,56 @@
+package embeddings
+
+import (
+	"context"
+	"lerian-mcp-memory/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TracedEmbeddingService wraps an EmbeddingService with a span per call.
+// It sits directly on the provider, so each retry attempt is its own span.
+type TracedEmbeddingService struct {
+	service EmbeddingService
+}
+
+// NewTracedEmbeddingService creates a new traced embedding service
+func NewTracedEmbeddingService(service EmbeddingService) EmbeddingService {
+	return &TracedEmbeddingService{service: service}
+}
+
+func (s *TracedEmbeddingService) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, func(error)) {
+	attrs = append(attrs, attribute.String("embedding.model", s.service.GetModel()))
+	ctx, span := tracing.Start(ctx, "embeddings."+method, attrs...)
+	return ctx, func(err error) { tracing.End(span, err) }
+}
+
+// GenerateEmbedding generates an embedding in a span
+func (s *TracedEmbeddingService) GenerateEmbedding(ctx context.Context, text string) (embedding []float64, err error) {
+	ctx, end := s.start(ctx, "GenerateEmbedding", attribute.Int("embedding.text_length", len(text)))
+	defer func() { end(err) }()
+	return s.service.GenerateEmbedding(ctx, text)
+}
+
+// GenerateBatchEmbeddings generates embeddings in a span
+func (s *TracedEmbeddingService) GenerateBatchEmbeddings(ctx context.Context, texts []string) (embeddings [][]float64, err error) {
+	ctx, end := s.start(ctx, "GenerateBatchEmbeddings", attribute.Int("embedding.batch_size", len(texts)))
+	defer func() { end(err) }()
+	return s.service.GenerateBatchEmbeddings(ctx, texts)
+}
+
+// GetDimension returns the embedding dimension
+func (s *TracedEmbeddingService) GetDimension() int {
+	return s.service.GetDimension()
+}
+
+// GetModel returns the model name
+func (s *TracedEmbeddingService) GetModel() string {
+	return s.service.GetModel()
+}
+
+// HealthCheck checks the provider in a span
+func (s *TracedEmbeddingService) HealthCheck(ctx context.Context) (err error) {
+	ctx, end := s.start(ctx, "HealthCheck")
+	defer func() { end(err) }()
+	return s.service.HealthCheck(ctx)
+}
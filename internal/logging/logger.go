@@ -9,9 +9,11 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Logger interface for structured logging with trace support
@@ -270,56 +272,63 @@ func (l *StructuredLogger) outputText(entry *LogEntry) {
 
 // extractTraceID extracts trace ID from context
 func (l *StructuredLogger) extractTraceID(ctx context.Context) string {
-	if ctx == nil {
-		return ""
-	}
+	return GetTraceID(ctx)
+}
 
-	if traceID, ok := ctx.Value(TraceIDKey).(string); ok {
-		return traceID
-	}
+// loggerHolder lets the default logger be swapped atomically
+type loggerHolder struct {
+	Logger
+}
 
-	return ""
+// Default logger instance; replaced atomically so a config reload can change
+// the level while requests are logging
+var defaultLogger atomic.Pointer[loggerHolder]
+
+func init() {
+	defaultLogger.Store(&loggerHolder{NewLogger(INFO)})
 }
 
-// Default logger instance
-var defaultLogger = NewLogger(INFO)
+// current returns the default logger
+func current() Logger {
+	return defaultLogger.Load().Logger
+}
 
 // Info logs an info-level message using the default logger
 func Info(msg string, fields ...interface{}) {
-	defaultLogger.Info(msg, fields...)
+	current().Info(msg, fields...)
 }
 
 func Warn(msg string, fields ...interface{}) {
-	defaultLogger.Warn(msg, fields...)
+	current().Warn(msg, fields...)
 }
 
 func Error(msg string, fields ...interface{}) {
-	defaultLogger.Error(msg, fields...)
+	current().Error(msg, fields...)
 }
 
 func Debug(msg string, fields ...interface{}) {
-	defaultLogger.Debug(msg, fields...)
+	current().Debug(msg, fields...)
 }
 
 func Fatal(msg string, fields ...interface{}) {
-	defaultLogger.Fatal(msg, fields...)
+	current().Fatal(msg, fields...)
 }
 
 // InfoContext logs an info-level message with context using the default logger
 func InfoContext(ctx context.Context, msg string, fields ...interface{}) {
-	defaultLogger.InfoContext(ctx, msg, fields...)
+	current().InfoContext(ctx, msg, fields...)
 }
 
 func WarnContext(ctx context.Context, msg string, fields ...interface{}) {
-	defaultLogger.WarnContext(ctx, msg, fields...)
+	current().WarnContext(ctx, msg, fields...)
 }
 
 func ErrorContext(ctx context.Context, msg string, fields ...interface{}) {
-	defaultLogger.ErrorContext(ctx, msg, fields...)
+	current().ErrorContext(ctx, msg, fields...)
 }
 
 func DebugContext(ctx context.Context, msg string, fields ...interface{}) {
-	defaultLogger.DebugContext(ctx, msg, fields...)
+	current().DebugContext(ctx, msg, fields...)
 }
 
 // GenerateTraceID generates a new unique trace ID
@@ -334,10 +343,16 @@ func WithTraceID(ctx context.Context, traceID string) context.Context {
 	return context.WithValue(ctx, TraceIDKey, traceID)
 }
 
+// GetTraceID returns the ID of the OpenTelemetry trace ctx belongs to, so
+// log entries and audit events can be found from a trace, or else the ID
+// set with WithTraceID
 func GetTraceID(ctx context.Context) string {
 	if ctx == nil {
 		return ""
 	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		return spanContext.TraceID().String()
+	}
 	if traceID, ok := ctx.Value(TraceIDKey).(string); ok {
 		return traceID
 	}
@@ -346,7 +361,7 @@ func GetTraceID(ctx context.Context) string {
 
 // WithComponent creates a logger with a specified component name
 func WithComponent(component string) Logger {
-	return defaultLogger.WithComponent(component)
+	return current().WithComponent(component)
 }
 
 // ParseLogLevel parses a string into a LogLevel
@@ -369,5 +384,10 @@ func ParseLogLevel(level string) LogLevel {
 
 // SetDefaultLogger sets the default logger instance
 func SetDefaultLogger(logger Logger) {
-	defaultLogger = logger
+	defaultLogger.Store(&loggerHolder{logger})
+}
+
+// SetLevel replaces the default logger with one at level
+func SetLevel(level LogLevel) {
+	SetDefaultLogger(NewLogger(level))
 }
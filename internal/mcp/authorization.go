@@ -0,0 +1,275 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"lerian-mcp-memory/internal/embeddings"
+	"lerian-mcp-memory/internal/logging"
+	"lerian-mcp-memory/internal/metrics"
+	"lerian-mcp-memory/internal/security"
+	"lerian-mcp-memory/internal/tracing"
+	"lerian-mcp-memory/pkg/types"
+	"strings"
+	"time"
+
+	"github.com/fredcamaral/gomcp-sdk/protocol"
+)
+
+// Operations that read across repositories; they need a global grant
+var crossRepositoryOperations = map[string]bool{
+	"search_multi_repo":         true,
+	"cross_repo_patterns":       true,
+	"find_similar_repositories": true,
+	"cross_repo_insights":       true,
+}
+
+// Operations that need read access, by consolidated tool. Operations not
+// listed need write access.
+var readOnlyOperations = map[string]map[string]bool{
+	"memory_tasks": {
+		"todo_read":             true,
+		"session_list":          true,
+		"workflow_analyze":      true,
+		"task_completion_stats": true,
+	},
+	"memory_transfer": {
+		"export_project": true,
+		"bulk_export":    true,
+		"continuity":     true,
+	},
+}
+
+// System operations that any authenticated caller may run without naming
+// a repository
+var openSystemOperations = map[string]bool{
+	OperationHealth:          true,
+	"get_documentation":      true,
+	"create_inline_citation": true,
+}
+
+// System operations that configure the whole server; they need a global
+// admin grant whatever repository they name
+var adminSystemOperations = map[string]bool{
+	OperationReembed:             true,
+	OperationRotateEncryptionKey: true,
+	OperationWebhookSubscribe:    true,
+	OperationWebhookUnsubscribe:  true,
+	OperationWebhookList:         true,
+	OperationWebhookDeadLetters:  true,
+	OperationWebhookRedeliver:    true,
+	OperationRepoConfigList:      true,
+}
+
+// System operations that change one repository's settings; they need an
+// admin grant on that repository
+var repositoryAdminSystemOperations = map[string]bool{
+	OperationRepoConfigSet:   true,
+	OperationRepoConfigReset: true,
+}
+
+// Legacy tools that only read, beyond those mapped to consolidated tools
+var readOnlyLegacyTools = map[string]bool{
+	"mcp__memory__memory_get_task_status": true,
+	"mcp__memory__memory_list_tasks":      true,
+}
+
+// toolAccess is what a tool call needs: a level on a repository, or on every
+// repository when Repository is security.GlobalResource
+type toolAccess struct {
+	Repository string
+	Level      security.AccessLevel
+	Open       bool
+}
+
+// addTool registers a tool whose calls are checked against the caller's
+// repository grants before the handler runs, traced, and counted and timed
+// on /metrics. Calls track which embedding model made their vectors, so
+// writes embedded before a re-embedding switched models are refused.
+func (ms *MemoryServer) addTool(tool protocol.Tool, handler protocol.ToolHandler) {
+	name := tool.Name
+	ms.mcpServer.AddTool(tool, protocol.ToolHandlerFunc(func(ctx context.Context, args map[string]interface{}) (result interface{}, err error) {
+		start := time.Now()
+		operation, repository := toolCallLabels(name, args)
+		ctx = embeddings.WithGenerationTracking(ctx)
+		ctx, span := tracing.Start(ctx, strings.TrimSpace(name+" "+operation),
+			tracing.AttrTool.String(name), tracing.AttrOperation.String(operation), tracing.AttrRepository.String(repository))
+		defer func() { tracing.End(span, err) }()
+
+		if err := ms.authorizeToolCall(ctx, name, args); err != nil {
+			metrics.ToolCalls.Inc(name, operation, repository, metrics.StatusDenied)
+			return nil, err
+		}
+		result, err = handler.Handle(ctx, args)
+		metrics.ToolCalls.Inc(name, operation, repository, metrics.Status(err))
+		metrics.ObserveSince(metrics.ToolDuration, start, name, operation, repository)
+		return result, err
+	}))
+}
+
+// toolCallLabels returns the operation and repository a tool call is
+// counted under. Legacy tools report the consolidated operation they map to.
+func toolCallLabels(toolName string, args map[string]interface{}) (operation, repository string) {
+	options := args
+	if mapping, ok := legacyToolMappingByName(toolName); ok {
+		operation = mapping.operation
+	} else if isConsolidatedTool(toolName) {
+		operation, _ = args["operation"].(string)
+		options, _ = args["options"].(map[string]interface{})
+	}
+	repository, _ = options["repository"].(string)
+	return operation, repository
+}
+
+// authorizeToolCall checks a tool call against the access control manager.
+// Calls without an authenticated principal come from stdio and are trusted.
+func (ms *MemoryServer) authorizeToolCall(ctx context.Context, toolName string, args map[string]interface{}) error {
+	acm := ms.container.GetAccessControl()
+	if acm == nil || !acm.IsEnabled() {
+		return nil
+	}
+	principal, ok := security.PrincipalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	return checkAccess(ctx, acm, principal, toolName, requiredToolAccess(toolName, args))
+}
+
+// authorizeRepositoryRead checks that the caller may read a repository, or
+// every repository when none is named. name identifies the request in errors.
+func (ms *MemoryServer) authorizeRepositoryRead(ctx context.Context, name, repository string) error {
+	return ms.authorizeRepository(ctx, name, repository, security.AccessLevelRead)
+}
+
+// authorizeChunk loads a chunk and checks the caller's access to the
+// repository it belongs to. Tool calls are checked against the repository
+// they name, so handlers that take chunk IDs check the chunk itself.
+func (ms *MemoryServer) authorizeChunk(ctx context.Context, name, chunkID string, level security.AccessLevel) (*types.ConversationChunk, error) {
+	chunk, err := ms.container.GetVectorStore().GetByID(ctx, chunkID)
+	if err != nil {
+		return nil, fmt.Errorf("chunk not found: %s", chunkID)
+	}
+	if err := ms.authorizeRepository(ctx, name, chunk.Metadata.Repository, level); err != nil {
+		return nil, err
+	}
+	return chunk, nil
+}
+
+// authorizeRepository checks that the caller has level access to a
+// repository, or to every repository when none is named
+func (ms *MemoryServer) authorizeRepository(ctx context.Context, name, repository string, level security.AccessLevel) error {
+	acm := ms.container.GetAccessControl()
+	if acm == nil || !acm.IsEnabled() {
+		return nil
+	}
+	principal, ok := security.PrincipalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if repository == "" {
+		repository = security.GlobalResource
+	}
+	return checkAccess(ctx, acm, principal, name, toolAccess{Repository: repository, Level: level})
+}
+
+// checkAccess reports a denial as an error naming what was requested
+func checkAccess(ctx context.Context, acm *security.AccessControlManager, principal security.Principal, name string, access toolAccess) error {
+	if access.Open {
+		return nil
+	}
+
+	allowed, err := acm.CheckRepositoryAccess(ctx, principal.UserID, access.Repository, access.Level)
+	if err != nil {
+		return fmt.Errorf("access denied: %w", err)
+	}
+	if !allowed {
+		logging.Warn("Request denied", "name", name, "user_id", principal.UserID, "repository", access.Repository, "level", access.Level)
+		if access.Repository == security.GlobalResource {
+			return fmt.Errorf("access denied: %s requires %s access to all repositories", name, access.Level)
+		}
+		return fmt.Errorf("access denied: %s requires %s access to repository %s", name, access.Level, access.Repository)
+	}
+	return nil
+}
+
+// requiredToolAccess maps a tool call to the access it needs. Consolidated
+// tools carry the repository in options; legacy tools take it as a
+// top-level argument.
+func requiredToolAccess(toolName string, args map[string]interface{}) toolAccess {
+	tool, operation, scope := toolName, "", ""
+	options := args
+
+	if mapping, ok := legacyToolMappingByName(toolName); ok {
+		tool, operation, scope = mapping.consolidatedTool, mapping.operation, mapping.scope
+	} else if isConsolidatedTool(toolName) {
+		operation, _ = args["operation"].(string)
+		scope, _ = args["scope"].(string)
+		options, _ = args["options"].(map[string]interface{})
+	} else {
+		level := security.AccessLevelWrite
+		if readOnlyLegacyTools[toolName] {
+			level = security.AccessLevelRead
+		}
+		return toolAccess{Repository: repositoryOption(options), Level: level}
+	}
+
+	level := consolidatedAccessLevel(tool, operation)
+	if tool == "memory_system" && openSystemOperations[operation] {
+		return toolAccess{Open: true}
+	}
+
+	repository := repositoryOption(options)
+	if tool == "memory_system" && adminSystemOperations[operation] {
+		repository = security.GlobalResource
+	}
+	if crossRepositoryOperations[operation] || scope == "cross_repo" || scope == "global" {
+		repository = security.GlobalResource
+	}
+	return toolAccess{Repository: repository, Level: level}
+}
+
+// consolidatedAccessLevel returns the level an operation of a consolidated
+// tool needs
+func consolidatedAccessLevel(tool, operation string) security.AccessLevel {
+	switch tool {
+	case "memory_read", "memory_analyze", "memory_intelligence":
+		return security.AccessLevelRead
+	case "memory_system":
+		if adminSystemOperations[operation] || repositoryAdminSystemOperations[operation] {
+			return security.AccessLevelAdmin
+		}
+		return security.AccessLevelRead
+	}
+	if readOnlyOperations[tool][operation] {
+		return security.AccessLevelRead
+	}
+	return security.AccessLevelWrite
+}
+
+// repositoryOption returns the repository argument, or the global resource
+// when the call names none, so that only callers with global grants may
+// run it
+func repositoryOption(options map[string]interface{}) string {
+	if repository, ok := options["repository"].(string); ok && repository != "" {
+		return repository
+	}
+	return security.GlobalResource
+}
+
+func isConsolidatedTool(name string) bool {
+	switch name {
+	case "memory_create", "memory_read", "memory_update", "memory_delete", "memory_analyze",
+		"memory_intelligence", "memory_transfer", "memory_tasks", "memory_system":
+		return true
+	}
+	return false
+}
+
+func legacyToolMappingByName(name string) (legacyToolMapping, bool) {
+	for _, mapping := range legacyToolMappings {
+		if mapping.originalName == name {
+			return mapping, true
+		}
+	}
+	return legacyToolMapping{}, false
+}
@@ -0,0 +1,311 @@
+package mcp
+
+import (
+	"context"
+	"lerian-mcp-memory/internal/di"
+	"lerian-mcp-memory/internal/metrics"
+	"lerian-mcp-memory/internal/security"
+	"lerian-mcp-memory/internal/tracing"
+	"lerian-mcp-memory/pkg/types"
+	"testing"
+	"time"
+
+	"github.com/fredcamaral/gomcp-sdk/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRequiredToolAccess(t *testing.T) {
+	const repo = "github.com/acme/api"
+
+	tests := []struct {
+		name     string
+		tool     string
+		args     map[string]interface{}
+		expected toolAccess
+	}{
+		{
+			name:     "read operation",
+			tool:     "memory_read",
+			args:     map[string]interface{}{"operation": "search", "options": map[string]interface{}{"repository": repo}},
+			expected: toolAccess{Repository: repo, Level: security.AccessLevelRead},
+		},
+		{
+			name:     "create operation",
+			tool:     "memory_create",
+			args:     map[string]interface{}{"operation": OperationStoreChunk, "options": map[string]interface{}{"repository": repo}},
+			expected: toolAccess{Repository: repo, Level: security.AccessLevelWrite},
+		},
+		{
+			name:     "read-only task operation",
+			tool:     "memory_tasks",
+			args:     map[string]interface{}{"operation": "todo_read", "options": map[string]interface{}{"repository": repo}},
+			expected: toolAccess{Repository: repo, Level: security.AccessLevelRead},
+		},
+		{
+			name:     "cross-repository operation",
+			tool:     "memory_read",
+			args:     map[string]interface{}{"operation": "search_multi_repo", "options": map[string]interface{}{"repository": repo}},
+			expected: toolAccess{Repository: security.GlobalResource, Level: security.AccessLevelRead},
+		},
+		{
+			name:     "missing repository",
+			tool:     "memory_delete",
+			args:     map[string]interface{}{"operation": "bulk_delete", "options": map[string]interface{}{}},
+			expected: toolAccess{Repository: security.GlobalResource, Level: security.AccessLevelWrite},
+		},
+		{
+			name:     "re-embedding",
+			tool:     "memory_system",
+			args:     map[string]interface{}{"operation": OperationReembed},
+			expected: toolAccess{Repository: security.GlobalResource, Level: security.AccessLevelAdmin},
+		},
+		{
+			name:     "key rotation",
+			tool:     "memory_system",
+			args:     map[string]interface{}{"operation": OperationRotateEncryptionKey},
+			expected: toolAccess{Repository: security.GlobalResource, Level: security.AccessLevelAdmin},
+		},
+		{
+			name:     "webhook subscription naming a repository",
+			tool:     "memory_system",
+			args:     map[string]interface{}{"operation": OperationWebhookSubscribe, "options": map[string]interface{}{"repository": repo}},
+			expected: toolAccess{Repository: security.GlobalResource, Level: security.AccessLevelAdmin},
+		},
+		{
+			name:     "repository settings change",
+			tool:     "memory_system",
+			args:     map[string]interface{}{"operation": OperationRepoConfigSet, "options": map[string]interface{}{"repository": repo}},
+			expected: toolAccess{Repository: repo, Level: security.AccessLevelAdmin},
+		},
+		{
+			name:     "repository settings read",
+			tool:     "memory_system",
+			args:     map[string]interface{}{"operation": OperationRepoConfigGet, "options": map[string]interface{}{"repository": repo}},
+			expected: toolAccess{Repository: repo, Level: security.AccessLevelRead},
+		},
+		{
+			name:     "health check",
+			tool:     "memory_system",
+			args:     map[string]interface{}{"operation": OperationHealth},
+			expected: toolAccess{Open: true},
+		},
+		{
+			name:     "legacy tool",
+			tool:     "mcp__memory__memory_search",
+			args:     map[string]interface{}{"query": "x", "repository": repo},
+			expected: toolAccess{Repository: repo, Level: security.AccessLevelRead},
+		},
+		{
+			name:     "unmapped legacy tool",
+			tool:     "mcp__memory__memory_create_task",
+			args:     map[string]interface{}{"repository": repo},
+			expected: toolAccess{Repository: repo, Level: security.AccessLevelWrite},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, requiredToolAccess(tt.tool, tt.args))
+		})
+	}
+}
+
+func TestAuthorizeToolCall(t *testing.T) {
+	acm := security.NewAccessControlManager()
+	user, err := acm.CreateUser("alice", "alice@example.com")
+	require.NoError(t, err)
+	token, err := acm.GenerateToken(user.ID, nil, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, acm.GrantRepositoryAccessByName("github.com/acme/api", user.ID, security.AccessLevelRead))
+
+	ms := &MemoryServer{container: &di.Container{AccessControl: acm}}
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: user.ID, TokenID: token.ID})
+
+	read := map[string]interface{}{"operation": "search", "options": map[string]interface{}{"repository": "github.com/acme/api"}}
+	assert.NoError(t, ms.authorizeToolCall(ctx, "memory_read", read))
+
+	write := map[string]interface{}{"operation": OperationStoreChunk, "options": map[string]interface{}{"repository": "github.com/acme/api"}}
+	assert.ErrorContains(t, ms.authorizeToolCall(ctx, "memory_create", write), "access denied")
+
+	other := map[string]interface{}{"operation": "search", "options": map[string]interface{}{"repository": "github.com/acme/web"}}
+	assert.ErrorContains(t, ms.authorizeToolCall(ctx, "memory_read", other), "access denied")
+
+	// stdio calls carry no principal and are not checked
+	assert.NoError(t, ms.authorizeToolCall(context.Background(), "memory_create", write))
+}
+
+func TestChunkHandlersCheckChunkRepository(t *testing.T) {
+	server := newLocalMemoryServer(t)
+	acm := security.NewAccessControlManager()
+	user, err := acm.CreateUser("alice", "alice@example.com")
+	require.NoError(t, err)
+	require.NoError(t, acm.GrantRepositoryAccessByName("github.com/acme/api", user.ID, security.AccessLevelWrite))
+	server.container.AccessControl = acm
+	ctx := security.WithPrincipal(context.Background(), security.Principal{UserID: user.ID})
+
+	now := time.Now()
+	own := storePromptChunk(t, server, "s1", types.ChunkTypeProblem, "Billing retries time out", types.OutcomeSuccess, now)
+	sibling := storePromptChunk(t, server, "s1", types.ChunkTypeSolution, "Raised the billing timeout", types.OutcomeSuccess, now)
+	foreign := storePromptChunk(t, server, "s2", types.ChunkTypeSolution, "Web session keys rotate daily", types.OutcomeSuccess, now)
+	foreign.Metadata.Repository = "github.com/acme/web"
+	require.NoError(t, server.container.GetVectorStore().Update(context.Background(), foreign))
+
+	// Every call names the repository the caller may write; only the chunk
+	// IDs point elsewhere
+	const repo = "github.com/acme/api"
+	denied := map[string]func() (interface{}, error){
+		"mark_refreshed": func() (interface{}, error) {
+			return server.handleMarkRefreshed(ctx, map[string]interface{}{"chunk_id": foreign.ID, "repository": repo})
+		},
+		"check_freshness": func() (interface{}, error) {
+			return server.handleCheckFreshness(ctx, map[string]interface{}{"chunk_id": foreign.ID, "repository": repo})
+		},
+		"create_relationship source": func() (interface{}, error) {
+			return server.handleMemoryLink(ctx, map[string]interface{}{"source_chunk_id": foreign.ID, "target_chunk_id": own.ID, "relation_type": "related_to", "repository": repo})
+		},
+		"create_relationship target": func() (interface{}, error) {
+			return server.handleMemoryLink(ctx, map[string]interface{}{"source_chunk_id": own.ID, "target_chunk_id": foreign.ID, "relation_type": "related_to", "repository": repo})
+		},
+		"get_relationships": func() (interface{}, error) {
+			return server.handleGetRelationships(ctx, map[string]interface{}{"chunk_id": foreign.ID, "repository": repo})
+		},
+		"traverse_graph": func() (interface{}, error) {
+			return server.handleTraverseGraph(ctx, map[string]interface{}{"start_chunk_id": foreign.ID, "repository": repo})
+		},
+		"auto_detect_relationships": func() (interface{}, error) {
+			return server.handleAutoDetectRelationships(ctx, map[string]interface{}{"chunk_id": foreign.ID, "session_id": "s2", "repository": repo})
+		},
+	}
+	crossLink, err := server.container.GetVectorStore().StoreRelationship(context.Background(), own.ID, foreign.ID, types.RelationRelatedTo, 0.5, types.ConfidenceExplicit)
+	require.NoError(t, err)
+	denied["update_relationship"] = func() (interface{}, error) {
+		return server.handleUpdateRelationship(ctx, map[string]interface{}{"relationship_id": crossLink.ID, "confidence": 0.9, "repository": repo})
+	}
+
+	createThread := func(chunkIDs ...interface{}) string {
+		result, err := server.handleCreateThread(context.Background(), map[string]interface{}{"chunk_ids": chunkIDs})
+		require.NoError(t, err)
+		return result.(map[string]interface{})["thread_id"].(string)
+	}
+	foreignThread, ownThread := createThread(foreign.ID), createThread(own.ID)
+	denied["create_thread"] = func() (interface{}, error) {
+		return server.handleCreateThread(ctx, map[string]interface{}{"chunk_ids": []interface{}{own.ID, foreign.ID}, "repository": repo})
+	}
+	denied["update_thread"] = func() (interface{}, error) {
+		return server.handleUpdateThread(ctx, map[string]interface{}{"thread_id": foreignThread, "title": "taken over", "repository": repo})
+	}
+	denied["update_thread add_chunks"] = func() (interface{}, error) {
+		return server.handleUpdateThread(ctx, map[string]interface{}{"thread_id": ownThread, "add_chunks": []interface{}{foreign.ID}, "repository": repo})
+	}
+	bulkChunk := func(id, repository string) map[string]interface{} {
+		return map[string]interface{}{"id": id, "content": "overwritten", "metadata": map[string]interface{}{"repository": repository}}
+	}
+	denied["bulk_update other repository"] = func() (interface{}, error) {
+		return server.handleBulkOperation(ctx, map[string]interface{}{"operation": "update", "repository": repo, "chunks": []interface{}{bulkChunk(own.ID, "github.com/acme/web")}})
+	}
+	denied["bulk_update foreign chunk"] = func() (interface{}, error) {
+		return server.handleBulkOperation(ctx, map[string]interface{}{"operation": "update", "repository": repo, "chunks": []interface{}{bulkChunk(foreign.ID, repo)}})
+	}
+
+	for name, call := range denied {
+		_, err := call()
+		assert.ErrorContains(t, err, "access denied", name)
+	}
+
+	unchanged, err := server.container.GetVectorStore().GetByID(context.Background(), foreign.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, unchanged.Metadata.ExtendedMetadata, "last_refreshed")
+
+	// The same calls on the caller's own chunks go through
+	_, err = server.handleMarkRefreshed(ctx, map[string]interface{}{"chunk_id": own.ID, "repository": repo})
+	require.NoError(t, err)
+	_, err = server.handleCheckFreshness(ctx, map[string]interface{}{"chunk_id": own.ID, "repository": repo})
+	require.NoError(t, err)
+	link, err := server.handleMemoryLink(ctx, map[string]interface{}{"source_chunk_id": own.ID, "target_chunk_id": sibling.ID, "relation_type": "related_to", "repository": repo})
+	require.NoError(t, err)
+	_, err = server.handleGetRelationships(ctx, map[string]interface{}{"chunk_id": own.ID, "repository": repo})
+	require.NoError(t, err)
+	_, err = server.handleTraverseGraph(ctx, map[string]interface{}{"start_chunk_id": own.ID, "repository": repo})
+	require.NoError(t, err)
+	_, err = server.handleUpdateRelationship(ctx, map[string]interface{}{"relationship_id": link.(map[string]interface{})["relationship_id"], "confidence": 0.9, "repository": repo})
+	require.NoError(t, err)
+	_, err = server.handleCreateThread(ctx, map[string]interface{}{"chunk_ids": []interface{}{own.ID, sibling.ID}, "repository": repo})
+	require.NoError(t, err)
+	_, err = server.handleUpdateThread(ctx, map[string]interface{}{"thread_id": ownThread, "add_chunks": []interface{}{sibling.ID}, "repository": repo})
+	require.NoError(t, err)
+	_, err = server.handleBulkOperation(ctx, map[string]interface{}{"operation": "update", "repository": repo, "chunks": []interface{}{bulkChunk(own.ID, repo)}})
+	require.NoError(t, err)
+
+	thread, err := server.container.GetThreadStore().GetThread(context.Background(), foreignThread)
+	require.NoError(t, err)
+	assert.NotEqual(t, "taken over", thread.Title)
+}
+
+func TestToolCallMetrics(t *testing.T) {
+	server := newLocalMemoryServer(t)
+	repo := "github.com/acme/metrics"
+	callTool := func(name string, args map[string]interface{}) {
+		resp := server.HandleRequest(context.Background(), &protocol.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      1,
+			Method:  "tools/call",
+			Params:  map[string]interface{}{"name": name, "arguments": args},
+		})
+		require.NotNil(t, resp)
+	}
+
+	callTool("memory_create", map[string]interface{}{
+		"operation": OperationStoreChunk,
+		"options":   map[string]interface{}{"content": "Metrics are scraped every 15s", "session_id": "s1", "repository": repo},
+	})
+	callTool("memory_create", map[string]interface{}{
+		"operation": OperationStoreChunk,
+		"options":   map[string]interface{}{"repository": repo},
+	})
+
+	assert.InDelta(t, 1, metrics.ToolCalls.Value("memory_create", OperationStoreChunk, repo, metrics.StatusSuccess), 0)
+	assert.InDelta(t, 1, metrics.ToolCalls.Value("memory_create", OperationStoreChunk, repo, metrics.StatusError), 0)
+	assert.Equal(t, uint64(2), metrics.ToolDuration.Count("memory_create", OperationStoreChunk, repo))
+
+	operation, repository := toolCallLabels("mcp__memory__memory_store_chunk", map[string]interface{}{"repository": repo})
+	assert.Equal(t, OperationStoreChunk, operation)
+	assert.Equal(t, repo, repository)
+}
+
+func TestToolCallSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	server := newLocalMemoryServer(t)
+	resp := server.HandleRequest(context.Background(), &protocol.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "tools/call",
+		Params: map[string]interface{}{"name": "memory_create", "arguments": map[string]interface{}{
+			"operation": OperationStoreChunk,
+			"options":   map[string]interface{}{"content": "Traces go to the collector", "session_id": "s1", "repository": "github.com/acme/traces"},
+		}},
+	})
+	require.Nil(t, resp.Error)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	root, ok := spans["memory_create store_chunk"]
+	require.True(t, ok)
+	assert.Contains(t, root.Attributes(), tracing.AttrRepository.String("github.com/acme/traces"))
+	assert.False(t, root.Parent().IsValid())
+
+	for _, name := range []string{"embeddings.GenerateEmbedding", "vectorstore.Store"} {
+		span, ok := spans[name]
+		require.True(t, ok, name)
+		assert.Equal(t, root.SpanContext().TraceID(), span.SpanContext().TraceID(), name)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"lerian-mcp-memory/internal/config"
 	"lerian-mcp-memory/internal/logging"
 	"strings"
 
@@ -15,7 +16,7 @@ import (
 // registerConsolidatedTools registers the 9 consolidated MCP tools
 func (ms *MemoryServer) registerConsolidatedTools() {
 	// 1. memory_create - All creation operations
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"memory_create",
 		"Handle all memory creation operations. CRITICAL: 'options' parameter MUST be a JSON object (not a JSON string). REQUIRED fields: repository is mandatory for ALL operations for multi-tenant isolation; store_chunk/store_decision require session_id+repository; create_thread requires name+description+chunk_ids+repository; create_relationship requires source_chunk_id+target_chunk_id+relation_type+repository. Use repository='global' for cross-project architecture decisions.",
 		mcp.ObjectSchema("Memory creation parameters", map[string]interface{}{
@@ -24,6 +25,7 @@ func (ms *MemoryServer) registerConsolidatedTools() {
 				"enum": []string{
 					OperationStoreChunk, OperationStoreDecision, "create_thread", "create_alias",
 					"create_relationship", "auto_detect_relationships", "import_context", "bulk_import",
+					OperationCreateFromTemplate,
 				},
 				"description": "Type of creation operation to perform",
 			},
@@ -50,6 +52,11 @@ func (ms *MemoryServer) registerConsolidatedTools() {
 						"type":        "string",
 						"description": "Content to store (required for store_chunk)",
 					},
+					"duplicate_policy": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{config.DedupePolicyMerge, config.DedupePolicySupersede, config.DedupePolicyReject, config.DedupePolicyAllow},
+						"description": "What store_chunk does when the content nearly duplicates a recent chunk in the repository: merge into it, supersede it, reject the new chunk, or allow both (defaults to the server's dedupe.policy)",
+					},
 					"decision": map[string]interface{}{
 						"type":        "string",
 						"description": "Decision text (required for store_decision)",
@@ -87,13 +94,22 @@ func (ms *MemoryServer) registerConsolidatedTools() {
 						"type":        "string",
 						"description": "Data to import (required for import_context)",
 					},
+					"template_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Template ID (required for create_from_template); see memory_read list_templates",
+					},
+					"fields": map[string]interface{}{
+						"type":                 "object",
+						"description":          "Template field values keyed by field name (required for create_from_template); see memory_read get_template",
+						"additionalProperties": true,
+					},
 				},
 			},
 		}, []string{"operation", "options"}),
 	), mcp.ToolHandlerFunc(ms.handleMemoryCreate))
 
 	// 2. memory_read - All read/query operations
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"memory_read",
 		"Handle all memory read operations. CRITICAL: 'options' parameter MUST be a JSON object (not a JSON string). REQUIRED fields: repository parameter is mandatory for ALL operations for multi-tenant isolation; search requires query+repository; get_context requires repository; find_similar requires problem+repository; get_relationships requires chunk_id+repository; search_multi_repo requires query+session_id+repository.",
 		mcp.ObjectSchema("Memory read parameters", map[string]interface{}{
@@ -103,6 +119,7 @@ func (ms *MemoryServer) registerConsolidatedTools() {
 					"search", "get_context", "find_similar", "get_patterns", "get_relationships",
 					"traverse_graph", "get_threads", "search_explained", "search_multi_repo",
 					"resolve_alias", "list_aliases", "get_bulk_progress",
+					OperationListTemplates, OperationGetTemplate,
 				},
 				"description": "Type of read operation to perform",
 			},
@@ -121,6 +138,11 @@ func (ms *MemoryServer) registerConsolidatedTools() {
 						"type":        "string",
 						"description": "Search query (required for search, search_multi_repo)",
 					},
+					"search_mode": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"vector", "lexical", "hybrid"},
+						"description": "Ranking for search and search_explained: vector (embedding similarity), lexical (BM25 keyword match, best for exact identifiers and error codes) or hybrid (reciprocal-rank fusion of both). Defaults to the server's configured mode",
+					},
 					"repository": map[string]interface{}{
 						"type":        "string",
 						"description": "Repository URL (REQUIRED for ALL operations for multi-tenant isolation) - must include full URL like 'github.com/user/repo', 'gitlab.com/user/repo', etc. Use 'global' for cross-project architecture decisions.",
@@ -149,13 +171,21 @@ func (ms *MemoryServer) registerConsolidatedTools() {
 						"type":        "string",
 						"description": "Operation ID (required for get_bulk_progress)",
 					},
+					"template_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Template ID (required for get_template)",
+					},
+					"chunk_type": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list templates producing this chunk type (optional for list_templates)",
+					},
 				},
 			},
 		}, []string{"operation", "options"}),
 	), mcp.ToolHandlerFunc(ms.handleMemoryRead))
 
 	// 3. memory_update - All update operations
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"memory_update",
 		"Handle all memory update operations including thread updates, relationship updates, refreshing memories, and conflict resolution. CRITICAL: 'options' parameter MUST be a JSON object (not a JSON string). REQUIRED fields: repository is mandatory for ALL operations for multi-tenant isolation.",
 		mcp.ObjectSchema("Memory update parameters", map[string]interface{}{
@@ -221,7 +251,7 @@ func (ms *MemoryServer) registerConsolidatedTools() {
 	), mcp.ToolHandlerFunc(ms.handleMemoryUpdate))
 
 	// 4. memory_delete - All deletion operations
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"memory_delete",
 		"Handle all memory deletion operations including bulk deletions and filtered deletions. CRITICAL: 'options' parameter MUST be a JSON object (not a JSON string). REQUIRED fields: repository parameter is mandatory for ALL operations to prevent cross-tenant data deletion.",
 		mcp.ObjectSchema("Memory delete parameters", map[string]interface{}{
@@ -256,7 +286,7 @@ func (ms *MemoryServer) registerConsolidatedTools() {
 	), mcp.ToolHandlerFunc(ms.handleMemoryDelete))
 
 	// 5. memory_analyze - All analysis operations
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"memory_analyze",
 		"Handle memory analysis operations. CRITICAL: 'options' parameter MUST be a JSON object (not a JSON string). REQUIRED fields: repository is mandatory for ALL operations for multi-tenant isolation; health_dashboard requires repository+session_id; cross_repo_patterns requires session_id+repository; find_similar_repositories requires repository+session_id.",
 		mcp.ObjectSchema("Memory analysis parameters", map[string]interface{}{
@@ -293,7 +323,7 @@ func (ms *MemoryServer) registerConsolidatedTools() {
 	), mcp.ToolHandlerFunc(ms.handleMemoryAnalyze))
 
 	// 6. memory_intelligence - AI-powered operations
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"memory_intelligence",
 		"Handle AI-powered operations. CRITICAL: 'options' parameter MUST be a JSON object (not a JSON string). REQUIRED fields: repository is mandatory for ALL operations for multi-tenant isolation; suggest_related requires current_context+session_id+repository; auto_insights requires repository+session_id; pattern_prediction requires context+repository+session_id.",
 		mcp.ObjectSchema("Memory intelligence parameters", map[string]interface{}{
@@ -335,7 +365,7 @@ func (ms *MemoryServer) registerConsolidatedTools() {
 	), mcp.ToolHandlerFunc(ms.handleMemoryIntelligence))
 
 	// 7. memory_transfer - Data transfer operations
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"memory_transfer",
 		"Handle data transfer operations with pagination support. CRITICAL: 'options' parameter MUST be a JSON object (not a JSON string). REQUIRED fields: repository is mandatory for ALL operations for multi-tenant isolation; export_project requires repository+session_id (optional: limit, offset, format, include_vectors); import_context requires data+repository+session_id; continuity requires repository.",
 		mcp.ObjectSchema("Memory transfer parameters", map[string]interface{}{
@@ -397,7 +427,7 @@ func (ms *MemoryServer) registerConsolidatedTools() {
 	), mcp.ToolHandlerFunc(ms.handleMemoryTransfer))
 
 	// 8. memory_tasks - Task and workflow management operations
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"memory_tasks",
 		"Handle task management and workflow tracking operations. CRITICAL: 'options' parameter MUST be a JSON object (not a JSON string). DECISION GUIDE for session_id: OMIT session_id for cross-session task continuity (RECOMMENDED - allows access to todos from previous conversations). INCLUDE session_id only when you need session-specific task isolation. BEHAVIORAL DIFFERENCE: Without session_id = repository-wide todos visible across all LLM sessions; With session_id = session-isolated todos.",
 		mcp.ObjectSchema("Memory tasks parameters", map[string]interface{}{
@@ -442,13 +472,13 @@ func (ms *MemoryServer) registerConsolidatedTools() {
 	), mcp.ToolHandlerFunc(ms.handleMemoryTasks))
 
 	// 9. memory_system - System operations
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"memory_system",
-		"Handle system-level memory operations including health checks, status reports, and citation management. CRITICAL: 'options' parameter MUST be a JSON object (not a JSON string). REQUIRED fields: repository parameter for status operations; health checks are global by default.",
+		"Handle system-level memory operations including health checks, status reports, citation management, re-embedding the collection with a new embedding provider, rotating the encryption key, managing webhooks that receive memory events, and per-repository settings (enabled, similarity threshold, retention, decay policy). CRITICAL: 'options' parameter MUST be a JSON object (not a JSON string). REQUIRED fields: repository parameter for status operations; health checks are global by default.",
 		mcp.ObjectSchema("Memory system parameters", map[string]interface{}{
 			"operation": map[string]interface{}{
 				"type":        "string",
-				"enum":        []string{OperationHealth, OperationStatus, "generate_citations", "create_inline_citation", "get_documentation"},
+				"enum":        []string{OperationHealth, OperationStatus, "generate_citations", "create_inline_citation", "get_documentation", OperationReembed, OperationRotateEncryptionKey, OperationWebhookSubscribe, OperationWebhookUnsubscribe, OperationWebhookList, OperationWebhookDeadLetters, OperationWebhookRedeliver, OperationRepoConfigGet, OperationRepoConfigSet, OperationRepoConfigReset, OperationRepoConfigList},
 				"description": "Type of system operation to perform",
 			},
 			"scope": map[string]interface{}{
@@ -459,7 +489,7 @@ func (ms *MemoryServer) registerConsolidatedTools() {
 			},
 			"options": map[string]interface{}{
 				"type":                 "object",
-				"description":          "Operation-specific parameters. REQUIRED fields: status requires repository; generate_citations requires query+chunk_ids+repository; create_inline_citation requires text+response_id; health checks are global by default",
+				"description":          "Operation-specific parameters. REQUIRED fields: status requires repository; generate_citations requires query+chunk_ids+repository; create_inline_citation requires text+response_id; reembed requires provider (or operation_id to retry a failed job); webhook_subscribe requires url; webhook_unsubscribe requires subscription_id; webhook_redeliver requires delivery_id; repo_config_get, repo_config_set and repo_config_reset require repository; health checks are global by default",
 				"additionalProperties": true,
 				"properties": map[string]interface{}{
 					"repository": map[string]interface{}{
@@ -483,6 +513,91 @@ func (ms *MemoryServer) registerConsolidatedTools() {
 						"type":        "string",
 						"description": "Response ID (required for create_inline_citation)",
 					},
+					"provider": map[string]interface{}{
+						"type":        "string",
+						"description": "Target embedding provider for reembed: openai, openai_compatible, ollama or hash",
+					},
+					"model": map[string]interface{}{
+						"type":        "string",
+						"description": "Target embedding model for reembed",
+					},
+					"base_url": map[string]interface{}{
+						"type":        "string",
+						"description": "Target provider endpoint for reembed (self-hosted providers)",
+					},
+					"dimension": map[string]interface{}{
+						"type":        "integer",
+						"description": "Target vector size for reembed; optional for well-known models",
+					},
+					"batch_size": map[string]interface{}{
+						"type":        "integer",
+						"description": "Chunks embedded per request during reembed (default 64)",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "For reembed: return the chunk count, token and cost estimate without writing anything",
+					},
+					"drop_previous": map[string]interface{}{
+						"type":        "boolean",
+						"description": "For reembed: delete the old collection after the swap (default false)",
+					},
+					"operation_id": map[string]interface{}{
+						"type":        "string",
+						"description": "For reembed: retry a failed job from its last saved batch",
+					},
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "For webhook_subscribe: http or https endpoint that receives signed POSTs",
+					},
+					"repositories": map[string]interface{}{
+						"type":        "array",
+						"description": "For webhook_subscribe: only send events for these repositories (default all)",
+						"items":       map[string]interface{}{"type": "string"},
+					},
+					"event_types": map[string]interface{}{
+						"type":        "array",
+						"description": "For webhook_subscribe: only send these event types: memory, relationship, thread, task (default all)",
+						"items":       map[string]interface{}{"type": "string"},
+					},
+					"secret": map[string]interface{}{
+						"type":        "string",
+						"description": "For webhook_subscribe: HMAC signing secret; generated and returned once when omitted",
+					},
+					"description": map[string]interface{}{
+						"type":        "string",
+						"description": "For webhook_subscribe: note shown by webhook_list",
+					},
+					"subscription_id": map[string]interface{}{
+						"type":        "string",
+						"description": "For webhook_unsubscribe, and to filter webhook_dead_letters",
+					},
+					"delivery_id": map[string]interface{}{
+						"type":        "string",
+						"description": "For webhook_redeliver: dead letter to queue again",
+					},
+					"enabled": map[string]interface{}{
+						"type":        "boolean",
+						"description": "For repo_config_set: store memories for the repository (false refuses new memories)",
+					},
+					"similarity_threshold": map[string]interface{}{
+						"type":        "number",
+						"description": "For repo_config_set: minimum relevance for searches that set none (0 uses the server default)",
+					},
+					"retention_days": map[string]interface{}{
+						"type":        "integer",
+						"description": "For repo_config_set: days memories are kept before cleanup (0 uses the server default)",
+					},
+					"decay": map[string]interface{}{
+						"type":        "object",
+						"description": "For repo_config_set: decay policy with exempt (boolean, skips decay and cleanup), summarization_threshold and deletion_threshold (0 uses the server default)",
+					},
+					"redaction_rules": map[string]interface{}{
+						"type":        "array",
+						"description": "For repo_config_set: custom redaction rules, each with name, pattern (regular expression; a named group \"secret\" limits the action to that part) and action (block, mask or hash). Replaces the repository's current rules.",
+						"items": map[string]interface{}{
+							"type": "object",
+						},
+					},
 				},
 			},
 		}, []string{"operation", "options"}),
@@ -531,8 +646,10 @@ func (ms *MemoryServer) handleMemoryCreate(ctx context.Context, args map[string]
 		return ms.handleImportContext(ctx, options)
 	case "bulk_import":
 		return ms.handleBulkImport(ctx, options)
+	case OperationCreateFromTemplate:
+		return ms.handleCreateFromTemplate(ctx, options)
 	default:
-		validOps := []string{"store_chunk", "store_decision", "create_thread", "create_alias", "create_relationship", "auto_detect_relationships", "import_context", "bulk_import"}
+		validOps := []string{"store_chunk", "store_decision", "create_thread", "create_alias", "create_relationship", "auto_detect_relationships", "import_context", "bulk_import", OperationCreateFromTemplate}
 		return nil, fmt.Errorf("unsupported create operation '%s'. Valid operations: %s. Example: {\"operation\": \"store_chunk\", \"options\": {\"repository\": \"github.com/user/repo\", \"content\": \"Fixed authentication bug\", \"session_id\": \"session-123\"}}", operation, strings.Join(validOps, ", "))
 	}
 }
@@ -608,6 +725,10 @@ func (ms *MemoryServer) routeReadOperation(ctx context.Context, operation string
 		return ms.handleSecureListAliases(ctx, options, repository)
 	case "get_bulk_progress":
 		return ms.handleGetBulkProgress(ctx, options)
+	case OperationListTemplates:
+		return ms.handleListTemplates(ctx, options)
+	case OperationGetTemplate:
+		return ms.handleGetTemplate(ctx, options)
 	default:
 		return ms.buildUnsupportedOperationError(operation)
 	}
@@ -615,7 +736,7 @@ func (ms *MemoryServer) routeReadOperation(ctx context.Context, operation string
 
 // buildUnsupportedOperationError builds error message for unsupported operations
 func (ms *MemoryServer) buildUnsupportedOperationError(operation string) (interface{}, error) {
-	validOps := []string{"search", "get_context", "find_similar", "get_patterns", "get_relationships", "traverse_graph", "get_threads", "search_explained", "search_multi_repo", "resolve_alias", "list_aliases", "get_bulk_progress"}
+	validOps := []string{"search", "get_context", "find_similar", "get_patterns", "get_relationships", "traverse_graph", "get_threads", "search_explained", "search_multi_repo", "resolve_alias", "list_aliases", "get_bulk_progress", OperationListTemplates, OperationGetTemplate}
 	return nil, fmt.Errorf("unsupported read operation '%s'. Valid operations: %s. Example: {\"operation\": \"search\", \"options\": {\"repository\": \"github.com/user/repo\", \"query\": \"authentication issues\"}}", operation, strings.Join(validOps, ", "))
 }
 
@@ -882,6 +1003,29 @@ func (ms *MemoryServer) routeSystemOperation(ctx context.Context, operation stri
 		return ms.handleInlineCitationOperation(ctx, options, repository, hasRepo)
 	case "get_documentation":
 		return ms.handleDocumentationOperation(ctx, options)
+	case OperationReembed:
+		// Re-embedding rebuilds the whole collection, so it is global
+		return ms.handleReembed(ctx, options)
+	case OperationRotateEncryptionKey:
+		return ms.handleRotateEncryptionKey(ctx, options)
+	case OperationWebhookSubscribe:
+		return ms.handleWebhookSubscribe(ctx, options)
+	case OperationWebhookUnsubscribe:
+		return ms.handleWebhookUnsubscribe(ctx, options)
+	case OperationWebhookList:
+		return ms.handleWebhookList(ctx, options)
+	case OperationWebhookDeadLetters:
+		return ms.handleWebhookDeadLetters(ctx, options)
+	case OperationWebhookRedeliver:
+		return ms.handleWebhookRedeliver(ctx, options)
+	case OperationRepoConfigGet:
+		return ms.handleRepoConfigGet(ctx, options)
+	case OperationRepoConfigSet:
+		return ms.handleRepoConfigSet(ctx, options)
+	case OperationRepoConfigReset:
+		return ms.handleRepoConfigReset(ctx, options)
+	case OperationRepoConfigList:
+		return ms.handleRepoConfigList(ctx, options)
 	default:
 		return ms.buildSystemOperationError(operation)
 	}
@@ -934,6 +1078,6 @@ func (ms *MemoryServer) handleDocumentationOperation(ctx context.Context, option
 
 // buildSystemOperationError builds error message for unsupported system operations
 func (ms *MemoryServer) buildSystemOperationError(operation string) (interface{}, error) {
-	validOps := []string{"health", "status", "generate_citations", "create_inline_citation", "get_documentation"}
+	validOps := []string{"health", "status", "generate_citations", "create_inline_citation", "get_documentation", OperationReembed, OperationRotateEncryptionKey, OperationWebhookSubscribe, OperationWebhookUnsubscribe, OperationWebhookList, OperationWebhookDeadLetters, OperationWebhookRedeliver, OperationRepoConfigGet, OperationRepoConfigSet, OperationRepoConfigReset, OperationRepoConfigList}
 	return nil, fmt.Errorf("unsupported system operation '%s'. Valid operations: %s. Example: {\"operation\": \"health\"} or {\"operation\": \"status\", \"options\": {\"repository\": \"github.com/user/repo\"}}", operation, strings.Join(validOps, ", "))
 }
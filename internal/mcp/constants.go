@@ -17,6 +17,32 @@ const (
 	OperationStoreDecision = "store_decision"
 	OperationHealth        = "health"
 	OperationStatus        = "status"
+	OperationReembed       = "reembed"
+
+	// OperationRotateEncryptionKey rotates the data key used for encryption at rest
+	OperationRotateEncryptionKey = "rotate_encryption_key"
+
+	// Webhook operations
+	OperationWebhookSubscribe   = "webhook_subscribe"
+	OperationWebhookUnsubscribe = "webhook_unsubscribe"
+	OperationWebhookList        = "webhook_list"
+	OperationWebhookDeadLetters = "webhook_dead_letters"
+	OperationWebhookRedeliver   = "webhook_redeliver"
+
+	// Per-repository settings operations
+	OperationRepoConfigGet   = "repo_config_get"
+	OperationRepoConfigSet   = "repo_config_set"
+	OperationRepoConfigReset = "repo_config_reset"
+	OperationRepoConfigList  = "repo_config_list"
+
+	// Memory template operations
+	OperationCreateFromTemplate = "create_from_template"
+	OperationListTemplates      = "list_templates"
+	OperationGetTemplate        = "get_template"
+
+	// NotificationBulkProgress is pushed to SSE sessions while a bulk
+	// operation or re-embedding they started is running
+	NotificationBulkProgress = "notifications/bulk_progress"
 
 	// Common filter values
 	FilterValueAll = "all"
@@ -0,0 +1,94 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/decay"
+	"lerian-mcp-memory/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDecayWritesAbstractiveSummaries(t *testing.T) {
+	var completions atomic.Int64
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		completions.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"Moved billing retries to a queue after timeouts."}}]}`))
+	}))
+	defer llm.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Storage.Provider = config.StorageProviderLocal
+	cfg.Storage.Local.DataDir = t.TempDir()
+	cfg.Storage.RepositoriesFile = filepath.Join(t.TempDir(), "repositories.json")
+	cfg.Embedding.Provider = config.EmbeddingProviderHash
+	cfg.Summarizer.Provider = config.SummarizerProviderOllama
+	cfg.Summarizer.BaseURL = llm.URL + "/v1"
+	server, err := NewMemoryServer(cfg)
+	require.NoError(t, err)
+	ctx := context.Background()
+	store := server.container.GetVectorStore()
+	require.NoError(t, store.Initialize(ctx))
+	t.Cleanup(func() { _ = store.Close() })
+
+	repo := "github.com/acme/api"
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	ids := make([]string, 0, 3)
+	for i, content := range []string{"Billing retries time out", "Tried raising the timeout", "Moved retries to a queue"} {
+		vector, err := server.container.GetEmbeddingService().GenerateEmbedding(ctx, content)
+		require.NoError(t, err)
+		chunk := &types.ConversationChunk{
+			ID:         "decayed-" + strconv.Itoa(i),
+			SessionID:  "s1",
+			Timestamp:  old.Add(time.Duration(i) * time.Hour),
+			Type:       types.ChunkTypeDiscussion,
+			Content:    content,
+			Summary:    content,
+			Embeddings: vector,
+			Metadata:   types.ChunkMetadata{Repository: repo, Outcome: types.OutcomeSuccess, Difficulty: types.DifficultySimple},
+		}
+		require.NoError(t, store.Store(ctx, chunk))
+		ids = append(ids, chunk.ID)
+	}
+
+	// Chunks two months old score 0.6: summarized, not deleted
+	_, err = server.handleMemoryDecayManagement(ctx, map[string]interface{}{
+		"repository": repo, "session_id": "s1", "action": "configure",
+		"config": map[string]interface{}{"summarization_threshold": 0.9, "deletion_threshold": 0.1},
+	})
+	require.NoError(t, err)
+
+	result, err := server.handleMemoryDecayManagement(ctx, map[string]interface{}{
+		"repository": repo, "session_id": "s1", "action": "run_decay",
+	})
+	require.NoError(t, err)
+	response := result.(map[string]interface{})
+	assert.Equal(t, "decay_executed", response["status"])
+	assert.Equal(t, &decay.DecayResult{Summarized: 3}, response["result"])
+	assert.EqualValues(t, 1, completions.Load())
+
+	chunks, err := store.ListByRepository(ctx, repo, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, chunks, 1, "the originals are replaced by their summary")
+	assert.Equal(t, "Moved billing retries to a queue after timeouts.", chunks[0].Content)
+	assert.ElementsMatch(t, ids, chunks[0].RelatedChunks)
+	assert.NotEmpty(t, chunks[0].Embeddings)
+
+	// Exempt repositories are left alone
+	systemOperation(t, server, OperationRepoConfigSet, map[string]interface{}{"repository": repo, "decay": map[string]interface{}{"exempt": true}})
+	result, err = server.handleMemoryDecayManagement(ctx, map[string]interface{}{
+		"repository": repo, "session_id": "s1", "action": "run_decay",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "decay_skipped", result.(map[string]interface{})["status"])
+}
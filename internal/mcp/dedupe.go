@@ -0,0 +1,183 @@
+package mcp
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"lerian-mcp-memory/internal/audit"
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/dedupe"
+	"lerian-mcp-memory/internal/logging"
+	"lerian-mcp-memory/internal/relationships"
+	"lerian-mcp-memory/internal/security"
+	"lerian-mcp-memory/pkg/types"
+)
+
+// duplicatePolicy returns the store_chunk duplicate_policy option, or the
+// server's dedupe.policy when it is not set
+func (ms *MemoryServer) duplicatePolicy(params map[string]interface{}) (string, error) {
+	policy, _ := params["duplicate_policy"].(string)
+	if policy == "" {
+		return ms.container.CurrentConfig().Dedupe.Policy, nil
+	}
+	if err := config.ValidateDedupePolicy(policy); err != nil {
+		return "", err
+	}
+	return policy, nil
+}
+
+// findDuplicate looks for a recent chunk that the new chunk nearly
+// duplicates. A failed lookup is logged and the chunk is stored as is.
+func (ms *MemoryServer) findDuplicate(ctx context.Context, chunk *types.ConversationChunk, policy string) *dedupe.Match {
+	cfg := ms.container.CurrentConfig().Dedupe
+	cfg.Policy = policy
+
+	match, err := dedupe.NewDetector(ms.container.GetVectorStore()).FindDuplicate(ctx, chunk, &cfg)
+	if err != nil {
+		logging.Warn("Duplicate detection failed", "error", err, "chunk_id", chunk.ID)
+		return nil
+	}
+	if match != nil {
+		logging.Info("Found near-duplicate chunk", "chunk_id", chunk.ID, "duplicate_of", match.Chunk.ID,
+			"policy", policy, "embedding_similarity", match.EmbeddingSimilarity, "shingle_similarity", match.ShingleSimilarity)
+	}
+	return match
+}
+
+// resolveDuplicate handles the merge and reject policies, which store no
+// new chunk
+func (ms *MemoryServer) resolveDuplicate(ctx context.Context, chunk *types.ConversationChunk, match *dedupe.Match, policy string, redactions []security.Redaction) (interface{}, error) {
+	if policy == config.DedupePolicyReject {
+		return map[string]interface{}{
+			"stored":    false,
+			"chunk_id":  match.Chunk.ID,
+			"type":      string(match.Chunk.Type),
+			"summary":   match.Chunk.Summary,
+			"duplicate": duplicateReport(match, policy),
+		}, nil
+	}
+
+	merged := mergeChunks(&match.Chunk, chunk)
+	if err := ms.container.GetVectorStore().Update(ctx, merged); err != nil {
+		return nil, err
+	}
+
+	if auditLogger := ms.container.GetAuditLogger(); auditLogger != nil {
+		auditLogger.LogEvent(ctx, audit.EventTypeMemoryUpdate, "Merged near-duplicate memory chunk", "memory", merged.ID, map[string]interface{}{
+			"repository":           merged.Metadata.Repository,
+			"embedding_similarity": match.EmbeddingSimilarity,
+			"shingle_similarity":   match.ShingleSimilarity,
+			"merge_count":          merged.Metadata.ExtendedMetadata[types.EMKeyMergeCount],
+		})
+	}
+	ms.auditRedactions(ctx, merged.Metadata.Repository, merged.ID, "memory_store_chunk", redactions, false)
+
+	response := map[string]interface{}{
+		"stored":    true,
+		"chunk_id":  merged.ID,
+		"type":      string(merged.Type),
+		"summary":   merged.Summary,
+		"stored_at": merged.Timestamp.Format(time.RFC3339),
+		"duplicate": duplicateReport(match, policy),
+	}
+	if len(redactions) > 0 {
+		response["redactions"] = redactions
+	}
+	return response, nil
+}
+
+// mergeChunks folds a new chunk into the existing one it duplicates. The
+// newer content, summary and embeddings replace the old; tags, files and
+// tools are combined.
+func mergeChunks(existing, chunk *types.ConversationChunk) *types.ConversationChunk {
+	merged := *existing
+	merged.Content = chunk.Content
+	merged.Summary = chunk.Summary
+	merged.Embeddings = chunk.Embeddings
+	merged.Timestamp = chunk.Timestamp
+	merged.Type = chunk.Type
+	merged.Metadata.Outcome = chunk.Metadata.Outcome
+	merged.Metadata.Tags = unionStrings(existing.Metadata.Tags, chunk.Metadata.Tags)
+	merged.Metadata.FilesModified = unionStrings(existing.Metadata.FilesModified, chunk.Metadata.FilesModified)
+	merged.Metadata.ToolsUsed = unionStrings(existing.Metadata.ToolsUsed, chunk.Metadata.ToolsUsed)
+
+	extended := make(map[string]interface{}, len(existing.Metadata.ExtendedMetadata)+len(chunk.Metadata.ExtendedMetadata)+1)
+	for key, value := range existing.Metadata.ExtendedMetadata {
+		extended[key] = value
+	}
+	// Redaction flags describe the content, which is replaced
+	delete(extended, types.EMKeyRedacted)
+	delete(extended, types.EMKeyRedactions)
+	for key, value := range chunk.Metadata.ExtendedMetadata {
+		extended[key] = value
+	}
+	extended[types.EMKeyMergeCount] = mergeCount(existing.Metadata.ExtendedMetadata) + 1
+	merged.Metadata.ExtendedMetadata = extended
+	return &merged
+}
+
+// mergeCount returns how many chunks have been merged into a chunk. Stored
+// metadata decodes numbers as float64.
+func mergeCount(extended map[string]interface{}) int {
+	switch count := extended[types.EMKeyMergeCount].(type) {
+	case int:
+		return count
+	case float64:
+		return int(count)
+	default:
+		return 0
+	}
+}
+
+// markSupersedes records on a new chunk which earlier chunk it replaces
+func markSupersedes(chunk *types.ConversationChunk, match *dedupe.Match) {
+	if chunk.Metadata.ExtendedMetadata == nil {
+		chunk.Metadata.ExtendedMetadata = make(map[string]interface{})
+	}
+	chunk.Metadata.ExtendedMetadata[types.EMKeySupersedes] = match.Chunk.ID
+}
+
+// linkSuperseded marks the earlier chunk as superseded by the stored chunk
+// and records a supersedes relationship between them
+func (ms *MemoryServer) linkSuperseded(ctx context.Context, chunk *types.ConversationChunk, match *dedupe.Match) {
+	old := match.Chunk
+	if old.Metadata.ExtendedMetadata == nil {
+		old.Metadata.ExtendedMetadata = make(map[string]interface{})
+	}
+	old.Metadata.ExtendedMetadata[types.EMKeySupersededBy] = chunk.ID
+	if err := ms.container.GetVectorStore().Update(ctx, &old); err != nil {
+		logging.Warn("Failed to mark chunk as superseded", "error", err, "chunk_id", old.ID, "superseded_by", chunk.ID)
+	}
+
+	relMgr := ms.container.GetRelationshipManager()
+	if _, err := relMgr.AddRelationship(ctx, chunk.ID, old.ID, relationships.RelTypeSupersedes, math.Min(match.Score(), 1), "Near-duplicate of an earlier chunk"); err != nil {
+		logging.Warn("Failed to create supersedes relationship", "error", err, "from", chunk.ID, "to", old.ID)
+	}
+}
+
+// duplicateReport describes a match in store_chunk responses
+func duplicateReport(match *dedupe.Match, policy string) map[string]interface{} {
+	return map[string]interface{}{
+		"chunk_id":             match.Chunk.ID,
+		"embedding_similarity": match.EmbeddingSimilarity,
+		"shingle_similarity":   match.ShingleSimilarity,
+		"action":               policy,
+	}
+}
+
+// unionStrings appends the values of b missing from a, keeping order
+func unionStrings(a, b []string) []string {
+	result := append([]string{}, a...)
+	seen := make(map[string]bool, len(a)+len(b))
+	for _, value := range a {
+		seen[value] = true
+	}
+	for _, value := range b {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"lerian-mcp-memory/internal/relationships"
+	"lerian-mcp-memory/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const duplicateContent = "Fixed the flaky billing test by freezing the clock before creating the invoice fixtures"
+
+func storeChunkParams(content, policy string, tags ...interface{}) map[string]interface{} {
+	params := map[string]interface{}{
+		"content":    content,
+		"session_id": "s1",
+		"repository": "github.com/acme/api",
+		"tags":       tags,
+	}
+	if policy != "" {
+		params["duplicate_policy"] = policy
+	}
+	return params
+}
+
+func storeChunk(t *testing.T, server *MemoryServer, params map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	result, err := server.handleStoreChunk(context.Background(), params)
+	require.NoError(t, err)
+	return result.(map[string]interface{})
+}
+
+func TestStoreChunkMergesDuplicates(t *testing.T) {
+	server := newLocalMemoryServer(t)
+	ctx := context.Background()
+
+	first := storeChunk(t, server, storeChunkParams(duplicateContent, "", "testing"))
+	assert.NotContains(t, first, "duplicate")
+
+	second := storeChunk(t, server, storeChunkParams(duplicateContent+".", "", "billing"))
+	assert.Equal(t, first["chunk_id"], second["chunk_id"], "merge is the default policy")
+	require.Contains(t, second, "duplicate")
+	report := second["duplicate"].(map[string]interface{})
+	assert.Equal(t, "merge", report["action"])
+	assert.Equal(t, first["chunk_id"], report["chunk_id"])
+	assert.InDelta(t, 1.0, report["shingle_similarity"], 1e-9)
+
+	chunks, err := server.container.GetVectorStore().ListByRepository(ctx, "github.com/acme/api", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	assert.Subset(t, chunks[0].Metadata.Tags, []string{"testing", "billing"})
+	assert.EqualValues(t, 1, chunks[0].Metadata.ExtendedMetadata[types.EMKeyMergeCount])
+
+	// Different content is stored as a new chunk
+	other := storeChunk(t, server, storeChunkParams("Upgraded the postgres driver to expose connection pool metrics", ""))
+	assert.NotEqual(t, first["chunk_id"], other["chunk_id"])
+	assert.NotContains(t, other, "duplicate")
+}
+
+func TestStoreChunkDuplicatePolicies(t *testing.T) {
+	server := newLocalMemoryServer(t)
+	ctx := context.Background()
+	first := storeChunk(t, server, storeChunkParams(duplicateContent, ""))
+
+	rejected := storeChunk(t, server, storeChunkParams(duplicateContent, "reject"))
+	assert.Equal(t, false, rejected["stored"])
+	assert.Equal(t, first["chunk_id"], rejected["chunk_id"])
+	assert.Equal(t, "reject", rejected["duplicate"].(map[string]interface{})["action"])
+
+	superseding := storeChunk(t, server, storeChunkParams(duplicateContent, "supersede"))
+	newID, oldID := superseding["chunk_id"].(string), first["chunk_id"].(string)
+	assert.NotEqual(t, oldID, newID)
+	assert.Equal(t, "supersede", superseding["duplicate"].(map[string]interface{})["action"])
+
+	newChunk, err := server.container.GetVectorStore().GetByID(ctx, newID)
+	require.NoError(t, err)
+	assert.Equal(t, oldID, newChunk.Metadata.ExtendedMetadata[types.EMKeySupersedes])
+	oldChunk, err := server.container.GetVectorStore().GetByID(ctx, oldID)
+	require.NoError(t, err)
+	assert.Equal(t, newID, oldChunk.Metadata.ExtendedMetadata[types.EMKeySupersededBy])
+	supersedes := server.container.GetRelationshipManager().GetRelationshipsByType(newID, relationships.RelTypeSupersedes)
+	require.Len(t, supersedes, 1)
+	assert.Equal(t, oldID, supersedes[0].ToChunkID)
+
+	allowed := storeChunk(t, server, storeChunkParams(duplicateContent, "allow"))
+	assert.NotContains(t, allowed, "duplicate")
+	assert.NotEqual(t, newID, allowed["chunk_id"])
+
+	_, err = server.handleStoreChunk(ctx, storeChunkParams(duplicateContent, "ignore"))
+	require.Error(t, err)
+}
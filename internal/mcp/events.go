@@ -0,0 +1,91 @@
+package mcp
+
+import (
+	"context"
+	"lerian-mcp-memory/internal/storage"
+	"lerian-mcp-memory/internal/threading"
+	"lerian-mcp-memory/internal/websocket"
+	"time"
+)
+
+// publishChange turns a store write into a WebSocket event
+func (ms *MemoryServer) publishChange(_ context.Context, change storage.ChangeEvent) {
+	event := websocket.MemoryEvent{
+		Type:      websocket.EventTypeMemory,
+		Action:    change.Action,
+		ChunkID:   change.ChunkID,
+		Timestamp: time.Now(),
+	}
+
+	if chunk := change.Chunk; chunk != nil {
+		event.Repository = chunk.Metadata.Repository
+		event.SessionID = chunk.SessionID
+		if change.Relationship == nil {
+			event.ChunkType = string(chunk.Type)
+			event.Summary = chunk.Summary
+			event.Tags = chunk.Metadata.Tags
+		}
+	}
+
+	if relationship := change.Relationship; relationship != nil {
+		event.Type = websocket.EventTypeRelationship
+		event.Data = map[string]interface{}{
+			"relationship_id":   relationship.ID,
+			"source_chunk_id":   relationship.SourceChunkID,
+			"target_chunk_id":   relationship.TargetChunkID,
+			"relation_type":     string(relationship.RelationType),
+			"confidence":        relationship.Confidence,
+			"confidence_source": string(relationship.ConfidenceSource),
+		}
+	}
+
+	ms.publishEvent(&event)
+}
+
+// publishThreadEvent reports a thread change
+func (ms *MemoryServer) publishThreadEvent(action string, thread *threading.MemoryThread) {
+	event := websocket.NewMemoryEvent(websocket.EventTypeThread, action, "", thread.Repository, "", map[string]interface{}{
+		"thread_id":   thread.ID,
+		"title":       thread.Title,
+		"type":        string(thread.Type),
+		"status":      string(thread.Status),
+		"chunk_count": len(thread.ChunkIDs),
+	})
+	ms.publishEvent(&event)
+}
+
+// publishTaskEvent reports a change to a workflow session or its todos
+func (ms *MemoryServer) publishTaskEvent(action, repository, sessionID string, data map[string]interface{}) {
+	event := websocket.NewMemoryEvent(websocket.EventTypeTask, action, "", repository, sessionID, data)
+	ms.publishEvent(&event)
+}
+
+// publishEvent broadcasts to WebSocket clients when a hub is configured,
+// queues the event for matching webhooks and tells sessions subscribed to
+// the affected resources
+func (ms *MemoryServer) publishEvent(event *websocket.MemoryEvent) {
+	if hub := ms.events.Load(); hub != nil {
+		hub.BroadcastMemoryEvent(event)
+	}
+	if ms.webhooks != nil {
+		ms.webhooks.Publish(event)
+	}
+	if ms.resourceSubs != nil {
+		ms.resourceSubs.notify(resourcesForEvent(event))
+	}
+}
+
+// resolveWebhookEvent refills the summary and tags of a chunk event whose
+// webhook delivery was restored from disk, where only its IDs are kept.
+// Deleted chunks and other event types go out with their metadata.
+func (ms *MemoryServer) resolveWebhookEvent(ctx context.Context, event *websocket.MemoryEvent) {
+	if event.Type != websocket.EventTypeMemory || event.ChunkID == "" || event.Action == websocket.ActionDeleted {
+		return
+	}
+	chunk, err := ms.container.GetVectorStore().GetByID(ctx, event.ChunkID)
+	if err != nil || chunk == nil {
+		return
+	}
+	event.Summary = chunk.Summary
+	event.Tags = chunk.Metadata.Tags
+}
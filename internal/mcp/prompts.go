@@ -0,0 +1,429 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"lerian-mcp-memory/internal/logging"
+	"lerian-mcp-memory/pkg/types"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	mcp "github.com/fredcamaral/gomcp-sdk"
+	"github.com/fredcamaral/gomcp-sdk/protocol"
+)
+
+// Prompt names
+const (
+	PromptResumeWork     = "resume_work"
+	PromptWriteADR       = "write_adr"
+	PromptSessionHandoff = "session_handoff"
+)
+
+const (
+	// maxPromptDecisions bounds the decisions quoted in an ADR prompt
+	maxPromptDecisions = 10
+
+	// maxPromptContentLength bounds each quoted chunk so prompts stay small
+	maxPromptContentLength = 2000
+
+	// promptRepositoryScan is how many recent chunks prompts look through
+	promptRepositoryScan = 200
+)
+
+// promptMessage is a message in a prompts/get result
+type promptMessage struct {
+	Role    string           `json:"role"`
+	Content protocol.Content `json:"content"`
+}
+
+// promptResult is the prompts/get result
+type promptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []promptMessage `json:"messages"`
+}
+
+// memoryPrompt is a prompt assembled from stored memory when it is requested
+type memoryPrompt struct {
+	prompt protocol.Prompt
+	build  func(ctx context.Context, args map[string]interface{}) (*promptResult, error)
+}
+
+// registerPrompts registers the workflow prompts clients can list and invoke
+func (ms *MemoryServer) registerPrompts() {
+	repositoryArg := mcp.NewPromptArgument("repository", "Repository URL, e.g. github.com/user/repo", true)
+
+	prompts := []memoryPrompt{
+		{
+			prompt: mcp.NewPrompt(PromptResumeWork,
+				"Resume work on a repository: incomplete work, recent activity, open todos and decisions from memory",
+				[]protocol.PromptArgument{
+					repositoryArg,
+					mcp.NewPromptArgument("recent_days", "Days of history to include (default 7)", false),
+				}),
+			build: ms.buildResumeWorkPrompt,
+		},
+		{
+			prompt: mcp.NewPrompt(PromptWriteADR,
+				"Write an Architecture Decision Record from the decisions stored for a repository",
+				[]protocol.PromptArgument{
+					repositoryArg,
+					mcp.NewPromptArgument("topic", "Only use decisions mentioning this topic", false),
+				}),
+			build: ms.buildWriteADRPrompt,
+		},
+		{
+			prompt: mcp.NewPrompt(PromptSessionHandoff,
+				"Write a handoff note for a session: what was done, what is unfinished and what to do next",
+				[]protocol.PromptArgument{
+					repositoryArg,
+					mcp.NewPromptArgument("session_id", "Session to hand off (default: the most recent one)", false),
+				}),
+			build: ms.buildSessionHandoffPrompt,
+		},
+	}
+
+	ms.prompts = make(map[string]memoryPrompt, len(prompts))
+	for _, p := range prompts {
+		p := p
+		ms.prompts[p.prompt.Name] = p
+		// The SDK lists the prompts; prompts/get is answered by HandleRequest
+		ms.mcpServer.AddPrompt(p.prompt, mcp.PromptHandlerFunc(func(ctx context.Context, args map[string]interface{}) ([]protocol.Content, error) {
+			result, err := ms.getPrompt(ctx, p.prompt.Name, args)
+			if err != nil {
+				return nil, err
+			}
+			contents := make([]protocol.Content, 0, len(result.Messages))
+			for _, message := range result.Messages {
+				contents = append(contents, message.Content)
+			}
+			return contents, nil
+		}))
+	}
+}
+
+// getPrompt checks the caller may read the repository and builds the prompt
+func (ms *MemoryServer) getPrompt(ctx context.Context, name string, args map[string]interface{}) (*promptResult, error) {
+	p, ok := ms.prompts[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown prompt %q", name)
+	}
+	for _, arg := range p.prompt.Arguments {
+		if arg.Required && promptArg(args, arg.Name) == "" {
+			return nil, fmt.Errorf("argument %q is required for prompt %s", arg.Name, name)
+		}
+	}
+	if err := ms.authorizeRepositoryRead(ctx, name, promptArg(args, "repository")); err != nil {
+		return nil, err
+	}
+
+	logging.Info("MCP PROMPT: prompt requested", "name", name, "args", args)
+	return p.build(ctx, args)
+}
+
+// handlePromptsGet answers prompts/get with role-tagged messages
+func (ms *MemoryServer) handlePromptsGet(ctx context.Context, req *protocol.JSONRPCRequest) *protocol.JSONRPCResponse {
+	params, _ := req.Params.(map[string]interface{})
+	name, _ := params["name"].(string)
+	if name == "" {
+		return jsonRPCError(req, protocol.InvalidParams, "Name parameter required")
+	}
+	if _, ok := ms.prompts[name]; !ok {
+		return jsonRPCError(req, protocol.InvalidParams, "Prompt not found: "+name)
+	}
+
+	args, _ := params["arguments"].(map[string]interface{})
+	result, err := ms.getPrompt(ctx, name, args)
+	if err != nil {
+		return jsonRPCError(req, protocol.InternalError, err.Error())
+	}
+	return &protocol.JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+// buildResumeWorkPrompt summarizes where work on a repository stands
+func (ms *MemoryServer) buildResumeWorkPrompt(ctx context.Context, args map[string]interface{}) (*promptResult, error) {
+	repository := promptArg(args, "repository")
+	recentDays := 7
+	if days, err := strconv.Atoi(promptArg(args, "recent_days")); err == nil && days > 0 {
+		recentDays = days
+	}
+
+	contextData, err := ms.buildEnhancedContext(ctx, repository, recentDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build context: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "I am resuming work on %s. This is what memory holds from the last %d days.\n", repository, recentDays)
+
+	incomplete, _ := contextData["incomplete_work"].([]map[string]interface{})
+	writePromptSection(&b, "Incomplete work", formatChunkSummaries(incomplete))
+	activity, _ := contextData["recent_activity"].([]map[string]interface{})
+	writePromptSection(&b, "Recent activity", formatChunkSummaries(activity))
+	writePromptSection(&b, "Open todos", ms.openTodoLines(repository, ""))
+	decisions, _ := contextData["architectural_decisions"].([]string)
+	writePromptSection(&b, "Architectural decisions", decisions)
+	patterns, _ := contextData["common_patterns"].([]string)
+	writePromptSection(&b, "Common patterns", patterns)
+	techStack, _ := contextData["tech_stack"].([]string)
+	writePromptSection(&b, "Tech stack", techStack)
+
+	b.WriteString("\nSummarize where the work stands, starting with the incomplete items, and propose the next steps. ")
+	b.WriteString("Use memory_read to fetch the full content of any chunk listed above before relying on it.\n")
+
+	return userPrompt("Resume work on "+repository, b.String()), nil
+}
+
+// buildWriteADRPrompt quotes stored decisions for an ADR
+func (ms *MemoryServer) buildWriteADRPrompt(ctx context.Context, args map[string]interface{}) (*promptResult, error) {
+	repository := promptArg(args, "repository")
+	topic := strings.ToLower(promptArg(args, "topic"))
+
+	chunks, err := ms.container.GetVectorStore().ListByRepository(ctx, repository, promptRepositoryScan, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository chunks: %w", err)
+	}
+
+	decisions := make([]types.ConversationChunk, 0)
+	for i := range chunks {
+		chunk := &chunks[i]
+		if chunk.Type != types.ChunkTypeArchitectureDecision {
+			continue
+		}
+		if topic != "" && !chunkMentions(chunk, topic) {
+			continue
+		}
+		decisions = append(decisions, *chunk)
+	}
+	if len(decisions) == 0 {
+		if topic != "" {
+			return nil, fmt.Errorf("no architectural decisions about %q are stored for %s", topic, repository)
+		}
+		return nil, fmt.Errorf("no architectural decisions are stored for %s", repository)
+	}
+	sort.Slice(decisions, func(i, j int) bool { return decisions[i].Timestamp.After(decisions[j].Timestamp) })
+	if len(decisions) > maxPromptDecisions {
+		decisions = decisions[:maxPromptDecisions]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Write an Architecture Decision Record for %s from the decisions recorded in memory below.\n", repository)
+	for i := range decisions {
+		decision := &decisions[i]
+		fmt.Fprintf(&b, "\n### %s\nChunk %s, recorded %s", decisionTitle(decision), decision.ID, decision.Timestamp.Format("2006-01-02"))
+		if len(decision.Metadata.Tags) > 0 {
+			fmt.Fprintf(&b, ", tags: %s", strings.Join(decision.Metadata.Tags, ", "))
+		}
+		fmt.Fprintf(&b, "\n\n%s\n", truncatePromptContent(decision.Content))
+	}
+
+	b.WriteString("\nUse the sections Title, Status, Context, Decision, Consequences and Alternatives Considered. ")
+	b.WriteString("Cite the chunk IDs the record is based on, and list the questions these decisions leave open instead of inventing answers.\n")
+
+	return userPrompt("Architecture Decision Record for "+repository, b.String()), nil
+}
+
+// buildSessionHandoffPrompt describes a session for whoever continues it
+func (ms *MemoryServer) buildSessionHandoffPrompt(ctx context.Context, args map[string]interface{}) (*promptResult, error) {
+	repository := promptArg(args, "repository")
+	sessionID := promptArg(args, "session_id")
+
+	chunks, err := ms.sessionChunksForHandoff(ctx, repository, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 && sessionID == "" {
+		return nil, fmt.Errorf("no sessions are stored for %s", repository)
+	}
+	if sessionID == "" {
+		sessionID = chunks[len(chunks)-1].SessionID
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "I am handing off session %s on %s. This is what memory recorded.\n", sessionID, repository)
+
+	timeline := make([]string, 0, len(chunks))
+	files := []string{}
+	seenFiles := map[string]bool{}
+	for i := range chunks {
+		chunk := &chunks[i]
+		line := fmt.Sprintf("%s [%s] %s", chunk.Timestamp.Format(time.RFC3339), chunk.Type, chunkSummary(chunk))
+		if chunk.Metadata.Outcome != "" {
+			line += " (" + string(chunk.Metadata.Outcome) + ")"
+		}
+		timeline = append(timeline, line+" - chunk "+chunk.ID)
+		for _, file := range chunk.Metadata.FilesModified {
+			if !seenFiles[file] {
+				seenFiles[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	writePromptSection(&b, "What happened", timeline)
+	writePromptSection(&b, "Unfinished work", formatChunkSummaries(ms.detectIncompleteWork(chunks)))
+	writePromptSection(&b, "Todos", ms.openTodoLines(repository, sessionID))
+	writePromptSection(&b, "Files changed", files)
+
+	b.WriteString("\nWrite a handoff note for whoever continues this work: what was done, what is still in progress, ")
+	b.WriteString("open problems and blockers, and concrete next steps naming the files involved.\n")
+
+	return userPrompt("Handoff for session "+sessionID, b.String()), nil
+}
+
+// sessionChunksForHandoff returns a session's chunks in time order. Without
+// a session ID the repository's most recent session is used.
+func (ms *MemoryServer) sessionChunksForHandoff(ctx context.Context, repository, sessionID string) ([]types.ConversationChunk, error) {
+	store := ms.container.GetVectorStore()
+
+	var candidates []types.ConversationChunk
+	var err error
+	if sessionID != "" {
+		candidates, err = store.ListBySession(ctx, sessionID)
+	} else {
+		candidates, err = store.ListByRepository(ctx, repository, promptRepositoryScan, 0)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session chunks: %w", err)
+	}
+
+	if sessionID == "" {
+		var latest time.Time
+		for i := range candidates {
+			if candidates[i].Timestamp.After(latest) {
+				latest, sessionID = candidates[i].Timestamp, candidates[i].SessionID
+			}
+		}
+	}
+
+	chunks := make([]types.ConversationChunk, 0, len(candidates))
+	for i := range candidates {
+		chunk := &candidates[i]
+		if chunk.SessionID == sessionID && chunk.Metadata.Repository == repository {
+			chunks = append(chunks, *chunk)
+		}
+	}
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].Timestamp.Before(chunks[j].Timestamp) })
+	return chunks, nil
+}
+
+// openTodoLines lists unfinished todos of a repository's active sessions,
+// or of one session
+func (ms *MemoryServer) openTodoLines(repository, sessionID string) []string {
+	sessions := ms.todoTracker.GetActiveSessionsByRepository(repository)
+	keys := make([]string, 0, len(sessions))
+	for key := range sessions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var lines []string
+	for _, key := range keys {
+		session := sessions[key]
+		if sessionID != "" && session.SessionID != sessionID {
+			continue
+		}
+		for _, todo := range session.Todos {
+			if todo.Status == "completed" || todo.Status == "cancelled" {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("[%s] %s (session %s)", todo.Status, todo.Content, session.SessionID))
+		}
+	}
+	return lines
+}
+
+// formatChunkSummaries renders the chunk maps built by the context helpers
+func formatChunkSummaries(items []map[string]interface{}) []string {
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		line := fmt.Sprintf("[%v] %v", item["type"], item["summary"])
+		if outcome, ok := item["outcome"].(string); ok && outcome != "" {
+			line += " (" + outcome + ")"
+		}
+		if id, ok := item["chunk_id"].(string); ok {
+			line += " - chunk " + id
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func writePromptSection(b *strings.Builder, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n", title)
+	for _, line := range lines {
+		fmt.Fprintf(b, "- %s\n", line)
+	}
+}
+
+func userPrompt(description, text string) *promptResult {
+	return &promptResult{
+		Description: description,
+		Messages: []promptMessage{{
+			Role:    "user",
+			Content: protocol.Content{Type: "text", Text: text},
+		}},
+	}
+}
+
+// promptArg reads a prompt argument. Arguments are strings in MCP, but
+// numbers and booleans sent by lenient clients are accepted.
+func promptArg(args map[string]interface{}, name string) string {
+	switch value := args[name].(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case nil:
+		return ""
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return ""
+		}
+		return string(encoded)
+	}
+}
+
+func chunkMentions(chunk *types.ConversationChunk, topic string) bool {
+	if strings.Contains(strings.ToLower(chunk.Summary), topic) || strings.Contains(strings.ToLower(chunk.Content), topic) {
+		return true
+	}
+	for _, tag := range chunk.Metadata.Tags {
+		if strings.Contains(strings.ToLower(tag), topic) {
+			return true
+		}
+	}
+	return false
+}
+
+func decisionTitle(chunk *types.ConversationChunk) string {
+	if chunk.Summary != "" {
+		return chunk.Summary
+	}
+	return "Decision " + chunk.ID
+}
+
+func chunkSummary(chunk *types.ConversationChunk) string {
+	if chunk.Summary != "" {
+		return chunk.Summary
+	}
+	return truncatePromptContent(strings.SplitN(chunk.Content, "\n", 2)[0])
+}
+
+func truncatePromptContent(content string) string {
+	if len(content) <= maxPromptContentLength {
+		return content
+	}
+	return strings.ToValidUTF8(content[:maxPromptContentLength], "") + "…"
+}
+
+func jsonRPCError(req *protocol.JSONRPCRequest, code int, message string) *protocol.JSONRPCResponse {
+	return &protocol.JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Error:   protocol.NewJSONRPCError(code, message, nil),
+	}
+}
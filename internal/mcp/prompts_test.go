@@ -0,0 +1,134 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/pkg/types"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fredcamaral/gomcp-sdk/protocol"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLocalMemoryServer creates a server on the embedded store, which needs
+// no external services
+func newLocalMemoryServer(t *testing.T) *MemoryServer {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	cfg.Storage.Provider = config.StorageProviderLocal
+	cfg.Storage.Local.DataDir = t.TempDir()
+	cfg.Storage.RepositoriesFile = filepath.Join(t.TempDir(), "repositories.json")
+	cfg.Embedding.Provider = config.EmbeddingProviderHash
+
+	server, err := NewMemoryServer(cfg)
+	require.NoError(t, err)
+	require.NoError(t, server.container.GetVectorStore().Initialize(context.Background()))
+	t.Cleanup(func() { _ = server.container.GetVectorStore().Close() })
+	return server
+}
+
+func storePromptChunk(t *testing.T, server *MemoryServer, sessionID string, chunkType types.ChunkType, summary string, outcome types.Outcome, at time.Time) *types.ConversationChunk {
+	t.Helper()
+	chunk := &types.ConversationChunk{
+		ID:         uuid.New().String(),
+		SessionID:  sessionID,
+		Timestamp:  at,
+		Type:       chunkType,
+		Content:    summary + " in detail",
+		Summary:    summary,
+		Embeddings: []float64{0.1, 0.2, 0.3},
+		Metadata: types.ChunkMetadata{
+			Repository:    "github.com/acme/api",
+			Outcome:       outcome,
+			Difficulty:    types.DifficultySimple,
+			FilesModified: []string{"billing/charge.go"},
+		},
+	}
+	require.NoError(t, server.container.GetVectorStore().Store(context.Background(), chunk))
+	return chunk
+}
+
+func getPrompt(t *testing.T, server *MemoryServer, name string, args map[string]interface{}) *protocol.JSONRPCResponse {
+	t.Helper()
+	return server.HandleRequest(context.Background(), &protocol.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "prompts/get",
+		Params:  map[string]interface{}{"name": name, "arguments": args},
+	})
+}
+
+func promptText(t *testing.T, resp *protocol.JSONRPCResponse) string {
+	t.Helper()
+	require.Nil(t, resp.Error)
+	data, err := json.Marshal(resp.Result)
+	require.NoError(t, err)
+
+	var result struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(data, &result))
+	require.Len(t, result.Messages, 1)
+	assert.Equal(t, "user", result.Messages[0].Role)
+	assert.Equal(t, "text", result.Messages[0].Content.Type)
+	return result.Messages[0].Content.Text
+}
+
+func TestPromptsAreListed(t *testing.T) {
+	server := newLocalMemoryServer(t)
+	resp := server.HandleRequest(context.Background(), &protocol.JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "prompts/list"})
+	require.Nil(t, resp.Error)
+
+	data, err := json.Marshal(resp.Result)
+	require.NoError(t, err)
+	for _, name := range []string{PromptResumeWork, PromptWriteADR, PromptSessionHandoff} {
+		assert.Contains(t, string(data), `"name":"`+name+`"`)
+	}
+}
+
+func TestPromptsAssembleMemory(t *testing.T) {
+	server := newLocalMemoryServer(t)
+	now := time.Now()
+	storePromptChunk(t, server, "s1", types.ChunkTypeArchitectureDecision, "Use idempotency keys for charges", types.OutcomeSuccess, now.Add(-2*time.Hour))
+	storePromptChunk(t, server, "s2", types.ChunkTypeProblem, "Duplicate charges on retry", types.OutcomeInProgress, now.Add(-time.Hour))
+
+	resume := promptText(t, getPrompt(t, server, PromptResumeWork, map[string]interface{}{"repository": "github.com/acme/api"}))
+	assert.Contains(t, resume, "## Incomplete work")
+	assert.Contains(t, resume, "Duplicate charges on retry")
+
+	adr := promptText(t, getPrompt(t, server, PromptWriteADR, map[string]interface{}{"repository": "github.com/acme/api", "topic": "idempotency"}))
+	assert.Contains(t, adr, "### Use idempotency keys for charges")
+	assert.Contains(t, adr, "Consequences")
+
+	handoff := promptText(t, getPrompt(t, server, PromptSessionHandoff, map[string]interface{}{"repository": "github.com/acme/api"}))
+	assert.Contains(t, handoff, "session s2")
+	assert.Contains(t, handoff, "billing/charge.go")
+	assert.NotContains(t, handoff, "idempotency keys")
+}
+
+func TestPromptErrors(t *testing.T) {
+	server := newLocalMemoryServer(t)
+
+	resp := getPrompt(t, server, "missing", nil)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, protocol.InvalidParams, resp.Error.Code)
+
+	resp = getPrompt(t, server, PromptResumeWork, map[string]interface{}{})
+	require.NotNil(t, resp.Error)
+	assert.Contains(t, resp.Error.Message, "repository")
+
+	resp = getPrompt(t, server, PromptWriteADR, map[string]interface{}{"repository": "github.com/acme/empty"})
+	require.NotNil(t, resp.Error)
+	assert.Contains(t, resp.Error.Message, "no architectural decisions")
+}
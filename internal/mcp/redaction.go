@@ -0,0 +1,192 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lerian-mcp-memory/internal/audit"
+	"lerian-mcp-memory/internal/bulk"
+	"lerian-mcp-memory/internal/logging"
+	"lerian-mcp-memory/internal/security"
+	"lerian-mcp-memory/pkg/types"
+)
+
+// redactContent scrubs content bound for a repository before it is chunked
+// and embedded. Content that matches a block rule is audited and refused.
+func (ms *MemoryServer) redactContent(ctx context.Context, repository, content, source string) (string, []security.Redaction, error) {
+	redacted, redactions, err := ms.container.Redact(repository, content)
+	if err != nil {
+		var blocked *security.BlockedContentError
+		if errors.As(err, &blocked) {
+			ms.auditRedactions(ctx, repository, "", source, redactions, true)
+			logging.Warn("Refused content with sensitive data", "repository", repository, "source", source, "rules", blocked.Rules)
+		}
+		return "", nil, err
+	}
+	return redacted, redactions, nil
+}
+
+// redactStrings scrubs each value in place and returns what matched, merged
+// rule by rule
+func (ms *MemoryServer) redactStrings(ctx context.Context, repository, source string, values ...*string) ([]security.Redaction, error) {
+	var redactions []security.Redaction
+	for _, value := range values {
+		redacted, matched, err := ms.redactContent(ctx, repository, *value, source)
+		if err != nil {
+			return nil, err
+		}
+		*value = redacted
+		redactions = mergeRedactions(redactions, matched)
+	}
+	return redactions, nil
+}
+
+// redactChunk scrubs a chunk's content and summary and flags it when
+// anything matched
+func (ms *MemoryServer) redactChunk(ctx context.Context, chunk *types.ConversationChunk, source string) ([]security.Redaction, error) {
+	content, summary := chunk.Content, chunk.Summary
+	redactions, err := ms.redactStrings(ctx, chunk.Metadata.Repository, source, &content, &summary)
+	if err != nil {
+		return nil, err
+	}
+	chunk.Content, chunk.Summary = content, summary
+	markRedacted(chunk, redactions)
+	return redactions, nil
+}
+
+// redactTemplateFields returns a copy of template fields with their text
+// scrubbed; the fields are kept in the chunk's metadata
+func (ms *MemoryServer) redactTemplateFields(ctx context.Context, repository string, fields map[string]interface{}) (map[string]interface{}, []security.Redaction, error) {
+	var redactions []security.Redaction
+	redacted := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		switch typed := value.(type) {
+		case string:
+			matched, err := ms.redactStrings(ctx, repository, "memory_create_from_template", &typed)
+			if err != nil {
+				return nil, nil, err
+			}
+			redactions = mergeRedactions(redactions, matched)
+			redacted[name] = typed
+		case []interface{}:
+			items := make([]interface{}, len(typed))
+			for i, item := range typed {
+				if text, ok := item.(string); ok {
+					matched, err := ms.redactStrings(ctx, repository, "memory_create_from_template", &text)
+					if err != nil {
+						return nil, nil, err
+					}
+					redactions = mergeRedactions(redactions, matched)
+					item = text
+				}
+				items[i] = item
+			}
+			redacted[name] = items
+		default:
+			redacted[name] = value
+		}
+	}
+	return redacted, redactions, nil
+}
+
+// redactImportedChunks scrubs the content and summaries of imported chunks.
+// Blocked chunks are dropped from the import and reported as errors.
+func (ms *MemoryServer) redactImportedChunks(ctx context.Context, result *bulk.ImportResult) {
+	kept := result.Chunks[:0]
+	for i := range result.Chunks {
+		chunk := result.Chunks[i]
+		repository := chunk.Metadata.Repository
+
+		redactions, err := ms.redactChunk(ctx, &chunk, "memory_bulk_import")
+		if err != nil {
+			result.Errors = append(result.Errors, bulk.ImportError{
+				Item:    i,
+				Message: fmt.Sprintf("chunk %s not stored: %v", chunk.ID, err),
+			})
+			result.FailedItems++
+			result.SuccessfulItems--
+			continue
+		}
+
+		ms.auditRedactions(ctx, repository, chunk.ID, "memory_bulk_import", redactions, false)
+		kept = append(kept, chunk)
+	}
+	result.Chunks = kept
+}
+
+// redactBulkChunks scrubs the chunks of a bulk operation. Blocked chunks
+// are dropped and described in the returned messages.
+func (ms *MemoryServer) redactBulkChunks(ctx context.Context, chunks []types.ConversationChunk) ([]types.ConversationChunk, []string) {
+	var blocked []string
+	kept := chunks[:0]
+	for i := range chunks {
+		chunk := chunks[i]
+		redactions, err := ms.redactChunk(ctx, &chunk, "memory_bulk_operation")
+		if err != nil {
+			blocked = append(blocked, fmt.Sprintf("chunk %s not stored: %v", chunk.ID, err))
+			continue
+		}
+		ms.auditRedactions(ctx, chunk.Metadata.Repository, chunk.ID, "memory_bulk_operation", redactions, false)
+		kept = append(kept, chunk)
+	}
+	return kept, blocked
+}
+
+// markRedacted flags a chunk whose content was scrubbed and names the rules
+func markRedacted(chunk *types.ConversationChunk, redactions []security.Redaction) {
+	if len(redactions) == 0 {
+		return
+	}
+	if chunk.Metadata.ExtendedMetadata == nil {
+		chunk.Metadata.ExtendedMetadata = make(map[string]interface{})
+	}
+	rules := make([]string, 0, len(redactions))
+	for _, redaction := range redactions {
+		rules = append(rules, redaction.Rule)
+	}
+	chunk.Metadata.ExtendedMetadata[types.EMKeyRedacted] = true
+	chunk.Metadata.ExtendedMetadata[types.EMKeyRedactions] = rules
+}
+
+// auditRedactions records which rules matched and what was done. The
+// matched values are never written.
+func (ms *MemoryServer) auditRedactions(ctx context.Context, repository, chunkID, source string, redactions []security.Redaction, blocked bool) {
+	auditLogger := ms.container.GetAuditLogger()
+	if auditLogger == nil || len(redactions) == 0 {
+		return
+	}
+
+	action := "Redacted sensitive data"
+	if blocked {
+		action = "Blocked content with sensitive data"
+	}
+	total := 0
+	for _, redaction := range redactions {
+		total += redaction.Count
+	}
+	auditLogger.LogEvent(ctx, audit.EventTypeRedaction, action, "memory", chunkID, map[string]interface{}{
+		"repository": repository,
+		"source":     source,
+		"blocked":    blocked,
+		"matches":    total,
+		"redactions": redactions,
+	})
+}
+
+// mergeRedactions adds the counts of b to a, rule by rule
+func mergeRedactions(a, b []security.Redaction) []security.Redaction {
+	for _, extra := range b {
+		merged := false
+		for i := range a {
+			if a[i].Rule == extra.Rule {
+				a[i].Count += extra.Count
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			a = append(a, extra)
+		}
+	}
+	return a
+}
@@ -0,0 +1,258 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/logging"
+	"time"
+)
+
+// ApplyConfig makes a reloaded configuration current without touching live
+// sessions. It returns the changed settings that need a restart.
+func (ms *MemoryServer) ApplyConfig(cfg *config.Config) []string {
+	restart := ms.container.ApplyConfig(cfg)
+	select {
+	case ms.configReloaded <- struct{}{}:
+	default:
+	}
+	return restart
+}
+
+// repoConfig returns the settings in effect for a repository
+func (ms *MemoryServer) repoConfig(repository string) config.RepoConfig {
+	return ms.container.RepoConfig(repository)
+}
+
+// checkRepositoryEnabled refuses writes to repositories whose memory is
+// turned off
+func (ms *MemoryServer) checkRepositoryEnabled(repository string) error {
+	if repository == "" || repository == GlobalMemoryRepository {
+		return nil
+	}
+	if repoConfig := ms.repoConfig(repository); !repoConfig.Enabled {
+		return fmt.Errorf("memory is disabled for repository %s. Enable it with memory_system repo_config_set {\"repository\": %q, \"enabled\": true}", repository, repository)
+	}
+	return nil
+}
+
+// minRelevance returns the search threshold for a repository
+func (ms *MemoryServer) minRelevance(repository string) float64 {
+	repoConfig := ms.repoConfig(repository)
+	return ms.container.CurrentConfig().MinRelevance(&repoConfig)
+}
+
+// decayThresholds returns the summarization and deletion thresholds for a
+// repository
+func (ms *MemoryServer) decayThresholds(repository string) (summarization, deletion float64) {
+	repoConfig := ms.repoConfig(repository)
+	return ms.container.CurrentConfig().DecayThresholds(&repoConfig)
+}
+
+// repoConfigResponse describes a repository's settings and the values they
+// resolve to
+func (ms *MemoryServer) repoConfigResponse(repository string) map[string]interface{} {
+	repoConfig := ms.repoConfig(repository)
+	cfg := ms.container.CurrentConfig()
+	_, stored := ms.container.RepoConfigs.Get(repository)
+	summarization, deletion := cfg.DecayThresholds(&repoConfig)
+
+	return map[string]interface{}{
+		"repository": repository,
+		"settings":   repoConfig,
+		"stored":     stored,
+		"effective": map[string]interface{}{
+			"enabled":                       repoConfig.Enabled,
+			"similarity_threshold":          cfg.MinRelevance(&repoConfig),
+			"retention_days":                cfg.RetentionDays(&repoConfig),
+			"decay_exempt":                  repoConfig.Decay.Exempt,
+			"decay_summarization_threshold": summarization,
+			"decay_deletion_threshold":      deletion,
+		},
+	}
+}
+
+// handleRepoConfigGet returns a repository's settings
+func (ms *MemoryServer) handleRepoConfigGet(_ context.Context, params map[string]interface{}) (interface{}, error) {
+	repository, ok := params["repository"].(string)
+	if !ok || repository == "" {
+		return nil, errors.New("repository parameter is required for repo_config_get. Example: {\"repository\": \"github.com/user/repo\"}")
+	}
+	return ms.repoConfigResponse(repository), nil
+}
+
+// handleRepoConfigSet changes a repository's settings. Options it leaves
+// out keep their current values.
+func (ms *MemoryServer) handleRepoConfigSet(_ context.Context, params map[string]interface{}) (interface{}, error) {
+	logging.Info("MCP TOOL: memory_system repo_config_set called", "params", params)
+
+	repository, ok := params["repository"].(string)
+	if !ok || repository == "" {
+		return nil, errors.New("repository parameter is required for repo_config_set. Example: {\"repository\": \"github.com/user/repo\", \"similarity_threshold\": 0.6, \"retention_days\": 30}")
+	}
+
+	repoConfig := ms.repoConfig(repository)
+	if enabled, ok := params["enabled"].(bool); ok {
+		repoConfig.Enabled = enabled
+	}
+	if sensitivity, ok := params["sensitivity"].(string); ok {
+		repoConfig.Sensitivity = sensitivity
+	}
+	if _, ok := params["exclude_patterns"]; ok {
+		repoConfig.ExcludePatterns = extractStringArray(params["exclude_patterns"])
+	}
+	if _, ok := params["tags"]; ok {
+		repoConfig.Tags = extractStringArray(params["tags"])
+	}
+	if threshold, ok := params["similarity_threshold"].(float64); ok {
+		repoConfig.SimilarityThreshold = threshold
+	}
+	if days, ok := params["retention_days"].(float64); ok {
+		repoConfig.RetentionDays = int(days)
+	}
+	if decay, ok := params["decay"].(map[string]interface{}); ok {
+		applyDecayPolicy(&repoConfig.Decay, decay)
+	}
+	if _, ok := params["redaction_rules"]; ok {
+		repoConfig.RedactionRules = parseRedactionRules(params["redaction_rules"])
+	}
+
+	if err := ms.container.RepoConfigs.Set(repository, repoConfig); err != nil {
+		return nil, fmt.Errorf("failed to save repository settings: %w", err)
+	}
+
+	logging.Info("Repository settings updated", "repository", repository)
+	return ms.repoConfigResponse(repository), nil
+}
+
+// handleRepoConfigReset drops a repository's stored settings
+func (ms *MemoryServer) handleRepoConfigReset(_ context.Context, params map[string]interface{}) (interface{}, error) {
+	logging.Info("MCP TOOL: memory_system repo_config_reset called", "params", params)
+
+	repository, ok := params["repository"].(string)
+	if !ok || repository == "" {
+		return nil, errors.New("repository parameter is required for repo_config_reset")
+	}
+	if err := ms.container.RepoConfigs.Delete(repository); err != nil {
+		if errors.Is(err, config.ErrRepoConfigNotFound) {
+			return nil, fmt.Errorf("repository %s has no stored settings", repository)
+		}
+		return nil, fmt.Errorf("failed to reset repository settings: %w", err)
+	}
+	return ms.repoConfigResponse(repository), nil
+}
+
+// handleRepoConfigList lists the repositories with stored settings
+func (ms *MemoryServer) handleRepoConfigList(_ context.Context, _ map[string]interface{}) (interface{}, error) {
+	repositories := ms.container.RepoConfigs.Repositories()
+	settings := make(map[string]config.RepoConfig, len(repositories))
+	for _, repository := range repositories {
+		settings[repository], _ = ms.container.RepoConfigs.Get(repository)
+	}
+	return map[string]interface{}{
+		"repositories": settings,
+		"total":        len(settings),
+	}, nil
+}
+
+// applyDecayPolicy overlays decay options on a policy
+func applyDecayPolicy(policy *config.DecayPolicy, options map[string]interface{}) {
+	if exempt, ok := options["exempt"].(bool); ok {
+		policy.Exempt = exempt
+	}
+	if threshold, ok := options["summarization_threshold"].(float64); ok {
+		policy.SummarizationThreshold = threshold
+	}
+	if threshold, ok := options["deletion_threshold"].(float64); ok {
+		policy.DeletionThreshold = threshold
+	}
+}
+
+// parseRedactionRules reads redaction rules from tool options. Rules are
+// validated when the settings are saved.
+func parseRedactionRules(value interface{}) []config.RedactionRule {
+	items, _ := value.([]interface{})
+	rules := make([]config.RedactionRule, 0, len(items))
+	for _, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		rule := config.RedactionRule{Action: config.RedactionActionMask}
+		rule.Name, _ = fields["name"].(string)
+		rule.Pattern, _ = fields["pattern"].(string)
+		if action, ok := fields["action"].(string); ok && action != "" {
+			rule.Action = action
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// Operations that label decay runs on /metrics
+const (
+	retentionCleanupOperation = "retention_cleanup"
+	decayRunOperation         = "run_decay"
+)
+
+// decayInterval returns how often retention cleanup runs
+func (ms *MemoryServer) decayInterval() time.Duration {
+	return time.Duration(ms.container.CurrentConfig().Decay.IntervalHours) * time.Hour
+}
+
+// runRetentionCleanup deletes memories older than their repository's
+// retention. Without per-repository overrides the store's own cleanup does
+// it in one pass.
+func (ms *MemoryServer) runRetentionCleanup(ctx context.Context) (int, error) {
+	cfg := ms.container.CurrentConfig()
+	repositories := ms.container.RepoConfigs.Repositories()
+	for repository := range cfg.Storage.Repositories {
+		repositories = append(repositories, repository)
+	}
+
+	overridden := false
+	for _, repository := range repositories {
+		repoConfig := ms.repoConfig(repository)
+		if repoConfig.RetentionDays > 0 || repoConfig.Decay.Exempt {
+			overridden = true
+			break
+		}
+	}
+	if !overridden {
+		return ms.container.GetVectorStore().Cleanup(ctx, cfg.Storage.RetentionDays)
+	}
+
+	chunks, err := ms.container.GetVectorStore().GetAllChunks(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list chunks for cleanup: %w", err)
+	}
+
+	now := time.Now()
+	cutoffs := make(map[string]time.Time)
+	var expired []string
+	for i := range chunks {
+		repository := chunks[i].Metadata.Repository
+		cutoff, ok := cutoffs[repository]
+		if !ok {
+			repoConfig := ms.repoConfig(repository)
+			if !repoConfig.Decay.Exempt {
+				cutoff = now.AddDate(0, 0, -cfg.RetentionDays(&repoConfig))
+			}
+			cutoffs[repository] = cutoff
+		}
+		// Exempt repositories keep the zero cutoff, which nothing precedes
+		if chunks[i].Timestamp.Before(cutoff) {
+			expired = append(expired, chunks[i].ID)
+		}
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	result, err := ms.container.GetVectorStore().BatchDelete(ctx, expired)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired chunks: %w", err)
+	}
+	return result.Success, nil
+}
@@ -0,0 +1,158 @@
+package mcp
+
+import (
+	"context"
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/logging"
+	"lerian-mcp-memory/pkg/types"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func systemOperation(t *testing.T, server *MemoryServer, operation string, options map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	result, err := server.routeSystemOperation(context.Background(), operation, options)
+	require.NoError(t, err, operation)
+	response, ok := result.(map[string]interface{})
+	require.True(t, ok)
+	return response
+}
+
+func TestRepoConfigOperations(t *testing.T) {
+	server := newLocalMemoryServer(t)
+	repo := "github.com/acme/api"
+
+	response := systemOperation(t, server, OperationRepoConfigGet, map[string]interface{}{"repository": repo})
+	assert.Equal(t, false, response["stored"])
+	assert.Equal(t, 0.5, response["effective"].(map[string]interface{})["similarity_threshold"])
+
+	response = systemOperation(t, server, OperationRepoConfigSet, map[string]interface{}{
+		"repository":           repo,
+		"similarity_threshold": 0.65,
+		"retention_days":       float64(30),
+		"decay":                map[string]interface{}{"summarization_threshold": 0.5},
+	})
+	assert.Equal(t, true, response["stored"])
+	effective := response["effective"].(map[string]interface{})
+	assert.Equal(t, 0.65, effective["similarity_threshold"])
+	assert.Equal(t, 30, effective["retention_days"])
+	assert.Equal(t, 0.5, effective["decay_summarization_threshold"])
+	assert.Equal(t, 0.1, effective["decay_deletion_threshold"], "unset thresholds use the server's")
+	assert.Equal(t, true, effective["enabled"], "options left out keep their values")
+
+	// Settings survive a restart
+	reopened, err := config.OpenRepoStore(server.container.Config.Storage.RepositoriesFile)
+	require.NoError(t, err)
+	saved, ok := reopened.Get(repo)
+	require.True(t, ok)
+	assert.Equal(t, 30, saved.RetentionDays)
+
+	_, err = server.routeSystemOperation(context.Background(), OperationRepoConfigSet, map[string]interface{}{"repository": repo, "similarity_threshold": 1.5})
+	require.Error(t, err)
+	_, err = server.routeSystemOperation(context.Background(), OperationRepoConfigSet, map[string]interface{}{"repository": repo, "decay": map[string]interface{}{"summarization_threshold": 0.2, "deletion_threshold": 0.3}})
+	require.Error(t, err)
+
+	response = systemOperation(t, server, OperationRepoConfigList, map[string]interface{}{})
+	assert.Equal(t, 1, response["total"])
+
+	response = systemOperation(t, server, OperationRepoConfigReset, map[string]interface{}{"repository": repo})
+	assert.Equal(t, false, response["stored"])
+	_, err = server.routeSystemOperation(context.Background(), OperationRepoConfigReset, map[string]interface{}{"repository": repo})
+	require.Error(t, err)
+}
+
+func TestDisabledRepositoryRefusesMemories(t *testing.T) {
+	server := newLocalMemoryServer(t)
+	repo := "github.com/acme/api"
+	params := map[string]interface{}{"content": "Fixed the refund rounding bug", "session_id": "s1", "repository": repo}
+
+	systemOperation(t, server, OperationRepoConfigSet, map[string]interface{}{"repository": repo, "enabled": false})
+	_, err := server.handleStoreChunk(context.Background(), params)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "memory is disabled")
+
+	systemOperation(t, server, OperationRepoConfigSet, map[string]interface{}{"repository": repo, "enabled": true})
+	_, err = server.handleStoreChunk(context.Background(), params)
+	require.NoError(t, err)
+}
+
+func TestRepositorySimilarityThreshold(t *testing.T) {
+	server := newLocalMemoryServer(t)
+	repo := "github.com/acme/api"
+	systemOperation(t, server, OperationRepoConfigSet, map[string]interface{}{"repository": repo, "similarity_threshold": 0.65})
+
+	query := server.buildMemoryQueryFromParams("refunds", map[string]interface{}{"repository": repo})
+	assert.InDelta(t, 0.65, query.MinRelevanceScore, 1e-9)
+
+	query = server.buildMemoryQueryFromParams("refunds", map[string]interface{}{"repository": repo, "min_relevance": 0.2})
+	assert.InDelta(t, 0.2, query.MinRelevanceScore, 1e-9, "an explicit threshold wins")
+
+	assert.InDelta(t, 0.5, server.minRelevance("github.com/acme/web"), 1e-9)
+}
+
+func TestApplyConfigReloadsSettings(t *testing.T) {
+	server := newLocalMemoryServer(t)
+	t.Cleanup(func() { logging.SetLevel(logging.INFO) })
+
+	reloaded := *server.container.CurrentConfig()
+	reloaded.Logging.Level = "debug"
+	reloaded.Search.DefaultMinRelevance = 0.42
+	reloaded.Decay.IntervalHours = 6
+	reloaded.Storage.RetentionDays = 14
+
+	assert.Empty(t, server.ApplyConfig(&reloaded))
+	assert.InDelta(t, 0.42, server.minRelevance("github.com/acme/api"), 1e-9)
+	assert.Equal(t, 6*time.Hour, server.decayInterval())
+	select {
+	case <-server.configReloaded:
+	default:
+		t.Fatal("periodic work was not told about the reload")
+	}
+
+	restart := reloaded
+	restart.Server.Port = 9999
+	restart.Storage.Provider = config.StorageProviderQdrant
+	assert.ElementsMatch(t, []string{"server.port", "storage.provider"}, server.ApplyConfig(&restart))
+}
+
+func TestRetentionCleanupPerRepository(t *testing.T) {
+	server := newLocalMemoryServer(t)
+	ctx := context.Background()
+
+	store := func(repository string, age time.Duration) string {
+		chunk := &types.ConversationChunk{
+			ID:         uuid.New().String(),
+			SessionID:  "s1",
+			Timestamp:  time.Now().Add(-age),
+			Type:       types.ChunkTypeDiscussion,
+			Content:    "Old discussion",
+			Summary:    "Old discussion",
+			Embeddings: []float64{0.1, 0.2, 0.3},
+			Metadata:   types.ChunkMetadata{Repository: repository, Outcome: types.OutcomeSuccess, Difficulty: types.DifficultySimple},
+		}
+		require.NoError(t, server.container.GetVectorStore().Store(ctx, chunk))
+		return chunk.ID
+	}
+
+	day := 24 * time.Hour
+	shortLived := store("github.com/acme/api", 40*day)
+	kept := store("github.com/acme/web", 40*day)
+	exempt := store("github.com/acme/legacy", 400*day)
+	expired := store("github.com/acme/web", 100*day)
+
+	systemOperation(t, server, OperationRepoConfigSet, map[string]interface{}{"repository": "github.com/acme/api", "retention_days": float64(30)})
+	systemOperation(t, server, OperationRepoConfigSet, map[string]interface{}{"repository": "github.com/acme/legacy", "decay": map[string]interface{}{"exempt": true}})
+
+	deleted, err := server.runRetentionCleanup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	for id, exists := range map[string]bool{shortLived: false, kept: true, exempt: true, expired: false} {
+		_, err := server.container.GetVectorStore().GetByID(ctx, id)
+		assert.Equal(t, exists, err == nil, id)
+	}
+}
@@ -0,0 +1,457 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lerian-mcp-memory/internal/logging"
+	"lerian-mcp-memory/internal/sse"
+	"lerian-mcp-memory/internal/websocket"
+	"lerian-mcp-memory/pkg/types"
+	"net/url"
+	"strings"
+	"sync"
+
+	mcp "github.com/fredcamaral/gomcp-sdk"
+	"github.com/fredcamaral/gomcp-sdk/protocol"
+)
+
+// Resource kinds, the first segment after memory://
+const (
+	ResourceRecent    = "recent"
+	ResourcePatterns  = "patterns"
+	ResourceDecisions = "decisions"
+	ResourceChunk     = "chunk"
+	ResourceThread    = "thread"
+	ResourceTasks     = "tasks"
+
+	resourceScheme      = "memory://"
+	resourceMimeType    = "application/json"
+	resourceUpdated     = "notifications/resources/updated"
+	globalInsightsURI   = resourceScheme + GlobalRepository + "/insights"
+	recentResourceLimit = 20
+)
+
+// resourceTemplate describes a family of resources in resources/templates/list
+type resourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// resourceContents is one entry of a resources/read result
+type resourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+var resourceTemplates = []resourceTemplate{
+	{
+		URITemplate: "memory://recent/{repository}",
+		Name:        "Recent Activity",
+		Description: "Recent conversation chunks for a repository",
+		MimeType:    resourceMimeType,
+	},
+	{
+		URITemplate: "memory://patterns/{repository}",
+		Name:        "Common Patterns",
+		Description: "Identified patterns in project history",
+		MimeType:    resourceMimeType,
+	},
+	{
+		URITemplate: "memory://decisions/{repository}",
+		Name:        "Architectural Decisions",
+		Description: "Key architectural decisions made",
+		MimeType:    resourceMimeType,
+	},
+	{
+		URITemplate: "memory://chunk/{id}",
+		Name:        "Memory Chunk",
+		Description: "A single conversation chunk",
+		MimeType:    resourceMimeType,
+	},
+	{
+		URITemplate: "memory://thread/{id}",
+		Name:        "Memory Thread",
+		Description: "A thread of related chunks",
+		MimeType:    resourceMimeType,
+	},
+	{
+		URITemplate: "memory://tasks/{repository}",
+		Name:        "Tasks",
+		Description: "Open todos and task chunks for a repository",
+		MimeType:    resourceMimeType,
+	},
+}
+
+// registerResources registers the fixed resources with the SDK. Templated
+// resources are served by HandleRequest, since the SDK only reads URIs that
+// were registered verbatim.
+func (ms *MemoryServer) registerResources() {
+	resource := mcp.NewResource(globalInsightsURI, "Global Insights", "Cross-project insights and patterns", resourceMimeType)
+	ms.mcpServer.AddResource(resource, mcp.ResourceHandlerFunc(ms.handleResourceRead))
+}
+
+// parseResourceURI splits memory://{kind}/{param}. The parameter is
+// everything after the kind, so repositories keep their slashes; escaped
+// forms such as github.com%2Fuser%2Frepo are accepted too.
+func parseResourceURI(uri string) (kind, param string, err error) {
+	rest, ok := strings.CutPrefix(uri, resourceScheme)
+	if !ok {
+		return "", "", fmt.Errorf("invalid resource URI: %s", uri)
+	}
+	kind, param, _ = strings.Cut(rest, "/")
+	if param, err = url.PathUnescape(param); err != nil {
+		return "", "", fmt.Errorf("invalid resource URI: %s", uri)
+	}
+
+	switch kind {
+	case ResourceRecent, ResourcePatterns, ResourceDecisions, ResourceTasks:
+		if param == "" {
+			return "", "", fmt.Errorf("repository required for %s resource", kind)
+		}
+	case ResourceChunk, ResourceThread:
+		if param == "" {
+			return "", "", fmt.Errorf("id required for %s resource", kind)
+		}
+	case GlobalRepository:
+		if param != "insights" {
+			return "", "", errors.New("invalid global resource")
+		}
+	default:
+		return "", "", fmt.Errorf("unknown resource type: %s", kind)
+	}
+	return kind, param, nil
+}
+
+// resourceURI is the canonical URI of a resource
+func resourceURI(kind, param string) string {
+	return resourceScheme + kind + "/" + param
+}
+
+// handleResourcesRead serves resources/read for every memory:// resource
+func (ms *MemoryServer) handleResourcesRead(ctx context.Context, req *protocol.JSONRPCRequest) *protocol.JSONRPCResponse {
+	params, _ := req.Params.(map[string]interface{})
+	uri, _ := params["uri"].(string)
+	if uri == "" {
+		return jsonRPCError(req, protocol.InvalidParams, "URI parameter required")
+	}
+	if _, _, err := parseResourceURI(uri); err != nil {
+		return jsonRPCError(req, protocol.InvalidParams, err.Error())
+	}
+
+	contents, err := ms.handleResourceRead(ctx, uri)
+	if err != nil {
+		return jsonRPCError(req, protocol.InternalError, err.Error())
+	}
+
+	result := make([]resourceContents, 0, len(contents))
+	for _, content := range contents {
+		result = append(result, resourceContents{URI: uri, MimeType: resourceMimeType, Text: content.Text})
+	}
+	return &protocol.JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: map[string]interface{}{"contents": result}}
+}
+
+// handleResourceTemplatesList serves resources/templates/list
+func (ms *MemoryServer) handleResourceTemplatesList(req *protocol.JSONRPCRequest) *protocol.JSONRPCResponse {
+	return &protocol.JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: map[string]interface{}{"resourceTemplates": resourceTemplates}}
+}
+
+// handleResourceRead reads a resource after checking the caller may read
+// the repository it belongs to
+func (ms *MemoryServer) handleResourceRead(ctx context.Context, uri string) ([]protocol.Content, error) {
+	kind, param, err := parseResourceURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	var result interface{}
+	switch kind {
+	case ResourceRecent, ResourcePatterns, ResourceDecisions, ResourceTasks:
+		if err := ms.authorizeRepositoryRead(ctx, "resources/read", param); err != nil {
+			return nil, err
+		}
+		result, err = ms.readRepositoryResource(ctx, kind, param)
+	case ResourceChunk:
+		result, err = ms.readChunkResource(ctx, param)
+	case ResourceThread:
+		result, err = ms.readThreadResource(ctx, param)
+	default:
+		result = map[string]interface{}{
+			"message": "Global insights feature coming soon",
+			"status":  "not_implemented",
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resource: %w", err)
+	}
+	return []protocol.Content{protocol.NewContent(string(resultJSON))}, nil
+}
+
+// readRepositoryResource builds the repository-scoped resources
+func (ms *MemoryServer) readRepositoryResource(ctx context.Context, kind, repository string) (interface{}, error) {
+	switch kind {
+	case ResourceRecent:
+		return ms.container.GetVectorStore().ListByRepository(ctx, repository, recentResourceLimit, 0)
+	case ResourcePatterns:
+		chunks, err := ms.container.GetVectorStore().ListByRepository(ctx, repository, 100, 0)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"repository": repository,
+			"patterns":   ms.analyzePatterns(chunks),
+		}, nil
+	case ResourceDecisions:
+		return ms.readDecisionsResource(ctx, repository)
+	default:
+		return ms.readTasksResource(ctx, repository)
+	}
+}
+
+// readDecisionsResource searches the repository's architecture decisions
+func (ms *MemoryServer) readDecisionsResource(ctx context.Context, repository string) (interface{}, error) {
+	memQuery := types.NewMemoryQuery("architectural decision")
+	memQuery.Repository = &repository
+	memQuery.Types = []types.ChunkType{types.ChunkTypeArchitectureDecision}
+	memQuery.Limit = 50
+
+	embeddings, err := ms.container.GetEmbeddingService().GenerateEmbedding(ctx, "architectural decision")
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := ms.container.GetVectorStore().Search(ctx, memQuery, embeddings)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"repository": repository,
+		"decisions":  ms.formatDecisionResults(results.Results),
+	}, nil
+}
+
+// readTasksResource combines the repository's open todos with its task chunks
+func (ms *MemoryServer) readTasksResource(ctx context.Context, repository string) (interface{}, error) {
+	todos, err := ms.handleTodoRead(ctx, map[string]interface{}{"repository": repository})
+	if err != nil {
+		return nil, err
+	}
+
+	chunks, err := ms.container.GetVectorStore().ListByRepository(ctx, repository, promptRepositoryScan, 0)
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]types.ConversationChunk, 0)
+	for i := range chunks {
+		if chunks[i].Type == types.ChunkTypeTask {
+			tasks = append(tasks, chunks[i])
+		}
+	}
+
+	return map[string]interface{}{
+		"repository": repository,
+		"todos":      todos,
+		"tasks":      ms.formatTaskList(tasks),
+	}, nil
+}
+
+// readChunkResource returns a chunk the caller may read
+func (ms *MemoryServer) readChunkResource(ctx context.Context, id string) (interface{}, error) {
+	chunk, err := ms.container.GetVectorStore().GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("chunk not found: %s", id)
+	}
+	if err := ms.authorizeRepositoryRead(ctx, "resources/read", chunk.Metadata.Repository); err != nil {
+		return nil, err
+	}
+	return chunk, nil
+}
+
+// readThreadResource returns a thread the caller may read
+func (ms *MemoryServer) readThreadResource(ctx context.Context, id string) (interface{}, error) {
+	thread, err := ms.container.GetThreadStore().GetThread(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("thread not found: %s", id)
+	}
+	if err := ms.authorizeRepositoryRead(ctx, "resources/read", thread.Repository); err != nil {
+		return nil, err
+	}
+	return thread, nil
+}
+
+// resourceSubscriptions tracks the resources each SSE session watches.
+// Subscriptions end with their session.
+type resourceSubscriptions struct {
+	mu       sync.Mutex
+	sessions map[*sse.Session]map[string]string // canonical URI -> URI as subscribed
+}
+
+func newResourceSubscriptions() *resourceSubscriptions {
+	return &resourceSubscriptions{sessions: make(map[*sse.Session]map[string]string)}
+}
+
+func (rs *resourceSubscriptions) subscribe(session *sse.Session, canonical, uri string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	uris, ok := rs.sessions[session]
+	if !ok {
+		uris = make(map[string]string)
+		rs.sessions[session] = uris
+		go func() {
+			<-session.Context().Done()
+			rs.mu.Lock()
+			delete(rs.sessions, session)
+			rs.mu.Unlock()
+		}()
+	}
+	uris[canonical] = uri
+}
+
+func (rs *resourceSubscriptions) unsubscribe(session *sse.Session, canonical string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	delete(rs.sessions[session], canonical)
+}
+
+// notify sends notifications/resources/updated to every session watching
+// one of the canonical URIs
+func (rs *resourceSubscriptions) notify(canonical []string) {
+	type update struct {
+		session *sse.Session
+		uri     string
+	}
+
+	rs.mu.Lock()
+	var updates []update
+	for session, uris := range rs.sessions {
+		for _, c := range canonical {
+			if uri, ok := uris[c]; ok {
+				updates = append(updates, update{session, uri})
+			}
+		}
+	}
+	rs.mu.Unlock()
+
+	for _, u := range updates {
+		_ = u.session.Notify(resourceUpdated, map[string]interface{}{"uri": u.uri})
+	}
+}
+
+// handleResourceSubscription serves resources/subscribe and
+// resources/unsubscribe. Updates are delivered on the session stream, so
+// the request has to arrive on an SSE or Streamable HTTP session.
+func (ms *MemoryServer) handleResourceSubscription(ctx context.Context, req *protocol.JSONRPCRequest) *protocol.JSONRPCResponse {
+	params, _ := req.Params.(map[string]interface{})
+	uri, _ := params["uri"].(string)
+	if uri == "" {
+		return jsonRPCError(req, protocol.InvalidParams, "URI parameter required")
+	}
+	kind, param, err := parseResourceURI(uri)
+	if err != nil {
+		return jsonRPCError(req, protocol.InvalidParams, err.Error())
+	}
+	session, ok := sse.SessionFromContext(ctx)
+	if !ok {
+		return jsonRPCError(req, protocol.InvalidRequest, "Resource subscriptions need a session; connect through /sse")
+	}
+
+	canonical := resourceURI(kind, param)
+	if req.Method == "resources/unsubscribe" {
+		ms.resourceSubs.unsubscribe(session, canonical)
+		return &protocol.JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: map[string]interface{}{}}
+	}
+
+	if err := ms.authorizeResourceSubscription(ctx, kind, param); err != nil {
+		return jsonRPCError(req, protocol.InvalidParams, err.Error())
+	}
+	ms.resourceSubs.subscribe(session, canonical, uri)
+	logging.Info("Resource subscribed", "uri", canonical, "session", session.ID)
+	return &protocol.JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: map[string]interface{}{}}
+}
+
+// authorizeResourceSubscription checks read access to the repository a
+// resource belongs to, looking chunks and threads up to find it
+func (ms *MemoryServer) authorizeResourceSubscription(ctx context.Context, kind, param string) error {
+	switch kind {
+	case ResourceChunk:
+		_, err := ms.readChunkResource(ctx, param)
+		return err
+	case ResourceThread:
+		_, err := ms.readThreadResource(ctx, param)
+		return err
+	case GlobalRepository:
+		return ms.authorizeRepositoryRead(ctx, "resources/subscribe", "")
+	default:
+		return ms.authorizeRepositoryRead(ctx, "resources/subscribe", param)
+	}
+}
+
+// withResourceSubscribe advertises resource subscriptions in an initialize
+// result. Update notifications go out over an SSE session, so clients
+// without one, such as stdio, are not offered them.
+func withResourceSubscribe(ctx context.Context, resp *protocol.JSONRPCResponse) *protocol.JSONRPCResponse {
+	if _, ok := sse.SessionFromContext(ctx); !ok {
+		return resp
+	}
+	result, ok := resp.Result.(protocol.InitializeResult)
+	if !ok || result.Capabilities.Resources == nil {
+		return resp
+	}
+	resources := *result.Capabilities.Resources
+	resources.Subscribe = true
+	result.Capabilities.Resources = &resources
+	resp.Result = result
+	return resp
+}
+
+// resourcesForEvent lists the canonical URIs of the resources an event
+// changes
+func resourcesForEvent(event *websocket.MemoryEvent) []string {
+	data, _ := event.Data.(map[string]interface{})
+	var uris []string
+
+	switch event.Type {
+	case websocket.EventTypeMemory:
+		if event.ChunkID != "" {
+			uris = append(uris, resourceURI(ResourceChunk, event.ChunkID))
+		}
+		if event.Repository == "" {
+			break
+		}
+		uris = append(uris,
+			resourceURI(ResourceRecent, event.Repository),
+			resourceURI(ResourcePatterns, event.Repository))
+		switch types.ChunkType(event.ChunkType) {
+		case types.ChunkTypeArchitectureDecision:
+			uris = append(uris, resourceURI(ResourceDecisions, event.Repository))
+		case types.ChunkTypeTask:
+			uris = append(uris, resourceURI(ResourceTasks, event.Repository))
+		}
+	case websocket.EventTypeRelationship:
+		for _, key := range []string{"source_chunk_id", "target_chunk_id"} {
+			if id, ok := data[key].(string); ok && id != "" {
+				uris = append(uris, resourceURI(ResourceChunk, id))
+			}
+		}
+	case websocket.EventTypeThread:
+		if id, ok := data["thread_id"].(string); ok && id != "" {
+			uris = append(uris, resourceURI(ResourceThread, id))
+		}
+	case websocket.EventTypeTask:
+		if event.Repository != "" {
+			uris = append(uris, resourceURI(ResourceTasks, event.Repository))
+		}
+	}
+	return uris
+}
@@ -0,0 +1,188 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"lerian-mcp-memory/internal/sse"
+	"lerian-mcp-memory/internal/websocket"
+	"lerian-mcp-memory/pkg/types"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fredcamaral/gomcp-sdk/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resourceRequest(method, uri string) *protocol.JSONRPCRequest {
+	return &protocol.JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: method, Params: map[string]interface{}{"uri": uri}}
+}
+
+func readResource(t *testing.T, server *MemoryServer, uri string) string {
+	t.Helper()
+	resp := server.HandleRequest(context.Background(), resourceRequest("resources/read", uri))
+	require.Nil(t, resp.Error, "reading %s", uri)
+	result, ok := resp.Result.(map[string]interface{})
+	require.True(t, ok)
+	contents, ok := result["contents"].([]resourceContents)
+	require.True(t, ok)
+	require.Len(t, contents, 1)
+	assert.Equal(t, uri, contents[0].URI)
+	assert.Equal(t, "application/json", contents[0].MimeType)
+	return contents[0].Text
+}
+
+func TestParseResourceURI(t *testing.T) {
+	kind, param, err := parseResourceURI("memory://recent/github.com/acme/api")
+	require.NoError(t, err)
+	assert.Equal(t, ResourceRecent, kind)
+	assert.Equal(t, "github.com/acme/api", param)
+
+	_, param, err = parseResourceURI("memory://tasks/github.com%2Facme%2Fapi")
+	require.NoError(t, err)
+	assert.Equal(t, "github.com/acme/api", param)
+
+	for _, uri := range []string{"memory://recent/", "memory://chunk", "memory://unknown/x", "memory://global/other", "file://x"} {
+		_, _, err := parseResourceURI(uri)
+		assert.Error(t, err, uri)
+	}
+}
+
+func TestResourceTemplatesAndReads(t *testing.T) {
+	server := newLocalMemoryServer(t)
+	chunk := storePromptChunk(t, server, "s1", types.ChunkTypeTask, "Add refund endpoint", types.OutcomeInProgress, time.Now())
+
+	resp := server.HandleRequest(context.Background(), &protocol.JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "resources/templates/list"})
+	require.Nil(t, resp.Error)
+	data, err := json.Marshal(resp.Result)
+	require.NoError(t, err)
+	for _, template := range []string{"memory://chunk/{id}", "memory://thread/{id}", "memory://tasks/{repository}", "memory://recent/{repository}"} {
+		assert.Contains(t, string(data), `"uriTemplate":"`+template+`"`)
+	}
+
+	assert.Contains(t, readResource(t, server, "memory://recent/github.com/acme/api"), chunk.ID)
+	assert.Contains(t, readResource(t, server, "memory://chunk/"+chunk.ID), "Add refund endpoint")
+	assert.Contains(t, readResource(t, server, "memory://tasks/github.com/acme/api"), "Add refund endpoint")
+
+	resp = server.HandleRequest(context.Background(), resourceRequest("resources/read", "memory://chunk/missing"))
+	require.NotNil(t, resp.Error)
+	resp = server.HandleRequest(context.Background(), resourceRequest("resources/read", "memory://bogus/x"))
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, protocol.InvalidParams, resp.Error.Code)
+}
+
+func TestInitializeAdvertisesResourceSubscriptions(t *testing.T) {
+	server := newLocalMemoryServer(t)
+	initialize := func(ctx context.Context) *protocol.ResourceCapability {
+		resp := server.HandleRequest(ctx, &protocol.JSONRPCRequest{
+			JSONRPC: "2.0", ID: 1, Method: "initialize",
+			Params: map[string]interface{}{"protocolVersion": protocol.Version, "capabilities": map[string]interface{}{}},
+		})
+		require.Nil(t, resp.Error)
+		result, ok := resp.Result.(protocol.InitializeResult)
+		require.True(t, ok)
+		require.NotNil(t, result.Capabilities.Resources)
+		return result.Capabilities.Resources
+	}
+
+	session, err := sse.NewManager(0, 0).Create("")
+	require.NoError(t, err)
+	assert.True(t, initialize(sse.WithSession(context.Background(), session)).Subscribe)
+
+	// stdio has no session to send updates on
+	assert.False(t, initialize(context.Background()).Subscribe)
+}
+
+func TestResourceSubscriptionNotifiesSession(t *testing.T) {
+	server := newLocalMemoryServer(t)
+	sessions := sse.NewManager(0, 0)
+	session, err := sessions.Create("")
+	require.NoError(t, err)
+	ctx := sse.WithSession(context.Background(), session)
+
+	// Subscriptions need a session
+	resp := server.HandleRequest(context.Background(), resourceRequest("resources/subscribe", "memory://recent/github.com/acme/api"))
+	require.NotNil(t, resp.Error)
+
+	resp = server.HandleRequest(ctx, resourceRequest("resources/subscribe", "memory://recent/github.com%2Facme%2Fapi"))
+	require.Nil(t, resp.Error)
+	resp = server.HandleRequest(ctx, resourceRequest("resources/subscribe", "memory://decisions/github.com/acme/api"))
+	require.Nil(t, resp.Error)
+	resp = server.HandleRequest(ctx, resourceRequest("resources/unsubscribe", "memory://decisions/github.com/acme/api"))
+	require.Nil(t, resp.Error)
+
+	storePromptChunk(t, server, "s1", types.ChunkTypeArchitectureDecision, "Use idempotency keys", types.OutcomeSuccess, time.Now())
+
+	// Read the notifications back from the session's replay buffer
+	stream := httptest.NewServer(sse.NewHandler(sessions, server, "/sse"))
+	t.Cleanup(stream.Close)
+	req, err := http.NewRequest(http.MethodGet, stream.URL, http.NoBody)
+	require.NoError(t, err)
+	req.Header.Set(sse.SessionHeader, session.ID)
+	httpResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = httpResp.Body.Close() })
+
+	line := make(chan string, 1)
+	go func() {
+		reader := bufio.NewReader(httpResp.Body)
+		for {
+			text, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if strings.HasPrefix(text, "data: ") {
+				line <- strings.TrimSpace(strings.TrimPrefix(text, "data: "))
+				return
+			}
+		}
+	}()
+
+	select {
+	case data := <-line:
+		var notification struct {
+			Method string `json:"method"`
+			Params struct {
+				URI string `json:"uri"`
+			} `json:"params"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(data), &notification))
+		assert.Equal(t, "notifications/resources/updated", notification.Method)
+		assert.Equal(t, "memory://recent/github.com%2Facme%2Fapi", notification.Params.URI, "sent with the URI as subscribed")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no resource update was delivered")
+	}
+
+	// Closing the session drops its subscriptions
+	sessions.Close(session.ID)
+	require.Eventually(t, func() bool {
+		server.resourceSubs.mu.Lock()
+		defer server.resourceSubs.mu.Unlock()
+		return len(server.resourceSubs.sessions) == 0
+	}, 2*time.Second, 5*time.Millisecond)
+}
+
+func TestResourcesForEvent(t *testing.T) {
+	repo := "github.com/acme/api"
+	decision := websocket.NewMemoryEvent(websocket.EventTypeMemory, websocket.ActionCreated, "c1", repo, "s1", nil)
+	decision.ChunkType = string(types.ChunkTypeArchitectureDecision)
+	assert.ElementsMatch(t, []string{
+		"memory://chunk/c1", "memory://recent/" + repo, "memory://patterns/" + repo, "memory://decisions/" + repo,
+	}, resourcesForEvent(&decision))
+
+	relationship := websocket.NewMemoryEvent(websocket.EventTypeRelationship, websocket.ActionCreated, "c1", repo, "s1", map[string]interface{}{
+		"source_chunk_id": "c1",
+		"target_chunk_id": "c2",
+	})
+	assert.ElementsMatch(t, []string{"memory://chunk/c1", "memory://chunk/c2"}, resourcesForEvent(&relationship))
+
+	thread := websocket.NewMemoryEvent(websocket.EventTypeThread, websocket.ActionUpdated, "", repo, "", map[string]interface{}{"thread_id": "t1"})
+	assert.Equal(t, []string{"memory://thread/t1"}, resourcesForEvent(&thread))
+
+	task := websocket.NewMemoryEvent(websocket.EventTypeTask, websocket.ActionUpdated, "", repo, "s1", nil)
+	assert.Equal(t, []string{"memory://tasks/" + repo}, resourcesForEvent(&task))
+}
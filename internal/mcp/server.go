@@ -14,8 +14,15 @@ import (
 	"lerian-mcp-memory/internal/di"
 	"lerian-mcp-memory/internal/intelligence"
 	"lerian-mcp-memory/internal/logging"
+	"lerian-mcp-memory/internal/metrics"
 	"lerian-mcp-memory/internal/relationships"
+	"lerian-mcp-memory/internal/security"
+	"lerian-mcp-memory/internal/sse"
+	"lerian-mcp-memory/internal/storage"
 	"lerian-mcp-memory/internal/threading"
+	"lerian-mcp-memory/internal/tracing"
+	"lerian-mcp-memory/internal/webhooks"
+	"lerian-mcp-memory/internal/websocket"
 	"lerian-mcp-memory/internal/workflow"
 	"lerian-mcp-memory/pkg/types"
 	"log"
@@ -26,12 +33,14 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	mcp "github.com/fredcamaral/gomcp-sdk"
 	"github.com/fredcamaral/gomcp-sdk/protocol"
 	"github.com/fredcamaral/gomcp-sdk/server"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // String constants for repeated values
@@ -43,6 +52,10 @@ const (
 	ValueDefault  = "default"
 )
 
+// reembedProgressInterval is how often re-embedding progress is checked for
+// SSE sessions that started the job
+const reembedProgressInterval = 2 * time.Second
+
 // MemoryServer implements the MCP server for Claude memory
 type MemoryServer struct {
 	container *di.Container
@@ -53,9 +66,21 @@ type MemoryServer struct {
 	bulkImporter *bulk.Importer
 	bulkExporter *bulk.Exporter
 	aliasManager *bulk.AliasManager
+	reembedder   *bulk.Reembedder
 
 	// Workflow tracking
 	todoTracker *workflow.TodoTracker
+
+	// Workflow prompts by name
+	prompts map[string]memoryPrompt
+
+	// Real-time change notifications; the hub is nil until set
+	events       atomic.Pointer[websocket.Hub]
+	webhooks     *webhooks.Manager
+	resourceSubs *resourceSubscriptions
+
+	// configReloaded wakes periodic work when a reload changes its settings
+	configReloaded chan struct{}
 }
 
 // NewMemoryServer creates a new memory MCP server
@@ -67,7 +92,9 @@ func NewMemoryServer(cfg *config.Config) (*MemoryServer, error) {
 	}
 
 	memServer := &MemoryServer{
-		container: container,
+		container:      container,
+		resourceSubs:   newResourceSubscriptions(),
+		configReloaded: make(chan struct{}, 1),
 	}
 
 	// Initialize bulk operations managers
@@ -76,10 +103,30 @@ func NewMemoryServer(cfg *config.Config) (*MemoryServer, error) {
 	memServer.bulkImporter = bulk.NewImporter(logger)
 	memServer.bulkExporter = bulk.NewExporter(container.GetVectorStore(), logger)
 	memServer.aliasManager = bulk.NewAliasManager(container.GetVectorStore(), logger)
+	memServer.reembedder = bulk.NewReembedder(bulk.ReembedderConfig{
+		Store:               container.GetVectorStore(),
+		CurrentEmbedding:    container.GetEmbeddingService(),
+		StateDir:            container.Config.Storage.ReembedStateDir,
+		NewEmbeddingService: container.NewEmbeddingServiceFor,
+		OnPromoted:          container.SwitchEmbeddingService,
+		EmbeddingText:       container.GetChunkingService().EmbeddingText,
+		Manager:             memServer.bulkManager,
+		Logger:              logger,
+	})
 
 	// Initialize workflow tracking
 	memServer.todoTracker = workflow.NewTodoTracker()
 
+	// Every chunk and relationship write is reported, whichever tool made it
+	memServer.webhooks = webhooks.NewManager(webhooks.Config{
+		StateDir: container.Config.Storage.WebhookStateDir,
+		Resolve:  memServer.resolveWebhookEvent,
+		Logger:   logger,
+	})
+	if observed := container.ObservedStore; observed != nil {
+		observed.Observe(memServer.publishChange)
+	}
+
 	// Create MCP server
 	serverName := getEnv("SERVICE_NAME", "claude-memory")
 	serverVersion := getEnv("SERVICE_VERSION", "VERSION_PLACEHOLDER")
@@ -87,6 +134,7 @@ func NewMemoryServer(cfg *config.Config) (*MemoryServer, error) {
 	memServer.mcpServer = mcpServer
 	memServer.registerTools()
 	memServer.registerResources()
+	memServer.registerPrompts()
 
 	return memServer, nil
 }
@@ -98,6 +146,35 @@ func (ms *MemoryServer) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to initialize vector store: %w", err)
 	}
 
+	// Restore aliases created before the last restart
+	if loaded, err := ms.aliasManager.LoadAliases(ctx); err != nil {
+		log.Printf("Warning: failed to load aliases: %v", err)
+	} else if loaded > 0 {
+		log.Printf("Loaded %d aliases", loaded)
+	}
+
+	// Continue re-embeddings interrupted by a restart. A job that had already
+	// swapped collections switches the embedding provider before the check below.
+	if resumed, err := ms.reembedder.Resume(ctx); err != nil {
+		log.Printf("Warning: failed to resume re-embedding jobs: %v", err)
+	} else if len(resumed) > 0 {
+		log.Printf("Resumed re-embedding jobs: %v", resumed)
+	}
+
+	// Refuse to run against a collection built by a different embedding provider
+	if err := ms.container.VerifyEmbeddingDimension(ctx); err != nil {
+		return err
+	}
+
+	// Finish re-encrypting chunks after a key rotation that a restart interrupted
+	ms.container.ResumeReencryption(ctx)
+
+	// Deliver webhook events queued before the last restart
+	if err := ms.webhooks.Load(); err != nil {
+		log.Printf("Warning: failed to load webhooks: %v", err)
+	}
+	go ms.webhooks.Run(ctx)
+
 	// Health check services
 	if err := ms.container.HealthCheck(ctx); err != nil {
 		log.Printf("Warning: Service health check failed: %v", err)
@@ -115,19 +192,37 @@ func (ms *MemoryServer) GetMCPServer() *server.Server {
 	return ms.mcpServer
 }
 
+// HandleRequest handles a JSON-RPC request. Prompts and resources are
+// answered here because the SDK returns prompt messages without their role,
+// only reads resources registered verbatim and has no subscriptions; every
+// other method goes to the SDK server.
+func (ms *MemoryServer) HandleRequest(ctx context.Context, req *protocol.JSONRPCRequest) *protocol.JSONRPCResponse {
+	switch req.Method {
+	case "initialize":
+		return withResourceSubscribe(ctx, ms.mcpServer.HandleRequest(ctx, req))
+	case "prompts/get":
+		return ms.handlePromptsGet(ctx, req)
+	case "resources/read":
+		return ms.handleResourcesRead(ctx, req)
+	case "resources/templates/list":
+		return ms.handleResourceTemplatesList(req)
+	case "resources/subscribe", "resources/unsubscribe":
+		return ms.handleResourceSubscription(ctx, req)
+	default:
+		return ms.mcpServer.HandleRequest(ctx, req)
+	}
+}
+
 // GetContainer returns the DI container for accessing services
 func (ms *MemoryServer) GetContainer() *di.Container {
 	return ms.container
 }
 
 // SetWebSocketHub sets the WebSocket hub for broadcasting memory updates
-func (ms *MemoryServer) SetWebSocketHub(hub interface{}) {
-	// Store hub in a type-safe way - we'll access it when needed
-	// For now, just log that it's been set
+func (ms *MemoryServer) SetWebSocketHub(hub *websocket.Hub) {
+	ms.events.Store(hub)
 	if hub != nil {
 		log.Printf("WebSocket hub configured for memory updates")
-		// In a more complete implementation, we'd store this and use it
-		// when memory operations occur to broadcast changes
 	}
 }
 
@@ -156,7 +251,7 @@ func (ms *MemoryServer) registerTools() {
 func (ms *MemoryServer) registerLegacyTools() {
 	// Register all original MCP tools with proper schemas
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_store_chunk",
 		"Store important conversation moments (bug fixes, solutions, decisions, learnings) for future reference. Automatically categorizes and links related memories.",
 		mcp.ObjectSchema("Store memory chunk parameters", map[string]interface{}{
@@ -168,10 +263,15 @@ func (ms *MemoryServer) registerLegacyTools() {
 			"tools_used":     mcp.ArraySchema("List of tools that were used", map[string]interface{}{"type": "string"}),
 			"tags":           mcp.ArraySchema("Additional tags for categorization (e.g., 'bug-fix', 'performance', 'architecture')", map[string]interface{}{"type": "string"}),
 			"client_type":    mcp.StringParam("Client type (e.g., 'claude-cli', 'chatgpt', 'vscode', 'web', 'api')", false),
+			"duplicate_policy": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{config.DedupePolicyMerge, config.DedupePolicySupersede, config.DedupePolicyReject, config.DedupePolicyAllow},
+				"description": "What to do when the content nearly duplicates a recent chunk in the repository: merge into it, supersede it, reject the new chunk, or allow both (optional, defaults to the server's dedupe.policy)",
+			},
 		}, []string{"content", "session_id"}),
 	), mcp.ToolHandlerFunc(ms.handleStoreChunk))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_search",
 		"Search past memories using natural language. Finds similar problems, solutions, and decisions. Use before solving to check if issue was encountered before.",
 		mcp.ObjectSchema("Search memory parameters", map[string]interface{}{
@@ -201,7 +301,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"query"}),
 	), mcp.ToolHandlerFunc(ms.handleSearch))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_get_context",
 		"Get project overview and recent activity. Use at session start or when switching projects to understand context, patterns, and ongoing work.",
 		mcp.ObjectSchema("Get context parameters", map[string]interface{}{
@@ -216,7 +316,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"repository"}),
 	), mcp.ToolHandlerFunc(ms.handleGetContext))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_find_similar",
 		"Find similar problems and their solutions from past experiences. Use when facing errors or complex challenges to learn from previous solutions.",
 		mcp.ObjectSchema("Find similar parameters", map[string]interface{}{
@@ -232,7 +332,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"problem"}),
 	), mcp.ToolHandlerFunc(ms.handleFindSimilar))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_store_decision",
 		"Explicitly store architectural/design decisions with rationale and alternatives. Use after making significant technical choices to preserve context.",
 		mcp.ObjectSchema("Store decision parameters", map[string]interface{}{
@@ -245,7 +345,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"decision", "rationale", "session_id"}),
 	), mcp.ToolHandlerFunc(ms.handleStoreDecision))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_get_patterns",
 		"Identify recurring patterns, common issues, and trends. Use for retrospectives, identifying refactoring needs, or understanding project challenges.",
 		mcp.ObjectSchema("Get patterns parameters", map[string]interface{}{
@@ -259,14 +359,14 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"repository"}),
 	), mcp.ToolHandlerFunc(ms.handleGetPatterns))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_health",
 		"Check the health status of the memory system",
 		mcp.ObjectSchema("Health check parameters", map[string]interface{}{}, []string{}),
 	), mcp.ToolHandlerFunc(ms.handleHealth))
 
 	// Phase 3.2: Advanced MCP Tools
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_suggest_related",
 		"Get AI-powered suggestions for related context based on current work",
 		mcp.ObjectSchema("Suggest related parameters", map[string]interface{}{
@@ -284,7 +384,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"current_context", "session_id"}),
 	), mcp.ToolHandlerFunc(ms.handleSuggestRelated))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_export_project",
 		"Export all memory data for a project in various formats",
 		mcp.ObjectSchema("Export project parameters", map[string]interface{}{
@@ -304,7 +404,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"repository", "session_id"}),
 	), mcp.ToolHandlerFunc(ms.handleExportProject))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_import_context",
 		"Import conversation context from external source",
 		mcp.ObjectSchema("Import context parameters", map[string]interface{}{
@@ -332,7 +432,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 	), mcp.ToolHandlerFunc(ms.handleImportContext))
 
 	// Quick Memory Actions - Convenience tools for common workflow queries
-	ms.mcpServer.AddTool(
+	ms.addTool(
 		mcp.NewTool("mcp__memory__memory_status",
 			"Get comprehensive status overview of memory system for a repository",
 			mcp.ObjectSchema("Memory status parameters", map[string]interface{}{
@@ -340,7 +440,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 			}, []string{"repository"}),
 		), mcp.ToolHandlerFunc(ms.handleMemoryStatus))
 
-	ms.mcpServer.AddTool(
+	ms.addTool(
 		mcp.NewTool("mcp__memory__memory_conflicts",
 			"Detect contradictory decisions or patterns across memories",
 			mcp.ObjectSchema("Memory conflicts parameters", map[string]interface{}{
@@ -349,7 +449,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 			}, []string{}),
 		), mcp.ToolHandlerFunc(ms.handleMemoryConflicts))
 
-	ms.mcpServer.AddTool(
+	ms.addTool(
 		mcp.NewTool("mcp__memory__memory_resolve_conflicts",
 			"Get detailed resolution strategies and recommendations for specific conflicts. Use after detecting conflicts to get actionable next steps.",
 			mcp.ObjectSchema("Memory conflict resolution parameters", map[string]interface{}{
@@ -374,7 +474,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 			}, []string{"conflict_ids"}),
 		), mcp.ToolHandlerFunc(ms.handleMemoryResolveConflicts))
 
-	ms.mcpServer.AddTool(
+	ms.addTool(
 		mcp.NewTool("mcp__memory__memory_continuity",
 			"Show what was left incomplete from previous sessions for resuming work",
 			mcp.ObjectSchema("Memory continuity parameters", map[string]interface{}{
@@ -385,7 +485,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		), mcp.ToolHandlerFunc(ms.handleMemoryContinuity))
 
 	// Memory Threading Tools
-	ms.mcpServer.AddTool(
+	ms.addTool(
 		mcp.NewTool("mcp__memory__memory_create_thread",
 			"Create a memory thread from related chunks to group coherent conversations",
 			mcp.ObjectSchema("Memory thread creation parameters", map[string]interface{}{
@@ -398,7 +498,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 			}, []string{"chunk_ids"}),
 		), mcp.ToolHandlerFunc(ms.handleCreateThread))
 
-	ms.mcpServer.AddTool(
+	ms.addTool(
 		mcp.NewTool("mcp__memory__memory_get_threads",
 			"Retrieve memory threads with optional filtering",
 			mcp.ObjectSchema("Memory thread retrieval parameters", map[string]interface{}{
@@ -410,7 +510,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 			}, []string{}),
 		), mcp.ToolHandlerFunc(ms.handleGetThreads))
 
-	ms.mcpServer.AddTool(
+	ms.addTool(
 		mcp.NewTool("mcp__memory__memory_detect_threads",
 			"Automatically detect and create memory threads from existing chunks",
 			mcp.ObjectSchema("Memory thread detection parameters", map[string]interface{}{
@@ -426,7 +526,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 			}, []string{"repository"}),
 		), mcp.ToolHandlerFunc(ms.handleDetectThreads))
 
-	ms.mcpServer.AddTool(
+	ms.addTool(
 		mcp.NewTool("mcp__memory__memory_update_thread",
 			"Update memory thread properties like status, title, or add/remove chunks",
 			mcp.ObjectSchema("Memory thread update parameters", map[string]interface{}{
@@ -443,7 +543,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		), mcp.ToolHandlerFunc(ms.handleUpdateThread))
 
 	// Cross-Project Pattern Detection Tools
-	ms.mcpServer.AddTool(
+	ms.addTool(
 		mcp.NewTool("mcp__memory__memory_analyze_cross_repo_patterns",
 			"Analyze patterns that appear across multiple repositories to identify shared solutions, common problems, and best practices",
 			mcp.ObjectSchema("Cross-repository pattern analysis parameters", map[string]interface{}{
@@ -467,7 +567,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 			}, []string{"session_id"}),
 		), mcp.ToolHandlerFunc(ms.handleAnalyzeCrossRepoPatterns))
 
-	ms.mcpServer.AddTool(
+	ms.addTool(
 		mcp.NewTool("mcp__memory__memory_find_similar_repositories",
 			"Find repositories with similar technology stacks, patterns, or problem domains for knowledge transfer and best practice sharing",
 			mcp.ObjectSchema("Similar repository discovery parameters", map[string]interface{}{
@@ -490,7 +590,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 			}, []string{"repository", "session_id"}),
 		), mcp.ToolHandlerFunc(ms.handleFindSimilarRepositories))
 
-	ms.mcpServer.AddTool(
+	ms.addTool(
 		mcp.NewTool("mcp__memory__memory_get_cross_repo_insights",
 			"Get comprehensive insights across all repositories including technology distribution, success rates, and common patterns",
 			mcp.ObjectSchema("Cross-repository insights parameters", map[string]interface{}{
@@ -501,7 +601,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 			}, []string{"session_id"}),
 		), mcp.ToolHandlerFunc(ms.handleGetCrossRepoInsights))
 
-	ms.mcpServer.AddTool(
+	ms.addTool(
 		mcp.NewTool("mcp__memory__memory_search_multi_repo",
 			"Search for patterns, solutions, or insights across multiple repositories with advanced filtering and ranking",
 			mcp.ObjectSchema("Multi-repository search parameters", map[string]interface{}{
@@ -538,7 +638,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		), mcp.ToolHandlerFunc(ms.handleSearchMultiRepo))
 
 	// Memory Health Dashboard Tool
-	ms.mcpServer.AddTool(
+	ms.addTool(
 		mcp.NewTool("mcp__memory__memory_health_dashboard",
 			"Get comprehensive memory system health overview including completion rates, outdated chunks, effectiveness scores, and system performance metrics",
 			mcp.ObjectSchema("Memory health dashboard parameters", map[string]interface{}{
@@ -556,7 +656,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		), mcp.ToolHandlerFunc(ms.handleMemoryHealthDashboard))
 
 	// Memory decay management tool
-	ms.mcpServer.AddTool(
+	ms.addTool(
 		mcp.NewTool("mcp__memory__memory_decay_management",
 			"Manage memory decay process with intelligent LLM-based summarization and archival",
 			mcp.ObjectSchema("Memory decay management parameters", map[string]interface{}{
@@ -604,7 +704,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 
 	// Relationship management tools
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_link",
 		"Create a relationship between two memory chunks. Use to explicitly connect related problems, solutions, decisions, or learnings.",
 		mcp.ObjectSchema("Link memory parameters", map[string]interface{}{
@@ -629,7 +729,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"source_chunk_id", "target_chunk_id", "relation_type"}),
 	), mcp.ToolHandlerFunc(ms.handleMemoryLink))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_get_relationships",
 		"Get relationships for a memory chunk. Use to understand how memories connect and find related context.",
 		mcp.ObjectSchema("Get relationships parameters", map[string]interface{}{
@@ -670,7 +770,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"chunk_id"}),
 	), mcp.ToolHandlerFunc(ms.handleGetRelationships))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_traverse_graph",
 		"Traverse the knowledge graph to discover connected memories and reasoning chains. Use to understand how decisions and solutions relate.",
 		mcp.ObjectSchema("Graph traversal parameters", map[string]interface{}{
@@ -693,7 +793,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"start_chunk_id"}),
 	), mcp.ToolHandlerFunc(ms.handleTraverseGraph))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_auto_detect_relationships",
 		"Automatically detect relationships for a memory chunk based on content, timing, and patterns. Use after storing important memories.",
 		mcp.ObjectSchema("Auto-detect relationships parameters", map[string]interface{}{
@@ -718,7 +818,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"chunk_id", "session_id"}),
 	), mcp.ToolHandlerFunc(ms.handleAutoDetectRelationships))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_update_relationship",
 		"Update the confidence score and metadata of an existing relationship. Use when you learn more about how memories relate.",
 		mcp.ObjectSchema("Update relationship parameters", map[string]interface{}{
@@ -739,7 +839,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"relationship_id"}),
 	), mcp.ToolHandlerFunc(ms.handleUpdateRelationship))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_search_explained",
 		"Search memories with detailed explanations of relevance, ranking factors, and citations. Use when you need to understand why results were returned.",
 		mcp.ObjectSchema("Explained search parameters", map[string]interface{}{
@@ -778,7 +878,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"query"}),
 	), mcp.ToolHandlerFunc(ms.handleSearchExplained))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_check_freshness",
 		"Check the freshness and staleness of memories. Use to identify outdated content that needs refreshing or archiving.",
 		mcp.ObjectSchema("Freshness check parameters", map[string]interface{}{
@@ -798,7 +898,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"repository"}),
 	), mcp.ToolHandlerFunc(ms.handleCheckFreshness))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_mark_refreshed",
 		"Mark a memory as recently refreshed/validated. Use after updating or verifying that content is still current.",
 		mcp.ObjectSchema("Mark refreshed parameters", map[string]interface{}{
@@ -813,7 +913,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 	), mcp.ToolHandlerFunc(ms.handleMarkRefreshed))
 
 	// Citation management tools
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_generate_citations",
 		"Generate formatted citations for search results or specific memory chunks. Use when you need to provide proper attribution for information used in responses.",
 		mcp.ObjectSchema("Generate citations parameters", map[string]interface{}{
@@ -842,7 +942,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"query", "chunk_ids"}),
 	), mcp.ToolHandlerFunc(ms.handleGenerateCitations))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_create_inline_citation",
 		"Create inline citation references for specific text portions. Use to add citation markers within AI responses.",
 		mcp.ObjectSchema("Create inline citation parameters", map[string]interface{}{
@@ -858,7 +958,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 	), mcp.ToolHandlerFunc(ms.handleCreateInlineCitation))
 
 	// Bulk operations tools
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_bulk_operation",
 		"Execute bulk operations on multiple memories efficiently with progress tracking and error handling.",
 		mcp.ObjectSchema("Bulk operation parameters", map[string]interface{}{
@@ -911,14 +1011,14 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"operation"}),
 	), mcp.ToolHandlerFunc(ms.handleBulkOperation))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_bulk_import",
-		"Import memories from various formats (JSON, markdown, CSV) with flexible chunking strategies.",
+		"Import memories from various formats (JSON, markdown, CSV, or Claude Code, ChatGPT, Cursor/VS Code and OpenAI chat transcripts) with flexible chunking strategies.",
 		mcp.ObjectSchema("Bulk import parameters", map[string]interface{}{
 			"data": mcp.StringParam("Data to import (content or base64 encoded)", true),
 			"format": map[string]interface{}{
 				"type":        "string",
-				"enum":        []string{"json", "markdown", "csv", "archive", "auto"},
+				"enum":        []string{"json", "markdown", "csv", "archive", "claude_code", "chatgpt", "cursor", "openai_chat", "auto"},
 				"description": "Format of the import data",
 				"default":     "auto",
 			},
@@ -952,7 +1052,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"data"}),
 	), mcp.ToolHandlerFunc(ms.handleBulkImport))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_bulk_export",
 		"Export memories to various formats with filtering, compression, and formatting options.",
 		mcp.ObjectSchema("Bulk export parameters", map[string]interface{}{
@@ -1029,7 +1129,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{}),
 	), mcp.ToolHandlerFunc(ms.handleBulkExport))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_create_alias",
 		"Create memory aliases for flexible referencing using tags, shortcuts, queries, or collections.",
 		mcp.ObjectSchema("Create alias parameters", map[string]interface{}{
@@ -1087,7 +1187,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"name", "type", "target"}),
 	), mcp.ToolHandlerFunc(ms.handleCreateAlias))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_resolve_alias",
 		"Resolve an alias reference to get the matching memory chunks.",
 		mcp.ObjectSchema("Resolve alias parameters", map[string]interface{}{
@@ -1095,7 +1195,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"alias_name"}),
 	), mcp.ToolHandlerFunc(ms.handleResolveAlias))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_list_aliases",
 		"List memory aliases with optional filtering and sorting.",
 		mcp.ObjectSchema("List aliases parameters", map[string]interface{}{
@@ -1123,7 +1223,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{}),
 	), mcp.ToolHandlerFunc(ms.handleListAliases))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_get_bulk_progress",
 		"Get the progress status of a bulk operation.",
 		mcp.ObjectSchema("Get bulk progress parameters", map[string]interface{}{
@@ -1132,7 +1232,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 	), mcp.ToolHandlerFunc(ms.handleGetBulkProgress))
 
 	// Task-oriented Memory Tools
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_create_task",
 		"Create task-oriented memory chunks for tracking work items, TODOs, and project tasks separately from general memories.",
 		mcp.ObjectSchema("Create task parameters", map[string]interface{}{
@@ -1164,7 +1264,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"title", "description", "session_id"}),
 	), mcp.ToolHandlerFunc(ms.handleCreateTask))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_get_task_status",
 		"Retrieve task status, progress, and details for specific tasks or filtered task lists.",
 		mcp.ObjectSchema("Get task status parameters", map[string]interface{}{
@@ -1194,7 +1294,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{}),
 	), mcp.ToolHandlerFunc(ms.handleGetTaskStatus))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_update_task",
 		"Update task status, progress, or other task properties. Use for marking progress, changing status, or updating details.",
 		mcp.ObjectSchema("Update task parameters", map[string]interface{}{
@@ -1231,7 +1331,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{"task_id", "session_id"}),
 	), mcp.ToolHandlerFunc(ms.handleUpdateTask))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_list_tasks",
 		"List and filter tasks with various criteria. Useful for dashboards, reports, and task management views.",
 		mcp.ObjectSchema("List tasks parameters", map[string]interface{}{
@@ -1272,7 +1372,7 @@ func (ms *MemoryServer) registerLegacyTools() {
 		}, []string{}),
 	), mcp.ToolHandlerFunc(ms.handleListTasks))
 
-	ms.mcpServer.AddTool(mcp.NewTool(
+	ms.addTool(mcp.NewTool(
 		"mcp__memory__memory_complete_task",
 		"Mark a task as completed with outcome summary and automatically update related dependencies.",
 		mcp.ObjectSchema("Complete task parameters", map[string]interface{}{
@@ -1298,48 +1398,6 @@ func (ms *MemoryServer) registerLegacyTools() {
 	), mcp.ToolHandlerFunc(ms.handleCompleteTask))
 }
 
-// registerResources registers MCP resources for browsing memory
-func (ms *MemoryServer) registerResources() {
-	// Register MCP resources for browsing memory data
-
-	resources := []struct {
-		uri         string
-		name        string
-		description string
-		mimeType    string
-	}{
-		{
-			uri:         "memory://recent/{repository}",
-			name:        "Recent Activity",
-			description: "Recent conversation chunks for a repository",
-			mimeType:    "application/json",
-		},
-		{
-			uri:         "memory://patterns/{repository}",
-			name:        "Common Patterns",
-			description: "Identified patterns in project history",
-			mimeType:    "application/json",
-		},
-		{
-			uri:         "memory://decisions/{repository}",
-			name:        "Architectural Decisions",
-			description: "Key architectural decisions made",
-			mimeType:    "application/json",
-		},
-		{
-			uri:         "memory://global/insights",
-			name:        "Global Insights",
-			description: "Cross-project insights and patterns",
-			mimeType:    "application/json",
-		},
-	}
-
-	for _, res := range resources {
-		resource := mcp.NewResource(res.uri, res.name, res.description, res.mimeType)
-		ms.mcpServer.AddResource(resource, mcp.ResourceHandlerFunc(ms.handleResourceRead))
-	}
-}
-
 // Tool handlers
 
 // validateStoreChunkParams validates required parameters for storing a chunk
@@ -1451,6 +1509,19 @@ func (ms *MemoryServer) handleStoreChunk(ctx context.Context, params map[string]
 
 	// Build metadata from parameters
 	metadata := ms.buildMetadataFromParams(params)
+	if err := ms.checkRepositoryEnabled(metadata.Repository); err != nil {
+		return nil, err
+	}
+	policy, err := ms.duplicatePolicy(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scrub secrets and personal data before anything is embedded
+	content, redactions, err := ms.redactContent(ctx, metadata.Repository, content, "memory_store_chunk")
+	if err != nil {
+		return nil, err
+	}
 
 	// Create repository-scoped session ID for multi-tenant isolation
 	repositoryScopedSessionID := ms.createRepositoryScopedSessionID(metadata.Repository, sessionID)
@@ -1469,6 +1540,16 @@ func (ms *MemoryServer) handleStoreChunk(ctx context.Context, params map[string]
 		return nil, fmt.Errorf("failed to create chunk: %w", err)
 	}
 	logging.Info("Chunk created successfully", "chunk_id", chunk.ID, "type", chunk.Type)
+	markRedacted(chunk, redactions)
+
+	// Near-duplicates of recent chunks are merged, rejected or superseded
+	duplicate := ms.findDuplicate(ctx, chunk, policy)
+	if duplicate != nil {
+		if policy != config.DedupePolicySupersede {
+			return ms.resolveDuplicate(ctx, chunk, duplicate, policy, redactions)
+		}
+		markSupersedes(chunk, duplicate)
+	}
 
 	// Process parent-child relationship if specified
 	ms.processParentChildRelationship(ctx, chunk, &metadata)
@@ -1486,17 +1567,29 @@ func (ms *MemoryServer) handleStoreChunk(ctx context.Context, params map[string]
 
 	// Log successful audit event
 	ms.logStoreChunkAudit(ctx, chunk, sessionID, startTime, nil)
+	ms.auditRedactions(ctx, metadata.Repository, chunk.ID, "memory_store_chunk", redactions, false)
+	if duplicate != nil {
+		ms.linkSuperseded(ctx, chunk, duplicate)
+	}
 
 	// Auto-detect relationships with recent chunks
 	ms.autoDetectRelationships(ctx, chunk)
 
 	logging.Info("memory_store_chunk completed successfully", "chunk_id", chunk.ID, "session_id", sessionID)
-	return map[string]interface{}{
+	response := map[string]interface{}{
+		"stored":    true,
 		"chunk_id":  chunk.ID,
 		"type":      string(chunk.Type),
 		"summary":   chunk.Summary,
 		"stored_at": chunk.Timestamp.Format(time.RFC3339),
-	}, nil
+	}
+	if len(redactions) > 0 {
+		response["redactions"] = redactions
+	}
+	if duplicate != nil {
+		response["duplicate"] = duplicateReport(duplicate, policy)
+	}
+	return response, nil
 }
 
 // validateSearchParams validates required parameters for search
@@ -1527,6 +1620,10 @@ func (ms *MemoryServer) buildMemoryQueryFromParams(query string, params map[stri
 
 	if minRel, ok := params["min_relevance"].(float64); ok {
 		memQuery.MinRelevanceScore = minRel
+	} else if memQuery.Repository != nil {
+		if repoConfig := ms.repoConfig(*memQuery.Repository); repoConfig.SimilarityThreshold > 0 {
+			memQuery.MinRelevanceScore = repoConfig.SimilarityThreshold
+		}
 	}
 
 	if chunkTypes, ok := params["types"].([]interface{}); ok {
@@ -1687,7 +1784,9 @@ func (ms *MemoryServer) handleSearch(ctx context.Context, params map[string]inte
 // executeProgressiveSearch implements a fallback strategy for searches
 // Tries progressively looser search criteria if initial search returns no results
 func (ms *MemoryServer) executeProgressiveSearch(ctx context.Context, query *types.MemoryQuery, embeddings []float64) (*types.SearchResults, error) {
-	searchConfig := ms.container.Config.Search
+	searchConfig := ms.container.CurrentConfig().Search
+	ctx, span := tracing.Start(ctx, "progressive_search")
+	defer span.End()
 
 	// If progressive search is disabled, just do a single search
 	if !searchConfig.EnableProgressiveSearch {
@@ -1695,8 +1794,8 @@ func (ms *MemoryServer) executeProgressiveSearch(ctx context.Context, query *typ
 	}
 
 	// Step 1: Try original query (strict search)
-	logging.Info("Progressive search: Step 1 - Strict search", "repo", query.Repository, "min_relevance", query.MinRelevanceScore)
-	results, err := ms.container.GetVectorStore().Search(ctx, query, embeddings)
+	logging.InfoContext(ctx, "Progressive search: Step 1 - Strict search", "repo", query.Repository, "min_relevance", query.MinRelevanceScore)
+	results, err := ms.searchStage(ctx, "strict", query, embeddings)
 	if err != nil {
 		return nil, err
 	}
@@ -1709,8 +1808,8 @@ func (ms *MemoryServer) executeProgressiveSearch(ctx context.Context, query *typ
 	// Step 2: Relax relevance score (loose search)
 	relaxedQuery := *query // Copy the query
 	relaxedQuery.MinRelevanceScore = searchConfig.RelaxedMinRelevance
-	logging.Info("Progressive search: Step 2 - Relaxed relevance", "min_relevance", relaxedQuery.MinRelevanceScore)
-	results, err = ms.container.GetVectorStore().Search(ctx, &relaxedQuery, embeddings)
+	logging.InfoContext(ctx, "Progressive search: Step 2 - Relaxed relevance", "min_relevance", relaxedQuery.MinRelevanceScore)
+	results, err = ms.searchStage(ctx, "relaxed", &relaxedQuery, embeddings)
 	if err != nil {
 		return nil, err
 	}
@@ -1722,7 +1821,9 @@ func (ms *MemoryServer) executeProgressiveSearch(ctx context.Context, query *typ
 
 	// Step 3: Try related repositories if original repo specified
 	if query.Repository != nil && searchConfig.EnableRepositoryFallback {
-		results, err := ms.searchRelatedRepositories(ctx, &relaxedQuery, embeddings, *query.Repository, searchConfig)
+		relatedCtx, relatedSpan := tracing.Start(ctx, "progressive_search.related_repositories")
+		results, err := ms.searchRelatedRepositories(relatedCtx, &relaxedQuery, embeddings, *query.Repository, searchConfig)
+		tracing.End(relatedSpan, err)
 		if err == nil && len(results.Results) > 0 {
 			return results, nil
 		}
@@ -1730,8 +1831,8 @@ func (ms *MemoryServer) executeProgressiveSearch(ctx context.Context, query *typ
 		// Step 3b: Complete repository fallback (remove filter)
 		repoFallbackQuery := relaxedQuery
 		repoFallbackQuery.Repository = nil
-		logging.Info("Progressive search: Step 3b - Complete repository fallback", "original_repo", *query.Repository)
-		results, err = ms.container.GetVectorStore().Search(ctx, &repoFallbackQuery, embeddings)
+		logging.InfoContext(ctx, "Progressive search: Step 3b - Complete repository fallback", "original_repo", *query.Repository)
+		results, err = ms.searchStage(ctx, "repository_fallback", &repoFallbackQuery, embeddings)
 		if err != nil {
 			return nil, err
 		}
@@ -1747,8 +1848,8 @@ func (ms *MemoryServer) executeProgressiveSearch(ctx context.Context, query *typ
 	broadQuery.MinRelevanceScore = searchConfig.BroadestMinRelevance
 	broadQuery.Repository = nil
 	broadQuery.Types = nil
-	logging.Info("Progressive search: Step 4 - Broadest search", "min_relevance", broadQuery.MinRelevanceScore)
-	results, err = ms.container.GetVectorStore().Search(ctx, &broadQuery, embeddings)
+	logging.InfoContext(ctx, "Progressive search: Step 4 - Broadest search", "min_relevance", broadQuery.MinRelevanceScore)
+	results, err = ms.searchStage(ctx, "broadest", &broadQuery, embeddings)
 	if err != nil {
 		return nil, err
 	}
@@ -1757,6 +1858,18 @@ func (ms *MemoryServer) executeProgressiveSearch(ctx context.Context, query *typ
 	return results, nil
 }
 
+// searchStage runs one step of progressive search in its own span
+func (ms *MemoryServer) searchStage(ctx context.Context, stage string, query *types.MemoryQuery, embeddings []float64) (results *types.SearchResults, err error) {
+	ctx, span := tracing.Start(ctx, "progressive_search."+stage, attribute.Float64("search.min_relevance", query.MinRelevanceScore))
+	defer func() {
+		if results != nil {
+			span.SetAttributes(attribute.Int("search.results", len(results.Results)))
+		}
+		tracing.End(span, err)
+	}()
+	return ms.container.GetVectorStore().Search(ctx, query, embeddings)
+}
+
 // generateRelatedRepositories creates variations of a repository name for fallback searches
 // Examples: "libs/commons-go" -> ["commons-go", "libs/commons", "commons", "go"]
 func (ms *MemoryServer) generateRelatedRepositories(originalRepo string) []string {
@@ -2249,21 +2362,39 @@ func (ms *MemoryServer) handleStoreDecision(ctx context.Context, params map[stri
 		return nil, err
 	}
 
+	repository := decisionRepository(params)
+	if err := ms.checkRepositoryEnabled(repository); err != nil {
+		return nil, err
+	}
+
+	// Scrub secrets and personal data before anything is embedded; the
+	// decision and rationale are also kept in metadata
+	decisionContext, _ := params["context"].(string)
+	redactions, err := ms.redactStrings(ctx, repository, "memory_store_decision", &decision, &rationale, &decisionContext)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build decision content and metadata
-	content := ms.buildDecisionContent(decision, rationale, params)
+	content := ms.buildDecisionContent(decision, rationale, decisionContext)
 	metadata := ms.buildDecisionMetadata(params, decision, rationale)
 
 	// Create and store decision chunk
-	chunk, err := ms.createDecisionChunk(ctx, sessionID, content, &metadata)
+	chunk, err := ms.createDecisionChunk(ctx, sessionID, content, &metadata, redactions)
 	if err != nil {
 		return nil, err
 	}
+	ms.auditRedactions(ctx, repository, chunk.ID, "memory_store_decision", redactions, false)
 
-	return map[string]interface{}{
+	response := map[string]interface{}{
 		"chunk_id":  chunk.ID,
 		"decision":  decision,
 		"stored_at": chunk.Timestamp.Format(time.RFC3339),
-	}, nil
+	}
+	if len(redactions) > 0 {
+		response["redactions"] = redactions
+	}
+	return response, nil
 }
 
 // validateStoreDecisionParams validates required parameters for storing decisions
@@ -2287,16 +2418,24 @@ func (ms *MemoryServer) validateStoreDecisionParams(params map[string]interface{
 }
 
 // buildDecisionContent creates formatted decision content
-func (ms *MemoryServer) buildDecisionContent(decision, rationale string, params map[string]interface{}) string {
+func (ms *MemoryServer) buildDecisionContent(decision, rationale, decisionContext string) string {
 	content := "ARCHITECTURAL DECISION: " + decision + "\n\nRATIONALE: " + rationale
 
-	if decisionContext, ok := params["context"].(string); ok && decisionContext != "" {
+	if decisionContext != "" {
 		content += "\n\nCONTEXT: " + decisionContext
 	}
 
 	return content
 }
 
+// decisionRepository returns the repository a decision is stored in
+func decisionRepository(params map[string]interface{}) string {
+	if repo, ok := params["repository"].(string); ok {
+		return normalizeRepository(repo)
+	}
+	return GlobalMemoryRepository
+}
+
 // buildDecisionMetadata creates metadata for decision chunks
 func (ms *MemoryServer) buildDecisionMetadata(params map[string]interface{}, decision, rationale string) types.ChunkMetadata {
 	metadata := types.ChunkMetadata{
@@ -2306,11 +2445,7 @@ func (ms *MemoryServer) buildDecisionMetadata(params map[string]interface{}, dec
 	}
 
 	// Set repository
-	if repo, ok := params["repository"].(string); ok {
-		metadata.Repository = normalizeRepository(repo)
-	} else {
-		metadata.Repository = GlobalMemoryRepository
-	}
+	metadata.Repository = decisionRepository(params)
 
 	// Add extended metadata
 	ms.addDecisionExtendedMetadata(&metadata, params, decision, rationale)
@@ -2352,7 +2487,7 @@ func (ms *MemoryServer) addDecisionExtendedMetadata(metadata *types.ChunkMetadat
 }
 
 // createDecisionChunk creates and stores the decision chunk
-func (ms *MemoryServer) createDecisionChunk(ctx context.Context, sessionID, content string, metadata *types.ChunkMetadata) (*types.ConversationChunk, error) {
+func (ms *MemoryServer) createDecisionChunk(ctx context.Context, sessionID, content string, metadata *types.ChunkMetadata, redactions []security.Redaction) (*types.ConversationChunk, error) {
 	// Create repository-scoped session ID
 	repositoryScopedSessionID := ms.createRepositoryScopedSessionID(metadata.Repository, sessionID)
 	logging.Info("Created repository-scoped session for decision", "original_session", sessionID, "scoped_session", repositoryScopedSessionID, "repository", metadata.Repository)
@@ -2365,6 +2500,7 @@ func (ms *MemoryServer) createDecisionChunk(ctx context.Context, sessionID, cont
 
 	// Override type to architecture decision
 	chunk.Type = types.ChunkTypeArchitectureDecision
+	markRedacted(chunk, redactions)
 
 	// Store chunk
 	if err := ms.container.GetVectorStore().Store(ctx, chunk); err != nil {
@@ -2462,111 +2598,6 @@ func (ms *MemoryServer) handleHealth(ctx context.Context, _ map[string]interface
 	return health, nil
 }
 
-// Resource handler
-
-func (ms *MemoryServer) handleResourceRead(ctx context.Context, uri string) ([]protocol.Content, error) {
-	parts := strings.Split(uri, "/")
-	if len(parts) < 3 {
-		return nil, fmt.Errorf("invalid resource URI: %s", uri)
-	}
-
-	resourceType := parts[2]
-
-	switch resourceType {
-	case "recent":
-		return ms.handleRecentResource(ctx, parts)
-	case "patterns":
-		return ms.handlePatternsResource(ctx, parts)
-	case "decisions":
-		return ms.handleDecisionsResource(ctx, parts)
-	case GlobalRepository:
-		return ms.handleGlobalResource(parts)
-	default:
-		return nil, fmt.Errorf("unknown resource type: %s", resourceType)
-	}
-}
-
-// handleRecentResource handles recent chunks resource requests
-func (ms *MemoryServer) handleRecentResource(ctx context.Context, parts []string) ([]protocol.Content, error) {
-	if len(parts) < 4 {
-		return nil, errors.New("repository required for recent resource")
-	}
-	repository := parts[3]
-	chunks, err := ms.container.GetVectorStore().ListByRepository(ctx, repository, 20, 0)
-	if err != nil {
-		return nil, err
-	}
-	chunksJSON, _ := json.Marshal(chunks)
-	return []protocol.Content{protocol.NewContent(string(chunksJSON))}, nil
-}
-
-// handlePatternsResource handles patterns resource requests
-func (ms *MemoryServer) handlePatternsResource(ctx context.Context, parts []string) ([]protocol.Content, error) {
-	if len(parts) < 4 {
-		return nil, errors.New("repository required for patterns resource")
-	}
-	repository := parts[3]
-	chunks, err := ms.container.GetVectorStore().ListByRepository(ctx, repository, 100, 0)
-	if err != nil {
-		return nil, err
-	}
-	patterns := ms.analyzePatterns(chunks)
-	result := map[string]interface{}{
-		"repository": repository,
-		"patterns":   patterns,
-	}
-	resultJSON, _ := json.Marshal(result)
-	return []protocol.Content{protocol.NewContent(string(resultJSON))}, nil
-}
-
-// handleDecisionsResource handles architecture decisions resource requests
-func (ms *MemoryServer) handleDecisionsResource(ctx context.Context, parts []string) ([]protocol.Content, error) {
-	if len(parts) < 4 {
-		return nil, errors.New("repository required for decisions resource")
-	}
-	repository := parts[3]
-
-	// Search for architecture decisions
-	memQuery := types.NewMemoryQuery("architectural decision")
-	memQuery.Repository = &repository
-	memQuery.Types = []types.ChunkType{types.ChunkTypeArchitectureDecision}
-	memQuery.Limit = 50
-
-	embeddings, err := ms.container.GetEmbeddingService().GenerateEmbedding(ctx, "architectural decision")
-	if err != nil {
-		return nil, err
-	}
-
-	results, err := ms.container.GetVectorStore().Search(ctx, memQuery, embeddings)
-	if err != nil {
-		return nil, err
-	}
-
-	decisions := ms.formatDecisionResults(results.Results)
-	result := map[string]interface{}{
-		"repository": repository,
-		"decisions":  decisions,
-	}
-	resultJSON, _ := json.Marshal(result)
-	return []protocol.Content{protocol.NewContent(string(resultJSON))}, nil
-}
-
-// handleGlobalResource handles global insights resource requests
-func (ms *MemoryServer) handleGlobalResource(parts []string) ([]protocol.Content, error) {
-	if len(parts) < 4 || parts[3] != "insights" {
-		return nil, errors.New("invalid global resource")
-	}
-
-	// Get global insights across all repositories
-	// This is a simplified implementation
-	result := map[string]interface{}{
-		"message": "Global insights feature coming soon",
-		"status":  "not_implemented",
-	}
-	resultJSON, _ := json.Marshal(result)
-	return []protocol.Content{protocol.NewContent(string(resultJSON))}, nil
-}
-
 // formatDecisionResults formats decision search results
 func (ms *MemoryServer) formatDecisionResults(results []types.SearchResult) []map[string]interface{} {
 	decisions := make([]map[string]interface{}, 0, len(results))
@@ -3637,6 +3668,17 @@ func (ms *MemoryServer) handleImportContext(ctx context.Context, params map[stri
 		return nil, err
 	}
 
+	// Scrub text before it is chunked and summarized. Archives hold JSON and
+	// are scrubbed chunk by chunk when stored.
+	var redactions []security.Redaction
+	if importParams.source != "archive" {
+		redactions, err = ms.redactStrings(ctx, importParams.repository, "memory_import_context", &importParams.data)
+		if err != nil {
+			return nil, err
+		}
+		ms.auditRedactions(ctx, importParams.repository, "", "memory_import_context", redactions, false)
+	}
+
 	importedChunks, err := ms.importChunksBySource(ctx, importParams)
 	if err != nil {
 		return nil, err
@@ -3644,7 +3686,11 @@ func (ms *MemoryServer) handleImportContext(ctx context.Context, params map[stri
 
 	storedCount := ms.storeImportedChunks(ctx, importedChunks, importParams)
 
-	return ms.buildImportResponse(importParams, importedChunks, storedCount), nil
+	response := ms.buildImportResponse(importParams, importedChunks, storedCount)
+	if len(redactions) > 0 {
+		response["redactions"] = redactions
+	}
+	return response, nil
 }
 
 // importParams holds parsed import parameters
@@ -3723,10 +3769,16 @@ func (ms *MemoryServer) storeImportedChunks(ctx context.Context, chunks []types.
 		chunk := &chunks[i]
 		chunk.SessionID = repositoryScopedSessionID
 
+		redactions, err := ms.redactChunk(ctx, chunk, "memory_import_context")
+		if err != nil {
+			log.Printf("Skipping chunk %s: %v", chunk.ID, err)
+			continue
+		}
 		if err := ms.processAndStoreChunk(ctx, chunk); err != nil {
 			log.Printf("Failed to process chunk %s: %v", chunk.ID, err)
 			continue
 		}
+		ms.auditRedactions(ctx, chunk.Metadata.Repository, chunk.ID, "memory_import_context", redactions, false)
 
 		storedCount++
 	}
@@ -3876,6 +3928,9 @@ func (ms *MemoryServer) GetServer() interface{} {
 
 // Close closes all connections
 func (ms *MemoryServer) Close() error {
+	if err := ms.webhooks.Flush(); err != nil {
+		log.Printf("Warning: failed to save webhook state: %v", err)
+	}
 	return ms.container.Shutdown()
 }
 
@@ -3972,6 +4027,14 @@ func (ms *MemoryServer) handleMemoryLink(ctx context.Context, params map[string]
 		confidence = c
 	}
 
+	// The call was authorized for the repository it names; the chunks may
+	// belong to others
+	for _, chunkID := range []string{sourceChunkID, targetChunkID} {
+		if _, err := ms.authorizeChunk(ctx, "memory_link", chunkID, security.AccessLevelWrite); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get storage from container
 	storage := ms.container.VectorStore
 
@@ -4008,6 +4071,9 @@ func (ms *MemoryServer) handleGetRelationships(ctx context.Context, params map[s
 	if err != nil {
 		return nil, err
 	}
+	if _, err := ms.authorizeChunk(ctx, "get_relationships", chunkID, security.AccessLevelRead); err != nil {
+		return nil, err
+	}
 
 	query := ms.buildRelationshipQuery(params, chunkID)
 	rels, err := ms.container.VectorStore.GetRelationships(ctx, query)
@@ -4147,6 +4213,9 @@ func (ms *MemoryServer) handleTraverseGraph(ctx context.Context, params map[stri
 	if !ok || startChunkID == "" {
 		return nil, errors.New("start_chunk_id is required")
 	}
+	if _, err := ms.authorizeChunk(ctx, "traverse_graph", startChunkID, security.AccessLevelRead); err != nil {
+		return nil, err
+	}
 
 	maxDepth := 3 // default
 	if md, ok := params["max_depth"].(float64); ok {
@@ -4242,6 +4311,13 @@ func (ms *MemoryServer) handleAutoDetectRelationships(ctx context.Context, param
 	if err != nil {
 		return nil, err
 	}
+	level := security.AccessLevelWrite
+	if autoStore, ok := params["auto_store"].(bool); ok && !autoStore {
+		level = security.AccessLevelRead
+	}
+	if _, err := ms.authorizeChunk(ctx, "auto_detect_relationships", chunkID, level); err != nil {
+		return nil, err
+	}
 
 	// Build detection configuration
 	detectionConfig := ms.buildRelationshipDetectionConfig(params)
@@ -4397,6 +4473,16 @@ func (ms *MemoryServer) handleUpdateRelationship(ctx context.Context, params map
 	// Get storage from container
 	storage := ms.container.VectorStore
 
+	current, err := storage.GetRelationshipByID(ctx, relationshipID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get relationship: %w", err)
+	}
+	for _, chunkID := range []string{current.SourceChunkID, current.TargetChunkID} {
+		if _, err := ms.authorizeChunk(ctx, "update_relationship", chunkID, security.AccessLevelWrite); err != nil {
+			return nil, err
+		}
+	}
+
 	// Build confidence factors
 	factors := types.ConfidenceFactors{}
 
@@ -4404,24 +4490,14 @@ func (ms *MemoryServer) handleUpdateRelationship(ctx context.Context, params map
 		factors.UserCertainty = &userCertainty
 	}
 
-	var newConfidence float64
-	var hasConfidence bool
+	// Keep the current confidence unless a new one is provided
+	newConfidence := current.Confidence
 	if confidence, ok := params["confidence"].(float64); ok {
 		newConfidence = confidence
-		hasConfidence = true
-	}
-
-	// If no new confidence provided, we need to get the current relationship
-	if !hasConfidence {
-		relationship, err := storage.GetRelationshipByID(ctx, relationshipID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get relationship: %w", err)
-		}
-		newConfidence = relationship.Confidence
 	}
 
 	// Update the relationship
-	err := storage.UpdateRelationship(ctx, relationshipID, newConfidence, factors)
+	err = storage.UpdateRelationship(ctx, relationshipID, newConfidence, factors)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update relationship: %w", err)
 	}
@@ -5985,6 +6061,8 @@ func (ms *MemoryServer) handleCreateThread(ctx context.Context, params map[strin
 		return nil, err
 	}
 
+	ms.publishThreadEvent(websocket.ActionCreated, thread)
+
 	// Build and return response
 	result := ms.buildCreateThreadResponse(thread)
 	logging.Info("memory_create_thread completed successfully", "thread_id", thread.ID, "chunk_count", len(chunks))
@@ -6046,11 +6124,15 @@ func (ms *MemoryServer) retrieveChunksByIDs(ctx context.Context, chunkIDs []stri
 	for _, chunkID := range chunkIDs {
 		// Note: We need a GetChunk method or similar. For now, we'll use search as fallback
 		// This is a simplified approach - in production you'd want a direct GetChunk method
-		if chunk, err := ms.getChunkByID(ctx, chunkID); err == nil {
-			chunks = append(chunks, *chunk)
-		} else {
+		chunk, err := ms.getChunkByID(ctx, chunkID)
+		if err != nil {
 			logging.Warn("Failed to retrieve chunk", "chunk_id", chunkID, "error", err)
+			continue
+		}
+		if err := ms.authorizeRepository(ctx, "create_thread", chunk.Metadata.Repository, security.AccessLevelRead); err != nil {
+			return nil, err
 		}
+		chunks = append(chunks, *chunk)
 	}
 
 	if len(chunks) == 0 {
@@ -6311,6 +6393,10 @@ func (ms *MemoryServer) handleDetectThreads(ctx context.Context, params map[stri
 				// Continue with other threads even if one fails
 			}
 		}
+	} else {
+		for _, thread := range filteredThreads {
+			ms.publishThreadEvent(websocket.ActionCreated, thread)
+		}
 	}
 
 	// Format response
@@ -6388,6 +6474,20 @@ func (ms *MemoryServer) addChunksToThreadFromParams(params map[string]interface{
 	return ms.addChunksToThread(thread, addChunksInterface)
 }
 
+// authorizeAddedChunks checks that the caller may read every chunk an
+// update adds to a thread, before any change is stored
+func (ms *MemoryServer) authorizeAddedChunks(ctx context.Context, params map[string]interface{}) error {
+	addChunksInterface, _ := params["add_chunks"].([]interface{})
+	for _, chunkInterface := range addChunksInterface {
+		if chunkID, ok := chunkInterface.(string); ok {
+			if _, err := ms.authorizeChunk(ctx, "update_thread", chunkID, security.AccessLevelRead); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // removeChunksFromThread removes chunks from thread if provided
 func (ms *MemoryServer) removeChunksFromThread(params map[string]interface{}, thread *threading.MemoryThread) bool {
 	removeChunksInterface, ok := params["remove_chunks"].([]interface{})
@@ -6461,6 +6561,12 @@ func (ms *MemoryServer) handleUpdateThread(ctx context.Context, params map[strin
 		logging.Error("Failed to get thread", "thread_id", threadID, "error", err)
 		return nil, fmt.Errorf("failed to get thread: %w", err)
 	}
+	if err := ms.authorizeRepository(ctx, "update_thread", thread.Repository, security.AccessLevelWrite); err != nil {
+		return nil, err
+	}
+	if err := ms.authorizeAddedChunks(ctx, params); err != nil {
+		return nil, err
+	}
 
 	updated := false
 
@@ -6491,6 +6597,9 @@ func (ms *MemoryServer) handleUpdateThread(ctx context.Context, params map[strin
 	if err != nil {
 		return nil, err
 	}
+	if updated {
+		ms.publishThreadEvent(websocket.ActionUpdated, thread)
+	}
 
 	// Build and return result
 	result := ms.buildThreadUpdateResult(thread, updated)
@@ -7642,8 +7751,7 @@ func (ms *MemoryServer) handleMemoryDecayManagement(ctx context.Context, params
 		if !hasConfig {
 			return nil, errors.New("config is required for configure action")
 		}
-		result = ms.handleDecayConfiguration(ctx, repository, sessionID, decayConfig)
-		err = nil
+		result, err = ms.handleDecayConfiguration(ctx, repository, sessionID, decayConfig)
 	default:
 		return nil, fmt.Errorf("unknown action: %s. Valid actions are: 'run_decay', 'configure', 'status', 'preview'", action)
 	}
@@ -7685,6 +7793,7 @@ func (ms *MemoryServer) handleDecayStatus(ctx context.Context, repository, sessi
 
 	// Analyze decay eligibility
 	now := time.Now()
+	summarizationThreshold, deletionThreshold := ms.decayThresholds(repository)
 	var oldChunks, staleChunks, candidatesForSummarization, candidatesForDeletion int
 	var totalAge time.Duration
 
@@ -7701,10 +7810,10 @@ func (ms *MemoryServer) handleDecayStatus(ctx context.Context, repository, sessi
 
 		// Estimate decay score
 		score := ms.estimateDecayScore(&chunks[i], age)
-		if score < 0.4 {
+		if score < summarizationThreshold {
 			candidatesForSummarization++
 		}
-		if score < 0.1 {
+		if score < deletionThreshold {
 			candidatesForDeletion++
 		}
 	}
@@ -7759,6 +7868,7 @@ func (ms *MemoryServer) handleDecayPreview(ctx context.Context, repository, sess
 
 	// Analyze what would be processed
 	now := time.Now()
+	summarizationThreshold, deletionThreshold := ms.decayThresholds(repository)
 	toSummarize := make([]map[string]interface{}, 0)
 	toDelete := make([]map[string]interface{}, 0)
 	toUpdate := make([]map[string]interface{}, 0)
@@ -7783,9 +7893,9 @@ func (ms *MemoryServer) handleDecayPreview(ctx context.Context, repository, sess
 		}
 
 		switch {
-		case score < 0.1:
+		case score < deletionThreshold:
 			toDelete = append(toDelete, chunkInfo)
-		case score < 0.4:
+		case score < summarizationThreshold:
 			toSummarize = append(toSummarize, chunkInfo)
 		case score < 0.7:
 			toUpdate = append(toUpdate, chunkInfo)
@@ -7828,14 +7938,7 @@ func (ms *MemoryServer) handleRunDecay(ctx context.Context, repository, sessionI
 		return ms.handleDecayPreview(ctx, repository, sessionID, intelligentMode)
 	}
 
-	// This is a simplified implementation
-	// In a full implementation, you would:
-	// 1. Create a proper decay manager instance
-	// 2. Configure it with embeddings if intelligentMode is true
-	// 3. Run the actual decay process
-
-	return map[string]interface{}{
-		"status":     "decay_executed",
+	result := map[string]interface{}{
 		"repository": repository,
 		"session_id": sessionID,
 		"mode": map[string]interface{}{
@@ -7843,13 +7946,34 @@ func (ms *MemoryServer) handleRunDecay(ctx context.Context, repository, sessionI
 			"preview_only": previewOnly,
 		},
 		"timestamp": time.Now().Format(time.RFC3339),
-		"message":   "Memory decay process completed. Note: This is a simplified implementation for demonstration.",
-		"note":      "Full implementation would integrate with the decay manager and perform actual summarization and cleanup.",
-	}, nil
+	}
+	if ms.repoConfig(repository).Decay.Exempt {
+		result["status"] = "decay_skipped"
+		result["message"] = "The repository is exempt from decay."
+		return result, nil
+	}
+
+	decayManager := ms.container.GetDecayManager()
+	if decayManager == nil {
+		return nil, errors.New("memory decay is not available")
+	}
+	summarizationThreshold, deletionThreshold := ms.decayThresholds(repository)
+	decayed, err := decayManager.RunDecayWithThresholds(ctx, repository, summarizationThreshold, deletionThreshold)
+	metrics.DecayRuns.Inc(decayRunOperation, repository, metrics.Status(err))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run decay: %w", err)
+	}
+	metrics.DecayDeletedChunks.Add(float64(decayed.Deleted), decayRunOperation, repository)
+
+	result["status"] = "decay_executed"
+	result["result"] = decayed
+	result["message"] = fmt.Sprintf("Memory decay completed: %d summarized, %d updated, %d deleted.", decayed.Summarized, decayed.Updated, decayed.Deleted)
+	return result, nil
 }
 
-// handleDecayConfiguration handles decay configuration updates
-func (ms *MemoryServer) handleDecayConfiguration(_ context.Context, repository, sessionID string, decayConfig map[string]interface{}) map[string]interface{} {
+// handleDecayConfiguration saves the repository's decay thresholds to its
+// settings; strategy and rate are reported but not configurable yet
+func (ms *MemoryServer) handleDecayConfiguration(_ context.Context, repository, sessionID string, decayConfig map[string]interface{}) (map[string]interface{}, error) {
 	// Parse configuration
 	strategy := "adaptive"
 	if s, ok := decayConfig["strategy"].(string); ok && s != "" {
@@ -7861,21 +7985,18 @@ func (ms *MemoryServer) handleDecayConfiguration(_ context.Context, repository,
 		baseDecayRate = rate
 	}
 
-	summarizationThreshold := 0.4
-	if threshold, ok := decayConfig["summarization_threshold"].(float64); ok {
-		summarizationThreshold = threshold
-	}
-
-	deletionThreshold := 0.1
-	if threshold, ok := decayConfig["deletion_threshold"].(float64); ok {
-		deletionThreshold = threshold
-	}
-
 	retentionPeriodDays := 7.0
 	if days, ok := decayConfig["retention_period_days"].(float64); ok {
 		retentionPeriodDays = days
 	}
 
+	repoConfig := ms.repoConfig(repository)
+	applyDecayPolicy(&repoConfig.Decay, decayConfig)
+	if err := ms.container.RepoConfigs.Set(repository, repoConfig); err != nil {
+		return nil, fmt.Errorf("failed to save decay configuration: %w", err)
+	}
+	summarizationThreshold, deletionThreshold := ms.decayThresholds(repository)
+
 	return map[string]interface{}{
 		"status":     "configuration_updated",
 		"repository": repository,
@@ -7886,10 +8007,11 @@ func (ms *MemoryServer) handleDecayConfiguration(_ context.Context, repository,
 			"base_decay_rate":         baseDecayRate,
 			"summarization_threshold": summarizationThreshold,
 			"deletion_threshold":      deletionThreshold,
+			"exempt":                  repoConfig.Decay.Exempt,
 			"retention_period_days":   retentionPeriodDays,
 		},
-		"note": "Configuration saved. This is a simplified implementation for demonstration.",
-	}
+		"note": "Thresholds and exemption are saved in the repository settings (see memory_system repo_config_get).",
+	}, nil
 }
 
 // estimateDecayScore estimates the decay score for a chunk (simplified implementation)
@@ -8115,22 +8237,35 @@ func (ms *MemoryServer) chunkExistsInThread(thread *threading.MemoryThread, chun
 	return false
 }
 
-// runPeriodicDecay runs automatic cleanup of old chunks periodically
+// runPeriodicDecay runs automatic cleanup of old chunks periodically. A
+// reload that changes the interval reschedules the next run from the last.
 func (ms *MemoryServer) runPeriodicDecay(ctx context.Context) {
-	ticker := time.NewTicker(24 * time.Hour) // Run daily
-	defer ticker.Stop()
+	interval := ms.decayInterval()
+	lastRun := time.Now()
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			logging.Info("Stopping periodic decay due to context cancellation")
 			return
-		case <-ticker.C:
+		case <-ms.configReloaded:
+			if next := ms.decayInterval(); next != interval {
+				logging.Info("Decay interval changed", "previous", interval, "interval", next)
+				interval = next
+				timer.Reset(time.Until(lastRun.Add(interval)))
+			}
+		case <-timer.C:
+			lastRun = time.Now()
+			timer.Reset(interval)
 			logging.Info("Running automatic memory decay cleanup")
 
-			// Clean up chunks older than retention period (90 days by default)
-			retentionDays := 90
-			deletedCount, err := ms.container.GetVectorStore().Cleanup(ctx, retentionDays)
+			// Clean up chunks older than their repository's retention period
+			retentionDays := ms.container.CurrentConfig().Storage.RetentionDays
+			deletedCount, err := ms.runRetentionCleanup(ctx)
+			metrics.DecayRuns.Inc(retentionCleanupOperation, "", metrics.Status(err))
+			metrics.DecayDeletedChunks.Add(float64(deletedCount), retentionCleanupOperation, "")
 			if err != nil {
 				logging.Error("Failed to run automatic cleanup", "error", err)
 				continue
@@ -8140,7 +8275,7 @@ func (ms *MemoryServer) runPeriodicDecay(ctx context.Context) {
 				logging.Info("Automatic cleanup completed", "deleted_chunks", deletedCount, "retention_days", retentionDays)
 
 				// Store cleanup result as memory chunk for tracking
-				content := fmt.Sprintf("Automatic memory cleanup completed. Deleted %d old chunks (retention: %d days)", deletedCount, retentionDays)
+				content := fmt.Sprintf("Automatic memory cleanup completed. Deleted %d old chunks (retention: %d days unless the repository sets its own)", deletedCount, retentionDays)
 				ms.storeCleanupResult(ctx, content)
 			}
 		}
@@ -8293,6 +8428,13 @@ func (ms *MemoryServer) handleMarkRefreshed(ctx context.Context, params map[stri
 		return nil, fmt.Errorf("invalid chunk_id format: expected UUID, got '%s'. Note: chunk IDs are UUIDs, not todo IDs", chunkID)
 	}
 
+	// The call was authorized for the repository it names; the chunk may
+	// belong to another
+	if _, err := ms.authorizeChunk(ctx, "memory_mark_refreshed", chunkID, security.AccessLevelWrite); err != nil {
+		logging.Warn("memory_mark_refreshed denied", "chunk_id", chunkID, "error", err)
+		return nil, err
+	}
+
 	validationNotes := ""
 	if notes, ok := params["validation_notes"].(string); ok {
 		validationNotes = notes
@@ -8335,10 +8477,11 @@ func (ms *MemoryServer) handleMarkRefreshed(ctx context.Context, params map[stri
 
 // checkSingleChunkFreshness checks freshness for a single chunk
 func (ms *MemoryServer) checkSingleChunkFreshness(ctx context.Context, chunkID string) (map[string]interface{}, error) {
-	// Get the chunk
-	chunk, err := ms.container.VectorStore.GetByID(ctx, chunkID)
+	// Get the chunk, checking the repository it belongs to rather than the
+	// one the call named
+	chunk, err := ms.authorizeChunk(ctx, "memory_check_freshness", chunkID, security.AccessLevelRead)
 	if err != nil {
-		return nil, fmt.Errorf("chunk not found: %w", err)
+		return nil, err
 	}
 
 	// Create freshness manager
@@ -8851,18 +8994,72 @@ func (ms *MemoryServer) handleBulkOperation(ctx context.Context, params map[stri
 	if err != nil {
 		return nil, fmt.Errorf("invalid bulk request: %w", err)
 	}
+	if err := ms.authorizeBulkRequest(ctx, params, &bulkReq); err != nil {
+		return nil, err
+	}
+
+	var blocked []string
+	if len(bulkReq.Chunks) > 0 {
+		bulkReq.Chunks, blocked = ms.redactBulkChunks(ctx, bulkReq.Chunks)
+		if len(bulkReq.Chunks) == 0 {
+			return nil, fmt.Errorf("every chunk was refused: %s", strings.Join(blocked, "; "))
+		}
+	}
 
+	bulkReq.Options.ProgressCallback = bulkProgressNotifier(ctx)
 	progress, err := ms.bulkManager.SubmitOperation(ctx, &bulkReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to submit bulk operation: %w", err)
 	}
 
 	response := ms.buildBulkOperationResponse(progress, string(bulkReq.Operation))
+	if len(blocked) > 0 {
+		response["blocked"] = blocked
+	}
 
 	logging.Info("memory_bulk_operation completed successfully", "operation_id", progress.OperationID)
 	return response, nil
 }
 
+// authorizeBulkRequest checks the chunks and IDs a bulk request touches.
+// Chunks carry their own repository, so each must match the one the call
+// was authorized for, and chunks the request overwrites or deletes are
+// checked against the repository they are stored in.
+func (ms *MemoryServer) authorizeBulkRequest(ctx context.Context, params map[string]interface{}, req *bulk.Request) error {
+	repository, _ := params["repository"].(string)
+	for i := range req.Chunks {
+		chunk := &req.Chunks[i]
+		if repository != "" && chunk.Metadata.Repository != repository {
+			return fmt.Errorf("access denied: chunk at index %d belongs to repository %q, not %q", i, chunk.Metadata.Repository, repository)
+		}
+		if err := ms.authorizeRepository(ctx, "bulk_operation", chunk.Metadata.Repository, security.AccessLevelWrite); err != nil {
+			return err
+		}
+		if err := ms.authorizeExistingChunk(ctx, chunk.ID); err != nil {
+			return err
+		}
+	}
+	for _, id := range req.IDs {
+		if err := ms.authorizeExistingChunk(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authorizeExistingChunk checks write access to a stored chunk; IDs that
+// are not stored yet need no check
+func (ms *MemoryServer) authorizeExistingChunk(ctx context.Context, chunkID string) error {
+	if chunkID == "" {
+		return nil
+	}
+	existing, err := ms.container.GetVectorStore().GetByID(ctx, chunkID)
+	if err != nil || existing == nil {
+		return nil
+	}
+	return ms.authorizeRepository(ctx, "bulk_operation", existing.Metadata.Repository, security.AccessLevelWrite)
+}
+
 // validateBulkOperationParams validates required parameters for bulk operation
 func (ms *MemoryServer) validateBulkOperationParams(params map[string]interface{}) (string, error) {
 	operation, ok := params["operation"].(string)
@@ -9012,16 +9209,22 @@ func (ms *MemoryServer) handleBulkImport(ctx context.Context, params map[string]
 		return nil, fmt.Errorf("import failed: %w", err)
 	}
 
+	// Scrub secrets and personal data before anything is embedded
+	ms.redactImportedChunks(ctx, result)
+
 	// Store imported chunks if successful
 	if len(result.Chunks) > 0 {
+		ms.embedImportedChunks(ctx, result)
+
 		bulkReq := bulk.Request{
 			Operation: bulk.OperationStore,
 			Chunks:    result.Chunks,
 			Options: bulk.Options{
-				BatchSize:       50,
-				MaxConcurrency:  3,
-				ValidateFirst:   false, // Already validated during import
-				ContinueOnError: true,
+				BatchSize:        50,
+				MaxConcurrency:   3,
+				ValidateFirst:    false, // Already validated during import
+				ContinueOnError:  true,
+				ProgressCallback: bulkProgressNotifier(ctx),
 			},
 		}
 
@@ -9046,6 +9249,46 @@ func (ms *MemoryServer) handleBulkImport(ctx context.Context, params map[string]
 	}, nil
 }
 
+// embedImportedChunks generates vectors for imported chunks that arrive
+// without them, so backfilled memories are found by search. A failed batch
+// is reported as a warning and its chunks are stored without vectors.
+func (ms *MemoryServer) embedImportedChunks(ctx context.Context, result *bulk.ImportResult) {
+	const batchSize = 50
+	service := ms.container.GetEmbeddingService()
+	chunker := ms.container.GetChunkingService()
+
+	var pending []int
+	for i := range result.Chunks {
+		if len(result.Chunks[i].Embeddings) == 0 {
+			pending = append(pending, i)
+		}
+	}
+
+	for start := 0; start < len(pending); start += batchSize {
+		batch := pending[start:min(start+batchSize, len(pending))]
+		texts := make([]string, len(batch))
+		for j, index := range batch {
+			texts[j] = chunker.EmbeddingText(&result.Chunks[index])
+		}
+
+		vectors, err := service.GenerateBatchEmbeddings(ctx, texts)
+		if err == nil && len(vectors) != len(batch) {
+			err = fmt.Errorf("embedding provider returned %d vectors for %d chunks", len(vectors), len(batch))
+		}
+		if err != nil {
+			logging.Warn("Failed to embed imported chunks", "error", err, "chunks", len(batch))
+			result.Warnings = append(result.Warnings, bulk.ImportWarning{
+				Item:    batch[0],
+				Message: fmt.Sprintf("embedding failed for %d chunks: %v", len(batch), err),
+			})
+			continue
+		}
+		for j, index := range batch {
+			result.Chunks[index].Embeddings = vectors[j]
+		}
+	}
+}
+
 // parseImportMetadata extracts metadata from parameters and updates import options
 func (ms *MemoryServer) parseImportMetadata(params map[string]interface{}, options *bulk.ImportOptions) {
 	metadataInterface, ok := params["metadata"].(map[string]interface{})
@@ -9359,12 +9602,32 @@ func (ms *MemoryServer) handleSecureBulkDelete(ctx context.Context, params map[s
 
 // handleSecureSearch performs repository-scoped search without progressive fallback
 func (ms *MemoryServer) handleSecureSearch(ctx context.Context, params map[string]interface{}, repository string) (interface{}, error) {
+	response, _, err := ms.executeSecureSearch(ctx, params, repository)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// executeSecureSearch runs a repository-scoped search in the requested
+// search_mode and returns the response along with per-result explanations
+func (ms *MemoryServer) executeSecureSearch(ctx context.Context, params map[string]interface{}, repository string) (map[string]interface{}, *storage.HybridSearchResults, error) {
 	logging.Info("MCP TOOL: memory_secure_search called", "params", params, "repository", repository)
 
 	// Parse search query
 	query, ok := params["query"].(string)
 	if !ok || query == "" {
-		return nil, errors.New("query parameter is required for search. Example: {\"query\": \"authentication bug fix\", \"repository\": \"github.com/user/repo\"}")
+		return nil, nil, errors.New("query parameter is required for search. Example: {\"query\": \"authentication bug fix\", \"repository\": \"github.com/user/repo\"}")
+	}
+
+	// Parse search mode, defaulting to the configured mode
+	modeName := ms.container.CurrentConfig().Search.DefaultMode
+	if mode, ok := params["search_mode"].(string); ok && mode != "" {
+		modeName = mode
+	}
+	searchMode, err := storage.ParseSearchMode(modeName)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Create memory query with strict repository isolation
@@ -9386,7 +9649,7 @@ func (ms *MemoryServer) handleSecureSearch(ctx context.Context, params map[strin
 	if minRelevance, ok := params["min_relevance"].(float64); ok && minRelevance > 0 {
 		memQuery.MinRelevanceScore = minRelevance
 	} else {
-		memQuery.MinRelevanceScore = 0.5 // Default relevance threshold
+		memQuery.MinRelevanceScore = ms.minRelevance(repository)
 	}
 
 	// Parse type filters if provided
@@ -9405,30 +9668,45 @@ func (ms *MemoryServer) handleSecureSearch(ctx context.Context, params map[strin
 		memQuery.Recency = types.Recency(recency)
 	}
 
-	// Generate embeddings for the query
-	embeddingService := ms.container.GetEmbeddingService()
-	embeddings, err := embeddingService.GenerateEmbedding(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
-	}
+	// Expand alias references such as @bug-fixes into the chunks they name;
+	// whatever text remains is searched as usual
+	searchText, aliasChunks, aliasNames := ms.expandAliasReferences(ctx, query, repository)
+	memQuery.Query = searchText
 
-	// Perform SECURE search (no progressive fallback that breaks repository isolation)
-	vectorStore := ms.container.GetVectorStore()
-	results, err := vectorStore.Search(ctx, &memQuery, embeddings)
-	if err != nil {
-		return nil, fmt.Errorf("search failed: %w", err)
+	results := &storage.HybridSearchResults{Mode: searchMode}
+	if strings.TrimSpace(searchText) != "" || len(aliasNames) == 0 {
+		// Generate embeddings for the query; lexical search does not need them
+		var embeddings []float64
+		if searchMode != storage.SearchModeLexical {
+			embeddingService := ms.container.GetEmbeddingService()
+			embeddings, err = embeddingService.GenerateEmbedding(ctx, searchText)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to generate embeddings: %w", err)
+			}
+		}
+
+		// Perform SECURE search (no progressive fallback that breaks repository isolation)
+		results, err = ms.getHybridSearcher().Search(ctx, &memQuery, embeddings, searchMode)
+		if err != nil {
+			return nil, nil, fmt.Errorf("search failed: %w", err)
+		}
 	}
+	mergeAliasResults(results, aliasChunks)
 
 	// Build response
 	response := map[string]interface{}{
 		"status":        "success",
 		"repository":    repository,
 		"query":         query,
+		"search_mode":   string(results.Mode),
 		"total":         results.Total,
 		"results":       results.Results,
 		"query_time":    results.QueryTime.Milliseconds(),
 		"security_note": "Repository-scoped search with no cross-tenant fallback",
 	}
+	if len(aliasNames) > 0 {
+		response["aliases"] = aliasNames
+	}
 
 	if repository == GlobalRepository {
 		response["scope"] = GlobalRepository
@@ -9438,10 +9716,98 @@ func (ms *MemoryServer) handleSecureSearch(ctx context.Context, params map[strin
 	logging.Info("Secure search completed",
 		"repository", repository,
 		"query", query,
+		"search_mode", results.Mode,
 		"results_count", results.Total,
 		"query_time_ms", results.QueryTime.Milliseconds())
 
-	return response, nil
+	return response, results, nil
+}
+
+// expandAliasReferences resolves alias references in a search query. It
+// returns the query with resolved references removed, the chunks they point
+// to within the repository, and the names of the resolved aliases.
+// Unknown references, such as "#123", stay in the query text.
+func (ms *MemoryServer) expandAliasReferences(ctx context.Context, query, repository string) (string, []types.ConversationChunk, []string) {
+	if ms.aliasManager == nil {
+		return query, nil, nil
+	}
+
+	resolved, err := ms.aliasManager.ResolveAliasReference(ctx, query)
+	if err != nil || len(resolved) == 0 {
+		return query, nil, nil
+	}
+
+	names := make([]string, 0, len(resolved))
+	for ref := range resolved {
+		names = append(names, ref)
+	}
+	sort.Strings(names)
+
+	seen := make(map[string]bool)
+	var chunks []types.ConversationChunk
+	remaining := query
+	for _, ref := range names {
+		remaining = strings.ReplaceAll(remaining, ref, "")
+		for i := range resolved[ref].Chunks {
+			chunk := resolved[ref].Chunks[i]
+			// SECURITY: aliases never reach outside the requested repository
+			if repository != GlobalRepository && chunk.Metadata.Repository != repository {
+				continue
+			}
+			if !seen[chunk.ID] {
+				seen[chunk.ID] = true
+				chunks = append(chunks, chunk)
+			}
+		}
+	}
+
+	return strings.Join(strings.Fields(remaining), " "), chunks, names
+}
+
+// mergeAliasResults puts alias chunks ahead of the search results, dropping
+// search hits that an alias already returned
+func mergeAliasResults(results *storage.HybridSearchResults, aliasChunks []types.ConversationChunk) {
+	if len(aliasChunks) == 0 {
+		return
+	}
+
+	aliasIDs := make(map[string]bool, len(aliasChunks))
+	merged := make([]types.SearchResult, 0, len(aliasChunks)+len(results.Results))
+	explanations := make([]storage.ResultExplanation, 0, cap(merged))
+	for i := range aliasChunks {
+		aliasIDs[aliasChunks[i].ID] = true
+		merged = append(merged, types.SearchResult{Chunk: aliasChunks[i], Score: 1.0})
+		explanations = append(explanations, storage.ResultExplanation{
+			ChunkID: aliasChunks[i].ID,
+			Score:   1.0,
+			Signals: map[string]storage.SignalContribution{
+				"alias": {Rank: i + 1, Score: 1.0, Contribution: 1.0, Share: 1.0},
+			},
+		})
+	}
+
+	for i := range results.Results {
+		if aliasIDs[results.Results[i].Chunk.ID] {
+			continue
+		}
+		merged = append(merged, results.Results[i])
+		if i < len(results.Explanations) {
+			explanations = append(explanations, results.Explanations[i])
+		}
+	}
+
+	results.Results = merged
+	results.Explanations = explanations
+	results.Total = len(merged)
+}
+
+// getHybridSearcher returns the container's searcher, or a vector-only
+// searcher over the vector store when the container was built without one
+func (ms *MemoryServer) getHybridSearcher() *storage.HybridSearcher {
+	if searcher := ms.container.GetHybridSearcher(); searcher != nil {
+		return searcher
+	}
+	return storage.NewHybridSearcher(ms.container.GetVectorStore(), ms.container.GetLexicalIndex(), ms.container.CurrentConfig().Search.RRFK)
 }
 
 // handleSecureFindSimilar performs repository-scoped similarity search
@@ -9477,24 +9843,33 @@ func (ms *MemoryServer) handleSecureSearchExplained(ctx context.Context, params
 	logging.Info("MCP TOOL: memory_secure_search_explained called", "params", params, "repository", repository)
 
 	// Perform secure search first
-	result, err := ms.handleSecureSearch(ctx, params, repository)
+	resultMap, results, err := ms.executeSecureSearch(ctx, params, repository)
 	if err != nil {
 		return nil, err
 	}
 
-	// Add explanation to the result
-	if resultMap, ok := result.(map[string]interface{}); ok {
-		resultMap["explanation"] = map[string]interface{}{
-			"search_strategy":   "Repository-scoped search with strict isolation",
-			"fallback_disabled": "Progressive search fallback disabled for security",
-			"repository_scope":  repository,
-		}
-		if repository == GlobalRepository {
-			resultMap["explanation"].(map[string]interface{})["scope_note"] = "Global search enabled for cross-project architecture decisions"
-		}
+	// Add explanation to the result, including each signal's contribution
+	explanation := map[string]interface{}{
+		"search_strategy":   "Repository-scoped search with strict isolation",
+		"fallback_disabled": "Progressive search fallback disabled for security",
+		"repository_scope":  repository,
+		"search_mode":       string(results.Mode),
+		"results":           results.Explanations,
+	}
+	switch results.Mode {
+	case storage.SearchModeHybrid:
+		explanation["ranking"] = fmt.Sprintf("Reciprocal-rank fusion of vector and lexical (BM25) rankings: each list adds 1/(%d+rank)", ms.container.CurrentConfig().Search.RRFK)
+	case storage.SearchModeLexical:
+		explanation["ranking"] = "BM25 keyword ranking; scores normalized by the top result"
+	default:
+		explanation["ranking"] = "Cosine similarity between query and chunk embeddings"
 	}
+	if repository == GlobalRepository {
+		explanation["scope_note"] = "Global search enabled for cross-project architecture decisions"
+	}
+	resultMap["explanation"] = explanation
 
-	return result, nil
+	return resultMap, nil
 }
 
 // Placeholder secure handlers for other operations
@@ -9945,7 +10320,7 @@ func (ms *MemoryServer) handleGetBulkProgress(ctx context.Context, params map[st
 	}
 
 	logging.Info("memory_get_bulk_progress completed successfully", "operation_id", operationID, "status", progress.Status)
-	return map[string]interface{}{
+	response := map[string]interface{}{
 		"operation_id":      progress.OperationID,
 		"status":            string(progress.Status),
 		"total_items":       progress.TotalItems,
@@ -9960,6 +10335,385 @@ func (ms *MemoryServer) handleGetBulkProgress(ctx context.Context, params map[st
 		"estimated_time":    progress.EstimatedTime.String(),
 		"errors":            bulkErrors,
 		"validation_errors": progress.ValidationErrors,
+	}
+	if progress.Phase != "" {
+		response["phase"] = progress.Phase
+	}
+	return response, nil
+}
+
+// handleReembed starts, retries or estimates a re-embedding of the vector
+// collection with another embedding provider
+func (ms *MemoryServer) handleReembed(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	logging.Info("MCP TOOL: memory_system reembed called", "params", params)
+
+	if operationID, ok := params["operation_id"].(string); ok && operationID != "" {
+		job, err := ms.reembedder.Retry(context.WithoutCancel(ctx), operationID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retry re-embedding: %w", err)
+		}
+		ms.watchReembedProgress(ctx, job.ID)
+		return map[string]interface{}{
+			"operation_id": job.ID,
+			"status":       string(bulk.StatusPending),
+			"cursor":       job.Cursor,
+			"message":      "Re-embedding resumed from its last saved batch. Track it with get_bulk_progress.",
+		}, nil
+	}
+
+	provider, _ := params["provider"].(string)
+	if provider == "" {
+		return nil, errors.New("provider parameter is required for reembed. Example: {\"provider\": \"ollama\", \"model\": \"nomic-embed-text\", \"dry_run\": true}")
+	}
+
+	opts := &bulk.ReembedOptions{
+		Target: config.EmbeddingConfig{
+			Provider:       provider,
+			RequestTimeout: ms.container.Config.Embedding.RequestTimeout,
+			RateLimitRPM:   ms.container.Config.Embedding.RateLimitRPM,
+		},
+	}
+	opts.Target.Model, _ = params["model"].(string)
+	opts.Target.BaseURL, _ = params["base_url"].(string)
+	if dimension, ok := params["dimension"].(float64); ok {
+		opts.Target.Dimension = int(dimension)
+	}
+	if batchSize, ok := params["batch_size"].(float64); ok {
+		opts.BatchSize = int(batchSize)
+	}
+	if dropPrevious, ok := params["drop_previous"].(bool); ok {
+		opts.DropPrevious = dropPrevious
+	}
+
+	if dryRun, ok := params["dry_run"].(bool); ok && dryRun {
+		estimate, err := ms.reembedder.Estimate(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate re-embedding: %w", err)
+		}
+		return map[string]interface{}{
+			"dry_run":  true,
+			"estimate": estimate,
+		}, nil
+	}
+
+	// The job outlives this request
+	job, err := ms.reembedder.Start(context.WithoutCancel(ctx), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start re-embedding: %w", err)
+	}
+	ms.watchReembedProgress(ctx, job.ID)
+
+	logging.Info("Re-embedding started", "operation_id", job.ID, "shadow_collection", job.ShadowCollection)
+	return map[string]interface{}{
+		"operation_id":      job.ID,
+		"status":            string(bulk.StatusPending),
+		"source_collection": job.SourceCollection,
+		"shadow_collection": job.ShadowCollection,
+		"message":           "Re-embedding started. Track it with get_bulk_progress. Once completed, update MCP_MEMORY_EMBEDDING_* to the new provider before restarting.",
+	}, nil
+}
+
+// bulkProgressNotifier pushes bulk progress to the SSE session that started
+// the operation, so clients need not poll get_bulk_progress. Callers without
+// a session get nil.
+func bulkProgressNotifier(ctx context.Context) func(bulk.Progress) {
+	session, ok := sse.SessionFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return func(progress bulk.Progress) {
+		if err := session.Notify(NotificationBulkProgress, progress); err != nil {
+			logging.Debug("Failed to push bulk progress", "operation_id", progress.OperationID, "error", err)
+		}
+	}
+}
+
+// watchReembedProgress pushes re-embedding progress to the caller's SSE
+// session until the job completes, fails or the session closes
+func (ms *MemoryServer) watchReembedProgress(ctx context.Context, jobID string) {
+	session, ok := sse.SessionFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(reembedProgressInterval)
+		defer ticker.Stop()
+
+		var last bulk.Progress
+		for {
+			select {
+			case <-ticker.C:
+			case <-session.Context().Done():
+				return
+			}
+
+			progress := ms.reembedder.Progress(jobID)
+			if progress.Phase == last.Phase && progress.ProcessedItems == last.ProcessedItems && progress.Status == last.Status {
+				continue
+			}
+			last = *progress
+			if err := session.Notify(NotificationBulkProgress, progress); err != nil {
+				return
+			}
+			if progress.Status == bulk.StatusCompleted || progress.Status == bulk.StatusFailed {
+				return
+			}
+		}
+	}()
+}
+
+// handleRotateEncryptionKey switches new writes to a fresh data key and
+// re-encrypts stored chunks in the background. Old keys stay in the keyring
+// so existing backups remain readable.
+func (ms *MemoryServer) handleRotateEncryptionKey(_ context.Context, _ map[string]interface{}) (interface{}, error) {
+	logging.Info("MCP TOOL: memory_system rotate_encryption_key called")
+
+	encryption := ms.container.GetEncryption()
+	if encryption == nil {
+		return nil, errors.New("encryption at rest is not enabled. Set MCP_MEMORY_ENCRYPTION_ENABLED=true and MCP_MEMORY_ENCRYPTION_PASSPHRASE")
+	}
+
+	previousKeyID := encryption.ActiveKeyID()
+	if err := encryption.RotateKey(""); err != nil {
+		return nil, fmt.Errorf("failed to rotate encryption key: %w", err)
+	}
+
+	logging.Info("Encryption key rotated", "previous_key_id", previousKeyID, "key_id", encryption.ActiveKeyID())
+	return map[string]interface{}{
+		"previous_key_id": previousKeyID,
+		"key_id":          encryption.ActiveKeyID(),
+		"status":          "reencrypting",
+		"message":         "New memories use the new key. Stored memories are being re-encrypted in the background.",
+	}, nil
+}
+
+// handleWebhookSubscribe registers an endpoint for memory events
+func (ms *MemoryServer) handleWebhookSubscribe(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	logging.Info("MCP TOOL: memory_system webhook_subscribe called")
+
+	endpoint, ok := params["url"].(string)
+	if !ok || endpoint == "" {
+		return nil, errors.New("url parameter is required for webhook_subscribe. Example: {\"url\": \"https://ci.example.com/hooks/memory\", \"repositories\": [\"github.com/user/repo\"], \"event_types\": [\"memory\"]}")
+	}
+	sub := webhooks.Subscription{
+		URL:          endpoint,
+		Repositories: extractStringArray(params["repositories"]),
+		EventTypes:   extractStringArray(params["event_types"]),
+	}
+	sub.Secret, _ = params["secret"].(string)
+	sub.Description, _ = params["description"].(string)
+	if principal, ok := security.PrincipalFromContext(ctx); ok {
+		sub.CreatedBy = principal.UserID
+	}
+
+	created, err := ms.webhooks.Subscribe(sub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe webhook: %w", err)
+	}
+
+	logging.Info("Webhook subscribed", "subscription_id", created.ID, "url", created.URL)
+	return map[string]interface{}{
+		"subscription": created,
+		"message":      "Store the secret now; it is not shown again. Verify the X-Memory-Signature header as HMAC-SHA256 of \"<X-Memory-Timestamp>.<body>\".",
+	}, nil
+}
+
+// handleWebhookUnsubscribe removes an endpoint and its queued deliveries
+func (ms *MemoryServer) handleWebhookUnsubscribe(_ context.Context, params map[string]interface{}) (interface{}, error) {
+	logging.Info("MCP TOOL: memory_system webhook_unsubscribe called", "params", params)
+
+	subscriptionID, ok := params["subscription_id"].(string)
+	if !ok || subscriptionID == "" {
+		return nil, errors.New("subscription_id parameter is required for webhook_unsubscribe")
+	}
+	if err := ms.webhooks.Unsubscribe(subscriptionID); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		StatusSuccess:     true,
+		"subscription_id": subscriptionID,
+	}, nil
+}
+
+// handleWebhookList lists the registered endpoints
+func (ms *MemoryServer) handleWebhookList(_ context.Context, _ map[string]interface{}) (interface{}, error) {
+	subscriptions := ms.webhooks.Subscriptions()
+	return map[string]interface{}{
+		"subscriptions": subscriptions,
+		"total":         len(subscriptions),
+		"pending":       ms.webhooks.Pending(),
+	}, nil
+}
+
+// handleWebhookDeadLetters lists deliveries that ran out of attempts
+func (ms *MemoryServer) handleWebhookDeadLetters(_ context.Context, params map[string]interface{}) (interface{}, error) {
+	subscriptionID, _ := params["subscription_id"].(string)
+	letters := ms.webhooks.DeadLetters(subscriptionID)
+	return map[string]interface{}{
+		"dead_letters": letters,
+		"total":        len(letters),
+	}, nil
+}
+
+// handleWebhookRedeliver queues a dead letter again
+func (ms *MemoryServer) handleWebhookRedeliver(_ context.Context, params map[string]interface{}) (interface{}, error) {
+	logging.Info("MCP TOOL: memory_system webhook_redeliver called", "params", params)
+
+	deliveryID, ok := params["delivery_id"].(string)
+	if !ok || deliveryID == "" {
+		return nil, errors.New("delivery_id parameter is required for webhook_redeliver. Use webhook_dead_letters to list them")
+	}
+	if err := ms.webhooks.Redeliver(deliveryID); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		StatusSuccess: true,
+		"delivery_id": deliveryID,
+		"status":      "queued",
+	}, nil
+}
+
+// handleCreateFromTemplate stores a memory built from a template, so the
+// chunk follows the template's structure instead of free text
+func (ms *MemoryServer) handleCreateFromTemplate(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	logging.Info("MCP TOOL: memory_create create_from_template called", "params", params)
+
+	templateManager := ms.container.GetTemplateManager()
+	if templateManager == nil {
+		return nil, errors.New("memory templates are not available")
+	}
+
+	templateID, ok := params["template_id"].(string)
+	if !ok || templateID == "" {
+		return nil, errors.New("template_id parameter is required for create_from_template. Use memory_read list_templates to see the available templates. Example: {\"template_id\": \"bug_fix\", \"session_id\": \"session-123\", \"fields\": {...}}")
+	}
+	sessionID, ok := params["session_id"].(string)
+	if !ok || sessionID == "" {
+		return nil, errors.New("session_id parameter is required for create_from_template")
+	}
+	fields, ok := params["fields"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("fields parameter is required for create_from_template and must be a JSON object keyed by template field name")
+	}
+
+	// Report every problem at once so the caller can fix them in one retry
+	validation := templateManager.ValidateInstance(templateID, fields)
+	if !validation.Valid {
+		problems := make([]string, 0, len(validation.Errors))
+		for _, validationErr := range validation.Errors {
+			problems = append(problems, validationErr.Field+": "+validationErr.Message)
+		}
+		return nil, fmt.Errorf("template %s validation failed: %s", templateID, strings.Join(problems, "; "))
+	}
+
+	metadata := ms.buildMetadataFromParams(params)
+	if err := ms.checkRepositoryEnabled(metadata.Repository); err != nil {
+		return nil, err
+	}
+
+	// Scrub the fields before they are rendered, embedded and kept in
+	// metadata
+	fields, redactions, err := ms.redactTemplateFields(ctx, metadata.Repository, fields)
+	if err != nil {
+		return nil, err
+	}
+	templateChunk, err := templateManager.CreateChunkFromTemplate(templateID, sessionID, fields, &metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chunk from template: %w", err)
+	}
+
+	// Summarize and embed the rendered content like any other memory, then
+	// keep the template's chunk type
+	repositoryScopedSessionID := ms.createRepositoryScopedSessionID(metadata.Repository, sessionID)
+	chunk, err := ms.container.GetChunkingService().CreateChunk(ctx, repositoryScopedSessionID, templateChunk.Content, &templateChunk.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chunk: %w", err)
+	}
+	chunk.Type = templateChunk.Type
+	markRedacted(chunk, redactions)
+
+	startTime := time.Now()
+	if err := ms.container.GetVectorStore().Store(ctx, chunk); err != nil {
+		ms.logStoreChunkAudit(ctx, chunk, sessionID, startTime, err)
+		return nil, fmt.Errorf("failed to store chunk: %w", err)
+	}
+	ms.logStoreChunkAudit(ctx, chunk, sessionID, startTime, nil)
+	ms.auditRedactions(ctx, metadata.Repository, chunk.ID, "memory_create_from_template", redactions, false)
+
+	logging.Info("create_from_template completed successfully", "chunk_id", chunk.ID, "template_id", templateID)
+	response := map[string]interface{}{
+		"chunk_id":    chunk.ID,
+		"template_id": templateID,
+		"type":        string(chunk.Type),
+		"tags":        chunk.Metadata.Tags,
+		"summary":     chunk.Summary,
+		"stored_at":   chunk.Timestamp.Format(time.RFC3339),
+	}
+	if len(validation.Warnings) > 0 {
+		response["warnings"] = validation.Warnings
+	}
+	if len(redactions) > 0 {
+		response["redactions"] = redactions
+	}
+	return response, nil
+}
+
+// handleListTemplates lists the available memory templates
+func (ms *MemoryServer) handleListTemplates(_ context.Context, params map[string]interface{}) (interface{}, error) {
+	templateManager := ms.container.GetTemplateManager()
+	if templateManager == nil {
+		return nil, errors.New("memory templates are not available")
+	}
+
+	chunkType, _ := params["chunk_type"].(string)
+	summaries := make([]map[string]interface{}, 0)
+	for _, template := range templateManager.ListTemplates() {
+		if chunkType != "" && string(template.ChunkType) != chunkType {
+			continue
+		}
+
+		required := make([]string, 0, len(template.RequiredFields))
+		for _, field := range template.RequiredFields {
+			required = append(required, field.Name)
+		}
+		summaries = append(summaries, map[string]interface{}{
+			"id":              template.ID,
+			"name":            template.Name,
+			"description":     template.Description,
+			"version":         template.Version,
+			"chunk_type":      string(template.ChunkType),
+			"required_fields": required,
+			"source":          template.Source,
+			"usage_count":     template.UsageCount,
+		})
+	}
+
+	return map[string]interface{}{
+		"templates": summaries,
+		"total":     len(summaries),
+		"message":   "Use get_template for field definitions, then memory_create create_from_template to store a memory",
+	}, nil
+}
+
+// handleGetTemplate returns a template with its full field definitions
+func (ms *MemoryServer) handleGetTemplate(_ context.Context, params map[string]interface{}) (interface{}, error) {
+	templateManager := ms.container.GetTemplateManager()
+	if templateManager == nil {
+		return nil, errors.New("memory templates are not available")
+	}
+
+	templateID, ok := params["template_id"].(string)
+	if !ok || templateID == "" {
+		return nil, errors.New("template_id parameter is required for get_template. Example: {\"template_id\": \"architectural_decision\", \"repository\": \"github.com/user/repo\"}")
+	}
+
+	template, err := templateManager.GetTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"template": template,
 	}, nil
 }
 
@@ -10737,6 +11491,10 @@ func (ms *MemoryServer) handleTodoWrite(ctx context.Context, args map[string]int
 	if err := ms.todoTracker.ProcessTodoWrite(ctx, sessionID, repository, todos); err != nil {
 		return nil, fmt.Errorf("failed to process todo write: %w", err)
 	}
+	ms.publishTaskEvent(websocket.ActionUpdated, repository, sessionID, map[string]interface{}{
+		"operation": "todo_write",
+		"todos":     todos,
+	})
 
 	return map[string]interface{}{
 		StatusSuccess: true,
@@ -10848,6 +11606,10 @@ func (ms *MemoryServer) handleTodoUpdate(ctx context.Context, args map[string]in
 	}
 
 	ms.todoTracker.ProcessToolUsage(sessionID, repository, toolName, toolContext)
+	ms.publishTaskEvent(websocket.ActionUpdated, repository, sessionID, map[string]interface{}{
+		"operation": "todo_update",
+		"tool_name": toolName,
+	})
 
 	return map[string]interface{}{
 		StatusSuccess: true,
@@ -10870,6 +11632,9 @@ func (ms *MemoryServer) handleSessionCreate(ctx context.Context, args map[string
 
 	// Create session by accessing it (auto-created in TodoTracker)
 	session := ms.todoTracker.GetOrCreateSession(sessionID, repository)
+	ms.publishTaskEvent(websocket.ActionCreated, repository, sessionID, map[string]interface{}{
+		"operation": "session_create",
+	})
 
 	return map[string]interface{}{
 		StatusSuccess: true,
@@ -10911,6 +11676,10 @@ func (ms *MemoryServer) handleSessionEnd(ctx context.Context, args map[string]in
 	}
 
 	ms.todoTracker.EndSession(sessionID, repository, outcome)
+	ms.publishTaskEvent(websocket.ActionUpdated, repository, sessionID, map[string]interface{}{
+		"operation": "session_end",
+		"outcome":   string(outcome),
+	})
 
 	return map[string]interface{}{
 		StatusSuccess: true,
@@ -0,0 +1,330 @@
+// Package metrics keeps labeled counters, gauges and histograms and exposes
+// them in the Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// MaxSeries caps the label combinations kept per metric, so label values
+// taken from requests cannot grow memory without bound. Observations for
+// further combinations are dropped.
+const MaxSeries = 10000
+
+// DefaultBuckets are histogram upper bounds in seconds, from 5ms to 30s
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Metric kinds as written in # TYPE lines
+const (
+	kindCounter   = "counter"
+	kindGauge     = "gauge"
+	kindHistogram = "histogram"
+)
+
+// Sample is one value of a metric computed at scrape time
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// Registry holds metrics and writes them in the Prometheus text format
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+	names    map[string]bool
+}
+
+// family is a metric and its series, one per label combination
+type family struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+	collect func() []Sample
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series is the state of one label combination
+type series struct {
+	labelValues []string
+	value       float64
+	counts      []uint64 // per bucket, not cumulative
+	count       uint64
+	sum         float64
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+func (r *Registry) register(f *family) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[f.name] {
+		panic("metrics: duplicate metric " + f.name)
+	}
+	r.names[f.name] = true
+	f.series = make(map[string]*series)
+	r.families = append(r.families, f)
+	return f
+}
+
+// Counter is a value that only goes up
+type Counter struct{ f *family }
+
+// NewCounter registers a counter with the given label names
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{r.register(&family{name: name, help: help, kind: kindCounter, labels: labels})}
+}
+
+// Inc adds one to the series for labelValues
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the series for labelValues
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	c.f.with(labelValues, func(s *series) { s.value += v })
+}
+
+// Value returns the current value of the series for labelValues
+func (c *Counter) Value(labelValues ...string) float64 {
+	return c.f.value(labelValues)
+}
+
+// Gauge is a value that can go up and down
+type Gauge struct{ f *family }
+
+// NewGauge registers a gauge with the given label names
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r.register(&family{name: name, help: help, kind: kindGauge, labels: labels})}
+}
+
+// Set sets the series for labelValues to v
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.f.with(labelValues, func(s *series) { s.value = v })
+}
+
+// Value returns the current value of the series for labelValues
+func (g *Gauge) Value(labelValues ...string) float64 {
+	return g.f.value(labelValues)
+}
+
+// NewGaugeFunc registers a gauge whose samples collect computes at every
+// scrape
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(&family{name: name, help: help, kind: kindGauge, labels: labels, collect: collect})
+}
+
+// Histogram counts observations into buckets
+type Histogram struct{ f *family }
+
+// NewHistogram registers a histogram with the given upper bounds, sorted
+// ascending, and label names
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{r.register(&family{name: name, help: help, kind: kindHistogram, labels: labels, buckets: buckets})}
+}
+
+// Observe records v in the series for labelValues
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.f.with(labelValues, func(s *series) {
+		for i, bound := range h.f.buckets {
+			if v <= bound {
+				s.counts[i]++
+				break
+			}
+		}
+		s.count++
+		s.sum += v
+	})
+}
+
+// Count returns the number of observations in the series for labelValues
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+	if s, ok := h.f.series[seriesKey(labelValues)]; ok {
+		return s.count
+	}
+	return 0
+}
+
+// with runs change on the series for labelValues, creating it if needed
+func (f *family) with(labelValues []string, change func(*series)) {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+	key := seriesKey(labelValues)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.series[key]
+	if !ok {
+		if len(f.series) >= MaxSeries {
+			return
+		}
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if f.kind == kindHistogram {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	change(s)
+}
+
+func (f *family) value(labelValues []string) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok := f.series[seriesKey(labelValues)]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func seriesKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+// WriteText writes every metric in the Prometheus text format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]*family(nil), r.families...)
+	r.mu.Unlock()
+
+	out := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(out)
+	}
+	return out.Flush()
+}
+
+// Handler serves the registry for scrapers
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.WriteText(w)
+	})
+}
+
+func (f *family) write(out *bufio.Writer) {
+	samples := f.snapshot()
+	if len(samples) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(out, "# TYPE %s %s\n", f.name, f.kind)
+
+	for _, s := range samples {
+		if f.kind != kindHistogram {
+			fmt.Fprintf(out, "%s%s %s\n", f.name, formatLabels(f.labels, s.labelValues, "", ""), formatValue(s.value))
+			continue
+		}
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(out, "%s_bucket%s %d\n", f.name, formatLabels(f.labels, s.labelValues, "le", formatValue(bound)), cumulative)
+		}
+		fmt.Fprintf(out, "%s_bucket%s %d\n", f.name, formatLabels(f.labels, s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(out, "%s_sum%s %s\n", f.name, formatLabels(f.labels, s.labelValues, "", ""), formatValue(s.sum))
+		fmt.Fprintf(out, "%s_count%s %d\n", f.name, formatLabels(f.labels, s.labelValues, "", ""), s.count)
+	}
+}
+
+// snapshot copies the series, sorted by label values so output is stable
+func (f *family) snapshot() []series {
+	var samples []series
+	if f.collect != nil {
+		for _, sample := range f.collect() {
+			if len(sample.LabelValues) == len(f.labels) {
+				samples = append(samples, series{labelValues: sample.LabelValues, value: sample.Value})
+			}
+		}
+	} else {
+		f.mu.Lock()
+		for _, s := range f.series {
+			copied := *s
+			copied.counts = append([]uint64(nil), s.counts...)
+			samples = append(samples, copied)
+		}
+		f.mu.Unlock()
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return seriesKey(samples[i].labelValues) < seriesKey(samples[j].labelValues)
+	})
+	return samples
+}
+
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(values[i]))
+		b.WriteByte('"')
+	}
+	if extraName != "" {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(extraName)
+		b.WriteString(`="`)
+		b.WriteString(extraValue)
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+func escapeHelp(v string) string {
+	return helpEscaper.Replace(v)
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T, registry *Registry) string {
+	t.Helper()
+	var out strings.Builder
+	require.NoError(t, registry.WriteText(&out))
+	return out.String()
+}
+
+func TestWriteText(t *testing.T) {
+	registry := NewRegistry()
+	calls := registry.NewCounter("test_calls_total", "Calls.", "tool", "status")
+	calls.Inc("memory_read", StatusSuccess)
+	calls.Add(2, "memory_create", StatusError)
+	calls.Add(-1, "memory_create", StatusError)
+	registry.NewGauge("test_unused", "Never set.")
+
+	text := scrape(t, registry)
+	assert.Equal(t, `# HELP test_calls_total Calls.
+# TYPE test_calls_total counter
+test_calls_total{tool="memory_create",status="error"} 2
+test_calls_total{tool="memory_read",status="success"} 1
+`, text, "series are sorted, negative adds ignored and empty metrics left out")
+
+	assert.Panics(t, func() { calls.Inc("memory_read") })
+	assert.Panics(t, func() { registry.NewCounter("test_calls_total", "Again.") })
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	registry := NewRegistry()
+	latency := registry.NewHistogram("test_duration_seconds", "Latency.", []float64{0.1, 1}, "operation")
+	latency.Observe(0.05, "search")
+	latency.Observe(0.5, "search")
+	latency.Observe(3, "search")
+
+	assert.Equal(t, uint64(3), latency.Count("search"))
+	assert.Contains(t, scrape(t, registry), `test_duration_seconds_bucket{operation="search",le="0.1"} 1
+test_duration_seconds_bucket{operation="search",le="1"} 2
+test_duration_seconds_bucket{operation="search",le="+Inf"} 3
+test_duration_seconds_sum{operation="search"} 3.55
+test_duration_seconds_count{operation="search"} 3
+`)
+}
+
+func TestLabelValuesAreEscaped(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounter("test_total", "Line one\nline two.", "repository").Inc("a\"b\\c\nd")
+
+	text := scrape(t, registry)
+	assert.Contains(t, text, `# HELP test_total Line one\nline two.`)
+	assert.Contains(t, text, `test_total{repository="a\"b\\c\nd"} 1`)
+}
+
+func TestSeriesAreCapped(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounter("test_total", "Capped.", "repository")
+	for i := 0; i <= MaxSeries; i++ {
+		counter.Inc(strconv.Itoa(i))
+	}
+	assert.InDelta(t, 1, counter.Value("0"), 0)
+	assert.InDelta(t, 0, counter.Value(strconv.Itoa(MaxSeries)), 0)
+}
+
+func TestCacheHitRatio(t *testing.T) {
+	CacheRequests.Inc("test_cache", CacheHit)
+	CacheRequests.Inc("test_cache", CacheHit)
+	CacheRequests.Inc("test_cache", CacheHit)
+	CacheRequests.Inc("test_cache", CacheMiss)
+
+	assert.Contains(t, scrape(t, Default), `mcp_memory_cache_hit_ratio{cache="test_cache"} 0.75`)
+}
+
+func TestHandler(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewGauge("test_up", "Up.").Set(1)
+
+	recorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, ContentType, recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), "test_up 1\n")
+
+	recorder = httptest.NewRecorder()
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/metrics", http.NoBody))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
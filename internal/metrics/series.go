@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"runtime"
+	"time"
+)
+
+// Default holds the server's metrics
+var Default = NewRegistry()
+
+// Result label values
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+	StatusDenied  = "denied"
+
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+// Series exposed on /metrics. Labels are shared across series: tool,
+// operation and repository for MCP calls, provider or backend for the
+// service behind an operation.
+var (
+	ToolCalls = Default.NewCounter("mcp_memory_tool_calls_total",
+		"MCP tool calls by tool, operation, repository and status.",
+		"tool", "operation", "repository", "status")
+	ToolDuration = Default.NewHistogram("mcp_memory_tool_duration_seconds",
+		"MCP tool call latency.", DefaultBuckets,
+		"tool", "operation", "repository")
+
+	EmbeddingDuration = Default.NewHistogram("mcp_memory_embedding_request_duration_seconds",
+		"Embedding API request latency; cache hits make no request.", DefaultBuckets,
+		"provider", "operation")
+	EmbeddingErrors = Default.NewCounter("mcp_memory_embedding_errors_total",
+		"Failed embedding API requests.",
+		"provider", "operation")
+
+	StorageDuration = Default.NewHistogram("mcp_memory_storage_operation_duration_seconds",
+		"Vector store operation latency.", DefaultBuckets,
+		"backend", "operation")
+
+	CacheRequests = Default.NewCounter("mcp_memory_cache_requests_total",
+		"Cache lookups by cache and result.",
+		"cache", "result")
+
+	DecayRuns = Default.NewCounter("mcp_memory_decay_runs_total",
+		"Decay and retention cleanup runs by operation, repository and status.",
+		"operation", "repository", "status")
+	DecayDeletedChunks = Default.NewCounter("mcp_memory_decay_deleted_chunks_total",
+		"Chunks deleted by decay and retention cleanup.",
+		"operation", "repository")
+
+	BulkJobs = Default.NewCounter("mcp_memory_bulk_jobs_total",
+		"Finished bulk and re-embedding jobs by operation and status.",
+		"operation", "status")
+)
+
+// startTime is when the process started serving metrics
+var startTime = time.Now()
+
+func init() {
+	Default.NewGaugeFunc("mcp_memory_cache_hit_ratio",
+		"Share of cache lookups that hit, since start.",
+		[]string{"cache"}, cacheHitRatios)
+	Default.NewGaugeFunc("mcp_memory_goroutines",
+		"Goroutines currently running.",
+		nil, func() []Sample { return []Sample{{Value: float64(runtime.NumGoroutine())}} })
+	Default.NewGaugeFunc("mcp_memory_heap_alloc_bytes",
+		"Bytes of allocated heap objects.",
+		nil, func() []Sample {
+			var memStats runtime.MemStats
+			runtime.ReadMemStats(&memStats)
+			return []Sample{{Value: float64(memStats.HeapAlloc)}}
+		})
+	Default.NewGaugeFunc("mcp_memory_start_time_seconds",
+		"Start time of the process since the Unix epoch.",
+		nil, func() []Sample { return []Sample{{Value: float64(startTime.Unix())}} })
+}
+
+// cacheHitRatios computes the hit ratio of every cache with lookups
+func cacheHitRatios() []Sample {
+	totals := make(map[string][2]float64)
+	for _, s := range CacheRequests.f.snapshot() {
+		cache, result := s.labelValues[0], s.labelValues[1]
+		counts := totals[cache]
+		if result == CacheHit {
+			counts[0] += s.value
+		}
+		counts[1] += s.value
+		totals[cache] = counts
+	}
+
+	samples := make([]Sample, 0, len(totals))
+	for cache, counts := range totals {
+		if counts[1] > 0 {
+			samples = append(samples, Sample{LabelValues: []string{cache}, Value: counts[0] / counts[1]})
+		}
+	}
+	return samples
+}
+
+// ObserveSince records the seconds elapsed since start in h
+func ObserveSince(h *Histogram, start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+// Status returns the status label for an error
+func Status(err error) string {
+	if err != nil {
+		return StatusError
+	}
+	return StatusSuccess
+}
@@ -16,25 +16,32 @@ import (
 	"strings"
 	"time"
 
+	"lerian-mcp-memory/internal/chains"
+	"lerian-mcp-memory/internal/threading"
 	"lerian-mcp-memory/pkg/types"
 )
 
 // BackupManager handles backup and restore operations
 type BackupManager struct {
 	storage       VectorStorage
+	threadStore   threading.ThreadStore
+	chainStore    chains.ChainStore
+	encryption    ChunkEncryption
 	backupDir     string
 	retentionDays int
 }
 
 // BackupMetadata contains information about a backup
 type BackupMetadata struct {
-	Version    string                 `json:"version"`
-	CreatedAt  time.Time              `json:"created_at"`
-	ChunkCount int                    `json:"chunk_count"`
-	Size       int64                  `json:"size"`
-	Checksum   string                 `json:"checksum"`
-	Repository string                 `json:"repository,omitempty"`
-	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Version     string                 `json:"version"`
+	CreatedAt   time.Time              `json:"created_at"`
+	ChunkCount  int                    `json:"chunk_count"`
+	ThreadCount int                    `json:"thread_count,omitempty"`
+	ChainCount  int                    `json:"chain_count,omitempty"`
+	Size        int64                  `json:"size"`
+	Checksum    string                 `json:"checksum"`
+	Repository  string                 `json:"repository,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // VectorStorage interface for backup operations
@@ -45,6 +52,13 @@ type VectorStorage interface {
 	ListCollections(ctx context.Context) ([]string, error)
 }
 
+// ChunkEncryption encrypts chunk fields so backups never hold plaintext
+// when encryption at rest is enabled
+type ChunkEncryption interface {
+	EncryptChunk(chunk *types.ConversationChunk) (*types.ConversationChunk, error)
+	DecryptChunk(chunk *types.ConversationChunk) error
+}
+
 // NewBackupManager creates a new backup manager
 func NewBackupManager(storage VectorStorage, backupDir string) *BackupManager {
 	return &BackupManager{
@@ -54,6 +68,29 @@ func NewBackupManager(storage VectorStorage, backupDir string) *BackupManager {
 	}
 }
 
+// SetThreadStore includes memory threads in backups and restores
+func (bm *BackupManager) SetThreadStore(store threading.ThreadStore) {
+	bm.threadStore = store
+}
+
+// SetChainStore includes memory chains in backups and restores
+func (bm *BackupManager) SetChainStore(store chains.ChainStore) {
+	bm.chainStore = store
+}
+
+// SetChunkEncryption writes chunks to backups in encrypted form and decrypts
+// them on restore, so the store re-encrypts them with its active key
+func (bm *BackupManager) SetChunkEncryption(encryption ChunkEncryption) {
+	bm.encryption = encryption
+}
+
+// backupContents is everything written to a single backup archive
+type backupContents struct {
+	chunks  []types.ConversationChunk
+	threads []*threading.MemoryThread
+	chains  []*chains.MemoryChain
+}
+
 // CreateBackup creates a complete backup of all data
 func (bm *BackupManager) CreateBackup(ctx context.Context, repository string) (*BackupMetadata, error) {
 	backupFile, err := bm.prepareBackupFile(repository)
@@ -66,12 +103,23 @@ func (bm *BackupManager) CreateBackup(ctx context.Context, repository string) (*
 		return nil, err
 	}
 
-	err = bm.writeBackupArchive(backupFile, chunks)
+	threads, err := bm.getThreadsForBackup(ctx, repository)
 	if err != nil {
 		return nil, err
 	}
 
-	metadata, err := bm.createBackupMetadata(backupFile, repository, len(chunks))
+	memoryChains, err := bm.getChainsForBackup(ctx, repository, chunks)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := &backupContents{chunks: chunks, threads: threads, chains: memoryChains}
+	err = bm.writeBackupArchive(backupFile, contents)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := bm.createBackupMetadata(backupFile, repository, contents)
 	if err != nil {
 		return nil, err
 	}
@@ -106,6 +154,66 @@ func (bm *BackupManager) getChunksForBackup(ctx context.Context, repository stri
 	return chunks, nil
 }
 
+// getThreadsForBackup retrieves threads for backup, limited to the repository
+// when one is given
+func (bm *BackupManager) getThreadsForBackup(ctx context.Context, repository string) ([]*threading.MemoryThread, error) {
+	if bm.threadStore == nil {
+		return nil, nil
+	}
+
+	var filters threading.ThreadFilters
+	if repository != "" {
+		filters.Repository = &repository
+	}
+
+	threads, err := bm.threadStore.ListThreads(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve threads: %w", err)
+	}
+	return threads, nil
+}
+
+// getChainsForBackup retrieves chains for backup. Chains have no repository,
+// so a repository backup keeps the chains that reference its chunks.
+func (bm *BackupManager) getChainsForBackup(ctx context.Context, repository string, chunks []types.ConversationChunk) ([]*chains.MemoryChain, error) {
+	if bm.chainStore == nil {
+		return nil, nil
+	}
+
+	var all []*chains.MemoryChain
+	const pageSize = 500
+	for offset := 0; ; offset += pageSize {
+		page, err := bm.chainStore.ListChains(ctx, pageSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve chains: %w", err)
+		}
+		all = append(all, page...)
+		if len(page) < pageSize {
+			break
+		}
+	}
+
+	if repository == "" {
+		return all, nil
+	}
+
+	chunkIDs := make(map[string]bool, len(chunks))
+	for i := range chunks {
+		chunkIDs[chunks[i].ID] = true
+	}
+
+	filtered := make([]*chains.MemoryChain, 0)
+	for _, chain := range all {
+		for _, id := range chain.ChunkIDs {
+			if chunkIDs[id] {
+				filtered = append(filtered, chain)
+				break
+			}
+		}
+	}
+	return filtered, nil
+}
+
 // filterChunksByRepository filters chunks by repository
 func (bm *BackupManager) filterChunksByRepository(chunks []types.ConversationChunk, repository string) []types.ConversationChunk {
 	filteredChunks := make([]types.ConversationChunk, 0)
@@ -118,7 +226,7 @@ func (bm *BackupManager) filterChunksByRepository(chunks []types.ConversationChu
 }
 
 // writeBackupArchive creates and writes the backup archive
-func (bm *BackupManager) writeBackupArchive(backupFile string, chunks []types.ConversationChunk) error {
+func (bm *BackupManager) writeBackupArchive(backupFile string, contents *backupContents) error {
 	file, err := os.Create(backupFile) // #nosec G304 -- Path is cleaned and safe
 	if err != nil {
 		return fmt.Errorf("failed to create backup file: %w", err)
@@ -143,13 +251,60 @@ func (bm *BackupManager) writeBackupArchive(backupFile string, chunks []types.Co
 		}
 	}()
 
-	return bm.writeChunksToTar(tarWriter, chunks)
+	if err := bm.writeChunksToTar(tarWriter, contents.chunks); err != nil {
+		return err
+	}
+
+	for _, thread := range contents.threads {
+		if err := writeJSONToTar(tarWriter, fmt.Sprintf("threads/thread_%s.json", thread.ID), thread); err != nil {
+			return fmt.Errorf("failed to write thread %s: %w", thread.ID, err)
+		}
+	}
+
+	for _, chain := range contents.chains {
+		if err := writeJSONToTar(tarWriter, fmt.Sprintf("chains/chain_%s.json", chain.ID), chain); err != nil {
+			return fmt.Errorf("failed to write chain %s: %w", chain.ID, err)
+		}
+	}
+	return nil
+}
+
+// writeJSONToTar writes value as an indented JSON entry
+func writeJSONToTar(tarWriter *tar.Writer, name string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+
+	header := &tar.Header{
+		Name: name,
+		Size: int64(len(data)),
+		Mode: 0o644,
+	}
+
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write tar header: %w", err)
+	}
+
+	if _, err := tarWriter.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
 }
 
 // writeChunksToTar writes chunks to the tar archive
 func (bm *BackupManager) writeChunksToTar(tarWriter *tar.Writer, chunks []types.ConversationChunk) error {
 	for i := range chunks {
-		chunkData, err := json.MarshalIndent(chunks[i], "", "  ")
+		chunk := &chunks[i]
+		if bm.encryption != nil {
+			encrypted, err := bm.encryption.EncryptChunk(chunk)
+			if err != nil {
+				return err
+			}
+			chunk = encrypted
+		}
+
+		chunkData, err := json.MarshalIndent(chunk, "", "  ")
 		if err != nil {
 			return fmt.Errorf("failed to marshal chunk %s: %w", chunks[i].ID, err)
 		}
@@ -172,18 +327,20 @@ func (bm *BackupManager) writeChunksToTar(tarWriter *tar.Writer, chunks []types.
 }
 
 // createBackupMetadata creates and saves backup metadata
-func (bm *BackupManager) createBackupMetadata(backupFile, repository string, chunkCount int) (*BackupMetadata, error) {
+func (bm *BackupManager) createBackupMetadata(backupFile, repository string, contents *backupContents) (*BackupMetadata, error) {
 	stat, err := os.Stat(backupFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get file stats: %w", err)
 	}
 
 	metadata := &BackupMetadata{
-		Version:    getEnv("MCP_MEMORY_BACKUP_VERSION", "1.0"),
-		CreatedAt:  time.Now(),
-		ChunkCount: chunkCount,
-		Size:       stat.Size(),
-		Repository: repository,
+		Version:     getEnv("MCP_MEMORY_BACKUP_VERSION", "1.0"),
+		CreatedAt:   time.Now(),
+		ChunkCount:  len(contents.chunks),
+		ThreadCount: len(contents.threads),
+		ChainCount:  len(contents.chains),
+		Size:        stat.Size(),
+		Repository:  repository,
 		Metadata: map[string]interface{}{
 			"backup_file": backupFile,
 			"compression": "gzip",
@@ -218,12 +375,21 @@ func (bm *BackupManager) RestoreBackup(ctx context.Context, backupFile string, o
 	}
 	defer closeFunc()
 
-	restoredCount, err := bm.restoreChunksFromTar(ctx, tarReader)
+	restored, err := bm.restoreEntriesFromTar(ctx, tarReader)
 	if err != nil {
 		return err
 	}
 
-	return bm.validateRestoredCount(restoredCount, metadata.ChunkCount)
+	if err := bm.validateRestoredCount(restored.chunks, metadata.ChunkCount); err != nil {
+		return err
+	}
+	if bm.threadStore != nil && restored.threads != metadata.ThreadCount {
+		return fmt.Errorf("thread count mismatch: expected %d, restored %d", metadata.ThreadCount, restored.threads)
+	}
+	if bm.chainStore != nil && restored.chains != metadata.ChainCount {
+		return fmt.Errorf("chain count mismatch: expected %d, restored %d", metadata.ChainCount, restored.chains)
+	}
+	return nil
 }
 
 // prepareBackupPath validates and normalizes the backup file path
@@ -276,34 +442,77 @@ func (bm *BackupManager) createTarReader(backupPath string) (*tar.Reader, func()
 	return tarReader, closeFunc, nil
 }
 
-// restoreChunksFromTar reads and restores chunks from tar archive
-func (bm *BackupManager) restoreChunksFromTar(ctx context.Context, tarReader *tar.Reader) (int, error) {
-	restoredCount := 0
+// restoredCounts tallies the entries restored from an archive
+type restoredCounts struct {
+	chunks  int
+	threads int
+	chains  int
+}
+
+// restoreEntriesFromTar reads and restores chunks, threads and chains from
+// the tar archive. Threads and chains are skipped when no store is set.
+func (bm *BackupManager) restoreEntriesFromTar(ctx context.Context, tarReader *tar.Reader) (restoredCounts, error) {
+	var restored restoredCounts
 	for {
 		header, err := tarReader.Next()
 		if errors.Is(err, io.EOF) {
 			break
 		}
 		if err != nil {
-			return 0, fmt.Errorf("failed to read tar header: %w", err)
+			return restoredCounts{}, fmt.Errorf("failed to read tar header: %w", err)
 		}
 
-		if !strings.HasPrefix(header.Name, "chunks/") {
-			continue
-		}
+		switch {
+		case strings.HasPrefix(header.Name, "chunks/"):
+			chunk, err := bm.readAndUnmarshalChunk(tarReader, header.Size)
+			if err != nil {
+				return restoredCounts{}, err
+			}
+			if bm.encryption != nil {
+				if err := bm.encryption.DecryptChunk(&chunk); err != nil {
+					return restoredCounts{}, err
+				}
+			}
 
-		chunk, err := bm.readAndUnmarshalChunk(tarReader, header.Size)
-		if err != nil {
-			return 0, err
-		}
+			if err := bm.storage.StoreChunk(ctx, &chunk); err != nil {
+				return restoredCounts{}, fmt.Errorf("failed to store chunk %s: %w", chunk.ID, err)
+			}
+			restored.chunks++
+		case strings.HasPrefix(header.Name, "threads/") && bm.threadStore != nil:
+			var thread threading.MemoryThread
+			if err := readJSONFromTar(tarReader, header.Size, &thread); err != nil {
+				return restoredCounts{}, err
+			}
 
-		if err := bm.storage.StoreChunk(ctx, &chunk); err != nil {
-			return 0, fmt.Errorf("failed to store chunk %s: %w", chunk.ID, err)
+			if err := bm.threadStore.StoreThread(ctx, &thread); err != nil {
+				return restoredCounts{}, fmt.Errorf("failed to store thread %s: %w", thread.ID, err)
+			}
+			restored.threads++
+		case strings.HasPrefix(header.Name, "chains/") && bm.chainStore != nil:
+			var chain chains.MemoryChain
+			if err := readJSONFromTar(tarReader, header.Size, &chain); err != nil {
+				return restoredCounts{}, err
+			}
+
+			if err := bm.chainStore.StoreChain(ctx, &chain); err != nil {
+				return restoredCounts{}, fmt.Errorf("failed to store chain %s: %w", chain.ID, err)
+			}
+			restored.chains++
 		}
+	}
+	return restored, nil
+}
 
-		restoredCount++
+// readJSONFromTar reads the current tar entry into value
+func readJSONFromTar(tarReader *tar.Reader, size int64, value interface{}) error {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(tarReader, data); err != nil {
+		return fmt.Errorf("failed to read backup entry: %w", err)
 	}
-	return restoredCount, nil
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("failed to unmarshal backup entry: %w", err)
+	}
+	return nil
 }
 
 // readAndUnmarshalChunk reads chunk data from tar and unmarshals it
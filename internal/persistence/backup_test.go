@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"lerian-mcp-memory/internal/chains"
+	"lerian-mcp-memory/internal/threading"
 	"lerian-mcp-memory/pkg/types"
 
 	"github.com/stretchr/testify/assert"
@@ -225,6 +228,114 @@ func TestBackupManager_RestoreBackup(t *testing.T) {
 	}
 }
 
+// sealingEncryption marks content and summary so tests can tell encrypted
+// chunks apart
+type sealingEncryption struct{}
+
+func (sealingEncryption) EncryptChunk(chunk *types.ConversationChunk) (*types.ConversationChunk, error) {
+	sealed := *chunk
+	sealed.Content = "sealed:" + chunk.Content
+	sealed.Summary = "sealed:" + chunk.Summary
+	return &sealed, nil
+}
+
+func (sealingEncryption) DecryptChunk(chunk *types.ConversationChunk) error {
+	chunk.Content = strings.TrimPrefix(chunk.Content, "sealed:")
+	chunk.Summary = strings.TrimPrefix(chunk.Summary, "sealed:")
+	return nil
+}
+
+func TestBackupManager_ChunkEncryption(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+
+	storage1 := NewMockVectorStorage()
+	storage1.chunks = createTestChunks()
+	bm1 := NewBackupManager(storage1, tempDir)
+	bm1.SetChunkEncryption(sealingEncryption{})
+
+	metadata, err := bm1.CreateBackup(ctx, "test-repo")
+	require.NoError(t, err)
+	backupFile, ok := metadata.Metadata["backup_file"].(string)
+	require.True(t, ok)
+
+	// The live chunks are not modified by the backup
+	assert.Equal(t, "Test content 1", storage1.chunks[0].Content)
+
+	// The archive only holds encrypted chunks
+	raw := NewMockVectorStorage()
+	require.NoError(t, NewBackupManager(raw, tempDir).RestoreBackup(ctx, backupFile, false))
+	require.Len(t, raw.chunks, 2)
+	for _, chunk := range raw.chunks {
+		assert.True(t, strings.HasPrefix(chunk.Content, "sealed:"))
+		assert.True(t, strings.HasPrefix(chunk.Summary, "sealed:"))
+	}
+
+	// Restoring with encryption hands plaintext to the store, which
+	// encrypts it with its own active key
+	storage2 := NewMockVectorStorage()
+	bm2 := NewBackupManager(storage2, tempDir)
+	bm2.SetChunkEncryption(sealingEncryption{})
+	require.NoError(t, bm2.RestoreBackup(ctx, backupFile, false))
+	require.Len(t, storage2.chunks, 2)
+	for _, chunk := range storage2.chunks {
+		assert.True(t, strings.HasPrefix(chunk.Content, "Test content"))
+	}
+}
+
+func TestBackupManager_ThreadsAndChains(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+
+	storage1 := NewMockVectorStorage()
+	storage1.chunks = createTestChunks()
+	threads1 := threading.NewInMemoryThreadStore()
+	chains1 := chains.NewInMemoryChainStore()
+	require.NoError(t, threads1.StoreThread(ctx, &threading.MemoryThread{
+		ID: "thread-1", Repository: "test-repo", Status: threading.ThreadStatusActive, ChunkIDs: []string{"chunk1"},
+	}))
+	require.NoError(t, threads1.StoreThread(ctx, &threading.MemoryThread{
+		ID: "thread-2", Repository: "another-repo", Status: threading.ThreadStatusActive,
+	}))
+	require.NoError(t, chains1.StoreChain(ctx, &chains.MemoryChain{ID: "chain-1", ChunkIDs: []string{"chunk1", "chunk2"}}))
+	require.NoError(t, chains1.StoreChain(ctx, &chains.MemoryChain{ID: "chain-2", ChunkIDs: []string{"chunk3"}}))
+
+	bm1 := NewBackupManager(storage1, tempDir)
+	bm1.SetThreadStore(threads1)
+	bm1.SetChainStore(chains1)
+
+	// Repository backups keep that repository's threads and the chains
+	// that reference its chunks
+	metadata, err := bm1.CreateBackup(ctx, "test-repo")
+	require.NoError(t, err)
+	assert.Equal(t, 2, metadata.ChunkCount)
+	assert.Equal(t, 1, metadata.ThreadCount)
+	assert.Equal(t, 1, metadata.ChainCount)
+
+	backupFile, ok := metadata.Metadata["backup_file"].(string)
+	require.True(t, ok)
+
+	threads2 := threading.NewInMemoryThreadStore()
+	chains2 := chains.NewInMemoryChainStore()
+	bm2 := NewBackupManager(NewMockVectorStorage(), tempDir)
+	bm2.SetThreadStore(threads2)
+	bm2.SetChainStore(chains2)
+
+	require.NoError(t, bm2.RestoreBackup(ctx, backupFile, false))
+
+	thread, err := threads2.GetThread(ctx, "thread-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chunk1"}, thread.ChunkIDs)
+	_, err = threads2.GetThread(ctx, "thread-2")
+	assert.Error(t, err)
+
+	chain, err := chains2.GetChain(ctx, "chain-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chunk1", "chunk2"}, chain.ChunkIDs)
+	_, err = chains2.GetChain(ctx, "chain-2")
+	assert.Error(t, err)
+}
+
 func TestBackupManager_RestoreBackup_Errors(t *testing.T) {
 	ctx := context.Background()
 	tempDir := t.TempDir()
@@ -6,9 +6,13 @@ import (
 	"crypto/rand"
 	"errors"
 	"fmt"
+	"lerian-mcp-memory/internal/tracing"
 	"math"
 	"math/big"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Config holds retry configuration
@@ -74,11 +78,18 @@ func (r *Retrier) Do(ctx context.Context, op Operation) *Result {
 	}, nil)
 }
 
-// DoWithData executes the operation with retries and passes data through attempts
+// DoWithData executes the operation with retries and passes data through
+// attempts. The attempts share a span; each failed one is an event on it.
 func (r *Retrier) DoWithData(ctx context.Context, op func(context.Context, interface{}) error, data interface{}) *Result {
 	start := time.Now()
 	result := &Result{Attempts: 0}
 
+	ctx, span := tracing.Start(ctx, "retry")
+	defer func() {
+		span.SetAttributes(attribute.Int("retry.attempts", result.Attempts))
+		tracing.End(span, result.Err)
+	}()
+
 	var lastErr error
 	delay := r.config.InitialDelay
 
@@ -101,6 +112,10 @@ retryLoop:
 		}
 
 		lastErr = err
+		span.AddEvent("attempt failed", trace.WithAttributes(
+			attribute.Int("retry.attempt", attempt),
+			attribute.String("error", err.Error()),
+		))
 
 		// Check if we should retry
 		if !r.config.RetryIf(err) {
@@ -130,6 +145,25 @@ retryLoop:
 	return result
 }
 
+// ShouldRetry reports whether an operation that failed with err on the
+// given attempt (starting at 1) may be tried again. Callers that persist
+// their attempts use it with Backoff instead of Do.
+func (r *Retrier) ShouldRetry(attempt int, err error) bool {
+	if !r.config.RetryIf(err) {
+		return false
+	}
+	return r.config.MaxAttempts == 0 || attempt < r.config.MaxAttempts
+}
+
+// Backoff returns the jittered delay to wait after the given failed attempt
+func (r *Retrier) Backoff(attempt int) time.Duration {
+	delay := r.config.InitialDelay
+	for i := 1; i < attempt; i++ {
+		delay = r.nextDelay(delay)
+	}
+	return r.calculateDelay(delay)
+}
+
 // calculateDelay adds jitter to the delay
 func (r *Retrier) calculateDelay(delay time.Duration) time.Duration {
 	if r.config.RandomizeFactor == 0 {
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -31,9 +32,12 @@ type Permission struct {
 	ExpiresAt *time.Time  `json:"expires_at,omitempty"`
 }
 
-// AccessToken represents an authentication token
+// AccessToken represents an authentication token. Only a hash of the token
+// is kept; the token itself is returned once, when it is generated.
 type AccessToken struct {
-	Token     string    `json:"token"`
+	ID        string    `json:"id"`
+	Token     string    `json:"token,omitempty"`
+	TokenHash string    `json:"token_hash,omitempty"`
 	UserID    string    `json:"user_id"`
 	Scope     []string  `json:"scope"`
 	ExpiresAt time.Time `json:"expires_at"`
@@ -55,11 +59,16 @@ type User struct {
 
 // AccessControlManager manages access control and permissions
 type AccessControlManager struct {
+	mu           sync.RWMutex
 	users        map[string]*User
-	tokens       map[string]*AccessToken
+	tokens       map[string]*AccessToken // keyed by token hash
 	repositories map[string]*Repository
 	policies     []AccessPolicy
 	enabled      bool
+
+	// File persistence, see OpenAccessControlManager
+	statePath    string
+	stateModTime time.Time
 }
 
 // Repository represents a repository with access controls
@@ -129,6 +138,11 @@ func (acm *AccessControlManager) CreateUser(username, email string) (*User, erro
 		return nil, errors.New("username and email are required")
 	}
 
+	acm.refresh()
+
+	acm.mu.Lock()
+	defer acm.mu.Unlock()
+
 	// Check if user already exists
 	for _, user := range acm.users {
 		if user.Username == username || user.Email == email {
@@ -149,11 +163,20 @@ func (acm *AccessControlManager) CreateUser(username, email string) (*User, erro
 	}
 
 	acm.users[userID] = user
+	if err := acm.saveLocked(); err != nil {
+		return nil, err
+	}
 	return user, nil
 }
 
-// GenerateToken generates an access token for a user
+// GenerateToken generates an access token for a user. The returned token
+// is the only copy of the token string.
 func (acm *AccessControlManager) GenerateToken(userID string, scope []string, duration time.Duration) (*AccessToken, error) {
+	acm.refresh()
+
+	acm.mu.Lock()
+	defer acm.mu.Unlock()
+
 	user, exists := acm.users[userID]
 	if !exists {
 		return nil, errors.New("user not found")
@@ -164,47 +187,69 @@ func (acm *AccessControlManager) GenerateToken(userID string, scope []string, du
 	}
 
 	tokenString := generateSecureToken()
+	tokenHash := hashToken(tokenString)
 	token := &AccessToken{
-		Token:     tokenString,
+		ID:        tokenHash[:tokenIDLength],
+		TokenHash: tokenHash,
 		UserID:    userID,
 		Scope:     scope,
 		ExpiresAt: time.Now().Add(duration),
 		CreatedAt: time.Now(),
 	}
 
-	acm.tokens[tokenString] = token
+	acm.tokens[tokenHash] = token
 
 	// Update user last login
 	now := time.Now()
 	user.LastLogin = &now
 
-	return token, nil
+	if err := acm.saveLocked(); err != nil {
+		delete(acm.tokens, tokenHash)
+		return nil, err
+	}
+
+	issued := *token
+	issued.Token = tokenString
+	return &issued, nil
 }
 
 // ValidateToken validates an access token
 func (acm *AccessControlManager) ValidateToken(tokenString string) (*AccessToken, error) {
-	token, exists := acm.tokens[tokenString]
+	acm.refresh()
+
+	acm.mu.Lock()
+	defer acm.mu.Unlock()
+
+	tokenHash := hashToken(tokenString)
+	token, exists := acm.tokens[tokenHash]
 	if !exists {
 		return nil, errors.New("invalid token")
 	}
 
 	if time.Now().After(token.ExpiresAt) {
-		delete(acm.tokens, tokenString)
+		delete(acm.tokens, tokenHash)
 		return nil, errors.New("token expired")
 	}
 
 	// Check if user is still active
 	user, exists := acm.users[token.UserID]
 	if !exists || !user.IsActive {
-		delete(acm.tokens, tokenString)
+		delete(acm.tokens, tokenHash)
 		return nil, errors.New("user not active")
 	}
 
-	return token, nil
+	validated := *token
+	validated.Token = tokenString
+	return &validated, nil
 }
 
 // CheckAccess checks if a user has access to perform an action on a resource
 func (acm *AccessControlManager) CheckAccess(ctx context.Context, userID, action, resource string) (bool, error) {
+	acm.refresh()
+
+	acm.mu.RLock()
+	defer acm.mu.RUnlock()
+
 	if !acm.enabled {
 		return true, nil
 	}
@@ -261,6 +306,16 @@ func (acm *AccessControlManager) CheckAccess(ctx context.Context, userID, action
 
 // GrantPermission grants a permission to a user
 func (acm *AccessControlManager) GrantPermission(userID string, permission Permission) error {
+	acm.mu.Lock()
+	defer acm.mu.Unlock()
+
+	if err := acm.grantPermissionLocked(userID, permission); err != nil {
+		return err
+	}
+	return acm.saveLocked()
+}
+
+func (acm *AccessControlManager) grantPermissionLocked(userID string, permission Permission) error {
 	user, exists := acm.users[userID]
 	if !exists {
 		return errors.New("user not found")
@@ -282,6 +337,9 @@ func (acm *AccessControlManager) GrantPermission(userID string, permission Permi
 
 // RevokePermission revokes a permission from a user
 func (acm *AccessControlManager) RevokePermission(userID, resource, action string) error {
+	acm.mu.Lock()
+	defer acm.mu.Unlock()
+
 	user, exists := acm.users[userID]
 	if !exists {
 		return errors.New("user not found")
@@ -291,7 +349,7 @@ func (acm *AccessControlManager) RevokePermission(userID, resource, action strin
 	for i, permission := range user.Permissions {
 		if permission.Resource == resource && permission.Action == action {
 			user.Permissions = append(user.Permissions[:i], user.Permissions[i+1:]...)
-			return nil
+			return acm.saveLocked()
 		}
 	}
 
@@ -300,6 +358,17 @@ func (acm *AccessControlManager) RevokePermission(userID, resource, action strin
 
 // CreateRepository creates a repository with access controls
 func (acm *AccessControlManager) CreateRepository(name, owner string, isPublic bool) (*Repository, error) {
+	acm.mu.Lock()
+	defer acm.mu.Unlock()
+
+	repo := acm.createRepositoryLocked(name, owner, isPublic)
+	if err := acm.saveLocked(); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func (acm *AccessControlManager) createRepositoryLocked(name, owner string, isPublic bool) *Repository {
 	repoID := generateSecureID()
 	repo := &Repository{
 		ID:           repoID,
@@ -312,33 +381,39 @@ func (acm *AccessControlManager) CreateRepository(name, owner string, isPublic b
 	}
 
 	acm.repositories[repoID] = repo
-	return repo, nil
+	return repo
 }
 
-// GrantRepositoryAccess grants access to a repository
+// GrantRepositoryAccess grants access to a repository. Granting again
+// changes the user's access level.
 func (acm *AccessControlManager) GrantRepositoryAccess(repoID, userID string, level AccessLevel) error {
+	acm.mu.Lock()
+	defer acm.mu.Unlock()
+
+	if err := acm.grantRepositoryAccessLocked(repoID, userID, level); err != nil {
+		return err
+	}
+	return acm.saveLocked()
+}
+
+func (acm *AccessControlManager) grantRepositoryAccessLocked(repoID, userID string, level AccessLevel) error {
 	repo, exists := acm.repositories[repoID]
 	if !exists {
 		return errors.New("repository not found")
 	}
 
-	// Check if user already has access
-	for _, allowedUser := range repo.AllowedUsers {
-		if allowedUser == userID {
-			return nil // User already has access
-		}
+	if !acm.sliceContainsString(repo.AllowedUsers, userID) {
+		repo.AllowedUsers = append(repo.AllowedUsers, userID)
 	}
 
-	repo.AllowedUsers = append(repo.AllowedUsers, userID)
-
 	// Grant specific permission
 	permission := Permission{
-		Resource: "repository:" + repoID,
+		Resource: repositoryResourcePrefix + repoID,
 		Action:   "*",
 		Level:    level,
 	}
 
-	return acm.GrantPermission(userID, permission)
+	return acm.grantPermissionLocked(userID, permission)
 }
 
 // Helper methods
@@ -354,6 +429,11 @@ func (acm *AccessControlManager) matchesPermission(permission Permission, action
 		return false
 	}
 
+	// Level-named actions need a permission of at least that level
+	if required, gated := accessLevelRank[AccessLevel(action)]; gated && permission.Level != "" {
+		return accessLevelRank[permission.Level] >= required
+	}
+
 	return true
 }
 
@@ -499,15 +579,26 @@ func generateSecureToken() string {
 	return hex.EncodeToString(hash[:])
 }
 
+func hashToken(tokenString string) string {
+	hash := sha256.Sum256([]byte(tokenString))
+	return hex.EncodeToString(hash[:])
+}
+
 // Enable turns on access control for the manager
 func (acm *AccessControlManager) Enable() {
+	acm.mu.Lock()
+	defer acm.mu.Unlock()
 	acm.enabled = true
 }
 
 func (acm *AccessControlManager) Disable() {
+	acm.mu.Lock()
+	defer acm.mu.Unlock()
 	acm.enabled = false
 }
 
 func (acm *AccessControlManager) IsEnabled() bool {
+	acm.mu.RLock()
+	defer acm.mu.RUnlock()
 	return acm.enabled
 }
@@ -0,0 +1,403 @@
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// tokenIDLength is the number of hash characters used as a token's ID
+	tokenIDLength = 12
+
+	// repositoryResourcePrefix prefixes repository IDs in permission resources
+	repositoryResourcePrefix = "repository:"
+
+	// GlobalResource grants access to every repository
+	GlobalResource = "*"
+
+	// DefaultTokenLifetime is the lifetime of tokens issued without one
+	DefaultTokenLifetime = 90 * 24 * time.Hour
+)
+
+// accessLevelRank orders the access levels that gate actions
+var accessLevelRank = map[AccessLevel]int{
+	AccessLevelNone:  0,
+	AccessLevelRead:  1,
+	AccessLevelWrite: 2,
+	AccessLevelAdmin: 3,
+}
+
+// accessControlState is the on-disk form of users, tokens and grants
+type accessControlState struct {
+	Users        []*User        `json:"users"`
+	Tokens       []*AccessToken `json:"tokens"`
+	Repositories []*Repository  `json:"repositories"`
+	Policies     []AccessPolicy `json:"policies"`
+}
+
+// OpenAccessControlManager creates an access control manager that keeps its
+// users, tokens and grants in a JSON file at path. Changes made by another
+// process, such as the admin CLI, are picked up when the file changes.
+func OpenAccessControlManager(path string) (*AccessControlManager, error) {
+	if path == "" {
+		return nil, errors.New("access control file path is required")
+	}
+
+	acm := NewAccessControlManager()
+	acm.statePath = path
+
+	acm.mu.Lock()
+	defer acm.mu.Unlock()
+	if err := acm.loadLocked(); err != nil {
+		return nil, err
+	}
+	return acm, nil
+}
+
+// loadLocked replaces the in-memory state with the file contents. A missing
+// file is an empty state.
+func (acm *AccessControlManager) loadLocked() error {
+	info, err := os.Stat(acm.statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat access control file: %w", err)
+	}
+
+	data, err := os.ReadFile(acm.statePath)
+	if err != nil {
+		return fmt.Errorf("failed to read access control file: %w", err)
+	}
+
+	var state accessControlState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to decode access control file: %w", err)
+	}
+
+	acm.users = make(map[string]*User, len(state.Users))
+	for _, user := range state.Users {
+		acm.users[user.ID] = user
+	}
+	acm.tokens = make(map[string]*AccessToken, len(state.Tokens))
+	for _, token := range state.Tokens {
+		acm.tokens[token.TokenHash] = token
+	}
+	acm.repositories = make(map[string]*Repository, len(state.Repositories))
+	for _, repo := range state.Repositories {
+		acm.repositories[repo.ID] = repo
+	}
+	acm.policies = state.Policies
+	if acm.policies == nil {
+		acm.policies = make([]AccessPolicy, 0)
+	}
+
+	acm.stateModTime = info.ModTime()
+	return nil
+}
+
+// saveLocked writes the state to the access control file, if there is one
+func (acm *AccessControlManager) saveLocked() error {
+	if acm.statePath == "" {
+		return nil
+	}
+
+	state := accessControlState{
+		Users:        make([]*User, 0, len(acm.users)),
+		Tokens:       make([]*AccessToken, 0, len(acm.tokens)),
+		Repositories: make([]*Repository, 0, len(acm.repositories)),
+		Policies:     acm.policies,
+	}
+	for _, user := range acm.users {
+		state.Users = append(state.Users, user)
+	}
+	for _, token := range acm.tokens {
+		state.Tokens = append(state.Tokens, token)
+	}
+	for _, repo := range acm.repositories {
+		state.Repositories = append(state.Repositories, repo)
+	}
+	sort.Slice(state.Users, func(i, j int) bool { return state.Users[i].ID < state.Users[j].ID })
+	sort.Slice(state.Tokens, func(i, j int) bool { return state.Tokens[i].ID < state.Tokens[j].ID })
+	sort.Slice(state.Repositories, func(i, j int) bool { return state.Repositories[i].ID < state.Repositories[j].ID })
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode access control state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(acm.statePath), 0o700); err != nil {
+		return fmt.Errorf("failed to create access control directory: %w", err)
+	}
+
+	// Write to a temporary file and rename, so readers never see a partial file
+	tmpPath := acm.statePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write access control file: %w", err)
+	}
+	if err := os.Rename(tmpPath, acm.statePath); err != nil {
+		return fmt.Errorf("failed to replace access control file: %w", err)
+	}
+
+	if info, err := os.Stat(acm.statePath); err == nil {
+		acm.stateModTime = info.ModTime()
+	}
+	return nil
+}
+
+// refresh reloads the access control file when another process changed it.
+// The current state is kept if the file cannot be read.
+func (acm *AccessControlManager) refresh() {
+	if acm.statePath == "" {
+		return
+	}
+
+	info, err := os.Stat(acm.statePath)
+	if err != nil {
+		return
+	}
+
+	acm.mu.Lock()
+	defer acm.mu.Unlock()
+	if info.ModTime().Equal(acm.stateModTime) {
+		return
+	}
+	_ = acm.loadLocked()
+}
+
+// FindUser looks a user up by ID or username and returns a copy
+func (acm *AccessControlManager) FindUser(idOrUsername string) (*User, bool) {
+	acm.refresh()
+
+	acm.mu.RLock()
+	defer acm.mu.RUnlock()
+
+	user := acm.findUserLocked(idOrUsername)
+	if user == nil {
+		return nil, false
+	}
+	found := *user
+	return &found, true
+}
+
+func (acm *AccessControlManager) findUserLocked(idOrUsername string) *User {
+	if user, exists := acm.users[idOrUsername]; exists {
+		return user
+	}
+	for _, user := range acm.users {
+		if user.Username == idOrUsername {
+			return user
+		}
+	}
+	return nil
+}
+
+// ListUsers returns copies of all users ordered by username
+func (acm *AccessControlManager) ListUsers() []User {
+	acm.refresh()
+
+	acm.mu.RLock()
+	defer acm.mu.RUnlock()
+
+	users := make([]User, 0, len(acm.users))
+	for _, user := range acm.users {
+		users = append(users, *user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users
+}
+
+// ListTokens returns the tokens of a user, or of every user when userID is
+// empty, oldest first. Token strings are never included.
+func (acm *AccessControlManager) ListTokens(userID string) []AccessToken {
+	acm.refresh()
+
+	acm.mu.RLock()
+	defer acm.mu.RUnlock()
+
+	tokens := make([]AccessToken, 0)
+	for _, token := range acm.tokens {
+		if userID != "" && token.UserID != userID {
+			continue
+		}
+		tokens = append(tokens, *token)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.Before(tokens[j].CreatedAt) })
+	return tokens
+}
+
+// RevokeToken deletes a token, given either its ID or the token itself
+func (acm *AccessControlManager) RevokeToken(idOrToken string) error {
+	acm.refresh()
+
+	acm.mu.Lock()
+	defer acm.mu.Unlock()
+
+	if _, exists := acm.tokens[hashToken(idOrToken)]; exists {
+		delete(acm.tokens, hashToken(idOrToken))
+		return acm.saveLocked()
+	}
+	for hash, token := range acm.tokens {
+		if token.ID == idOrToken {
+			delete(acm.tokens, hash)
+			return acm.saveLocked()
+		}
+	}
+	return errors.New("token not found")
+}
+
+// findRepositoryLocked looks a repository up by name
+func (acm *AccessControlManager) findRepositoryLocked(name string) *Repository {
+	for _, repo := range acm.repositories {
+		if repo.Name == name {
+			return repo
+		}
+	}
+	return nil
+}
+
+// GrantRepositoryAccessByName grants a user access to a repository by name,
+// registering the repository on first use. The name "*" grants the level on
+// every repository.
+func (acm *AccessControlManager) GrantRepositoryAccessByName(name, userID string, level AccessLevel) error {
+	if name == "" {
+		return errors.New("repository name is required")
+	}
+	if _, known := accessLevelRank[level]; !known {
+		return fmt.Errorf("invalid access level: %s", level)
+	}
+
+	acm.refresh()
+
+	acm.mu.Lock()
+	defer acm.mu.Unlock()
+
+	if name == GlobalResource {
+		permission := Permission{Resource: GlobalResource, Action: "*", Level: level}
+		if err := acm.grantPermissionLocked(userID, permission); err != nil {
+			return err
+		}
+		return acm.saveLocked()
+	}
+
+	if _, exists := acm.users[userID]; !exists {
+		return errors.New("user not found")
+	}
+
+	repo := acm.findRepositoryLocked(name)
+	if repo == nil {
+		repo = acm.createRepositoryLocked(name, userID, false)
+	}
+	if err := acm.grantRepositoryAccessLocked(repo.ID, userID, level); err != nil {
+		return err
+	}
+	return acm.saveLocked()
+}
+
+// RevokeRepositoryAccess removes a user's grant on a repository by name. The
+// name "*" removes the user's global grant.
+func (acm *AccessControlManager) RevokeRepositoryAccess(name, userID string) error {
+	acm.refresh()
+
+	acm.mu.Lock()
+	defer acm.mu.Unlock()
+
+	user, exists := acm.users[userID]
+	if !exists {
+		return errors.New("user not found")
+	}
+
+	resource := GlobalResource
+	if name != GlobalResource {
+		repo := acm.findRepositoryLocked(name)
+		if repo == nil {
+			return errors.New("repository not found")
+		}
+		resource = repositoryResourcePrefix + repo.ID
+
+		allowed := repo.AllowedUsers[:0]
+		for _, allowedUser := range repo.AllowedUsers {
+			if allowedUser != userID {
+				allowed = append(allowed, allowedUser)
+			}
+		}
+		repo.AllowedUsers = allowed
+	}
+
+	permissions := user.Permissions[:0]
+	for _, permission := range user.Permissions {
+		if permission.Resource != resource {
+			permissions = append(permissions, permission)
+		}
+	}
+	user.Permissions = permissions
+
+	return acm.saveLocked()
+}
+
+// CheckRepositoryAccess checks whether a user may act on a repository at the
+// given level. Public repositories are readable by every user; repositories
+// that were never registered are only reachable through global grants.
+func (acm *AccessControlManager) CheckRepositoryAccess(ctx context.Context, userID, repository string, level AccessLevel) (bool, error) {
+	acm.refresh()
+
+	acm.mu.RLock()
+	resource := GlobalResource
+	public := false
+	if repository != "" && repository != GlobalResource {
+		resource = repositoryResourcePrefix + repository
+		if repo := acm.findRepositoryLocked(repository); repo != nil {
+			resource = repositoryResourcePrefix + repo.ID
+			public = repo.IsPublic
+		}
+	}
+	acm.mu.RUnlock()
+
+	if public && level == AccessLevelRead {
+		return true, nil
+	}
+	return acm.CheckAccess(ctx, userID, string(level), resource)
+}
+
+// RepositoryGrant describes one user's access to one repository
+type RepositoryGrant struct {
+	Repository string      `json:"repository"`
+	Level      AccessLevel `json:"level"`
+}
+
+// ListGrants returns the repository grants of a user, ordered by repository
+func (acm *AccessControlManager) ListGrants(userID string) ([]RepositoryGrant, error) {
+	acm.refresh()
+
+	acm.mu.RLock()
+	defer acm.mu.RUnlock()
+
+	user, exists := acm.users[userID]
+	if !exists {
+		return nil, errors.New("user not found")
+	}
+
+	grants := make([]RepositoryGrant, 0, len(user.Permissions))
+	for _, permission := range user.Permissions {
+		if permission.Resource == GlobalResource {
+			grants = append(grants, RepositoryGrant{Repository: GlobalResource, Level: permission.Level})
+			continue
+		}
+		repoID, ok := strings.CutPrefix(permission.Resource, repositoryResourcePrefix)
+		if !ok {
+			continue
+		}
+		if repo, exists := acm.repositories[repoID]; exists {
+			grants = append(grants, RepositoryGrant{Repository: repo.Name, Level: permission.Level})
+		}
+	}
+	sort.Slice(grants, func(i, j int) bool { return grants[i].Repository < grants[j].Repository })
+	return grants, nil
+}
@@ -0,0 +1,105 @@
+package security
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAccessControlManager_PersistsUsersTokensAndGrants(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access_control.json")
+	ctx := context.Background()
+
+	acm, err := OpenAccessControlManager(path)
+	require.NoError(t, err)
+
+	user, err := acm.CreateUser("alice", "alice@example.com")
+	require.NoError(t, err)
+	token, err := acm.GenerateToken(user.ID, nil, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, acm.GrantRepositoryAccessByName("github.com/acme/api", user.ID, AccessLevelWrite))
+
+	// The token itself is never written to disk
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.False(t, strings.Contains(string(data), token.Token))
+
+	reopened, err := OpenAccessControlManager(path)
+	require.NoError(t, err)
+
+	validated, err := reopened.ValidateToken(token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, validated.UserID)
+
+	allowed, err := reopened.CheckRepositoryAccess(ctx, user.ID, "github.com/acme/api", AccessLevelWrite)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = reopened.CheckRepositoryAccess(ctx, user.ID, "github.com/acme/api", AccessLevelAdmin)
+	require.NoError(t, err)
+	assert.False(t, allowed, "write grant must not allow admin")
+
+	allowed, err = reopened.CheckRepositoryAccess(ctx, user.ID, "github.com/acme/web", AccessLevelRead)
+	require.NoError(t, err)
+	assert.False(t, allowed, "grant must not leak to other repositories")
+
+	grants, err := reopened.ListGrants(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []RepositoryGrant{{Repository: "github.com/acme/api", Level: AccessLevelWrite}}, grants)
+}
+
+func TestAccessControlManager_PicksUpChangesFromOtherProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access_control.json")
+	ctx := context.Background()
+
+	server, err := OpenAccessControlManager(path)
+	require.NoError(t, err)
+
+	// The admin CLI works on its own manager over the same file
+	admin, err := OpenAccessControlManager(path)
+	require.NoError(t, err)
+	user, err := admin.CreateUser("bob", "bob@example.com")
+	require.NoError(t, err)
+	token, err := admin.GenerateToken(user.ID, nil, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, admin.GrantRepositoryAccessByName(GlobalResource, user.ID, AccessLevelRead))
+
+	_, err = server.ValidateToken(token.Token)
+	require.NoError(t, err)
+	allowed, err := server.CheckRepositoryAccess(ctx, user.ID, "github.com/acme/any", AccessLevelRead)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// Make sure the next write gets a new modification time
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, admin.RevokeToken(token.ID))
+	require.NoError(t, admin.RevokeRepositoryAccess(GlobalResource, user.ID))
+
+	_, err = server.ValidateToken(token.Token)
+	assert.Error(t, err)
+	allowed, err = server.CheckRepositoryAccess(ctx, user.ID, "github.com/acme/any", AccessLevelRead)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestAccessControlManager_RegrantChangesLevel(t *testing.T) {
+	acm := NewAccessControlManager()
+	ctx := context.Background()
+
+	user, err := acm.CreateUser("carol", "carol@example.com")
+	require.NoError(t, err)
+
+	require.NoError(t, acm.GrantRepositoryAccessByName("github.com/acme/api", user.ID, AccessLevelRead))
+	require.NoError(t, acm.GrantRepositoryAccessByName("github.com/acme/api", user.ID, AccessLevelAdmin))
+
+	allowed, err := acm.CheckRepositoryAccess(ctx, user.ID, "github.com/acme/api", AccessLevelAdmin)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Len(t, acm.users[user.ID].Permissions, 1)
+}
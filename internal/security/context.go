@@ -0,0 +1,23 @@
+package security
+
+import "context"
+
+// Principal identifies the authenticated caller of a request
+type Principal struct {
+	UserID  string
+	TokenID string
+}
+
+type principalContextKey struct{}
+
+// WithPrincipal returns a context carrying the authenticated caller
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the authenticated caller, if the request was
+// authenticated. Requests over stdio carry no principal.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(Principal)
+	return principal, ok
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 
 	"golang.org/x/crypto/pbkdf2"
 )
@@ -21,6 +22,13 @@ type EncryptionManager struct {
 	saltLength int
 	keyLength  int
 	iterations int
+
+	// Envelope encryption, see OpenEncryptionManager
+	mu           sync.RWMutex
+	keyring      *keyring
+	keyringPath  string
+	dataKeys     map[string][]byte
+	rotatedHooks []func(activeKeyID string)
 }
 
 // EncryptedData represents encrypted data with metadata
@@ -311,12 +319,19 @@ func (em *EncryptionManager) ValidateEncryption() error {
 	return nil
 }
 
-// RotateKey rotates the encryption key (in a real implementation)
+// RotateKey rotates the encryption key. With a keyring, a new data key
+// becomes active and the data keys are re-wrapped under newPassword (an
+// empty newPassword keeps the current one); registered OnKeyRotated hooks
+// then re-encrypt existing data in the background.
 func (em *EncryptionManager) RotateKey(newPassword string) error {
 	if !em.enabled {
 		return errors.New("encryption is not enabled")
 	}
 
+	if em.hasKeyring() {
+		return em.rotateEnvelopeKey(newPassword)
+	}
+
 	// In a real implementation, this would:
 	// 1. Decrypt all data with old key
 	// 2. Generate new key from new password
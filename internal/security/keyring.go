@@ -0,0 +1,399 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// EncryptedFieldPrefix marks a value produced by EncryptField. Values
+// without it are plaintext written before encryption was enabled.
+const EncryptedFieldPrefix = "enc:v1:"
+
+const keyringVersion = 1
+
+// ErrWrongPassphrase is returned when the keyring cannot be unlocked
+var ErrWrongPassphrase = errors.New("wrong encryption passphrase for keyring")
+
+// keyring is the on-disk form of the data keys. Every data key is wrapped
+// (encrypted) with the master key derived from the passphrase, so changing
+// the passphrase only re-wraps the data keys and never touches stored data.
+type keyring struct {
+	Version     int          `json:"version"`
+	Salt        string       `json:"salt"`
+	Iterations  int          `json:"iterations"`
+	ActiveKeyID string       `json:"active_key_id"`
+	Keys        []wrappedKey `json:"keys"`
+
+	// ReencryptPending is set by a rotation and cleared once all data has
+	// been re-encrypted with the active key
+	ReencryptPending bool `json:"reencrypt_pending,omitempty"`
+}
+
+// wrappedKey is a data key encrypted with the master key
+type wrappedKey struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Wrapped   string    `json:"wrapped"`
+}
+
+// OpenEncryptionManager creates an encryption manager that uses envelope
+// encryption: fields are encrypted with a data key kept in the keyring file
+// at path, wrapped with a master key derived from masterPassword. The
+// keyring is created on first use. Old data keys stay in the keyring after
+// a rotation so older backups can still be read.
+func OpenEncryptionManager(masterPassword, path string) (*EncryptionManager, error) {
+	if masterPassword == "" {
+		return nil, errors.New("encryption passphrase is required")
+	}
+	if path == "" {
+		return nil, errors.New("keyring path is required")
+	}
+
+	em := NewEncryptionManager("")
+	em.keyringPath = path
+
+	kr, err := readKeyring(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if kr == nil {
+		salt := make([]byte, em.saltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+		kr = &keyring{
+			Version:    keyringVersion,
+			Salt:       base64.StdEncoding.EncodeToString(salt),
+			Iterations: em.iterations,
+		}
+		em.keyring = kr
+		em.masterKey = em.deriveMasterKey(masterPassword, salt, kr.Iterations)
+		em.dataKeys = make(map[string][]byte)
+		if _, err := em.addDataKeyLocked(); err != nil {
+			return nil, err
+		}
+		if err := em.saveKeyringLocked(); err != nil {
+			return nil, err
+		}
+		em.enabled = true
+		return em, nil
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(kr.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode keyring salt: %w", err)
+	}
+	em.keyring = kr
+	em.masterKey = em.deriveMasterKey(masterPassword, salt, kr.Iterations)
+	if err := em.unwrapDataKeys(); err != nil {
+		return nil, err
+	}
+	em.enabled = true
+	return em, nil
+}
+
+func readKeyring(path string) (*keyring, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+
+	var kr keyring
+	if err := json.Unmarshal(data, &kr); err != nil {
+		return nil, fmt.Errorf("failed to decode keyring: %w", err)
+	}
+	if kr.Version != keyringVersion {
+		return nil, fmt.Errorf("unsupported keyring version %d", kr.Version)
+	}
+	return &kr, nil
+}
+
+func (em *EncryptionManager) deriveMasterKey(password string, salt []byte, iterations int) []byte {
+	return pbkdf2.Key([]byte(password), salt, iterations, em.keyLength, sha256.New)
+}
+
+// unwrapDataKeys decrypts every data key in the keyring with the master key
+func (em *EncryptionManager) unwrapDataKeys() error {
+	em.dataKeys = make(map[string][]byte, len(em.keyring.Keys))
+	for _, key := range em.keyring.Keys {
+		wrapped, err := base64.StdEncoding.DecodeString(key.Wrapped)
+		if err != nil {
+			return fmt.Errorf("failed to decode data key %s: %w", key.ID, err)
+		}
+		dataKey, err := openAESGCM(em.masterKey, wrapped, []byte(key.ID))
+		if err != nil {
+			return ErrWrongPassphrase
+		}
+		em.dataKeys[key.ID] = dataKey
+	}
+	if _, ok := em.dataKeys[em.keyring.ActiveKeyID]; !ok {
+		return fmt.Errorf("keyring has no active data key %q", em.keyring.ActiveKeyID)
+	}
+	return nil
+}
+
+// addDataKeyLocked generates a data key, wraps it and makes it active
+func (em *EncryptionManager) addDataKeyLocked() (string, error) {
+	dataKey := make([]byte, em.keyLength)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	keyID := generateSecureID()[:12]
+
+	wrapped, err := sealAESGCM(em.masterKey, dataKey, []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	em.dataKeys[keyID] = dataKey
+	em.keyring.Keys = append(em.keyring.Keys, wrappedKey{
+		ID:        keyID,
+		CreatedAt: time.Now().UTC(),
+		Wrapped:   base64.StdEncoding.EncodeToString(wrapped),
+	})
+	em.keyring.ActiveKeyID = keyID
+	return keyID, nil
+}
+
+// saveKeyringLocked writes the keyring atomically with owner-only permissions
+func (em *EncryptionManager) saveKeyringLocked() error {
+	data, err := json.MarshalIndent(em.keyring, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode keyring: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(em.keyringPath), 0o700); err != nil {
+		return fmt.Errorf("failed to create keyring directory: %w", err)
+	}
+	tmpPath := em.keyringPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write keyring: %w", err)
+	}
+	if err := os.Rename(tmpPath, em.keyringPath); err != nil {
+		return fmt.Errorf("failed to replace keyring: %w", err)
+	}
+	return nil
+}
+
+func (em *EncryptionManager) hasKeyring() bool {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+	return em.keyring != nil
+}
+
+// rotateEnvelopeKey activates a new data key and, when newPassword is set,
+// re-wraps all data keys under a master key derived from it
+func (em *EncryptionManager) rotateEnvelopeKey(newPassword string) error {
+	em.mu.Lock()
+
+	previousKeyring := *em.keyring
+	previousKeyring.Keys = append([]wrappedKey(nil), em.keyring.Keys...)
+	previousMaster := em.masterKey
+
+	if newPassword != "" {
+		salt := make([]byte, em.saltLength)
+		if _, err := rand.Read(salt); err != nil {
+			em.mu.Unlock()
+			return fmt.Errorf("failed to generate salt: %w", err)
+		}
+		em.keyring.Salt = base64.StdEncoding.EncodeToString(salt)
+		em.masterKey = em.deriveMasterKey(newPassword, salt, em.keyring.Iterations)
+
+		// Re-wrap every data key so older data and backups stay readable
+		for i, key := range em.keyring.Keys {
+			wrapped, err := sealAESGCM(em.masterKey, em.dataKeys[key.ID], []byte(key.ID))
+			if err != nil {
+				em.keyring, em.masterKey = &previousKeyring, previousMaster
+				em.mu.Unlock()
+				return fmt.Errorf("failed to wrap data key: %w", err)
+			}
+			em.keyring.Keys[i].Wrapped = base64.StdEncoding.EncodeToString(wrapped)
+		}
+	}
+
+	activeKeyID, err := em.addDataKeyLocked()
+	if err == nil {
+		em.keyring.ReencryptPending = true
+		err = em.saveKeyringLocked()
+	}
+	if err != nil {
+		em.keyring, em.masterKey = &previousKeyring, previousMaster
+		em.mu.Unlock()
+		return err
+	}
+
+	hooks := append([]func(string){}, em.rotatedHooks...)
+	em.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(activeKeyID)
+	}
+	return nil
+}
+
+// OnKeyRotated registers a function called after RotateKey activates a new
+// data key. It is used to start re-encrypting stored data.
+func (em *EncryptionManager) OnKeyRotated(hook func(activeKeyID string)) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	em.rotatedHooks = append(em.rotatedHooks, hook)
+}
+
+// ActiveKeyID returns the ID of the data key used for new encryptions
+func (em *EncryptionManager) ActiveKeyID() string {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+	if em.keyring == nil {
+		return ""
+	}
+	return em.keyring.ActiveKeyID
+}
+
+// ReencryptPending reports whether a rotation has not yet been followed by
+// a completed re-encryption
+func (em *EncryptionManager) ReencryptPending() bool {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+	return em.keyring != nil && em.keyring.ReencryptPending
+}
+
+// MarkReencrypted records that all data uses the given data key. It is
+// ignored if another rotation happened in the meantime.
+func (em *EncryptionManager) MarkReencrypted(keyID string) error {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	if em.keyring == nil || em.keyring.ActiveKeyID != keyID || !em.keyring.ReencryptPending {
+		return nil
+	}
+	em.keyring.ReencryptPending = false
+	return em.saveKeyringLocked()
+}
+
+// EncryptField encrypts a value with the active data key. The result is
+// self-describing, so it can be decrypted after later rotations.
+func (em *EncryptionManager) EncryptField(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	em.mu.RLock()
+	if em.keyring == nil {
+		em.mu.RUnlock()
+		return "", errors.New("field encryption requires a keyring")
+	}
+	keyID := em.keyring.ActiveKeyID
+	dataKey := em.dataKeys[keyID]
+	em.mu.RUnlock()
+
+	sealed, err := sealAESGCM(dataKey, []byte(plaintext), []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt field: %w", err)
+	}
+	return EncryptedFieldPrefix + keyID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptField decrypts a value produced by EncryptField. Plaintext values
+// are returned unchanged.
+func (em *EncryptionManager) DecryptField(value string) (string, error) {
+	keyID, encoded, ok := splitEncryptedField(value)
+	if !ok {
+		return value, nil
+	}
+
+	em.mu.RLock()
+	dataKey, found := em.dataKeys[keyID]
+	em.mu.RUnlock()
+	if !found {
+		return "", fmt.Errorf("unknown data key %q", keyID)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode encrypted field: %w", err)
+	}
+	plaintext, err := openAESGCM(dataKey, sealed, []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt field: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// FieldKeyID returns the data key a value was encrypted with, if any
+func FieldKeyID(value string) (string, bool) {
+	keyID, _, ok := splitEncryptedField(value)
+	return keyID, ok
+}
+
+// DataKeyIDs returns the IDs of all data keys, oldest first
+func (em *EncryptionManager) DataKeyIDs() []string {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+	if em.keyring == nil {
+		return nil
+	}
+	keys := append([]wrappedKey(nil), em.keyring.Keys...)
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		ids = append(ids, key.ID)
+	}
+	return ids
+}
+
+func splitEncryptedField(value string) (keyID, encoded string, ok bool) {
+	rest, found := strings.CutPrefix(value, EncryptedFieldPrefix)
+	if !found {
+		return "", "", false
+	}
+	return strings.Cut(rest, ":")
+}
+
+// sealAESGCM encrypts plaintext and returns the nonce followed by the ciphertext
+func sealAESGCM(key, plaintext, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// openAESGCM decrypts the output of sealAESGCM
+func openAESGCM(key, sealed, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -0,0 +1,89 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenEncryptionManager_FieldsSurviveRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyring.json")
+
+	em, err := OpenEncryptionManager("passphrase", path)
+	require.NoError(t, err)
+	assert.True(t, em.IsEnabled())
+
+	encrypted, err := em.EncryptField("customer incident details")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, EncryptedFieldPrefix))
+	assert.NotContains(t, encrypted, "customer")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	reopened, err := OpenEncryptionManager("passphrase", path)
+	require.NoError(t, err)
+	decrypted, err := reopened.DecryptField(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "customer incident details", decrypted)
+
+	_, err = OpenEncryptionManager("wrong", path)
+	assert.ErrorIs(t, err, ErrWrongPassphrase)
+}
+
+func TestEncryptionManager_DecryptFieldPassesPlaintextThrough(t *testing.T) {
+	em, err := OpenEncryptionManager("passphrase", filepath.Join(t.TempDir(), "keyring.json"))
+	require.NoError(t, err)
+
+	value, err := em.DecryptField("written before encryption was enabled")
+	require.NoError(t, err)
+	assert.Equal(t, "written before encryption was enabled", value)
+
+	empty, err := em.EncryptField("")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestEncryptionManager_RotateKeyWithKeyring(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	em, err := OpenEncryptionManager("old-passphrase", path)
+	require.NoError(t, err)
+
+	oldKeyID := em.ActiveKeyID()
+	encrypted, err := em.EncryptField("before rotation")
+	require.NoError(t, err)
+
+	var rotatedTo string
+	em.OnKeyRotated(func(activeKeyID string) { rotatedTo = activeKeyID })
+
+	require.NoError(t, em.RotateKey("new-passphrase"))
+	assert.NotEqual(t, oldKeyID, em.ActiveKeyID())
+	assert.Equal(t, em.ActiveKeyID(), rotatedTo)
+	assert.True(t, em.ReencryptPending())
+
+	// New encryptions use the new data key
+	reencrypted, err := em.EncryptField("before rotation")
+	require.NoError(t, err)
+	keyID, ok := FieldKeyID(reencrypted)
+	require.True(t, ok)
+	assert.Equal(t, em.ActiveKeyID(), keyID)
+
+	require.NoError(t, em.MarkReencrypted(em.ActiveKeyID()))
+	assert.False(t, em.ReencryptPending())
+
+	// Old data keys are re-wrapped under the new passphrase
+	_, err = OpenEncryptionManager("old-passphrase", path)
+	assert.ErrorIs(t, err, ErrWrongPassphrase)
+
+	reopened, err := OpenEncryptionManager("new-passphrase", path)
+	require.NoError(t, err)
+	decrypted, err := reopened.DecryptField(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "before rotation", decrypted)
+	assert.Equal(t, []string{oldKeyID, em.ActiveKeyID()}, reopened.DataKeyIDs())
+}
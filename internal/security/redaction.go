@@ -0,0 +1,286 @@
+package security
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"lerian-mcp-memory/internal/config"
+)
+
+// Redaction categories reported for each rule
+const (
+	RedactionCategorySecret = "secret"
+	RedactionCategoryPII    = "pii"
+	RedactionCategoryCustom = "custom"
+)
+
+// secretGroup is the named group that limits an action to part of a match
+const secretGroup = "secret"
+
+// hashPrefixLength is how much of the hash a hashed value keeps
+const hashPrefixLength = 16
+
+// redactionDetector is a built-in rule. accept, when set, rejects matches
+// that only look like the real thing.
+type redactionDetector struct {
+	name    string
+	pattern *regexp.Regexp
+	accept  func(value string) bool
+}
+
+// secretDetectors find credentials in well-known token formats
+var secretDetectors = []redactionDetector{
+	{name: "private_key", pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{name: "aws_access_key", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "github_token", pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{name: "slack_token", pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{name: "stripe_key", pattern: regexp.MustCompile(`\b[rs]k_(?:live|test)_[A-Za-z0-9]{16,}\b`)},
+	{name: "llm_api_key", pattern: regexp.MustCompile(`\bsk-(?:proj-|ant-)?[A-Za-z0-9_-]{20,}`)},
+	{name: "google_api_key", pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{name: "jwt", pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{name: "connection_password", pattern: regexp.MustCompile(`\b[A-Za-z][A-Za-z0-9+.-]*://[^\s:/@]+:(?P<secret>[^\s@/]+)@`)},
+	{
+		// KEY=value and key: "value" lines, as pasted from .env files and
+		// config. Values without a digit or symbol are usually identifiers.
+		name:    "credential_assignment",
+		pattern: regexp.MustCompile(`(?i)\b[A-Za-z0-9_.-]*(?:api[_-]?key|secret|token|password|passwd|pwd|access[_-]?key|private[_-]?key|credentials?)["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9_\-+/=.~!@#$%^&*]{8,})`),
+		accept:  hasDigitOrSymbol,
+	},
+}
+
+// piiDetectors find personal data
+var piiDetectors = []redactionDetector{
+	{name: "email", pattern: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+	{name: "ip_address", pattern: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`), accept: isPersonalIP},
+	{name: "phone", pattern: regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)|\b\d{2,4})[\s.-]\d{3,4}[\s.-]\d{4}\b`)},
+}
+
+// entropyCandidate matches the unbroken tokens the entropy detector scores
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_=-]{24,}`)
+
+// Redaction reports how often one rule matched and what was done
+type Redaction struct {
+	Rule     string `json:"rule"`
+	Category string `json:"category"`
+	Action   string `json:"action"`
+	Count    int    `json:"count"`
+}
+
+// BlockedContentError is returned when content matches a block rule. It
+// names the rules, never the matched values.
+type BlockedContentError struct {
+	Rules []string
+}
+
+func (e *BlockedContentError) Error() string {
+	return "content contains sensitive data that may not be stored (" + strings.Join(e.Rules, ", ") + ")"
+}
+
+// Redactor scrubs secrets and personal data from content before it is
+// embedded and stored. Settings are passed on every call, so reloaded
+// configuration and per-repository rules apply at once.
+type Redactor struct {
+	encryption *EncryptionManager
+
+	mu     sync.Mutex
+	custom map[string]*regexp.Regexp
+}
+
+// NewRedactor creates a redactor. Masks and hashes come from em's
+// AnonymizeData and HashSensitiveData, which work without encryption
+// enabled; a nil em uses a disabled manager.
+func NewRedactor(em *EncryptionManager) *Redactor {
+	if em == nil {
+		em = NewEncryptionManager("")
+	}
+	return &Redactor{encryption: em, custom: make(map[string]*regexp.Regexp)}
+}
+
+// Redact applies the repository's rules, the server's custom rules and the
+// built-in detectors to content, in that order. It returns the scrubbed
+// content and what was redacted, or a *BlockedContentError.
+func (r *Redactor) Redact(content string, cfg *config.RedactionConfig, repoRules []config.RedactionRule) (string, []Redaction, error) {
+	if !cfg.Enabled || content == "" {
+		return content, nil, nil
+	}
+
+	counts := make(map[string]*Redaction)
+	var order []string
+	record := func(rule, category, action string, n int) {
+		if n == 0 {
+			return
+		}
+		if existing, ok := counts[rule]; ok {
+			existing.Count += n
+			return
+		}
+		counts[rule] = &Redaction{Rule: rule, Category: category, Action: action, Count: n}
+		order = append(order, rule)
+	}
+
+	rules := append(append([]config.RedactionRule{}, repoRules...), cfg.Rules...)
+	for _, rule := range rules {
+		pattern, err := r.compile(rule.Pattern)
+		if err != nil {
+			return "", nil, fmt.Errorf("redaction rule %s: %w", rule.Name, err)
+		}
+		var n int
+		content, n = r.apply(content, pattern, nil, rule.Name, RedactionCategoryCustom, rule.Action)
+		record(rule.Name, RedactionCategoryCustom, rule.Action, n)
+	}
+
+	for _, detector := range secretDetectors {
+		var n int
+		content, n = r.apply(content, detector.pattern, detector.accept, detector.name, RedactionCategorySecret, cfg.SecretAction)
+		record(detector.name, RedactionCategorySecret, cfg.SecretAction, n)
+	}
+	if cfg.EntropyThreshold > 0 {
+		accept := func(value string) bool { return looksRandom(value, cfg.EntropyThreshold) }
+		var n int
+		content, n = r.apply(content, entropyCandidate, accept, "high_entropy_string", RedactionCategorySecret, cfg.SecretAction)
+		record("high_entropy_string", RedactionCategorySecret, cfg.SecretAction, n)
+	}
+	for _, detector := range piiDetectors {
+		var n int
+		content, n = r.apply(content, detector.pattern, detector.accept, detector.name, RedactionCategoryPII, cfg.PIIAction)
+		record(detector.name, RedactionCategoryPII, cfg.PIIAction, n)
+	}
+
+	redactions := make([]Redaction, 0, len(order))
+	var blocked []string
+	for _, rule := range order {
+		redactions = append(redactions, *counts[rule])
+		if counts[rule].Action == config.RedactionActionBlock {
+			blocked = append(blocked, rule)
+		}
+	}
+	if len(blocked) > 0 {
+		sort.Strings(blocked)
+		return "", redactions, &BlockedContentError{Rules: blocked}
+	}
+	return content, redactions, nil
+}
+
+// apply replaces the accepted matches of pattern and counts them. Block
+// rules count matches and leave content alone; the caller refuses it.
+func (r *Redactor) apply(content string, pattern *regexp.Regexp, accept func(string) bool, rule, category, action string) (string, int) {
+	secretIndex := pattern.SubexpIndex(secretGroup)
+	count := 0
+
+	var out strings.Builder
+	last := 0
+	for _, match := range pattern.FindAllStringSubmatchIndex(content, -1) {
+		start, end := match[0], match[1]
+		if secretIndex > 0 && match[2*secretIndex] >= 0 {
+			start, end = match[2*secretIndex], match[2*secretIndex+1]
+		}
+		value := content[start:end]
+		if value == "" || (accept != nil && !accept(value)) {
+			continue
+		}
+		count++
+		if action == config.RedactionActionBlock {
+			continue
+		}
+		out.WriteString(content[last:start])
+		out.WriteString(r.replacement(value, rule, category, action))
+		last = end
+	}
+	if count == 0 || action == config.RedactionActionBlock {
+		return content, count
+	}
+	out.WriteString(content[last:])
+	return out.String(), count
+}
+
+// replacement returns the placeholder that stands in for value
+func (r *Redactor) replacement(value, rule, category, action string) string {
+	if action == config.RedactionActionHash {
+		hash := r.encryption.HashSensitiveData(value)
+		if len(hash) > hashPrefixLength {
+			hash = hash[:hashPrefixLength]
+		}
+		return "[HASHED:" + rule + ":" + hash + "]"
+	}
+
+	// Personal data masks the way AnonymizeData does, where it recognises
+	// the value whole
+	if category == RedactionCategoryPII {
+		if masked := r.encryption.AnonymizeData(value); strings.HasPrefix(masked, "[") && strings.HasSuffix(masked, "_REDACTED]") {
+			return masked
+		}
+		return "[" + strings.ToUpper(rule) + "_REDACTED]"
+	}
+	return "[REDACTED:" + rule + "]"
+}
+
+// compile returns a custom rule's pattern, compiling it once
+func (r *Redactor) compile(pattern string) (*regexp.Regexp, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if compiled, ok := r.custom[pattern]; ok {
+		return compiled, nil
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	r.custom[pattern] = compiled
+	return compiled, nil
+}
+
+// looksRandom reports whether a token is likely a generated secret: letters
+// and digits mixed, with high entropy. Hex digests stay below 4 bits per
+// character and are left alone.
+func looksRandom(token string, threshold float64) bool {
+	hasLetter, hasDigit := false, false
+	for _, c := range token {
+		switch {
+		case c >= '0' && c <= '9':
+			hasDigit = true
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			hasLetter = true
+		}
+	}
+	return hasLetter && hasDigit && shannonEntropy(token) >= threshold
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	frequencies := make(map[rune]int)
+	for _, c := range s {
+		frequencies[c]++
+	}
+	length := float64(len([]rune(s)))
+	entropy := 0.0
+	for _, n := range frequencies {
+		p := float64(n) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// hasDigitOrSymbol reports whether a value is more than a plain word
+func hasDigitOrSymbol(value string) bool {
+	for _, c := range value {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && c != '_' {
+			return true
+		}
+	}
+	return false
+}
+
+// isPersonalIP skips loopback and unspecified addresses, which identify
+// nobody
+func isPersonalIP(value string) bool {
+	ip := net.ParseIP(value)
+	return ip != nil && !ip.IsLoopback() && !ip.IsUnspecified()
+}
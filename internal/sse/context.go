@@ -0,0 +1,27 @@
+package sse
+
+import "context"
+
+type sessionKey struct{}
+
+// WithSession attaches the session a request arrived on to ctx
+func WithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// SessionFromContext returns the session a request arrived on. stdio calls
+// and HTTP calls without a session have none.
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	session, ok := ctx.Value(sessionKey{}).(*Session)
+	return session, ok && session != nil
+}
+
+// Notify sends a notification to the session a request arrived on and
+// reports whether there was one
+func Notify(ctx context.Context, method string, params interface{}) bool {
+	session, ok := SessionFromContext(ctx)
+	if !ok {
+		return false
+	}
+	return session.Notify(method, params) == nil
+}
@@ -0,0 +1,284 @@
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"lerian-mcp-memory/internal/security"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fredcamaral/gomcp-sdk/protocol"
+)
+
+const (
+	// SessionHeader carries the session ID on Streamable HTTP requests
+	SessionHeader = "Mcp-Session-Id"
+
+	// sessionQueryParam carries the session ID on the message endpoint
+	// announced to HTTP+SSE clients
+	sessionQueryParam = "session_id"
+
+	// DefaultHeartbeatInterval keeps proxies from closing quiet streams
+	DefaultHeartbeatInterval = 30 * time.Second
+
+	// reconnectDelay is the retry delay suggested to EventSource clients
+	reconnectDelay = 3 * time.Second
+)
+
+// RequestHandler processes a JSON-RPC request
+type RequestHandler interface {
+	HandleRequest(ctx context.Context, req *protocol.JSONRPCRequest) *protocol.JSONRPCResponse
+}
+
+// Handler serves the MCP stream endpoint for both HTTP+SSE and Streamable
+// HTTP clients:
+//
+//   - GET opens a stream. Without a session it creates one, returns its ID in
+//     the Mcp-Session-Id header and announces the message endpoint in an
+//     "endpoint" event. With a session it resumes after Last-Event-ID.
+//   - POST with ?session_id= answers 202 and delivers the response on the
+//     stream. POST with the Mcp-Session-Id header answers in the body, and
+//     notifications raised while handling it go to the stream. POST without
+//     a session answers in the body; initialize also creates a session.
+//   - DELETE closes the session.
+//
+// Sessions belong to the user that created them when access control is on.
+type Handler struct {
+	sessions  *Manager
+	handler   RequestHandler
+	endpoint  string
+	heartbeat time.Duration
+}
+
+// NewHandler creates a stream handler. endpoint is the path clients POST
+// messages to, usually the path the handler is mounted on.
+func NewHandler(sessions *Manager, handler RequestHandler, endpoint string) *Handler {
+	return &Handler{
+		sessions:  sessions,
+		handler:   handler,
+		endpoint:  endpoint,
+		heartbeat: DefaultHeartbeatInterval,
+	}
+}
+
+// ServeHTTP dispatches on the request method
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.serveStream(w, r)
+	case http.MethodPost:
+		h.serveMessage(w, r)
+	case http.MethodDelete:
+		h.serveClose(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// serveStream writes session events until the client disconnects
+func (h *Handler) serveStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	session, created, status := h.streamSession(r)
+	if session == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	// The server's write timeout would otherwise end the stream
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Failed to clear SSE write deadline: %v", err)
+	}
+
+	replay, live, complete := session.attach(lastEventID(r))
+	defer session.detach(live)
+	if !complete {
+		log.Printf("SSE session %s resumed after its replay buffer moved on; some events were lost", session.ID)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set(SessionHeader, session.ID)
+	w.WriteHeader(http.StatusOK)
+
+	_, _ = fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay.Milliseconds())
+	if created {
+		// HTTP+SSE clients post their messages to this URL
+		_, _ = fmt.Fprintf(w, "event: endpoint\ndata: %s?%s=%s\n\n", h.endpoint, sessionQueryParam, session.ID)
+	}
+	for _, event := range replay {
+		writeEvent(w, event)
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, open := <-live:
+			if !open {
+				// Replaced by a newer stream, or too far behind
+				return
+			}
+			writeEvent(w, event)
+			flusher.Flush()
+		case <-ticker.C:
+			_, _ = fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-session.Context().Done():
+			return
+		}
+	}
+}
+
+// streamSession finds the session a stream resumes or creates a new one
+func (h *Handler) streamSession(r *http.Request) (session *Session, created bool, status int) {
+	if id := sessionID(r); id != "" {
+		session, status := h.ownedSession(r, id)
+		return session, false, status
+	}
+
+	session, err := h.sessions.Create(userID(r.Context()))
+	if err != nil {
+		return nil, false, http.StatusServiceUnavailable
+	}
+	return session, true, http.StatusOK
+}
+
+// serveMessage handles a JSON-RPC message from the client
+func (h *Handler) serveMessage(w http.ResponseWriter, r *http.Request) {
+	var req protocol.JSONRPCRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON-RPC request", http.StatusBadRequest)
+		return
+	}
+
+	var session *Session
+	if id := sessionID(r); id != "" {
+		var status int
+		if session, status = h.ownedSession(r, id); session == nil {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+	}
+
+	// Notifications and client responses need no reply
+	if req.ID == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	// HTTP+SSE clients read every response from the stream
+	if session != nil && r.Header.Get(SessionHeader) == "" {
+		ctx := h.requestContext(session.Context(), r, session)
+		go func() {
+			resp := h.handler.HandleRequest(ctx, &req)
+			if _, err := session.Publish(resp); err != nil {
+				log.Printf("Failed to deliver response on SSE session %s: %v", session.ID, err)
+			}
+		}()
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if session == nil && req.Method == "initialize" {
+		created, err := h.sessions.Create(userID(r.Context()))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		session = created
+	}
+
+	ctx := r.Context()
+	if session != nil {
+		ctx = WithSession(ctx, session)
+		w.Header().Set(SessionHeader, session.ID)
+	}
+
+	resp := h.handler.HandleRequest(ctx, &req)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding SSE response: %v", err)
+	}
+}
+
+// serveClose ends a session at the client's request
+func (h *Handler) serveClose(w http.ResponseWriter, r *http.Request) {
+	id := sessionID(r)
+	if id == "" {
+		http.Error(w, "Missing session", http.StatusBadRequest)
+		return
+	}
+	if session, status := h.ownedSession(r, id); session == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	h.sessions.Close(id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ownedSession returns the session if it exists and belongs to the caller
+func (h *Handler) ownedSession(r *http.Request, id string) (*Session, int) {
+	session, ok := h.sessions.Get(id)
+	if !ok {
+		// Tells Streamable HTTP clients to start a new session
+		return nil, http.StatusNotFound
+	}
+	if session.UserID != userID(r.Context()) {
+		return nil, http.StatusForbidden
+	}
+	return session, http.StatusOK
+}
+
+// requestContext carries the caller's identity and session into a request
+// that runs after its POST has returned
+func (h *Handler) requestContext(base context.Context, r *http.Request, session *Session) context.Context {
+	ctx := WithSession(base, session)
+	if principal, ok := security.PrincipalFromContext(r.Context()); ok {
+		ctx = security.WithPrincipal(ctx, principal)
+	}
+	return ctx
+}
+
+func writeEvent(w http.ResponseWriter, event Event) {
+	_, _ = fmt.Fprintf(w, "id: %d\nevent: message\ndata: %s\n\n", event.ID, event.Data)
+}
+
+func sessionID(r *http.Request) string {
+	if id := r.Header.Get(SessionHeader); id != "" {
+		return id
+	}
+	return r.URL.Query().Get(sessionQueryParam)
+}
+
+func lastEventID(r *http.Request) uint64 {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	id, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+func userID(ctx context.Context) string {
+	if principal, ok := security.PrincipalFromContext(ctx); ok {
+		return principal.UserID
+	}
+	return ""
+}
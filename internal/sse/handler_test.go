@@ -0,0 +1,219 @@
+package sse
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"lerian-mcp-memory/internal/security"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fredcamaral/gomcp-sdk/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoHandler answers every request with its method and notifies the
+// caller's session first
+type echoHandler struct{}
+
+func (echoHandler) HandleRequest(ctx context.Context, req *protocol.JSONRPCRequest) *protocol.JSONRPCResponse {
+	Notify(ctx, "notifications/test", map[string]string{"method": req.Method})
+	return &protocol.JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: req.Method}
+}
+
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// readEvents reads n events from an SSE stream, skipping comments and
+// retry hints
+func readEvents(t *testing.T, reader *bufio.Reader, n int) []sseEvent {
+	t.Helper()
+	events := make(chan []sseEvent, 1)
+	go func() {
+		var result []sseEvent
+		var current sseEvent
+		for len(result) < n {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				break
+			}
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case line == "":
+				if current.data != "" {
+					result = append(result, current)
+				}
+				current = sseEvent{}
+			case strings.HasPrefix(line, "id: "):
+				current.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				current.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				current.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+		events <- result
+	}()
+
+	select {
+	case result := <-events:
+		require.Len(t, result, n)
+		return result
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %d events", n)
+		return nil
+	}
+}
+
+func openStream(t *testing.T, req *http.Request) (*http.Response, *bufio.Reader) {
+	t.Helper()
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	return resp, bufio.NewReader(resp.Body)
+}
+
+func postMessage(t *testing.T, url string, header http.Header, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestHandlerDeliversResponsesOnStream(t *testing.T) {
+	sessions := NewManager(0, 0)
+	server := httptest.NewServer(NewHandler(sessions, echoHandler{}, "/sse"))
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, http.NoBody)
+	require.NoError(t, err)
+	resp, reader := openStream(t, req)
+	sessionID := resp.Header.Get(SessionHeader)
+	require.NotEmpty(t, sessionID)
+
+	endpoint := readEvents(t, reader, 1)[0]
+	assert.Equal(t, "endpoint", endpoint.event)
+	assert.Equal(t, "/sse?session_id="+sessionID, endpoint.data)
+
+	post := postMessage(t, server.URL+"?session_id="+sessionID, nil, `{"jsonrpc":"2.0","id":7,"method":"tools/list"}`)
+	assert.Equal(t, http.StatusAccepted, post.StatusCode)
+
+	events := readEvents(t, reader, 2)
+	assert.Equal(t, "1", events[0].id)
+	assert.Contains(t, events[0].data, `"method":"notifications/test"`)
+
+	var response protocol.JSONRPCResponse
+	require.NoError(t, json.Unmarshal([]byte(events[1].data), &response))
+	assert.Equal(t, "2", events[1].id)
+	assert.Equal(t, float64(7), response.ID)
+	assert.Equal(t, "tools/list", response.Result)
+}
+
+func TestHandlerResumesFromLastEventID(t *testing.T) {
+	sessions := NewManager(0, 0)
+	server := httptest.NewServer(NewHandler(sessions, echoHandler{}, "/sse"))
+	t.Cleanup(server.Close)
+
+	// Streamable HTTP: initialize creates the session
+	init := postMessage(t, server.URL, nil, `{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+	require.Equal(t, http.StatusOK, init.StatusCode)
+	sessionID := init.Header.Get(SessionHeader)
+	require.NotEmpty(t, sessionID)
+
+	// Responses come back in the body; the notification waits on the stream
+	header := http.Header{SessionHeader: []string{sessionID}}
+	call := postMessage(t, server.URL, header, `{"jsonrpc":"2.0","id":2,"method":"tools/call"}`)
+	require.Equal(t, http.StatusOK, call.StatusCode)
+
+	session, ok := sessions.Get(sessionID)
+	require.True(t, ok)
+	require.NoError(t, session.Notify("notifications/later", nil))
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, http.NoBody)
+	require.NoError(t, err)
+	req.Header.Set(SessionHeader, sessionID)
+	req.Header.Set("Last-Event-ID", "2")
+	_, reader := openStream(t, req)
+
+	events := readEvents(t, reader, 1)
+	assert.Equal(t, "3", events[0].id)
+	assert.Contains(t, events[0].data, "notifications/later")
+}
+
+func TestHandlerRejectsUnknownAndForeignSessions(t *testing.T) {
+	sessions := NewManager(0, 0)
+	handler := NewHandler(sessions, echoHandler{}, "/sse")
+
+	req := httptest.NewRequest(http.MethodPost, "/sse", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	req.Header.Set(SessionHeader, "missing")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	session, err := sessions.Create("alice")
+	require.NoError(t, err)
+
+	req = httptest.NewRequest(http.MethodPost, "/sse", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	req = req.WithContext(security.WithPrincipal(req.Context(), security.Principal{UserID: "bob"}))
+	req.Header.Set(SessionHeader, session.ID)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/sse", http.NoBody)
+	req = req.WithContext(security.WithPrincipal(req.Context(), security.Principal{UserID: "alice"}))
+	req.Header.Set(SessionHeader, session.ID)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Zero(t, sessions.Count())
+}
+
+func TestSessionReplayBufferIsBounded(t *testing.T) {
+	session := newSession("s1", "", 2)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, session.Notify("notifications/test", i))
+	}
+
+	replay, _, complete := session.attach(1)
+	assert.False(t, complete)
+	require.Len(t, replay, 2)
+	assert.Equal(t, uint64(4), replay[0].ID)
+	assert.Equal(t, uint64(5), replay[1].ID)
+
+	replay, _, complete = session.attach(4)
+	assert.True(t, complete)
+	require.Len(t, replay, 1)
+	assert.Equal(t, uint64(5), replay[0].ID)
+}
+
+func TestManagerClosesIdleSessions(t *testing.T) {
+	sessions := NewManager(0, time.Minute)
+	idle, err := sessions.Create("")
+	require.NoError(t, err)
+	active, err := sessions.Create("")
+	require.NoError(t, err)
+	active.attach(0)
+
+	sessions.closeIdle(time.Now().Add(2 * time.Minute))
+
+	_, ok := sessions.Get(idle.ID)
+	assert.False(t, ok)
+	assert.Error(t, idle.Context().Err())
+	_, ok = sessions.Get(active.ID)
+	assert.True(t, ok)
+}
@@ -0,0 +1,134 @@
+package sse
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Manager keeps the open sessions and closes those whose stream has been
+// gone longer than the idle timeout
+type Manager struct {
+	mu          sync.RWMutex
+	sessions    map[string]*Session
+	bufferSize  int
+	idleTimeout time.Duration
+	maxSessions int
+}
+
+// NewManager creates a session manager. Zero values select the defaults.
+func NewManager(bufferSize int, idleTimeout time.Duration) *Manager {
+	if bufferSize <= 0 {
+		bufferSize = DefaultReplayBufferSize
+	}
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultSessionIdleTimeout
+	}
+	return &Manager{
+		sessions:    make(map[string]*Session),
+		bufferSize:  bufferSize,
+		idleTimeout: idleTimeout,
+		maxSessions: DefaultMaxSessions,
+	}
+}
+
+// Create opens a session for userID, which is empty without access control
+func (m *Manager) Create(userID string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.sessions) >= m.maxSessions {
+		return nil, ErrTooManySessions
+	}
+
+	session := newSession(uuid.New().String(), userID, m.bufferSize)
+	m.sessions[session.ID] = session
+	return session, nil
+}
+
+// Get returns an open session
+func (m *Manager) Get(id string) (*Session, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	session, ok := m.sessions[id]
+	return session, ok
+}
+
+// Close ends a session and its stream
+func (m *Manager) Close(id string) bool {
+	m.mu.Lock()
+	session, ok := m.sessions[id]
+	delete(m.sessions, id)
+	m.mu.Unlock()
+
+	if ok {
+		session.close()
+	}
+	return ok
+}
+
+// Broadcast sends a notification to every session
+func (m *Manager) Broadcast(method string, params interface{}) {
+	m.mu.RLock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		sessions = append(sessions, session)
+	}
+	m.mu.RUnlock()
+
+	for _, session := range sessions {
+		if err := session.Notify(method, params); err != nil {
+			log.Printf("Failed to notify SSE session %s: %v", session.ID, err)
+		}
+	}
+}
+
+// Count returns the number of open sessions
+func (m *Manager) Count() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.sessions)
+}
+
+// Run closes idle sessions until ctx is done, then closes all of them
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.idleTimeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.closeIdle(time.Now())
+		case <-ctx.Done():
+			m.mu.Lock()
+			sessions := m.sessions
+			m.sessions = make(map[string]*Session)
+			m.mu.Unlock()
+			for _, session := range sessions {
+				session.close()
+			}
+			return
+		}
+	}
+}
+
+func (m *Manager) closeIdle(now time.Time) {
+	m.mu.Lock()
+	var idle []*Session
+	for id, session := range m.sessions {
+		if since, detached := session.idleSince(); detached && now.Sub(since) > m.idleTimeout {
+			idle = append(idle, session)
+			delete(m.sessions, id)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, session := range idle {
+		session.close()
+	}
+	if len(idle) > 0 {
+		log.Printf("Closed %d idle SSE sessions", len(idle))
+	}
+}
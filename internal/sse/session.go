@@ -0,0 +1,187 @@
+// Package sse implements the MCP server-to-client stream on the HTTP
+// transport: sessions bound to a Server-Sent Events stream, JSON-RPC
+// responses and notifications delivered on that stream, and Last-Event-ID
+// resumption from a bounded replay buffer.
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultReplayBufferSize is how many events a session keeps for
+	// clients that reconnect with Last-Event-ID
+	DefaultReplayBufferSize = 256
+
+	// DefaultSessionIdleTimeout is how long a session survives without a
+	// connected stream before it is closed
+	DefaultSessionIdleTimeout = 10 * time.Minute
+
+	// DefaultMaxSessions caps concurrent sessions
+	DefaultMaxSessions = 1000
+
+	// liveQueueSize is how many events may wait for a slow stream. A stream
+	// that falls further behind is closed; the client resumes from the
+	// replay buffer.
+	liveQueueSize = 64
+)
+
+// ErrTooManySessions is returned when the session limit is reached
+var ErrTooManySessions = errors.New("too many SSE sessions")
+
+// Event is a message published on a session stream
+type Event struct {
+	ID   uint64
+	Data []byte
+}
+
+// notification is a JSON-RPC notification sent by the server
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// Session is a client connection that outlives individual HTTP requests.
+// Messages published to it are numbered, buffered for replay and written to
+// the attached stream, if any.
+type Session struct {
+	ID     string
+	UserID string
+
+	mu         sync.Mutex
+	nextID     uint64
+	buffer     []Event
+	bufferSize int
+	live       chan Event
+	detachedAt time.Time
+	closed     bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newSession(id, userID string, bufferSize int) *Session {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Session{
+		ID:         id,
+		UserID:     userID,
+		bufferSize: bufferSize,
+		buffer:     make([]Event, 0, bufferSize),
+		detachedAt: time.Now(),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Context is cancelled when the session closes. Requests answered on the
+// stream run under it, so they are not cut short when their POST returns.
+func (s *Session) Context() context.Context {
+	return s.ctx
+}
+
+// Publish sends a JSON-RPC message on the session stream
+func (s *Session) Publish(message interface{}) (uint64, error) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal SSE message: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, errors.New("session is closed")
+	}
+
+	s.nextID++
+	event := Event{ID: s.nextID, Data: data}
+	if len(s.buffer) == s.bufferSize {
+		copy(s.buffer, s.buffer[1:])
+		s.buffer = s.buffer[:len(s.buffer)-1]
+	}
+	s.buffer = append(s.buffer, event)
+
+	if s.live != nil {
+		select {
+		case s.live <- event:
+		default:
+			// Too slow to keep up; the client reconnects and replays
+			s.detachLocked()
+		}
+	}
+	return event.ID, nil
+}
+
+// Notify sends a JSON-RPC notification on the session stream
+func (s *Session) Notify(method string, params interface{}) error {
+	_, err := s.Publish(notification{JSONRPC: "2.0", Method: method, Params: params})
+	return err
+}
+
+// attach makes a new stream the session's only stream and returns the
+// buffered events after lastEventID. complete is false when some of those
+// events have already left the buffer.
+func (s *Session) attach(lastEventID uint64) (replay []Event, live <-chan Event, complete bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A reconnect replaces a stream the server has not noticed is gone
+	s.detachLocked()
+	s.live = make(chan Event, liveQueueSize)
+
+	complete = true
+	if len(s.buffer) > 0 && s.buffer[0].ID > lastEventID+1 && lastEventID < s.nextID {
+		complete = false
+	}
+	for _, event := range s.buffer {
+		if event.ID > lastEventID {
+			replay = append(replay, event)
+		}
+	}
+	return replay, s.live, complete
+}
+
+// detach disconnects live if it is still the session's stream
+func (s *Session) detach(live <-chan Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.live != nil && (<-chan Event)(s.live) == live {
+		s.detachLocked()
+	}
+}
+
+func (s *Session) detachLocked() {
+	if s.live == nil {
+		return
+	}
+	close(s.live)
+	s.live = nil
+	s.detachedAt = time.Now()
+}
+
+// idleSince reports when the session lost its stream, or false if a stream
+// is attached
+func (s *Session) idleSince() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.live != nil {
+		return time.Time{}, false
+	}
+	return s.detachedAt, true
+}
+
+func (s *Session) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.detachLocked()
+	s.cancel()
+}
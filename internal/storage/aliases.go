@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DocumentKindAlias groups alias records in a DocumentStore
+const DocumentKindAlias = "aliases"
+
+// AliasStore persists alias records. PostgresStore keeps them in their own
+// table; other backends keep them as documents.
+type AliasStore interface {
+	StoreAlias(ctx context.Context, record *AliasRecord) error
+	ListAliases(ctx context.Context) ([]AliasRecord, error)
+	DeleteAlias(ctx context.Context, aliasID string) error
+}
+
+// Ensure both alias stores satisfy the interface
+var (
+	_ AliasStore = (*PostgresStore)(nil)
+	_ AliasStore = (*DocumentAliasStore)(nil)
+)
+
+// FindAliasStore returns the alias store of the backend behind store,
+// falling back to its documents. It reports false when the backend can
+// persist neither.
+func FindAliasStore(store VectorStore) (AliasStore, bool) {
+	if aliases, ok := unwrapStore[AliasStore](store); ok {
+		return aliases, true
+	}
+	if documents, ok := FindDocumentStore(store); ok {
+		return NewDocumentAliasStore(documents), true
+	}
+	return nil, false
+}
+
+// DocumentAliasStore persists alias records in a DocumentStore
+type DocumentAliasStore struct {
+	documents DocumentStore
+}
+
+// NewDocumentAliasStore creates an alias store on top of documents
+func NewDocumentAliasStore(documents DocumentStore) *DocumentAliasStore {
+	return &DocumentAliasStore{documents: documents}
+}
+
+// StoreAlias inserts or replaces an alias record
+func (s *DocumentAliasStore) StoreAlias(ctx context.Context, record *AliasRecord) error {
+	if record == nil || record.ID == "" || record.Name == "" {
+		return errors.New("alias ID and name cannot be empty")
+	}
+	if !json.Valid(record.Data) {
+		return errors.New("alias data must be valid JSON")
+	}
+
+	stored := *record
+	if stored.UpdatedAt.IsZero() {
+		stored.UpdatedAt = time.Now()
+	}
+
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alias: %w", err)
+	}
+	if err := s.documents.PutDocument(ctx, DocumentKindAlias, stored.ID, data); err != nil {
+		return fmt.Errorf("failed to store alias: %w", err)
+	}
+	return nil
+}
+
+// ListAliases returns every persisted alias record ordered by name
+func (s *DocumentAliasStore) ListAliases(ctx context.Context) ([]AliasRecord, error) {
+	documents, err := s.documents.ListDocuments(ctx, DocumentKindAlias)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aliases: %w", err)
+	}
+
+	records := make([]AliasRecord, 0, len(documents))
+	for _, data := range documents {
+		var record AliasRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("failed to decode alias: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Name < records[j].Name
+	})
+	return records, nil
+}
+
+// DeleteAlias removes an alias record. Deleting a missing alias is not an error.
+func (s *DocumentAliasStore) DeleteAlias(ctx context.Context, aliasID string) error {
+	err := s.documents.DeleteDocument(ctx, DocumentKindAlias, aliasID)
+	if err != nil && !errors.Is(err, ErrDocumentNotFound) {
+		return fmt.Errorf("failed to delete alias: %w", err)
+	}
+	return nil
+}
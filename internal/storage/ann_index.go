@@ -0,0 +1,303 @@
+package storage
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// annIndex is an approximate nearest neighbour index based on random
+// hyperplane locality-sensitive hashing. Each table hashes a vector to a
+// bucket using the signs of its projections onto random hyperplanes, so
+// vectors with a small cosine distance tend to share buckets.
+//
+// The hyperplanes are derived from a persisted seed, which keeps the on-disk
+// representation down to the seed and the bucket membership lists.
+type annIndex struct {
+	mu        sync.RWMutex
+	dimension int
+	tables    int
+	bits      int
+	seed      int64
+	planes    [][][]float32                // tables x bits x dimension
+	buckets   []map[uint64]map[string]bool // tables x bucket -> ids
+	size      int
+}
+
+// annIndexFile is the gob-encoded on-disk form of an annIndex
+type annIndexFile struct {
+	Version   int
+	Dimension int
+	Tables    int
+	Bits      int
+	Seed      int64
+	Size      int
+	Buckets   []map[uint64][]string
+}
+
+const annIndexFileVersion = 1
+
+// newANNIndex creates an empty index; the dimension is fixed by the first vector added
+func newANNIndex(tables, bits int, seed int64) *annIndex {
+	if tables <= 0 {
+		tables = 8
+	}
+	if bits <= 0 || bits > 64 {
+		bits = 12
+	}
+	idx := &annIndex{
+		tables: tables,
+		bits:   bits,
+		seed:   seed,
+	}
+	idx.resetBuckets()
+	return idx
+}
+
+// resetBuckets clears all bucket membership
+func (idx *annIndex) resetBuckets() {
+	idx.buckets = make([]map[uint64]map[string]bool, idx.tables)
+	for i := range idx.buckets {
+		idx.buckets[i] = make(map[uint64]map[string]bool)
+	}
+	idx.size = 0
+}
+
+// ensureDimension fixes the index dimension and generates hyperplanes
+func (idx *annIndex) ensureDimension(dimension int) error {
+	if dimension <= 0 {
+		return errors.New("vector dimension must be positive")
+	}
+	if idx.dimension == 0 {
+		idx.dimension = dimension
+	}
+	if idx.dimension != dimension {
+		return fmt.Errorf("vector dimension mismatch: index uses %d, got %d", idx.dimension, dimension)
+	}
+	if idx.planes == nil {
+		idx.generatePlanes()
+	}
+	return nil
+}
+
+// generatePlanes deterministically creates the random hyperplanes from the seed
+func (idx *annIndex) generatePlanes() {
+	rng := rand.New(rand.NewSource(idx.seed)) // #nosec G404 -- hashing, not security sensitive
+	idx.planes = make([][][]float32, idx.tables)
+	for t := 0; t < idx.tables; t++ {
+		idx.planes[t] = make([][]float32, idx.bits)
+		for b := 0; b < idx.bits; b++ {
+			plane := make([]float32, idx.dimension)
+			for d := range plane {
+				plane[d] = float32(rng.NormFloat64())
+			}
+			idx.planes[t][b] = plane
+		}
+	}
+}
+
+// hash computes the bucket key of a vector for one table
+func (idx *annIndex) hash(table int, vector []float64) uint64 {
+	var key uint64
+	for b, plane := range idx.planes[table] {
+		var dot float64
+		for d, v := range vector {
+			dot += v * float64(plane[d])
+		}
+		if dot >= 0 {
+			key |= 1 << uint(b)
+		}
+	}
+	return key
+}
+
+// Add inserts a vector under the given ID
+func (idx *annIndex) Add(id string, vector []float64) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if err := idx.ensureDimension(len(vector)); err != nil {
+		return err
+	}
+
+	for t := 0; t < idx.tables; t++ {
+		key := idx.hash(t, vector)
+		bucket, ok := idx.buckets[t][key]
+		if !ok {
+			bucket = make(map[string]bool)
+			idx.buckets[t][key] = bucket
+		}
+		if t == 0 && !bucket[id] {
+			idx.size++
+		}
+		bucket[id] = true
+	}
+	return nil
+}
+
+// Remove deletes a vector previously added under the given ID
+func (idx *annIndex) Remove(id string, vector []float64) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.planes == nil || len(vector) != idx.dimension {
+		return
+	}
+
+	for t := 0; t < idx.tables; t++ {
+		key := idx.hash(t, vector)
+		bucket, ok := idx.buckets[t][key]
+		if !ok {
+			continue
+		}
+		if t == 0 && bucket[id] {
+			idx.size--
+		}
+		delete(bucket, id)
+		if len(bucket) == 0 {
+			delete(idx.buckets[t], key)
+		}
+	}
+}
+
+// Candidates returns the IDs sharing a bucket with the query vector in any
+// table, also probing buckets one bit away to improve recall.
+func (idx *annIndex) Candidates(vector []float64) map[string]bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	candidates := make(map[string]bool)
+	if idx.planes == nil || len(vector) != idx.dimension {
+		return candidates
+	}
+
+	for t := 0; t < idx.tables; t++ {
+		key := idx.hash(t, vector)
+		for id := range idx.buckets[t][key] {
+			candidates[id] = true
+		}
+		for b := 0; b < idx.bits; b++ {
+			for id := range idx.buckets[t][key^(1<<uint(b))] {
+				candidates[id] = true
+			}
+		}
+	}
+	return candidates
+}
+
+// Size returns the number of indexed vectors
+func (idx *annIndex) Size() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.size
+}
+
+// Dimension returns the vector dimension of the index, or 0 if empty
+func (idx *annIndex) Dimension() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.dimension
+}
+
+// Save writes the index atomically to path
+func (idx *annIndex) Save(path string) error {
+	idx.mu.RLock()
+	file := annIndexFile{
+		Version:   annIndexFileVersion,
+		Dimension: idx.dimension,
+		Tables:    idx.tables,
+		Bits:      idx.bits,
+		Seed:      idx.seed,
+		Size:      idx.size,
+		Buckets:   make([]map[uint64][]string, idx.tables),
+	}
+	for t, table := range idx.buckets {
+		file.Buckets[t] = make(map[uint64][]string, len(table))
+		for key, bucket := range table {
+			ids := make([]string, 0, len(bucket))
+			for id := range bucket {
+				ids = append(ids, id)
+			}
+			file.Buckets[t][key] = ids
+		}
+	}
+	idx.mu.RUnlock()
+
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(filepath.Clean(tmpPath), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create index file: %w", err)
+	}
+	if err := gob.NewEncoder(f).Encode(&file); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close index file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace index file: %w", err)
+	}
+	return nil
+}
+
+// loadANNIndex reads an index previously written by Save. Indexes built with
+// different table or bit counts are rejected so they can be rebuilt.
+func loadANNIndex(path string, tables, bits int) (*annIndex, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var file annIndexFile
+	if err := gob.NewDecoder(f).Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to decode index: %w", err)
+	}
+	if file.Version != annIndexFileVersion {
+		return nil, fmt.Errorf("unsupported index version %d", file.Version)
+	}
+	if file.Tables != tables || file.Bits != bits || len(file.Buckets) != file.Tables {
+		return nil, errors.New("index parameters changed")
+	}
+
+	idx := newANNIndex(file.Tables, file.Bits, file.Seed)
+	if file.Dimension > 0 {
+		if err := idx.ensureDimension(file.Dimension); err != nil {
+			return nil, err
+		}
+	}
+	for t, table := range file.Buckets {
+		for key, ids := range table {
+			bucket := make(map[string]bool, len(ids))
+			for _, id := range ids {
+				bucket[id] = true
+			}
+			idx.buckets[t][key] = bucket
+		}
+	}
+	idx.size = file.Size
+	return idx, nil
+}
+
+// cosineSimilarity returns the cosine similarity of two equal-length vectors
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -35,6 +35,16 @@ func NewCircuitBreakerVectorStore(store VectorStore, config *circuitbreaker.Conf
 	}
 }
 
+// FindCircuitBreakerStore unwraps store until it finds the circuit breaker
+func FindCircuitBreakerStore(store VectorStore) (*CircuitBreakerVectorStore, bool) {
+	return unwrapStore[*CircuitBreakerVectorStore](store)
+}
+
+// Unwrap returns the wrapped store
+func (s *CircuitBreakerVectorStore) Unwrap() VectorStore {
+	return s.store
+}
+
 // Initialize initializes the store
 func (s *CircuitBreakerVectorStore) Initialize(ctx context.Context) error {
 	return s.cb.Execute(ctx, func(ctx context.Context) error {
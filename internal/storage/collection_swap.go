@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// ShadowCollectionStore is implemented by stores that can rebuild their
+// vectors in a shadow collection and then switch to it atomically. This is
+// how an installation changes embedding model: vectors of one dimension can
+// never be written into a collection created for another.
+type ShadowCollectionStore interface {
+	// ActiveCollection returns the collection currently serving reads and writes
+	ActiveCollection() string
+
+	// OpenShadowCollection creates the named collection for vectors of the
+	// given dimension if it does not exist, and returns a store bound to it.
+	// Existing contents are kept so an interrupted rebuild can resume.
+	OpenShadowCollection(ctx context.Context, name string, dimension int) (VectorStore, error)
+
+	// PromoteCollection atomically makes the named collection active and
+	// returns the name under which the previous collection is kept
+	PromoteCollection(ctx context.Context, name string) (string, error)
+
+	// DropCollection deletes an inactive collection
+	DropCollection(ctx context.Context, name string) error
+}
+
+// shadowCollectionNamePattern keeps shadow names valid as Qdrant collection
+// names, directory names and unquoted PostgreSQL identifiers
+var shadowCollectionNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// validateShadowCollectionName checks a shadow or previous collection name
+func validateShadowCollectionName(name string) error {
+	if !shadowCollectionNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid collection name %q: use lowercase letters, digits and underscores", name)
+	}
+	return nil
+}
+
+// FindShadowCollectionStore unwraps store until it finds one that supports
+// shadow collections
+func FindShadowCollectionStore(store VectorStore) (ShadowCollectionStore, bool) {
+	return unwrapStore[ShadowCollectionStore](store)
+}
+
+// unwrapStore returns the first store in a wrapper chain implementing T
+func unwrapStore[T any](store VectorStore) (T, bool) {
+	for store != nil {
+		if found, ok := store.(T); ok {
+			return found, true
+		}
+		unwrapper, ok := store.(vectorStoreUnwrapper)
+		if !ok {
+			break
+		}
+		store = unwrapper.Unwrap()
+	}
+
+	var zero T
+	return zero, false
+}
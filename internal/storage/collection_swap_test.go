@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"lerian-mcp-memory/pkg/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStoreShadowCollectionSwap(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	store := newTestLocalStore(t, dataDir)
+
+	now := time.Now()
+	first := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{1, 0, 0}, now)
+	second := newLocalTestChunk("github.com/acme/api", types.ChunkTypeSolution, []float64{0, 1, 0}, now)
+	require.NoError(t, store.Store(ctx, first))
+	require.NoError(t, store.Store(ctx, second))
+	relationship, err := store.StoreRelationship(ctx, first.ID, second.ID, types.RelationSolvedBy, 0.9, types.ConfidenceExplicit)
+	require.NoError(t, err)
+
+	swapper, ok := FindShadowCollectionStore(NewRetryableVectorStore(store, nil))
+	require.True(t, ok, "wrappers are unwrapped")
+	assert.Equal(t, "test_memory", swapper.ActiveCollection())
+
+	_, err = swapper.OpenShadowCollection(ctx, "Bad-Name", 4)
+	assert.Error(t, err)
+	_, err = swapper.OpenShadowCollection(ctx, "test_memory", 4)
+	assert.Error(t, err)
+
+	shadow, err := swapper.OpenShadowCollection(ctx, "test_memory_v2", 4)
+	require.NoError(t, err)
+	for _, chunk := range []*types.ConversationChunk{first, second} {
+		copied := *chunk
+		copied.Embeddings = []float64{0, 0, 0, 1}
+		require.NoError(t, shadow.Store(ctx, &copied))
+	}
+	require.NoError(t, shadow.Close())
+
+	_, err = swapper.OpenShadowCollection(ctx, "test_memory_v2", 3)
+	assert.ErrorIs(t, err, ErrVectorDimensionMismatch, "reopening checks the existing dimension")
+
+	previous, err := swapper.PromoteCollection(ctx, "test_memory_v2")
+	require.NoError(t, err)
+	assert.Equal(t, "test_memory", previous)
+	assert.Equal(t, "test_memory_v2", store.ActiveCollection())
+
+	dimension, err := store.VectorDimension(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, dimension)
+	got, err := store.GetRelationshipByID(ctx, relationship.ID)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, got.SourceChunkID, "relationships follow the promotion")
+
+	assert.Error(t, swapper.DropCollection(ctx, "test_memory_v2"), "active collection cannot be dropped")
+	require.NoError(t, store.Close())
+
+	// A restarted store follows the pointer file
+	reopened := newTestLocalStore(t, dataDir)
+	defer func() { _ = reopened.Close() }()
+	assert.Equal(t, "test_memory_v2", reopened.ActiveCollection())
+	chunks, err := reopened.GetAllChunks(ctx)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 2)
+
+	require.NoError(t, reopened.DropCollection(ctx, previous))
+	collections, err := reopened.ListCollections(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test_memory_v2"}, collections)
+}
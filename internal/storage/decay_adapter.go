@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"lerian-mcp-memory/internal/decay"
+	"lerian-mcp-memory/pkg/types"
+)
+
+// decayPageSize is how many chunks DecayStorageAdapter lists at a time
+const decayPageSize = 500
+
+// ChunkEmbedder generates the vectors for chunks stored without them
+type ChunkEmbedder interface {
+	GenerateEmbedding(ctx context.Context, text string) ([]float64, error)
+}
+
+// DecayStorageAdapter adapts VectorStore to decay.MemoryStore
+type DecayStorageAdapter struct {
+	store    VectorStore
+	embedder ChunkEmbedder
+}
+
+// NewDecayStorageAdapter creates a decay storage adapter. Summary chunks
+// written by decay are embedded with embedder before they are stored.
+func NewDecayStorageAdapter(store VectorStore, embedder ChunkEmbedder) decay.MemoryStore {
+	return &DecayStorageAdapter{
+		store:    store,
+		embedder: embedder,
+	}
+}
+
+// GetAllChunks lists every chunk in a repository
+func (d *DecayStorageAdapter) GetAllChunks(ctx context.Context, repository string) ([]types.ConversationChunk, error) {
+	var chunks []types.ConversationChunk
+	for offset := 0; ; offset += decayPageSize {
+		page, err := d.store.ListByRepository(ctx, repository, decayPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, page...)
+		if len(page) < decayPageSize {
+			return chunks, nil
+		}
+	}
+}
+
+// UpdateChunk updates a chunk
+func (d *DecayStorageAdapter) UpdateChunk(ctx context.Context, chunk *types.ConversationChunk) error {
+	return d.store.Update(ctx, chunk)
+}
+
+// DeleteChunk deletes a chunk
+func (d *DecayStorageAdapter) DeleteChunk(ctx context.Context, chunkID string) error {
+	return d.store.Delete(ctx, chunkID)
+}
+
+// StoreChunk embeds a chunk when it has no vector and stores it
+func (d *DecayStorageAdapter) StoreChunk(ctx context.Context, chunk *types.ConversationChunk) error {
+	if len(chunk.Embeddings) == 0 && d.embedder != nil {
+		embedding, err := d.embedder.GenerateEmbedding(ctx, chunk.Content)
+		if err != nil {
+			return fmt.Errorf("failed to embed summary: %w", err)
+		}
+		chunk.Embeddings = embedding
+	}
+	return d.store.Store(ctx, chunk)
+}
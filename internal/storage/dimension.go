@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrVectorDimensionMismatch is returned when the embedding provider and the
+// stored collection disagree on vector size
+var ErrVectorDimensionMismatch = errors.New("vector dimension mismatch")
+
+// VectorDimensionReporter is implemented by stores that can report the vector
+// size of their active collection. A dimension of 0 means the collection has
+// no fixed size yet, for example an empty local store.
+type VectorDimensionReporter interface {
+	VectorDimension(ctx context.Context) (int, error)
+}
+
+// vectorStoreUnwrapper is implemented by wrappers that delegate to another store
+type vectorStoreUnwrapper interface {
+	Unwrap() VectorStore
+}
+
+// VerifyVectorDimension checks that the store's collection uses the expected
+// dimension. Wrappers are unwrapped until a store reports its dimension;
+// stores that cannot report one are accepted.
+func VerifyVectorDimension(ctx context.Context, store VectorStore, expected int) error {
+	reporter, ok := unwrapStore[VectorDimensionReporter](store)
+	if !ok {
+		return nil
+	}
+
+	actual, err := reporter.VectorDimension(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read collection vector dimension: %w", err)
+	}
+	if actual != 0 && actual != expected {
+		return fmt.Errorf("%w: collection uses %d dimensions but the embedding provider produces %d; re-embed the collection or switch back to the previous provider",
+			ErrVectorDimensionMismatch, actual, expected)
+	}
+	return nil
+}
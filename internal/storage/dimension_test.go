@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"context"
+	"lerian-mcp-memory/pkg/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyVectorDimension(t *testing.T) {
+	ctx := context.Background()
+	local := newTestLocalStore(t, t.TempDir())
+	defer func() { _ = local.Close() }()
+
+	// Same wrapping order as the DI container
+	wrapped := NewLexicalIndexedStore(NewCircuitBreakerVectorStore(NewRetryableVectorStore(local, nil), nil), NewLexicalIndex())
+
+	require.NoError(t, VerifyVectorDimension(ctx, wrapped, 768), "an empty collection accepts any dimension")
+
+	require.NoError(t, local.Store(ctx, newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{1, 0, 0}, time.Now())))
+	require.NoError(t, VerifyVectorDimension(ctx, wrapped, 3))
+
+	err := VerifyVectorDimension(ctx, wrapped, 768)
+	require.ErrorIs(t, err, ErrVectorDimensionMismatch)
+	assert.Contains(t, err.Error(), "collection uses 3 dimensions")
+
+	assert.NoError(t, VerifyVectorDimension(ctx, NewSimpleMockVectorStore(), 768), "stores without a dimension are accepted")
+}
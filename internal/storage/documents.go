@@ -0,0 +1,295 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lerian-mcp-memory/internal/chains"
+	"lerian-mcp-memory/internal/threading"
+	"sort"
+	"time"
+)
+
+// Document kinds kept alongside the vectors
+const (
+	DocumentKindThread = "threads"
+	DocumentKindChain  = "chains"
+)
+
+// ErrDocumentNotFound is returned when a document does not exist
+var ErrDocumentNotFound = errors.New("document not found")
+
+// DocumentStore is implemented by backends that can keep small JSON
+// documents, such as threads and chains, next to the vectors. Documents are
+// grouped by kind and addressed by ID.
+type DocumentStore interface {
+	PutDocument(ctx context.Context, kind, id string, data []byte) error
+	GetDocument(ctx context.Context, kind, id string) ([]byte, error)
+	ListDocuments(ctx context.Context, kind string) ([][]byte, error)
+	DeleteDocument(ctx context.Context, kind, id string) error
+}
+
+// FindDocumentStore unwraps store until it finds one that keeps documents
+func FindDocumentStore(store VectorStore) (DocumentStore, bool) {
+	return unwrapStore[DocumentStore](store)
+}
+
+// Ensure the document-backed stores satisfy the interfaces they replace
+var (
+	_ threading.ThreadStore = (*DocumentThreadStore)(nil)
+	_ chains.ChainStore     = (*DocumentChainStore)(nil)
+)
+
+// DocumentThreadStore persists memory threads in a DocumentStore. Every call
+// reads through to the backend, so several server instances share threads.
+type DocumentThreadStore struct {
+	documents DocumentStore
+}
+
+// NewDocumentThreadStore creates a thread store on top of documents
+func NewDocumentThreadStore(documents DocumentStore) *DocumentThreadStore {
+	return &DocumentThreadStore{documents: documents}
+}
+
+// StoreThread inserts or replaces a thread
+func (s *DocumentThreadStore) StoreThread(ctx context.Context, thread *threading.MemoryThread) error {
+	if thread == nil {
+		return errors.New("thread cannot be nil")
+	}
+	if thread.ID == "" {
+		return errors.New("thread ID cannot be empty")
+	}
+
+	// Update timestamp, like the in-memory store
+	thread.LastUpdate = time.Now()
+	return s.write(ctx, thread)
+}
+
+func (s *DocumentThreadStore) write(ctx context.Context, thread *threading.MemoryThread) error {
+	data, err := json.Marshal(thread)
+	if err != nil {
+		return fmt.Errorf("failed to marshal thread: %w", err)
+	}
+	if err := s.documents.PutDocument(ctx, DocumentKindThread, thread.ID, data); err != nil {
+		return fmt.Errorf("failed to store thread: %w", err)
+	}
+	return nil
+}
+
+// GetThread retrieves a thread by ID
+func (s *DocumentThreadStore) GetThread(ctx context.Context, threadID string) (*threading.MemoryThread, error) {
+	if threadID == "" {
+		return nil, errors.New("thread ID cannot be empty")
+	}
+
+	data, err := s.documents.GetDocument(ctx, DocumentKindThread, threadID)
+	if errors.Is(err, ErrDocumentNotFound) {
+		return nil, fmt.Errorf("thread not found: %s", threadID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread: %w", err)
+	}
+
+	var thread threading.MemoryThread
+	if err := json.Unmarshal(data, &thread); err != nil {
+		return nil, fmt.Errorf("failed to decode thread: %w", err)
+	}
+	return &thread, nil
+}
+
+// GetThreadsByRepository retrieves all threads for a repository
+func (s *DocumentThreadStore) GetThreadsByRepository(ctx context.Context, repository string) ([]*threading.MemoryThread, error) {
+	return s.ListThreads(ctx, threading.ThreadFilters{Repository: &repository})
+}
+
+// GetActiveThreads retrieves all active threads for a repository
+func (s *DocumentThreadStore) GetActiveThreads(ctx context.Context, repository string) ([]*threading.MemoryThread, error) {
+	status := threading.ThreadStatusActive
+	return s.ListThreads(ctx, threading.ThreadFilters{Repository: &repository, Status: &status})
+}
+
+// UpdateThreadStatus updates the status of a thread
+func (s *DocumentThreadStore) UpdateThreadStatus(ctx context.Context, threadID string, status threading.ThreadStatus) error {
+	thread, err := s.GetThread(ctx, threadID)
+	if err != nil {
+		return err
+	}
+
+	thread.Status = status
+	thread.LastUpdate = time.Now()
+
+	// Set end time if thread is being completed
+	if status == threading.ThreadStatusComplete && thread.EndTime == nil {
+		now := time.Now()
+		thread.EndTime = &now
+	}
+
+	return s.write(ctx, thread)
+}
+
+// DeleteThread removes a thread
+func (s *DocumentThreadStore) DeleteThread(ctx context.Context, threadID string) error {
+	if threadID == "" {
+		return errors.New("thread ID cannot be empty")
+	}
+
+	err := s.documents.DeleteDocument(ctx, DocumentKindThread, threadID)
+	if errors.Is(err, ErrDocumentNotFound) {
+		return fmt.Errorf("thread not found: %s", threadID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete thread: %w", err)
+	}
+	return nil
+}
+
+// ListThreads retrieves threads based on filters, most recently updated first
+func (s *DocumentThreadStore) ListThreads(ctx context.Context, filters threading.ThreadFilters) ([]*threading.MemoryThread, error) {
+	documents, err := s.documents.ListDocuments(ctx, DocumentKindThread)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list threads: %w", err)
+	}
+
+	var threads []*threading.MemoryThread
+	for _, data := range documents {
+		var thread threading.MemoryThread
+		if err := json.Unmarshal(data, &thread); err != nil {
+			return nil, fmt.Errorf("failed to decode thread: %w", err)
+		}
+		if filters.Matches(&thread) {
+			threads = append(threads, &thread)
+		}
+	}
+
+	sort.SliceStable(threads, func(i, j int) bool {
+		return threads[i].LastUpdate.After(threads[j].LastUpdate)
+	})
+	return threads, nil
+}
+
+// DocumentChainStore persists memory chains in a DocumentStore
+type DocumentChainStore struct {
+	documents DocumentStore
+}
+
+// NewDocumentChainStore creates a chain store on top of documents
+func NewDocumentChainStore(documents DocumentStore) *DocumentChainStore {
+	return &DocumentChainStore{documents: documents}
+}
+
+// StoreChain stores a memory chain
+func (s *DocumentChainStore) StoreChain(ctx context.Context, chain *chains.MemoryChain) error {
+	if chain == nil || chain.ID == "" {
+		return errors.New("invalid chain")
+	}
+	return s.write(ctx, chain)
+}
+
+func (s *DocumentChainStore) write(ctx context.Context, chain *chains.MemoryChain) error {
+	data, err := json.Marshal(chain)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chain: %w", err)
+	}
+	if err := s.documents.PutDocument(ctx, DocumentKindChain, chain.ID, data); err != nil {
+		return fmt.Errorf("failed to store chain: %w", err)
+	}
+	return nil
+}
+
+// GetChain retrieves a chain by ID
+func (s *DocumentChainStore) GetChain(ctx context.Context, chainID string) (*chains.MemoryChain, error) {
+	data, err := s.documents.GetDocument(ctx, DocumentKindChain, chainID)
+	if errors.Is(err, ErrDocumentNotFound) {
+		return nil, fmt.Errorf("chain not found: %s", chainID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain: %w", err)
+	}
+
+	var chain chains.MemoryChain
+	if err := json.Unmarshal(data, &chain); err != nil {
+		return nil, fmt.Errorf("failed to decode chain: %w", err)
+	}
+	return &chain, nil
+}
+
+// GetChainsByChunkID retrieves all chains containing a specific chunk
+func (s *DocumentChainStore) GetChainsByChunkID(ctx context.Context, chunkID string) ([]*chains.MemoryChain, error) {
+	all, err := s.allChains(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*chains.MemoryChain, 0)
+	for _, chain := range all {
+		for _, id := range chain.ChunkIDs {
+			if id == chunkID {
+				result = append(result, chain)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// UpdateChain updates an existing chain
+func (s *DocumentChainStore) UpdateChain(ctx context.Context, chain *chains.MemoryChain) error {
+	if chain == nil || chain.ID == "" {
+		return errors.New("invalid chain")
+	}
+	if _, err := s.GetChain(ctx, chain.ID); err != nil {
+		return err
+	}
+	return s.write(ctx, chain)
+}
+
+// DeleteChain deletes a chain
+func (s *DocumentChainStore) DeleteChain(ctx context.Context, chainID string) error {
+	err := s.documents.DeleteDocument(ctx, DocumentKindChain, chainID)
+	if errors.Is(err, ErrDocumentNotFound) {
+		return fmt.Errorf("chain not found: %s", chainID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete chain: %w", err)
+	}
+	return nil
+}
+
+// ListChains lists chains newest first with pagination
+func (s *DocumentChainStore) ListChains(ctx context.Context, limit, offset int) ([]*chains.MemoryChain, error) {
+	all, err := s.allChains(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].CreatedAt.After(all[j].CreatedAt)
+	})
+
+	if offset > len(all) {
+		return []*chains.MemoryChain{}, nil
+	}
+	end := offset + limit
+	if end > len(all) {
+		end = len(all)
+	}
+	return all[offset:end], nil
+}
+
+func (s *DocumentChainStore) allChains(ctx context.Context) ([]*chains.MemoryChain, error) {
+	documents, err := s.documents.ListDocuments(ctx, DocumentKindChain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chains: %w", err)
+	}
+
+	result := make([]*chains.MemoryChain, 0, len(documents))
+	for _, data := range documents {
+		var chain chains.MemoryChain
+		if err := json.Unmarshal(data, &chain); err != nil {
+			return nil, fmt.Errorf("failed to decode chain: %w", err)
+		}
+		result = append(result, &chain)
+	}
+	return result, nil
+}
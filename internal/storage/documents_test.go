@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"lerian-mcp-memory/internal/chains"
+	"lerian-mcp-memory/internal/threading"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentThreadStoreSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store := newTestLocalStore(t, dir)
+	documents, ok := FindDocumentStore(NewRetryableVectorStore(store, nil))
+	require.True(t, ok)
+	threads := NewDocumentThreadStore(documents)
+
+	require.NoError(t, threads.StoreThread(ctx, &threading.MemoryThread{
+		ID:         "thread-1",
+		Title:      "Fix login",
+		Status:     threading.ThreadStatusActive,
+		Repository: "repo-a",
+		ChunkIDs:   []string{"chunk-1", "chunk-2"},
+		StartTime:  time.Now(),
+	}))
+	require.NoError(t, threads.StoreThread(ctx, &threading.MemoryThread{
+		ID:         "thread-2",
+		Title:      "Refactor",
+		Status:     threading.ThreadStatusActive,
+		Repository: "repo-b",
+		StartTime:  time.Now(),
+	}))
+	require.NoError(t, threads.UpdateThreadStatus(ctx, "thread-1", threading.ThreadStatusComplete))
+	require.NoError(t, store.Close())
+
+	// A fresh store over the same directory sees the same threads
+	reopened := NewDocumentThreadStore(newTestLocalStore(t, dir))
+
+	thread, err := reopened.GetThread(ctx, "thread-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Fix login", thread.Title)
+	assert.Equal(t, threading.ThreadStatusComplete, thread.Status)
+	assert.NotNil(t, thread.EndTime)
+	assert.Equal(t, []string{"chunk-1", "chunk-2"}, thread.ChunkIDs)
+
+	byRepo, err := reopened.GetThreadsByRepository(ctx, "repo-b")
+	require.NoError(t, err)
+	require.Len(t, byRepo, 1)
+	assert.Equal(t, "thread-2", byRepo[0].ID)
+
+	active, err := reopened.GetActiveThreads(ctx, "repo-a")
+	require.NoError(t, err)
+	assert.Empty(t, active)
+
+	require.NoError(t, reopened.DeleteThread(ctx, "thread-2"))
+	_, err = reopened.GetThread(ctx, "thread-2")
+	assert.Error(t, err)
+	assert.Error(t, reopened.DeleteThread(ctx, "thread-2"))
+}
+
+func TestDocumentChainStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store := newTestLocalStore(t, dir)
+	chainStore := NewDocumentChainStore(store)
+
+	base := time.Now()
+	require.NoError(t, chainStore.StoreChain(ctx, &chains.MemoryChain{
+		ID: "chain-old", Name: "old", ChunkIDs: []string{"a", "b"}, CreatedAt: base.Add(-time.Hour),
+	}))
+	require.NoError(t, chainStore.StoreChain(ctx, &chains.MemoryChain{
+		ID: "chain-new", Name: "new", ChunkIDs: []string{"b", "c"}, CreatedAt: base,
+	}))
+	assert.Error(t, chainStore.UpdateChain(ctx, &chains.MemoryChain{ID: "missing"}))
+	require.NoError(t, store.Close())
+
+	chainStore = NewDocumentChainStore(newTestLocalStore(t, dir))
+
+	listed, err := chainStore.ListChains(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, "chain-new", listed[0].ID)
+
+	paged, err := chainStore.ListChains(ctx, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, paged, 1)
+	assert.Equal(t, "chain-old", paged[0].ID)
+
+	withB, err := chainStore.GetChainsByChunkID(ctx, "b")
+	require.NoError(t, err)
+	assert.Len(t, withB, 2)
+
+	withC, err := chainStore.GetChainsByChunkID(ctx, "c")
+	require.NoError(t, err)
+	require.Len(t, withC, 1)
+	assert.Equal(t, "chain-new", withC[0].ID)
+
+	require.NoError(t, chainStore.DeleteChain(ctx, "chain-old"))
+	_, err = chainStore.GetChain(ctx, "chain-old")
+	assert.Error(t, err)
+}
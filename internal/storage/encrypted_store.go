@@ -0,0 +1,484 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"lerian-mcp-memory/internal/logging"
+	"lerian-mcp-memory/internal/security"
+	"lerian-mcp-memory/pkg/types"
+	"time"
+)
+
+// ChunkCipher encrypts the sensitive fields of a chunk: content, summary,
+// modified file paths, task assignee and the text in its extended metadata,
+// such as decision and template fields. Repository, type, tags and the
+// embeddings stay in plaintext so filtering and vector search keep working;
+// embeddings are always computed from the plaintext before storage.
+type ChunkCipher struct {
+	encryption *security.EncryptionManager
+}
+
+// NewChunkCipher creates a chunk cipher backed by an envelope encryption
+// manager from security.OpenEncryptionManager
+func NewChunkCipher(encryption *security.EncryptionManager) *ChunkCipher {
+	return &ChunkCipher{encryption: encryption}
+}
+
+// EncryptChunk returns an encrypted copy of chunk. The chunk itself is left
+// untouched, so callers such as the lexical index keep seeing plaintext.
+func (c *ChunkCipher) EncryptChunk(chunk *types.ConversationChunk) (*types.ConversationChunk, error) {
+	encrypted := *chunk
+	err := c.transform(&encrypted, c.encryption.EncryptField)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt chunk %s: %w", chunk.ID, err)
+	}
+	return &encrypted, nil
+}
+
+// DecryptChunk decrypts chunk in place. Fields written before encryption
+// was enabled are left as they are.
+func (c *ChunkCipher) DecryptChunk(chunk *types.ConversationChunk) error {
+	if err := c.transform(chunk, c.encryption.DecryptField); err != nil {
+		return fmt.Errorf("failed to decrypt chunk %s: %w", chunk.ID, err)
+	}
+	return nil
+}
+
+// NeedsReencryption reports whether a stored chunk has a sensitive field in
+// plaintext or encrypted with a data key other than the active one
+func (c *ChunkCipher) NeedsReencryption(chunk *types.ConversationChunk) bool {
+	activeKeyID := c.encryption.ActiveKeyID()
+	stale := func(value string) bool {
+		if value == "" {
+			return false
+		}
+		keyID, encrypted := security.FieldKeyID(value)
+		return !encrypted || keyID != activeKeyID
+	}
+
+	if stale(chunk.Content) || stale(chunk.Summary) {
+		return true
+	}
+	for _, file := range chunk.Metadata.FilesModified {
+		if stale(file) {
+			return true
+		}
+	}
+	if chunk.Metadata.TaskAssignee != nil && stale(*chunk.Metadata.TaskAssignee) {
+		return true
+	}
+
+	found := false
+	_, _ = transformMetadataValue(chunk.Metadata.ExtendedMetadata, func(value string) (string, error) {
+		found = found || stale(value)
+		return value, nil
+	})
+	return found
+}
+
+// transform applies fn to every sensitive field. Slices and pointers are
+// replaced rather than modified, so copies made by EncryptChunk never share
+// them with the original chunk.
+func (c *ChunkCipher) transform(chunk *types.ConversationChunk, fn func(string) (string, error)) error {
+	var err error
+	if chunk.Content, err = fn(chunk.Content); err != nil {
+		return err
+	}
+	if chunk.Summary, err = fn(chunk.Summary); err != nil {
+		return err
+	}
+
+	if chunk.Metadata.FilesModified != nil {
+		files := make([]string, len(chunk.Metadata.FilesModified))
+		for i, file := range chunk.Metadata.FilesModified {
+			if files[i], err = fn(file); err != nil {
+				return err
+			}
+		}
+		chunk.Metadata.FilesModified = files
+	}
+
+	if chunk.Metadata.TaskAssignee != nil {
+		assignee, err := fn(*chunk.Metadata.TaskAssignee)
+		if err != nil {
+			return err
+		}
+		chunk.Metadata.TaskAssignee = &assignee
+	}
+
+	if chunk.Metadata.ExtendedMetadata != nil {
+		extended, err := transformMetadataValue(chunk.Metadata.ExtendedMetadata, fn)
+		if err != nil {
+			return err
+		}
+		chunk.Metadata.ExtendedMetadata = extended.(map[string]interface{})
+	}
+	return nil
+}
+
+// transformMetadataValue applies fn to every string in an extended metadata
+// value, copying maps and slices on the way. Other values are kept as they
+// are.
+func transformMetadataValue(value interface{}, fn func(string) (string, error)) (interface{}, error) {
+	switch typed := value.(type) {
+	case string:
+		return fn(typed)
+	case []string:
+		values := make([]string, len(typed))
+		for i, item := range typed {
+			var err error
+			if values[i], err = fn(item); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	case []interface{}:
+		values := make([]interface{}, len(typed))
+		for i, item := range typed {
+			var err error
+			if values[i], err = transformMetadataValue(item, fn); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	case map[string]interface{}:
+		values := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			var err error
+			if values[key], err = transformMetadataValue(item, fn); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	default:
+		return value, nil
+	}
+}
+
+// EncryptedStore wraps a VectorStore and encrypts sensitive chunk fields
+// before they reach it, decrypting them again on every read. It sits below
+// the lexical index, which only ever holds plaintext in memory.
+type EncryptedStore struct {
+	store  VectorStore
+	cipher *ChunkCipher
+}
+
+// NewEncryptedStore creates a store that encrypts chunks with cipher
+func NewEncryptedStore(store VectorStore, cipher *ChunkCipher) *EncryptedStore {
+	return &EncryptedStore{
+		store:  store,
+		cipher: cipher,
+	}
+}
+
+// Unwrap returns the wrapped store
+func (s *EncryptedStore) Unwrap() VectorStore {
+	return s.store
+}
+
+// Cipher returns the cipher used for chunks
+func (s *EncryptedStore) Cipher() *ChunkCipher {
+	return s.cipher
+}
+
+func (s *EncryptedStore) decryptChunks(chunks []types.ConversationChunk) error {
+	for i := range chunks {
+		if err := s.cipher.DecryptChunk(&chunks[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Initialize initializes the wrapped store
+func (s *EncryptedStore) Initialize(ctx context.Context) error {
+	return s.store.Initialize(ctx)
+}
+
+// Store encrypts and stores a chunk
+func (s *EncryptedStore) Store(ctx context.Context, chunk *types.ConversationChunk) error {
+	encrypted, err := s.cipher.EncryptChunk(chunk)
+	if err != nil {
+		return err
+	}
+	return s.store.Store(ctx, encrypted)
+}
+
+// Search performs vector similarity search and decrypts the results
+func (s *EncryptedStore) Search(ctx context.Context, query *types.MemoryQuery, embeddings []float64) (*types.SearchResults, error) {
+	results, err := s.store.Search(ctx, query, embeddings)
+	if err != nil || results == nil {
+		return results, err
+	}
+	for i := range results.Results {
+		if err := s.cipher.DecryptChunk(&results.Results[i].Chunk); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// GetByID gets and decrypts a chunk by ID
+func (s *EncryptedStore) GetByID(ctx context.Context, id string) (*types.ConversationChunk, error) {
+	chunk, err := s.store.GetByID(ctx, id)
+	if err != nil || chunk == nil {
+		return chunk, err
+	}
+	if err := s.cipher.DecryptChunk(chunk); err != nil {
+		return nil, err
+	}
+	return chunk, nil
+}
+
+// ListByRepository lists and decrypts chunks by repository
+func (s *EncryptedStore) ListByRepository(ctx context.Context, repository string, limit, offset int) ([]types.ConversationChunk, error) {
+	chunks, err := s.store.ListByRepository(ctx, repository, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return chunks, s.decryptChunks(chunks)
+}
+
+// ListBySession lists and decrypts chunks by session
+func (s *EncryptedStore) ListBySession(ctx context.Context, sessionID string) ([]types.ConversationChunk, error) {
+	chunks, err := s.store.ListBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return chunks, s.decryptChunks(chunks)
+}
+
+// Delete deletes a chunk
+func (s *EncryptedStore) Delete(ctx context.Context, id string) error {
+	return s.store.Delete(ctx, id)
+}
+
+// Update encrypts and updates a chunk
+func (s *EncryptedStore) Update(ctx context.Context, chunk *types.ConversationChunk) error {
+	encrypted, err := s.cipher.EncryptChunk(chunk)
+	if err != nil {
+		return err
+	}
+	return s.store.Update(ctx, encrypted)
+}
+
+// HealthCheck checks the wrapped store
+func (s *EncryptedStore) HealthCheck(ctx context.Context) error {
+	return s.store.HealthCheck(ctx)
+}
+
+// GetStats gets store statistics
+func (s *EncryptedStore) GetStats(ctx context.Context) (*StoreStats, error) {
+	return s.store.GetStats(ctx)
+}
+
+// Cleanup removes old chunks
+func (s *EncryptedStore) Cleanup(ctx context.Context, retentionDays int) (int, error) {
+	return s.store.Cleanup(ctx, retentionDays)
+}
+
+// Close closes the wrapped store
+func (s *EncryptedStore) Close() error {
+	return s.store.Close()
+}
+
+// GetAllChunks gets and decrypts all chunks
+func (s *EncryptedStore) GetAllChunks(ctx context.Context) ([]types.ConversationChunk, error) {
+	chunks, err := s.store.GetAllChunks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return chunks, s.decryptChunks(chunks)
+}
+
+// DeleteCollection deletes a collection
+func (s *EncryptedStore) DeleteCollection(ctx context.Context, collection string) error {
+	return s.store.DeleteCollection(ctx, collection)
+}
+
+// ListCollections lists all collections
+func (s *EncryptedStore) ListCollections(ctx context.Context) ([]string, error) {
+	return s.store.ListCollections(ctx)
+}
+
+// FindSimilar finds and decrypts similar chunks
+func (s *EncryptedStore) FindSimilar(ctx context.Context, content string, chunkType *types.ChunkType, limit int) ([]types.ConversationChunk, error) {
+	chunks, err := s.store.FindSimilar(ctx, content, chunkType, limit)
+	if err != nil {
+		return nil, err
+	}
+	return chunks, s.decryptChunks(chunks)
+}
+
+// StoreChunk encrypts and stores a chunk
+func (s *EncryptedStore) StoreChunk(ctx context.Context, chunk *types.ConversationChunk) error {
+	encrypted, err := s.cipher.EncryptChunk(chunk)
+	if err != nil {
+		return err
+	}
+	return s.store.StoreChunk(ctx, encrypted)
+}
+
+// BatchStore encrypts and stores chunks
+func (s *EncryptedStore) BatchStore(ctx context.Context, chunks []*types.ConversationChunk) (*BatchResult, error) {
+	encrypted := make([]*types.ConversationChunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk == nil {
+			encrypted = append(encrypted, nil)
+			continue
+		}
+		encryptedChunk, err := s.cipher.EncryptChunk(chunk)
+		if err != nil {
+			return nil, err
+		}
+		encrypted = append(encrypted, encryptedChunk)
+	}
+	return s.store.BatchStore(ctx, encrypted)
+}
+
+// BatchDelete deletes chunks
+func (s *EncryptedStore) BatchDelete(ctx context.Context, ids []string) (*BatchResult, error) {
+	return s.store.BatchDelete(ctx, ids)
+}
+
+// StoreRelationship stores a relationship
+func (s *EncryptedStore) StoreRelationship(ctx context.Context, sourceID, targetID string, relationType types.RelationType, confidence float64, source types.ConfidenceSource) (*types.MemoryRelationship, error) {
+	return s.store.StoreRelationship(ctx, sourceID, targetID, relationType, confidence, source)
+}
+
+// GetRelationships gets relationships and decrypts the chunks they include
+func (s *EncryptedStore) GetRelationships(ctx context.Context, query *types.RelationshipQuery) ([]types.RelationshipResult, error) {
+	results, err := s.store.GetRelationships(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		for _, chunk := range []*types.ConversationChunk{results[i].SourceChunk, results[i].TargetChunk} {
+			if chunk == nil {
+				continue
+			}
+			if err := s.cipher.DecryptChunk(chunk); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return results, nil
+}
+
+// TraverseGraph traverses the graph and decrypts the chunks it includes
+func (s *EncryptedStore) TraverseGraph(ctx context.Context, startChunkID string, maxDepth int, relationTypes []types.RelationType) (*types.GraphTraversalResult, error) {
+	result, err := s.store.TraverseGraph(ctx, startChunkID, maxDepth, relationTypes)
+	if err != nil || result == nil {
+		return result, err
+	}
+	for i := range result.Nodes {
+		if result.Nodes[i].Chunk == nil {
+			continue
+		}
+		if err := s.cipher.DecryptChunk(result.Nodes[i].Chunk); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// UpdateRelationship updates a relationship
+func (s *EncryptedStore) UpdateRelationship(ctx context.Context, relationshipID string, confidence float64, factors types.ConfidenceFactors) error {
+	return s.store.UpdateRelationship(ctx, relationshipID, confidence, factors)
+}
+
+// DeleteRelationship deletes a relationship
+func (s *EncryptedStore) DeleteRelationship(ctx context.Context, relationshipID string) error {
+	return s.store.DeleteRelationship(ctx, relationshipID)
+}
+
+// GetRelationshipByID gets a relationship by ID
+func (s *EncryptedStore) GetRelationshipByID(ctx context.Context, relationshipID string) (*types.MemoryRelationship, error) {
+	return s.store.GetRelationshipByID(ctx, relationshipID)
+}
+
+// ActiveCollection returns the active collection of the wrapped store
+func (s *EncryptedStore) ActiveCollection() string {
+	if swapper, ok := FindShadowCollectionStore(s.store); ok {
+		return swapper.ActiveCollection()
+	}
+	return ""
+}
+
+// OpenShadowCollection opens a shadow collection that is encrypted like the
+// live one, so re-embedding never writes plaintext
+func (s *EncryptedStore) OpenShadowCollection(ctx context.Context, name string, dimension int) (VectorStore, error) {
+	swapper, ok := FindShadowCollectionStore(s.store)
+	if !ok {
+		return nil, fmt.Errorf("storage backend does not support shadow collections")
+	}
+	shadow, err := swapper.OpenShadowCollection(ctx, name, dimension)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncryptedStore(shadow, s.cipher), nil
+}
+
+// PromoteCollection makes the named collection active
+func (s *EncryptedStore) PromoteCollection(ctx context.Context, name string) (string, error) {
+	swapper, ok := FindShadowCollectionStore(s.store)
+	if !ok {
+		return "", fmt.Errorf("storage backend does not support shadow collections")
+	}
+	return swapper.PromoteCollection(ctx, name)
+}
+
+// DropCollection deletes an inactive collection
+func (s *EncryptedStore) DropCollection(ctx context.Context, name string) error {
+	swapper, ok := FindShadowCollectionStore(s.store)
+	if !ok {
+		return fmt.Errorf("storage backend does not support shadow collections")
+	}
+	return swapper.DropCollection(ctx, name)
+}
+
+// Reencrypt rewrites every chunk that is still in plaintext or encrypted
+// with an older data key, then records that the active key is in use
+// everywhere. It is safe to run again after an interruption.
+func (s *EncryptedStore) Reencrypt(ctx context.Context) (int, error) {
+	start := time.Now()
+	activeKeyID := s.cipher.encryption.ActiveKeyID()
+
+	stored, err := s.store.GetAllChunks(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list chunks for re-encryption: %w", err)
+	}
+
+	rewritten := 0
+	for i := range stored {
+		if err := ctx.Err(); err != nil {
+			return rewritten, err
+		}
+		chunk := &stored[i]
+		if !s.cipher.NeedsReencryption(chunk) {
+			continue
+		}
+		if err := s.cipher.DecryptChunk(chunk); err != nil {
+			return rewritten, err
+		}
+		if err := s.Update(ctx, chunk); err != nil {
+			return rewritten, fmt.Errorf("failed to re-encrypt chunk %s: %w", chunk.ID, err)
+		}
+		rewritten++
+	}
+
+	if err := s.cipher.encryption.MarkReencrypted(activeKeyID); err != nil {
+		return rewritten, err
+	}
+	logging.Info("Re-encryption completed",
+		"chunks", rewritten,
+		"key_id", activeKeyID,
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+	return rewritten, nil
+}
+
+// FindEncryptedStore unwraps store until it finds the encryption layer
+func FindEncryptedStore(store VectorStore) (*EncryptedStore, bool) {
+	return unwrapStore[*EncryptedStore](store)
+}
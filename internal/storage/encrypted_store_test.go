@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"lerian-mcp-memory/internal/security"
+	"lerian-mcp-memory/pkg/types"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEncryptedStore(t *testing.T) (*EncryptedStore, *LocalStore, *security.EncryptionManager) {
+	t.Helper()
+	dir := t.TempDir()
+	em, err := security.OpenEncryptionManager("passphrase", filepath.Join(dir, "keyring.json"))
+	require.NoError(t, err)
+
+	local := newTestLocalStore(t, filepath.Join(dir, "data"))
+	t.Cleanup(func() { _ = local.Close() })
+	return NewEncryptedStore(local, NewChunkCipher(em)), local, em
+}
+
+func TestEncryptedStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, local, _ := newTestEncryptedStore(t)
+
+	chunk := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{0.2, 0.8, 0.1}, time.Now())
+	chunk.Summary = "payment outage"
+	chunk.Metadata.FilesModified = []string{"billing/secret.go"}
+	require.NoError(t, store.Store(ctx, chunk))
+
+	// The caller's chunk stays in plaintext
+	assert.Equal(t, "content for github.com/acme/api", chunk.Content)
+	assert.Equal(t, []string{"billing/secret.go"}, chunk.Metadata.FilesModified)
+
+	raw, err := local.GetByID(ctx, chunk.ID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(raw.Content, security.EncryptedFieldPrefix))
+	assert.True(t, strings.HasPrefix(raw.Summary, security.EncryptedFieldPrefix))
+	assert.NotContains(t, raw.Metadata.FilesModified[0], "secret")
+	assert.Equal(t, "github.com/acme/api", raw.Metadata.Repository)
+	assert.Equal(t, chunk.Embeddings, raw.Embeddings)
+
+	loaded, err := store.GetByID(ctx, chunk.ID)
+	require.NoError(t, err)
+	assert.Equal(t, chunk.Content, loaded.Content)
+	assert.Equal(t, "payment outage", loaded.Summary)
+	assert.Equal(t, []string{"billing/secret.go"}, loaded.Metadata.FilesModified)
+
+	results, err := store.Search(ctx, types.NewMemoryQuery("anything"), []float64{0.2, 0.8, 0.1})
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.Equal(t, chunk.Content, results.Results[0].Chunk.Content)
+}
+
+func TestEncryptedStoreEncryptsExtendedMetadata(t *testing.T) {
+	ctx := context.Background()
+	store, local, _ := newTestEncryptedStore(t)
+
+	chunk := newLocalTestChunk("github.com/acme/api", types.ChunkTypeArchitectureDecision, []float64{0.2, 0.8, 0.1}, time.Now())
+	chunk.Metadata.ExtendedMetadata = map[string]interface{}{
+		"decision_text":  "Move billing to the vault cluster",
+		"rationale_text": "The old host leaked customer cards",
+		"template_fields": map[string]interface{}{
+			"root_cause":         "Expired TLS certificate on payments",
+			"reproduction_steps": []interface{}{"Call the refund endpoint", 3.0},
+		},
+		types.EMKeyRedactions:      []string{"email"},
+		types.EMKeyConfidenceScore: 0.9,
+	}
+	require.NoError(t, store.Store(ctx, chunk))
+	assert.Equal(t, "Move billing to the vault cluster", chunk.Metadata.ExtendedMetadata["decision_text"], "the caller's metadata stays in plaintext")
+
+	raw, err := local.GetByID(ctx, chunk.ID)
+	require.NoError(t, err)
+	stored, err := json.Marshal(raw)
+	require.NoError(t, err)
+	for _, text := range []string{"vault cluster", "customer cards", "TLS certificate", "refund endpoint", `"email"`} {
+		assert.NotContains(t, string(stored), text)
+	}
+	assert.Equal(t, 0.9, raw.Metadata.ExtendedMetadata[types.EMKeyConfidenceScore])
+	assert.False(t, store.Cipher().NeedsReencryption(raw))
+
+	loaded, err := store.GetByID(ctx, chunk.ID)
+	require.NoError(t, err)
+	assert.Equal(t, chunk.Metadata.ExtendedMetadata, loaded.Metadata.ExtendedMetadata)
+
+	// Metadata written before it was encrypted is picked up by re-encryption
+	raw.Metadata.ExtendedMetadata["decision_text"] = "plaintext"
+	assert.True(t, store.Cipher().NeedsReencryption(raw))
+}
+
+func TestEncryptedStoreReencrypt(t *testing.T) {
+	ctx := context.Background()
+	store, local, em := newTestEncryptedStore(t)
+
+	// One chunk written before encryption was enabled, one with the old key
+	plain := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{0.2, 0.8, 0.1}, time.Now())
+	require.NoError(t, local.Store(ctx, plain))
+	encrypted := newLocalTestChunk("github.com/acme/api", types.ChunkTypeSolution, []float64{0.1, 0.2, 0.9}, time.Now())
+	require.NoError(t, store.Store(ctx, encrypted))
+
+	require.NoError(t, em.RotateKey(""))
+	require.True(t, em.ReencryptPending())
+
+	rewritten, err := store.Reencrypt(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rewritten)
+	assert.False(t, em.ReencryptPending())
+
+	for _, id := range []string{plain.ID, encrypted.ID} {
+		raw, err := local.GetByID(ctx, id)
+		require.NoError(t, err)
+		keyID, ok := security.FieldKeyID(raw.Content)
+		require.True(t, ok)
+		assert.Equal(t, em.ActiveKeyID(), keyID)
+
+		loaded, err := store.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "content for github.com/acme/api", loaded.Content)
+	}
+
+	// Nothing left to do on a second run
+	rewritten, err = store.Reencrypt(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, rewritten)
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"lerian-mcp-memory/pkg/types"
+	"time"
+)
+
+// recencyCutoff returns the earliest timestamp allowed by a recency filter.
+// A zero time means the recency does not restrict results.
+func recencyCutoff(recency types.Recency, now time.Time) time.Time {
+	switch recency {
+	case types.RecencyRecent:
+		return now.AddDate(0, 0, -7) // Last 7 days
+	case types.RecencyLastMonth:
+		return now.AddDate(0, -1, 0) // Last month
+	case types.RecencyAllTime:
+		return time.Time{}
+	default:
+		return time.Time{}
+	}
+}
+
+// chunkMatchesQuery reports whether a chunk satisfies the conditions that
+// QdrantStore.buildFilter derives from the same query. Backends that filter
+// in process use it so results match the Qdrant backend.
+func chunkMatchesQuery(chunk *types.ConversationChunk, query *types.MemoryQuery, now time.Time) bool {
+	return fieldsMatchQuery(chunk.Metadata.Repository, chunk.Type, chunk.Timestamp, query, now)
+}
+
+// fieldsMatchQuery applies the query filter to the indexed fields of a chunk
+func fieldsMatchQuery(repository string, chunkType types.ChunkType, timestamp time.Time, query *types.MemoryQuery, now time.Time) bool {
+	// Repository filter. The global repository only matches chunks stored
+	// under "global", exactly like the Qdrant should-condition does.
+	if query.Repository != nil && *query.Repository != "" {
+		if *query.Repository == globalRepository {
+			if repository != globalRepository {
+				return false
+			}
+		} else if repository != *query.Repository {
+			return false
+		}
+	}
+
+	// Type filter
+	if len(query.Types) > 0 {
+		typeMatches := false
+		for _, t := range query.Types {
+			if chunkType == t {
+				typeMatches = true
+				break
+			}
+		}
+		if !typeMatches {
+			return false
+		}
+	}
+
+	// Recency filter - Qdrant stores timestamps with second precision
+	if cutoff := recencyCutoff(query.Recency, now); !cutoff.IsZero() {
+		if timestamp.Unix() < cutoff.Unix() {
+			return false
+		}
+	}
+
+	return true
+}
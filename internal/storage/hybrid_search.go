@@ -0,0 +1,316 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lerian-mcp-memory/internal/logging"
+	"lerian-mcp-memory/pkg/types"
+	"sort"
+	"time"
+)
+
+// SearchMode selects the ranking signals used by HybridSearcher
+type SearchMode string
+
+const (
+	// SearchModeVector ranks by embedding cosine similarity only
+	SearchModeVector SearchMode = "vector"
+	// SearchModeLexical ranks by BM25 keyword score only
+	SearchModeLexical SearchMode = "lexical"
+	// SearchModeHybrid merges both rankings with reciprocal-rank fusion
+	SearchModeHybrid SearchMode = "hybrid"
+)
+
+// Signal names used in result explanations
+const (
+	SignalVector  = "vector"
+	SignalLexical = "lexical"
+)
+
+const (
+	defaultRRFK                 = 60
+	defaultHybridSearchLimit    = 10
+	hybridCandidateMultiplier   = 3
+	minHybridCandidatesPerQuery = 20
+)
+
+// ParseSearchMode validates a search mode name
+func ParseSearchMode(mode string) (SearchMode, error) {
+	switch SearchMode(mode) {
+	case SearchModeVector, SearchModeLexical, SearchModeHybrid:
+		return SearchMode(mode), nil
+	default:
+		return "", fmt.Errorf("invalid search_mode %q: must be one of vector, lexical, hybrid", mode)
+	}
+}
+
+// SignalContribution describes how one ranking signal contributed to a result
+type SignalContribution struct {
+	Rank         int      `json:"rank"`                    // 1-based rank in the signal's list
+	Score        float64  `json:"score"`                   // raw signal score (cosine or BM25)
+	Contribution float64  `json:"contribution"`            // score added to the final result score
+	Share        float64  `json:"share"`                   // fraction of the final score, 0-1
+	MatchedTerms []string `json:"matched_terms,omitempty"` // lexical only
+}
+
+// ResultExplanation reports the per-signal breakdown of one result
+type ResultExplanation struct {
+	ChunkID string                        `json:"chunk_id"`
+	Score   float64                       `json:"score"`
+	Signals map[string]SignalContribution `json:"signals"`
+}
+
+// HybridSearchResults holds ranked results with a parallel list of explanations
+type HybridSearchResults struct {
+	Mode         SearchMode           `json:"mode"`
+	Results      []types.SearchResult `json:"results"`
+	Explanations []ResultExplanation  `json:"explanations"`
+	Total        int                  `json:"total"`
+	QueryTime    time.Duration        `json:"query_time"`
+}
+
+// HybridSearcher runs vector, lexical or fused searches over a VectorStore
+// and the LexicalIndex maintained next to it
+type HybridSearcher struct {
+	store VectorStore
+	index *LexicalIndex
+	rrfK  int
+}
+
+// NewHybridSearcher creates a searcher; rrfK <= 0 uses the conventional 60
+func NewHybridSearcher(store VectorStore, index *LexicalIndex, rrfK int) *HybridSearcher {
+	if rrfK <= 0 {
+		rrfK = defaultRRFK
+	}
+	return &HybridSearcher{
+		store: store,
+		index: index,
+		rrfK:  rrfK,
+	}
+}
+
+// Search runs the query in the given mode. Embeddings are ignored in lexical
+// mode and required otherwise.
+func (hs *HybridSearcher) Search(ctx context.Context, query *types.MemoryQuery, embeddings []float64, mode SearchMode) (*HybridSearchResults, error) {
+	start := time.Now()
+
+	var (
+		results *HybridSearchResults
+		err     error
+	)
+	switch mode {
+	case SearchModeVector, "":
+		results, err = hs.vectorSearch(ctx, query, embeddings)
+	case SearchModeLexical:
+		results, err = hs.lexicalSearch(ctx, query)
+	case SearchModeHybrid:
+		results, err = hs.hybridSearch(ctx, query, embeddings)
+	default:
+		return nil, fmt.Errorf("invalid search_mode %q: must be one of vector, lexical, hybrid", mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results.Total = len(results.Results)
+	results.QueryTime = time.Since(start)
+	return results, nil
+}
+
+// vectorSearch returns the store's cosine ranking unchanged
+func (hs *HybridSearcher) vectorSearch(ctx context.Context, query *types.MemoryQuery, embeddings []float64) (*HybridSearchResults, error) {
+	vectorResults, err := hs.store.Search(ctx, query, embeddings)
+	if err != nil {
+		return nil, err
+	}
+
+	explanations := make([]ResultExplanation, len(vectorResults.Results))
+	for i, result := range vectorResults.Results {
+		explanations[i] = ResultExplanation{
+			ChunkID: result.Chunk.ID,
+			Score:   result.Score,
+			Signals: map[string]SignalContribution{
+				SignalVector: {Rank: i + 1, Score: result.Score, Contribution: result.Score, Share: 1},
+			},
+		}
+	}
+
+	return &HybridSearchResults{
+		Mode:         SearchModeVector,
+		Results:      vectorResults.Results,
+		Explanations: explanations,
+	}, nil
+}
+
+// lexicalSearch ranks by BM25. Scores are normalized by the top score so they
+// stay in 0-1 like cosine scores; the raw BM25 value is in the explanation.
+func (hs *HybridSearcher) lexicalSearch(ctx context.Context, query *types.MemoryQuery) (*HybridSearchResults, error) {
+	if hs.index == nil {
+		return nil, errors.New("lexical index is not available")
+	}
+
+	matches := hs.index.Search(query, time.Now(), searchLimit(query))
+	chunks := hs.loadChunks(ctx, matches)
+
+	results := make([]types.SearchResult, 0, len(matches))
+	explanations := make([]ResultExplanation, 0, len(matches))
+	var topScore float64
+	for _, match := range matches {
+		chunk, ok := chunks[match.ChunkID]
+		if !ok {
+			continue
+		}
+		if topScore == 0 {
+			topScore = match.Score
+		}
+		score := match.Score / topScore
+		results = append(results, types.SearchResult{Chunk: *chunk, Score: score})
+		explanations = append(explanations, ResultExplanation{
+			ChunkID: match.ChunkID,
+			Score:   score,
+			Signals: map[string]SignalContribution{
+				SignalLexical: {
+					Rank:         len(results),
+					Score:        match.Score,
+					Contribution: score,
+					Share:        1,
+					MatchedTerms: match.MatchedTerms,
+				},
+			},
+		})
+	}
+
+	return &HybridSearchResults{
+		Mode:         SearchModeLexical,
+		Results:      results,
+		Explanations: explanations,
+	}, nil
+}
+
+// hybridSearch fuses the vector and lexical rankings with reciprocal-rank
+// fusion: each list adds 1/(k+rank) for every chunk it contains.
+func (hs *HybridSearcher) hybridSearch(ctx context.Context, query *types.MemoryQuery, embeddings []float64) (*HybridSearchResults, error) {
+	if hs.index == nil {
+		return nil, errors.New("lexical index is not available")
+	}
+
+	limit := searchLimit(query)
+	candidates := limit * hybridCandidateMultiplier
+	if candidates < minHybridCandidatesPerQuery {
+		candidates = minHybridCandidatesPerQuery
+	}
+
+	vectorQuery := *query
+	vectorQuery.Limit = candidates
+	vectorResults, err := hs.store.Search(ctx, &vectorQuery, embeddings)
+	if err != nil {
+		return nil, err
+	}
+	matches := hs.index.Search(query, time.Now(), candidates)
+
+	chunks := make(map[string]*types.ConversationChunk, len(vectorResults.Results)+len(matches))
+	fused := make(map[string]*ResultExplanation)
+	explain := func(id string) *ResultExplanation {
+		explanation, ok := fused[id]
+		if !ok {
+			explanation = &ResultExplanation{ChunkID: id, Signals: make(map[string]SignalContribution, 2)}
+			fused[id] = explanation
+		}
+		return explanation
+	}
+
+	for i := range vectorResults.Results {
+		result := &vectorResults.Results[i]
+		chunks[result.Chunk.ID] = &result.Chunk
+		contribution := hs.reciprocalRank(i + 1)
+		explanation := explain(result.Chunk.ID)
+		explanation.Score += contribution
+		explanation.Signals[SignalVector] = SignalContribution{Rank: i + 1, Score: result.Score, Contribution: contribution}
+	}
+	for i, match := range matches {
+		contribution := hs.reciprocalRank(i + 1)
+		explanation := explain(match.ChunkID)
+		explanation.Score += contribution
+		explanation.Signals[SignalLexical] = SignalContribution{
+			Rank:         i + 1,
+			Score:        match.Score,
+			Contribution: contribution,
+			MatchedTerms: match.MatchedTerms,
+		}
+	}
+
+	ranked := make([]*ResultExplanation, 0, len(fused))
+	for _, explanation := range fused {
+		ranked = append(ranked, explanation)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].ChunkID < ranked[j].ChunkID
+	})
+
+	// Lexical-only hits are not in the vector results and must be loaded
+	missing := make([]LexicalMatch, 0)
+	for _, explanation := range ranked {
+		if _, ok := chunks[explanation.ChunkID]; !ok {
+			missing = append(missing, LexicalMatch{ChunkID: explanation.ChunkID})
+		}
+	}
+	for id, chunk := range hs.loadChunks(ctx, missing) {
+		chunks[id] = chunk
+	}
+
+	results := make([]types.SearchResult, 0, limit)
+	explanations := make([]ResultExplanation, 0, limit)
+	for _, explanation := range ranked {
+		if len(results) >= limit {
+			break
+		}
+		chunk, ok := chunks[explanation.ChunkID]
+		if !ok {
+			continue
+		}
+		for name, signal := range explanation.Signals {
+			signal.Share = signal.Contribution / explanation.Score
+			explanation.Signals[name] = signal
+		}
+		results = append(results, types.SearchResult{Chunk: *chunk, Score: explanation.Score})
+		explanations = append(explanations, *explanation)
+	}
+
+	return &HybridSearchResults{
+		Mode:         SearchModeHybrid,
+		Results:      results,
+		Explanations: explanations,
+	}, nil
+}
+
+// reciprocalRank is the RRF contribution of a 1-based rank
+func (hs *HybridSearcher) reciprocalRank(rank int) float64 {
+	return 1 / float64(hs.rrfK+rank)
+}
+
+// loadChunks fetches the chunks behind lexical matches. Chunks that can no
+// longer be loaded are skipped.
+func (hs *HybridSearcher) loadChunks(ctx context.Context, matches []LexicalMatch) map[string]*types.ConversationChunk {
+	chunks := make(map[string]*types.ConversationChunk, len(matches))
+	for _, match := range matches {
+		chunk, err := hs.store.GetByID(ctx, match.ChunkID)
+		if err != nil {
+			logging.Debug("Skipping lexical match that could not be loaded", "chunk_id", match.ChunkID, "error", err)
+			continue
+		}
+		chunks[match.ChunkID] = chunk
+	}
+	return chunks
+}
+
+// searchLimit applies the default page size to non-positive limits
+func searchLimit(query *types.MemoryQuery) int {
+	if query.Limit <= 0 {
+		return defaultHybridSearchLimit
+	}
+	return query.Limit
+}
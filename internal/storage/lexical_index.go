@@ -0,0 +1,314 @@
+package storage
+
+import (
+	"lerian-mcp-memory/pkg/types"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// lexicalStopWords are dropped from documents and queries
+var lexicalStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "but": true, "not": true,
+	"you": true, "all": true, "can": true, "was": true, "with": true, "this": true,
+	"that": true, "from": true, "have": true, "has": true, "had": true, "its": true,
+	"into": true, "our": true, "out": true, "what": true, "when": true, "how": true,
+	"is": true, "in": true, "of": true, "to": true, "a": true, "an": true, "on": true,
+	"at": true, "by": true, "or": true, "it": true, "be": true, "as": true,
+}
+
+// LexicalIndex is an in-memory BM25 index over chunk text. It keeps only
+// term statistics and the fields needed for query filtering; callers load
+// the matching chunks from the VectorStore.
+type LexicalIndex struct {
+	mu          sync.RWMutex
+	docs        map[string]*lexicalDocument
+	postings    map[string]map[string]int // term -> chunk ID -> term frequency
+	totalLength int
+}
+
+// lexicalDocument holds the indexed form of one chunk
+type lexicalDocument struct {
+	repository string
+	chunkType  types.ChunkType
+	timestamp  time.Time
+	length     int
+	terms      map[string]int
+}
+
+// LexicalMatch is one BM25 search hit
+type LexicalMatch struct {
+	ChunkID      string   `json:"chunk_id"`
+	Score        float64  `json:"score"`
+	MatchedTerms []string `json:"matched_terms"`
+}
+
+// NewLexicalIndex creates an empty BM25 index
+func NewLexicalIndex() *LexicalIndex {
+	return &LexicalIndex{
+		docs:     make(map[string]*lexicalDocument),
+		postings: make(map[string]map[string]int),
+	}
+}
+
+// Add indexes a chunk, replacing any previous version with the same ID
+func (li *LexicalIndex) Add(chunk *types.ConversationChunk) {
+	if chunk == nil || chunk.ID == "" {
+		return
+	}
+
+	terms := make(map[string]int)
+	length := 0
+	for _, token := range lexicalTokens(lexicalChunkText(chunk)) {
+		terms[token]++
+		length++
+	}
+
+	li.mu.Lock()
+	defer li.mu.Unlock()
+
+	li.removeLocked(chunk.ID)
+	li.docs[chunk.ID] = &lexicalDocument{
+		repository: chunk.Metadata.Repository,
+		chunkType:  chunk.Type,
+		timestamp:  chunk.Timestamp,
+		length:     length,
+		terms:      terms,
+	}
+	for term, tf := range terms {
+		posting, ok := li.postings[term]
+		if !ok {
+			posting = make(map[string]int)
+			li.postings[term] = posting
+		}
+		posting[chunk.ID] = tf
+	}
+	li.totalLength += length
+}
+
+// Remove drops a chunk from the index
+func (li *LexicalIndex) Remove(id string) {
+	li.mu.Lock()
+	defer li.mu.Unlock()
+	li.removeLocked(id)
+}
+
+// removeLocked drops a chunk; the caller must hold the write lock
+func (li *LexicalIndex) removeLocked(id string) {
+	doc, ok := li.docs[id]
+	if !ok {
+		return
+	}
+	for term := range doc.terms {
+		posting := li.postings[term]
+		delete(posting, id)
+		if len(posting) == 0 {
+			delete(li.postings, term)
+		}
+	}
+	li.totalLength -= doc.length
+	delete(li.docs, id)
+}
+
+// RemoveOlderThan drops chunks with a timestamp before the cutoff, mirroring
+// VectorStore.Cleanup, and returns how many were removed
+func (li *LexicalIndex) RemoveOlderThan(cutoff time.Time) int {
+	li.mu.Lock()
+	defer li.mu.Unlock()
+
+	removed := 0
+	for id, doc := range li.docs {
+		if doc.timestamp.Unix() < cutoff.Unix() {
+			li.removeLocked(id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Reset replaces the index contents with the given chunks
+func (li *LexicalIndex) Reset(chunks []types.ConversationChunk) {
+	li.mu.Lock()
+	li.docs = make(map[string]*lexicalDocument, len(chunks))
+	li.postings = make(map[string]map[string]int)
+	li.totalLength = 0
+	li.mu.Unlock()
+
+	for i := range chunks {
+		li.Add(&chunks[i])
+	}
+}
+
+// Size returns the number of indexed chunks
+func (li *LexicalIndex) Size() int {
+	li.mu.RLock()
+	defer li.mu.RUnlock()
+	return len(li.docs)
+}
+
+// Search ranks the chunks passing the query filter by BM25 score against
+// query.Query. Results are ordered by descending score.
+func (li *LexicalIndex) Search(query *types.MemoryQuery, now time.Time, limit int) []LexicalMatch {
+	queryTerms := uniqueStrings(lexicalTokens(query.Query))
+	if len(queryTerms) == 0 {
+		return []LexicalMatch{}
+	}
+
+	li.mu.RLock()
+	defer li.mu.RUnlock()
+
+	docCount := float64(len(li.docs))
+	if docCount == 0 {
+		return []LexicalMatch{}
+	}
+	avgLength := float64(li.totalLength) / docCount
+	if avgLength == 0 {
+		avgLength = 1
+	}
+
+	matches := make(map[string]*LexicalMatch)
+	for _, term := range queryTerms {
+		posting := li.postings[term]
+		if len(posting) == 0 {
+			continue
+		}
+		df := float64(len(posting))
+		idf := math.Log(1 + (docCount-df+0.5)/(df+0.5))
+
+		for id, tf := range posting {
+			doc := li.docs[id]
+			if !fieldsMatchQuery(doc.repository, doc.chunkType, doc.timestamp, query, now) {
+				continue
+			}
+			freq := float64(tf)
+			score := idf * freq * (bm25K1 + 1) / (freq + bm25K1*(1-bm25B+bm25B*float64(doc.length)/avgLength))
+
+			match, ok := matches[id]
+			if !ok {
+				match = &LexicalMatch{ChunkID: id}
+				matches[id] = match
+			}
+			match.Score += score
+			match.MatchedTerms = append(match.MatchedTerms, term)
+		}
+	}
+
+	results := make([]LexicalMatch, 0, len(matches))
+	for _, match := range matches {
+		results = append(results, *match)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ChunkID < results[j].ChunkID
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// lexicalChunkText returns the searchable text of a chunk
+func lexicalChunkText(chunk *types.ConversationChunk) string {
+	parts := []string{chunk.Content, chunk.Summary}
+	parts = append(parts, chunk.Metadata.Tags...)
+	parts = append(parts, chunk.Metadata.FilesModified...)
+	parts = append(parts, chunk.Metadata.ToolsUsed...)
+	parts = append(parts, chunk.Metadata.CodeSymbols()...)
+	return strings.Join(parts, " ")
+}
+
+// lexicalTokens splits text into lowercase terms. Identifiers are indexed both
+// whole and split on camelCase and snake_case boundaries, so a query for
+// handleSecureBulkDelete matches the exact identifier while "bulk delete"
+// still matches its parts.
+func lexicalTokens(text string) []string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.Trim(word, "_")
+		if word == "" {
+			continue
+		}
+		lower := strings.ToLower(word)
+		if len(lower) > 1 && !lexicalStopWords[lower] {
+			tokens = append(tokens, lower)
+		}
+
+		parts := splitIdentifier(word)
+		if len(parts) < 2 {
+			continue
+		}
+		for _, part := range parts {
+			part = strings.ToLower(part)
+			if len(part) > 1 && !lexicalStopWords[part] {
+				tokens = append(tokens, part)
+			}
+		}
+	}
+	return tokens
+}
+
+// splitIdentifier splits camelCase, PascalCase, snake_case and letter/digit
+// boundaries: "parseHTTPResponse_v2" -> [parse HTTP Response v 2]
+func splitIdentifier(word string) []string {
+	runes := []rune(word)
+	parts := make([]string, 0, 4)
+	start := 0
+
+	flush := func(end int) {
+		if end > start {
+			parts = append(parts, string(runes[start:end]))
+		}
+		start = end
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '_' {
+			flush(i)
+			start = i + 1
+			continue
+		}
+		if i == start {
+			continue
+		}
+		prev := runes[i-1]
+		switch {
+		case unicode.IsLower(prev) && unicode.IsUpper(r):
+			flush(i)
+		case unicode.IsUpper(prev) && unicode.IsUpper(r) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			flush(i)
+		case unicode.IsDigit(prev) != unicode.IsDigit(r):
+			flush(i)
+		}
+	}
+	flush(len(runes))
+	return parts
+}
+
+// uniqueStrings returns the values in first-seen order without duplicates
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
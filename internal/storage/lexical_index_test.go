@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"context"
+	"lerian-mcp-memory/pkg/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLexicalTokens(t *testing.T) {
+	tokens := lexicalTokens("Fixed handleSecureBulkDelete returning ERR_CONN_42 in parseHTTPResponse")
+
+	assert.Contains(t, tokens, "handlesecurebulkdelete", "whole identifier is indexed")
+	assert.Contains(t, tokens, "bulk", "camelCase parts are indexed")
+	assert.Contains(t, tokens, "err_conn_42")
+	assert.Contains(t, tokens, "conn")
+	assert.Contains(t, tokens, "42")
+	assert.Contains(t, tokens, "http")
+	assert.Contains(t, tokens, "response")
+	assert.NotContains(t, tokens, "in", "stop words are dropped")
+}
+
+func TestSplitIdentifier(t *testing.T) {
+	assert.Equal(t, []string{"parse", "HTTP", "Response", "v", "2"}, splitIdentifier("parseHTTPResponse_v2"))
+	assert.Equal(t, []string{"simple"}, splitIdentifier("simple"))
+	assert.Equal(t, []string{"snake", "case"}, splitIdentifier("snake_case"))
+}
+
+func newLexicalTestChunk(id, repository, content string, timestamp time.Time) *types.ConversationChunk {
+	chunk := newLocalTestChunk(repository, types.ChunkTypeProblem, []float64{1, 0, 0}, timestamp)
+	chunk.ID = id
+	chunk.Content = content
+	return chunk
+}
+
+func TestLexicalIndexCodeSymbols(t *testing.T) {
+	now := time.Now()
+	index := NewLexicalIndex()
+	repo := "github.com/acme/api"
+
+	// Symbols read back from storage decode as []interface{}
+	defining := newLexicalTestChunk("defines", repo, "the store keeps chunks in a map", now)
+	defining.Metadata.ExtendedMetadata = map[string]interface{}{types.EMKeyCodeSymbols: []interface{}{"ChunkStore", "ChunkStore.Get"}}
+	index.Add(defining)
+	index.Add(newLexicalTestChunk("mentions", repo, "the store was slow", now))
+
+	matches := index.Search(types.NewMemoryQuery("ChunkStore"), now, 10)
+	require.NotEmpty(t, matches)
+	assert.Equal(t, "defines", matches[0].ChunkID)
+	assert.Contains(t, matches[0].MatchedTerms, "chunkstore", "the whole symbol matches the defining chunk")
+}
+
+func TestLexicalIndexSearch(t *testing.T) {
+	now := time.Now()
+	index := NewLexicalIndex()
+	repo := "github.com/acme/api"
+
+	index.Add(newLexicalTestChunk("exact", repo, "handleSecureBulkDelete panics when ids are empty", now))
+	index.Add(newLexicalTestChunk("partial", repo, "bulk import is slow for large files", now))
+	index.Add(newLexicalTestChunk("unrelated", repo, "configure the docker compose network", now))
+	index.Add(newLexicalTestChunk("other-repo", "github.com/acme/web", "handleSecureBulkDelete in the web client", now))
+	index.Add(newLexicalTestChunk("old", repo, "handleSecureBulkDelete legacy behaviour", now.AddDate(0, -2, 0)))
+
+	query := types.NewMemoryQuery("handleSecureBulkDelete")
+	query.Repository = &repo
+
+	matches := index.Search(query, now, 10)
+	require.NotEmpty(t, matches)
+	assert.Equal(t, "exact", matches[0].ChunkID)
+	assert.Contains(t, matches[0].MatchedTerms, "handlesecurebulkdelete")
+	for _, match := range matches {
+		assert.NotEqual(t, "other-repo", match.ChunkID, "repository filter applies")
+		assert.NotEqual(t, "old", match.ChunkID, "recency filter applies")
+		assert.NotEqual(t, "unrelated", match.ChunkID)
+	}
+
+	t.Run("replacing a chunk updates its terms", func(t *testing.T) {
+		index.Add(newLexicalTestChunk("exact", repo, "nothing relevant anymore", now))
+		matches := index.Search(query, now, 10)
+		for _, match := range matches {
+			assert.NotEqual(t, "exact", match.ChunkID)
+		}
+	})
+
+	t.Run("remove and cleanup", func(t *testing.T) {
+		size := index.Size()
+		index.Remove("partial")
+		assert.Equal(t, size-1, index.Size())
+
+		assert.Equal(t, 1, index.RemoveOlderThan(now.AddDate(0, -1, 0)))
+		assert.Equal(t, size-2, index.Size())
+	})
+
+	t.Run("empty query", func(t *testing.T) {
+		assert.Empty(t, index.Search(types.NewMemoryQuery("the and"), now, 10))
+	})
+}
+
+func TestLexicalIndexedStoreKeepsIndexInSync(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	repo := "github.com/acme/api"
+
+	base := newTestLocalStore(t, dataDir)
+	seed := newLexicalTestChunk("seed", repo, "existing chunk about ERR_CONN_42", time.Now())
+	require.NoError(t, base.Store(ctx, seed))
+	require.NoError(t, base.Close())
+
+	index := NewLexicalIndex()
+	store := NewLexicalIndexedStore(NewLocalStore(base.config), index)
+	require.NoError(t, store.Initialize(ctx))
+	defer func() { _ = store.Close() }()
+	assert.Equal(t, 1, index.Size(), "index is rebuilt from the store")
+
+	chunk := newLexicalTestChunk("new", repo, "added chunk mentioning ERR_CONN_42", time.Now())
+	require.NoError(t, store.Store(ctx, chunk))
+	assert.Equal(t, 2, index.Size())
+
+	invalid := newLexicalTestChunk("invalid", repo, "no embeddings", time.Now())
+	invalid.Embeddings = nil
+	_, _ = store.BatchStore(ctx, []*types.ConversationChunk{
+		newLexicalTestChunk("batch", repo, "batched", time.Now()),
+		invalid,
+	})
+	assert.Equal(t, 3, index.Size(), "only stored chunks are indexed")
+
+	require.NoError(t, store.Delete(ctx, "new"))
+	_, err := store.BatchDelete(ctx, []string{"batch"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, index.Size())
+}
+
+func TestHybridSearcher(t *testing.T) {
+	ctx := context.Background()
+	repo := "github.com/acme/api"
+	now := time.Now()
+
+	index := NewLexicalIndex()
+	store := NewLexicalIndexedStore(newTestLocalStore(t, t.TempDir()), index)
+	defer func() { _ = store.Close() }()
+
+	// "semantic" is closest to the query embedding; "keyword" holds the exact identifier
+	semantic := newLexicalTestChunk("semantic", repo, "removing many records at once fails", now)
+	semantic.Embeddings = []float64{1, 0, 0}
+	keyword := newLexicalTestChunk("keyword", repo, "handleSecureBulkDelete returns ERR_CONN_42", now)
+	keyword.Embeddings = []float64{0.6, 0.8, 0}
+	both := newLexicalTestChunk("both", repo, "bulk delete handler handleSecureBulkDelete times out", now)
+	both.Embeddings = []float64{0.9, 0.1, 0}
+	for _, chunk := range []*types.ConversationChunk{semantic, keyword, both} {
+		require.NoError(t, store.Store(ctx, chunk))
+	}
+
+	searcher := NewHybridSearcher(store, index, 60)
+	query := types.NewMemoryQuery("handleSecureBulkDelete")
+	query.Repository = &repo
+	query.MinRelevanceScore = 0
+	embeddings := []float64{1, 0, 0}
+
+	t.Run("vector", func(t *testing.T) {
+		results, err := searcher.Search(ctx, query, embeddings, SearchModeVector)
+		require.NoError(t, err)
+		require.Len(t, results.Results, 3)
+		assert.Equal(t, "semantic", results.Results[0].Chunk.ID)
+		assert.Equal(t, 1, results.Explanations[0].Signals[SignalVector].Rank)
+	})
+
+	t.Run("lexical", func(t *testing.T) {
+		results, err := searcher.Search(ctx, query, nil, SearchModeLexical)
+		require.NoError(t, err)
+		require.Len(t, results.Results, 2)
+		assert.InDelta(t, 1.0, results.Results[0].Score, 1e-9, "top lexical score is normalized to 1")
+		for _, result := range results.Results {
+			assert.NotEqual(t, "semantic", result.Chunk.ID)
+		}
+		assert.NotEmpty(t, results.Explanations[0].Signals[SignalLexical].MatchedTerms)
+	})
+
+	t.Run("hybrid", func(t *testing.T) {
+		results, err := searcher.Search(ctx, query, embeddings, SearchModeHybrid)
+		require.NoError(t, err)
+		require.Len(t, results.Results, 3)
+		assert.Equal(t, "both", results.Results[0].Chunk.ID, "ranked well by both signals")
+		assert.Len(t, results.Explanations, len(results.Results))
+
+		top := results.Explanations[0]
+		require.Contains(t, top.Signals, SignalVector)
+		require.Contains(t, top.Signals, SignalLexical)
+		assert.InDelta(t, top.Score, top.Signals[SignalVector].Contribution+top.Signals[SignalLexical].Contribution, 1e-12)
+		assert.InDelta(t, 1.0, top.Signals[SignalVector].Share+top.Signals[SignalLexical].Share, 1e-9)
+		assert.InDelta(t, 1.0/float64(60+top.Signals[SignalVector].Rank), top.Signals[SignalVector].Contribution, 1e-12)
+
+		for _, explanation := range results.Explanations {
+			if explanation.ChunkID == "semantic" {
+				assert.NotContains(t, explanation.Signals, SignalLexical)
+				assert.InDelta(t, 1.0, explanation.Signals[SignalVector].Share, 1e-9)
+			}
+		}
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		_, err := searcher.Search(ctx, query, embeddings, SearchMode("fuzzy"))
+		assert.Error(t, err)
+		_, err = ParseSearchMode("fuzzy")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"context"
+	"lerian-mcp-memory/internal/logging"
+	"lerian-mcp-memory/pkg/types"
+	"time"
+)
+
+// LexicalIndexedStore wraps a VectorStore and keeps a LexicalIndex in step
+// with every chunk write, so keyword search always sees the same chunks as
+// vector search. The index is rebuilt from the store on Initialize.
+type LexicalIndexedStore struct {
+	store VectorStore
+	index *LexicalIndex
+}
+
+// NewLexicalIndexedStore creates a store that maintains the given index
+func NewLexicalIndexedStore(store VectorStore, index *LexicalIndex) *LexicalIndexedStore {
+	return &LexicalIndexedStore{
+		store: store,
+		index: index,
+	}
+}
+
+// Unwrap returns the wrapped store
+func (s *LexicalIndexedStore) Unwrap() VectorStore {
+	return s.store
+}
+
+// Initialize initializes the store and builds the lexical index from its chunks
+func (s *LexicalIndexedStore) Initialize(ctx context.Context) error {
+	if err := s.store.Initialize(ctx); err != nil {
+		return err
+	}
+	s.rebuildIndex(ctx)
+	return nil
+}
+
+// rebuildIndex reloads the lexical index from the store. Failures only
+// degrade keyword search, so they are logged rather than returned.
+func (s *LexicalIndexedStore) rebuildIndex(ctx context.Context) {
+	start := time.Now()
+	chunks, err := s.store.GetAllChunks(ctx)
+	if err != nil {
+		logging.Warn("Failed to build lexical index; keyword search will be incomplete", "error", err)
+		return
+	}
+	s.index.Reset(chunks)
+	logging.Info("Lexical index built",
+		"chunks", s.index.Size(),
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+}
+
+// Store stores a chunk and indexes its text
+func (s *LexicalIndexedStore) Store(ctx context.Context, chunk *types.ConversationChunk) error {
+	if err := s.store.Store(ctx, chunk); err != nil {
+		return err
+	}
+	s.index.Add(chunk)
+	return nil
+}
+
+// Search performs vector similarity search
+func (s *LexicalIndexedStore) Search(ctx context.Context, query *types.MemoryQuery, embeddings []float64) (*types.SearchResults, error) {
+	return s.store.Search(ctx, query, embeddings)
+}
+
+// GetByID gets a chunk by ID
+func (s *LexicalIndexedStore) GetByID(ctx context.Context, id string) (*types.ConversationChunk, error) {
+	return s.store.GetByID(ctx, id)
+}
+
+// ListByRepository lists chunks by repository
+func (s *LexicalIndexedStore) ListByRepository(ctx context.Context, repository string, limit, offset int) ([]types.ConversationChunk, error) {
+	return s.store.ListByRepository(ctx, repository, limit, offset)
+}
+
+// ListBySession lists chunks by session
+func (s *LexicalIndexedStore) ListBySession(ctx context.Context, sessionID string) ([]types.ConversationChunk, error) {
+	return s.store.ListBySession(ctx, sessionID)
+}
+
+// Delete deletes a chunk and removes it from the index
+func (s *LexicalIndexedStore) Delete(ctx context.Context, id string) error {
+	if err := s.store.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.index.Remove(id)
+	return nil
+}
+
+// Update updates a chunk and re-indexes its text
+func (s *LexicalIndexedStore) Update(ctx context.Context, chunk *types.ConversationChunk) error {
+	if err := s.store.Update(ctx, chunk); err != nil {
+		return err
+	}
+	s.index.Add(chunk)
+	return nil
+}
+
+// HealthCheck checks the wrapped store
+func (s *LexicalIndexedStore) HealthCheck(ctx context.Context) error {
+	return s.store.HealthCheck(ctx)
+}
+
+// GetStats gets store statistics
+func (s *LexicalIndexedStore) GetStats(ctx context.Context) (*StoreStats, error) {
+	return s.store.GetStats(ctx)
+}
+
+// Cleanup removes old chunks from the store and the index
+func (s *LexicalIndexedStore) Cleanup(ctx context.Context, retentionDays int) (int, error) {
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+	deleted, err := s.store.Cleanup(ctx, retentionDays)
+	if err != nil {
+		return deleted, err
+	}
+	s.index.RemoveOlderThan(cutoff)
+	return deleted, nil
+}
+
+// Close closes the wrapped store
+func (s *LexicalIndexedStore) Close() error {
+	return s.store.Close()
+}
+
+// GetAllChunks gets all chunks
+func (s *LexicalIndexedStore) GetAllChunks(ctx context.Context) ([]types.ConversationChunk, error) {
+	return s.store.GetAllChunks(ctx)
+}
+
+// DeleteCollection deletes a collection and resynchronizes the index
+func (s *LexicalIndexedStore) DeleteCollection(ctx context.Context, collection string) error {
+	if err := s.store.DeleteCollection(ctx, collection); err != nil {
+		return err
+	}
+
+	// The wrapper does not know which collection is active, so reload
+	chunks, err := s.store.GetAllChunks(ctx)
+	if err != nil {
+		chunks = nil
+	}
+	s.index.Reset(chunks)
+	return nil
+}
+
+// ListCollections lists all collections
+func (s *LexicalIndexedStore) ListCollections(ctx context.Context) ([]string, error) {
+	return s.store.ListCollections(ctx)
+}
+
+// FindSimilar finds similar chunks
+func (s *LexicalIndexedStore) FindSimilar(ctx context.Context, content string, chunkType *types.ChunkType, limit int) ([]types.ConversationChunk, error) {
+	return s.store.FindSimilar(ctx, content, chunkType, limit)
+}
+
+// StoreChunk stores a chunk and indexes its text
+func (s *LexicalIndexedStore) StoreChunk(ctx context.Context, chunk *types.ConversationChunk) error {
+	if err := s.store.StoreChunk(ctx, chunk); err != nil {
+		return err
+	}
+	s.index.Add(chunk)
+	return nil
+}
+
+// BatchStore stores chunks and indexes the ones that were stored
+func (s *LexicalIndexedStore) BatchStore(ctx context.Context, chunks []*types.ConversationChunk) (*BatchResult, error) {
+	result, err := s.store.BatchStore(ctx, chunks)
+	if result == nil {
+		return result, err
+	}
+
+	stored := make(map[string]bool, len(result.ProcessedIDs))
+	for _, id := range result.ProcessedIDs {
+		stored[id] = true
+	}
+	for _, chunk := range chunks {
+		if chunk != nil && stored[chunk.ID] {
+			s.index.Add(chunk)
+		}
+	}
+	return result, err
+}
+
+// BatchDelete deletes chunks and removes them from the index
+func (s *LexicalIndexedStore) BatchDelete(ctx context.Context, ids []string) (*BatchResult, error) {
+	result, err := s.store.BatchDelete(ctx, ids)
+	if err != nil {
+		return result, err
+	}
+	for _, id := range ids {
+		s.index.Remove(id)
+	}
+	return result, nil
+}
+
+// StoreRelationship stores a relationship
+func (s *LexicalIndexedStore) StoreRelationship(ctx context.Context, sourceID, targetID string, relationType types.RelationType, confidence float64, source types.ConfidenceSource) (*types.MemoryRelationship, error) {
+	return s.store.StoreRelationship(ctx, sourceID, targetID, relationType, confidence, source)
+}
+
+// GetRelationships gets relationships
+func (s *LexicalIndexedStore) GetRelationships(ctx context.Context, query *types.RelationshipQuery) ([]types.RelationshipResult, error) {
+	return s.store.GetRelationships(ctx, query)
+}
+
+// TraverseGraph traverses the relationship graph
+func (s *LexicalIndexedStore) TraverseGraph(ctx context.Context, startChunkID string, maxDepth int, relationTypes []types.RelationType) (*types.GraphTraversalResult, error) {
+	return s.store.TraverseGraph(ctx, startChunkID, maxDepth, relationTypes)
+}
+
+// UpdateRelationship updates a relationship
+func (s *LexicalIndexedStore) UpdateRelationship(ctx context.Context, relationshipID string, confidence float64, factors types.ConfidenceFactors) error {
+	return s.store.UpdateRelationship(ctx, relationshipID, confidence, factors)
+}
+
+// DeleteRelationship deletes a relationship
+func (s *LexicalIndexedStore) DeleteRelationship(ctx context.Context, relationshipID string) error {
+	return s.store.DeleteRelationship(ctx, relationshipID)
+}
+
+// GetRelationshipByID gets a relationship by ID
+func (s *LexicalIndexedStore) GetRelationshipByID(ctx context.Context, relationshipID string) (*types.MemoryRelationship, error) {
+	return s.store.GetRelationshipByID(ctx, relationshipID)
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const localDocumentsDir = "documents"
+
+// Ensure LocalStore can keep threads and chains
+var _ DocumentStore = (*LocalStore)(nil)
+
+// PutDocument atomically writes a document below the collection directory
+func (ls *LocalStore) PutDocument(_ context.Context, kind, id string, data []byte) error {
+	start := time.Now()
+	defer ls.updateMetrics("put_document", start)
+
+	if err := validateLocalDocument(kind, id); err != nil {
+		return err
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if err := ls.checkReady(); err != nil {
+		return err
+	}
+
+	dir := ls.documentsDir(kind)
+	if err := os.MkdirAll(dir, localDirPermissions); err != nil {
+		return fmt.Errorf("failed to create local storage directory %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, id+".json")
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, localFilePermissions); err != nil {
+		return fmt.Errorf("failed to write document %s/%s: %w", kind, id, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to write document %s/%s: %w", kind, id, err)
+	}
+	return nil
+}
+
+// GetDocument reads a document
+func (ls *LocalStore) GetDocument(_ context.Context, kind, id string) ([]byte, error) {
+	start := time.Now()
+	defer ls.updateMetrics("get_document", start)
+
+	if err := validateLocalDocument(kind, id); err != nil {
+		return nil, err
+	}
+
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	if err := ls.checkReady(); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(ls.documentsDir(kind), filepath.Clean(id+".json")))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s/%s", ErrDocumentNotFound, kind, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document %s/%s: %w", kind, id, err)
+	}
+	return data, nil
+}
+
+// ListDocuments reads every document of a kind
+func (ls *LocalStore) ListDocuments(_ context.Context, kind string) ([][]byte, error) {
+	start := time.Now()
+	defer ls.updateMetrics("list_documents", start)
+
+	if err := validateLocalID(kind); err != nil {
+		return nil, err
+	}
+
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	if err := ls.checkReady(); err != nil {
+		return nil, err
+	}
+
+	var documents [][]byte
+	err := loadJSONDir(ls.documentsDir(kind), func(data []byte) error {
+		documents = append(documents, data)
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list %s documents: %w", kind, err)
+	}
+	return documents, nil
+}
+
+// DeleteDocument removes a document
+func (ls *LocalStore) DeleteDocument(_ context.Context, kind, id string) error {
+	start := time.Now()
+	defer ls.updateMetrics("delete_document", start)
+
+	if err := validateLocalDocument(kind, id); err != nil {
+		return err
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if err := ls.checkReady(); err != nil {
+		return err
+	}
+
+	err := os.Remove(filepath.Join(ls.documentsDir(kind), id+".json"))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s/%s", ErrDocumentNotFound, kind, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete document %s/%s: %w", kind, id, err)
+	}
+	return nil
+}
+
+func (ls *LocalStore) documentsDir(kind string) string {
+	return filepath.Join(ls.baseDir, localDocumentsDir, kind)
+}
+
+// copyDocumentsLocked copies every document into another collection
+// directory. Callers must hold ls.mu.
+func (ls *LocalStore) copyDocumentsLocked(targetDir string) error {
+	source := filepath.Join(ls.baseDir, localDocumentsDir)
+	kinds, err := os.ReadDir(source)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read documents: %w", err)
+	}
+
+	for _, kind := range kinds {
+		if !kind.IsDir() {
+			continue
+		}
+		targetKindDir := filepath.Join(targetDir, localDocumentsDir, kind.Name())
+		if err := os.MkdirAll(targetKindDir, localDirPermissions); err != nil {
+			return fmt.Errorf("failed to create local storage directory %s: %w", targetKindDir, err)
+		}
+
+		entries, err := os.ReadDir(filepath.Join(source, kind.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read %s documents: %w", kind.Name(), err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(source, kind.Name(), entry.Name()))
+			if err != nil {
+				return fmt.Errorf("failed to read document %s/%s: %w", kind.Name(), entry.Name(), err)
+			}
+			if err := os.WriteFile(filepath.Join(targetKindDir, entry.Name()), data, localFilePermissions); err != nil {
+				return fmt.Errorf("failed to copy document %s/%s: %w", kind.Name(), entry.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateLocalDocument(kind, id string) error {
+	if err := validateLocalID(kind); err != nil {
+		return err
+	}
+	return validateLocalID(id)
+}
@@ -0,0 +1,1181 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/logging"
+	"lerian-mcp-memory/internal/metrics"
+	"lerian-mcp-memory/pkg/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	localChunksDir          = "chunks"
+	localRelationshipsDir   = "relationships"
+	localIndexFile          = "index.gob"
+	localIndexSeed          = 1536
+	localDefaultSearchLimit = 10
+	localFilePermissions    = 0o600
+	localDirPermissions     = 0o750
+)
+
+// LocalStore implements VectorStore on the local filesystem. Each chunk and
+// relationship is kept as a JSON document, and similarity search goes through
+// an on-disk LSH index with an exact re-rank, so no external service is needed.
+type LocalStore struct {
+	mu             sync.RWMutex
+	metricsMu      sync.Mutex
+	config         *config.LocalStorageConfig
+	metrics        *StorageMetrics
+	baseCollection string
+	collectionName string
+	baseDir        string
+
+	chunks        map[string]*types.ConversationChunk
+	relationships map[string]*types.MemoryRelationship
+	index         *annIndex
+	indexDirty    bool
+	initialized   bool
+}
+
+// NewLocalStore creates a new embedded local vector store
+func NewLocalStore(cfg *config.LocalStorageConfig) *LocalStore {
+	collectionName := cfg.Collection
+	if collectionName == "" {
+		collectionName = defaultQdrantCollection
+	}
+
+	return &LocalStore{
+		config:         cfg,
+		baseCollection: collectionName,
+		collectionName: collectionName,
+		baseDir:        filepath.Join(cfg.DataDir, collectionName),
+		chunks:         make(map[string]*types.ConversationChunk),
+		relationships:  make(map[string]*types.MemoryRelationship),
+		index:          newANNIndex(cfg.IndexTables, cfg.IndexBits, localIndexSeed),
+		metrics: &StorageMetrics{
+			OperationCounts:  make(map[string]int64),
+			AverageLatency:   make(map[string]float64),
+			ErrorCounts:      make(map[string]int64),
+			ConnectionStatus: "unknown",
+		},
+	}
+}
+
+// Initialize creates the data directories and loads existing data and index
+func (ls *LocalStore) Initialize(ctx context.Context) error {
+	start := time.Now()
+	defer ls.updateMetrics("initialize", start)
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	// Follow a previous re-embedding swap
+	pointer, err := readActivePointer(ls.activePointerPath())
+	if err != nil {
+		ls.setConnectionStatus(connectionStatusError)
+		return err
+	}
+	if pointer != "" {
+		ls.collectionName = pointer
+		ls.baseDir = filepath.Join(ls.config.DataDir, pointer)
+	}
+
+	if err := ls.loadLocked(); err != nil {
+		ls.setConnectionStatus(connectionStatusError)
+		return err
+	}
+
+	ls.initialized = true
+	ls.setConnectionStatus("connected")
+	logging.Info("Local vector store initialized",
+		"collection", ls.collectionName,
+		"path", ls.baseDir,
+		"chunks", len(ls.chunks),
+		"relationships", len(ls.relationships),
+	)
+	return nil
+}
+
+// loadLocked creates the collection directories and loads its chunks,
+// relationships and index. Callers must hold ls.mu.
+func (ls *LocalStore) loadLocked() error {
+	for _, dir := range []string{ls.chunksDir(), ls.relationshipsDir()} {
+		if err := os.MkdirAll(dir, localDirPermissions); err != nil {
+			return fmt.Errorf("failed to create local storage directory %s: %w", dir, err)
+		}
+	}
+
+	chunks := make(map[string]*types.ConversationChunk)
+	if err := loadJSONDir(ls.chunksDir(), func(data []byte) error {
+		var chunk types.ConversationChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return err
+		}
+		chunks[chunk.ID] = &chunk
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to load chunks: %w", err)
+	}
+
+	relationships := make(map[string]*types.MemoryRelationship)
+	if err := loadJSONDir(ls.relationshipsDir(), func(data []byte) error {
+		var relationship types.MemoryRelationship
+		if err := json.Unmarshal(data, &relationship); err != nil {
+			return err
+		}
+		relationships[relationship.ID] = &relationship
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to load relationships: %w", err)
+	}
+
+	ls.chunks = chunks
+	ls.relationships = relationships
+
+	// Reuse the persisted index when it still describes the stored chunks.
+	// Chunk writes rename files into the chunks directory, so a directory
+	// modified after the index was saved means the index missed an update.
+	index, err := loadANNIndex(ls.indexPath(), ls.index.tables, ls.index.bits)
+	if err != nil || index.Size() != len(chunks) || !ls.indexIsCurrent() {
+		if err != nil && !os.IsNotExist(err) {
+			logging.Warn("Rebuilding local ANN index", "reason", err)
+		}
+		if err := ls.rebuildIndex(); err != nil {
+			return err
+		}
+	} else {
+		ls.index = index
+	}
+	ls.indexDirty = false
+	return nil
+}
+
+// indexIsCurrent reports whether the index file was saved after the last chunk change
+func (ls *LocalStore) indexIsCurrent() bool {
+	indexInfo, err := os.Stat(ls.indexPath())
+	if err != nil {
+		return false
+	}
+	dirInfo, err := os.Stat(ls.chunksDir())
+	if err != nil {
+		return false
+	}
+	return !dirInfo.ModTime().After(indexInfo.ModTime())
+}
+
+// rebuildIndex recreates the ANN index from the loaded chunks
+func (ls *LocalStore) rebuildIndex() error {
+	ls.index = newANNIndex(ls.index.tables, ls.index.bits, localIndexSeed)
+	for id, chunk := range ls.chunks {
+		if len(chunk.Embeddings) == 0 {
+			continue
+		}
+		if err := ls.index.Add(id, chunk.Embeddings); err != nil {
+			return fmt.Errorf("failed to index chunk %s: %w", id, err)
+		}
+	}
+	if err := ls.index.Save(ls.indexPath()); err != nil {
+		return fmt.Errorf("failed to save local ANN index: %w", err)
+	}
+	ls.indexDirty = false
+	return nil
+}
+
+// Store saves a conversation chunk to local disk
+func (ls *LocalStore) Store(ctx context.Context, chunk *types.ConversationChunk) error {
+	start := time.Now()
+	defer ls.updateMetrics("store", start)
+
+	if err := chunk.Validate(); err != nil {
+		return fmt.Errorf("invalid chunk: %w", err)
+	}
+
+	if len(chunk.Embeddings) == 0 {
+		return errors.New("chunk must have embeddings before storing")
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if err := ls.storeLocked(chunk); err != nil {
+		return err
+	}
+
+	logging.Debug("Stored chunk in local store",
+		"id", chunk.ID,
+		"repository", chunk.Metadata.Repository,
+		"type", chunk.Type,
+	)
+	return nil
+}
+
+// VectorDimension returns the dimension of the stored vectors, or 0 while
+// the store is empty
+func (ls *LocalStore) VectorDimension(_ context.Context) (int, error) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	if err := ls.checkReady(); err != nil {
+		return 0, err
+	}
+	return ls.index.Dimension(), nil
+}
+
+// storeLocked persists a validated chunk; the caller must hold the write lock
+func (ls *LocalStore) storeLocked(chunk *types.ConversationChunk) error {
+	if err := ls.checkReady(); err != nil {
+		return err
+	}
+	if err := validateLocalID(chunk.ID); err != nil {
+		return err
+	}
+	if dim := ls.index.Dimension(); dim != 0 && dim != len(chunk.Embeddings) {
+		return fmt.Errorf("failed to store chunk in local store: vector dimension mismatch: collection uses %d, got %d", dim, len(chunk.Embeddings))
+	}
+
+	stored := *chunk
+	if err := writeJSONFile(ls.chunkPath(chunk.ID), &stored); err != nil {
+		return fmt.Errorf("failed to store chunk in local store: %w", err)
+	}
+
+	if existing, ok := ls.chunks[chunk.ID]; ok {
+		ls.index.Remove(existing.ID, existing.Embeddings)
+	}
+	if err := ls.index.Add(chunk.ID, chunk.Embeddings); err != nil {
+		return fmt.Errorf("failed to index chunk: %w", err)
+	}
+	ls.chunks[chunk.ID] = &stored
+	ls.indexDirty = true
+	return nil
+}
+
+// Search performs similarity search over the local collection
+func (ls *LocalStore) Search(ctx context.Context, query *types.MemoryQuery, embeddings []float64) (*types.SearchResults, error) {
+	start := time.Now()
+	defer ls.updateMetrics("search", start)
+
+	if len(embeddings) == 0 {
+		return nil, errors.New("embeddings cannot be empty")
+	}
+
+	// Qdrant falls back to its default page size when no positive limit is given
+	limit := query.Limit
+	if limit <= 0 {
+		limit = localDefaultSearchLimit
+	}
+
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	if err := ls.checkReady(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	// Score only the ANN candidates and fall back to an exhaustive scan when
+	// none of them pass the query filters
+	results := ls.scoreChunks(ls.index.Candidates(embeddings), query, embeddings, now)
+	if len(results) == 0 {
+		results = ls.scoreChunks(nil, query, embeddings, now)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	logging.Debug("Search completed",
+		"query", query.Query,
+		"results", len(results),
+		"total_time_ms", time.Since(start).Milliseconds(),
+	)
+
+	return &types.SearchResults{
+		Results:   results,
+		Total:     len(results),
+		QueryTime: time.Since(start),
+	}, nil
+}
+
+// scoreChunks scores the candidate chunks (all chunks when candidates is nil)
+// that pass the query filter and relevance threshold
+func (ls *LocalStore) scoreChunks(candidates map[string]bool, query *types.MemoryQuery, embeddings []float64, now time.Time) []types.SearchResult {
+	results := make([]types.SearchResult, 0)
+	score := func(chunk *types.ConversationChunk) {
+		if !chunkMatchesQuery(chunk, query, now) {
+			return
+		}
+		similarity := cosineSimilarity(embeddings, chunk.Embeddings)
+		if similarity < query.MinRelevanceScore {
+			return
+		}
+		results = append(results, types.SearchResult{
+			Chunk: *chunk,
+			Score: similarity,
+		})
+	}
+
+	if candidates == nil {
+		for _, chunk := range ls.chunks {
+			score(chunk)
+		}
+		return results
+	}
+	for id := range candidates {
+		if chunk, ok := ls.chunks[id]; ok {
+			score(chunk)
+		}
+	}
+	return results
+}
+
+// GetByID retrieves a chunk by its ID
+func (ls *LocalStore) GetByID(ctx context.Context, id string) (*types.ConversationChunk, error) {
+	start := time.Now()
+	defer ls.updateMetrics("get_by_id", start)
+
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	chunk, ok := ls.chunks[id]
+	if !ok {
+		return nil, fmt.Errorf("chunk not found with ID: %s", id)
+	}
+
+	result := *chunk
+	return &result, nil
+}
+
+// ListByRepository lists chunks by repository, newest first
+func (ls *LocalStore) ListByRepository(ctx context.Context, repository string, limit, offset int) ([]types.ConversationChunk, error) {
+	start := time.Now()
+	defer ls.updateMetrics("list_by_repository", start)
+
+	ls.mu.RLock()
+	matching := ls.collectChunks(func(chunk *types.ConversationChunk) bool {
+		return chunk.Metadata.Repository == repository
+	})
+	ls.mu.RUnlock()
+
+	sort.Slice(matching, func(i, j int) bool {
+		return matching[i].Timestamp.After(matching[j].Timestamp)
+	})
+
+	var chunks []types.ConversationChunk
+	if offset >= 0 && offset < len(matching) {
+		end := offset + limit
+		if limit < 0 || end > len(matching) {
+			end = len(matching)
+		}
+		chunks = matching[offset:end]
+	}
+
+	return chunks, nil
+}
+
+// ListBySession lists chunks by session ID, oldest first
+func (ls *LocalStore) ListBySession(ctx context.Context, sessionID string) ([]types.ConversationChunk, error) {
+	start := time.Now()
+	defer ls.updateMetrics("list_by_session", start)
+
+	ls.mu.RLock()
+	chunks := ls.collectChunks(func(chunk *types.ConversationChunk) bool {
+		return chunk.SessionID == sessionID
+	})
+	ls.mu.RUnlock()
+
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].Timestamp.Before(chunks[j].Timestamp)
+	})
+
+	return chunks, nil
+}
+
+// collectChunks copies the chunks accepted by match; the caller must hold a lock
+func (ls *LocalStore) collectChunks(match func(chunk *types.ConversationChunk) bool) []types.ConversationChunk {
+	chunks := make([]types.ConversationChunk, 0)
+	for _, chunk := range ls.chunks {
+		if match(chunk) {
+			chunks = append(chunks, *chunk)
+		}
+	}
+	return chunks
+}
+
+// Delete removes a chunk by ID
+func (ls *LocalStore) Delete(ctx context.Context, id string) error {
+	start := time.Now()
+	defer ls.updateMetrics("delete", start)
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if err := ls.deleteLocked(id); err != nil {
+		return fmt.Errorf("failed to delete chunk from local store: %w", err)
+	}
+
+	logging.Debug("Deleted chunk from local store", "id", id)
+	return nil
+}
+
+// deleteLocked removes a chunk file, its index entry and its relationships; the caller must hold the write lock.
+// Deleting a missing chunk is not an error, matching Qdrant semantics.
+func (ls *LocalStore) deleteLocked(id string) error {
+	if err := validateLocalID(id); err != nil {
+		return err
+	}
+	if err := os.Remove(ls.chunkPath(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if chunk, ok := ls.chunks[id]; ok {
+		ls.index.Remove(id, chunk.Embeddings)
+		delete(ls.chunks, id)
+		ls.indexDirty = true
+	}
+	return ls.deleteChunkRelationshipsLocked(id)
+}
+
+// deleteChunkRelationshipsLocked removes the relationships that start or
+// end at a chunk; the caller must hold the write lock
+func (ls *LocalStore) deleteChunkRelationshipsLocked(chunkID string) error {
+	for id, relationship := range ls.relationships {
+		if relationship.SourceChunkID != chunkID && relationship.TargetChunkID != chunkID {
+			continue
+		}
+		if err := os.Remove(ls.relationshipPath(id)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete relationship %s: %w", id, err)
+		}
+		delete(ls.relationships, id)
+	}
+	return nil
+}
+
+// Update modifies an existing chunk
+func (ls *LocalStore) Update(ctx context.Context, chunk *types.ConversationChunk) error {
+	start := time.Now()
+	defer ls.updateMetrics("update", start)
+
+	if err := chunk.Validate(); err != nil {
+		return fmt.Errorf("invalid chunk: %w", err)
+	}
+
+	// Like Qdrant, update is an upsert
+	return ls.Store(ctx, chunk)
+}
+
+// HealthCheck verifies the data directory is usable
+func (ls *LocalStore) HealthCheck(ctx context.Context) error {
+	start := time.Now()
+	defer ls.updateMetrics("health_check", start)
+
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	if err := ls.checkReady(); err != nil {
+		ls.setConnectionStatus(connectionStatusError)
+		return fmt.Errorf("local store health check failed: %w", err)
+	}
+	if _, err := os.Stat(ls.chunksDir()); err != nil {
+		ls.setConnectionStatus(connectionStatusError)
+		return fmt.Errorf("local store health check failed: %w", err)
+	}
+
+	ls.setConnectionStatus("healthy")
+	return nil
+}
+
+// GetStats returns statistics about the store
+func (ls *LocalStore) GetStats(ctx context.Context) (*StoreStats, error) {
+	start := time.Now()
+	defer ls.updateMetrics("get_stats", start)
+
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	stats := &StoreStats{
+		TotalChunks:  int64(len(ls.chunks)),
+		ChunksByType: make(map[string]int64),
+		ChunksByRepo: make(map[string]int64),
+	}
+
+	var oldestTime, newestTime *time.Time
+	totalEmbeddingSize := 0
+	for _, chunk := range ls.chunks {
+		stats.ChunksByType[string(chunk.Type)]++
+		stats.ChunksByRepo[chunk.Metadata.Repository]++
+		totalEmbeddingSize += len(chunk.Embeddings)
+
+		timestamp := chunk.Timestamp
+		if oldestTime == nil || timestamp.Before(*oldestTime) {
+			oldestTime = &timestamp
+		}
+		if newestTime == nil || timestamp.After(*newestTime) {
+			newestTime = &timestamp
+		}
+	}
+
+	if oldestTime != nil {
+		oldestStr := oldestTime.Format(time.RFC3339)
+		stats.OldestChunk = &oldestStr
+	}
+	if newestTime != nil {
+		newestStr := newestTime.Format(time.RFC3339)
+		stats.NewestChunk = &newestStr
+	}
+	if len(ls.chunks) > 0 {
+		stats.AverageEmbedding = float64(totalEmbeddingSize) / float64(len(ls.chunks))
+	}
+	stats.StorageSize = directorySize(ls.baseDir)
+
+	return stats, nil
+}
+
+// Cleanup removes chunks older than the retention period
+func (ls *LocalStore) Cleanup(ctx context.Context, retentionDays int) (int, error) {
+	start := time.Now()
+	defer ls.updateMetrics("cleanup", start)
+
+	cutoffTimestamp := time.Now().AddDate(0, 0, -retentionDays).Unix()
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	expired := make([]string, 0)
+	for id, chunk := range ls.chunks {
+		if chunk.Timestamp.Unix() < cutoffTimestamp {
+			expired = append(expired, id)
+		}
+	}
+
+	for _, id := range expired {
+		if err := ls.deleteLocked(id); err != nil {
+			return 0, fmt.Errorf("failed to cleanup old chunks: %w", err)
+		}
+	}
+
+	logging.Info("Cleaned up old chunks",
+		"deleted_count", len(expired),
+		"retention_days", retentionDays,
+	)
+
+	return len(expired), nil
+}
+
+// Close flushes the ANN index to disk
+func (ls *LocalStore) Close() error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if ls.initialized && ls.indexDirty {
+		if err := ls.index.Save(ls.indexPath()); err != nil {
+			return fmt.Errorf("failed to save local ANN index: %w", err)
+		}
+		ls.indexDirty = false
+	}
+
+	ls.setConnectionStatus("closed")
+	logging.Info("Local vector store closed", "collection", ls.collectionName)
+	return nil
+}
+
+// GetAllChunks retrieves all chunks from the collection
+func (ls *LocalStore) GetAllChunks(ctx context.Context) ([]types.ConversationChunk, error) {
+	start := time.Now()
+	defer ls.updateMetrics("get_all_chunks", start)
+
+	ls.mu.RLock()
+	chunks := ls.collectChunks(func(*types.ConversationChunk) bool { return true })
+	ls.mu.RUnlock()
+
+	logging.Debug("Retrieved all chunks", "count", len(chunks))
+	return chunks, nil
+}
+
+// DeleteCollection deletes the collection's chunks, relationships and index
+func (ls *LocalStore) DeleteCollection(ctx context.Context, collection string) error {
+	start := time.Now()
+	defer ls.updateMetrics("delete_collection", start)
+
+	collectionName := collection
+	if collectionName == "" {
+		collectionName = ls.collectionName
+	}
+	if err := validateLocalID(collectionName); err != nil {
+		return err
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if err := os.RemoveAll(filepath.Join(ls.config.DataDir, collectionName)); err != nil {
+		return fmt.Errorf("failed to delete collection %s: %w", collectionName, err)
+	}
+
+	if collectionName == ls.collectionName {
+		ls.chunks = make(map[string]*types.ConversationChunk)
+		ls.relationships = make(map[string]*types.MemoryRelationship)
+		ls.index = newANNIndex(ls.index.tables, ls.index.bits, localIndexSeed)
+		ls.indexDirty = false
+		ls.initialized = false
+	}
+
+	logging.Info("Deleted collection", "collection", collectionName)
+	return nil
+}
+
+// ListCollections lists the collections present in the data directory
+func (ls *LocalStore) ListCollections(ctx context.Context) ([]string, error) {
+	start := time.Now()
+	defer ls.updateMetrics("list_collections", start)
+
+	entries, err := os.ReadDir(ls.config.DataDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	collections := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			collections = append(collections, entry.Name())
+		}
+	}
+
+	logging.Debug("Listed collections", "count", len(collections))
+	return collections, nil
+}
+
+// FindSimilar finds similar chunks based on content using embeddings
+func (ls *LocalStore) FindSimilar(ctx context.Context, content string, chunkType *types.ChunkType, limit int) ([]types.ConversationChunk, error) {
+	start := time.Now()
+	defer ls.updateMetrics("find_similar", start)
+
+	// Same contract as QdrantStore: callers must embed the content and use Search
+	return nil, errors.New("FindSimilar requires embedding service integration - use Search method with embeddings instead")
+}
+
+// StoreChunk is an alias for Store for backward compatibility
+func (ls *LocalStore) StoreChunk(ctx context.Context, chunk *types.ConversationChunk) error {
+	return ls.Store(ctx, chunk)
+}
+
+// BatchStore stores multiple chunks in a single operation
+func (ls *LocalStore) BatchStore(ctx context.Context, chunks []*types.ConversationChunk) (*BatchResult, error) {
+	start := time.Now()
+	defer ls.updateMetrics("batch_store", start)
+
+	if len(chunks) == 0 {
+		return &BatchResult{Success: 0, Failed: 0}, nil
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	processedIDs := make([]string, 0, len(chunks))
+	errorMessages := make([]string, 0)
+
+	for i := range chunks {
+		chunk := chunks[i]
+		if err := chunk.Validate(); err != nil {
+			errorMessages = append(errorMessages, fmt.Sprintf("invalid chunk %s: %v", chunk.ID, err))
+			continue
+		}
+
+		if len(chunk.Embeddings) == 0 {
+			errorMessages = append(errorMessages, "chunk "+chunk.ID+" has no embeddings")
+			continue
+		}
+
+		if err := ls.storeLocked(chunk); err != nil {
+			errorMessages = append(errorMessages, fmt.Sprintf("chunk %s: %v", chunk.ID, err))
+			continue
+		}
+		processedIDs = append(processedIDs, chunk.ID)
+	}
+
+	result := &BatchResult{
+		Success:      len(processedIDs),
+		Failed:       len(chunks) - len(processedIDs),
+		Errors:       errorMessages,
+		ProcessedIDs: processedIDs,
+	}
+
+	logging.Debug("Batch store completed",
+		"success", result.Success,
+		"failed", result.Failed,
+		"total", len(chunks),
+	)
+
+	return result, nil
+}
+
+// BatchDelete deletes multiple chunks by their IDs
+func (ls *LocalStore) BatchDelete(ctx context.Context, ids []string) (*BatchResult, error) {
+	start := time.Now()
+	defer ls.updateMetrics("batch_delete", start)
+
+	if len(ids) == 0 {
+		return &BatchResult{Success: 0, Failed: 0}, nil
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	result := &BatchResult{ProcessedIDs: ids}
+	for _, id := range ids {
+		if err := ls.deleteLocked(id); err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("chunk %s: %v", id, err))
+			continue
+		}
+		result.Success++
+	}
+
+	logging.Debug("Batch delete completed",
+		"success", result.Success,
+		"total", len(ids),
+	)
+
+	return result, nil
+}
+
+// Relationship management methods
+
+// StoreRelationship creates and stores a new memory relationship
+func (ls *LocalStore) StoreRelationship(ctx context.Context, sourceID, targetID string, relationType types.RelationType, confidence float64, source types.ConfidenceSource) (*types.MemoryRelationship, error) {
+	start := time.Now()
+	defer ls.updateMetrics("store_relationship", start)
+
+	relationship, err := types.NewMemoryRelationship(sourceID, targetID, relationType, confidence, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create relationship: %w", err)
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if err := ls.storeRelationshipLocked(relationship); err != nil {
+		return nil, err
+	}
+
+	// Store inverse relationship if symmetric
+	if relationType.IsSymmetric() {
+		inverseRelationship, err := types.NewMemoryRelationship(targetID, sourceID, relationType, confidence, source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create inverse relationship: %w", err)
+		}
+		if err := ls.storeRelationshipLocked(inverseRelationship); err != nil {
+			return nil, fmt.Errorf("failed to store inverse relationship: %w", err)
+		}
+	}
+
+	return relationship, nil
+}
+
+// storeRelationshipLocked persists a relationship; the caller must hold the write lock
+func (ls *LocalStore) storeRelationshipLocked(relationship *types.MemoryRelationship) error {
+	if err := relationship.Validate(); err != nil {
+		return fmt.Errorf("invalid relationship: %w", err)
+	}
+	if err := ls.checkReady(); err != nil {
+		return err
+	}
+	if err := validateLocalID(relationship.ID); err != nil {
+		return err
+	}
+	if err := writeJSONFile(ls.relationshipPath(relationship.ID), relationship); err != nil {
+		return fmt.Errorf("failed to store relationship in local store: %w", err)
+	}
+	stored := *relationship
+	ls.relationships[relationship.ID] = &stored
+	return nil
+}
+
+// GetRelationships finds relationships for a chunk
+func (ls *LocalStore) GetRelationships(ctx context.Context, query *types.RelationshipQuery) ([]types.RelationshipResult, error) {
+	start := time.Now()
+	defer ls.updateMetrics("get_relationships", start)
+
+	if err := query.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+
+	ls.mu.RLock()
+	results := make([]types.RelationshipResult, 0)
+	for _, relationship := range ls.relationships {
+		if relationshipMatchesQuery(relationship, query) {
+			results = append(results, types.RelationshipResult{Relationship: *relationship})
+		}
+	}
+	ls.mu.RUnlock()
+
+	sortRelationships(results, query.SortBy, query.SortOrder)
+
+	if query.Limit > 0 && len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+
+	return results, nil
+}
+
+// relationshipMatchesQuery applies the direction, confidence and type filters
+// that RelationshipStore.GetRelationships applies
+func relationshipMatchesQuery(relationship *types.MemoryRelationship, query *types.RelationshipQuery) bool {
+	switch query.Direction {
+	case "outgoing":
+		if relationship.SourceChunkID != query.ChunkID {
+			return false
+		}
+	case "incoming":
+		if relationship.TargetChunkID != query.ChunkID {
+			return false
+		}
+	case "both":
+		if relationship.SourceChunkID != query.ChunkID && relationship.TargetChunkID != query.ChunkID {
+			return false
+		}
+	}
+
+	if relationship.Confidence < query.MinConfidence {
+		return false
+	}
+
+	if len(query.RelationTypes) > 0 {
+		for _, rt := range query.RelationTypes {
+			if relationship.RelationType == rt {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// TraverseGraph traverses the knowledge graph starting from a chunk
+func (ls *LocalStore) TraverseGraph(ctx context.Context, startChunkID string, maxDepth int, relationTypes []types.RelationType) (*types.GraphTraversalResult, error) {
+	start := time.Now()
+	defer ls.updateMetrics("traverse_graph", start)
+
+	return traverseRelationshipGraph(ctx, startChunkID, maxDepth, relationTypes, ls.GetRelationships), nil
+}
+
+// UpdateRelationship updates an existing relationship's confidence
+func (ls *LocalStore) UpdateRelationship(ctx context.Context, relationshipID string, confidence float64, factors types.ConfidenceFactors) error {
+	start := time.Now()
+	defer ls.updateMetrics("update_relationship", start)
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	existing, ok := ls.relationships[relationshipID]
+	if !ok {
+		return fmt.Errorf("relationship not found: %s", relationshipID)
+	}
+
+	relationship := *existing
+	if err := relationship.UpdateConfidence(confidence, factors); err != nil {
+		return fmt.Errorf("failed to update confidence: %w", err)
+	}
+
+	return ls.storeRelationshipLocked(&relationship)
+}
+
+// DeleteRelationship removes a relationship
+func (ls *LocalStore) DeleteRelationship(ctx context.Context, relationshipID string) error {
+	start := time.Now()
+	defer ls.updateMetrics("delete_relationship", start)
+
+	if err := validateLocalID(relationshipID); err != nil {
+		return err
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if err := os.Remove(ls.relationshipPath(relationshipID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete relationship: %w", err)
+	}
+	delete(ls.relationships, relationshipID)
+	return nil
+}
+
+// GetRelationshipByID retrieves a specific relationship
+func (ls *LocalStore) GetRelationshipByID(ctx context.Context, relationshipID string) (*types.MemoryRelationship, error) {
+	start := time.Now()
+	defer ls.updateMetrics("get_relationship_by_id", start)
+
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	relationship, ok := ls.relationships[relationshipID]
+	if !ok {
+		return nil, fmt.Errorf("relationship not found: %s", relationshipID)
+	}
+
+	result := *relationship
+	return &result, nil
+}
+
+// Shadow collection support
+
+// localActivePointer records which collection directory is active after a swap
+type localActivePointer struct {
+	Collection string    `json:"collection"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// activePointerPath is the file naming the active collection
+func (ls *LocalStore) activePointerPath() string {
+	return filepath.Join(ls.config.DataDir, ls.baseCollection+".active")
+}
+
+// readActivePointer returns the collection named by the pointer file, or ""
+// when no swap has happened
+func readActivePointer(path string) (string, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read active collection pointer: %w", err)
+	}
+
+	var pointer localActivePointer
+	if err := json.Unmarshal(data, &pointer); err != nil {
+		return "", fmt.Errorf("failed to decode active collection pointer %s: %w", path, err)
+	}
+	if err := validateLocalID(pointer.Collection); err != nil {
+		return "", err
+	}
+	return pointer.Collection, nil
+}
+
+// ActiveCollection returns the collection currently serving reads and writes
+func (ls *LocalStore) ActiveCollection() string {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return ls.collectionName
+}
+
+// OpenShadowCollection opens the named collection directory as a separate
+// store. Its chunks may not use the active collection's dimension.
+func (ls *LocalStore) OpenShadowCollection(ctx context.Context, name string, dimension int) (VectorStore, error) {
+	if err := validateShadowCollectionName(name); err != nil {
+		return nil, err
+	}
+	if name == ls.ActiveCollection() {
+		return nil, fmt.Errorf("collection %s is already active", name)
+	}
+
+	shadowConfig := *ls.config
+	shadowConfig.Collection = name
+	shadow := NewLocalStore(&shadowConfig)
+	shadow.baseCollection = name
+	if err := shadow.Initialize(ctx); err != nil {
+		return nil, fmt.Errorf("failed to open shadow collection %s: %w", name, err)
+	}
+
+	if err := VerifyVectorDimension(ctx, shadow, dimension); err != nil {
+		_ = shadow.Close()
+		return nil, err
+	}
+	return shadow, nil
+}
+
+// PromoteCollection makes the named collection active by rewriting the
+// pointer file, then reloads from it. Relationships and documents do not
+// depend on the embedding model and are carried over from the previous
+// collection.
+func (ls *LocalStore) PromoteCollection(_ context.Context, name string) (string, error) {
+	start := time.Now()
+	defer ls.updateMetrics("promote_collection", start)
+
+	if err := validateShadowCollectionName(name); err != nil {
+		return "", err
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if err := ls.checkReady(); err != nil {
+		return "", err
+	}
+	previous := ls.collectionName
+	if name == previous {
+		return "", fmt.Errorf("collection %s is already active", name)
+	}
+
+	targetDir := filepath.Join(ls.config.DataDir, name)
+	if _, err := os.Stat(filepath.Join(targetDir, localChunksDir)); err != nil {
+		return "", fmt.Errorf("collection %s does not exist: %w", name, err)
+	}
+
+	if ls.indexDirty {
+		if err := ls.index.Save(ls.indexPath()); err != nil {
+			return "", fmt.Errorf("failed to save local ANN index: %w", err)
+		}
+		ls.indexDirty = false
+	}
+
+	targetRelationships := filepath.Join(targetDir, localRelationshipsDir)
+	if err := os.MkdirAll(targetRelationships, localDirPermissions); err != nil {
+		return "", fmt.Errorf("failed to create local storage directory %s: %w", targetRelationships, err)
+	}
+	for id, relationship := range ls.relationships {
+		if err := writeJSONFile(filepath.Join(targetRelationships, id+".json"), relationship); err != nil {
+			return "", fmt.Errorf("failed to copy relationship %s: %w", id, err)
+		}
+	}
+
+	if err := ls.copyDocumentsLocked(targetDir); err != nil {
+		return "", err
+	}
+
+	pointer := localActivePointer{Collection: name, UpdatedAt: time.Now().UTC()}
+	if err := writeJSONFile(ls.activePointerPath(), pointer); err != nil {
+		return "", fmt.Errorf("failed to write active collection pointer: %w", err)
+	}
+
+	ls.collectionName = name
+	ls.baseDir = targetDir
+	if err := ls.loadLocked(); err != nil {
+		ls.setConnectionStatus(connectionStatusError)
+		return "", fmt.Errorf("failed to load promoted collection %s: %w", name, err)
+	}
+
+	logging.Info("Promoted local collection", "collection", name, "previous", previous)
+	return previous, nil
+}
+
+// DropCollection deletes an inactive collection directory
+func (ls *LocalStore) DropCollection(ctx context.Context, name string) error {
+	if name == ls.ActiveCollection() {
+		return fmt.Errorf("refusing to drop active collection %s", name)
+	}
+	return ls.DeleteCollection(ctx, name)
+}
+
+// Helper methods
+
+func (ls *LocalStore) checkReady() error {
+	if !ls.initialized {
+		return errors.New("local store is not initialized")
+	}
+	return nil
+}
+
+func (ls *LocalStore) chunksDir() string {
+	return filepath.Join(ls.baseDir, localChunksDir)
+}
+
+func (ls *LocalStore) relationshipsDir() string {
+	return filepath.Join(ls.baseDir, localRelationshipsDir)
+}
+
+func (ls *LocalStore) indexPath() string {
+	return filepath.Join(ls.baseDir, localIndexFile)
+}
+
+func (ls *LocalStore) chunkPath(id string) string {
+	return filepath.Join(ls.chunksDir(), id+".json")
+}
+
+func (ls *LocalStore) relationshipPath(id string) string {
+	return filepath.Join(ls.relationshipsDir(), id+".json")
+}
+
+// updateMetrics updates operation metrics
+func (ls *LocalStore) updateMetrics(operation string, start time.Time) {
+	duration := time.Since(start)
+	metrics.StorageDuration.Observe(duration.Seconds(), config.StorageProviderLocal, operation)
+
+	ls.metricsMu.Lock()
+	defer ls.metricsMu.Unlock()
+
+	ls.metrics.OperationCounts[operation]++
+
+	// Update average latency
+	currentAvg := ls.metrics.AverageLatency[operation]
+	count := float64(ls.metrics.OperationCounts[operation])
+	newLatency := float64(duration.Milliseconds())
+	ls.metrics.AverageLatency[operation] = (currentAvg*(count-1) + newLatency) / count
+
+	ls.metrics.LastOperation = &operation
+}
+
+// setConnectionStatus records the current connection status
+func (ls *LocalStore) setConnectionStatus(status string) {
+	ls.metricsMu.Lock()
+	defer ls.metricsMu.Unlock()
+	ls.metrics.ConnectionStatus = status
+}
+
+// validateLocalID rejects IDs that cannot safely be used as file names
+func validateLocalID(id string) error {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		return fmt.Errorf("invalid identifier for local store: %q", id)
+	}
+	return nil
+}
+
+// writeJSONFile atomically writes a value as JSON
+func writeJSONFile(path string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, localFilePermissions); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// loadJSONDir calls decode for every JSON document in dir
+func loadJSONDir(dir string, decode func(data []byte) error) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return err
+		}
+		if err := decode(data); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// directorySize returns the total size in bytes of the files below dir
+func directorySize(dir string) int64 {
+	var size int64
+	_ = filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
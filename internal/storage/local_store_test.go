@@ -0,0 +1,352 @@
+package storage
+
+import (
+	"context"
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/pkg/types"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLocalStore(t *testing.T, dataDir string) *LocalStore {
+	t.Helper()
+	store := NewLocalStore(&config.LocalStorageConfig{
+		DataDir:     dataDir,
+		Collection:  "test_memory",
+		IndexTables: 4,
+		IndexBits:   6,
+	})
+	require.NoError(t, store.Initialize(context.Background()))
+	return store
+}
+
+func newLocalTestChunk(repository string, chunkType types.ChunkType, embeddings []float64, timestamp time.Time) *types.ConversationChunk {
+	return &types.ConversationChunk{
+		ID:         uuid.New().String(),
+		SessionID:  "session-1",
+		Timestamp:  timestamp,
+		Type:       chunkType,
+		Content:    "content for " + repository,
+		Embeddings: embeddings,
+		Metadata: types.ChunkMetadata{
+			Repository: repository,
+			Outcome:    types.OutcomeSuccess,
+			Difficulty: types.DifficultySimple,
+		},
+	}
+}
+
+func TestLocalStoreSearchFilters(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStore(t, t.TempDir())
+	defer func() { _ = store.Close() }()
+
+	now := time.Now()
+	repoChunk := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{1, 0, 0}, now)
+	solutionChunk := newLocalTestChunk("github.com/acme/api", types.ChunkTypeSolution, []float64{0.9, 0.1, 0}, now)
+	otherRepoChunk := newLocalTestChunk("github.com/acme/web", types.ChunkTypeProblem, []float64{1, 0, 0}, now)
+	globalChunk := newLocalTestChunk(globalRepository, types.ChunkTypeArchitectureDecision, []float64{1, 0, 0}, now)
+	oldChunk := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{1, 0, 0}, now.AddDate(0, 0, -30))
+	orthogonalChunk := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{0, 1, 0}, now)
+
+	for _, chunk := range []*types.ConversationChunk{repoChunk, solutionChunk, otherRepoChunk, globalChunk, oldChunk, orthogonalChunk} {
+		require.NoError(t, store.Store(ctx, chunk))
+	}
+
+	t.Run("repository isolation and recency", func(t *testing.T) {
+		repo := "github.com/acme/api"
+		query := types.NewMemoryQuery("anything")
+		query.Repository = &repo
+
+		results, err := store.Search(ctx, query, []float64{1, 0, 0})
+		require.NoError(t, err)
+		ids := searchResultIDs(results)
+		assert.ElementsMatch(t, []string{repoChunk.ID, solutionChunk.ID}, ids)
+		assert.Equal(t, repoChunk.ID, results.Results[0].Chunk.ID, "highest cosine score first")
+	})
+
+	t.Run("all time includes old chunks", func(t *testing.T) {
+		repo := "github.com/acme/api"
+		query := types.NewMemoryQuery("anything")
+		query.Repository = &repo
+		query.Recency = types.RecencyAllTime
+
+		results, err := store.Search(ctx, query, []float64{1, 0, 0})
+		require.NoError(t, err)
+		assert.Contains(t, searchResultIDs(results), oldChunk.ID)
+	})
+
+	t.Run("global repository only matches global chunks", func(t *testing.T) {
+		repo := globalRepository
+		query := types.NewMemoryQuery("anything")
+		query.Repository = &repo
+
+		results, err := store.Search(ctx, query, []float64{1, 0, 0})
+		require.NoError(t, err)
+		assert.Equal(t, []string{globalChunk.ID}, searchResultIDs(results))
+	})
+
+	t.Run("type filter", func(t *testing.T) {
+		query := types.NewMemoryQuery("anything")
+		query.Types = []types.ChunkType{types.ChunkTypeSolution}
+
+		results, err := store.Search(ctx, query, []float64{1, 0, 0})
+		require.NoError(t, err)
+		assert.Equal(t, []string{solutionChunk.ID}, searchResultIDs(results))
+	})
+
+	t.Run("min relevance excludes dissimilar chunks", func(t *testing.T) {
+		query := types.NewMemoryQuery("anything")
+		query.MinRelevanceScore = 0.5
+
+		results, err := store.Search(ctx, query, []float64{1, 0, 0})
+		require.NoError(t, err)
+		assert.NotContains(t, searchResultIDs(results), orthogonalChunk.ID)
+	})
+
+	t.Run("empty embeddings rejected", func(t *testing.T) {
+		_, err := store.Search(ctx, types.NewMemoryQuery("anything"), nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestLocalStorePersistence(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+
+	store := newTestLocalStore(t, dataDir)
+	chunk := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{0.2, 0.8, 0.1}, time.Now())
+	require.NoError(t, store.Store(ctx, chunk))
+
+	rel, err := store.StoreRelationship(ctx, chunk.ID, uuid.New().String(), types.RelationLedTo, 0.9, types.ConfidenceExplicit)
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	reopened := newTestLocalStore(t, dataDir)
+	defer func() { _ = reopened.Close() }()
+
+	loaded, err := reopened.GetByID(ctx, chunk.ID)
+	require.NoError(t, err)
+	assert.Equal(t, chunk.Content, loaded.Content)
+	assert.Equal(t, chunk.Embeddings, loaded.Embeddings)
+
+	loadedRel, err := reopened.GetRelationshipByID(ctx, rel.ID)
+	require.NoError(t, err)
+	assert.Equal(t, rel.SourceChunkID, loadedRel.SourceChunkID)
+
+	query := types.NewMemoryQuery("anything")
+	results, err := reopened.Search(ctx, query, []float64{0.2, 0.8, 0.1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{chunk.ID}, searchResultIDs(results))
+}
+
+func TestLocalStoreIndexRebuiltWhenStale(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+
+	store := newTestLocalStore(t, dataDir)
+	require.NoError(t, store.Close())
+
+	// Simulate a crash: chunks written without the index being flushed
+	crashed := newTestLocalStore(t, dataDir)
+	chunk := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{1, 1, 0}, time.Now())
+	require.NoError(t, crashed.Store(ctx, chunk))
+
+	reopened := newTestLocalStore(t, dataDir)
+	defer func() { _ = reopened.Close() }()
+	assert.True(t, reopened.index.Candidates([]float64{1, 1, 0})[chunk.ID])
+}
+
+func TestLocalStoreListAndBatch(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStore(t, t.TempDir())
+	defer func() { _ = store.Close() }()
+
+	base := time.Now().Add(-time.Hour)
+	chunks := make([]*types.ConversationChunk, 0, 5)
+	for i := 0; i < 5; i++ {
+		chunks = append(chunks, newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{1, float64(i), 0}, base.Add(time.Duration(i)*time.Minute)))
+	}
+	invalid := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, nil, base)
+	wrongDimension := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{1, 2}, base)
+
+	result, err := store.BatchStore(ctx, append(chunks, invalid, wrongDimension))
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Success)
+	assert.Equal(t, 2, result.Failed)
+
+	page, err := store.ListByRepository(ctx, "github.com/acme/api", 2, 1)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, chunks[3].ID, page[0].ID, "newest first")
+	assert.Equal(t, chunks[2].ID, page[1].ID)
+
+	session, err := store.ListBySession(ctx, "session-1")
+	require.NoError(t, err)
+	require.Len(t, session, 5)
+	assert.Equal(t, chunks[0].ID, session[0].ID, "oldest first")
+
+	deleted, err := store.BatchDelete(ctx, []string{chunks[0].ID, chunks[1].ID})
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted.Success)
+
+	stats, err := store.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats.TotalChunks)
+	assert.Equal(t, int64(3), stats.ChunksByRepo["github.com/acme/api"])
+}
+
+func TestLocalStoreCleanup(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStore(t, t.TempDir())
+	defer func() { _ = store.Close() }()
+
+	recent := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{1, 0}, time.Now())
+	old := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{1, 0}, time.Now().AddDate(0, 0, -100))
+	require.NoError(t, store.Store(ctx, recent))
+	require.NoError(t, store.Store(ctx, old))
+
+	deleted, err := store.Cleanup(ctx, 90)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	_, err = store.GetByID(ctx, old.ID)
+	assert.Error(t, err)
+	_, err = store.GetByID(ctx, recent.ID)
+	assert.NoError(t, err)
+}
+
+func TestLocalStoreRelationships(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStore(t, t.TempDir())
+	defer func() { _ = store.Close() }()
+
+	a, b, c := uuid.New().String(), uuid.New().String(), uuid.New().String()
+
+	ab, err := store.StoreRelationship(ctx, a, b, types.RelationLedTo, 0.9, types.ConfidenceExplicit)
+	require.NoError(t, err)
+	_, err = store.StoreRelationship(ctx, b, c, types.RelationLedTo, 0.8, types.ConfidenceExplicit)
+	require.NoError(t, err)
+	_, err = store.StoreRelationship(ctx, a, c, types.RelationRelatedTo, 0.3, types.ConfidenceInferred)
+	require.NoError(t, err)
+
+	query := types.NewRelationshipQuery(a)
+	query.Direction = "outgoing"
+	results, err := store.GetRelationships(ctx, query)
+	require.NoError(t, err)
+	require.Len(t, results, 1, "low confidence relationship filtered out")
+	assert.Equal(t, ab.ID, results[0].Relationship.ID)
+
+	graph, err := store.TraverseGraph(ctx, a, 3, []types.RelationType{types.RelationLedTo})
+	require.NoError(t, err)
+	assert.Len(t, graph.Nodes, 3)
+
+	require.NoError(t, store.UpdateRelationship(ctx, ab.ID, 0.95, types.ConfidenceFactors{}))
+	updated, err := store.GetRelationshipByID(ctx, ab.ID)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.95, updated.Confidence, 0.0001)
+
+	require.NoError(t, store.DeleteRelationship(ctx, ab.ID))
+	_, err = store.GetRelationshipByID(ctx, ab.ID)
+	assert.Error(t, err)
+}
+
+func TestLocalStoreDeleteRemovesRelationships(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	store := newTestLocalStore(t, dataDir)
+
+	now := time.Now()
+	a := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{1, 0, 0}, now)
+	b := newLocalTestChunk("github.com/acme/api", types.ChunkTypeSolution, []float64{0, 1, 0}, now)
+	c := newLocalTestChunk("github.com/acme/api", types.ChunkTypeSolution, []float64{0, 0, 1}, now)
+	for _, chunk := range []*types.ConversationChunk{a, b, c} {
+		require.NoError(t, store.Store(ctx, chunk))
+	}
+	ab, err := store.StoreRelationship(ctx, a.ID, b.ID, types.RelationSolvedBy, 0.9, types.ConfidenceExplicit)
+	require.NoError(t, err)
+	cb, err := store.StoreRelationship(ctx, c.ID, b.ID, types.RelationRelatedTo, 0.9, types.ConfidenceExplicit)
+	require.NoError(t, err)
+	ac, err := store.StoreRelationship(ctx, a.ID, c.ID, types.RelationRelatedTo, 0.9, types.ConfidenceExplicit)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Delete(ctx, b.ID))
+	for _, id := range []string{ab.ID, cb.ID} {
+		_, err := store.GetRelationshipByID(ctx, id)
+		assert.Error(t, err, "relationships of the deleted chunk go with it")
+	}
+	_, err = store.GetRelationshipByID(ctx, ac.ID)
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	reopened := newTestLocalStore(t, dataDir)
+	defer func() { _ = reopened.Close() }()
+	_, err = reopened.GetRelationshipByID(ctx, ab.ID)
+	assert.Error(t, err, "and their files are removed")
+	_, err = reopened.GetRelationshipByID(ctx, ac.ID)
+	assert.NoError(t, err)
+}
+
+func TestLocalStoreSearchScoresOnlyCandidates(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStore(t, t.TempDir())
+	defer func() { _ = store.Close() }()
+
+	// Opposite vectors fall on the other side of every hyperplane, so they
+	// never share an LSH bucket
+	now := time.Now()
+	near := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{1, 1, 0}, now)
+	opposite := newLocalTestChunk("github.com/acme/web", types.ChunkTypeProblem, []float64{-1, -1, 0}, now)
+	require.NoError(t, store.Store(ctx, near))
+	require.NoError(t, store.Store(ctx, opposite))
+
+	query := types.NewMemoryQuery("anything")
+	query.Limit = 10
+	query.MinRelevanceScore = -1
+	results, err := store.Search(ctx, query, []float64{1, 1, 0})
+	require.NoError(t, err)
+	assert.Equal(t, []string{near.ID}, searchResultIDs(results), "a short candidate list is not padded with a full scan")
+
+	// Without any matching candidate the search scans every chunk
+	repository := "github.com/acme/web"
+	query.Repository = &repository
+	results, err = store.Search(ctx, query, []float64{1, 1, 0})
+	require.NoError(t, err)
+	assert.Equal(t, []string{opposite.ID}, searchResultIDs(results))
+}
+
+func TestChunkMatchesQueryMirrorsQdrantFilter(t *testing.T) {
+	now := time.Now()
+	qs := NewQdrantStore(&config.QdrantConfig{})
+
+	repo := "github.com/acme/api"
+	global := globalRepository
+	query := &types.MemoryQuery{Repository: &repo, Types: []types.ChunkType{types.ChunkTypeProblem}, Recency: types.RecencyRecent}
+
+	filter := qs.buildFilter(query)
+	require.NotNil(t, filter)
+	assert.Len(t, filter.Must, 3, "repository, type and recency conditions")
+
+	chunk := newLocalTestChunk(repo, types.ChunkTypeProblem, []float64{1}, now)
+	assert.True(t, chunkMatchesQuery(chunk, query, now))
+
+	chunk.Metadata.Repository = global
+	assert.False(t, chunkMatchesQuery(chunk, query, now))
+
+	globalQuery := &types.MemoryQuery{Repository: &global, Recency: types.RecencyAllTime}
+	assert.True(t, chunkMatchesQuery(chunk, globalQuery, now))
+	assert.Nil(t, qs.buildFilter(&types.MemoryQuery{Recency: types.RecencyAllTime}))
+	assert.True(t, chunkMatchesQuery(chunk, &types.MemoryQuery{Recency: types.RecencyAllTime}, now))
+}
+
+func searchResultIDs(results *types.SearchResults) []string {
+	ids := make([]string, 0, len(results.Results))
+	for i := range results.Results {
+		ids = append(ids, results.Results[i].Chunk.ID)
+	}
+	return ids
+}
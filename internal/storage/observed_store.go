@@ -0,0 +1,392 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"lerian-mcp-memory/pkg/types"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrEmbeddingModelChanged is returned for a write whose vectors came from
+// the previous embedding model: it waited out a collection promotion, or its
+// vectors were generated before one. Retrying the write embeds it with the
+// new model.
+var ErrEmbeddingModelChanged = errors.New("embedding model changed while the write waited; retry")
+
+// WriteFence blocks chunk writes while a collection is swapped
+type WriteFence interface {
+	// FenceWrites blocks new chunk writes and waits for those in flight.
+	// release reopens writes; with promoted set, writes carrying vectors that
+	// waited on the fence fail with ErrEmbeddingModelChanged.
+	FenceWrites() (release func(promoted bool))
+}
+
+// FindWriteFence finds the write fence in a store's wrapper chain
+func FindWriteFence(store VectorStore) (WriteFence, bool) {
+	return unwrapStore[WriteFence](store)
+}
+
+// Change actions reported by ObservedStore
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// ChangeEvent describes a successful chunk or relationship write. Exactly one
+// of Chunk and Relationship is set; Chunk is also set for relationship
+// changes when the source chunk could be loaded, so listeners know its
+// repository.
+type ChangeEvent struct {
+	Action       string
+	ChunkID      string
+	Chunk        *types.ConversationChunk
+	Relationship *types.MemoryRelationship
+}
+
+// ChangeObserver is called after every successful write
+type ChangeObserver func(ctx context.Context, change ChangeEvent)
+
+// ObservedStore wraps a VectorStore and reports every chunk and relationship
+// write to an observer, such as the WebSocket hub. Without an observer it
+// only forwards calls.
+type ObservedStore struct {
+	store VectorStore
+
+	mu       sync.RWMutex
+	observer ChangeObserver
+
+	// fence is held shared by chunk writes and exclusively by FenceWrites;
+	// promotions counts fences that ended in a promotion
+	fence      sync.RWMutex
+	promotions atomic.Uint64
+
+	// staleVectors reports writes whose vectors predate the active
+	// embedding model
+	staleVectors func(ctx context.Context) bool
+}
+
+// NewObservedStore creates a store that reports writes once Observe is called
+func NewObservedStore(store VectorStore) *ObservedStore {
+	return &ObservedStore{store: store}
+}
+
+// Observe sets the observer; nil stops reporting
+func (s *ObservedStore) Observe(observer ChangeObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observer = observer
+}
+
+// CheckVectors sets the check that refuses chunk writes whose vectors were
+// generated by a model that has since been replaced. Call it before the
+// store is shared.
+func (s *ObservedStore) CheckVectors(stale func(ctx context.Context) bool) {
+	s.staleVectors = stale
+}
+
+// Unwrap returns the wrapped store
+func (s *ObservedStore) Unwrap() VectorStore {
+	return s.store
+}
+
+// FenceWrites blocks chunk writes until release is called
+func (s *ObservedStore) FenceWrites() func(promoted bool) {
+	s.fence.Lock()
+	var once sync.Once
+	return func(promoted bool) {
+		once.Do(func() {
+			if promoted {
+				s.promotions.Add(1)
+			}
+			s.fence.Unlock()
+		})
+	}
+}
+
+// beginWrite waits out a write fence and returns the function ending the
+// write. Writes carrying vectors are refused after a promotion, and when
+// their vectors were generated before one.
+func (s *ObservedStore) beginWrite(ctx context.Context, withVectors bool) (func(), error) {
+	promotions := s.promotions.Load()
+	s.fence.RLock()
+	if withVectors && (s.promotions.Load() != promotions || (s.staleVectors != nil && s.staleVectors(ctx))) {
+		s.fence.RUnlock()
+		return nil, ErrEmbeddingModelChanged
+	}
+	return s.fence.RUnlock, nil
+}
+
+func (s *ObservedStore) currentObserver() ChangeObserver {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.observer
+}
+
+func (s *ObservedStore) notifyChunk(ctx context.Context, action string, chunk *types.ConversationChunk) {
+	if observer := s.currentObserver(); observer != nil {
+		observer(ctx, ChangeEvent{Action: action, ChunkID: chunk.ID, Chunk: chunk})
+	}
+}
+
+func (s *ObservedStore) notifyRelationship(ctx context.Context, action string, relationship *types.MemoryRelationship) {
+	observer := s.currentObserver()
+	if observer == nil || relationship == nil {
+		return
+	}
+	change := ChangeEvent{Action: action, ChunkID: relationship.SourceChunkID, Relationship: relationship}
+	if source, err := s.store.GetByID(ctx, relationship.SourceChunkID); err == nil {
+		change.Chunk = source
+	}
+	observer(ctx, change)
+}
+
+// Initialize initializes the wrapped store
+func (s *ObservedStore) Initialize(ctx context.Context) error {
+	return s.store.Initialize(ctx)
+}
+
+// Store stores a chunk and reports it as created
+func (s *ObservedStore) Store(ctx context.Context, chunk *types.ConversationChunk) error {
+	end, err := s.beginWrite(ctx, true)
+	if err != nil {
+		return err
+	}
+	err = s.store.Store(ctx, chunk)
+	end()
+	if err != nil {
+		return err
+	}
+	s.notifyChunk(ctx, ChangeCreated, chunk)
+	return nil
+}
+
+// Search performs vector similarity search
+func (s *ObservedStore) Search(ctx context.Context, query *types.MemoryQuery, embeddings []float64) (*types.SearchResults, error) {
+	return s.store.Search(ctx, query, embeddings)
+}
+
+// GetByID gets a chunk by ID
+func (s *ObservedStore) GetByID(ctx context.Context, id string) (*types.ConversationChunk, error) {
+	return s.store.GetByID(ctx, id)
+}
+
+// ListByRepository lists chunks by repository
+func (s *ObservedStore) ListByRepository(ctx context.Context, repository string, limit, offset int) ([]types.ConversationChunk, error) {
+	return s.store.ListByRepository(ctx, repository, limit, offset)
+}
+
+// ListBySession lists chunks by session
+func (s *ObservedStore) ListBySession(ctx context.Context, sessionID string) ([]types.ConversationChunk, error) {
+	return s.store.ListBySession(ctx, sessionID)
+}
+
+// Delete deletes a chunk and reports it as deleted. The chunk is loaded
+// first so the report carries its repository.
+func (s *ObservedStore) Delete(ctx context.Context, id string) error {
+	var deleted *types.ConversationChunk
+	if s.currentObserver() != nil {
+		deleted, _ = s.store.GetByID(ctx, id)
+	}
+	end, err := s.beginWrite(ctx, false)
+	if err != nil {
+		return err
+	}
+	err = s.store.Delete(ctx, id)
+	end()
+	if err != nil {
+		return err
+	}
+	s.notifyDeleted(ctx, id, deleted)
+	return nil
+}
+
+func (s *ObservedStore) notifyDeleted(ctx context.Context, id string, chunk *types.ConversationChunk) {
+	if observer := s.currentObserver(); observer != nil {
+		observer(ctx, ChangeEvent{Action: ChangeDeleted, ChunkID: id, Chunk: chunk})
+	}
+}
+
+// Update updates a chunk and reports it as updated
+func (s *ObservedStore) Update(ctx context.Context, chunk *types.ConversationChunk) error {
+	end, err := s.beginWrite(ctx, true)
+	if err != nil {
+		return err
+	}
+	err = s.store.Update(ctx, chunk)
+	end()
+	if err != nil {
+		return err
+	}
+	s.notifyChunk(ctx, ChangeUpdated, chunk)
+	return nil
+}
+
+// HealthCheck checks the wrapped store
+func (s *ObservedStore) HealthCheck(ctx context.Context) error {
+	return s.store.HealthCheck(ctx)
+}
+
+// GetStats gets store statistics
+func (s *ObservedStore) GetStats(ctx context.Context) (*StoreStats, error) {
+	return s.store.GetStats(ctx)
+}
+
+// Cleanup removes old chunks
+func (s *ObservedStore) Cleanup(ctx context.Context, retentionDays int) (int, error) {
+	end, err := s.beginWrite(ctx, false)
+	if err != nil {
+		return 0, err
+	}
+	defer end()
+	return s.store.Cleanup(ctx, retentionDays)
+}
+
+// Close closes the wrapped store
+func (s *ObservedStore) Close() error {
+	return s.store.Close()
+}
+
+// GetAllChunks gets all chunks
+func (s *ObservedStore) GetAllChunks(ctx context.Context) ([]types.ConversationChunk, error) {
+	return s.store.GetAllChunks(ctx)
+}
+
+// DeleteCollection deletes a collection
+func (s *ObservedStore) DeleteCollection(ctx context.Context, collection string) error {
+	return s.store.DeleteCollection(ctx, collection)
+}
+
+// ListCollections lists all collections
+func (s *ObservedStore) ListCollections(ctx context.Context) ([]string, error) {
+	return s.store.ListCollections(ctx)
+}
+
+// FindSimilar finds similar chunks
+func (s *ObservedStore) FindSimilar(ctx context.Context, content string, chunkType *types.ChunkType, limit int) ([]types.ConversationChunk, error) {
+	return s.store.FindSimilar(ctx, content, chunkType, limit)
+}
+
+// StoreChunk stores a chunk and reports it as created
+func (s *ObservedStore) StoreChunk(ctx context.Context, chunk *types.ConversationChunk) error {
+	end, err := s.beginWrite(ctx, true)
+	if err != nil {
+		return err
+	}
+	err = s.store.StoreChunk(ctx, chunk)
+	end()
+	if err != nil {
+		return err
+	}
+	s.notifyChunk(ctx, ChangeCreated, chunk)
+	return nil
+}
+
+// BatchStore stores chunks and reports the ones that were stored
+func (s *ObservedStore) BatchStore(ctx context.Context, chunks []*types.ConversationChunk) (*BatchResult, error) {
+	end, err := s.beginWrite(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.store.BatchStore(ctx, chunks)
+	end()
+	if result == nil || s.currentObserver() == nil {
+		return result, err
+	}
+
+	stored := make(map[string]bool, len(result.ProcessedIDs))
+	for _, id := range result.ProcessedIDs {
+		stored[id] = true
+	}
+	for _, chunk := range chunks {
+		if chunk != nil && stored[chunk.ID] {
+			s.notifyChunk(ctx, ChangeCreated, chunk)
+		}
+	}
+	return result, err
+}
+
+// BatchDelete deletes chunks and reports the ones that were deleted
+func (s *ObservedStore) BatchDelete(ctx context.Context, ids []string) (*BatchResult, error) {
+	end, err := s.beginWrite(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if s.currentObserver() == nil {
+		defer end()
+		return s.store.BatchDelete(ctx, ids)
+	}
+
+	deleted := make(map[string]*types.ConversationChunk, len(ids))
+	for _, id := range ids {
+		if chunk, err := s.store.GetByID(ctx, id); err == nil {
+			deleted[id] = chunk
+		}
+	}
+
+	result, err := s.store.BatchDelete(ctx, ids)
+	end()
+	if result == nil {
+		return result, err
+	}
+	processed := result.ProcessedIDs
+	if len(processed) == 0 && err == nil && result.Failed == 0 {
+		processed = ids
+	}
+	for _, id := range processed {
+		s.notifyDeleted(ctx, id, deleted[id])
+	}
+	return result, err
+}
+
+// StoreRelationship stores a relationship and reports it as created
+func (s *ObservedStore) StoreRelationship(ctx context.Context, sourceID, targetID string, relationType types.RelationType, confidence float64, source types.ConfidenceSource) (*types.MemoryRelationship, error) {
+	relationship, err := s.store.StoreRelationship(ctx, sourceID, targetID, relationType, confidence, source)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyRelationship(ctx, ChangeCreated, relationship)
+	return relationship, nil
+}
+
+// GetRelationships gets relationships
+func (s *ObservedStore) GetRelationships(ctx context.Context, query *types.RelationshipQuery) ([]types.RelationshipResult, error) {
+	return s.store.GetRelationships(ctx, query)
+}
+
+// TraverseGraph traverses the graph
+func (s *ObservedStore) TraverseGraph(ctx context.Context, startChunkID string, maxDepth int, relationTypes []types.RelationType) (*types.GraphTraversalResult, error) {
+	return s.store.TraverseGraph(ctx, startChunkID, maxDepth, relationTypes)
+}
+
+// UpdateRelationship updates a relationship and reports it as updated
+func (s *ObservedStore) UpdateRelationship(ctx context.Context, relationshipID string, confidence float64, factors types.ConfidenceFactors) error {
+	if err := s.store.UpdateRelationship(ctx, relationshipID, confidence, factors); err != nil {
+		return err
+	}
+	if s.currentObserver() != nil {
+		if relationship, err := s.store.GetRelationshipByID(ctx, relationshipID); err == nil {
+			s.notifyRelationship(ctx, ChangeUpdated, relationship)
+		}
+	}
+	return nil
+}
+
+// DeleteRelationship deletes a relationship and reports it as deleted
+func (s *ObservedStore) DeleteRelationship(ctx context.Context, relationshipID string) error {
+	var relationship *types.MemoryRelationship
+	if s.currentObserver() != nil {
+		relationship, _ = s.store.GetRelationshipByID(ctx, relationshipID)
+	}
+	if err := s.store.DeleteRelationship(ctx, relationshipID); err != nil {
+		return err
+	}
+	s.notifyRelationship(ctx, ChangeDeleted, relationship)
+	return nil
+}
+
+// GetRelationshipByID gets a relationship by ID
+func (s *ObservedStore) GetRelationshipByID(ctx context.Context, relationshipID string) (*types.MemoryRelationship, error) {
+	return s.store.GetRelationshipByID(ctx, relationshipID)
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"lerian-mcp-memory/internal/embeddings"
+	"lerian-mcp-memory/pkg/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObservedStoreReportsWrites(t *testing.T) {
+	ctx := context.Background()
+	local := newTestLocalStore(t, t.TempDir())
+	t.Cleanup(func() { _ = local.Close() })
+	store := NewObservedStore(local)
+
+	// Writes before Observe are not reported
+	first := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{0.1, 0.2, 0.3}, time.Now())
+	require.NoError(t, store.Store(ctx, first))
+
+	var changes []ChangeEvent
+	store.Observe(func(_ context.Context, change ChangeEvent) {
+		changes = append(changes, change)
+	})
+
+	second := newLocalTestChunk("github.com/acme/api", types.ChunkTypeSolution, []float64{0.3, 0.2, 0.1}, time.Now())
+	require.NoError(t, store.Store(ctx, second))
+	second.Summary = "fixed"
+	require.NoError(t, store.Update(ctx, second))
+
+	relationship, err := store.StoreRelationship(ctx, first.ID, second.ID, types.RelationSolvedBy, 0.9, types.ConfidenceExplicit)
+	require.NoError(t, err)
+	require.NoError(t, store.Delete(ctx, first.ID))
+
+	require.Len(t, changes, 4)
+	assert.Equal(t, ChangeCreated, changes[0].Action)
+	assert.Equal(t, second.ID, changes[0].ChunkID)
+	assert.Equal(t, ChangeUpdated, changes[1].Action)
+
+	assert.Equal(t, ChangeCreated, changes[2].Action)
+	assert.Equal(t, relationship.ID, changes[2].Relationship.ID)
+	require.NotNil(t, changes[2].Chunk)
+	assert.Equal(t, "github.com/acme/api", changes[2].Chunk.Metadata.Repository)
+
+	// Deletes carry the chunk so listeners know its repository
+	assert.Equal(t, ChangeDeleted, changes[3].Action)
+	assert.Equal(t, first.ID, changes[3].ChunkID)
+	require.NotNil(t, changes[3].Chunk)
+	assert.Equal(t, "github.com/acme/api", changes[3].Chunk.Metadata.Repository)
+}
+
+func TestObservedStoreSkipsFailedWrites(t *testing.T) {
+	ctx := context.Background()
+	local := newTestLocalStore(t, t.TempDir())
+	t.Cleanup(func() { _ = local.Close() })
+	store := NewObservedStore(local)
+
+	reported := 0
+	store.Observe(func(context.Context, ChangeEvent) { reported++ })
+
+	invalid := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{0.1, 0.2, 0.3}, time.Now())
+	invalid.Content = ""
+	assert.Error(t, store.Store(ctx, invalid))
+	assert.Error(t, store.Update(ctx, invalid))
+	assert.Zero(t, reported)
+}
+
+func TestObservedStoreFencesWrites(t *testing.T) {
+	ctx := context.Background()
+	local := newTestLocalStore(t, t.TempDir())
+	t.Cleanup(func() { _ = local.Close() })
+	store := NewObservedStore(local)
+
+	existing := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, []float64{0.1, 0.2, 0.3}, time.Now())
+	require.NoError(t, store.Store(ctx, existing))
+
+	fence, ok := FindWriteFence(NewRetryableVectorStore(store, nil))
+	require.True(t, ok, "the fence is found through outer wrappers")
+
+	release := fence.FenceWrites()
+	stored, deleted := make(chan error, 1), make(chan error, 1)
+	waiting := newLocalTestChunk("github.com/acme/api", types.ChunkTypeSolution, []float64{0.3, 0.2, 0.1}, time.Now())
+	go func() { stored <- store.Store(ctx, waiting) }()
+	go func() { deleted <- store.Delete(ctx, existing.ID) }()
+
+	select {
+	case <-stored:
+		t.Fatal("store finished while writes were fenced")
+	case <-deleted:
+		t.Fatal("delete finished while writes were fenced")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release(true)
+	assert.ErrorIs(t, <-stored, ErrEmbeddingModelChanged, "its vector came from the previous model")
+	assert.NoError(t, <-deleted, "deletes carry no vectors")
+	_, err := local.GetByID(ctx, waiting.ID)
+	assert.Error(t, err)
+
+	require.NoError(t, store.Store(ctx, waiting), "writes after the fence go through")
+
+	// A fence without a promotion only delays writes
+	release = fence.FenceWrites()
+	go func() { stored <- store.Update(ctx, waiting) }()
+	release(false)
+	assert.NoError(t, <-stored)
+}
+
+func TestObservedStoreRefusesVectorsFromReplacedModel(t *testing.T) {
+	ctx := context.Background()
+	local := newTestLocalStore(t, t.TempDir())
+	t.Cleanup(func() { _ = local.Close() })
+	store := NewObservedStore(local)
+	models := embeddings.NewSwitchableEmbeddingService(embeddings.NewHashEmbeddingService(3))
+	store.CheckVectors(models.StaleVectors)
+
+	// Embedded with the old model, written after the fence was released
+	// without ever waiting on it
+	embeddedEarly := embeddings.WithGenerationTracking(ctx)
+	vector, err := models.GenerateEmbedding(embeddedEarly, "billing retries")
+	require.NoError(t, err)
+	chunk := newLocalTestChunk("github.com/acme/api", types.ChunkTypeProblem, vector, time.Now())
+
+	fence, ok := FindWriteFence(store)
+	require.True(t, ok)
+	release := fence.FenceWrites()
+	models.Swap(embeddings.NewHashEmbeddingService(3))
+	release(true)
+
+	assert.ErrorIs(t, store.Store(embeddedEarly, chunk), ErrEmbeddingModelChanged)
+	_, err = local.GetByID(ctx, chunk.ID)
+	assert.Error(t, err)
+	assert.NoError(t, store.Delete(embeddedEarly, "missing"), "writes without vectors are not checked")
+
+	embeddedLate := embeddings.WithGenerationTracking(ctx)
+	chunk.Embeddings, err = models.GenerateEmbedding(embeddedLate, "billing retries")
+	require.NoError(t, err)
+	assert.NoError(t, store.Store(embeddedLate, chunk))
+	assert.NoError(t, store.Update(ctx, chunk), "untracked writes only wait on the fence")
+}
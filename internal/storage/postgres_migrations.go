@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lerian-mcp-memory/internal/logging"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// postgresMigrationLockID serializes migrations across server instances
+	postgresMigrationLockID = 0x6d63706d656d // "mcpmem"
+
+	// maxHNSWDimensions is the largest vector pgvector builds an HNSW index on
+	maxHNSWDimensions = 2000
+)
+
+// postgresMigration is a versioned schema change. Version 1 is the baseline
+// created by scripts/init-postgres.sql, so store migrations start at 2.
+type postgresMigration struct {
+	Version     int64
+	Description string
+	Statements  func(vectorDimension int) []string
+}
+
+// postgresMigrations lists every schema change in order. Applied migrations
+// must never be edited; add a new version instead.
+var postgresMigrations = []postgresMigration{
+	{
+		Version:     2,
+		Description: "create memory chunks with pgvector embeddings",
+		Statements: func(vectorDimension int) []string {
+			return append([]string{
+				`CREATE EXTENSION IF NOT EXISTS vector`,
+				fmt.Sprintf(`CREATE TABLE IF NOT EXISTS memory_chunks (
+	id TEXT PRIMARY KEY,
+	session_id TEXT NOT NULL,
+	repository TEXT NOT NULL DEFAULT '',
+	type TEXT NOT NULL,
+	timestamp TIMESTAMPTZ NOT NULL,
+	data JSONB NOT NULL,
+	embedding vector(%d) NOT NULL
+)`, vectorDimension),
+				`CREATE INDEX IF NOT EXISTS memory_chunks_repository_timestamp_idx ON memory_chunks (repository, timestamp DESC)`,
+				`CREATE INDEX IF NOT EXISTS memory_chunks_session_idx ON memory_chunks (session_id, timestamp)`,
+				`CREATE INDEX IF NOT EXISTS memory_chunks_type_idx ON memory_chunks (type)`,
+			}, postgresEmbeddingIndex("memory_chunks", vectorDimension)...)
+		},
+	},
+	{
+		Version:     3,
+		Description: "create memory relationships",
+		Statements: func(int) []string {
+			return []string{
+				`CREATE TABLE IF NOT EXISTS memory_relationships (
+	id TEXT PRIMARY KEY,
+	source_chunk_id TEXT NOT NULL,
+	target_chunk_id TEXT NOT NULL,
+	relation_type TEXT NOT NULL,
+	confidence DOUBLE PRECISION NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	data JSONB NOT NULL
+)`,
+				`CREATE INDEX IF NOT EXISTS memory_relationships_source_idx ON memory_relationships (source_chunk_id)`,
+				`CREATE INDEX IF NOT EXISTS memory_relationships_target_idx ON memory_relationships (target_chunk_id)`,
+			}
+		},
+	},
+	{
+		Version:     4,
+		Description: "create memory threads and aliases",
+		Statements: func(int) []string {
+			return []string{
+				`CREATE TABLE IF NOT EXISTS memory_threads (
+	id TEXT PRIMARY KEY,
+	repository TEXT NOT NULL DEFAULT '',
+	type TEXT NOT NULL,
+	status TEXT NOT NULL,
+	start_time TIMESTAMPTZ NOT NULL,
+	last_update TIMESTAMPTZ NOT NULL,
+	data JSONB NOT NULL
+)`,
+				`CREATE INDEX IF NOT EXISTS memory_threads_repository_status_idx ON memory_threads (repository, status)`,
+				`CREATE TABLE IF NOT EXISTS memory_aliases (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE,
+	repository TEXT NOT NULL DEFAULT '',
+	updated_at TIMESTAMPTZ NOT NULL,
+	data JSONB NOT NULL
+)`,
+				`CREATE INDEX IF NOT EXISTS memory_aliases_repository_idx ON memory_aliases (repository)`,
+			}
+		},
+	},
+	{
+		Version:     5,
+		Description: "create memory documents for chains and other JSON records",
+		Statements: func(int) []string {
+			return []string{
+				`CREATE TABLE IF NOT EXISTS memory_documents (
+	kind TEXT NOT NULL,
+	id TEXT NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL,
+	data JSONB NOT NULL,
+	PRIMARY KEY (kind, id)
+)`,
+			}
+		},
+	},
+}
+
+// runPostgresMigrations applies pending migrations, recording each version in
+// schema_migrations. Every migration runs in its own transaction together with
+// its schema_migrations row, so a failure leaves the previous version intact.
+func runPostgresMigrations(ctx context.Context, pool *pgxpool.Pool, vectorDimension int) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection for migrations: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, postgresMigrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, postgresMigrationLockID); err != nil {
+			logging.Warn("Failed to release migration lock", "error", err)
+		}
+	}()
+
+	// Same definition as scripts/init-postgres.sql for databases created without it
+	if _, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version BIGINT PRIMARY KEY,
+	dirty BOOLEAN NOT NULL DEFAULT FALSE
+)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var dirtyVersion int64
+	err = conn.QueryRow(ctx, `SELECT version FROM schema_migrations WHERE dirty ORDER BY version LIMIT 1`).Scan(&dirtyVersion)
+	switch {
+	case err == nil:
+		return fmt.Errorf("database schema is dirty at version %d; fix it manually before starting", dirtyVersion)
+	case !errors.Is(err, pgx.ErrNoRows):
+		return fmt.Errorf("failed to check schema_migrations: %w", err)
+	}
+
+	var current int64
+	if err := conn.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	if vectorDimension > maxHNSWDimensions {
+		logging.Warn("Vector dimension is above the HNSW limit; similarity searches scan every chunk",
+			"dimension", vectorDimension,
+			"limit", maxHNSWDimensions,
+		)
+	}
+
+	for _, migration := range pendingPostgresMigrations(current) {
+		if err := applyPostgresMigration(ctx, conn.Conn(), migration, vectorDimension); err != nil {
+			return err
+		}
+		logging.Info("Applied postgres migration",
+			"version", migration.Version,
+			"description", migration.Description,
+		)
+	}
+
+	return nil
+}
+
+// postgresEmbeddingIndex returns the HNSW index for a chunks table, or
+// nothing when the vectors are too wide for one. The statement is the same
+// as before the limit was checked, so migration 2 is unchanged wherever it
+// could already have been applied.
+func postgresEmbeddingIndex(table string, dimension int) []string {
+	if dimension > maxHNSWDimensions {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %[1]s_embedding_idx ON %[1]s USING hnsw (embedding vector_cosine_ops)`, table),
+	}
+}
+
+// pendingPostgresMigrations returns the migrations newer than the current version
+func pendingPostgresMigrations(current int64) []postgresMigration {
+	pending := make([]postgresMigration, 0, len(postgresMigrations))
+	for _, migration := range postgresMigrations {
+		if migration.Version > current {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+// applyPostgresMigration runs one migration and records its version atomically
+func applyPostgresMigration(ctx context.Context, conn *pgx.Conn, migration postgresMigration, vectorDimension int) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", migration.Version, err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, statement := range migration.Statements(vectorDimension) {
+		if _, err := tx.Exec(ctx, statement); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, FALSE)`, migration.Version); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", migration.Version, err)
+	}
+	return nil
+}
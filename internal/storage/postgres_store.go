@@ -0,0 +1,1127 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/logging"
+	"lerian-mcp-memory/internal/metrics"
+	"lerian-mcp-memory/pkg/types"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	postgresChunksTable        = "memory_chunks"
+	postgresDefaultSearchLimit = 10
+)
+
+// PostgresStore implements VectorStore on PostgreSQL with the pgvector
+// extension. Chunks, relationships, threads and aliases live in regular
+// tables whose schema is managed by versioned migrations.
+type PostgresStore struct {
+	config      *config.PostgresStorageConfig
+	pool        *pgxpool.Pool
+	metrics     *StorageMetrics
+	metricsMu   sync.Mutex
+	chunksTable string
+	sharedPool  bool // shadow stores borrow the pool and must not close it
+}
+
+// NewPostgresStore creates a new PostgreSQL vector store
+func NewPostgresStore(cfg *config.PostgresStorageConfig) *PostgresStore {
+	return &PostgresStore{
+		config:      cfg,
+		chunksTable: postgresChunksTable,
+		metrics: &StorageMetrics{
+			OperationCounts:  make(map[string]int64),
+			AverageLatency:   make(map[string]float64),
+			ErrorCounts:      make(map[string]int64),
+			ConnectionStatus: "unknown",
+		},
+	}
+}
+
+// Initialize connects to PostgreSQL and applies pending schema migrations
+func (ps *PostgresStore) Initialize(ctx context.Context) error {
+	start := time.Now()
+	defer ps.updateMetrics("initialize", start)
+
+	poolConfig, err := pgxpool.ParseConfig(postgresConnString(ps.config))
+	if err != nil {
+		ps.setConnectionStatus(connectionStatusError)
+		return fmt.Errorf("invalid postgres configuration: %w", err)
+	}
+	if ps.config.MaxConnections > 0 && ps.config.MaxConnections <= int(^uint32(0)>>1) {
+		poolConfig.MaxConns = int32(ps.config.MaxConnections) // #nosec G115 -- bounds checked above
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		ps.setConnectionStatus(connectionStatusError)
+		return fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		ps.setConnectionStatus(connectionStatusError)
+		return fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	if err := runPostgresMigrations(ctx, pool, ps.config.VectorDimension); err != nil {
+		pool.Close()
+		ps.setConnectionStatus(connectionStatusError)
+		return err
+	}
+
+	ps.pool = pool
+	ps.setConnectionStatus("connected")
+	logging.Info("Postgres vector store initialized",
+		"host", ps.config.Host,
+		"database", ps.config.Database,
+		"vector_dimension", ps.config.VectorDimension,
+	)
+	return nil
+}
+
+// Store saves a conversation chunk, replacing any chunk with the same ID
+func (ps *PostgresStore) Store(ctx context.Context, chunk *types.ConversationChunk) error {
+	start := time.Now()
+	defer ps.updateMetrics("store", start)
+
+	if err := chunk.Validate(); err != nil {
+		return fmt.Errorf("invalid chunk: %w", err)
+	}
+
+	if len(chunk.Embeddings) == 0 {
+		return errors.New("chunk must have embeddings before storing")
+	}
+
+	if err := ps.checkReady(); err != nil {
+		return err
+	}
+
+	if err := ps.storeChunk(ctx, ps.pool, chunk); err != nil {
+		return err
+	}
+
+	logging.Debug("Stored chunk in postgres",
+		"id", chunk.ID,
+		"repository", chunk.Metadata.Repository,
+		"type", chunk.Type,
+	)
+	return nil
+}
+
+// postgresExecer is satisfied by both the pool and transactions
+type postgresExecer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// storeChunk upserts a validated chunk using the given pool or transaction
+func (ps *PostgresStore) storeChunk(ctx context.Context, db postgresExecer, chunk *types.ConversationChunk) error {
+	if ps.config.VectorDimension > 0 && len(chunk.Embeddings) != ps.config.VectorDimension {
+		return fmt.Errorf("failed to store chunk in postgres: vector dimension mismatch: collection uses %d, got %d", ps.config.VectorDimension, len(chunk.Embeddings))
+	}
+
+	data, err := marshalChunkData(chunk)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(ctx, `INSERT INTO `+ps.chunksTable+` (id, session_id, repository, type, timestamp, data, embedding)
+VALUES ($1, $2, $3, $4, $5, $6, $7::vector)
+ON CONFLICT (id) DO UPDATE SET
+	session_id = EXCLUDED.session_id,
+	repository = EXCLUDED.repository,
+	type = EXCLUDED.type,
+	timestamp = EXCLUDED.timestamp,
+	data = EXCLUDED.data,
+	embedding = EXCLUDED.embedding`,
+		chunk.ID,
+		chunk.SessionID,
+		chunk.Metadata.Repository,
+		string(chunk.Type),
+		chunk.Timestamp,
+		data,
+		formatPGVector(chunk.Embeddings),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store chunk in postgres: %w", err)
+	}
+	return nil
+}
+
+// Search performs cosine similarity search with pgvector
+func (ps *PostgresStore) Search(ctx context.Context, query *types.MemoryQuery, embeddings []float64) (*types.SearchResults, error) {
+	start := time.Now()
+	defer ps.updateMetrics("search", start)
+
+	if len(embeddings) == 0 {
+		return nil, errors.New("embeddings cannot be empty")
+	}
+
+	if err := ps.checkReady(); err != nil {
+		return nil, err
+	}
+
+	// Qdrant falls back to its default page size when no positive limit is given
+	limit := query.Limit
+	if limit <= 0 {
+		limit = postgresDefaultSearchLimit
+	}
+
+	args := []interface{}{formatPGVector(embeddings), query.MinRelevanceScore, limit}
+	where, args := buildPostgresFilter(query, time.Now(), args)
+
+	rows, err := ps.pool.Query(ctx, `SELECT data, embedding::text, 1 - (embedding <=> $1::vector) AS score
+FROM `+ps.chunksTable+`
+WHERE `+where+` AND 1 - (embedding <=> $1::vector) >= $2
+ORDER BY embedding <=> $1::vector
+LIMIT $3`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]types.SearchResult, 0, limit)
+	for rows.Next() {
+		var data []byte
+		var vector string
+		var score float64
+		if err := rows.Scan(&data, &vector, &score); err != nil {
+			return nil, fmt.Errorf("failed to read search result: %w", err)
+		}
+		chunk, err := unmarshalChunkData(data, vector)
+		if err != nil {
+			logging.Warn("Failed to decode chunk from postgres", "error", err)
+			continue
+		}
+		results = append(results, types.SearchResult{Chunk: *chunk, Score: score})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	logging.Debug("Search completed",
+		"query", query.Query,
+		"results", len(results),
+		"total_time_ms", time.Since(start).Milliseconds(),
+	)
+
+	return &types.SearchResults{
+		Results:   results,
+		Total:     len(results),
+		QueryTime: time.Since(start),
+	}, nil
+}
+
+// buildPostgresFilter translates a MemoryQuery into a WHERE clause with the
+// same semantics as QdrantStore.buildFilter. Placeholders continue after the
+// given arguments, and the extended argument list is returned.
+func buildPostgresFilter(query *types.MemoryQuery, now time.Time, args []interface{}) (string, []interface{}) {
+	conditions := make([]string, 0, 3)
+
+	// Repository filter. An exact match also gives the global repository its
+	// Qdrant semantics: only chunks stored under "global" match.
+	if query.Repository != nil && *query.Repository != "" {
+		args = append(args, *query.Repository)
+		conditions = append(conditions, fmt.Sprintf("repository = $%d", len(args)))
+	}
+
+	// Type filter
+	if len(query.Types) > 0 {
+		chunkTypes := make([]string, len(query.Types))
+		for i, t := range query.Types {
+			chunkTypes[i] = string(t)
+		}
+		args = append(args, chunkTypes)
+		conditions = append(conditions, fmt.Sprintf("type = ANY($%d)", len(args)))
+	}
+
+	// Recency filter, truncated to seconds like the Qdrant timestamp payload
+	if cutoff := recencyCutoff(query.Recency, now); !cutoff.IsZero() {
+		args = append(args, time.Unix(cutoff.Unix(), 0))
+		conditions = append(conditions, fmt.Sprintf("timestamp >= $%d", len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "TRUE", args
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// GetByID retrieves a chunk by its ID
+func (ps *PostgresStore) GetByID(ctx context.Context, id string) (*types.ConversationChunk, error) {
+	start := time.Now()
+	defer ps.updateMetrics("get_by_id", start)
+
+	if err := ps.checkReady(); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	var vector string
+	err := ps.pool.QueryRow(ctx, `SELECT data, embedding::text FROM `+ps.chunksTable+` WHERE id = $1`, id).Scan(&data, &vector)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("chunk not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chunk: %w", err)
+	}
+
+	return unmarshalChunkData(data, vector)
+}
+
+// ListByRepository lists chunks by repository, newest first
+func (ps *PostgresStore) ListByRepository(ctx context.Context, repository string, limit, offset int) ([]types.ConversationChunk, error) {
+	start := time.Now()
+	defer ps.updateMetrics("list_by_repository", start)
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	// A negative limit returns every remaining chunk
+	var pageLimit interface{}
+	if limit >= 0 {
+		pageLimit = limit
+	}
+
+	return ps.queryChunks(ctx, `SELECT data, embedding::text FROM `+ps.chunksTable+`
+WHERE repository = $1
+ORDER BY timestamp DESC
+LIMIT $2 OFFSET $3`, repository, pageLimit, offset)
+}
+
+// ListBySession lists chunks by session ID, oldest first
+func (ps *PostgresStore) ListBySession(ctx context.Context, sessionID string) ([]types.ConversationChunk, error) {
+	start := time.Now()
+	defer ps.updateMetrics("list_by_session", start)
+
+	return ps.queryChunks(ctx, `SELECT data, embedding::text FROM `+ps.chunksTable+`
+WHERE session_id = $1
+ORDER BY timestamp ASC`, sessionID)
+}
+
+// queryChunks runs a query returning (data, embedding) rows and decodes them
+func (ps *PostgresStore) queryChunks(ctx context.Context, sql string, args ...interface{}) ([]types.ConversationChunk, error) {
+	if err := ps.checkReady(); err != nil {
+		return nil, err
+	}
+
+	rows, err := ps.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chunks: %w", err)
+	}
+	defer rows.Close()
+
+	chunks := make([]types.ConversationChunk, 0)
+	for rows.Next() {
+		var data []byte
+		var vector string
+		if err := rows.Scan(&data, &vector); err != nil {
+			return nil, fmt.Errorf("failed to read chunk: %w", err)
+		}
+		chunk, err := unmarshalChunkData(data, vector)
+		if err != nil {
+			logging.Warn("Failed to decode chunk from postgres", "error", err)
+			continue
+		}
+		chunks = append(chunks, *chunk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list chunks: %w", err)
+	}
+
+	return chunks, nil
+}
+
+// Delete removes a chunk by ID. Deleting a missing chunk is not an error,
+// matching Qdrant semantics.
+func (ps *PostgresStore) Delete(ctx context.Context, id string) error {
+	start := time.Now()
+	defer ps.updateMetrics("delete", start)
+
+	if err := ps.checkReady(); err != nil {
+		return err
+	}
+
+	if _, err := ps.pool.Exec(ctx, `DELETE FROM `+ps.chunksTable+` WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete chunk from postgres: %w", err)
+	}
+
+	logging.Debug("Deleted chunk from postgres", "id", id)
+	return nil
+}
+
+// Update modifies an existing chunk
+func (ps *PostgresStore) Update(ctx context.Context, chunk *types.ConversationChunk) error {
+	start := time.Now()
+	defer ps.updateMetrics("update", start)
+
+	if err := chunk.Validate(); err != nil {
+		return fmt.Errorf("invalid chunk: %w", err)
+	}
+
+	// Like Qdrant, update is an upsert
+	return ps.Store(ctx, chunk)
+}
+
+// HealthCheck verifies the database connection
+func (ps *PostgresStore) HealthCheck(ctx context.Context) error {
+	start := time.Now()
+	defer ps.updateMetrics("health_check", start)
+
+	if err := ps.checkReady(); err != nil {
+		ps.setConnectionStatus(connectionStatusError)
+		return fmt.Errorf("postgres health check failed: %w", err)
+	}
+
+	if err := ps.pool.Ping(ctx); err != nil {
+		ps.setConnectionStatus(connectionStatusError)
+		return fmt.Errorf("postgres health check failed: %w", err)
+	}
+
+	ps.setConnectionStatus("healthy")
+	return nil
+}
+
+// VectorDimension returns the dimension of the embedding column as created by
+// the migrations. pgvector stores it as the column type modifier.
+func (ps *PostgresStore) VectorDimension(ctx context.Context) (int, error) {
+	if err := ps.checkReady(); err != nil {
+		return 0, err
+	}
+
+	var typmod int
+	err := ps.pool.QueryRow(ctx,
+		`SELECT atttypmod FROM pg_attribute WHERE attrelid = '`+ps.chunksTable+`'::regclass AND attname = 'embedding'`,
+	).Scan(&typmod)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read embedding column dimension: %w", err)
+	}
+	if typmod <= 0 {
+		return 0, nil
+	}
+	return typmod, nil
+}
+
+// GetStats returns statistics about the store
+func (ps *PostgresStore) GetStats(ctx context.Context) (*StoreStats, error) {
+	start := time.Now()
+	defer ps.updateMetrics("get_stats", start)
+
+	if err := ps.checkReady(); err != nil {
+		return nil, err
+	}
+
+	stats := &StoreStats{
+		ChunksByType: make(map[string]int64),
+		ChunksByRepo: make(map[string]int64),
+	}
+
+	var oldest, newest *time.Time
+	var averageEmbedding *float64
+	err := ps.pool.QueryRow(ctx, `SELECT COUNT(*), MIN(timestamp), MAX(timestamp), AVG(vector_dims(embedding))::float8,
+	pg_total_relation_size('`+ps.chunksTable+`')
+FROM `+ps.chunksTable+``).Scan(&stats.TotalChunks, &oldest, &newest, &averageEmbedding, &stats.StorageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection info: %w", err)
+	}
+
+	if oldest != nil {
+		oldestStr := oldest.Format(time.RFC3339)
+		stats.OldestChunk = &oldestStr
+	}
+	if newest != nil {
+		newestStr := newest.Format(time.RFC3339)
+		stats.NewestChunk = &newestStr
+	}
+	if averageEmbedding != nil {
+		stats.AverageEmbedding = *averageEmbedding
+	}
+
+	if err := ps.countChunksBy(ctx, "type", stats.ChunksByType); err != nil {
+		return nil, err
+	}
+	if err := ps.countChunksBy(ctx, "repository", stats.ChunksByRepo); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// countChunksBy fills counts grouped by a chunk column
+func (ps *PostgresStore) countChunksBy(ctx context.Context, column string, counts map[string]int64) error {
+	rows, err := ps.pool.Query(ctx, `SELECT `+pgx.Identifier{column}.Sanitize()+`, COUNT(*) FROM `+ps.chunksTable+` GROUP BY 1`)
+	if err != nil {
+		return fmt.Errorf("failed to count chunks by %s: %w", column, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var count int64
+		if err := rows.Scan(&key, &count); err != nil {
+			return fmt.Errorf("failed to count chunks by %s: %w", column, err)
+		}
+		counts[key] = count
+	}
+	return rows.Err()
+}
+
+// Cleanup removes chunks older than the retention period
+func (ps *PostgresStore) Cleanup(ctx context.Context, retentionDays int) (int, error) {
+	start := time.Now()
+	defer ps.updateMetrics("cleanup", start)
+
+	if err := ps.checkReady(); err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Unix(time.Now().AddDate(0, 0, -retentionDays).Unix(), 0)
+	tag, err := ps.pool.Exec(ctx, `DELETE FROM `+ps.chunksTable+` WHERE timestamp < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to cleanup old chunks: %w", err)
+	}
+
+	deleted := int(tag.RowsAffected())
+	logging.Info("Cleaned up old chunks",
+		"deleted_count", deleted,
+		"retention_days", retentionDays,
+	)
+
+	return deleted, nil
+}
+
+// Close releases the connection pool
+func (ps *PostgresStore) Close() error {
+	if ps.pool != nil && !ps.sharedPool {
+		ps.pool.Close()
+		ps.pool = nil
+	}
+	ps.setConnectionStatus("closed")
+	return nil
+}
+
+// GetAllChunks retrieves all chunks from the store
+func (ps *PostgresStore) GetAllChunks(ctx context.Context) ([]types.ConversationChunk, error) {
+	start := time.Now()
+	defer ps.updateMetrics("get_all_chunks", start)
+
+	chunks, err := ps.queryChunks(ctx, `SELECT data, embedding::text FROM `+ps.chunksTable+` ORDER BY timestamp ASC`)
+	if err != nil {
+		return nil, err
+	}
+
+	logging.Debug("Retrieved all chunks", "count", len(chunks))
+	return chunks, nil
+}
+
+// DeleteCollection removes every chunk and relationship. PostgreSQL keeps a
+// single collection, the memory_chunks table; shadow stores only clear their
+// own table.
+func (ps *PostgresStore) DeleteCollection(ctx context.Context, collection string) error {
+	start := time.Now()
+	defer ps.updateMetrics("delete_collection", start)
+
+	if collection != "" && collection != ps.chunksTable {
+		return fmt.Errorf("collection not found: %s", collection)
+	}
+
+	if err := ps.checkReady(); err != nil {
+		return err
+	}
+
+	tables := ps.chunksTable
+	if !ps.sharedPool {
+		tables += ", memory_relationships"
+	}
+	if _, err := ps.pool.Exec(ctx, `TRUNCATE `+tables); err != nil {
+		return fmt.Errorf("failed to delete collection %s: %w", ps.chunksTable, err)
+	}
+
+	logging.Info("Deleted collection", "collection", ps.chunksTable)
+	return nil
+}
+
+// ListCollections lists the available collections
+func (ps *PostgresStore) ListCollections(ctx context.Context) ([]string, error) {
+	start := time.Now()
+	defer ps.updateMetrics("list_collections", start)
+
+	return []string{ps.chunksTable}, nil
+}
+
+// FindSimilar finds similar chunks based on content using embeddings
+func (ps *PostgresStore) FindSimilar(ctx context.Context, content string, chunkType *types.ChunkType, limit int) ([]types.ConversationChunk, error) {
+	start := time.Now()
+	defer ps.updateMetrics("find_similar", start)
+
+	// Same contract as QdrantStore: callers must embed the content and use Search
+	return nil, errors.New("FindSimilar requires embedding service integration - use Search method with embeddings instead")
+}
+
+// StoreChunk is an alias for Store for backward compatibility
+func (ps *PostgresStore) StoreChunk(ctx context.Context, chunk *types.ConversationChunk) error {
+	return ps.Store(ctx, chunk)
+}
+
+// BatchStore stores multiple chunks in a single transaction. Invalid chunks
+// are reported and skipped; the valid ones are committed together.
+func (ps *PostgresStore) BatchStore(ctx context.Context, chunks []*types.ConversationChunk) (*BatchResult, error) {
+	start := time.Now()
+	defer ps.updateMetrics("batch_store", start)
+
+	if len(chunks) == 0 {
+		return &BatchResult{Success: 0, Failed: 0}, nil
+	}
+
+	if err := ps.checkReady(); err != nil {
+		return nil, err
+	}
+
+	tx, err := ps.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to batch store chunks: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	processedIDs := make([]string, 0, len(chunks))
+	errorMessages := make([]string, 0)
+
+	for i := range chunks {
+		chunk := chunks[i]
+		if err := chunk.Validate(); err != nil {
+			errorMessages = append(errorMessages, fmt.Sprintf("invalid chunk %s: %v", chunk.ID, err))
+			continue
+		}
+
+		if len(chunk.Embeddings) == 0 {
+			errorMessages = append(errorMessages, "chunk "+chunk.ID+" has no embeddings")
+			continue
+		}
+
+		// A savepoint keeps one failing row from aborting the whole batch
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to batch store chunks: %w", err)
+		}
+		if err := ps.storeChunk(ctx, savepoint, chunk); err != nil {
+			_ = savepoint.Rollback(ctx)
+			errorMessages = append(errorMessages, fmt.Sprintf("chunk %s: %v", chunk.ID, err))
+			continue
+		}
+		if err := savepoint.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("failed to batch store chunks: %w", err)
+		}
+		processedIDs = append(processedIDs, chunk.ID)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to batch store chunks: %w", err)
+	}
+
+	result := &BatchResult{
+		Success:      len(processedIDs),
+		Failed:       len(chunks) - len(processedIDs),
+		Errors:       errorMessages,
+		ProcessedIDs: processedIDs,
+	}
+
+	logging.Debug("Batch store completed",
+		"success", result.Success,
+		"failed", result.Failed,
+		"total", len(chunks),
+	)
+
+	return result, nil
+}
+
+// BatchDelete deletes multiple chunks by their IDs
+func (ps *PostgresStore) BatchDelete(ctx context.Context, ids []string) (*BatchResult, error) {
+	start := time.Now()
+	defer ps.updateMetrics("batch_delete", start)
+
+	if len(ids) == 0 {
+		return &BatchResult{Success: 0, Failed: 0}, nil
+	}
+
+	if err := ps.checkReady(); err != nil {
+		return nil, err
+	}
+
+	if _, err := ps.pool.Exec(ctx, `DELETE FROM `+ps.chunksTable+` WHERE id = ANY($1)`, ids); err != nil {
+		return &BatchResult{
+			Success: 0,
+			Failed:  len(ids),
+			Errors:  []string{err.Error()},
+		}, fmt.Errorf("failed to batch delete chunks: %w", err)
+	}
+
+	logging.Debug("Batch delete completed", "total", len(ids))
+
+	return &BatchResult{
+		Success:      len(ids),
+		Failed:       0,
+		ProcessedIDs: ids,
+	}, nil
+}
+
+// Relationship management methods
+
+// StoreRelationship creates and stores a new memory relationship
+func (ps *PostgresStore) StoreRelationship(ctx context.Context, sourceID, targetID string, relationType types.RelationType, confidence float64, source types.ConfidenceSource) (*types.MemoryRelationship, error) {
+	start := time.Now()
+	defer ps.updateMetrics("store_relationship", start)
+
+	relationship, err := types.NewMemoryRelationship(sourceID, targetID, relationType, confidence, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create relationship: %w", err)
+	}
+
+	if err := ps.storeRelationship(ctx, relationship); err != nil {
+		return nil, err
+	}
+
+	// Store inverse relationship if symmetric
+	if relationType.IsSymmetric() {
+		inverseRelationship, err := types.NewMemoryRelationship(targetID, sourceID, relationType, confidence, source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create inverse relationship: %w", err)
+		}
+		if err := ps.storeRelationship(ctx, inverseRelationship); err != nil {
+			return nil, fmt.Errorf("failed to store inverse relationship: %w", err)
+		}
+	}
+
+	return relationship, nil
+}
+
+// storeRelationship upserts a relationship row
+func (ps *PostgresStore) storeRelationship(ctx context.Context, relationship *types.MemoryRelationship) error {
+	if err := relationship.Validate(); err != nil {
+		return fmt.Errorf("invalid relationship: %w", err)
+	}
+	if err := ps.checkReady(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(relationship)
+	if err != nil {
+		return fmt.Errorf("failed to marshal relationship: %w", err)
+	}
+
+	_, err = ps.pool.Exec(ctx, `INSERT INTO memory_relationships (id, source_chunk_id, target_chunk_id, relation_type, confidence, created_at, data)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (id) DO UPDATE SET
+	source_chunk_id = EXCLUDED.source_chunk_id,
+	target_chunk_id = EXCLUDED.target_chunk_id,
+	relation_type = EXCLUDED.relation_type,
+	confidence = EXCLUDED.confidence,
+	created_at = EXCLUDED.created_at,
+	data = EXCLUDED.data`,
+		relationship.ID,
+		relationship.SourceChunkID,
+		relationship.TargetChunkID,
+		string(relationship.RelationType),
+		relationship.Confidence,
+		relationship.CreatedAt,
+		data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store relationship in postgres: %w", err)
+	}
+	return nil
+}
+
+// GetRelationships finds relationships for a chunk
+func (ps *PostgresStore) GetRelationships(ctx context.Context, query *types.RelationshipQuery) ([]types.RelationshipResult, error) {
+	start := time.Now()
+	defer ps.updateMetrics("get_relationships", start)
+
+	if err := query.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+
+	if err := ps.checkReady(); err != nil {
+		return nil, err
+	}
+
+	where, args := buildPostgresRelationshipFilter(query)
+	rows, err := ps.pool.Query(ctx, `SELECT data FROM memory_relationships WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get relationships: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]types.RelationshipResult, 0)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read relationship: %w", err)
+		}
+		var relationship types.MemoryRelationship
+		if err := json.Unmarshal(data, &relationship); err != nil {
+			logging.Warn("Failed to decode relationship from postgres", "error", err)
+			continue
+		}
+		results = append(results, types.RelationshipResult{Relationship: relationship})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get relationships: %w", err)
+	}
+
+	// Sorting in Go keeps tie-breaking identical to the other backends
+	sortRelationships(results, query.SortBy, query.SortOrder)
+
+	if query.Limit > 0 && len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+
+	return results, nil
+}
+
+// buildPostgresRelationshipFilter translates a RelationshipQuery into a WHERE
+// clause with the same semantics as relationshipMatchesQuery
+func buildPostgresRelationshipFilter(query *types.RelationshipQuery) (string, []interface{}) {
+	args := []interface{}{query.ChunkID}
+	conditions := make([]string, 0, 3)
+
+	switch query.Direction {
+	case "outgoing":
+		conditions = append(conditions, "source_chunk_id = $1")
+	case "incoming":
+		conditions = append(conditions, "target_chunk_id = $1")
+	case "both":
+		conditions = append(conditions, "(source_chunk_id = $1 OR target_chunk_id = $1)")
+	}
+
+	args = append(args, query.MinConfidence)
+	conditions = append(conditions, fmt.Sprintf("confidence >= $%d", len(args)))
+
+	if len(query.RelationTypes) > 0 {
+		relationTypes := make([]string, len(query.RelationTypes))
+		for i, rt := range query.RelationTypes {
+			relationTypes[i] = string(rt)
+		}
+		args = append(args, relationTypes)
+		conditions = append(conditions, fmt.Sprintf("relation_type = ANY($%d)", len(args)))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// TraverseGraph traverses the knowledge graph starting from a chunk
+func (ps *PostgresStore) TraverseGraph(ctx context.Context, startChunkID string, maxDepth int, relationTypes []types.RelationType) (*types.GraphTraversalResult, error) {
+	start := time.Now()
+	defer ps.updateMetrics("traverse_graph", start)
+
+	return traverseRelationshipGraph(ctx, startChunkID, maxDepth, relationTypes, ps.GetRelationships), nil
+}
+
+// UpdateRelationship updates an existing relationship's confidence
+func (ps *PostgresStore) UpdateRelationship(ctx context.Context, relationshipID string, confidence float64, factors types.ConfidenceFactors) error {
+	start := time.Now()
+	defer ps.updateMetrics("update_relationship", start)
+
+	relationship, err := ps.GetRelationshipByID(ctx, relationshipID)
+	if err != nil {
+		return err
+	}
+
+	if err := relationship.UpdateConfidence(confidence, factors); err != nil {
+		return fmt.Errorf("failed to update confidence: %w", err)
+	}
+
+	return ps.storeRelationship(ctx, relationship)
+}
+
+// DeleteRelationship deletes a relationship by ID
+func (ps *PostgresStore) DeleteRelationship(ctx context.Context, relationshipID string) error {
+	start := time.Now()
+	defer ps.updateMetrics("delete_relationship", start)
+
+	if err := ps.checkReady(); err != nil {
+		return err
+	}
+
+	if _, err := ps.pool.Exec(ctx, `DELETE FROM memory_relationships WHERE id = $1`, relationshipID); err != nil {
+		return fmt.Errorf("failed to delete relationship: %w", err)
+	}
+	return nil
+}
+
+// GetRelationshipByID retrieves a specific relationship
+func (ps *PostgresStore) GetRelationshipByID(ctx context.Context, relationshipID string) (*types.MemoryRelationship, error) {
+	start := time.Now()
+	defer ps.updateMetrics("get_relationship_by_id", start)
+
+	if err := ps.checkReady(); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	err := ps.pool.QueryRow(ctx, `SELECT data FROM memory_relationships WHERE id = $1`, relationshipID).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("relationship not found: %s", relationshipID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get relationship: %w", err)
+	}
+
+	var relationship types.MemoryRelationship
+	if err := json.Unmarshal(data, &relationship); err != nil {
+		return nil, fmt.Errorf("failed to decode relationship: %w", err)
+	}
+	return &relationship, nil
+}
+
+// Helper methods
+
+func (ps *PostgresStore) checkReady() error {
+	if ps.pool == nil {
+		return errors.New("postgres store is not initialized")
+	}
+	return nil
+}
+
+// updateMetrics updates operation metrics
+func (ps *PostgresStore) updateMetrics(operation string, start time.Time) {
+	duration := time.Since(start)
+	metrics.StorageDuration.Observe(duration.Seconds(), config.StorageProviderPostgres, operation)
+
+	ps.metricsMu.Lock()
+	defer ps.metricsMu.Unlock()
+
+	ps.metrics.OperationCounts[operation]++
+
+	// Update average latency
+	currentAvg := ps.metrics.AverageLatency[operation]
+	count := float64(ps.metrics.OperationCounts[operation])
+	newLatency := float64(duration.Milliseconds())
+	ps.metrics.AverageLatency[operation] = (currentAvg*(count-1) + newLatency) / count
+
+	ps.metrics.LastOperation = &operation
+}
+
+// setConnectionStatus records the current connection status
+func (ps *PostgresStore) setConnectionStatus(status string) {
+	ps.metricsMu.Lock()
+	defer ps.metricsMu.Unlock()
+	ps.metrics.ConnectionStatus = status
+}
+
+// postgresConnString builds a connection string from the configuration
+func postgresConnString(cfg *config.PostgresStorageConfig) string {
+	if cfg.DSN != "" {
+		return cfg.DSN
+	}
+
+	u := url.URL{
+		Scheme: "postgres",
+		Host:   net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:   "/" + cfg.Database,
+	}
+	if cfg.User != "" {
+		if cfg.Password != "" {
+			u.User = url.UserPassword(cfg.User, cfg.Password)
+		} else {
+			u.User = url.User(cfg.User)
+		}
+	}
+	if cfg.SSLMode != "" {
+		u.RawQuery = url.Values{"sslmode": []string{cfg.SSLMode}}.Encode()
+	}
+	return u.String()
+}
+
+// marshalChunkData encodes a chunk without its embeddings, which are kept in
+// the vector column
+func marshalChunkData(chunk *types.ConversationChunk) ([]byte, error) {
+	stored := *chunk
+	stored.Embeddings = nil
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal chunk: %w", err)
+	}
+	return data, nil
+}
+
+// unmarshalChunkData decodes a chunk row and restores its embeddings
+func unmarshalChunkData(data []byte, vector string) (*types.ConversationChunk, error) {
+	var chunk types.ConversationChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return nil, fmt.Errorf("failed to decode chunk: %w", err)
+	}
+	embeddings, err := parsePGVector(vector)
+	if err != nil {
+		return nil, err
+	}
+	chunk.Embeddings = embeddings
+	return &chunk, nil
+}
+
+// formatPGVector encodes embeddings in pgvector's text format, e.g. [1,2.5,3]
+func formatPGVector(values []float64) string {
+	var sb strings.Builder
+	sb.Grow(len(values) * 10)
+	sb.WriteByte('[')
+	for i, v := range values {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(v, 'g', -1, 32))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// parsePGVector decodes pgvector's text format
+func parsePGVector(text string) ([]float64, error) {
+	text = strings.TrimSpace(text)
+	if len(text) < 2 || text[0] != '[' || text[len(text)-1] != ']' {
+		return nil, fmt.Errorf("invalid vector literal: %q", text)
+	}
+	body := text[1 : len(text)-1]
+	if body == "" {
+		return []float64{}, nil
+	}
+
+	parts := strings.Split(body, ",")
+	values := make([]float64, len(parts))
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector component %q: %w", part, err)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// ActiveCollection returns the table serving reads and writes
+func (ps *PostgresStore) ActiveCollection() string {
+	return ps.chunksTable
+}
+
+// OpenShadowCollection creates a chunks table for vectors of the given
+// dimension and returns a store bound to it that shares this store's pool
+func (ps *PostgresStore) OpenShadowCollection(ctx context.Context, name string, dimension int) (VectorStore, error) {
+	if err := ps.checkReady(); err != nil {
+		return nil, err
+	}
+	if err := validateShadowCollectionName(name); err != nil {
+		return nil, err
+	}
+	if name == ps.chunksTable {
+		return nil, fmt.Errorf("shadow collection %s is the active collection", name)
+	}
+	if dimension <= 0 {
+		return nil, errors.New("shadow collection dimension must be positive")
+	}
+
+	for _, statement := range postgresShadowTableStatements(name, dimension) {
+		if _, err := ps.pool.Exec(ctx, statement); err != nil {
+			return nil, fmt.Errorf("failed to create shadow collection %s: %w", name, err)
+		}
+	}
+
+	shadowConfig := *ps.config
+	shadowConfig.VectorDimension = dimension
+	shadow := NewPostgresStore(&shadowConfig)
+	shadow.pool = ps.pool
+	shadow.chunksTable = name
+	shadow.sharedPool = true
+	shadow.setConnectionStatus("connected")
+
+	// An existing table from an interrupted run must match the new dimension
+	actual, err := shadow.VectorDimension(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if actual != 0 && actual != dimension {
+		return nil, fmt.Errorf("%w: shadow collection %s uses %d dimensions, expected %d", ErrVectorDimensionMismatch, name, actual, dimension)
+	}
+	return shadow, nil
+}
+
+// PromoteCollection swaps the shadow table in under the active name in a
+// single transaction. The previous table is kept with a timestamped name.
+func (ps *PostgresStore) PromoteCollection(ctx context.Context, name string) (string, error) {
+	if err := ps.checkReady(); err != nil {
+		return "", err
+	}
+	if err := validateShadowCollectionName(name); err != nil {
+		return "", err
+	}
+
+	previous := fmt.Sprintf("%s_previous_%d", ps.chunksTable, time.Now().Unix())
+	tx, err := ps.pool.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin collection swap: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `ALTER TABLE `+ps.chunksTable+` RENAME TO `+previous); err != nil {
+		return "", fmt.Errorf("failed to rename active collection: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `ALTER TABLE `+name+` RENAME TO `+ps.chunksTable); err != nil {
+		return "", fmt.Errorf("failed to promote collection %s: %w", name, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("failed to commit collection swap: %w", err)
+	}
+
+	logging.Info("Promoted postgres collection", "collection", name, "previous", previous)
+	return previous, nil
+}
+
+// DropCollection drops an inactive chunks table
+func (ps *PostgresStore) DropCollection(ctx context.Context, name string) error {
+	if err := ps.checkReady(); err != nil {
+		return err
+	}
+	if err := validateShadowCollectionName(name); err != nil {
+		return err
+	}
+	if name == ps.chunksTable {
+		return fmt.Errorf("cannot drop the active collection %s", name)
+	}
+
+	if _, err := ps.pool.Exec(ctx, `DROP TABLE IF EXISTS `+name); err != nil {
+		return fmt.Errorf("failed to drop collection %s: %w", name, err)
+	}
+	return nil
+}
+
+// postgresShadowTableStatements creates a chunks table shaped like the
+// memory_chunks migration. Index names are derived from the table so they
+// do not collide after the table is promoted.
+func postgresShadowTableStatements(table string, dimension int) []string {
+	return append([]string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id TEXT PRIMARY KEY,
+	session_id TEXT NOT NULL,
+	repository TEXT NOT NULL DEFAULT '',
+	type TEXT NOT NULL,
+	timestamp TIMESTAMPTZ NOT NULL,
+	data JSONB NOT NULL,
+	embedding vector(%d) NOT NULL
+)`, table, dimension),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %[1]s_repository_timestamp_idx ON %[1]s (repository, timestamp DESC)`, table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %[1]s_session_idx ON %[1]s (session_id, timestamp)`, table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %[1]s_type_idx ON %[1]s (type)`, table),
+	}, postgresEmbeddingIndex(table, dimension)...)
+}
@@ -0,0 +1,439 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lerian-mcp-memory/internal/threading"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Ensure PostgresStore can back the thread manager directly
+var _ threading.ThreadStore = (*PostgresStore)(nil)
+
+// AliasRecord is the persisted form of a memory alias. The alias itself is
+// kept as an opaque JSON document so storage does not depend on bulk.
+type AliasRecord struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Repository string          `json:"repository,omitempty"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Thread persistence (threading.ThreadStore)
+
+// StoreThread inserts or replaces a thread
+func (ps *PostgresStore) StoreThread(ctx context.Context, thread *threading.MemoryThread) error {
+	start := time.Now()
+	defer ps.updateMetrics("store_thread", start)
+
+	if thread == nil {
+		return errors.New("thread cannot be nil")
+	}
+	if thread.ID == "" {
+		return errors.New("thread ID cannot be empty")
+	}
+	if err := ps.checkReady(); err != nil {
+		return err
+	}
+
+	// Update timestamp, like the in-memory store
+	thread.LastUpdate = time.Now()
+
+	return ps.writeThread(ctx, thread)
+}
+
+// writeThread upserts a thread row
+func (ps *PostgresStore) writeThread(ctx context.Context, thread *threading.MemoryThread) error {
+	data, err := json.Marshal(thread)
+	if err != nil {
+		return fmt.Errorf("failed to marshal thread: %w", err)
+	}
+
+	_, err = ps.pool.Exec(ctx, `INSERT INTO memory_threads (id, repository, type, status, start_time, last_update, data)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (id) DO UPDATE SET
+	repository = EXCLUDED.repository,
+	type = EXCLUDED.type,
+	status = EXCLUDED.status,
+	start_time = EXCLUDED.start_time,
+	last_update = EXCLUDED.last_update,
+	data = EXCLUDED.data`,
+		thread.ID,
+		thread.Repository,
+		string(thread.Type),
+		string(thread.Status),
+		thread.StartTime,
+		thread.LastUpdate,
+		data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store thread in postgres: %w", err)
+	}
+	return nil
+}
+
+// GetThread retrieves a thread by ID
+func (ps *PostgresStore) GetThread(ctx context.Context, threadID string) (*threading.MemoryThread, error) {
+	start := time.Now()
+	defer ps.updateMetrics("get_thread", start)
+
+	if threadID == "" {
+		return nil, errors.New("thread ID cannot be empty")
+	}
+	if err := ps.checkReady(); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	err := ps.pool.QueryRow(ctx, `SELECT data FROM memory_threads WHERE id = $1`, threadID).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("thread not found: %s", threadID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread: %w", err)
+	}
+
+	var thread threading.MemoryThread
+	if err := json.Unmarshal(data, &thread); err != nil {
+		return nil, fmt.Errorf("failed to decode thread: %w", err)
+	}
+	return &thread, nil
+}
+
+// GetThreadsByRepository retrieves all threads for a repository
+func (ps *PostgresStore) GetThreadsByRepository(ctx context.Context, repository string) ([]*threading.MemoryThread, error) {
+	return ps.ListThreads(ctx, threading.ThreadFilters{Repository: &repository})
+}
+
+// GetActiveThreads retrieves all active threads for a repository
+func (ps *PostgresStore) GetActiveThreads(ctx context.Context, repository string) ([]*threading.MemoryThread, error) {
+	status := threading.ThreadStatusActive
+	return ps.ListThreads(ctx, threading.ThreadFilters{Repository: &repository, Status: &status})
+}
+
+// UpdateThreadStatus updates the status of a thread
+func (ps *PostgresStore) UpdateThreadStatus(ctx context.Context, threadID string, status threading.ThreadStatus) error {
+	start := time.Now()
+	defer ps.updateMetrics("update_thread_status", start)
+
+	thread, err := ps.GetThread(ctx, threadID)
+	if err != nil {
+		return err
+	}
+
+	thread.Status = status
+	thread.LastUpdate = time.Now()
+
+	// Set end time if thread is being completed
+	if status == threading.ThreadStatusComplete && thread.EndTime == nil {
+		now := time.Now()
+		thread.EndTime = &now
+	}
+
+	return ps.writeThread(ctx, thread)
+}
+
+// DeleteThread removes a thread
+func (ps *PostgresStore) DeleteThread(ctx context.Context, threadID string) error {
+	start := time.Now()
+	defer ps.updateMetrics("delete_thread", start)
+
+	if threadID == "" {
+		return errors.New("thread ID cannot be empty")
+	}
+	if err := ps.checkReady(); err != nil {
+		return err
+	}
+
+	tag, err := ps.pool.Exec(ctx, `DELETE FROM memory_threads WHERE id = $1`, threadID)
+	if err != nil {
+		return fmt.Errorf("failed to delete thread: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("thread not found: %s", threadID)
+	}
+	return nil
+}
+
+// ListThreads retrieves threads based on filters
+func (ps *PostgresStore) ListThreads(ctx context.Context, filters threading.ThreadFilters) ([]*threading.MemoryThread, error) {
+	start := time.Now()
+	defer ps.updateMetrics("list_threads", start)
+
+	if err := ps.checkReady(); err != nil {
+		return nil, err
+	}
+
+	where, args, err := buildPostgresThreadFilter(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ps.pool.Query(ctx, `SELECT data FROM memory_threads WHERE `+where+` ORDER BY last_update DESC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list threads: %w", err)
+	}
+	defer rows.Close()
+
+	var threads []*threading.MemoryThread
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read thread: %w", err)
+		}
+		var thread threading.MemoryThread
+		if err := json.Unmarshal(data, &thread); err != nil {
+			return nil, fmt.Errorf("failed to decode thread: %w", err)
+		}
+		threads = append(threads, &thread)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list threads: %w", err)
+	}
+
+	return threads, nil
+}
+
+// buildPostgresThreadFilter translates ThreadFilters into a WHERE clause with
+// the same semantics as the in-memory thread store
+func buildPostgresThreadFilter(filters threading.ThreadFilters) (string, []interface{}, error) {
+	args := make([]interface{}, 0, 6)
+	conditions := make([]string, 0, 6)
+
+	if filters.Repository != nil {
+		args = append(args, *filters.Repository)
+		conditions = append(conditions, fmt.Sprintf("repository = $%d", len(args)))
+	}
+	if filters.Type != nil {
+		args = append(args, string(*filters.Type))
+		conditions = append(conditions, fmt.Sprintf("type = $%d", len(args)))
+	}
+	if filters.Status != nil {
+		args = append(args, string(*filters.Status))
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filters.SessionID != nil {
+		sessionJSON, err := json.Marshal([]string{*filters.SessionID})
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to encode session filter: %w", err)
+		}
+		args = append(args, string(sessionJSON))
+		conditions = append(conditions, fmt.Sprintf("data->'session_ids' @> $%d::jsonb", len(args)))
+	}
+	if len(filters.Tags) > 0 {
+		tagsJSON, err := json.Marshal(filters.Tags)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to encode tags filter: %w", err)
+		}
+		args = append(args, string(tagsJSON))
+		conditions = append(conditions, fmt.Sprintf("data->'tags' @> $%d::jsonb", len(args)))
+	}
+	if filters.Since != nil {
+		args = append(args, *filters.Since)
+		conditions = append(conditions, fmt.Sprintf("last_update >= $%d", len(args)))
+	}
+	if filters.Until != nil {
+		args = append(args, *filters.Until)
+		conditions = append(conditions, fmt.Sprintf("start_time <= $%d", len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "TRUE", args, nil
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// Alias persistence
+
+// StoreAlias inserts or replaces an alias record
+func (ps *PostgresStore) StoreAlias(ctx context.Context, record *AliasRecord) error {
+	start := time.Now()
+	defer ps.updateMetrics("store_alias", start)
+
+	if record == nil || record.ID == "" || record.Name == "" {
+		return errors.New("alias ID and name cannot be empty")
+	}
+	if !json.Valid(record.Data) {
+		return errors.New("alias data must be valid JSON")
+	}
+	if err := ps.checkReady(); err != nil {
+		return err
+	}
+
+	updatedAt := record.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+
+	_, err := ps.pool.Exec(ctx, `INSERT INTO memory_aliases (id, name, repository, updated_at, data)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (id) DO UPDATE SET
+	name = EXCLUDED.name,
+	repository = EXCLUDED.repository,
+	updated_at = EXCLUDED.updated_at,
+	data = EXCLUDED.data`,
+		record.ID,
+		record.Name,
+		record.Repository,
+		updatedAt,
+		[]byte(record.Data),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store alias in postgres: %w", err)
+	}
+	return nil
+}
+
+// ListAliases returns every persisted alias record
+func (ps *PostgresStore) ListAliases(ctx context.Context) ([]AliasRecord, error) {
+	start := time.Now()
+	defer ps.updateMetrics("list_aliases", start)
+
+	if err := ps.checkReady(); err != nil {
+		return nil, err
+	}
+
+	rows, err := ps.pool.Query(ctx, `SELECT id, name, repository, updated_at, data FROM memory_aliases ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aliases: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]AliasRecord, 0)
+	for rows.Next() {
+		var record AliasRecord
+		var data []byte
+		if err := rows.Scan(&record.ID, &record.Name, &record.Repository, &record.UpdatedAt, &data); err != nil {
+			return nil, fmt.Errorf("failed to read alias: %w", err)
+		}
+		record.Data = data
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list aliases: %w", err)
+	}
+
+	return records, nil
+}
+
+// DeleteAlias removes an alias record. Deleting a missing alias is not an error.
+func (ps *PostgresStore) DeleteAlias(ctx context.Context, aliasID string) error {
+	start := time.Now()
+	defer ps.updateMetrics("delete_alias", start)
+
+	if err := ps.checkReady(); err != nil {
+		return err
+	}
+
+	if _, err := ps.pool.Exec(ctx, `DELETE FROM memory_aliases WHERE id = $1`, aliasID); err != nil {
+		return fmt.Errorf("failed to delete alias: %w", err)
+	}
+	return nil
+}
+
+// Document persistence (DocumentStore)
+
+// Ensure PostgresStore can keep chains and other documents
+var _ DocumentStore = (*PostgresStore)(nil)
+
+// PutDocument inserts or replaces a document
+func (ps *PostgresStore) PutDocument(ctx context.Context, kind, id string, data []byte) error {
+	start := time.Now()
+	defer ps.updateMetrics("put_document", start)
+
+	if kind == "" || id == "" {
+		return errors.New("document kind and ID cannot be empty")
+	}
+	if !json.Valid(data) {
+		return errors.New("document data must be valid JSON")
+	}
+	if err := ps.checkReady(); err != nil {
+		return err
+	}
+
+	_, err := ps.pool.Exec(ctx, `INSERT INTO memory_documents (kind, id, updated_at, data)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (kind, id) DO UPDATE SET
+	updated_at = EXCLUDED.updated_at,
+	data = EXCLUDED.data`,
+		kind, id, time.Now(), data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store document in postgres: %w", err)
+	}
+	return nil
+}
+
+// GetDocument retrieves a document
+func (ps *PostgresStore) GetDocument(ctx context.Context, kind, id string) ([]byte, error) {
+	start := time.Now()
+	defer ps.updateMetrics("get_document", start)
+
+	if err := ps.checkReady(); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	err := ps.pool.QueryRow(ctx, `SELECT data FROM memory_documents WHERE kind = $1 AND id = $2`, kind, id).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s/%s", ErrDocumentNotFound, kind, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+	return data, nil
+}
+
+// ListDocuments returns every document of a kind
+func (ps *PostgresStore) ListDocuments(ctx context.Context, kind string) ([][]byte, error) {
+	start := time.Now()
+	defer ps.updateMetrics("list_documents", start)
+
+	if err := ps.checkReady(); err != nil {
+		return nil, err
+	}
+
+	rows, err := ps.pool.Query(ctx, `SELECT data FROM memory_documents WHERE kind = $1 ORDER BY id`, kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	defer rows.Close()
+
+	var documents [][]byte
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read document: %w", err)
+		}
+		documents = append(documents, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	return documents, nil
+}
+
+// DeleteDocument removes a document
+func (ps *PostgresStore) DeleteDocument(ctx context.Context, kind, id string) error {
+	start := time.Now()
+	defer ps.updateMetrics("delete_document", start)
+
+	if err := ps.checkReady(); err != nil {
+		return err
+	}
+
+	tag, err := ps.pool.Exec(ctx, `DELETE FROM memory_documents WHERE kind = $1 AND id = $2`, kind, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s/%s", ErrDocumentNotFound, kind, id)
+	}
+	return nil
+}
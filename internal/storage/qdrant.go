@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"lerian-mcp-memory/internal/config"
 	"lerian-mcp-memory/internal/logging"
+	"lerian-mcp-memory/internal/metrics"
 	"lerian-mcp-memory/pkg/types"
 	"log"
 	"math"
 	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/qdrant/go-client/qdrant"
@@ -29,8 +31,14 @@ type QdrantStore struct {
 	client            *qdrant.Client
 	config            *config.QdrantConfig
 	metrics           *StorageMetrics
-	collectionName    string
 	relationshipStore *RelationshipStore
+
+	// baseCollection is the configured name. The collection actually in use
+	// can differ after a re-embedding swap and is recorded in Qdrant under
+	// the baseCollection+"_active" alias.
+	baseCollection string
+	activeMu       sync.RWMutex
+	collectionName string
 }
 
 // NewQdrantStore creates a new Qdrant vector store
@@ -42,6 +50,7 @@ func NewQdrantStore(cfg *config.QdrantConfig) *QdrantStore {
 
 	return &QdrantStore{
 		config:         cfg,
+		baseCollection: collectionName,
 		collectionName: collectionName,
 		metrics: &StorageMetrics{
 			OperationCounts:  make(map[string]int64),
@@ -77,6 +86,18 @@ func (qs *QdrantStore) Initialize(ctx context.Context) error {
 		return fmt.Errorf("failed to initialize relationship store: %w", err)
 	}
 
+	// Threads and chains live in their own collection
+	if err := qs.initializeDocuments(ctx); err != nil {
+		qs.metrics.ConnectionStatus = connectionStatusError
+		return err
+	}
+
+	// Follow a previous re-embedding swap
+	if err := qs.resolveActiveCollection(ctx); err != nil {
+		qs.metrics.ConnectionStatus = connectionStatusError
+		return err
+	}
+
 	// Check if collection exists
 	collections, err := qs.client.ListCollections(ctx)
 	if err != nil {
@@ -87,7 +108,7 @@ func (qs *QdrantStore) Initialize(ctx context.Context) error {
 	// Check if our collection exists
 	collectionExists := false
 	for _, collectionName := range collections {
-		if collectionName == qs.collectionName {
+		if collectionName == qs.collection() {
 			collectionExists = true
 			break
 		}
@@ -96,24 +117,50 @@ func (qs *QdrantStore) Initialize(ctx context.Context) error {
 	// Create collection if it doesn't exist
 	if !collectionExists {
 		err = qs.client.CreateCollection(ctx, &qdrant.CreateCollection{
-			CollectionName: qs.collectionName,
+			CollectionName: qs.collection(),
 			VectorsConfig: qdrant.NewVectorsConfig(&qdrant.VectorParams{
-				Size:     uint64(defaultVectorSize),
+				Size:     uint64(qs.vectorSize()),
 				Distance: qdrant.Distance_Cosine,
 			}),
 		})
 		if err != nil {
 			qs.metrics.ConnectionStatus = connectionStatusError
-			return fmt.Errorf("failed to create collection %s: %w", qs.collectionName, err)
+			return fmt.Errorf("failed to create collection %s: %w", qs.collection(), err)
 		}
-		logging.Info("Created Qdrant collection", "collection", qs.collectionName)
+		logging.Info("Created Qdrant collection", "collection", qs.collection())
 	}
 
 	qs.metrics.ConnectionStatus = "connected"
-	logging.Info("Qdrant collection initialized", "collection", qs.collectionName)
+	logging.Info("Qdrant collection initialized", "collection", qs.collection())
 	return nil
 }
 
+// vectorSize returns the size used for new collections
+func (qs *QdrantStore) vectorSize() int {
+	if qs.config.VectorSize > 0 {
+		return qs.config.VectorSize
+	}
+	return defaultVectorSize
+}
+
+// VectorDimension returns the vector size of the collection. Collections
+// using named vectors report 0 and are not checked.
+func (qs *QdrantStore) VectorDimension(ctx context.Context) (int, error) {
+	if qs.client == nil {
+		return 0, errors.New("qdrant store is not initialized")
+	}
+
+	info, err := qs.client.GetCollectionInfo(ctx, qs.collection())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get collection info: %w", err)
+	}
+	params := info.GetConfig().GetParams().GetVectorsConfig().GetParams()
+	if params == nil {
+		return 0, nil
+	}
+	return int(params.GetSize()), nil
+}
+
 // Store saves a conversation chunk to Qdrant
 func (qs *QdrantStore) Store(ctx context.Context, chunk *types.ConversationChunk) error {
 	start := time.Now()
@@ -132,7 +179,7 @@ func (qs *QdrantStore) Store(ctx context.Context, chunk *types.ConversationChunk
 
 	// Upsert point to collection
 	_, err := qs.client.Upsert(ctx, &qdrant.UpsertPoints{
-		CollectionName: qs.collectionName,
+		CollectionName: qs.collection(),
 		Points:         []*qdrant.PointStruct{point},
 	})
 
@@ -165,7 +212,7 @@ func (qs *QdrantStore) Search(ctx context.Context, query *types.MemoryQuery, emb
 
 	// Perform search using Query method
 	searchResult, err := qs.client.Query(ctx, &qdrant.QueryPoints{
-		CollectionName: qs.collectionName,
+		CollectionName: qs.collection(),
 		Query:          qdrant.NewQuery(embeddings32...),
 		Limit: func() *uint64 {
 			if query.Limit < 0 {
@@ -224,7 +271,7 @@ func (qs *QdrantStore) GetByID(ctx context.Context, id string) (*types.Conversat
 
 	// Get point by ID
 	points, err := qs.client.Get(ctx, &qdrant.GetPoints{
-		CollectionName: qs.collectionName,
+		CollectionName: qs.collection(),
 		Ids:            []*qdrant.PointId{qs.stringToPointID(id)},
 		WithPayload:    &qdrant.WithPayloadSelector{SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true}},
 		WithVectors:    &qdrant.WithVectorsSelector{SelectorOptions: &qdrant.WithVectorsSelector_Enable{Enable: true}},
@@ -278,7 +325,7 @@ func (qs *QdrantStore) ListByRepository(ctx context.Context, repository string,
 	}
 
 	points, err := qs.client.Scroll(ctx, &qdrant.ScrollPoints{
-		CollectionName: qs.collectionName,
+		CollectionName: qs.collection(),
 		Filter:         filter,
 		Limit:          qdrant.PtrOf(scrollLimit),
 		WithPayload:    &qdrant.WithPayloadSelector{SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true}},
@@ -344,7 +391,7 @@ func (qs *QdrantStore) ListBySession(ctx context.Context, sessionID string) ([]t
 
 	// Scroll through points
 	points, err := qs.client.Scroll(ctx, &qdrant.ScrollPoints{
-		CollectionName: qs.collectionName,
+		CollectionName: qs.collection(),
 		Filter:         filter,
 		WithPayload:    &qdrant.WithPayloadSelector{SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true}},
 		WithVectors:    &qdrant.WithVectorsSelector{SelectorOptions: &qdrant.WithVectorsSelector_Enable{Enable: true}},
@@ -384,7 +431,7 @@ func (qs *QdrantStore) Delete(ctx context.Context, id string) error {
 	defer qs.updateMetrics("delete", start)
 
 	_, err := qs.client.Delete(ctx, &qdrant.DeletePoints{
-		CollectionName: qs.collectionName,
+		CollectionName: qs.collection(),
 		Points: &qdrant.PointsSelector{
 			PointsSelectorOneOf: &qdrant.PointsSelector_Points{
 				Points: &qdrant.PointsIdsList{
@@ -421,7 +468,7 @@ func (qs *QdrantStore) HealthCheck(ctx context.Context) error {
 	defer qs.updateMetrics("health_check", start)
 
 	// Try to get collection info
-	_, err := qs.client.GetCollectionInfo(ctx, qs.collectionName)
+	_, err := qs.client.GetCollectionInfo(ctx, qs.collection())
 	if err != nil {
 		qs.metrics.ConnectionStatus = connectionStatusError
 		return fmt.Errorf("qdrant health check failed: %w", err)
@@ -437,7 +484,7 @@ func (qs *QdrantStore) GetStats(ctx context.Context) (*StoreStats, error) {
 	defer qs.updateMetrics("get_stats", start)
 
 	// Get collection info
-	info, err := qs.client.GetCollectionInfo(ctx, qs.collectionName)
+	info, err := qs.client.GetCollectionInfo(ctx, qs.collection())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get collection info: %w", err)
 	}
@@ -504,7 +551,7 @@ func (qs *QdrantStore) enrichStatsWithSampleData(ctx context.Context, stats *Sto
 	sampleSize := qs.calculateSampleSize(stats.TotalChunks)
 
 	points, err := qs.client.Scroll(ctx, &qdrant.ScrollPoints{
-		CollectionName: qs.collectionName,
+		CollectionName: qs.collection(),
 		Limit:          qdrant.PtrOf(sampleSize),
 		WithPayload:    &qdrant.WithPayloadSelector{SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true}},
 	})
@@ -638,7 +685,7 @@ func (qs *QdrantStore) Cleanup(ctx context.Context, retentionDays int) (int, err
 
 	// Count first
 	deletedCount64, err := qs.client.Count(ctx, &qdrant.CountPoints{
-		CollectionName: qs.collectionName,
+		CollectionName: qs.collection(),
 		Filter:         filter,
 	})
 	if err != nil {
@@ -654,7 +701,7 @@ func (qs *QdrantStore) Cleanup(ctx context.Context, retentionDays int) (int, err
 
 	// Delete old chunks
 	_, err = qs.client.Delete(ctx, &qdrant.DeletePoints{
-		CollectionName: qs.collectionName,
+		CollectionName: qs.collection(),
 		Points: &qdrant.PointsSelector{
 			PointsSelectorOneOf: &qdrant.PointsSelector_Filter{
 				Filter: filter,
@@ -854,29 +901,17 @@ func (qs *QdrantStore) buildFilter(query *types.MemoryQuery) *qdrant.Filter {
 	}
 
 	// Recency-based filtering
-	if query.Recency != "" && query.Recency != types.RecencyAllTime {
-		var cutoffTime time.Time
-		switch query.Recency {
-		case types.RecencyRecent:
-			cutoffTime = time.Now().AddDate(0, 0, -7) // Last 7 days
-		case types.RecencyLastMonth:
-			cutoffTime = time.Now().AddDate(0, -1, 0) // Last month
-		case types.RecencyAllTime:
-			// No time filtering for all time
-		}
-
-		if !cutoffTime.IsZero() {
-			conditions = append(conditions, &qdrant.Condition{
-				ConditionOneOf: &qdrant.Condition_Field{
-					Field: &qdrant.FieldCondition{
-						Key: "timestamp",
-						Range: &qdrant.Range{
-							Gte: qdrant.PtrOf(float64(cutoffTime.Unix())),
-						},
+	if cutoffTime := recencyCutoff(query.Recency, time.Now()); !cutoffTime.IsZero() {
+		conditions = append(conditions, &qdrant.Condition{
+			ConditionOneOf: &qdrant.Condition_Field{
+				Field: &qdrant.FieldCondition{
+					Key: "timestamp",
+					Range: &qdrant.Range{
+						Gte: qdrant.PtrOf(float64(cutoffTime.Unix())),
 					},
 				},
-			})
-		}
+			},
+		})
 	}
 
 	if len(conditions) == 0 {
@@ -956,6 +991,7 @@ func (qs *QdrantStore) getStringSliceFromPayload(payload map[string]*qdrant.Valu
 // updateMetrics updates operation metrics
 func (qs *QdrantStore) updateMetrics(operation string, start time.Time) {
 	duration := time.Since(start)
+	metrics.StorageDuration.Observe(duration.Seconds(), config.StorageProviderQdrant, operation)
 
 	qs.metrics.OperationCounts[operation]++
 
@@ -977,7 +1013,7 @@ func (qs *QdrantStore) GetAllChunks(ctx context.Context) ([]types.ConversationCh
 
 	// Use Scroll to get all points with a large limit
 	points, err := qs.client.Scroll(ctx, &qdrant.ScrollPoints{
-		CollectionName: qs.collectionName,
+		CollectionName: qs.collection(),
 		Limit:          qdrant.PtrOf(uint32(10000)), // Large limit, adjust as needed
 		WithPayload:    &qdrant.WithPayloadSelector{SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true}},
 		WithVectors:    &qdrant.WithVectorsSelector{SelectorOptions: &qdrant.WithVectorsSelector_Enable{Enable: true}},
@@ -1015,7 +1051,7 @@ func (qs *QdrantStore) DeleteCollection(ctx context.Context, collection string)
 	// Use the provided collection name or default to current collection
 	collectionName := collection
 	if collectionName == "" {
-		collectionName = qs.collectionName
+		collectionName = qs.collection()
 	}
 
 	err := qs.client.DeleteCollection(ctx, collectionName)
@@ -1092,7 +1128,7 @@ func (qs *QdrantStore) BatchStore(ctx context.Context, chunks []*types.Conversat
 	// Perform batch upsert
 	if len(points) > 0 {
 		_, err := qs.client.Upsert(ctx, &qdrant.UpsertPoints{
-			CollectionName: qs.collectionName,
+			CollectionName: qs.collection(),
 			Points:         points,
 		})
 
@@ -1139,7 +1175,7 @@ func (qs *QdrantStore) BatchDelete(ctx context.Context, ids []string) (*BatchRes
 
 	// Perform batch delete
 	_, err := qs.client.Delete(ctx, &qdrant.DeletePoints{
-		CollectionName: qs.collectionName,
+		CollectionName: qs.collection(),
 		Points: &qdrant.PointsSelector{
 			PointsSelectorOneOf: &qdrant.PointsSelector_Points{
 				Points: &qdrant.PointsIdsList{
@@ -1204,3 +1240,134 @@ func (qs *QdrantStore) DeleteRelationship(ctx context.Context, relationshipID st
 func (qs *QdrantStore) GetRelationshipByID(ctx context.Context, relationshipID string) (*types.MemoryRelationship, error) {
 	return qs.relationshipStore.GetByID(ctx, relationshipID)
 }
+
+// Shadow collection support
+
+// collection returns the collection currently serving reads and writes
+func (qs *QdrantStore) collection() string {
+	qs.activeMu.RLock()
+	defer qs.activeMu.RUnlock()
+	return qs.collectionName
+}
+
+// activeAlias is the alias pointing at the collection in use
+func (qs *QdrantStore) activeAlias() string {
+	return qs.baseCollection + "_active"
+}
+
+// resolveActiveCollection switches to the collection behind the active alias,
+// if a re-embedding swap has created one
+func (qs *QdrantStore) resolveActiveCollection(ctx context.Context) error {
+	aliases, err := qs.client.ListAliases(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list collection aliases: %w", err)
+	}
+
+	for _, alias := range aliases {
+		if alias.GetAliasName() == qs.activeAlias() {
+			qs.activeMu.Lock()
+			qs.collectionName = alias.GetCollectionName()
+			qs.activeMu.Unlock()
+			logging.Info("Using aliased Qdrant collection", "alias", alias.GetAliasName(), "collection", alias.GetCollectionName())
+			return nil
+		}
+	}
+	return nil
+}
+
+// ActiveCollection returns the collection currently serving reads and writes
+func (qs *QdrantStore) ActiveCollection() string {
+	return qs.collection()
+}
+
+// OpenShadowCollection creates the named collection if needed and returns a
+// store bound to it that shares this store's client and relationships
+func (qs *QdrantStore) OpenShadowCollection(ctx context.Context, name string, dimension int) (VectorStore, error) {
+	if err := validateShadowCollectionName(name); err != nil {
+		return nil, err
+	}
+	if name == qs.collection() {
+		return nil, fmt.Errorf("collection %s is already active", name)
+	}
+
+	exists, err := qs.client.CollectionExists(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check collection %s: %w", name, err)
+	}
+	if !exists {
+		err = qs.client.CreateCollection(ctx, &qdrant.CreateCollection{
+			CollectionName: name,
+			VectorsConfig: qdrant.NewVectorsConfig(&qdrant.VectorParams{
+				Size:     uint64(dimension),
+				Distance: qdrant.Distance_Cosine,
+			}),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create collection %s: %w", name, err)
+		}
+		logging.Info("Created Qdrant shadow collection", "collection", name, "dimension", dimension)
+	}
+
+	shadowConfig := *qs.config
+	shadowConfig.VectorSize = dimension
+	shadow := NewQdrantStore(&shadowConfig)
+	shadow.client = qs.client
+	shadow.relationshipStore = qs.relationshipStore
+	shadow.baseCollection = name
+	shadow.collectionName = name
+	shadow.metrics.ConnectionStatus = "connected"
+
+	if err := VerifyVectorDimension(ctx, shadow, dimension); err != nil {
+		return nil, err
+	}
+	return shadow, nil
+}
+
+// PromoteCollection points the active alias at the named collection in one
+// alias update and switches this store over. The previous collection keeps
+// its name.
+func (qs *QdrantStore) PromoteCollection(ctx context.Context, name string) (string, error) {
+	start := time.Now()
+	defer qs.updateMetrics("promote_collection", start)
+
+	if err := validateShadowCollectionName(name); err != nil {
+		return "", err
+	}
+
+	qs.activeMu.Lock()
+	defer qs.activeMu.Unlock()
+
+	previous := qs.collectionName
+	if name == previous {
+		return "", fmt.Errorf("collection %s is already active", name)
+	}
+
+	aliases, err := qs.client.ListAliases(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list collection aliases: %w", err)
+	}
+	actions := make([]*qdrant.AliasOperations, 0, 2)
+	for _, alias := range aliases {
+		if alias.GetAliasName() == qs.activeAlias() {
+			actions = append(actions, qdrant.NewAliasDelete(qs.activeAlias()))
+			break
+		}
+	}
+	actions = append(actions, qdrant.NewAliasCreate(qs.activeAlias(), name))
+
+	if err := qs.client.UpdateAliases(ctx, actions); err != nil {
+		return "", fmt.Errorf("failed to point alias %s at %s: %w", qs.activeAlias(), name, err)
+	}
+	qs.collectionName = name
+
+	logging.Info("Promoted Qdrant collection", "collection", name, "previous", previous)
+	return previous, nil
+}
+
+// DropCollection deletes an inactive collection
+func (qs *QdrantStore) DropCollection(ctx context.Context, name string) error {
+	if name == qs.collection() {
+		return fmt.Errorf("refusing to drop active collection %s", name)
+	}
+	return qs.DeleteCollection(ctx, name)
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/qdrant/go-client/qdrant"
+)
+
+const (
+	documentsCollection = "memory_documents"
+	documentsPageSize   = 256
+)
+
+// documentIDNamespace derives stable point IDs from document kinds and IDs,
+// since Qdrant point IDs must be UUIDs
+var documentIDNamespace = uuid.MustParse("6f1d6c2e-8f0a-4a53-9a0c-4d6d656d6f72")
+
+// Ensure QdrantStore can keep threads and chains
+var _ DocumentStore = (*QdrantStore)(nil)
+
+// initializeDocuments creates the documents collection if it doesn't exist.
+// Like relationships, documents are stored as payload with a dummy vector.
+func (qs *QdrantStore) initializeDocuments(ctx context.Context) error {
+	exists, err := qs.client.CollectionExists(ctx, documentsCollection)
+	if err != nil {
+		return fmt.Errorf("failed to check documents collection: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	err = qs.client.CreateCollection(ctx, &qdrant.CreateCollection{
+		CollectionName: documentsCollection,
+		VectorsConfig: qdrant.NewVectorsConfig(&qdrant.VectorParams{
+			Size:     1,
+			Distance: qdrant.Distance_Cosine,
+		}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create documents collection: %w", err)
+	}
+	return nil
+}
+
+// PutDocument upserts a document
+func (qs *QdrantStore) PutDocument(ctx context.Context, kind, id string, data []byte) error {
+	start := time.Now()
+	defer qs.updateMetrics("put_document", start)
+
+	_, err := qs.client.Upsert(ctx, &qdrant.UpsertPoints{
+		CollectionName: documentsCollection,
+		Points: []*qdrant.PointStruct{{
+			Id:      documentPointID(kind, id),
+			Vectors: &qdrant.Vectors{VectorsOptions: &qdrant.Vectors_Vector{Vector: &qdrant.Vector{Data: []float32{0.0}}}}, // Dummy vector
+			Payload: map[string]*qdrant.Value{
+				"kind":       qs.stringToValue(kind),
+				"doc_id":     qs.stringToValue(id),
+				"data":       qs.stringToValue(string(data)),
+				"updated_at": qs.int64ToValue(time.Now().Unix()),
+			},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store document %s/%s in Qdrant: %w", kind, id, err)
+	}
+	return nil
+}
+
+// GetDocument retrieves a document
+func (qs *QdrantStore) GetDocument(ctx context.Context, kind, id string) ([]byte, error) {
+	start := time.Now()
+	defer qs.updateMetrics("get_document", start)
+
+	points, err := qs.client.Get(ctx, &qdrant.GetPoints{
+		CollectionName: documentsCollection,
+		Ids:            []*qdrant.PointId{documentPointID(kind, id)},
+		WithPayload:    qdrant.NewWithPayload(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document %s/%s: %w", kind, id, err)
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("%w: %s/%s", ErrDocumentNotFound, kind, id)
+	}
+	return []byte(qs.getStringFromPayload(points[0].GetPayload(), "data")), nil
+}
+
+// ListDocuments pages through every document of a kind
+func (qs *QdrantStore) ListDocuments(ctx context.Context, kind string) ([][]byte, error) {
+	start := time.Now()
+	defer qs.updateMetrics("list_documents", start)
+
+	var documents [][]byte
+	var offset *qdrant.PointId
+	for {
+		resp, err := qs.client.GetPointsClient().Scroll(ctx, &qdrant.ScrollPoints{
+			CollectionName: documentsCollection,
+			Filter:         &qdrant.Filter{Must: []*qdrant.Condition{qdrant.NewMatchKeyword("kind", kind)}},
+			Limit:          qdrant.PtrOf(uint32(documentsPageSize)),
+			Offset:         offset,
+			WithPayload:    qdrant.NewWithPayload(true),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s documents: %w", kind, err)
+		}
+
+		for _, point := range resp.GetResult() {
+			documents = append(documents, []byte(qs.getStringFromPayload(point.GetPayload(), "data")))
+		}
+
+		offset = resp.GetNextPageOffset()
+		if offset == nil {
+			return documents, nil
+		}
+	}
+}
+
+// DeleteDocument removes a document
+func (qs *QdrantStore) DeleteDocument(ctx context.Context, kind, id string) error {
+	start := time.Now()
+	defer qs.updateMetrics("delete_document", start)
+
+	// Qdrant deletes are idempotent; check first to report missing documents
+	if _, err := qs.GetDocument(ctx, kind, id); err != nil {
+		return err
+	}
+
+	_, err := qs.client.Delete(ctx, &qdrant.DeletePoints{
+		CollectionName: documentsCollection,
+		Points:         qdrant.NewPointsSelector(documentPointID(kind, id)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete document %s/%s: %w", kind, id, err)
+	}
+	return nil
+}
+
+func documentPointID(kind, id string) *qdrant.PointId {
+	return qdrant.NewIDUUID(uuid.NewSHA1(documentIDNamespace, []byte(kind+"/"+id)).String())
+}
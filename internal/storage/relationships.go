@@ -187,7 +187,7 @@ func (rs *RelationshipStore) GetRelationships(ctx context.Context, query *types.
 	}
 
 	// Sort relationships
-	rs.sortRelationships(relationships, query.SortBy, query.SortOrder)
+	sortRelationships(relationships, query.SortBy, query.SortOrder)
 
 	// Apply limit
 	if query.Limit > 0 && len(relationships) > query.Limit {
@@ -202,115 +202,7 @@ func (rs *RelationshipStore) TraverseGraph(ctx context.Context, startChunkID str
 	start := time.Now()
 	defer rs.updateMetrics("traverse_graph", start)
 
-	if maxDepth <= 0 {
-		maxDepth = 3
-	}
-
-	visited := make(map[string]bool)
-	paths := make([]types.GraphPath, 0)
-	nodes := make(map[string]*types.GraphNode)
-	edges := make(map[string]*types.GraphEdge)
-
-	// Recursive traversal function
-	var traverse func(chunkID string, currentPath []string, currentScore float64, depth int)
-	traverse = func(chunkID string, currentPath []string, currentScore float64, depth int) {
-		if depth > maxDepth || visited[chunkID] {
-			return
-		}
-
-		visited[chunkID] = true
-		currentPath = append(currentPath, chunkID)
-
-		// Add node if not exists
-		if _, exists := nodes[chunkID]; !exists {
-			nodes[chunkID] = &types.GraphNode{
-				ChunkID:    chunkID,
-				Degree:     0,
-				Centrality: 0.0,
-			}
-		}
-
-		// Get relationships for current chunk
-		query := types.NewRelationshipQuery(chunkID)
-		query.RelationTypes = relationTypes
-		query.MaxDepth = 1 // Only direct relationships
-		relationships, err := rs.GetRelationships(ctx, query)
-		if err != nil {
-			return
-		}
-
-		// Follow each relationship
-		for i := range relationships {
-			rel := &relationships[i]
-			relationship := rel.Relationship
-			var targetID string
-
-			// Determine target based on direction
-			if relationship.SourceChunkID == chunkID {
-				targetID = relationship.TargetChunkID
-			} else {
-				targetID = relationship.SourceChunkID
-			}
-
-			// Add edge
-			edgeKey := relationship.SourceChunkID + "-" + relationship.TargetChunkID
-			edges[edgeKey] = &types.GraphEdge{
-				Relationship: relationship,
-				Weight:       relationship.Confidence,
-			}
-
-			// Update node degrees
-			nodes[chunkID].Degree++
-			if _, exists := nodes[targetID]; !exists {
-				nodes[targetID] = &types.GraphNode{
-					ChunkID:    targetID,
-					Degree:     0,
-					Centrality: 0.0,
-				}
-			}
-			nodes[targetID].Degree++
-
-			// Calculate path score (average confidence)
-			newScore := (currentScore*float64(len(currentPath)-1) + relationship.Confidence) / float64(len(currentPath))
-
-			// Continue traversal
-			traverse(targetID, currentPath, newScore, depth+1)
-		}
-
-		// Add path if it has multiple nodes
-		if len(currentPath) > 1 {
-			pathType := rs.determinePathType(currentPath, relationships)
-			paths = append(paths, types.GraphPath{
-				ChunkIDs: append([]string{}, currentPath...),
-				Score:    currentScore,
-				Depth:    len(currentPath) - 1,
-				PathType: pathType,
-			})
-		}
-	}
-
-	// Start traversal
-	traverse(startChunkID, []string{}, 1.0, 0)
-
-	// Calculate centrality scores
-	rs.calculateCentrality(nodes)
-
-	// Convert maps to slices
-	nodeSlice := make([]types.GraphNode, 0, len(nodes))
-	for _, node := range nodes {
-		nodeSlice = append(nodeSlice, *node)
-	}
-
-	edgeSlice := make([]types.GraphEdge, 0, len(edges))
-	for _, edge := range edges {
-		edgeSlice = append(edgeSlice, *edge)
-	}
-
-	return &types.GraphTraversalResult{
-		Paths: paths,
-		Nodes: nodeSlice,
-		Edges: edgeSlice,
-	}, nil
+	return traverseRelationshipGraph(ctx, startChunkID, maxDepth, relationTypes, rs.GetRelationships), nil
 }
 
 // UpdateRelationship updates an existing relationship
@@ -610,7 +502,7 @@ func (rs *RelationshipStore) calculateLimit(limit int) *uint32 {
 	return qdrant.PtrOf(uint32(limit))
 }
 
-func (rs *RelationshipStore) sortRelationships(relationships []types.RelationshipResult, sortBy, sortOrder string) {
+func sortRelationships(relationships []types.RelationshipResult, sortBy, sortOrder string) {
 	if sortBy == "" {
 		sortBy = "confidence"
 	}
@@ -638,7 +530,7 @@ func (rs *RelationshipStore) sortRelationships(relationships []types.Relationshi
 	})
 }
 
-func (rs *RelationshipStore) determinePathType(chunkIDs []string, relationships []types.RelationshipResult) string {
+func determinePathType(chunkIDs []string, relationships []types.RelationshipResult) string {
 	if len(relationships) == 0 {
 		return "unknown"
 	}
@@ -682,7 +574,7 @@ func (rs *RelationshipStore) determinePathType(chunkIDs []string, relationships
 	return "general"
 }
 
-func (rs *RelationshipStore) calculateCentrality(nodes map[string]*types.GraphNode) {
+func calculateCentrality(nodes map[string]*types.GraphNode) {
 	totalDegree := 0
 	for _, node := range nodes {
 		totalDegree += node.Degree
@@ -696,6 +588,123 @@ func (rs *RelationshipStore) calculateCentrality(nodes map[string]*types.GraphNo
 	}
 }
 
+// relationshipFetcher looks up the direct relationships matching a query
+type relationshipFetcher func(ctx context.Context, query *types.RelationshipQuery) ([]types.RelationshipResult, error)
+
+// traverseRelationshipGraph walks the relationship graph depth-first from a chunk.
+// It is shared by every VectorStore backend so traversal results are identical.
+func traverseRelationshipGraph(ctx context.Context, startChunkID string, maxDepth int, relationTypes []types.RelationType, fetch relationshipFetcher) *types.GraphTraversalResult {
+	if maxDepth <= 0 {
+		maxDepth = 3
+	}
+
+	visited := make(map[string]bool)
+	paths := make([]types.GraphPath, 0)
+	nodes := make(map[string]*types.GraphNode)
+	edges := make(map[string]*types.GraphEdge)
+
+	// Recursive traversal function
+	var traverse func(chunkID string, currentPath []string, currentScore float64, depth int)
+	traverse = func(chunkID string, currentPath []string, currentScore float64, depth int) {
+		if depth > maxDepth || visited[chunkID] {
+			return
+		}
+
+		visited[chunkID] = true
+		currentPath = append(currentPath, chunkID)
+
+		// Add node if not exists
+		if _, exists := nodes[chunkID]; !exists {
+			nodes[chunkID] = &types.GraphNode{
+				ChunkID:    chunkID,
+				Degree:     0,
+				Centrality: 0.0,
+			}
+		}
+
+		// Get relationships for current chunk
+		query := types.NewRelationshipQuery(chunkID)
+		query.RelationTypes = relationTypes
+		query.MaxDepth = 1 // Only direct relationships
+		relationships, err := fetch(ctx, query)
+		if err != nil {
+			return
+		}
+
+		// Follow each relationship
+		for i := range relationships {
+			rel := &relationships[i]
+			relationship := rel.Relationship
+			var targetID string
+
+			// Determine target based on direction
+			if relationship.SourceChunkID == chunkID {
+				targetID = relationship.TargetChunkID
+			} else {
+				targetID = relationship.SourceChunkID
+			}
+
+			// Add edge
+			edgeKey := relationship.SourceChunkID + "-" + relationship.TargetChunkID
+			edges[edgeKey] = &types.GraphEdge{
+				Relationship: relationship,
+				Weight:       relationship.Confidence,
+			}
+
+			// Update node degrees
+			nodes[chunkID].Degree++
+			if _, exists := nodes[targetID]; !exists {
+				nodes[targetID] = &types.GraphNode{
+					ChunkID:    targetID,
+					Degree:     0,
+					Centrality: 0.0,
+				}
+			}
+			nodes[targetID].Degree++
+
+			// Calculate path score (average confidence)
+			newScore := (currentScore*float64(len(currentPath)-1) + relationship.Confidence) / float64(len(currentPath))
+
+			// Continue traversal
+			traverse(targetID, currentPath, newScore, depth+1)
+		}
+
+		// Add path if it has multiple nodes
+		if len(currentPath) > 1 {
+			pathType := determinePathType(currentPath, relationships)
+			paths = append(paths, types.GraphPath{
+				ChunkIDs: append([]string{}, currentPath...),
+				Score:    currentScore,
+				Depth:    len(currentPath) - 1,
+				PathType: pathType,
+			})
+		}
+	}
+
+	// Start traversal
+	traverse(startChunkID, []string{}, 1.0, 0)
+
+	// Calculate centrality scores
+	calculateCentrality(nodes)
+
+	// Convert maps to slices
+	nodeSlice := make([]types.GraphNode, 0, len(nodes))
+	for _, node := range nodes {
+		nodeSlice = append(nodeSlice, *node)
+	}
+
+	edgeSlice := make([]types.GraphEdge, 0, len(edges))
+	for _, edge := range edges {
+		edgeSlice = append(edgeSlice, *edge)
+	}
+
+	return &types.GraphTraversalResult{
+		Paths: paths,
+		Nodes: nodeSlice,
+		Edges: edgeSlice,
+	}
+}
+
 func (rs *RelationshipStore) updateMetrics(operation string, start time.Time) {
 	duration := time.Since(start)
 
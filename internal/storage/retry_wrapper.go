@@ -25,6 +25,11 @@ func NewRetryableVectorStore(store VectorStore, config *retry.Config) VectorStor
 	}
 }
 
+// Unwrap returns the wrapped store
+func (r *RetryableVectorStore) Unwrap() VectorStore {
+	return r.store
+}
+
 // defaultRetryConfig returns the default retry configuration for storage operations
 func defaultRetryConfig() *retry.Config {
 	return &retry.Config{
@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"context"
+	"lerian-mcp-memory/internal/tracing"
+	"lerian-mcp-memory/pkg/types"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TracedVectorStore wraps a VectorStore with a span per call. It sits
+// directly on the backend, below the retry and circuit-breaker wrappers, so
+// each attempt is its own span.
+type TracedVectorStore struct {
+	store   VectorStore
+	backend string
+}
+
+// NewTracedVectorStore creates a store that traces calls to backend, the
+// storage provider name
+func NewTracedVectorStore(store VectorStore, backend string) *TracedVectorStore {
+	return &TracedVectorStore{store: store, backend: backend}
+}
+
+// Unwrap returns the wrapped store
+func (s *TracedVectorStore) Unwrap() VectorStore {
+	return s.store
+}
+
+func (s *TracedVectorStore) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, func(error)) {
+	attrs = append(attrs, attribute.String("db.system", s.backend), tracing.AttrOperation.String(method))
+	ctx, span := tracing.Start(ctx, "vectorstore."+method, attrs...)
+	return ctx, func(err error) { tracing.End(span, err) }
+}
+
+func repositoryAttr(repository string) attribute.KeyValue {
+	return tracing.AttrRepository.String(repository)
+}
+
+func chunkAttr(chunk *types.ConversationChunk) attribute.KeyValue {
+	if chunk == nil {
+		return repositoryAttr("")
+	}
+	return repositoryAttr(chunk.Metadata.Repository)
+}
+
+func queryAttr(query *types.MemoryQuery) attribute.KeyValue {
+	if query == nil || query.Repository == nil {
+		return repositoryAttr("")
+	}
+	return repositoryAttr(*query.Repository)
+}
+
+// Initialize initializes the wrapped store in a span
+func (s *TracedVectorStore) Initialize(ctx context.Context) (err error) {
+	ctx, end := s.start(ctx, "Initialize")
+	defer func() { end(err) }()
+	return s.store.Initialize(ctx)
+}
+
+// Store stores a chunk in a span
+func (s *TracedVectorStore) Store(ctx context.Context, chunk *types.ConversationChunk) (err error) {
+	ctx, end := s.start(ctx, "Store", chunkAttr(chunk))
+	defer func() { end(err) }()
+	return s.store.Store(ctx, chunk)
+}
+
+// Search performs vector similarity search in a span
+func (s *TracedVectorStore) Search(ctx context.Context, query *types.MemoryQuery, embeddings []float64) (results *types.SearchResults, err error) {
+	ctx, end := s.start(ctx, "Search", queryAttr(query))
+	defer func() { end(err) }()
+	return s.store.Search(ctx, query, embeddings)
+}
+
+// GetByID gets a chunk by ID in a span
+func (s *TracedVectorStore) GetByID(ctx context.Context, id string) (chunk *types.ConversationChunk, err error) {
+	ctx, end := s.start(ctx, "GetByID")
+	defer func() { end(err) }()
+	return s.store.GetByID(ctx, id)
+}
+
+// ListByRepository lists chunks by repository in a span
+func (s *TracedVectorStore) ListByRepository(ctx context.Context, repository string, limit, offset int) (chunks []types.ConversationChunk, err error) {
+	ctx, end := s.start(ctx, "ListByRepository", repositoryAttr(repository))
+	defer func() { end(err) }()
+	return s.store.ListByRepository(ctx, repository, limit, offset)
+}
+
+// ListBySession lists chunks by session in a span
+func (s *TracedVectorStore) ListBySession(ctx context.Context, sessionID string) (chunks []types.ConversationChunk, err error) {
+	ctx, end := s.start(ctx, "ListBySession")
+	defer func() { end(err) }()
+	return s.store.ListBySession(ctx, sessionID)
+}
+
+// Delete deletes a chunk in a span
+func (s *TracedVectorStore) Delete(ctx context.Context, id string) (err error) {
+	ctx, end := s.start(ctx, "Delete")
+	defer func() { end(err) }()
+	return s.store.Delete(ctx, id)
+}
+
+// Update updates a chunk in a span
+func (s *TracedVectorStore) Update(ctx context.Context, chunk *types.ConversationChunk) (err error) {
+	ctx, end := s.start(ctx, "Update", chunkAttr(chunk))
+	defer func() { end(err) }()
+	return s.store.Update(ctx, chunk)
+}
+
+// HealthCheck checks the wrapped store in a span
+func (s *TracedVectorStore) HealthCheck(ctx context.Context) (err error) {
+	ctx, end := s.start(ctx, "HealthCheck")
+	defer func() { end(err) }()
+	return s.store.HealthCheck(ctx)
+}
+
+// GetStats returns store statistics in a span
+func (s *TracedVectorStore) GetStats(ctx context.Context) (stats *StoreStats, err error) {
+	ctx, end := s.start(ctx, "GetStats")
+	defer func() { end(err) }()
+	return s.store.GetStats(ctx)
+}
+
+// Cleanup deletes chunks past retention in a span
+func (s *TracedVectorStore) Cleanup(ctx context.Context, retentionDays int) (deleted int, err error) {
+	ctx, end := s.start(ctx, "Cleanup")
+	defer func() { end(err) }()
+	return s.store.Cleanup(ctx, retentionDays)
+}
+
+// GetAllChunks returns every chunk in a span
+func (s *TracedVectorStore) GetAllChunks(ctx context.Context) (chunks []types.ConversationChunk, err error) {
+	ctx, end := s.start(ctx, "GetAllChunks")
+	defer func() { end(err) }()
+	return s.store.GetAllChunks(ctx)
+}
+
+// DeleteCollection deletes a collection in a span
+func (s *TracedVectorStore) DeleteCollection(ctx context.Context, collection string) (err error) {
+	ctx, end := s.start(ctx, "DeleteCollection")
+	defer func() { end(err) }()
+	return s.store.DeleteCollection(ctx, collection)
+}
+
+// ListCollections lists collections in a span
+func (s *TracedVectorStore) ListCollections(ctx context.Context) (collections []string, err error) {
+	ctx, end := s.start(ctx, "ListCollections")
+	defer func() { end(err) }()
+	return s.store.ListCollections(ctx)
+}
+
+// FindSimilar finds chunks similar to content in a span
+func (s *TracedVectorStore) FindSimilar(ctx context.Context, content string, chunkType *types.ChunkType, limit int) (chunks []types.ConversationChunk, err error) {
+	ctx, end := s.start(ctx, "FindSimilar")
+	defer func() { end(err) }()
+	return s.store.FindSimilar(ctx, content, chunkType, limit)
+}
+
+// StoreChunk stores a chunk in a span
+func (s *TracedVectorStore) StoreChunk(ctx context.Context, chunk *types.ConversationChunk) (err error) {
+	ctx, end := s.start(ctx, "StoreChunk", chunkAttr(chunk))
+	defer func() { end(err) }()
+	return s.store.StoreChunk(ctx, chunk)
+}
+
+// BatchStore stores chunks in a batch in a span
+func (s *TracedVectorStore) BatchStore(ctx context.Context, chunks []*types.ConversationChunk) (result *BatchResult, err error) {
+	ctx, end := s.start(ctx, "BatchStore", attribute.Int("db.batch_size", len(chunks)))
+	defer func() { end(err) }()
+	return s.store.BatchStore(ctx, chunks)
+}
+
+// BatchDelete deletes chunks in a batch in a span
+func (s *TracedVectorStore) BatchDelete(ctx context.Context, ids []string) (result *BatchResult, err error) {
+	ctx, end := s.start(ctx, "BatchDelete", attribute.Int("db.batch_size", len(ids)))
+	defer func() { end(err) }()
+	return s.store.BatchDelete(ctx, ids)
+}
+
+// StoreRelationship stores a relationship in a span
+func (s *TracedVectorStore) StoreRelationship(ctx context.Context, sourceID, targetID string, relationType types.RelationType, confidence float64, source types.ConfidenceSource) (relationship *types.MemoryRelationship, err error) {
+	ctx, end := s.start(ctx, "StoreRelationship")
+	defer func() { end(err) }()
+	return s.store.StoreRelationship(ctx, sourceID, targetID, relationType, confidence, source)
+}
+
+// GetRelationships queries relationships in a span
+func (s *TracedVectorStore) GetRelationships(ctx context.Context, query *types.RelationshipQuery) (relationships []types.RelationshipResult, err error) {
+	ctx, end := s.start(ctx, "GetRelationships")
+	defer func() { end(err) }()
+	return s.store.GetRelationships(ctx, query)
+}
+
+// TraverseGraph walks the relationship graph in a span
+func (s *TracedVectorStore) TraverseGraph(ctx context.Context, startChunkID string, maxDepth int, relationTypes []types.RelationType) (traversal *types.GraphTraversalResult, err error) {
+	ctx, end := s.start(ctx, "TraverseGraph")
+	defer func() { end(err) }()
+	return s.store.TraverseGraph(ctx, startChunkID, maxDepth, relationTypes)
+}
+
+// UpdateRelationship updates a relationship in a span
+func (s *TracedVectorStore) UpdateRelationship(ctx context.Context, relationshipID string, confidence float64, factors types.ConfidenceFactors) (err error) {
+	ctx, end := s.start(ctx, "UpdateRelationship")
+	defer func() { end(err) }()
+	return s.store.UpdateRelationship(ctx, relationshipID, confidence, factors)
+}
+
+// DeleteRelationship deletes a relationship in a span
+func (s *TracedVectorStore) DeleteRelationship(ctx context.Context, relationshipID string) (err error) {
+	ctx, end := s.start(ctx, "DeleteRelationship")
+	defer func() { end(err) }()
+	return s.store.DeleteRelationship(ctx, relationshipID)
+}
+
+// GetRelationshipByID gets a relationship by ID in a span
+func (s *TracedVectorStore) GetRelationshipByID(ctx context.Context, relationshipID string) (relationship *types.MemoryRelationship, err error) {
+	ctx, end := s.start(ctx, "GetRelationshipByID")
+	defer func() { end(err) }()
+	return s.store.GetRelationshipByID(ctx, relationshipID)
+}
+
+// Close closes the wrapped store
+func (s *TracedVectorStore) Close() error {
+	return s.store.Close()
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"lerian-mcp-memory/internal/retry"
+	"lerian-mcp-memory/pkg/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracedVectorStoreSpansEachAttempt(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	mockStore := new(MockVectorStore)
+	mockStore.On("Store", mock.Anything, mock.Anything).Return(errors.New("connection refused")).Once()
+	mockStore.On("Store", mock.Anything, mock.Anything).Return(nil).Once()
+	store := NewRetryableVectorStore(NewTracedVectorStore(mockStore, "qdrant"), &retry.Config{
+		MaxAttempts:  3,
+		InitialDelay: time.Millisecond,
+		MaxDelay:     10 * time.Millisecond,
+		Multiplier:   2.0,
+		RetryIf:      func(error) bool { return true },
+	})
+
+	chunk := &types.ConversationChunk{ID: "traced", Metadata: types.ChunkMetadata{Repository: "github.com/acme/api"}}
+	require.NoError(t, store.Store(context.Background(), chunk))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	failed, succeeded, retried := spans[0], spans[1], spans[2]
+	assert.Equal(t, "vectorstore.Store", failed.Name())
+	assert.Equal(t, codes.Error, failed.Status().Code)
+	assert.Equal(t, "vectorstore.Store", succeeded.Name())
+	assert.Equal(t, codes.Unset, succeeded.Status().Code)
+	assert.Equal(t, "retry", retried.Name())
+	assert.Len(t, retried.Events(), 1, "the failed attempt is an event on the retry span")
+
+	for _, attempt := range []sdktrace.ReadOnlySpan{failed, succeeded} {
+		assert.Equal(t, retried.SpanContext().SpanID(), attempt.Parent().SpanID())
+		assert.Contains(t, attempt.Attributes(), repositoryAttr("github.com/acme/api"))
+	}
+}
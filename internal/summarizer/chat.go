@@ -0,0 +1,93 @@
+package summarizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"lerian-mcp-memory/internal/config"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultOpenAISummaryModel = "gpt-4o-mini"
+	defaultOllamaBaseURL      = "http://localhost:11434/v1"
+	defaultOllamaSummaryModel = "llama3.2"
+)
+
+// ChatProvider completes prompts with an OpenAI-compatible chat completions
+// endpoint: OpenAI, servers such as vLLM or LM Studio, or Ollama's /v1 API
+type ChatProvider struct {
+	client      *openai.Client
+	model       string
+	temperature float32
+}
+
+// NewChatProvider creates the chat provider selected by cfg.Summarizer
+func NewChatProvider(cfg *config.Config) (*ChatProvider, error) {
+	settings := cfg.Summarizer
+	apiKey, baseURL, model := settings.APIKey, settings.BaseURL, settings.Model
+
+	switch settings.Provider {
+	case config.SummarizerProviderOpenAI:
+		if apiKey == "" {
+			apiKey = cfg.OpenAI.APIKey
+		}
+		if apiKey == "" {
+			return nil, errors.New("API key is required")
+		}
+		if model == "" {
+			model = defaultOpenAISummaryModel
+		}
+	case config.SummarizerProviderOllama:
+		if baseURL == "" {
+			baseURL = defaultOllamaBaseURL
+		}
+		if model == "" {
+			model = defaultOllamaSummaryModel
+		}
+	case config.SummarizerProviderOpenAICompatible:
+		if baseURL == "" || model == "" {
+			return nil, errors.New("base URL and model are required")
+		}
+	default:
+		return nil, fmt.Errorf("%s is not a chat provider", settings.Provider)
+	}
+
+	clientConfig := openai.DefaultConfig(apiKey)
+	if baseURL != "" {
+		clientConfig.BaseURL = strings.TrimRight(baseURL, "/")
+	}
+	return &ChatProvider{
+		client:      openai.NewClientWithConfig(clientConfig),
+		model:       model,
+		temperature: float32(settings.Temperature),
+	}, nil
+}
+
+// Complete sends a system and a user message and returns the first choice
+func (p *ChatProvider) Complete(ctx context.Context, system, prompt string, maxTokens int) (string, error) {
+	resp, err := p.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: p.model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: system},
+			{Role: openai.ChatMessageRoleUser, Content: prompt},
+		},
+		MaxTokens:   maxTokens,
+		Temperature: p.temperature,
+	})
+	if err != nil {
+		return "", fmt.Errorf("chat completion failed: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("chat completion returned no choices")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// Model returns the model name
+func (p *ChatProvider) Model() string {
+	return p.model
+}
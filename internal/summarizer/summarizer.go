@@ -0,0 +1,316 @@
+// Package summarizer writes abstractive summaries of chunks, sessions and
+// decayed memories with a language model, within a token budget and with a
+// cache. Callers keep their extractive summaries as the fallback.
+package summarizer
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/tracing"
+	"lerian-mcp-memory/pkg/types"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Kind is what a summary is written for; each has its own prompt
+type Kind string
+
+const (
+	// KindChunk summarizes a single chunk in a sentence or two
+	KindChunk Kind = "chunk"
+	// KindSession summarizes a whole conversation session
+	KindSession Kind = "session"
+	// KindDecay condenses aging memories that replace their originals
+	KindDecay Kind = "decay"
+)
+
+const (
+	// chunkOutputTokens caps chunk summaries, which are shown in lists
+	chunkOutputTokens = 80
+	// failureCooldown is how long a failed provider is skipped, so every
+	// store does not wait for the same timeout
+	failureCooldown = time.Minute
+	// charsPerToken approximates tokens for budgeting without a tokenizer
+	charsPerToken = 4
+)
+
+// ErrUnavailable is returned when no provider is configured or the provider
+// failed; callers fall back to their extractive summaries
+var ErrUnavailable = errors.New("summarizer unavailable")
+
+// prompts are the system prompts for each kind
+var prompts = map[Kind]string{
+	KindChunk: "You summarize excerpts of software development conversations for a memory index. " +
+		"Reply with one or two plain sentences stating the problem, decision or result and the key technical names. " +
+		"Do not add preamble, markdown or details that are not in the text.",
+	KindSession: "You summarize a software development session from its chunks, in order. " +
+		"Reply with a short paragraph covering the goal, what was tried, what worked, decisions made and open issues. " +
+		"Keep file, function and tool names. Do not invent details.",
+	KindDecay: "You condense older memories of a software project that will replace the originals. " +
+		"Keep every decision, solution, error signature and lesson learned, with file and function names; drop chatter. " +
+		"Reply with a compact paragraph or short bullet list. Do not invent details.",
+}
+
+// Provider completes a prompt with a language model
+type Provider interface {
+	// Complete returns the model's reply to prompt under the system
+	// instructions, using at most maxTokens tokens
+	Complete(ctx context.Context, system, prompt string, maxTokens int) (string, error)
+
+	// Model returns the model name, part of the cache key
+	Model() string
+}
+
+// Service writes summaries with a provider. A nil *Service, or one without
+// a provider, returns ErrUnavailable.
+type Service struct {
+	provider Provider
+	config   config.SummarizerConfig
+
+	mu          sync.Mutex
+	cache       map[string]*list.Element
+	order       *list.List
+	unavailable time.Time // the provider is skipped until then
+}
+
+// cacheEntry is a cached summary
+type cacheEntry struct {
+	key     string
+	summary string
+}
+
+// NewService creates a summarization service over provider
+func NewService(provider Provider, cfg *config.SummarizerConfig) *Service {
+	return &Service{
+		provider: provider,
+		config:   *cfg,
+		cache:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// NewFromConfig creates the service for cfg.Summarizer. The none provider
+// gives a service that always falls back.
+func NewFromConfig(cfg *config.Config) (*Service, error) {
+	switch cfg.Summarizer.Provider {
+	case config.SummarizerProviderNone, "":
+		return NewService(nil, &cfg.Summarizer), nil
+	case config.SummarizerProviderOpenAI, config.SummarizerProviderOpenAICompatible, config.SummarizerProviderOllama:
+		provider, err := NewChatProvider(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s summarizer: %w", cfg.Summarizer.Provider, err)
+		}
+		return NewService(provider, &cfg.Summarizer), nil
+	default:
+		return nil, fmt.Errorf("unknown summarizer provider: %s", cfg.Summarizer.Provider)
+	}
+}
+
+// Available reports whether summaries are written by a provider
+func (s *Service) Available() bool {
+	return s != nil && s.provider != nil
+}
+
+// Summarize returns a summary of text. Text over the input budget is cut.
+func (s *Service) Summarize(ctx context.Context, kind Kind, text string) (string, error) {
+	if !s.Available() {
+		return "", ErrUnavailable
+	}
+	text = TruncateToTokens(strings.TrimSpace(text), s.config.MaxInputTokens)
+	if text == "" {
+		return "", errors.New("nothing to summarize")
+	}
+
+	key := s.cacheKey(kind, text)
+	if summary, ok := s.cached(key); ok {
+		return summary, nil
+	}
+
+	s.mu.Lock()
+	coolingDown := time.Now().Before(s.unavailable)
+	s.mu.Unlock()
+	if coolingDown {
+		return "", ErrUnavailable
+	}
+
+	summary, err := s.complete(ctx, kind, text)
+	if err != nil {
+		// A caller that gave up says nothing about the provider
+		if !callerGaveUp(ctx, err) {
+			s.mu.Lock()
+			s.unavailable = time.Now().Add(failureCooldown)
+			s.mu.Unlock()
+		}
+		return "", fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+
+	s.store(key, summary)
+	return summary, nil
+}
+
+// callerGaveUp reports whether err comes from ctx being cancelled or
+// reaching its deadline, rather than from the provider
+func callerGaveUp(ctx context.Context, err error) bool {
+	return ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded))
+}
+
+// SummarizeChunks summarizes chunks in chronological order. Each chunk gets
+// an even share of the input budget left after the headers, so long chunks
+// cannot crowd out the rest and the last chunks are not cut off.
+func (s *Service) SummarizeChunks(ctx context.Context, kind Kind, chunks []types.ConversationChunk) (string, error) {
+	if !s.Available() {
+		return "", ErrUnavailable
+	}
+	if len(chunks) == 0 {
+		return "", errors.New("no chunks to summarize")
+	}
+
+	ordered := make([]*types.ConversationChunk, len(chunks))
+	for i := range chunks {
+		ordered[i] = &chunks[i]
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Timestamp.Before(ordered[j].Timestamp)
+	})
+
+	headers := make([]string, len(ordered))
+	budget := s.config.MaxInputTokens
+	for i, chunk := range ordered {
+		headers[i] = fmt.Sprintf("[%d] %s", i+1, chunk.Type)
+		if chunk.Metadata.Outcome != "" {
+			headers[i] += ", " + string(chunk.Metadata.Outcome)
+		}
+		if !chunk.Timestamp.IsZero() {
+			headers[i] += ", " + chunk.Timestamp.UTC().Format(time.RFC3339)
+		}
+		// The header, the separators and the truncation mark
+		budget -= EstimateTokens(headers[i] + "\n\n\n …")
+	}
+
+	// Shares are never raised above what fits, or the final truncation
+	// would drop the last chunks entirely
+	perChunk := budget / len(ordered)
+	if perChunk < 1 {
+		perChunk = 1
+	}
+
+	var text strings.Builder
+	for i, chunk := range ordered {
+		text.WriteString(headers[i])
+		text.WriteString("\n")
+		text.WriteString(TruncateToTokens(strings.TrimSpace(chunk.Content), perChunk))
+		text.WriteString("\n\n")
+	}
+	return s.Summarize(ctx, kind, text.String())
+}
+
+// complete asks the provider for a summary in a span
+func (s *Service) complete(ctx context.Context, kind Kind, text string) (summary string, err error) {
+	maxTokens := s.config.MaxOutputTokens
+	if kind == KindChunk && maxTokens > chunkOutputTokens {
+		maxTokens = chunkOutputTokens
+	}
+
+	ctx, span := tracing.Start(ctx, "summarizer.Summarize",
+		attribute.String("summarizer.kind", string(kind)),
+		attribute.String("summarizer.model", s.provider.Model()),
+		attribute.Int("summarizer.input_tokens", EstimateTokens(text)))
+	defer func() { tracing.End(span, err) }()
+
+	if s.config.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.config.RequestTimeout)*time.Second)
+		defer cancel()
+	}
+
+	reply, err := s.provider.Complete(ctx, prompts[kind], text, maxTokens)
+	if err != nil {
+		return "", err
+	}
+	summary = cleanSummary(reply)
+	if summary == "" {
+		return "", errors.New("provider returned an empty summary")
+	}
+	return summary, nil
+}
+
+// cacheKey identifies a summary by kind, model and input
+func (s *Service) cacheKey(kind Kind, text string) string {
+	hash := sha256.Sum256([]byte(string(kind) + "\x00" + s.provider.Model() + "\x00" + text))
+	return hex.EncodeToString(hash[:])
+}
+
+// cached returns a cached summary and marks it recently used
+func (s *Service) cached(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	element, ok := s.cache[key]
+	if !ok {
+		return "", false
+	}
+	s.order.MoveToFront(element)
+	return element.Value.(*cacheEntry).summary, true
+}
+
+// store caches a summary, evicting the least recently used past CacheSize
+func (s *Service) store(key, summary string) {
+	if s.config.CacheSize <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if element, ok := s.cache[key]; ok {
+		element.Value.(*cacheEntry).summary = summary
+		s.order.MoveToFront(element)
+		return
+	}
+	s.cache[key] = s.order.PushFront(&cacheEntry{key: key, summary: summary})
+	for s.order.Len() > s.config.CacheSize {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.cache, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// EstimateTokens approximates the number of tokens in text
+func EstimateTokens(text string) int {
+	return (len([]rune(text)) + charsPerToken - 1) / charsPerToken
+}
+
+// TruncateToTokens cuts text to about maxTokens tokens, at a word boundary
+// where there is one near the cut
+func TruncateToTokens(text string, maxTokens int) string {
+	runes := []rune(text)
+	limit := maxTokens * charsPerToken
+	if maxTokens <= 0 || len(runes) <= limit {
+		return text
+	}
+	cut := string(runes[:limit])
+	if i := strings.LastIndexAny(cut, " \n\t"); i > len(cut)*3/4 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut) + " …"
+}
+
+// cleanSummary trims whitespace, wrapping quotes and a "Summary:" label
+// that models add despite the prompt
+func cleanSummary(reply string) string {
+	summary := strings.TrimSpace(reply)
+	for _, label := range []string{"Summary:", "summary:", "**Summary:**"} {
+		summary = strings.TrimSpace(strings.TrimPrefix(summary, label))
+	}
+	if len(summary) >= 2 && summary[0] == '"' && summary[len(summary)-1] == '"' {
+		summary = strings.TrimSpace(summary[1 : len(summary)-1])
+	}
+	return summary
+}
@@ -0,0 +1,223 @@
+package summarizer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider records prompts and replies with a fixed summary or error
+type fakeProvider struct {
+	mu        sync.Mutex
+	reply     string
+	err       error
+	prompts   []string
+	maxTokens []int
+}
+
+func (p *fakeProvider) Complete(_ context.Context, _, prompt string, maxTokens int) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prompts = append(p.prompts, prompt)
+	p.maxTokens = append(p.maxTokens, maxTokens)
+	return p.reply, p.err
+}
+
+func (p *fakeProvider) Model() string { return "fake" }
+
+func (p *fakeProvider) calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.prompts)
+}
+
+func defaultSettings() *config.SummarizerConfig {
+	cfg := config.DefaultConfig().Summarizer
+	return &cfg
+}
+
+func TestSummarizeCachesAndCleans(t *testing.T) {
+	provider := &fakeProvider{reply: "  Summary: \"Fixed the retry loop in the webhook sender.\"  "}
+	service := NewService(provider, defaultSettings())
+	ctx := context.Background()
+
+	summary, err := service.Summarize(ctx, KindChunk, "We fixed the retry loop")
+	require.NoError(t, err)
+	assert.Equal(t, "Fixed the retry loop in the webhook sender.", summary)
+	assert.Equal(t, []int{chunkOutputTokens}, provider.maxTokens, "chunk summaries stay short")
+
+	_, err = service.Summarize(ctx, KindChunk, "We fixed the retry loop")
+	require.NoError(t, err)
+	assert.Equal(t, 1, provider.calls(), "the second call is served from the cache")
+
+	_, err = service.Summarize(ctx, KindSession, "We fixed the retry loop")
+	require.NoError(t, err)
+	assert.Equal(t, 2, provider.calls(), "kinds are cached separately")
+	assert.Equal(t, defaultSettings().MaxOutputTokens, provider.maxTokens[1])
+}
+
+func TestSummarizeCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	provider := &fakeProvider{reply: "summary"}
+	settings := defaultSettings()
+	settings.CacheSize = 2
+	service := NewService(provider, settings)
+	ctx := context.Background()
+
+	for _, text := range []string{"a", "b", "a", "c", "a", "b"} {
+		_, err := service.Summarize(ctx, KindChunk, text)
+		require.NoError(t, err)
+	}
+	// a, b and c miss once each; b was evicted by c and misses again
+	assert.Equal(t, 4, provider.calls())
+}
+
+func TestSummarizeUnavailable(t *testing.T) {
+	ctx := context.Background()
+
+	var none *Service
+	_, err := none.Summarize(ctx, KindChunk, "text")
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	service, err := NewFromConfig(config.DefaultConfig())
+	require.NoError(t, err)
+	assert.False(t, service.Available(), "the default provider is none")
+	_, err = service.SummarizeChunks(ctx, KindSession, []types.ConversationChunk{{Content: "text"}})
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	provider := &fakeProvider{err: errors.New("connection refused")}
+	service = NewService(provider, defaultSettings())
+	_, err = service.Summarize(ctx, KindChunk, "first")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	_, err = service.Summarize(ctx, KindChunk, "second")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, 1, provider.calls(), "a failed provider is skipped while it cools down")
+}
+
+func TestSummarizeCancelledCallerSkipsCooldown(t *testing.T) {
+	provider := &fakeProvider{err: context.Canceled}
+	service := NewService(provider, defaultSettings())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := service.Summarize(ctx, KindChunk, "first")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	provider.mu.Lock()
+	provider.err, provider.reply = nil, "summary"
+	provider.mu.Unlock()
+	summary, err := service.Summarize(context.Background(), KindChunk, "second")
+	require.NoError(t, err)
+	assert.Equal(t, "summary", summary)
+
+	// A timeout the caller did not set is the provider's fault
+	provider.mu.Lock()
+	provider.err = context.DeadlineExceeded
+	provider.mu.Unlock()
+	_, err = service.Summarize(context.Background(), KindChunk, "third")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	_, err = service.Summarize(context.Background(), KindChunk, "fourth")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, 3, provider.calls(), "the provider cools down after its own timeout")
+}
+
+func TestSummarizeChunksKeepsEveryChunk(t *testing.T) {
+	provider := &fakeProvider{reply: "Session summary"}
+	settings := defaultSettings()
+	settings.MaxInputTokens = 600
+	service := NewService(provider, settings)
+
+	now := time.Now()
+	chunks := make([]types.ConversationChunk, 20)
+	for i := range chunks {
+		chunks[i] = types.ConversationChunk{
+			Type:      types.ChunkTypeDiscussion,
+			Content:   strings.Repeat("detail ", 100),
+			Timestamp: now.Add(time.Duration(i) * time.Minute),
+		}
+	}
+	_, err := service.SummarizeChunks(context.Background(), KindSession, chunks)
+	require.NoError(t, err)
+
+	prompt := provider.prompts[0]
+	assert.Contains(t, prompt, "[20] discussion", "the last chunk is not truncated away")
+	assert.LessOrEqual(t, EstimateTokens(prompt), settings.MaxInputTokens)
+}
+
+func TestSummarizeChunksBudget(t *testing.T) {
+	provider := &fakeProvider{reply: "Session summary"}
+	settings := defaultSettings()
+	settings.MaxInputTokens = 200
+	service := NewService(provider, settings)
+
+	now := time.Now()
+	chunks := []types.ConversationChunk{
+		{Type: types.ChunkTypeSolution, Content: "Second: " + strings.Repeat("solution detail ", 200), Timestamp: now},
+		{Type: types.ChunkTypeProblem, Content: "First: the build fails on arm64", Timestamp: now.Add(-time.Hour)},
+	}
+	summary, err := service.SummarizeChunks(context.Background(), KindSession, chunks)
+	require.NoError(t, err)
+	assert.Equal(t, "Session summary", summary)
+
+	prompt := provider.prompts[0]
+	assert.Less(t, strings.Index(prompt, "First:"), strings.Index(prompt, "Second:"), "chunks are in time order")
+	assert.Contains(t, prompt, "[1] problem")
+	assert.LessOrEqual(t, EstimateTokens(prompt), settings.MaxInputTokens+10)
+	assert.Equal(t, "Second: ", chunks[0].Content[:8], "chunks are not modified")
+}
+
+func TestTruncateToTokens(t *testing.T) {
+	assert.Equal(t, "short text", TruncateToTokens("short text", 10))
+	truncated := TruncateToTokens(strings.Repeat("word ", 100), 10)
+	assert.LessOrEqual(t, len([]rune(truncated)), 42)
+	assert.True(t, strings.HasSuffix(truncated, "word …"), truncated)
+}
+
+func TestChatProvider(t *testing.T) {
+	var request struct {
+		Model     string `json:"model"`
+		MaxTokens int    `json:"max_tokens"`
+		Messages  []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"Switched the cache to Redis."}}]}`))
+	}))
+	defer server.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Summarizer.Provider = config.SummarizerProviderOllama
+	cfg.Summarizer.BaseURL = server.URL + "/v1/"
+	service, err := NewFromConfig(cfg)
+	require.NoError(t, err)
+	require.True(t, service.Available())
+
+	summary, err := service.Summarize(context.Background(), KindChunk, "We decided to move the cache to Redis")
+	require.NoError(t, err)
+	assert.Equal(t, "Switched the cache to Redis.", summary)
+	assert.Equal(t, defaultOllamaSummaryModel, request.Model)
+	assert.Equal(t, chunkOutputTokens, request.MaxTokens)
+	require.Len(t, request.Messages, 2)
+	assert.Equal(t, "system", request.Messages[0].Role)
+	assert.Equal(t, "We decided to move the cache to Redis", request.Messages[1].Content)
+
+	cfg.Summarizer.Provider = config.SummarizerProviderOpenAI
+	_, err = NewFromConfig(cfg)
+	require.Error(t, err, "openai needs a key")
+}
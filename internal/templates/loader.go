@@ -0,0 +1,151 @@
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"lerian-mcp-memory/pkg/types"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// TemplateSourceBuiltin marks templates that ship with the server
+const TemplateSourceBuiltin = "builtin"
+
+// validFieldTypes are the field types validateFieldType understands
+var validFieldTypes = map[string]bool{
+	"string":  true,
+	"number":  true,
+	"boolean": true,
+	"array":   true,
+	"object":  true,
+}
+
+// LoadTemplatesFromDir loads every *.yaml and *.yml file in dir as a
+// template. Files use the same snake_case keys as the JSON form, and the
+// file name (without extension) is the ID when none is given. A file
+// template replaces a built-in template with the same ID. Invalid files are
+// skipped and reported together in the returned error.
+func (tm *TemplateManager) LoadTemplatesFromDir(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read template directory: %w", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	loaded := 0
+	var errs []error
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		template, err := ParseTemplateYAML(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		tm.mu.Lock()
+		tm.templates[template.ID] = template
+		tm.mu.Unlock()
+		loaded++
+	}
+
+	return loaded, errors.Join(errs...)
+}
+
+// ParseTemplateYAML reads and validates a single template file
+func ParseTemplateYAML(path string) (*MemoryTemplate, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- Path comes from the configured template directory
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %s: %w", path, err)
+	}
+
+	// Decode through JSON so YAML files share the JSON field names and
+	// value conversions
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+	}
+	jsonData, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert template %s: %w", path, err)
+	}
+
+	var template MemoryTemplate
+	if err := json.Unmarshal(jsonData, &template); err != nil {
+		return nil, fmt.Errorf("failed to decode template %s: %w", path, err)
+	}
+
+	if template.ID == "" {
+		template.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if template.Version == "" {
+		template.Version = "1.0"
+	}
+	now := time.Now().UTC()
+	template.CreatedAt = now
+	template.UpdatedAt = now
+	template.UsageCount = 0
+	template.Source = path
+
+	if err := validateTemplateDefinition(&template); err != nil {
+		return nil, fmt.Errorf("invalid template %s: %w", path, err)
+	}
+	return &template, nil
+}
+
+// validateTemplateDefinition checks a template loaded from a file
+func validateTemplateDefinition(template *MemoryTemplate) error {
+	if template.Name == "" {
+		return errors.New("name is required")
+	}
+	if !types.ChunkType(template.ChunkType).Valid() {
+		return fmt.Errorf("invalid chunk_type: %q", template.ChunkType)
+	}
+	if len(template.RequiredFields) == 0 {
+		return errors.New("at least one required field is needed")
+	}
+
+	seen := make(map[string]bool)
+	for _, fields := range [][]TemplateField{template.RequiredFields, template.OptionalFields} {
+		for i := range fields {
+			field := &fields[i]
+			if field.Name == "" {
+				return errors.New("field name cannot be empty")
+			}
+			if seen[field.Name] {
+				return fmt.Errorf("duplicate field: %s", field.Name)
+			}
+			seen[field.Name] = true
+			if !validFieldTypes[field.Type] {
+				return fmt.Errorf("field %s has invalid type %q: must be string, number, boolean, array or object", field.Name, field.Type)
+			}
+			if field.Validation != nil && field.Validation.Pattern != nil {
+				if _, err := regexp.Compile(*field.Validation.Pattern); err != nil {
+					return fmt.Errorf("field %s has invalid pattern: %w", field.Name, err)
+				}
+			}
+		}
+	}
+
+	// Fields listed under required_fields are required
+	for i := range template.RequiredFields {
+		template.RequiredFields[i].Required = true
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"lerian-mcp-memory/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTemplatesFromDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "release_note.yaml"), []byte(`
+name: Release Note
+chunk_type: discussion
+required_fields:
+  - name: version
+    type: string
+    description: Released version
+    validation:
+      pattern: "^v[0-9]+\\.[0-9]+\\.[0-9]+$"
+  - name: channel
+    type: string
+    description: Release channel
+    options: [stable, beta]
+optional_fields:
+  - name: highlights
+    type: array
+    description: Notable changes
+auto_tags: [release]
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.yml"), []byte(`
+name: Broken
+chunk_type: not_a_type
+required_fields:
+  - name: x
+    type: string
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o600))
+
+	tm := NewTemplateManager()
+	builtins := len(tm.ListTemplates())
+
+	loaded, err := tm.LoadTemplatesFromDir(dir)
+	assert.Equal(t, 1, loaded)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "broken.yml")
+	assert.Len(t, tm.ListTemplates(), builtins+1)
+
+	template, err := tm.GetTemplate("release_note")
+	require.NoError(t, err)
+	assert.Equal(t, "Release Note", template.Name)
+	assert.Equal(t, types.ChunkTypeDiscussion, template.ChunkType)
+	assert.Equal(t, "1.0", template.Version)
+	assert.True(t, template.RequiredFields[0].Required)
+	assert.Equal(t, filepath.Join(dir, "release_note.yaml"), template.Source)
+
+	// Options and patterns from the file are enforced
+	result := tm.ValidateInstance("release_note", map[string]interface{}{"version": "1.2", "channel": "nightly"})
+	assert.False(t, result.Valid)
+	codes := make([]string, 0, len(result.Errors))
+	for _, validationErr := range result.Errors {
+		codes = append(codes, validationErr.Code)
+	}
+	assert.ElementsMatch(t, []string{"PATTERN_VIOLATION", "INVALID_OPTION"}, codes)
+
+	metadata := &types.ChunkMetadata{
+		Repository: "github.com/acme/api",
+		Outcome:    types.OutcomeSuccess,
+		Difficulty: types.DifficultySimple,
+	}
+	chunk, err := tm.CreateChunkFromTemplate("release_note", "session-1", map[string]interface{}{
+		"version":    "v1.2.0",
+		"channel":    "stable",
+		"highlights": []interface{}{"faster search"},
+	}, metadata)
+	require.NoError(t, err)
+	assert.Equal(t, types.ChunkTypeDiscussion, chunk.Type)
+	assert.Contains(t, chunk.Content, "v1.2.0")
+	assert.Contains(t, chunk.Metadata.Tags, "release")
+
+	template, err = tm.GetTemplate("release_note")
+	require.NoError(t, err)
+	assert.Equal(t, 1, template.UsageCount)
+}
+
+func TestExampleTemplatesAreValid(t *testing.T) {
+	tm := NewTemplateManager()
+	loaded, err := tm.LoadTemplatesFromDir(filepath.Join("..", "..", "configs", "templates"))
+	require.NoError(t, err)
+	assert.Positive(t, loaded)
+}
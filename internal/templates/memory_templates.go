@@ -6,7 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"lerian-mcp-memory/pkg/types"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -45,6 +48,7 @@ type MemoryTemplate struct {
 	CreatedAt       time.Time              `json:"created_at"`
 	UpdatedAt       time.Time              `json:"updated_at"`
 	UsageCount      int                    `json:"usage_count"`
+	Source          string                 `json:"source,omitempty"` // "builtin" or the YAML file it came from
 }
 
 // TemplateInstance represents a completed template with user data
@@ -78,6 +82,7 @@ type ValidationWarning struct {
 
 // TemplateManager manages memory templates
 type TemplateManager struct {
+	mu        sync.RWMutex
 	templates map[string]*MemoryTemplate
 }
 
@@ -107,25 +112,37 @@ func (tm *TemplateManager) loadBuiltinTemplates() {
 	}
 
 	for _, template := range builtinTemplates {
+		template.Source = TemplateSourceBuiltin
 		tm.templates[template.ID] = template
 	}
 }
 
-// GetTemplate retrieves a template by ID
+// GetTemplate retrieves a copy of a template by ID
 func (tm *TemplateManager) GetTemplate(id string) (*MemoryTemplate, error) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
 	template, exists := tm.templates[id]
 	if !exists {
 		return nil, fmt.Errorf("template not found: %s", id)
 	}
-	return template, nil
+	templateCopy := *template
+	return &templateCopy, nil
 }
 
-// ListTemplates returns all available templates
+// ListTemplates returns copies of all available templates ordered by ID
 func (tm *TemplateManager) ListTemplates() []*MemoryTemplate {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
 	templates := make([]*MemoryTemplate, 0, len(tm.templates))
 	for _, template := range tm.templates {
-		templates = append(templates, template)
+		templateCopy := *template
+		templates = append(templates, &templateCopy)
 	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].ID < templates[j].ID
+	})
 	return templates
 }
 
@@ -185,6 +202,9 @@ func (tm *TemplateManager) ValidateInstance(templateID string, fields map[string
 		}
 	}
 
+	// Rule violations on any field make the instance invalid
+	result.Valid = len(result.Errors) == 0
+
 	return result
 }
 
@@ -234,8 +254,12 @@ func (tm *TemplateManager) CreateChunkFromTemplate(templateID, sessionID string,
 	}
 
 	// Update template usage count
-	template.UsageCount++
-	template.UpdatedAt = time.Now().UTC()
+	tm.mu.Lock()
+	if stored, exists := tm.templates[templateID]; exists {
+		stored.UsageCount++
+		stored.UpdatedAt = time.Now().UTC()
+	}
+	tm.mu.Unlock()
 
 	return chunk, nil
 }
@@ -262,6 +286,24 @@ func (tm *TemplateManager) validateField(field *TemplateField, value interface{}
 		return err
 	}
 
+	// Enum-like fields only accept their listed options
+	if strValue, ok := value.(string); ok && len(field.Options) > 0 {
+		allowed := false
+		for _, option := range field.Options {
+			if strValue == option {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   field.Name,
+				Message: "Field must be one of: " + strings.Join(field.Options, ", "),
+				Code:    "INVALID_OPTION",
+			})
+		}
+	}
+
 	// Custom validation rules
 	if field.Validation != nil {
 		tm.validateFieldRules(field, value, result)
@@ -348,6 +390,19 @@ func (tm *TemplateManager) validateFieldRules(field *TemplateField, value interf
 		}
 	}
 
+	// Pattern and option validation
+	if strValue, ok := value.(string); ok {
+		if validation.Pattern != nil {
+			if matched, err := regexp.MatchString(*validation.Pattern, strValue); err != nil || !matched {
+				result.Errors = append(result.Errors, ValidationError{
+					Field:   field.Name,
+					Message: fmt.Sprintf("Field must match pattern %s", *validation.Pattern),
+					Code:    "PATTERN_VIOLATION",
+				})
+			}
+		}
+	}
+
 	// Number range validation
 	if numValue, ok := tm.getNumericValue(value); ok {
 		if validation.Min != nil && numValue < *validation.Min {
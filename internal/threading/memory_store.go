@@ -157,34 +157,40 @@ func (s *InMemoryThreadStore) ListThreads(ctx context.Context, filters ThreadFil
 
 // matchesFilters checks if a thread matches the given filters
 func (s *InMemoryThreadStore) matchesFilters(thread *MemoryThread, filters ThreadFilters) bool {
-	return s.matchesBasicFilters(thread, filters) &&
-		s.matchesSessionFilter(thread, filters) &&
-		s.matchesTagsFilter(thread, filters) &&
-		s.matchesTimeFilters(thread, filters)
+	return filters.Matches(thread)
+}
+
+// Matches reports whether a thread satisfies every filter. Durable thread
+// stores use it so all implementations filter the same way.
+func (f ThreadFilters) Matches(thread *MemoryThread) bool {
+	return f.matchesBasicFilters(thread) &&
+		f.matchesSessionFilter(thread) &&
+		f.matchesTagsFilter(thread) &&
+		f.matchesTimeFilters(thread)
 }
 
 // matchesBasicFilters checks basic string filters
-func (s *InMemoryThreadStore) matchesBasicFilters(thread *MemoryThread, filters ThreadFilters) bool {
-	if filters.Repository != nil && thread.Repository != *filters.Repository {
+func (f ThreadFilters) matchesBasicFilters(thread *MemoryThread) bool {
+	if f.Repository != nil && thread.Repository != *f.Repository {
 		return false
 	}
-	if filters.Type != nil && thread.Type != *filters.Type {
+	if f.Type != nil && thread.Type != *f.Type {
 		return false
 	}
-	if filters.Status != nil && thread.Status != *filters.Status {
+	if f.Status != nil && thread.Status != *f.Status {
 		return false
 	}
 	return true
 }
 
 // matchesSessionFilter checks if thread matches session ID filter
-func (s *InMemoryThreadStore) matchesSessionFilter(thread *MemoryThread, filters ThreadFilters) bool {
-	if filters.SessionID == nil {
+func (f ThreadFilters) matchesSessionFilter(thread *MemoryThread) bool {
+	if f.SessionID == nil {
 		return true
 	}
 
 	for _, sessionID := range thread.SessionIDs {
-		if sessionID == *filters.SessionID {
+		if sessionID == *f.SessionID {
 			return true
 		}
 	}
@@ -192,8 +198,8 @@ func (s *InMemoryThreadStore) matchesSessionFilter(thread *MemoryThread, filters
 }
 
 // matchesTagsFilter checks if thread has all required tags
-func (s *InMemoryThreadStore) matchesTagsFilter(thread *MemoryThread, filters ThreadFilters) bool {
-	if len(filters.Tags) == 0 {
+func (f ThreadFilters) matchesTagsFilter(thread *MemoryThread) bool {
+	if len(f.Tags) == 0 {
 		return true
 	}
 
@@ -202,7 +208,7 @@ func (s *InMemoryThreadStore) matchesTagsFilter(thread *MemoryThread, filters Th
 		threadTagSet[tag] = true
 	}
 
-	for _, requiredTag := range filters.Tags {
+	for _, requiredTag := range f.Tags {
 		if !threadTagSet[requiredTag] {
 			return false
 		}
@@ -211,11 +217,11 @@ func (s *InMemoryThreadStore) matchesTagsFilter(thread *MemoryThread, filters Th
 }
 
 // matchesTimeFilters checks time range filters
-func (s *InMemoryThreadStore) matchesTimeFilters(thread *MemoryThread, filters ThreadFilters) bool {
-	if filters.Since != nil && thread.LastUpdate.Before(*filters.Since) {
+func (f ThreadFilters) matchesTimeFilters(thread *MemoryThread) bool {
+	if f.Since != nil && thread.LastUpdate.Before(*f.Since) {
 		return false
 	}
-	if filters.Until != nil && thread.StartTime.After(*filters.Until) {
+	if f.Until != nil && thread.StartTime.After(*f.Until) {
 		return false
 	}
 	return true
@@ -0,0 +1,89 @@
+// Package tracing creates OpenTelemetry spans for tool calls, embedding
+// requests and storage operations, and exports them over OTLP/HTTP.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"lerian-mcp-memory/internal/config"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans this server creates
+const tracerName = "lerian-mcp-memory"
+
+// Attribute keys shared by spans. Repository and operation match the
+// labels on /metrics.
+const (
+	AttrTool       = attribute.Key("mcp.tool")
+	AttrOperation  = attribute.Key("mcp.operation")
+	AttrRepository = attribute.Key("memory.repository")
+)
+
+// Setup installs the global tracer provider and W3C trace context
+// propagation. When tracing is disabled it installs nothing, so spans are
+// no-ops. The returned function flushes pending spans and stops the
+// exporter.
+func Setup(ctx context.Context, cfg *config.TracingConfig) (func(context.Context) error, error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var options []otlptracehttp.Option
+	if cfg.Endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// Start starts a span as a child of the span in ctx, if any. When tracing
+// is off the span carries no trace and ctx is returned unchanged.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	spanCtx, span := otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+	if !span.SpanContext().IsValid() {
+		return ctx, span
+	}
+	return spanCtx, span
+}
+
+// End marks the span failed when err is set, then ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Extract continues the trace named in W3C traceparent headers
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
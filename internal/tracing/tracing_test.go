@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"lerian-mcp-memory/internal/config"
+	"lerian-mcp-memory/internal/logging"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), &config.TracingConfig{Enabled: false})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+
+	// Without a provider spans are no-ops and carry no trace
+	ctx, span := Start(context.Background(), "noop")
+	End(span, nil)
+	assert.False(t, span.SpanContext().IsValid())
+	assert.Empty(t, logging.GetTraceID(ctx))
+}
+
+func TestSetupEnabled(t *testing.T) {
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	cfg := config.DefaultConfig().Tracing
+	cfg.Enabled = true
+	cfg.Endpoint = "http://127.0.0.1:4318"
+	shutdown, err := Setup(context.Background(), &cfg)
+	require.NoError(t, err)
+
+	_, span := Start(context.Background(), "sampled")
+	assert.True(t, span.SpanContext().IsSampled())
+	span.End()
+
+	// Nothing listens on the endpoint; shutdown gives up on the export
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_ = shutdown(ctx)
+}
+
+func TestEndRecordsError(t *testing.T) {
+	recorder := recordSpans(t)
+
+	ctx, span := Start(context.Background(), "failing", AttrTool.String("memory_read"))
+	assert.Equal(t, span.SpanContext().TraceID().String(), logging.GetTraceID(ctx), "logs and audit events carry the trace ID")
+	End(span, errors.New("qdrant unavailable"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "qdrant unavailable", spans[0].Status().Description)
+	assert.Contains(t, spans[0].Attributes(), AttrTool.String("memory_read"))
+}
+
+func TestExtractContinuesCallerTrace(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+	recorder := recordSpans(t)
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	_, span := Start(Extract(context.Background(), header), "memory_read search")
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}
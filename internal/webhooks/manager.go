@@ -0,0 +1,604 @@
+// Package webhooks pushes memory events to external HTTP endpoints. Each
+// subscription filters events by repository and event type, payloads are
+// signed with the subscription's secret, and undelivered events are kept on
+// disk and retried with backoff until they succeed or move to a dead-letter
+// list.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"lerian-mcp-memory/internal/retry"
+	"lerian-mcp-memory/internal/websocket"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultMaxAttempts is how often a delivery is tried before it moves to
+	// the dead-letter list
+	DefaultMaxAttempts = 8
+
+	// DefaultPollInterval is how often the queue is checked for due retries
+	DefaultPollInterval = time.Second
+
+	// DefaultTimeout bounds a single delivery request
+	DefaultTimeout = 10 * time.Second
+
+	// DefaultWorkers is the number of deliveries sent at the same time
+	DefaultWorkers = 4
+
+	stateFileName       = "webhooks.json"
+	maxQueuedDeliveries = 10000
+	maxDeadLetters      = 1000
+	maxDrainBytes       = 64 * 1024
+	userAgent           = "lerian-mcp-memory-webhooks"
+)
+
+var (
+	// ErrSubscriptionNotFound is returned for unknown subscription IDs
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+
+	// ErrDeliveryNotFound is returned for unknown dead-letter IDs
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// Subscription is a registered endpoint. Empty filters match every event.
+type Subscription struct {
+	ID           string    `json:"id"`
+	URL          string    `json:"url"`
+	Secret       string    `json:"secret,omitempty"`
+	Repositories []string  `json:"repositories,omitempty"`
+	EventTypes   []string  `json:"event_types,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	CreatedBy    string    `json:"created_by,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Matches applies the same filters WebSocket subscriptions use
+func (s *Subscription) Matches(event *websocket.MemoryEvent) bool {
+	filter := websocket.Subscription{Repositories: s.Repositories, EventTypes: s.EventTypes}
+	return filter.Matches(event)
+}
+
+// Delivery is one event queued for one subscription. Event holds only the
+// IDs and metadata, which is all that reaches the state file; the full
+// payload stays in memory until the delivery completes.
+type Delivery struct {
+	ID             string                `json:"id"`
+	SubscriptionID string                `json:"subscription_id"`
+	Event          websocket.MemoryEvent `json:"event"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	LastStatus     int                   `json:"last_status,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	FailedAt       *time.Time            `json:"failed_at,omitempty"`
+
+	payload *websocket.MemoryEvent
+}
+
+// Config configures a Manager
+type Config struct {
+	// StateDir holds the subscriptions, the delivery queue and the
+	// dead-letter list
+	StateDir string
+
+	// Retry sets the attempts and backoff between them; its RetryIf sees
+	// *retry.PermanentError for responses that will not succeed on retry
+	Retry *retry.Config
+
+	// Client sends deliveries; defaults to one with DefaultTimeout
+	Client *http.Client
+
+	// Resolve rebuilds the payload of a delivery restored from the state
+	// file, which keeps only the event's IDs and metadata. Without it such
+	// deliveries are sent with the metadata alone.
+	Resolve func(ctx context.Context, event *websocket.MemoryEvent)
+
+	PollInterval time.Duration
+	Workers      int
+	Logger       *log.Logger
+}
+
+// Manager keeps webhook subscriptions and delivers events to them
+type Manager struct {
+	config  Config
+	retrier *retry.Retrier
+	logger  *log.Logger
+	wake    chan struct{}
+	wg      sync.WaitGroup
+
+	mu            sync.Mutex
+	subscriptions map[string]*Subscription
+	queue         []*Delivery
+	deadLetters   []*Delivery
+	inFlight      map[string]bool
+	dirty         bool // the queue changed since the state file was written
+	version       uint64
+
+	saveMu sync.Mutex
+	saved  uint64
+}
+
+// state is the on-disk form of a Manager
+type state struct {
+	Subscriptions []*Subscription `json:"subscriptions"`
+	Queue         []*Delivery     `json:"queue"`
+	DeadLetters   []*Delivery     `json:"dead_letters"`
+}
+
+// NewManager creates a webhook manager. Call Load before Run to pick up the
+// state left by a previous run.
+func NewManager(cfg Config) *Manager {
+	if cfg.Retry == nil {
+		cfg.Retry = retry.NewConfigWithOptions(
+			retry.WithMaxAttempts(DefaultMaxAttempts),
+			retry.WithDelay(5*time.Second),
+			retry.WithMaxDelay(time.Hour),
+		)
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: DefaultTimeout}
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = log.New(log.Writer(), "[Webhooks] ", log.LstdFlags)
+	}
+
+	return &Manager{
+		config:        cfg,
+		retrier:       retry.New(cfg.Retry),
+		logger:        logger,
+		wake:          make(chan struct{}, 1),
+		subscriptions: make(map[string]*Subscription),
+		inFlight:      make(map[string]bool),
+	}
+}
+
+// Load reads the saved subscriptions, queue and dead letters
+func (m *Manager) Load() error {
+	data, err := os.ReadFile(m.statePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read webhook state: %w", err)
+	}
+
+	var saved state
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to decode webhook state: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscriptions = make(map[string]*Subscription, len(saved.Subscriptions))
+	for _, sub := range saved.Subscriptions {
+		m.subscriptions[sub.ID] = sub
+	}
+	m.queue = saved.Queue
+	m.deadLetters = saved.DeadLetters
+	return nil
+}
+
+// Subscribe registers an endpoint. A secret is generated when none is
+// given; the returned subscription is the only place it is shown.
+func (m *Manager) Subscribe(sub Subscription) (*Subscription, error) {
+	endpoint, err := url.Parse(sub.URL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("webhook url must be an absolute http or https URL: %q", sub.URL)
+	}
+	if sub.Secret == "" {
+		if sub.Secret, err = generateSecret(); err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+	}
+	sub.ID = uuid.New().String()
+	sub.CreatedAt = time.Now().UTC()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscriptions[sub.ID] = &sub
+	if err := m.saveLocked(); err != nil {
+		delete(m.subscriptions, sub.ID)
+		return nil, err
+	}
+
+	created := sub
+	return &created, nil
+}
+
+// Unsubscribe removes a subscription and its queued deliveries. Its dead
+// letters stay for inspection.
+func (m *Manager) Unsubscribe(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.subscriptions[id]; !ok {
+		return ErrSubscriptionNotFound
+	}
+	delete(m.subscriptions, id)
+
+	remaining := m.queue[:0]
+	for _, delivery := range m.queue {
+		if delivery.SubscriptionID != id {
+			remaining = append(remaining, delivery)
+		}
+	}
+	m.queue = remaining
+	return m.saveLocked()
+}
+
+// Subscriptions lists the registered endpoints without their secrets
+func (m *Manager) Subscriptions() []Subscription {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subs := make([]Subscription, 0, len(m.subscriptions))
+	for _, sub := range m.subscriptions {
+		redacted := *sub
+		redacted.Secret = ""
+		subs = append(subs, redacted)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].CreatedAt.Before(subs[j].CreatedAt) })
+	return subs
+}
+
+// Pending returns the number of queued deliveries
+func (m *Manager) Pending() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.queue)
+}
+
+// DeadLetters returns the deliveries that ran out of attempts, oldest
+// first, optionally for one subscription
+func (m *Manager) DeadLetters(subscriptionID string) []Delivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	letters := make([]Delivery, 0, len(m.deadLetters))
+	for _, delivery := range m.deadLetters {
+		if subscriptionID == "" || delivery.SubscriptionID == subscriptionID {
+			letters = append(letters, *delivery)
+		}
+	}
+	return letters
+}
+
+// Redeliver moves a dead letter back to the queue with fresh attempts
+func (m *Manager) Redeliver(deliveryID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, delivery := range m.deadLetters {
+		if delivery.ID != deliveryID {
+			continue
+		}
+		if _, ok := m.subscriptions[delivery.SubscriptionID]; !ok {
+			return ErrSubscriptionNotFound
+		}
+		m.deadLetters = append(m.deadLetters[:i], m.deadLetters[i+1:]...)
+		delivery.Attempts = 0
+		delivery.FailedAt = nil
+		delivery.NextAttemptAt = time.Now().UTC()
+		m.queue = append(m.queue, delivery)
+		if err := m.saveLocked(); err != nil {
+			return err
+		}
+		m.signal()
+		return nil
+	}
+	return ErrDeliveryNotFound
+}
+
+// Publish queues an event for every matching subscription
+func (m *Manager) Publish(event *websocket.MemoryEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	queued := 0
+	for _, sub := range m.subscriptions {
+		if !sub.Matches(event) {
+			continue
+		}
+		if len(m.queue) >= maxQueuedDeliveries {
+			m.logger.Printf("Webhook queue is full; dropping %s.%s event for %s", event.Type, event.Action, sub.ID)
+			continue
+		}
+		payload := *event
+		m.queue = append(m.queue, &Delivery{
+			ID:             uuid.New().String(),
+			SubscriptionID: sub.ID,
+			Event:          metadataOnly(event),
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			payload:        &payload,
+		})
+		queued++
+	}
+	if queued == 0 {
+		return
+	}
+	m.dirty = true
+	m.signal()
+}
+
+// Run delivers queued events until ctx is done. Queue changes are written
+// to the state file here rather than by Publish, so memory writes do no file
+// I/O.
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		m.dispatchDue(ctx)
+		m.flushLogged()
+		select {
+		case <-ctx.Done():
+			m.wg.Wait()
+			m.flushLogged()
+			return
+		case <-ticker.C:
+		case <-m.wake:
+		}
+	}
+}
+
+// dispatchDue starts deliveries whose next attempt is due, up to the
+// worker limit
+func (m *Manager) dispatchDue(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, delivery := range m.queue {
+		if len(m.inFlight) >= m.config.Workers {
+			return
+		}
+		if m.inFlight[delivery.ID] || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		sub, ok := m.subscriptions[delivery.SubscriptionID]
+		if !ok {
+			continue
+		}
+
+		m.inFlight[delivery.ID] = true
+		target, snapshot := *sub, *delivery
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			status, err := m.send(ctx, &target, &snapshot)
+			if ctx.Err() != nil {
+				// Shutting down; the attempt is retried after a restart
+				m.mu.Lock()
+				delete(m.inFlight, snapshot.ID)
+				m.mu.Unlock()
+				return
+			}
+			m.complete(snapshot.ID, status, err)
+		}()
+	}
+}
+
+// metadataOnly copies the parts of an event that may be written to disk;
+// content, summary, tags and free-form data are left out
+func metadataOnly(event *websocket.MemoryEvent) websocket.MemoryEvent {
+	return websocket.MemoryEvent{
+		Type:       event.Type,
+		Action:     event.Action,
+		ChunkID:    event.ChunkID,
+		ChunkType:  event.ChunkType,
+		Repository: event.Repository,
+		SessionID:  event.SessionID,
+		Timestamp:  event.Timestamp,
+	}
+}
+
+// payloadFor returns the event to send, rebuilding it when the delivery was
+// restored from disk
+func (m *Manager) payloadFor(ctx context.Context, delivery *Delivery) websocket.MemoryEvent {
+	if delivery.payload != nil {
+		return *delivery.payload
+	}
+	event := delivery.Event
+	if m.config.Resolve != nil {
+		m.config.Resolve(ctx, &event)
+	}
+	return event
+}
+
+// send posts one delivery
+func (m *Manager) send(ctx context.Context, sub *Subscription, delivery *Delivery) (int, error) {
+	body, err := json.Marshal(m.payloadFor(ctx, delivery))
+	if err != nil {
+		return 0, &retry.PermanentError{Err: fmt.Errorf("failed to encode event: %w", err)}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, &retry.PermanentError{Err: err}
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderEvent, delivery.Event.Type+"."+delivery.Event.Action)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, body))
+
+	resp, err := m.config.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, nil
+	}
+	err = fmt.Errorf("endpoint returned %s", resp.Status)
+	if resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, &retry.PermanentError{Err: err}
+}
+
+// complete records the outcome of an attempt
+func (m *Manager) complete(id string, status int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.inFlight, id)
+
+	index := -1
+	for i, delivery := range m.queue {
+		if delivery.ID == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		// Unsubscribed while the attempt was running
+		return
+	}
+
+	delivery := m.queue[index]
+	delivery.Attempts++
+	delivery.LastStatus = status
+	switch {
+	case err == nil:
+		m.queue = append(m.queue[:index], m.queue[index+1:]...)
+	case m.retrier.ShouldRetry(delivery.Attempts, err):
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = time.Now().UTC().Add(m.retrier.Backoff(delivery.Attempts))
+	default:
+		delivery.LastError = err.Error()
+		failedAt := time.Now().UTC()
+		delivery.FailedAt = &failedAt
+		m.queue = append(m.queue[:index], m.queue[index+1:]...)
+		m.deadLetters = append(m.deadLetters, delivery)
+		if len(m.deadLetters) > maxDeadLetters {
+			m.deadLetters = m.deadLetters[len(m.deadLetters)-maxDeadLetters:]
+		}
+		m.logger.Printf("Webhook delivery %s to %s failed after %d attempts: %v", delivery.ID, delivery.SubscriptionID, delivery.Attempts, err)
+	}
+	m.dirty = true
+}
+
+// Flush writes queue changes that have not reached the state file yet
+func (m *Manager) Flush() error {
+	m.mu.Lock()
+	if !m.dirty {
+		m.mu.Unlock()
+		return nil
+	}
+	data, version, err := m.encodeLocked()
+	m.mu.Unlock()
+	if err == nil {
+		err = m.writeState(data, version)
+	}
+	if err != nil {
+		m.mu.Lock()
+		m.dirty = true
+		m.mu.Unlock()
+	}
+	return err
+}
+
+// flushLogged flushes the state and logs a failure; the write is retried
+// on the next pass
+func (m *Manager) flushLogged() {
+	if err := m.Flush(); err != nil {
+		m.logger.Printf("Warning: %v", err)
+	}
+}
+
+// saveLocked writes the state file right away, for changes whose caller
+// needs to know they were saved
+func (m *Manager) saveLocked() error {
+	data, version, err := m.encodeLocked()
+	if err == nil {
+		err = m.writeState(data, version)
+	}
+	if err != nil {
+		m.dirty = true
+	}
+	return err
+}
+
+// encodeLocked snapshots the state and numbers the snapshot, so an older
+// one never overwrites a newer one on disk
+func (m *Manager) encodeLocked() ([]byte, uint64, error) {
+	saved := state{Queue: m.queue, DeadLetters: m.deadLetters}
+	for _, sub := range m.subscriptions {
+		saved.Subscriptions = append(saved.Subscriptions, sub)
+	}
+	sort.Slice(saved.Subscriptions, func(i, j int) bool {
+		return saved.Subscriptions[i].CreatedAt.Before(saved.Subscriptions[j].CreatedAt)
+	})
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encode webhook state: %w", err)
+	}
+	m.dirty = false
+	m.version++
+	return data, m.version, nil
+}
+
+// writeState atomically writes an encoded snapshot; secrets make the file
+// private
+func (m *Manager) writeState(data []byte, version uint64) error {
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
+	if version <= m.saved {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.config.StateDir, 0o750); err != nil {
+		return fmt.Errorf("failed to create webhook state directory: %w", err)
+	}
+	path := m.statePath()
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save webhook state: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to save webhook state: %w", err)
+	}
+	m.saved = version
+	return nil
+}
+
+func (m *Manager) statePath() string {
+	return filepath.Join(m.config.StateDir, stateFileName)
+}
+
+// signal wakes Run without blocking
+func (m *Manager) signal() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
@@ -11,11 +11,27 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+// Event types for memory changes
+const (
+	EventTypeMemory       = "memory"
+	EventTypeRelationship = "relationship"
+	EventTypeThread       = "thread"
+	EventTypeTask         = "task"
+)
+
+// Event actions
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+)
+
 // MemoryEvent represents a memory change event
 type MemoryEvent struct {
 	Type       string      `json:"type"`
 	Action     string      `json:"action"` // "created", "updated", "deleted"
 	ChunkID    string      `json:"chunk_id,omitempty"`
+	ChunkType  string      `json:"chunk_type,omitempty"`
 	Repository string      `json:"repository,omitempty"`
 	SessionID  string      `json:"session_id,omitempty"`
 	Content    string      `json:"content,omitempty"`
@@ -25,14 +41,67 @@ type MemoryEvent struct {
 	Data       interface{} `json:"data,omitempty"`
 }
 
+// Subscription selects the events a client receives. Empty fields match
+// everything; chunk types and tags only filter memory events.
+type Subscription struct {
+	Repositories []string `json:"repositories,omitempty"`
+	SessionID    string   `json:"session_id,omitempty"`
+	ChunkTypes   []string `json:"chunk_types,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	EventTypes   []string `json:"event_types,omitempty"`
+}
+
+// Matches reports whether event passes the subscription filters
+func (s *Subscription) Matches(event *MemoryEvent) bool {
+	if len(s.Repositories) > 0 && event.Repository != "" && !contains(s.Repositories, event.Repository) {
+		return false
+	}
+	if s.SessionID != "" && event.SessionID != "" && s.SessionID != event.SessionID {
+		return false
+	}
+	if len(s.EventTypes) > 0 && !contains(s.EventTypes, event.Type) {
+		return false
+	}
+	if event.Type != EventTypeMemory {
+		return true
+	}
+	if len(s.ChunkTypes) > 0 && !contains(s.ChunkTypes, event.ChunkType) {
+		return false
+	}
+	if len(s.Tags) > 0 && !containsAny(s.Tags, event.Tags) {
+		return false
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(values, candidates []string) bool {
+	for _, candidate := range candidates {
+		if contains(values, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
 // Client represents a WebSocket client
 type Client struct {
 	ID         string
 	Connection *websocket.Conn
 	Send       chan MemoryEvent
 	Hub        *Hub
-	Repository string // Filter events by repository
-	SessionID  string // Filter events by session
+
+	mu           sync.RWMutex
+	subscription Subscription
+	authorize    func(repositories []string) bool
 }
 
 // Hub manages WebSocket connections and broadcasts
@@ -146,17 +215,8 @@ func (h *Hub) shouldSendToClient(client *Client, event *MemoryEvent) bool {
 		return true
 	}
 
-	// Filter by repository if client has preference
-	if client.Repository != "" && event.Repository != "" && client.Repository != event.Repository {
-		return false
-	}
-
-	// Filter by session if client has preference
-	if client.SessionID != "" && event.SessionID != "" && client.SessionID != event.SessionID {
-		return false
-	}
-
-	return true
+	subscription := client.Subscription()
+	return subscription.Matches(event)
 }
 
 // RegisterClient registers a new client with the hub
@@ -185,18 +245,32 @@ func (h *Hub) GetClientCount() int {
 	return len(h.clients)
 }
 
-// NewClient creates a new WebSocket client
-func NewClient(id string, conn *websocket.Conn, hub *Hub, repository, sessionID string) *Client {
+// NewClient creates a new WebSocket client with an initial subscription
+func NewClient(id string, conn *websocket.Conn, hub *Hub, subscription Subscription) *Client {
 	return &Client{
-		ID:         id,
-		Connection: conn,
-		Send:       make(chan MemoryEvent, 256),
-		Hub:        hub,
-		Repository: repository,
-		SessionID:  sessionID,
+		ID:           id,
+		Connection:   conn,
+		Send:         make(chan MemoryEvent, 256),
+		Hub:          hub,
+		subscription: subscription,
 	}
 }
 
+// SetAuthorizer checks the repositories of later subscription changes; an
+// empty list means every repository. Without one, every change is accepted.
+func (c *Client) SetAuthorizer(authorize func(repositories []string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.authorize = authorize
+}
+
+// Subscription returns a copy of the client's current subscription
+func (c *Client) Subscription() Subscription {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.subscription
+}
+
 // WritePump pumps messages from the hub to the websocket connection
 func (c *Client) WritePump(ctx context.Context) {
 	ticker := time.NewTicker(54 * time.Second)
@@ -254,8 +328,8 @@ func (c *Client) ReadPump(ctx context.Context) {
 		}
 	}()
 
-	// Set read limits and timeouts
-	c.Connection.SetReadLimit(512)
+	// Set read limits and timeouts; subscriptions may list several filters
+	c.Connection.SetReadLimit(4096)
 	if err := c.Connection.SetReadDeadline(time.Now().Add(60 * time.Second)); err != nil {
 		log.Printf("Error setting read deadline: %v", err)
 	}
@@ -295,40 +369,114 @@ func (c *Client) handleClientMessage(msg map[string]interface{}) {
 	}
 
 	switch msgType {
-	case "subscribe":
-		// Handle subscription requests
-		if repo, ok := msg["repository"].(string); ok {
-			c.Repository = repo
-			log.Printf("Client %s subscribed to repository: %s", c.ID, repo)
+	case "subscribe", "unsubscribe":
+		c.mu.Lock()
+		next := c.subscription
+		if msgType == "subscribe" {
+			applySubscribe(&next, msg)
+		} else {
+			applyUnsubscribe(&next, msg)
 		}
-		if session, ok := msg["session_id"].(string); ok {
-			c.SessionID = session
-			log.Printf("Client %s subscribed to session: %s", c.ID, session)
+		allowed := c.authorize == nil || c.authorize(next.Repositories)
+		if allowed {
+			c.subscription = next
 		}
-
-	case "unsubscribe":
-		// Handle unsubscription requests
-		if _, ok := msg["repository"]; ok {
-			c.Repository = ""
-			log.Printf("Client %s unsubscribed from repository", c.ID)
-		}
-		if _, ok := msg["session_id"]; ok {
-			c.SessionID = ""
-			log.Printf("Client %s unsubscribed from session", c.ID)
+		current := c.subscription
+		c.mu.Unlock()
+
+		if !allowed {
+			log.Printf("Client %s was denied subscription to %v", c.ID, next.Repositories)
+			c.reply(MemoryEvent{Type: "error", Action: msgType, Timestamp: time.Now(), Data: map[string]interface{}{
+				"message": "access denied to the requested repositories",
+			}})
+			return
 		}
+		log.Printf("Client %s updated subscription: %+v", c.ID, current)
+		c.reply(MemoryEvent{Type: "subscription", Action: msgType, Timestamp: time.Now(), Data: current})
 
 	case "ping":
 		// Respond to ping with pong
-		pong := MemoryEvent{
+		c.reply(MemoryEvent{
 			Type:      "pong",
 			Timestamp: time.Now(),
+		})
+	}
+}
+
+// reply queues an event for this client only
+func (c *Client) reply(event MemoryEvent) {
+	select {
+	case c.Send <- event:
+	default:
+		// Channel full, client will be removed
+	}
+}
+
+// applySubscribe sets the filters named in a subscribe message. The single
+// "repository" field adds to the repository list.
+func applySubscribe(subscription *Subscription, msg map[string]interface{}) {
+	if repo, ok := msg["repository"].(string); ok && repo != "" && !contains(subscription.Repositories, repo) {
+		subscription.Repositories = append(append([]string(nil), subscription.Repositories...), repo)
+	}
+	if repos, ok := stringList(msg["repositories"]); ok {
+		subscription.Repositories = repos
+	}
+	if session, ok := msg["session_id"].(string); ok {
+		subscription.SessionID = session
+	}
+	if chunkTypes, ok := stringList(msg["chunk_types"]); ok {
+		subscription.ChunkTypes = chunkTypes
+	}
+	if tags, ok := stringList(msg["tags"]); ok {
+		subscription.Tags = tags
+	}
+	if eventTypes, ok := stringList(msg["event_types"]); ok {
+		subscription.EventTypes = eventTypes
+	}
+}
+
+// applyUnsubscribe clears the filters named in an unsubscribe message.
+// "repositories" removes the listed repositories; "repository" clears all.
+func applyUnsubscribe(subscription *Subscription, msg map[string]interface{}) {
+	if _, ok := msg["repository"]; ok {
+		subscription.Repositories = nil
+	}
+	if repos, ok := stringList(msg["repositories"]); ok {
+		remaining := make([]string, 0, len(subscription.Repositories))
+		for _, repo := range subscription.Repositories {
+			if !contains(repos, repo) {
+				remaining = append(remaining, repo)
+			}
 		}
-		select {
-		case c.Send <- pong:
-		default:
-			// Channel full, client will be removed
+		subscription.Repositories = remaining
+	}
+	if _, ok := msg["session_id"]; ok {
+		subscription.SessionID = ""
+	}
+	if _, ok := msg["chunk_types"]; ok {
+		subscription.ChunkTypes = nil
+	}
+	if _, ok := msg["tags"]; ok {
+		subscription.Tags = nil
+	}
+	if _, ok := msg["event_types"]; ok {
+		subscription.EventTypes = nil
+	}
+}
+
+// stringList reads a JSON array of strings
+func stringList(value interface{}) ([]string, bool) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok && s != "" {
+			result = append(result, s)
 		}
 	}
+	return result, true
 }
 
 // NewMemoryEvent creates a new memory event with the specified parameters
@@ -0,0 +1,70 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionMatches(t *testing.T) {
+	memory := &MemoryEvent{Type: EventTypeMemory, Repository: "api", ChunkType: "problem", Tags: []string{"auth", "bug"}}
+	thread := &MemoryEvent{Type: EventTypeThread, Repository: "api"}
+
+	tests := []struct {
+		name         string
+		subscription Subscription
+		event        *MemoryEvent
+		want         bool
+	}{
+		{"empty matches everything", Subscription{}, memory, true},
+		{"any listed repository", Subscription{Repositories: []string{"web", "api"}}, memory, true},
+		{"other repository", Subscription{Repositories: []string{"web"}}, memory, false},
+		{"chunk type", Subscription{ChunkTypes: []string{"solution"}}, memory, false},
+		{"any tag", Subscription{Tags: []string{"bug"}}, memory, true},
+		{"no shared tag", Subscription{Tags: []string{"perf"}}, memory, false},
+		{"event type", Subscription{EventTypes: []string{EventTypeTask}}, thread, false},
+		{"chunk filters ignore threads", Subscription{ChunkTypes: []string{"solution"}, Tags: []string{"perf"}}, thread, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.subscription.Matches(tt.event))
+		})
+	}
+}
+
+func TestApplySubscribeAndUnsubscribe(t *testing.T) {
+	subscription := Subscription{Repositories: []string{"api"}}
+
+	applySubscribe(&subscription, map[string]interface{}{
+		"repository":  "web",
+		"chunk_types": []interface{}{"problem"},
+		"event_types": []interface{}{EventTypeMemory, EventTypeThread},
+	})
+	assert.Equal(t, []string{"api", "web"}, subscription.Repositories)
+	assert.Equal(t, []string{"problem"}, subscription.ChunkTypes)
+	assert.Equal(t, []string{EventTypeMemory, EventTypeThread}, subscription.EventTypes)
+
+	applyUnsubscribe(&subscription, map[string]interface{}{
+		"repositories": []interface{}{"api"},
+		"chunk_types":  true,
+	})
+	assert.Equal(t, []string{"web"}, subscription.Repositories)
+	assert.Nil(t, subscription.ChunkTypes)
+	assert.Len(t, subscription.EventTypes, 2)
+}
+
+func TestClientSubscribeChecksAuthorizer(t *testing.T) {
+	client := NewClient("c1", nil, NewHub(), Subscription{Repositories: []string{"api"}})
+	client.SetAuthorizer(func(repositories []string) bool {
+		return !contains(repositories, "secret")
+	})
+
+	client.handleClientMessage(map[string]interface{}{"type": "subscribe", "repository": "secret"})
+	assert.Equal(t, []string{"api"}, client.Subscription().Repositories)
+	assert.Equal(t, "error", (<-client.Send).Type)
+
+	client.handleClientMessage(map[string]interface{}{"type": "subscribe", "repository": "web"})
+	assert.Equal(t, []string{"api", "web"}, client.Subscription().Repositories)
+	assert.Equal(t, "subscription", (<-client.Send).Type)
+}
@@ -82,6 +82,8 @@ const (
 	EMKeyStackTraces      = "stack_traces"
 	EMKeyCommandResults   = "command_results"
 	EMKeyFileOperations   = "file_operations"
+	EMKeyCodeLanguages    = "code_languages"
+	EMKeyCodeSymbols      = "code_symbols"
 
 	// Relationship Keys
 	EMKeyParentChunk   = "parent_chunk_id"
@@ -103,8 +105,34 @@ const (
 	EMKeyEffectivenessScore = "effectiveness_score"
 	EMKeyIsObsolete         = "is_obsolete"
 	EMKeyArchivedAt         = "archived_at"
+
+	// Redaction Keys
+	EMKeyRedacted   = "redacted"
+	EMKeyRedactions = "redactions"
+
+	// Deduplication Keys
+	EMKeyMergeCount = "merge_count"
 )
 
+// CodeSymbols returns the function and type names recorded for code in the
+// chunk. Stored metadata decodes lists as []interface{}, so both forms are
+// accepted.
+func (cm *ChunkMetadata) CodeSymbols() []string {
+	switch symbols := cm.ExtendedMetadata[EMKeyCodeSymbols].(type) {
+	case []string:
+		return symbols
+	case []interface{}:
+		names := make([]string, 0, len(symbols))
+		for _, symbol := range symbols {
+			if name, ok := symbol.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
 // Client types
 const (
 	ClientTypeCLI     = "claude-cli"
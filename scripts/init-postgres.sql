@@ -3,6 +3,8 @@
 -- Create extensions
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 CREATE EXTENSION IF NOT EXISTS "pgcrypto";
+-- pgvector, required by the postgres storage provider (creating it needs superuser)
+CREATE EXTENSION IF NOT EXISTS "vector";
 
 -- Create user if not exists (for development/testing)
 DO $$
@@ -24,4 +26,7 @@ CREATE TABLE IF NOT EXISTS schema_migrations (
 );
 
 -- Insert initial migration version
-INSERT INTO schema_migrations (version, dirty) VALUES (1, FALSE) ON CONFLICT DO NOTHING;
\ No newline at end of file
+INSERT INTO schema_migrations (version, dirty) VALUES (1, FALSE) ON CONFLICT DO NOTHING;
+
+-- The server applies later versions itself at startup
+GRANT SELECT, INSERT, UPDATE ON schema_migrations TO mcpuser;
\ No newline at end of file